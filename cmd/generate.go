@@ -11,13 +11,16 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var generateDialect string
+
 // generateCmd represents the 'generate' command.
 var generateCmd = &cobra.Command{
 	Use:   "generate [input-directory] [output-directory]",
 	Short: "Generate .go and .sql files from YAML definitions",
 	Long: `This command scans the specified input directory for YAML (.yaml) files,
 then generates the corresponding .go and .sql destination file paths. If no input directory is provided, the current directory is used.
-If no output directory is provided, it defaults to the same directory as input.`,
+If no output directory is provided, it defaults to the same directory as input.
+The --dialect flag sets the SQL dialect (mysql, postgres or sqlite) of entities that don't define 'dialect' themselves.`,
 	Args: cobra.RangeArgs(0, 2),
 	Run:  generate,
 }
@@ -56,8 +59,12 @@ func generate(cmd *cobra.Command, args []string) {
 	if err != nil {
 		log.Fatalf("Failed creating generator %v", err)
 	}
+	if err := gen.SetDialect(generateDialect); err != nil {
+		log.Fatalf("Invalid dialect: %v", err)
+	}
 
 	hadDalFile := false
+	var dialects []string
 	for _, entry := range entries {
 		// Only consider files (ignore directories)
 		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".yaml") {
@@ -110,6 +117,12 @@ func generate(cmd *cobra.Command, args []string) {
 
 			fmt.Printf("Generated SQL: %s\n", sqlPath)
 			hadDalFile = true
+
+			dialect, err := gen.EntityDialect(yamlContent)
+			if err != nil {
+				log.Fatalf("failed reading dialect of file %s, %v", inputFile, err)
+			}
+			dialects = append(dialects, dialect)
 		}
 	}
 
@@ -118,9 +131,22 @@ func generate(cmd *cobra.Command, args []string) {
 		if err != nil {
 			log.Fatalf("failed writing shared provider files to path %s, %v", outputDir, err)
 		}
+
+		// Only the drivers of the dialects in use are imported.
+		driversFile, err := gen.GenerateDrivers(dialects)
+		if err != nil {
+			log.Fatalf("failed GenerateDrivers, %v", err)
+		}
+
+		driversPath := filepath.Join(outputDir, "drivers.gen.go")
+		if err := os.WriteFile(driversPath, []byte(driversFile), 0644); err != nil {
+			log.Fatalf("failed writing drivers to file %s, %v", driversPath, err)
+		}
+		fmt.Printf("Generated drivers: %s\n", driversPath)
 	}
 }
 
 func init() {
+	generateCmd.Flags().StringVar(&generateDialect, "dialect", "mysql", "default SQL dialect for entities without a dialect (mysql, postgres or sqlite)")
 	rootCmd.AddCommand(generateCmd)
 }
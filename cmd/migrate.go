@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"dalforge/generator"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	migrateFromRevision string
+	migrateDialect      string
+)
+
+// migrateCmd represents the 'migrate' command.
+var migrateCmd = &cobra.Command{
+	Use:   "migrate [input-directory] [output-directory]",
+	Short: "Generate numbered up/down SQL migrations from changes in YAML definitions",
+	Long: `This command compares every entity YAML file in the input directory against its previous
+revision and writes numbered, reversible migration files into [output-directory]/migrations.
+The previous revision is read from the lock file stored next to the migrations
+(migrations/<entity>.lock.yaml), or from a git revision when --from is provided.
+The entity's version field drives the numbering, so it must be bumped whenever the schema changes.
+A migrations.gen.go file embedding the migrations is written next to them for use with NewMigrator.
+If no output directory is provided, it defaults to the same directory as input.`,
+	Args: cobra.RangeArgs(0, 2),
+	Run:  migrate,
+}
+
+func migrate(cmd *cobra.Command, args []string) {
+	var inputDir, outputDir string
+
+	// Determine the input directory
+	if len(args) > 0 {
+		inputDir = args[0]
+	} else {
+		dir, err := os.Getwd()
+		if err != nil {
+			log.Fatalf("Failed to get current directory: %v", err)
+		}
+		inputDir = dir
+	}
+
+	// Determine the output directory
+	if len(args) > 1 {
+		outputDir = args[1]
+	} else {
+		outputDir = inputDir
+	}
+	migrationsDir := filepath.Join(outputDir, "migrations")
+
+	entries, err := os.ReadDir(inputDir)
+	if err != nil {
+		log.Fatalf("Failed to read directory '%s': %v", inputDir, err)
+	}
+
+	gen, err := generator.NewGenerator()
+	if err != nil {
+		log.Fatalf("Failed creating generator %v", err)
+	}
+	if err := gen.SetDialect(migrateDialect); err != nil {
+		log.Fatalf("Invalid dialect: %v", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".yaml") {
+			continue
+		}
+
+		baseName := strings.TrimSuffix(entry.Name(), ".yaml")
+
+		// skip serverprovider and db_config files as those are not entity files.
+		if strings.Contains(baseName, "serverprovider") || strings.Contains(baseName, "db_config") {
+			continue
+		}
+
+		inputFile := filepath.Join(inputDir, entry.Name())
+		lockPath := filepath.Join(migrationsDir, baseName+".lock.yaml")
+
+		current, err := os.ReadFile(inputFile)
+		if err != nil {
+			log.Fatalf("Failed reading file: %s", err)
+		}
+
+		previous, err := previousRevision(inputDir, entry.Name(), lockPath)
+		if err != nil {
+			log.Fatalf("failed reading previous revision of %s, %v", inputFile, err)
+		}
+
+		migration, err := gen.GenerateMigration(previous, string(current))
+		if err != nil {
+			log.Fatalf("failed GenerateMigration on file %s, %v", inputFile, err)
+		}
+
+		if migration == nil {
+			fmt.Printf("No schema changes: %s\n", inputFile)
+			continue
+		}
+
+		if err := os.MkdirAll(migrationsDir, 0755); err != nil {
+			log.Fatalf("failed creating directory %s, %v", migrationsDir, err)
+		}
+
+		upPath := filepath.Join(migrationsDir, migration.FileName("up"))
+		downPath := filepath.Join(migrationsDir, migration.FileName("down"))
+
+		if _, statErr := os.Stat(upPath); statErr == nil {
+			log.Fatalf("migration %s already exists; bump the version of %s", upPath, inputFile)
+		}
+
+		if err := os.WriteFile(upPath, []byte(migration.Up), 0644); err != nil {
+			log.Fatalf("failed writing migration to file %s, %v", upPath, err)
+		}
+		if err := os.WriteFile(downPath, []byte(migration.Down), 0644); err != nil {
+			log.Fatalf("failed writing migration to file %s, %v", downPath, err)
+		}
+
+		// Lock file records the revision the latest migration was generated from, with its generated indexes.
+		lock, err := gen.GenerateLock(string(current))
+		if err != nil {
+			log.Fatalf("failed GenerateLock on file %s, %v", inputFile, err)
+		}
+		if err := os.WriteFile(lockPath, []byte(lock), 0644); err != nil {
+			log.Fatalf("failed writing lock file %s, %v", lockPath, err)
+		}
+
+		fmt.Printf("Generated migration: %s\n", upPath)
+		fmt.Printf("Generated migration: %s\n", downPath)
+	}
+
+	// Keep the embed file in place whenever there are migrations, including previously generated ones.
+	if existing, _ := filepath.Glob(filepath.Join(migrationsDir, "*.sql")); len(existing) > 0 {
+		embedFile, err := gen.GenerateMigrationsEmbed()
+		if err != nil {
+			log.Fatalf("failed GenerateMigrationsEmbed, %v", err)
+		}
+
+		embedPath := filepath.Join(outputDir, "migrations.gen.go")
+		if err := os.WriteFile(embedPath, []byte(embedFile), 0644); err != nil {
+			log.Fatalf("failed writing migrations embed to file %s, %v", embedPath, err)
+		}
+		fmt.Printf("Generated migrations embed: %s\n", embedPath)
+	}
+}
+
+// previousRevision loads the previous entity YAML either from git or from the lock file.
+// It returns an empty string when there is no previous revision.
+func previousRevision(inputDir, fileName, lockPath string) (string, error) {
+	if migrateFromRevision != "" {
+		out, err := exec.Command("git", "-C", inputDir, "show", migrateFromRevision+":./"+fileName).Output()
+		if err != nil {
+			// File did not exist at that revision; treat as a new entity.
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) && (strings.Contains(string(exitErr.Stderr), "exists on disk, but not in") ||
+				strings.Contains(string(exitErr.Stderr), "does not exist in")) {
+				return "", nil
+			}
+			return "", err
+		}
+		return string(out), nil
+	}
+
+	data, err := os.ReadFile(lockPath)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func init() {
+	migrateCmd.Flags().StringVar(&migrateFromRevision, "from", "", "git revision to diff against instead of the lock file")
+	migrateCmd.Flags().StringVar(&migrateDialect, "dialect", "mysql", "default SQL dialect for entities without a dialect (mysql, postgres or sqlite)")
+	rootCmd.AddCommand(migrateCmd)
+}
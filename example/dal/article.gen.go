@@ -0,0 +1,2749 @@
+/*
+NOTE! This code is autogenerated.
+Don't manually change this code.
+Version [c26a0e3ea1c5a3dde3d010ba02f2941f39d4d59b89e0ebb6f6c40036a0ca564c]
+*/
+package dal
+
+import (
+    "context"
+    "encoding/gob"
+    "database/sql"
+    _ "embed"
+    "errors"
+    "iter"
+    "strings"
+    "sync/atomic"
+
+    "fmt"
+    "time"
+    
+	"encoding/json"
+	
+
+    log "github.com/sirupsen/logrus"
+    "github.com/patrickmn/go-cache"
+    "github.com/sony/gobreaker"
+)
+
+func init() {
+	gob.Register(Article{})
+}
+
+// Struct representing Article. Do not use these structs directly across services as this struct carries db specific information.
+// recommendation: create higher level service layer structs that might look same or very similar instead.
+type Article struct {
+    ID        int64 `json:"id"` // Auto-incremented number
+    Version   int32 `json:"version"` // Only change this value directly in very specific cases.  
+    
+    Attributes *json.RawMessage `json:"attributes"`
+    AuthorId int64 `json:"author_id"`
+    Body string `json:"body"`
+    PublishedAt *time.Time `json:"published_at"`
+    Rating float64 `json:"rating"`
+    Slug string `json:"slug"`
+    Title string `json:"title"`
+    Uid string `json:"uid"`
+
+    Created   time.Time `json:"created"`
+    Updated   time.Time `json:"updated"`
+
+    DeletedAt *time.Time `json:"deleted_at"`
+
+}
+
+// ArticleRepository defines the interface for the Article.
+// Use this interface in your services to easily mock the database.
+type ArticleRepository interface {
+    CreateTable(ctx context.Context) error
+    GetByID(ctx context.Context, id int64) (*Article, error)
+
+    InvalidateCache(entity *Article)
+    FlushListCache()
+    FlushAllCache()
+    Create(ctx context.Context, entity *Article) (*Article, error)
+    
+    // CreateBulk performs a batch insert of multiple Article entities.
+    //
+    // CACHING & PERFORMANCE NOTE:
+    // This operation safely batches inserts into chunks of 500 to avoid exceeding the 
+    // database driver's maximum placeholder limits.
+    // 
+    // Because new items are being added to the database, this function will automatically 
+    // call FlushListCache() to ensure that any cached paginated lists or count queries 
+    // are invalidated and recalculated on their next request.
+    CreateBulk(ctx context.Context, entities []*Article) ([]*Article, error)
+    
+    Update(ctx context.Context, entity *Article) error
+    // UpsertBySlug inserts the entity, or overwrites title, body of the row with the same slug.
+    UpsertBySlug(ctx context.Context, entity *Article) (*Article, error)
+    // UpsertBulkBySlug upserts the entities in chunks and reports whether each was inserted, updated or unchanged.
+    UpsertBulkBySlug(ctx context.Context, entities []*Article) ([]UpsertOutcome, error)
+    Delete(ctx context.Context, entity *Article) error
+    HardDelete(ctx context.Context, entity *Article) error
+    GetBySlug(ctx context.Context, slug string) (*Article, error)
+    GetByUid(ctx context.Context, uid string) (*Article, error)
+    // GetByUids executes a bulk get operation.
+    //
+    // PERFORMANCE & CACHING NOTE:
+    // This function is highly optimized for caching. It first checks the local in-memory 
+    // cache for every individual key. Only the missing keys (cache misses) are fetched 
+    // from the database in safe chunks of 500. The newly fetched items are then cached 
+    // automatically.
+    //
+    // To prevent massive memory allocations and driver crashes, this operation is 
+    // hard-limited to 5000 items per call.
+    GetByUids(ctx context.Context, uids []string) ([]*Article, error)
+    // UpdateRatingByUids executes a bulk partial update using an IN clause.
+    //
+    // ⚠️ CAUTION - CACHE FLUSH NOTE ⚠️
+    // Because bulk updates modify multiple records simultaneously, it is difficult to 
+    // track and selectively invalidate individual cache entries safely. Therefore, if 
+    // this operation successfully updates one or more rows, it will trigger a global 
+    // FlushAllCache() to ensure local memory safety and broadcast cache invalidation 
+    // to peer nodes via Pub/Sub. Use this operation carefully in high-read environments.
+    //
+    // This operation is hard-limited to 5000 items and executes in database batches of 500.
+    UpdateRatingByUids(ctx context.Context, rating float64, uids []string) error
+    // ListByAuthors executes a bulk list operation using an IN clause on the author_id column.
+    //
+    // PERFORMANCE & CACHING NOTE:
+    // To prevent massive memory allocations and database driver crashes, this operation 
+    // is hard-limited to 5000 items and executes database queries in safe batches of 500.
+    // 
+    // Unlike standard list operations, this function does NOT use the listCache because 
+    // the permutations of dynamic input slices are practically infinite. Instead, it queries the 
+    // database directly but aggressively warms the local single-item cache with the 
+    // results. Subsequent fetches for any individual Article returned by this query 
+    // will be instant zero-latency cache hits.
+    ListByAuthors(ctx context.Context, authorIds []int64) ([]*Article, error)
+    // GetSlugsByAuthor fetches a list of slug values.
+    // CACHING NOTE: Uses the shared listCache for invalidation.
+    GetSlugsByAuthor(ctx context.Context, authorId int64) ([]string, error)
+    // ListByAuthor returns a page of entities ordered by created and the cursor of the next page, nil after the last one.
+    ListByAuthor(ctx context.Context, authorId int64, cursor *ArticleListByAuthorCursor, pageSize int) ([]*Article, *ArticleListByAuthorCursor, error)
+    CountListByAuthor(ctx context.Context, authorId int64) (int64, error)
+    // IterateByListByAuthor walks the whole list in batches on the read database, bypassing the caches.
+    IterateByListByAuthor(ctx context.Context, authorId int64, opts IterateOptions) iter.Seq2[*Article, error]
+    // IterateAll walks every entity in ID order in batches on the read database, bypassing the caches.
+    IterateAll(ctx context.Context, opts IterateOptions) iter.Seq2[*Article, error]
+    // DeleteByAuthor executes a custom bulk delete operation.
+    // 
+    // ⚠️ CAUTION - CACHE FLUSH NOTE ⚠️
+    // Because bulk deletions modify multiple records simultaneously, it is difficult to 
+    // track and selectively invalidate individual cache entries safely. Therefore, if 
+    // this operation successfully deletes one or more rows, it will trigger a global 
+    // FlushAllCache() to ensure local memory safety and broadcast cache invalidation 
+    // to peer nodes via Pub/Sub. Use this operation carefully in high-read environments.
+    //
+    // PERFORMANCE NOTE: To prevent database locks, undo-log bloat, and replication lag, 
+    // this operation requires a limit parameter. If the provided limit is <= 0 or > 5000, 
+    // it will be automatically clamped to 5000.
+    DeleteByAuthor(ctx context.Context, authorId int64, limit int) (int64, error)
+    
+    // HardDeleteByAuthor executes a permanent custom bulk delete operation.
+    // 
+    // ⚠️ CAUTION - CACHE FLUSH NOTE ⚠️
+    // Because bulk deletions modify multiple records simultaneously, it is difficult to 
+    // track and selectively invalidate individual cache entries safely. Therefore, if 
+    // this operation successfully deletes one or more rows, it will trigger a global 
+    // FlushAllCache() to ensure local memory safety and broadcast cache invalidation 
+    // to peer nodes via Pub/Sub. Use this operation carefully in high-read environments.
+    //
+    // PERFORMANCE NOTE: To prevent database locks, undo-log bloat, and replication lag, 
+    // this operation requires a limit parameter. If the provided limit is <= 0 or > 5000, 
+    // it will be automatically clamped to 5000.
+    HardDeleteByAuthor(ctx context.Context, authorId int64, limit int) (int64, error)
+
+}
+
+type articleRepository struct {
+    dbProvider          DBProvider
+    cache               *cache.Cache  // Simple TTL cache for single items, for inmemory cache management
+  	listCache           *cache.Cache // Cache for lists. Simple TTL cache, for inmemory cache management
+    countCache          *cache.Cache // Cache for counts. Simple TTL cache, for inmemory cache management
+    cacheProvider       CacheProvider
+    configProvider      ConfigProvider
+	dbBreaker           *gobreaker.CircuitBreaker
+    telemetryProvider TelemetryProvider
+    listEpoch         atomic.Int64
+    hooks             articleHooks
+}
+
+
+// ArticleBeforeCreate is a hook run by Create and upserts before inserting the Article. Returning an error vetoes the insert.
+type ArticleBeforeCreate interface {
+    BeforeCreate(ctx context.Context, entity *Article) error
+}
+
+// ArticleAfterCreate is a hook run by Create and upserts after inserting the Article. Returning an error rolls the insert back.
+type ArticleAfterCreate interface {
+    AfterCreate(ctx context.Context, entity *Article) error
+}
+
+// ArticleBeforeCreateBulk is a hook run by CreateBulk and bulk upserts before inserting the Articles. Returning an error vetoes the inserts.
+type ArticleBeforeCreateBulk interface {
+    BeforeCreateBulk(ctx context.Context, entities []*Article) error
+}
+
+// ArticleAfterCreateBulk is a hook run by CreateBulk and bulk upserts after inserting the Articles. Returning an error rolls the inserts back.
+type ArticleAfterCreateBulk interface {
+    AfterCreateBulk(ctx context.Context, entities []*Article) error
+}
+
+// ArticleBeforeUpdate is a hook run by Update, upserts and patches before storing the Article. Returning an error vetoes the update.
+type ArticleBeforeUpdate interface {
+    BeforeUpdate(ctx context.Context, entity *Article) error
+}
+
+// ArticleAfterUpdate is a hook run by Update and upserts after storing the Article. Returning an error rolls the update back.
+type ArticleAfterUpdate interface {
+    AfterUpdate(ctx context.Context, entity *Article) error
+}
+
+// ArticleBeforeDelete is a hook run by Delete and HardDelete before removing the Article. Returning an error vetoes the delete.
+type ArticleBeforeDelete interface {
+    BeforeDelete(ctx context.Context, entity *Article) error
+}
+
+// ArticleAfterDelete is a hook run by Delete and HardDelete after removing the Article. Returning an error rolls the delete back.
+type ArticleAfterDelete interface {
+    AfterDelete(ctx context.Context, entity *Article) error
+}
+
+// articleHooks holds the hooks of a articleRepository per operation: the methods of Article
+// implementing the entity hook interfaces first, then the hooks passed to NewArticleRepository in order.
+type articleHooks struct {
+    beforeCreate     []hookFunc[*Article]
+    afterCreate      []hookFunc[*Article]
+    beforeCreateBulk []hookFunc[[]*Article]
+    afterCreateBulk  []hookFunc[[]*Article]
+    beforeUpdate     []hookFunc[*Article]
+    afterUpdate      []hookFunc[*Article]
+    beforeDelete     []hookFunc[*Article]
+    afterDelete      []hookFunc[*Article]
+}
+
+// newArticleHooks sorts hooks by the Article hook interfaces they implement. It panics on a hook
+// implementing none of them, which is a programming error.
+func newArticleHooks(hooks []interface{}) articleHooks {
+    var h articleHooks
+    h.beforeCreate = appendEntityHook(h.beforeCreate, BeforeCreateHook.BeforeCreate)
+    h.afterCreate = appendEntityHook(h.afterCreate, AfterCreateHook.AfterCreate)
+    h.beforeCreateBulk = appendEntityBulkHook(h.beforeCreateBulk, BeforeCreateHook.BeforeCreate)
+    h.afterCreateBulk = appendEntityBulkHook(h.afterCreateBulk, AfterCreateHook.AfterCreate)
+    h.beforeUpdate = appendEntityHook(h.beforeUpdate, BeforeUpdateHook.BeforeUpdate)
+    h.afterUpdate = appendEntityHook(h.afterUpdate, AfterUpdateHook.AfterUpdate)
+    h.beforeDelete = appendEntityHook(h.beforeDelete, BeforeDeleteHook.BeforeDelete)
+    h.afterDelete = appendEntityHook(h.afterDelete, AfterDeleteHook.AfterDelete)
+
+    for _, hook := range hooks {
+        registered := false
+        if hook, ok := hook.(ArticleBeforeCreate); ok {
+            h.beforeCreate, registered = append(h.beforeCreate, hook.BeforeCreate), true
+        }
+        if hook, ok := hook.(ArticleAfterCreate); ok {
+            h.afterCreate, registered = append(h.afterCreate, hook.AfterCreate), true
+        }
+        if hook, ok := hook.(ArticleBeforeCreateBulk); ok {
+            h.beforeCreateBulk, registered = append(h.beforeCreateBulk, hook.BeforeCreateBulk), true
+        }
+        if hook, ok := hook.(ArticleAfterCreateBulk); ok {
+            h.afterCreateBulk, registered = append(h.afterCreateBulk, hook.AfterCreateBulk), true
+        }
+        if hook, ok := hook.(ArticleBeforeUpdate); ok {
+            h.beforeUpdate, registered = append(h.beforeUpdate, hook.BeforeUpdate), true
+        }
+        if hook, ok := hook.(ArticleAfterUpdate); ok {
+            h.afterUpdate, registered = append(h.afterUpdate, hook.AfterUpdate), true
+        }
+        if hook, ok := hook.(ArticleBeforeDelete); ok {
+            h.beforeDelete, registered = append(h.beforeDelete, hook.BeforeDelete), true
+        }
+        if hook, ok := hook.(ArticleAfterDelete); ok {
+            h.afterDelete, registered = append(h.afterDelete, hook.AfterDelete), true
+        }
+        if !registered {
+            panic(fmt.Sprintf("NewArticleRepository: hook %T implements none of the Article hook interfaces", hook))
+        }
+    }
+    return h
+}
+
+// NewArticleRepository now returns the ArticleRepository interface.
+// Each of hooks implements one or more of the ArticleBefore* and ArticleAfter* hook interfaces.
+func NewArticleRepository(
+    provider DBProvider, 
+    cacheProvider CacheProvider,
+    configProvider ConfigProvider, 
+    dbSettings gobreaker.Settings, 
+    telemetry TelemetryProvider,
+    hooks ...interface{}) ArticleRepository {
+
+    if configProvider == nil {
+        configProvider = DefaultConfigProvider{}
+    }
+
+    if cacheProvider == nil {
+        cacheProvider = NoopCacheProvider{}
+    }
+
+    if telemetry == nil {
+		telemetry = NoopTelemetryProvider{}
+	}
+
+    if dbSettings.Name == "" {
+        dbSettings.Name = "article_dal"
+    }
+    if dbSettings.ReadyToTrip == nil {
+        dbSettings.ReadyToTrip = func(counts gobreaker.Counts) bool {
+            return counts.ConsecutiveFailures > 4
+        }
+    }
+    if dbSettings.OnStateChange == nil {
+		dbSettings.OnStateChange = OnCircuitBreakerStateChange
+	}
+
+    dbSettings.IsSuccessful = func(err error) bool {
+		if err == nil {
+			return true
+		}
+		// If the record just doesn't exist, the database is perfectly healthy
+		if errors.Is(err, ErrNotFound) {
+			return true
+		}
+		// Neither does a hook vetoing the operation or an invalid entity
+		var hookErr *HookError
+		var validationErr *ValidationError
+		if errors.As(err, &hookErr) || errors.As(err, &validationErr) {
+			return true
+		}
+		return false
+	}
+
+
+    if dbSettings.Timeout == 0 {
+        dbSettings.Timeout = time.Second * 20
+    }
+
+    // Default expiration is 5 minutes and cleanup of 10 minutes
+   	singleCache := cache.New(5*time.Minute, 10*time.Minute)
+	listCache := cache.New(5*time.Minute, 10*time.Minute)
+    countCache := cache.New(5*time.Minute, 10*time.Minute)
+
+    
+
+    newDAL := &articleRepository{
+        dbProvider:     provider,
+        cache:          singleCache,
+        listCache:      listCache,
+        countCache:     countCache,
+        cacheProvider:  cacheProvider,
+        configProvider: configProvider,
+		dbBreaker:      gobreaker.NewCircuitBreaker(dbSettings),
+        telemetryProvider: telemetry,
+        hooks:          newArticleHooks(hooks),
+    }
+
+    // Initialize the epoch and register the Pub/Sub handler
+    newDAL.listEpoch.Store(time.Now().UnixNano())
+    cacheProvider.OnBumpEpoch("article", newDAL.onBumpEpoch)
+
+    // initialize cache invalidation handler
+    cacheProvider.OnCacheInvalidated("article", newDAL.onCacheInvalidated)
+    cacheProvider.OnCacheFlushList("article", newDAL.onCacheFlushList)
+    cacheProvider.OnCacheFlushItem("article", newDAL.onCacheFlushItem)
+
+    return newDAL
+}
+
+func (d *articleRepository) getCacheKey(id int64) string {
+    return fmt.Sprintf("article_id:%d", id)
+}
+
+
+
+func (d *articleRepository) getEpoch() int64 {
+    return d.listEpoch.Load()
+}
+
+func (d *articleRepository) bumpEpoch() {
+    d.listEpoch.Store(time.Now().UnixNano())
+}
+
+func (d *articleRepository) onBumpEpoch() {
+    d.bumpEpoch()
+}
+
+func (d *articleRepository) InvalidateCache(entity *Article) {
+    cacheKey := d.getCacheKey(entity.ID)
+	d.cache.Delete(cacheKey)
+
+	// Invalidate cache entry across instances
+	d.cacheProvider.InvalidateCache("article", cacheKey)
+}
+
+func (d *articleRepository) FlushListCache() {
+    d.listCache.Flush()
+    d.countCache.Flush()
+    d.cacheProvider.FlushListCache("article")
+}
+
+// FlushAllCache clears both list/count caches AND single-item caches locally, 
+// and broadcasts the flush to other instances.
+func (d *articleRepository) FlushAllCache() {
+	d.cache.Flush()
+	d.listCache.Flush()
+	d.countCache.Flush()
+	d.cacheProvider.FlushListCache("article")
+	d.cacheProvider.FlushItemCache("article")
+}
+
+// Handles cache_flush_item. Just clears the single items cache locally.
+func (d *articleRepository) onCacheFlushItem() {
+	d.cache.Flush()
+}
+
+// Handles cache invalidations. Just remove cached entry by key
+func (d *articleRepository) onCacheInvalidated(key string) {
+    d.cache.Delete(key)
+}
+
+// Handles cache_flush_list. Just clears all lists cache.
+func (d *articleRepository) onCacheFlushList() {
+    d.listCache.Flush()
+    d.countCache.Flush()
+}
+
+
+
+
+//go:embed article.sql
+var schemaArticle string
+
+func (d *articleRepository) CreateTable(ctx context.Context) error {
+	// Get write database connection
+	dbs := d.dbProvider.AllDatabases("article", "write")
+
+	for _, db := range dbs {
+		// First check if table exists
+		exists, err := d.tableExists(ctx, db)
+		if err != nil {
+			return fmt.Errorf("failed to check table existence: %w", err)
+		}
+		if exists {
+		    log.Infof("CreateTable: table [%s] already exist", "article")
+			return nil
+		}
+
+		// 1. Strip out comment lines completely BEFORE splitting by semicolons
+		var cleanLines []string
+		for _, line := range strings.Split(schemaArticle, "\n") {
+			trimmedLine := strings.TrimSpace(line)
+			// Skip empty lines and comment lines ('#' for MySQL, '--' for PostgreSQL and SQLite)
+			if trimmedLine == "" || strings.HasPrefix(trimmedLine, "#") || strings.HasPrefix(trimmedLine, "--") {
+				continue
+			}
+			cleanLines = append(cleanLines, line)
+		}
+		
+		// 2. Re-join the clean lines, then split by semicolon
+		cleanSchema := strings.Join(cleanLines, "\n")
+		statements := strings.Split(cleanSchema, ";")
+
+		// 3. Execute the actual statements
+		for _, stmt := range statements {
+			stmt = strings.TrimSpace(stmt)
+			if stmt == "" {
+				continue
+			}
+
+			_, err = db.ExecContext(ctx, stmt)
+			if err != nil {
+				return fmt.Errorf("failed to execute SQL statement %q: %w", stmt, err)
+			}
+		}
+	}
+	log.Infof("CreateTable: table [%s] created", "article")
+
+	return nil
+}
+
+func (d *articleRepository) tableExists(ctx context.Context, db *sql.DB) (bool, error) {
+	query := `
+		SELECT 1
+		FROM information_schema.tables 
+		WHERE table_schema = current_schema() 
+		AND table_name = 'articles'
+	`
+
+	var exists bool
+	err := db.QueryRowContext(ctx, query).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to query table existence: %w", err)
+	}
+
+	return exists, nil
+}
+
+
+
+
+func (d *articleRepository) Create(ctx context.Context, entity *Article) (*Article, error) {
+    if d.configProvider.BlockedWrites("article") {
+        return nil, ErrOperationBlocked
+    }
+
+	if entity.ID > 0 {
+		return nil, fmt.Errorf("articleRepository.Create failed as ID > 0")
+	}
+
+	d.telemetryProvider.IncDALOperation("article", "create")
+
+	created, updated := entity.Created, entity.Updated
+	_, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return nil, withHooks(ctx, "Create", entity, d.hooks.beforeCreate, d.hooks.afterCreate, nil, func(ctx context.Context) error {
+			_, err := d.create(ctx, entity)
+			return err
+		})
+	})
+
+	if err != nil {
+		entity.ID, entity.Created, entity.Updated = 0, created, updated // An after hook may have rolled the insert back
+		return nil, err
+	}
+
+	// Inside a transaction caches are only touched once it commits.
+	cached := *entity
+	afterCommit(ctx, func() {
+		d.setCached(&cached)
+
+		// All lists cache should be flushed.
+		d.FlushListCache()
+	})
+
+	return entity, err
+}
+
+func (d *articleRepository) create(ctx context.Context, entity *Article) (*Article, error) {
+	const operation = "create"
+	start := time.Now()
+
+	now := time.Now().Truncate(time.Second)
+	entity.Created = now
+	entity.Updated = now
+	
+	// Auto-generate UID fields if they are not provided
+    if entity.Uid == "" {
+        entity.Uid = GenerateUID("art")
+    }
+
+	query := `
+		INSERT INTO articles (attributes,author_id,body,published_at,rating,slug,title,uid,
+created,updated)
+		VALUES (?,?,?,?,?,?,?,?,?,?)
+		RETURNING id
+	`
+    query = rebindPostgres(query)
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "article", true)
+    if dbErr != nil {
+		d.telemetryProvider.IncDBError("article", operation)		
+        return nil, dbErr
+    }
+
+	// PostgreSQL has no LastInsertId, the generated id is returned by the INSERT itself.
+	var id int64
+	err := db.QueryRowContext(ctx, query,entity.Attributes, entity.AuthorId, entity.Body, entity.PublishedAt, entity.Rating, entity.Slug, entity.Title, entity.Uid,
+		entity.Created, entity.Updated).Scan(&id)
+
+	if err != nil {
+		d.telemetryProvider.IncDBError("article", operation)
+		return nil, fmt.Errorf("failed to insert Article: %w", err)
+	}
+
+	entity.ID = id	
+	d.telemetryProvider.IncDBRequest("article", operation)	
+	d.telemetryProvider.ObserveDBLatency("article", operation, time.Since(start).Seconds())	
+	return entity, nil
+}
+
+
+
+func (d *articleRepository) CreateBulk(ctx context.Context, entities []*Article) ([]*Article, error) {
+	if d.configProvider.BlockedWrites("article") {
+		return nil, ErrOperationBlocked
+	}
+
+
+	const operation = "create_bulk"
+	d.telemetryProvider.IncDALOperation("article", operation)
+
+	var created []*Article
+	_, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return nil, withHooks(ctx, "CreateBulk", entities, d.hooks.beforeCreateBulk, d.hooks.afterCreateBulk, nil, func(ctx context.Context) error {
+			var err error
+			created, err = d.createBulk(ctx, entities)
+			return err
+		})
+	})
+
+	if err != nil {
+		// With after hooks all chunks share a transaction, which the error rolled back.
+		// A retry must not find the IDs of the vanished rows.
+		if len(d.hooks.afterCreateBulk) > 0 {
+			for _, entity := range entities {
+				entity.ID = 0
+			}
+		}
+		return nil, err
+	}
+
+	// Inside a transaction caches are only touched once it commits.
+	cached := make([]Article, len(created))
+	for i, entity := range created {
+		cached[i] = *entity
+	}
+	afterCommit(ctx, func() {
+		for i := range cached {
+			d.setCached(&cached[i])
+		}
+
+		// All lists cache should be flushed.
+		d.FlushListCache()
+	})
+
+	return created, err
+}
+
+func (d *articleRepository) createBulk(ctx context.Context, entities []*Article) ([]*Article, error) {
+    const operation = "create_bulk"
+    start := time.Now()
+
+    if len(entities) == 0 {
+        return nil, fmt.Errorf("empty entities list")
+    }
+
+    // Get the database connection once, outside the loop
+    db, dbErr := databaseFor(ctx, d.dbProvider, "article", true)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("article", operation)
+        return nil, dbErr
+    }
+
+    now := time.Now().Truncate(time.Second)
+    batchSize := 500 // Safe limit to prevent exceeding MySQL's max placeholders
+
+    // Process entities in chunks
+    for i := 0; i < len(entities); i += batchSize {
+        end := i + batchSize
+        if end > len(entities) {
+            end = len(entities)
+        }
+        
+        chunk := entities[i:end]
+
+        // Prepare data and parameters scoped ONLY to this chunk
+        valuePlaceholders := make([]string, 0, len(chunk))
+        // Dynamically calculate fields per entity: custom columns + 2 (created, updated)
+        params := make([]interface{}, 0, len(chunk)*(8 + 2)) 
+
+        for _, entity := range chunk {
+            if entity.ID > 0 {
+                return nil, fmt.Errorf("entity with existing ID in bulk create")
+            }
+
+            entity.Created = now
+            entity.Updated = now
+
+			// Auto-generate UID fields if they are not provided
+			if entity.Uid == "" {
+				entity.Uid = GenerateUID("art")
+			}
+
+            valuePlaceholders = append(valuePlaceholders, "(?,?,?,?,?,?,?,?,?,?)")
+            params = append(params,
+                entity.Attributes,
+                entity.AuthorId,
+                entity.Body,
+                entity.PublishedAt,
+                entity.Rating,
+                entity.Slug,
+                entity.Title,
+                entity.Uid,
+                entity.Created,
+                entity.Updated,
+            )
+        }
+
+        query := fmt.Sprintf(`
+            INSERT INTO articles
+            (attributes,author_id,body,published_at,rating,slug,title,uid,
+created,updated)
+            VALUES %s
+            RETURNING id
+        `, strings.Join(valuePlaceholders, ","))
+    query = rebindPostgres(query)
+
+        // PostgreSQL returns the ids generated by a multi row INSERT in VALUES order.
+        rows, err := db.QueryContext(ctx, query, params...)
+        if err != nil {
+            d.telemetryProvider.IncDBError("article", operation)
+            return nil, fmt.Errorf("failed to bulk insert articles chunk: %w", err)
+        }
+
+        j := 0
+        for rows.Next() {
+            if j >= len(chunk) {
+                rows.Close()
+                return nil, fmt.Errorf("mismatch between inserted rows and entity count in chunk")
+            }
+            if err := rows.Scan(&chunk[j].ID); err != nil {
+                rows.Close()
+                d.telemetryProvider.IncDBError("article", operation)
+                return nil, fmt.Errorf("failed to scan inserted ID for chunk: %w", err)
+            }
+            j++
+        }
+        rows.Close()
+
+        if err := rows.Err(); err != nil {
+            d.telemetryProvider.IncDBError("article", operation)
+            return nil, fmt.Errorf("failed to read inserted IDs for chunk: %w", err)
+        }
+
+        if j != len(chunk) {
+            return nil, fmt.Errorf("mismatch between inserted rows and entity count in chunk")
+        }
+    }
+
+    d.telemetryProvider.IncDBRequest("article", operation) 
+    d.telemetryProvider.ObserveDBLatency("article", operation, time.Since(start).Seconds())    
+    return entities, nil
+}
+
+
+
+// ErrNotFound is returned in case there was nothing to update; either missing id or newer version of entity is already stored.
+func (d *articleRepository) Update(ctx context.Context, entity *Article) error {
+    if d.configProvider.BlockedWrites("article") {
+        return ErrOperationBlocked
+    }
+
+	const operation = "update"
+	d.telemetryProvider.IncDALOperation("article", operation)
+	// 1. Get existing entity to check for changes. Required for proper cache invalidation.
+	existing, err := d.GetByID(ctx, entity.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get existing Article, id: %v, err: %w", entity.ID, err)
+	}
+
+	// Perform the update in DB.
+	version := entity.Version
+	_, err2 := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return nil, withHooks(ctx, "Update", entity, d.hooks.beforeUpdate, d.hooks.afterUpdate, nil, func(ctx context.Context) error {
+			if err := d.update(ctx, entity); err != nil {
+				return err
+			}
+			entity.Version++
+			return nil
+		})
+	})
+
+	if err2 != nil {
+		entity.Version = version // An after hook may have rolled the update back
+		return err2
+	}
+
+	// Compared once the before hooks ran, as they may change the unique values.
+	
+	var oldSlug string
+	if existing.Slug != entity.Slug {
+		oldSlug = existing.Slug
+	}
+		
+	var oldUid string
+	if existing.Uid != entity.Uid {
+		oldUid = existing.Uid
+	}
+		
+
+	// Inside a transaction caches are only touched once it commits.
+	cached := *entity
+	afterCommit(ctx, func() {
+		
+	if oldSlug != "" {
+		oldCacheKey := fmt.Sprintf("article_slug:%s", oldSlug)
+		d.cache.Delete(oldCacheKey)
+		d.cacheProvider.InvalidateCache("article", oldCacheKey)
+	}
+	if oldUid != "" {
+		oldCacheKey := fmt.Sprintf("article_uid:%s", oldUid)
+		d.cache.Delete(oldCacheKey)
+		d.cacheProvider.InvalidateCache("article", oldCacheKey)
+	}
+
+		// Lets clear item from cache on other instances and here
+		d.InvalidateCache(&cached)
+		d.FlushListCache()
+
+		// our entity can become part of local cache
+		d.setCached(&cached)
+	})
+
+	return nil
+}
+
+func (d *articleRepository) update(ctx context.Context, entity *Article) error {
+	const operation = "update"
+	start := time.Now()
+
+	entity.Updated = time.Now()
+
+	query := `
+		UPDATE articles
+		SET attributes = ?,author_id = ?,body = ?,published_at = ?,rating = ?,slug = ?,title = ?,uid = ?, updated=?, version = version + 1
+		WHERE id = ? AND version = ? AND deleted_at IS NULL
+	`
+    query = rebindPostgres(query)
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "article", true)
+    if dbErr != nil {
+		d.telemetryProvider.IncDBError("article", operation)
+        return dbErr
+    }
+
+	res, err := db.ExecContext(ctx, query,entity.Attributes, entity.AuthorId, entity.Body, entity.PublishedAt, entity.Rating, entity.Slug, entity.Title, entity.Uid,
+	entity.Updated, entity.ID, entity.Version)
+
+	if err != nil {
+		d.telemetryProvider.IncDBError("article", operation)
+		return fmt.Errorf("failed to update Article: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		d.telemetryProvider.IncDBError("article", operation)
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		// clean out cache for specific ID to limit stale cache entries
+		stale := *entity
+		afterCommit(ctx, func() { d.InvalidateCache(&stale) })
+		return ErrNotFound
+	}
+
+	d.telemetryProvider.ObserveDBLatency("article", operation, time.Since(start).Seconds())	
+
+	return nil
+}
+
+
+
+// UpsertBySlug inserts the entity, or overwrites title, body of the Article
+// with the same slug, bumping its version. The returned entity holds the stored row.
+// Unlike Update it doesn't check the version, the last upsert wins. The Create or Update hooks run depending
+// on whether a Article with the slug was found.
+func (d *articleRepository) UpsertBySlug(ctx context.Context, entity *Article) (*Article, error) {
+    if d.configProvider.BlockedWrites("article") {
+        return nil, ErrOperationBlocked
+    }
+
+	const operation = "upsert_by_slug"
+	d.telemetryProvider.IncDALOperation("article", operation)
+
+	// 1. Get existing entity to check for changes. Required for proper cache invalidation.
+	existing, err := d.GetBySlug(ctx, entity.Slug)
+	if errors.Is(err, ErrNotFound) {
+		// Nothing is cached for a row that doesn't exist yet.
+		existing = entity
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get existing Article, slug: %v, err: %w", entity.Slug, err)
+	}
+
+	// The stored row decides between the Create and Update hooks.
+	hookName, beforeHooks, afterHooks := "Create", d.hooks.beforeCreate, d.hooks.afterCreate
+	if existing != entity {
+		hookName, beforeHooks, afterHooks = "Update", d.hooks.beforeUpdate, d.hooks.afterUpdate
+	}
+
+	_, err = executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return nil, withHooks(ctx, hookName, entity, beforeHooks, afterHooks, nil, func(ctx context.Context) error {
+			_, err := d.upsertBySlug(ctx, entity)
+			return err
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Compared with the stored row once the before hooks ran, as they may change the unique values.
+	
+	var oldSlug string
+	if existing.Slug != entity.Slug {
+		oldSlug = existing.Slug
+	}
+		
+	var oldUid string
+	if existing.Uid != entity.Uid {
+		oldUid = existing.Uid
+	}
+		
+
+	// Inside a transaction caches are only touched once it commits.
+	cached := *entity
+	afterCommit(ctx, func() {
+		
+	if oldSlug != "" {
+		oldCacheKey := fmt.Sprintf("article_slug:%s", oldSlug)
+		d.cache.Delete(oldCacheKey)
+		d.cacheProvider.InvalidateCache("article", oldCacheKey)
+	}
+	if oldUid != "" {
+		oldCacheKey := fmt.Sprintf("article_uid:%s", oldUid)
+		d.cache.Delete(oldCacheKey)
+		d.cacheProvider.InvalidateCache("article", oldCacheKey)
+	}
+
+		d.InvalidateCache(&cached)
+		d.FlushListCache()
+
+		d.setCached(&cached)
+	})
+
+	return entity, nil
+}
+
+func (d *articleRepository) upsertBySlug(ctx context.Context, entity *Article) (*Article, error) {
+	const operation = "upsert_by_slug"
+	start := time.Now()
+
+	now := time.Now().Truncate(time.Second)
+	entity.Created = now
+	entity.Updated = now
+
+	// Auto-generate UID fields if they are not provided
+    if entity.Uid == "" {
+        entity.Uid = GenerateUID("art")
+    }
+
+	query := `INSERT INTO articles (attributes,author_id,body,published_at,rating,slug,title,uid,created,updated) VALUES (?,?,?,?,?,?,?,?,?,?) ON CONFLICT (slug) DO UPDATE SET title = excluded.title, body = excluded.body, updated = CASE WHEN articles.title IS DISTINCT FROM excluded.title OR articles.body IS DISTINCT FROM excluded.body THEN excluded.updated ELSE articles.updated END, version = CASE WHEN articles.title IS DISTINCT FROM excluded.title OR articles.body IS DISTINCT FROM excluded.body THEN articles.version + 1 ELSE articles.version END RETURNING id, version, attributes, author_id, body, published_at, rating, slug, title, uid, created, updated, deleted_at`
+    query = rebindPostgres(query)
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "article", true)
+    if dbErr != nil {
+		d.telemetryProvider.IncDBError("article", operation)
+        return nil, dbErr
+    }
+
+	// The stored row is returned by the upsert itself.
+	{
+		row := db.QueryRowContext(ctx, query,entity.Attributes, entity.AuthorId, entity.Body, entity.PublishedAt, entity.Rating, entity.Slug, entity.Title, entity.Uid,
+			entity.Created, entity.Updated)
+		err := row.Scan(
+			&entity.ID,
+			&entity.Version,&entity.Attributes,&entity.AuthorId,&entity.Body,&entity.PublishedAt,&entity.Rating,&entity.Slug,&entity.Title,&entity.Uid,
+			&entity.Created,
+			&entity.Updated,
+			&entity.DeletedAt,
+		)
+		if err != nil {
+			d.telemetryProvider.IncDBError("article", operation)
+			return nil, fmt.Errorf("failed to upsert Article: %w", err)
+		}
+	}
+
+	d.telemetryProvider.IncDBRequest("article", operation)
+	d.telemetryProvider.ObserveDBLatency("article", operation, time.Since(start).Seconds())
+	return entity, nil
+}
+
+
+
+// UpsertBulkBySlug upserts the entities by slug in chunks of 500 rows, overwriting
+// title, body of the existing Articles. Rows already holding the given values are not written.
+// The entities are filled from the stored rows, including the IDs of updated rows, and the outcome of
+// every entity is returned in the same order. Each chunk is written in a transaction; use RunInTx to make
+// the whole call atomic. Inserted rows run the CreateBulk hooks and updated ones the Update hooks.
+// Only the written rows are invalidated in the cache.
+func (d *articleRepository) UpsertBulkBySlug(ctx context.Context, entities []*Article) ([]UpsertOutcome, error) {
+	if d.configProvider.BlockedWrites("article") {
+		return nil, ErrOperationBlocked
+	}
+
+	const operation = "upsert_bulk_by_slug"
+	d.telemetryProvider.IncDALOperation("article", operation)
+
+	if len(entities) == 0 {
+		return nil, nil // Nothing to upsert
+	}
+
+	// A statement can't upsert the same row twice, and the outcome would be ambiguous.
+	seen := make(map[string]struct{}, len(entities))
+	for _, entity := range entities {
+		if _, exists := seen[entity.Slug]; exists {
+			return nil, fmt.Errorf("duplicate slug %v in bulk upsert", entity.Slug)
+		}
+		seen[entity.Slug] = struct{}{}
+	}
+
+	outcomes := make([]UpsertOutcome, len(entities))
+	var staleKeys []string
+	batchSize := 500 // Safe limit to prevent exceeding the placeholder limits
+
+	for i := 0; i < len(entities); i += batchSize {
+		end := i + batchSize
+		if end > len(entities) {
+			end = len(entities)
+		}
+
+		result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+			return d.upsertBulkBySlug(ctx, entities[i:end], outcomes[i:end])
+		})
+		if err != nil {
+			return nil, err // Fail fast, earlier chunks stay written
+		}
+		staleKeys = append(staleKeys, result.([]string)...)
+	}
+
+	// Inside a transaction caches are only touched once it commits.
+	var inserted, updated []Article
+	for i, entity := range entities {
+		switch outcomes[i] {
+		case UpsertInserted:
+			inserted = append(inserted, *entity)
+		case UpsertUpdated:
+			updated = append(updated, *entity)
+		}
+	}
+	afterCommit(ctx, func() {
+		for _, key := range staleKeys {
+			d.cache.Delete(key)
+			d.cacheProvider.InvalidateCache("article", key)
+		}
+		for i := range updated {
+			d.InvalidateCache(&updated[i])
+			d.setCached(&updated[i])
+		}
+		for i := range inserted {
+			d.setCached(&inserted[i])
+		}
+
+		if len(inserted) > 0 || len(updated) > 0 {
+			d.FlushListCache()
+		}
+	})
+
+	return outcomes, nil
+}
+
+// upsertBulkBySlug upserts a single chunk and returns the cache keys of overwritten unique values.
+func (d *articleRepository) upsertBulkBySlug(ctx context.Context, chunk []*Article, outcomes []UpsertOutcome) ([]string, error) {
+	// The stored rows stay locked from the read that classifies them until the write commits, and errors of
+	// the after hooks roll the chunk back.
+	if !inTx(ctx) {
+		return withinTx(ctx, func(ctx context.Context) ([]string, error) { return d.upsertBulkBySlug(ctx, chunk, outcomes) })
+	}
+	const operation = "upsert_bulk_by_slug"
+	start := time.Now()
+
+	db, dbErr := databaseFor(ctx, d.dbProvider, "article", true)
+	if dbErr != nil {
+		d.telemetryProvider.IncDBError("article", operation)
+		return nil, dbErr
+	}
+
+	// 1. Load and lock the stored rows to tell inserts, updates and unchanged rows apart
+	keys := make([]interface{}, len(chunk))
+	for j, entity := range chunk {
+		keys[j] = entity.Slug
+	}
+	stored, err := d.storedBySlugs(ctx, db, keys)
+	if err != nil {
+		d.telemetryProvider.IncDBError("article", operation)
+		return nil, err
+	}
+
+	var staleKeys []string
+	var inserts, updates []*Article
+	for j, entity := range chunk {
+		existing, exists := stored[entity.Slug]
+		switch {
+		case !exists:
+			outcomes[j] = UpsertInserted
+			inserts = append(inserts, entity)
+		case sameValue(existing.Title, entity.Title) && sameValue(existing.Body, entity.Body):
+			outcomes[j] = UpsertUnchanged
+			*entity = *existing
+		default:
+			outcomes[j] = UpsertUpdated
+
+			// Keep the stored values of the columns that are not overwritten
+			merged := *existing
+			merged.Title = entity.Title
+			merged.Body = entity.Body
+			*entity = merged
+			updates = append(updates, entity)
+		}
+	}
+
+	// Inserts run the hooks of CreateBulk and updates the ones of Update, unchanged rows none.
+	if len(inserts) > 0 {
+		if err := runHooks(ctx, "BeforeCreateBulk", d.hooks.beforeCreateBulk, inserts); err != nil {
+			return nil, err
+		}
+	}
+	for _, entity := range updates {
+		if err := runHooks(ctx, "BeforeUpdate", d.hooks.beforeUpdate, entity); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now().Truncate(time.Second)
+	var writtenKeys []interface{}
+	valuePlaceholders := make([]string, 0, len(chunk))
+	params := make([]interface{}, 0, len(chunk)*(8 + 2))
+
+	for j, entity := range chunk {
+		if outcomes[j] == UpsertUnchanged {
+			continue
+		}
+
+		// Auto-generate UID fields of inserted rows if they are not provided
+		if outcomes[j] == UpsertInserted && entity.Uid == "" {
+			entity.Uid = GenerateUID("art")
+		}
+
+		entity.Created = now
+		entity.Updated = now
+
+		writtenKeys = append(writtenKeys, entity.Slug)
+		valuePlaceholders = append(valuePlaceholders, "(?,?,?,?,?,?,?,?,?,?)")
+		params = append(params,
+			entity.Attributes,
+			entity.AuthorId,
+			entity.Body,
+			entity.PublishedAt,
+			entity.Rating,
+			entity.Slug,
+			entity.Title,
+			entity.Uid,
+			entity.Created,
+			entity.Updated,
+		)
+	}
+
+	if len(writtenKeys) == 0 {
+		return nil, nil // Every row is unchanged
+	}
+
+	// 2. Write the inserted and updated rows in a single statement
+	query := fmt.Sprintf(`
+		INSERT INTO articles
+		(attributes,author_id,body,published_at,rating,slug,title,uid,
+created,updated)
+		VALUES %s
+	`, strings.Join(valuePlaceholders, ",")) + ` ON CONFLICT (slug) DO UPDATE SET title = excluded.title, body = excluded.body, updated = CASE WHEN articles.title IS DISTINCT FROM excluded.title OR articles.body IS DISTINCT FROM excluded.body THEN excluded.updated ELSE articles.updated END, version = CASE WHEN articles.title IS DISTINCT FROM excluded.title OR articles.body IS DISTINCT FROM excluded.body THEN articles.version + 1 ELSE articles.version END`
+    query = rebindPostgres(query)
+
+	if _, err := db.ExecContext(ctx, query, params...); err != nil {
+		d.telemetryProvider.IncDBError("article", operation)
+		return nil, fmt.Errorf("failed to bulk upsert articles chunk: %w", err)
+	}
+
+	// 3. LastInsertId can't identify the rows of a multi row upsert, so reload them by slug
+	stored, err = d.storedBySlugs(ctx, db, writtenKeys)
+	if err != nil {
+		d.telemetryProvider.IncDBError("article", operation)
+		return nil, err
+	}
+
+	for j, entity := range chunk {
+		if outcomes[j] == UpsertUnchanged {
+			continue
+		}
+
+		row, exists := stored[entity.Slug]
+		if !exists {
+			// The database matched a row the key doesn't, e.g. one differing in accents or trailing spaces. Let it find the row
+			// of this slug alone rather than failing after the write.
+			again, err := d.storedBySlugs(ctx, db, []interface{}{entity.Slug})
+			if err != nil {
+				d.telemetryProvider.IncDBError("article", operation)
+				return nil, err
+			}
+			for _, match := range again {
+				row = match
+			}
+		}
+		if row == nil {
+			return nil, fmt.Errorf("upserted Article with slug %v not found", entity.Slug)
+		}
+
+		// A concurrent insert of the same slug turns our insert into an update.
+		if outcomes[j] == UpsertInserted && row.Version > 0 {
+			outcomes[j] = UpsertUpdated
+		}
+		*entity = *row
+	}
+
+	if len(inserts) > 0 {
+		if err := runHooks(ctx, "AfterCreateBulk", d.hooks.afterCreateBulk, inserts); err != nil {
+			return nil, err
+		}
+	}
+	for _, entity := range updates {
+		if err := runHooks(ctx, "AfterUpdate", d.hooks.afterUpdate, entity); err != nil {
+			return nil, err
+		}
+	}
+
+	d.telemetryProvider.IncDBRequest("article", operation)
+	d.telemetryProvider.ObserveDBLatency("article", operation, time.Since(start).Seconds())
+	return staleKeys, nil
+}
+
+// storedBySlugs loads and locks the rows holding the given slug values from db, keyed by slug as the database compares it.
+func (d *articleRepository) storedBySlugs(ctx context.Context, db dbExecutor, keys []interface{}) (map[string]*Article, error) {
+	placeholders := make([]string, len(keys))
+	for i := range keys {
+		placeholders[i] = "?"
+	}
+
+	// Soft deleted rows have their unique values scrambled, so they never match.
+	query := fmt.Sprintf(`
+		SELECT id, version, attributes, author_id, body, published_at, rating, slug, title, uid, created, updated, deleted_at
+		FROM articles
+		WHERE slug IN (%s) FOR UPDATE
+	`, strings.Join(placeholders, ","))
+    query = rebindPostgres(query)
+
+	rows, err := db.QueryContext(ctx, query, keys...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stored articles: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[string]*Article, len(keys))
+	for rows.Next() {
+		var entity Article
+		err := rows.Scan(
+			&entity.ID,
+			&entity.Version,
+			&entity.Attributes,
+			&entity.AuthorId,
+			&entity.Body,
+			&entity.PublishedAt,
+			&entity.Rating,
+			&entity.Slug,
+			&entity.Title,
+			&entity.Uid,
+			&entity.Created,
+			&entity.Updated,
+			&entity.DeletedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan Article: %w", err)
+		}
+		result[entity.Slug] = &entity
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stored articles: %w", err)
+	}
+	return result, nil
+}
+
+
+
+func (d *articleRepository) Delete(ctx context.Context, entity *Article) error {
+    if d.configProvider.BlockedWrites("article") {
+        return ErrOperationBlocked
+    }
+
+	if entity.ID == 0 {
+		// nothing can be deleted as entity is not created.
+		return ErrNotFound
+	}
+
+	const operation = "delete"
+	d.telemetryProvider.IncDALOperation("article", operation)
+
+	_, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return nil, withHooks(ctx, "Delete", entity, d.hooks.beforeDelete, d.hooks.afterDelete, nil, func(ctx context.Context) error {
+			return d.delete(ctx, entity.ID)
+		})
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// Lets clear item from cache and on next use use DB as source of truth
+	deleted := *entity
+	afterCommit(ctx, func() {
+		d.InvalidateCache(&deleted)
+		d.FlushListCache()
+	})
+
+	return err
+}
+
+func (d *articleRepository) delete(ctx context.Context, id int64) error {
+	start := time.Now()
+	const operation = "delete"
+
+	
+	query := `
+		UPDATE articles
+		SET 
+			deleted_at = NOW(), 
+			updated = NOW(), 
+			version = version + 1
+			, slug = slug || '-del-' || gen_random_uuid()::text
+			, uid = uid || '-del-' || gen_random_uuid()::text
+		WHERE id = ? AND deleted_at IS NULL
+	`
+	
+    query = rebindPostgres(query)
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "article", true)
+    if dbErr != nil {
+		d.telemetryProvider.IncDBError("article", operation)
+        return dbErr
+    }
+
+	res, err := db.ExecContext(ctx, query, id)
+	if err != nil {
+		d.telemetryProvider.IncDBError("article", operation)
+		return fmt.Errorf("failed to delete article: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		d.telemetryProvider.IncDBError("article", operation)
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	d.telemetryProvider.IncDBRequest("article", operation)	
+	d.telemetryProvider.ObserveDBLatency("article", operation, time.Since(start).Seconds())	
+	return nil
+}
+
+
+// HardDelete permanently removes the entity from the database.
+// returns ErrNotFound in case nothing is deleted.
+func (d *articleRepository) HardDelete(ctx context.Context, entity *Article) error {
+    if d.configProvider.BlockedWrites("article") {
+        return ErrOperationBlocked
+    }
+
+	if entity.ID == 0 {
+		// nothing can be deleted as entity is not created.
+		return ErrNotFound
+	}
+
+	const operation = "hard_delete"
+	d.telemetryProvider.IncDALOperation("article", operation)
+
+	_, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return nil, withHooks(ctx, "Delete", entity, d.hooks.beforeDelete, d.hooks.afterDelete, nil, func(ctx context.Context) error {
+			return d.hardDelete(ctx, entity.ID)
+		})
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// Lets clear item from cache and on next use use DB as source of truth
+	deleted := *entity
+	afterCommit(ctx, func() {
+		d.InvalidateCache(&deleted)
+		d.FlushListCache()
+	})
+
+	return err
+}
+
+func (d *articleRepository) hardDelete(ctx context.Context, id int64) error {
+	start := time.Now()
+	const operation = "hard_delete"
+
+	query := `
+		DELETE FROM articles
+		WHERE id = ?
+	`
+    query = rebindPostgres(query)
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "article", true)
+    if dbErr != nil {
+		d.telemetryProvider.IncDBError("article", operation)
+        return dbErr
+    }
+
+	res, err := db.ExecContext(ctx, query, id)
+	if err != nil {
+		d.telemetryProvider.IncDBError("article", operation)
+		return fmt.Errorf("failed to hard delete article: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		d.telemetryProvider.IncDBError("article", operation)
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	d.telemetryProvider.IncDBRequest("article", operation)	
+	d.telemetryProvider.ObserveDBLatency("article", operation, time.Since(start).Seconds())	
+	return nil
+}
+
+
+
+
+
+func (d *articleRepository) GetByID(ctx context.Context, id int64) (*Article, error) {
+    if d.configProvider.BlockedReads("article") {
+        return nil, ErrOperationBlocked
+    }
+
+    const operation = "get_by_id"
+    d.telemetryProvider.IncDALOperation("article", operation)
+
+	// Load from cache. Transactions bypass caches to see their own writes.
+    if !inTx(ctx) {
+        cachedEntity, _ := d.getByIDCached(id)
+        if cachedEntity != nil {
+            return cachedEntity, nil
+        }
+    }
+
+	// Fallback to database if cache miss or decoding fails
+	result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return d.getByID(ctx, id)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	entity, ok := result.(*Article)
+    if !ok {
+        return nil, fmt.Errorf("invalid type")
+    }
+
+    if !inTx(ctx) {
+        _ = d.setCached(entity)
+    }
+
+    return entity, err
+}
+
+func (d *articleRepository) setCached(entity *Article) error {
+    // if we have not reached the maximum number of items set the cache. otherwise expirations will
+	// handle this
+    itemCount := d.cache.ItemCount()
+    if itemCount < 10000 {
+        cacheKey := d.getCacheKey(entity.ID)
+
+        // Store in cache with new format        
+        d.telemetryProvider.IncCacheWrite("article")
+        d.telemetryProvider.SetCacheSize("article", float64(itemCount+1))        
+
+        copy := *entity
+		d.cache.Set(cacheKey, &copy, time.Second*300)
+    }
+
+    return nil
+}
+
+// Gets entity from cache only or return nil
+func (d *articleRepository) getByIDCached(id int64) (*Article, error) {
+    const operation = "get_by_id"
+    cacheKey := d.getCacheKey(id)
+    val, found := d.cache.Get(cacheKey)
+    if found {
+        entity, ok := val.(*Article)
+        if !ok {
+            return nil, fmt.Errorf("articleRepository.GetById: Cache returned wrong type")
+        }
+
+        d.telemetryProvider.IncCacheHit("article", operation)        
+        copy := *entity
+        return &copy, nil
+    }
+
+    // Cache missed or error during fetching cached data
+    d.telemetryProvider.IncCacheMiss("article", operation)        	
+
+    return nil, nil
+}
+
+func (d *articleRepository) getByID(ctx context.Context, id int64) (*Article, error) {
+    const operation = "get_by_id"
+    dbStart := time.Now();
+
+    query := `
+        SELECT id, version, attributes, author_id, body, published_at, rating, slug, title, uid, created, updated, deleted_at
+        FROM articles
+        WHERE id = ? AND deleted_at IS NULL
+    `
+    query = rebindPostgres(query)
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "article", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("article", operation)
+        return nil, dbErr
+    }
+
+    row := db.QueryRowContext(ctx, query, id)
+    var entity Article
+    err := row.Scan(
+        &entity.ID,
+        &entity.Version,&entity.Attributes,&entity.AuthorId,&entity.Body,&entity.PublishedAt,&entity.Rating,&entity.Slug,&entity.Title,&entity.Uid,
+        &entity.Created,
+        &entity.Updated,
+        &entity.DeletedAt,
+    )
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return nil, ErrNotFound
+        }
+        d.telemetryProvider.IncDBError("article", operation)
+        return nil, fmt.Errorf("failed to get Article by ID: %w", err)
+    }
+
+    d.telemetryProvider.IncDBRequest("article", operation)	
+    d.telemetryProvider.ObserveDBLatency("article", operation, time.Since(dbStart).Seconds())	
+    return &entity, nil
+}
+
+
+
+
+func (d *articleRepository) GetBySlug(ctx context.Context, slug string) (*Article, error) {
+    if d.configProvider.BlockedReads("article") {
+        return nil, ErrOperationBlocked
+    }
+
+    const operation = "get_by_slug"
+    d.telemetryProvider.IncDALOperation("article", operation)
+
+    // key would for example be: user_email:test@something.com, but in this case cache is only mapping email -> id
+    cacheKey := fmt.Sprintf("article_slug:%v", slug)
+
+    // Fetch from cache Slug -> ID mapping
+    // Transactions bypass caches to see their own writes.
+    val, found := d.cache.Get(cacheKey)
+    if found && !inTx(ctx) {
+        entityId, ok := val.(int64)
+        if !ok {
+            return nil, fmt.Errorf("articleRepository.GetBySlug: Cache returned wrong type; expected ID type")
+        }
+
+        d.telemetryProvider.IncCacheHit("article", operation)        
+        
+        // return entity by that id
+        return d.GetByID(ctx, entityId)
+    }
+
+    // Cache missed or error during fetching cached data
+    d.telemetryProvider.IncCacheMiss("article", operation)        	
+
+	// Fallback to database if cache miss or decoding fails
+	result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return d.getBySlug(ctx, slug)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	entity := result.(*Article)
+
+	// Store in cache Slug -> ID mapping
+    if !inTx(ctx) {
+        d.telemetryProvider.IncCacheWrite("article")    
+        d.cache.Set(cacheKey, entity.ID, time.Second*300)
+    }
+
+    return entity, err
+}
+
+func (d *articleRepository) getBySlug(ctx context.Context, slug string) (*Article, error) {
+    const operation = "get_by_slug"
+    dbStart := time.Now()
+
+    query := `
+        SELECT id, version, attributes, author_id, body, published_at, rating, slug, title, uid, created, updated, deleted_at
+        FROM articles
+        WHERE slug = ? AND deleted_at IS NULL
+    `
+    query = rebindPostgres(query)
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "article", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("article", operation)
+        return nil, dbErr
+    }
+
+    row := db.QueryRowContext(ctx, query, slug)
+    var entity Article
+    err := row.Scan(
+        &entity.ID,
+        &entity.Version,
+        &entity.Attributes,
+        &entity.AuthorId,
+        &entity.Body,
+        &entity.PublishedAt,
+        &entity.Rating,
+        &entity.Slug,
+        &entity.Title,
+        &entity.Uid,
+        &entity.Created,
+        &entity.Updated,
+        &entity.DeletedAt,
+    )
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return nil, ErrNotFound
+        }
+
+        d.telemetryProvider.IncDBError("article", operation)
+        return nil, fmt.Errorf("failed to get Article by Slug: %w", err)
+    }
+
+    d.telemetryProvider.IncDBRequest("article", operation)	
+    d.telemetryProvider.ObserveDBLatency("article", operation, time.Since(dbStart).Seconds())	
+    return &entity, nil
+}
+
+
+
+func (d *articleRepository) GetByUid(ctx context.Context, uid string) (*Article, error) {
+    if d.configProvider.BlockedReads("article") {
+        return nil, ErrOperationBlocked
+    }
+
+    const operation = "get_by_uid"
+    d.telemetryProvider.IncDALOperation("article", operation)
+
+    // key would for example be: user_email:test@something.com, but in this case cache is only mapping email -> id
+    cacheKey := fmt.Sprintf("article_uid:%v", uid)
+
+    // Fetch from cache Uid -> ID mapping
+    // Transactions bypass caches to see their own writes.
+    val, found := d.cache.Get(cacheKey)
+    if found && !inTx(ctx) {
+        entityId, ok := val.(int64)
+        if !ok {
+            return nil, fmt.Errorf("articleRepository.GetByUid: Cache returned wrong type; expected ID type")
+        }
+
+        d.telemetryProvider.IncCacheHit("article", operation)        
+        
+        // return entity by that id
+        return d.GetByID(ctx, entityId)
+    }
+
+    // Cache missed or error during fetching cached data
+    d.telemetryProvider.IncCacheMiss("article", operation)        	
+
+	// Fallback to database if cache miss or decoding fails
+	result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return d.getByUid(ctx, uid)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	entity := result.(*Article)
+
+	// Store in cache Uid -> ID mapping
+    if !inTx(ctx) {
+        d.telemetryProvider.IncCacheWrite("article")    
+        d.cache.Set(cacheKey, entity.ID, time.Second*300)
+    }
+
+    return entity, err
+}
+
+func (d *articleRepository) getByUid(ctx context.Context, uid string) (*Article, error) {
+    const operation = "get_by_uid"
+    dbStart := time.Now()
+
+    query := `
+        SELECT id, version, attributes, author_id, body, published_at, rating, slug, title, uid, created, updated, deleted_at
+        FROM articles
+        WHERE uid = ? AND deleted_at IS NULL
+    `
+    query = rebindPostgres(query)
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "article", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("article", operation)
+        return nil, dbErr
+    }
+
+    row := db.QueryRowContext(ctx, query, uid)
+    var entity Article
+    err := row.Scan(
+        &entity.ID,
+        &entity.Version,
+        &entity.Attributes,
+        &entity.AuthorId,
+        &entity.Body,
+        &entity.PublishedAt,
+        &entity.Rating,
+        &entity.Slug,
+        &entity.Title,
+        &entity.Uid,
+        &entity.Created,
+        &entity.Updated,
+        &entity.DeletedAt,
+    )
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return nil, ErrNotFound
+        }
+
+        d.telemetryProvider.IncDBError("article", operation)
+        return nil, fmt.Errorf("failed to get Article by Uid: %w", err)
+    }
+
+    d.telemetryProvider.IncDBRequest("article", operation)	
+    d.telemetryProvider.ObserveDBLatency("article", operation, time.Since(dbStart).Seconds())	
+    return &entity, nil
+}
+
+
+
+
+// ArticleListByAuthorCursor points at the last Article of a ListByAuthor page. Pass it to ListByAuthor to fetch the next page,
+// or nil for the first one.
+type ArticleListByAuthorCursor struct {
+	Created time.Time
+	ID int64
+}
+
+// cacheKey identifies the page in list cache keys.
+func (c *ArticleListByAuthorCursor) cacheKey() string {
+	if c == nil {
+		return "first"
+	}
+	return fmt.Sprintf("%v:%d", c.Created.UnixNano(), c.ID)
+}
+
+// newArticleListByAuthorCursor returns the cursor of the page following entities, or nil if it was the last page.
+func newArticleListByAuthorCursor(entities []*Article, pageSize int) *ArticleListByAuthorCursor {
+	if len(entities) == 0 || len(entities) < pageSize {
+		return nil
+	}
+
+	last := entities[len(entities)-1]
+	return &ArticleListByAuthorCursor{Created: last.Created, ID: last.ID}
+}
+
+// ListByAuthor returns a page of Articles ordered by created descending, starting after cursor,
+// and the cursor of the next page, which is nil once the last page is reached.
+func (d *articleRepository) ListByAuthor(ctx context.Context, authorId int64, cursor *ArticleListByAuthorCursor, pageSize int) ([]*Article, *ArticleListByAuthorCursor, error) {
+	entities, err := d.listByAuthorWithCache(ctx, authorId, cursor, pageSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entities, newArticleListByAuthorCursor(entities, pageSize), nil
+}
+
+func (d *articleRepository) listByAuthorWithCache(ctx context.Context, authorId int64, cursor *ArticleListByAuthorCursor, pageSize int) ([]*Article, error) {
+    if d.configProvider.BlockedReads("article") {
+        return nil, ErrOperationBlocked
+    }
+
+	const operation = "list_by_author"
+	d.telemetryProvider.IncDALOperation("article", operation)
+
+    cacheKey := fmt.Sprintf("article_list_by_author:%v:%s:%d", authorId, cursor.cacheKey(), pageSize)
+    // Transactions bypass caches to see their own writes.
+    val, found := d.listCache.Get(cacheKey)
+    if found && !inTx(ctx) {
+        entityIDs, ok := val.([]int64)
+        if !ok {
+            return nil, fmt.Errorf("articleRepository.ListByAuthor: Cache returned wrong type; expected array ID type")
+        }
+
+        var entities []*Article
+        missingEntries := false
+        for _, id := range entityIDs {
+            entity, err := d.getByIDCached(id)
+            if err != nil {
+                // failed using cache
+                return nil, err
+            }
+
+            if entity == nil {
+                // we have one or more missing entity in cache somehow. reload the whole list.
+                missingEntries = true
+                break
+            }
+
+            entities = append(entities, entity)
+        }
+
+        if !missingEntries {
+            d.telemetryProvider.IncCacheHit("article", operation)        
+            return entities, nil
+        }
+    }
+
+    // Otherwise, it's a cache miss or decode error
+     d.telemetryProvider.IncCacheMiss("article", operation)       
+
+    // 2) Fallback to DB
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+        return d.listByAuthor(ctx, authorId, cursor, pageSize)
+    })
+
+    if err != nil {
+        // DB also failed
+        return nil, err
+    }
+
+    entities := result.([]*Article)
+    if inTx(ctx) {
+        return entities, nil
+    }
+
+    // Store Cache. cacheKey = user_list_by_id:startID:pageSize.
+	// Stored is array of entity IDs.
+	var entityIDs []int64
+	for _, entity := range entities {
+		entityIDs = append(entityIDs, entity.ID)
+        // cache each entity individualy
+        d.setCached(entity)
+	}
+	d.listCache.Set(cacheKey, entityIDs, time.Second*60)
+
+    return entities, nil
+}
+
+func (d *articleRepository) listByAuthor(ctx context.Context, authorId int64, cursor *ArticleListByAuthorCursor, pageSize int) ([]*Article, error) {
+    const operation = "list_by_author"
+	dbStart := time.Now()
+
+    var query string
+
+    // the first page query is different for pagination
+    if cursor == nil {
+        query = `SELECT id, version, attributes, author_id, body, published_at, rating, slug, title, uid, created, updated, deleted_at FROM articles WHERE (author_id = ?) AND deleted_at IS NULL ORDER BY created DESC, id DESC LIMIT ?`
+    } else {
+        query = `SELECT id, version, attributes, author_id, body, published_at, rating, slug, title, uid, created, updated, deleted_at FROM articles WHERE (author_id = ?) AND deleted_at IS NULL AND (created, id) < (?, ?) ORDER BY created DESC, id DESC LIMIT ?`
+    }
+    query = rebindPostgres(query)
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "article", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("article", operation)
+        return nil, dbErr
+    }
+
+    var rows *sql.Rows
+    var err error
+
+    if cursor == nil {
+	    rows, err = db.QueryContext(ctx, query, authorId, pageSize)
+    } else {
+        rows, err = db.QueryContext(ctx, query, authorId, cursor.Created, cursor.ID, pageSize)
+    }
+
+	if err != nil {
+        d.telemetryProvider.IncDBError("article", operation)
+		return nil, fmt.Errorf("failed to query articles: %w", err)
+	}
+	defer rows.Close()
+
+	var entities []*Article
+	for rows.Next() {
+		var entity Article
+		err := rows.Scan(
+			&entity.ID,
+            &entity.Version,
+            &entity.Attributes,
+            &entity.AuthorId,
+            &entity.Body,
+            &entity.PublishedAt,
+            &entity.Rating,
+            &entity.Slug,
+            &entity.Title,
+            &entity.Uid,
+            &entity.Created,
+            &entity.Updated,
+            &entity.DeletedAt,
+		)
+		if err != nil {
+            d.telemetryProvider.IncDBError("article", operation)
+			return nil, fmt.Errorf("failed to scan Article: %w", err)
+		}
+		entities = append(entities, &entity)
+	}
+
+	if err = rows.Err(); err != nil {
+        d.telemetryProvider.IncDBError("article", operation)
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+    d.telemetryProvider.IncDBRequest("article", operation)	
+    d.telemetryProvider.ObserveDBLatency("article", operation, time.Since(dbStart).Seconds())	
+	return entities, nil
+}
+
+
+
+// Count function for the specific list
+func (d *articleRepository) CountListByAuthor(ctx context.Context, authorId int64) (int64, error) {
+	if d.configProvider.BlockedReads("article") {
+		return 0, ErrOperationBlocked
+	}
+
+	const operation = "count_list_by_author"
+	d.telemetryProvider.IncDALOperation("article", operation)
+	
+	cacheKey := fmt.Sprintf("article_count_list_by_author:%v", authorId)
+	// Transactions bypass caches to see their own writes.
+	val, found := d.countCache.Get(cacheKey)
+	if found && !inTx(ctx) {
+		count, ok := val.(int64)
+		if !ok {
+			return 0, fmt.Errorf("articleRepository.CountListByAuthor: Cache returned wrong type; expected int64")
+		}
+
+		d.telemetryProvider.IncCacheHit("article", operation)        
+		return count, nil
+	}
+
+	// Otherwise, it's a cache miss or decode error
+	 d.telemetryProvider.IncCacheMiss("article", operation)       
+
+	// 2) Fallback to DB
+	count, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return d.countListByAuthor(ctx, authorId)
+	})
+
+	if err != nil {
+		// DB also failed
+		return 0, err
+	}
+
+	if !inTx(ctx) {
+		d.countCache.Set(cacheKey, count, time.Second*60)
+	}
+
+	return count.(int64), nil
+}
+
+func (d *articleRepository) countListByAuthor(ctx context.Context, authorId int64) (int64, error) {
+	const operation = "count_list_by_author"
+	dbStart := time.Now()
+
+	// if startID is zero then query is different for pagination
+	query := `SELECT count(*) FROM articles WHERE (author_id = ?) AND deleted_at IS NULL`
+    query = rebindPostgres(query)
+
+	db, dbErr := databaseFor(ctx, d.dbProvider, "article", false)
+	if dbErr != nil {
+		d.telemetryProvider.IncDBError("article", operation)
+		return 0, dbErr
+	}
+
+	var err error
+	var count int64
+
+	row := db.QueryRowContext(ctx, query, authorId)
+
+	err = row.Scan(
+		&count,
+	)
+
+	if err != nil {
+		d.telemetryProvider.IncDBError("article", operation)
+		return 0, fmt.Errorf("failed to query articles: %w", err)
+	}
+
+	d.telemetryProvider.IncDBRequest("article", operation)	
+	d.telemetryProvider.ObserveDBLatency("article", operation, time.Since(dbStart).Seconds())	
+	return count, nil
+}
+
+
+
+
+// IterateAll walks every Article in ID order, a batch at a time, on the read database. It bypasses the
+// caches and leaves them untouched, so backfills and exports don't evict hot entries.
+func (d *articleRepository) IterateAll(ctx context.Context, opts IterateOptions) iter.Seq2[*Article, error] {
+    return iterateBatches(ctx, opts, func(after *Article, batchSize int) ([]*Article, error) {
+        if d.configProvider.BlockedReads("article") {
+            return nil, ErrOperationBlocked
+        }
+        d.telemetryProvider.IncDALOperation("article", "iterate_all")
+
+        startID := opts.StartAfterID
+        if after != nil {
+            startID = after.ID
+        }
+
+        result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+            return d.iterateAll(ctx, startID, batchSize)
+        })
+        if err != nil {
+            return nil, err
+        }
+        return result.([]*Article), nil
+    })
+}
+
+func (d *articleRepository) iterateAll(ctx context.Context, startID int64, batchSize int) ([]*Article, error) {
+    const operation = "iterate_all"
+    dbStart := time.Now()
+
+    query := `SELECT id, version, attributes, author_id, body, published_at, rating, slug, title, uid, created, updated, deleted_at FROM articles WHERE deleted_at IS NULL AND id > ? ORDER BY id LIMIT ?`
+    query = rebindPostgres(query)
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "article", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("article", operation)
+        return nil, dbErr
+    }
+
+    rows, err := db.QueryContext(ctx, query, startID, batchSize)
+    if err != nil {
+        d.telemetryProvider.IncDBError("article", operation)
+        return nil, fmt.Errorf("failed to iterate articles: %w", err)
+    }
+    defer rows.Close()
+
+    var entities []*Article
+    for rows.Next() {
+        var entity Article
+        err := rows.Scan(
+            &entity.ID,
+            &entity.Version,
+            &entity.Attributes,
+            &entity.AuthorId,
+            &entity.Body,
+            &entity.PublishedAt,
+            &entity.Rating,
+            &entity.Slug,
+            &entity.Title,
+            &entity.Uid,
+            &entity.Created,
+            &entity.Updated,
+            &entity.DeletedAt,
+        )
+        if err != nil {
+            d.telemetryProvider.IncDBError("article", operation)
+            return nil, fmt.Errorf("failed to scan Article: %w", err)
+        }
+        entities = append(entities, &entity)
+    }
+
+    if err = rows.Err(); err != nil {
+        d.telemetryProvider.IncDBError("article", operation)
+        return nil, fmt.Errorf("rows error: %w", err)
+    }
+
+    d.telemetryProvider.IncDBRequest("article", operation)
+    d.telemetryProvider.ObserveDBLatency("article", operation, time.Since(dbStart).Seconds())
+    return entities, nil
+}
+
+
+
+// IterateByListByAuthor walks every Article of ListByAuthor in its order, a batch at a time, on the read
+// database. It bypasses the caches and leaves them untouched, so backfills and exports don't evict hot entries.
+func (d *articleRepository) IterateByListByAuthor(ctx context.Context, authorId int64, opts IterateOptions) iter.Seq2[*Article, error] {
+    return iterateBatches(ctx, opts, func(after *Article, batchSize int) ([]*Article, error) {
+        if d.configProvider.BlockedReads("article") {
+            return nil, ErrOperationBlocked
+        }
+        d.telemetryProvider.IncDALOperation("article", "iterate_by_list_by_author")
+
+        var cursor *ArticleListByAuthorCursor
+        if after != nil {
+            cursor = newArticleListByAuthorCursor([]*Article{after}, 1)
+        } else if opts.StartAfterID != 0 {
+            // Continue after the position of the entity in the list
+            entity, err := d.getByID(ctx, opts.StartAfterID)
+            if err != nil {
+                return nil, fmt.Errorf("failed to resume ListByAuthor after Article %d: %w", opts.StartAfterID, err)
+            }
+            cursor = newArticleListByAuthorCursor([]*Article{entity}, 1)
+        }
+
+        result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+            return d.listByAuthor(ctx, authorId, cursor, batchSize)
+        })
+        if err != nil {
+            return nil, err
+        }
+        return result.([]*Article), nil
+    })
+}
+
+
+
+func (d *articleRepository) GetByUids(ctx context.Context, uids []string) ([]*Article, error) {
+    if d.configProvider.BlockedReads("article") {
+        return nil, ErrOperationBlocked
+    }
+
+    if len(uids) == 0 {
+        return nil, nil // Nothing to fetch
+    }
+    
+    // Hard limit to prevent massive memory allocations and driver crashes
+    if len(uids) > 5000 {
+        return nil, fmt.Errorf("bulk get operation exceeds maximum limit of 5000 items")
+    }
+
+    const operation = "get_bulk_by_uid"
+    d.telemetryProvider.IncDALOperation("article", operation)
+
+    var results []*Article
+    var missingKeys []interface{}
+
+    // 1. Check local in-memory cache for each key. Transactions bypass caches to see their own writes.
+    for _, key := range uids {
+        if inTx(ctx) {
+            missingKeys = append(missingKeys, key)
+            continue
+        }
+        cacheKey := fmt.Sprintf("article_uid:%v", key)
+        val, found := d.cache.Get(cacheKey)
+        if found {
+            entityId, ok := val.(int64)
+            if ok {
+                entity, _ := d.getByIDCached(entityId)
+                if entity != nil {
+                    results = append(results, entity)
+                    continue
+                }
+            }
+        }
+        missingKeys = append(missingKeys, key)
+    }
+
+    if len(missingKeys) == 0 {
+        d.telemetryProvider.IncCacheHit("article", operation)
+        return results, nil
+    }
+
+    d.telemetryProvider.IncCacheMiss("article", operation)
+
+    // 2. Fetch cache misses from DB via Circuit Breaker in safe chunks
+    var dbEntities []*Article
+    batchSize := 500
+    
+    for i := 0; i < len(missingKeys); i += batchSize {
+        end := i + batchSize
+        if end > len(missingKeys) {
+            end = len(missingKeys)
+        }
+        chunk := missingKeys[i:end]
+
+        dbResult, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+            return d.getByUids(ctx, chunk)
+        })
+
+        if err != nil {
+            return nil, err // Fail fast if a chunk fails
+        }
+        
+        dbEntities = append(dbEntities, dbResult.([]*Article)...)
+    }
+
+    results = append(results, dbEntities...)
+
+    if inTx(ctx) {
+        return results, nil
+    }
+
+    // 3. Cache the newly fetched items for future zero-latency access
+    for _, entity := range dbEntities {
+        d.setCached(entity)
+        cacheMappingKey := fmt.Sprintf("article_uid:%v", entity.Uid)
+        d.cache.Set(cacheMappingKey, entity.ID, time.Second*300)
+    }
+
+    return results, nil
+}
+
+func (d *articleRepository) getByUids(ctx context.Context, missingKeys []interface{}) ([]*Article, error) {
+    const operation = "get_bulk_by_uid"
+    dbStart := time.Now()
+
+    // Dynamically build ?,?,? placeholders for the IN clause
+    placeholders := make([]string, len(missingKeys))
+    for i := range missingKeys {
+        placeholders[i] = "?"
+    }
+
+    query := fmt.Sprintf(`
+        SELECT id, version, attributes, author_id, body, published_at, rating, slug, title, uid, created, updated, deleted_at
+        FROM articles
+        WHERE uid IN (%s) AND deleted_at IS NULL
+    `, strings.Join(placeholders, ","))
+    query = rebindPostgres(query)
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "article", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("article", operation)
+        return nil, dbErr
+    }
+
+    rows, err := db.QueryContext(ctx, query, missingKeys...)
+    if err != nil {
+        d.telemetryProvider.IncDBError("article", operation)
+        return nil, fmt.Errorf("failed to bulk query articles: %w", err)
+    }
+    defer rows.Close()
+
+    var entities []*Article
+    for rows.Next() {
+        var entity Article
+        err := rows.Scan(
+            &entity.ID,
+            &entity.Version,
+            &entity.Attributes,
+            &entity.AuthorId,
+            &entity.Body,
+            &entity.PublishedAt,
+            &entity.Rating,
+            &entity.Slug,
+            &entity.Title,
+            &entity.Uid,
+            &entity.Created,
+            &entity.Updated,
+            &entity.DeletedAt,
+        )
+        if err != nil {
+            d.telemetryProvider.IncDBError("article", operation)
+            return nil, fmt.Errorf("failed to scan Article: %w", err)
+        }
+        entities = append(entities, &entity)
+    }
+
+    d.telemetryProvider.IncDBRequest("article", operation)
+    d.telemetryProvider.ObserveDBLatency("article", operation, time.Since(dbStart).Seconds())
+
+    return entities, nil
+}
+
+
+
+// UpdateRatingByUids executes a bulk partial update using an IN clause.
+//
+// ⚠️ CAUTION - CACHE FLUSH NOTE ⚠️
+// Because bulk updates modify multiple records simultaneously, it is difficult to 
+// track and selectively invalidate individual cache entries safely. Therefore, if 
+// this operation successfully updates one or more rows, it will trigger a global 
+// FlushAllCache() to ensure local memory safety and broadcast cache invalidation 
+// to peer nodes via Pub/Sub. Use this operation carefully in high-read environments.
+//
+// This operation is hard-limited to 5000 items and executes in database batches of 500.
+func (d *articleRepository) UpdateRatingByUids(ctx context.Context, rating float64, uids []string) error {
+    if d.configProvider.BlockedWrites("article") {
+        return ErrOperationBlocked
+    }
+
+    if len(uids) == 0 {
+        return nil // Nothing to update
+    }
+    
+    // Hard limit to prevent massive memory allocations and driver crashes
+    if len(uids) > 5000 {
+        return fmt.Errorf("bulk update operation exceeds maximum limit of 5000 items")
+    }
+
+    const operation = "update_bulk_update_rating_by_uids"
+    d.telemetryProvider.IncDALOperation("article", operation)
+
+    var totalRowsAffected int64
+    batchSize := 500
+    
+    for i := 0; i < len(uids); i += batchSize {
+        end := i + batchSize
+        if end > len(uids) {
+            end = len(uids)
+        }
+        chunk := uids[i:end]
+        
+        result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+            // Pass the SET columns, then the chunk
+            return d.updateRatingByUids(ctx, rating, chunk)
+        })
+
+        if err != nil {
+            return err // Fail fast if a chunk fails
+        }
+        totalRowsAffected += result.(int64)
+    }
+
+    if totalRowsAffected > 0 {
+        // Since many rows changed at once, we perform a global flush to ensure local 
+        // node safety and trigger pub/sub notifications for peer nodes.
+        afterCommit(ctx, d.FlushAllCache)
+    }
+
+    return nil
+}
+
+func (d *articleRepository) updateRatingByUids(ctx context.Context, rating float64, uids []string) (int64, error) {
+    const operation = "update_bulk_update_rating_by_uids"
+    dbStart := time.Now()
+    now := time.Now()
+
+    // 1. Dynamically build ?,?,? placeholders for the IN clause
+    placeholders := make([]string, len(uids))
+    for i := range uids {
+        placeholders[i] = "?"
+    }
+
+    query := fmt.Sprintf(`
+        UPDATE articles
+        SET
+            rating = ?,
+            updated = ?,
+            version = version + 1
+        WHERE uid IN (%s) AND deleted_at IS NULL
+    `, strings.Join(placeholders, ","))
+    query = rebindPostgres(query)
+
+    // 2. Build the flat arguments array (SET arguments first, then WHERE IN arguments)
+    args := make([]interface{}, 0, 1 + 1 + len(uids))
+    args = append(args, rating)
+    args = append(args, now) // the 'updated' timestamp
+
+    for _, val := range uids {
+        args = append(args, val)
+    }
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "article", true)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("article", operation)
+        return 0, dbErr
+    }
+
+    res, err := db.ExecContext(ctx, query, args...)
+    if err != nil {
+        d.telemetryProvider.IncDBError("article", operation)
+        return 0, fmt.Errorf("failed to execute %s: %w", operation, err)
+    }
+
+    rowsAffected, err := res.RowsAffected()
+    if err != nil {
+        d.telemetryProvider.IncDBError("article", operation)
+        return 0, fmt.Errorf("failed to get rows affected: %w", err)
+    }
+
+    d.telemetryProvider.IncDBRequest("article", operation)
+    d.telemetryProvider.ObserveDBLatency("article", operation, time.Since(dbStart).Seconds())
+
+    return rowsAffected, nil
+}
+
+
+
+func (d *articleRepository) ListByAuthors(ctx context.Context, authorIds []int64) ([]*Article, error) {
+    if d.configProvider.BlockedReads("article") {
+        return nil, ErrOperationBlocked
+    }
+
+    if len(authorIds) == 0 {
+        return nil, nil // Nothing to fetch
+    }
+    
+    if len(authorIds) > 5000 {
+        return nil, fmt.Errorf("bulk list operation exceeds maximum limit of 5000 items")
+    }
+
+    const operation = "list_bulk_list_by_authors"
+    d.telemetryProvider.IncDALOperation("article", operation)
+
+    var results []*Article
+    
+    batchSize := 500
+    
+    for i := 0; i < len(authorIds); i += batchSize {
+        end := i + batchSize
+        if end > len(authorIds) {
+            end = len(authorIds)
+        }
+        chunk := authorIds[i:end]
+
+        // Convert typed chunk to []interface{} for the variadic SQL args
+        args := make([]interface{}, len(chunk))
+        for j, v := range chunk {
+            args[j] = v
+        }
+
+        dbResult, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+            return d.listByAuthors(ctx, args)
+        })
+
+        if err != nil {
+            return nil, err // Fail fast if a chunk fails
+        }
+        
+        dbEntities := dbResult.([]*Article)
+        results = append(results, dbEntities...)
+
+        // Cache the newly fetched items. Transactions bypass caches to see their own writes.
+        if !inTx(ctx) {
+            for _, entity := range dbEntities {
+                d.setCached(entity)
+            }
+        }
+    }
+
+    return results, nil
+}
+
+func (d *articleRepository) listByAuthors(ctx context.Context, chunkArgs []interface{}) ([]*Article, error) {
+    const operation = "list_bulk_list_by_authors"
+    dbStart := time.Now()
+
+    // Dynamically build ?,?,? placeholders for the IN clause
+    placeholders := make([]string, len(chunkArgs))
+    for i := range chunkArgs {
+        placeholders[i] = "?"
+    }
+
+    query := fmt.Sprintf(`
+        SELECT id, version, attributes, author_id, body, published_at, rating, slug, title, uid, created, updated, deleted_at
+        FROM articles
+        WHERE author_id IN (%s) AND deleted_at IS NULL
+    `, strings.Join(placeholders, ","))
+    query = rebindPostgres(query)
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "article", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("article", operation)
+        return nil, dbErr
+    }
+
+    // Build the final arguments slice (Scalars first, then chunked IN values)
+    var queryArgs []interface{}
+    queryArgs = append(queryArgs, chunkArgs...)
+
+    rows, err := db.QueryContext(ctx, query, queryArgs...)
+    if err != nil {
+        d.telemetryProvider.IncDBError("article", operation)
+        return nil, fmt.Errorf("failed to bulk query articles: %w", err)
+    }
+    defer rows.Close()
+
+    var entities []*Article
+    for rows.Next() {
+        var entity Article
+        err := rows.Scan(
+            &entity.ID,
+            &entity.Version,
+            &entity.Attributes,
+            &entity.AuthorId,
+            &entity.Body,
+            &entity.PublishedAt,
+            &entity.Rating,
+            &entity.Slug,
+            &entity.Title,
+            &entity.Uid,
+            &entity.Created,
+            &entity.Updated,
+            &entity.DeletedAt,
+        )
+        if err != nil {
+            d.telemetryProvider.IncDBError("article", operation)
+            return nil, fmt.Errorf("failed to scan Article: %w", err)
+        }
+        entities = append(entities, &entity)
+    }
+
+    if err = rows.Err(); err != nil {
+        d.telemetryProvider.IncDBError("article", operation)
+        return nil, fmt.Errorf("rows error: %w", err)
+    }
+
+    d.telemetryProvider.IncDBRequest("article", operation)
+    d.telemetryProvider.ObserveDBLatency("article", operation, time.Since(dbStart).Seconds())
+
+    return entities, nil
+}
+
+
+
+func (d *articleRepository) GetSlugsByAuthor(ctx context.Context, authorId int64) ([]string, error) {
+    if d.configProvider.BlockedReads("article") {
+        return nil, ErrOperationBlocked
+    }
+
+    const operation = "pluck_get_slugs_by_author"
+    d.telemetryProvider.IncDALOperation("article", operation)
+
+    cacheKey := fmt.Sprintf("article_pluck_get_slugs_by_author:%v", authorId)
+    // Transactions bypass caches to see their own writes.
+    val, found := d.listCache.Get(cacheKey)
+    if found && !inTx(ctx) {
+        cachedSlice, ok := val.([]string)
+        if !ok {
+            return nil, fmt.Errorf("articleRepository.GetSlugsByAuthor: Cache returned wrong type")
+        }
+        d.telemetryProvider.IncCacheHit("article", operation)
+        return cachedSlice, nil
+    }
+
+    d.telemetryProvider.IncCacheMiss("article", operation)
+
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+        return d.getSlugsByAuthor(ctx, authorId)
+    })
+
+    if err != nil {
+        return nil, err
+    }
+
+    slicedResult := result.([]string)
+    
+    // Store in the shared listCache
+    if !inTx(ctx) {
+        d.listCache.Set(cacheKey, slicedResult, time.Second*time.Duration(60))
+    }
+
+    return slicedResult, nil
+}
+
+func (d *articleRepository) getSlugsByAuthor(ctx context.Context, authorId int64) ([]string, error) {
+    const operation = "pluck_get_slugs_by_author"
+    dbStart := time.Now()
+
+    query := `SELECT slug FROM articles WHERE (author_id = ?) AND deleted_at IS NULL`
+    query = rebindPostgres(query)
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "article", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("article", operation)
+        return nil, dbErr
+    }
+
+    
+    rows, err := db.QueryContext(ctx, query, authorId)
+    
+
+    if err != nil {
+        d.telemetryProvider.IncDBError("article", operation)
+        return nil, fmt.Errorf("failed to query pluck %s: %w", operation, err)
+    }
+    defer rows.Close()
+
+    var results []string
+    for rows.Next() {
+        var item string
+        if err := rows.Scan(&item); err != nil {
+            d.telemetryProvider.IncDBError("article", operation)
+            return nil, fmt.Errorf("failed to scan pluck item: %w", err)
+        }
+        results = append(results, item)
+    }
+
+    if err = rows.Err(); err != nil {
+        d.telemetryProvider.IncDBError("article", operation)
+        return nil, fmt.Errorf("rows error: %w", err)
+    }
+
+    d.telemetryProvider.IncDBRequest("article", operation)
+    d.telemetryProvider.ObserveDBLatency("article", operation, time.Since(dbStart).Seconds())
+
+    return results, nil
+}
+
+
+
+
+
+
+
+
+
+
+
+
+
+
+
+
+
+
+func (d *articleRepository) DeleteByAuthor(ctx context.Context, authorId int64, limit int) (int64, error) {
+    if d.configProvider.BlockedWrites("article") {
+        return 0, ErrOperationBlocked
+    }
+
+    // Clamp the limit to a safe range
+    if limit <= 0 || limit > 5000 {
+        limit = 5000
+    }
+
+    const operation = "delete_by_author"
+    d.telemetryProvider.IncDALOperation("article", operation)
+
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+        return d.deleteByAuthor(ctx, authorId, limit)
+    })
+
+    if err != nil {
+        return 0, err
+    }
+
+    rowsAffected := result.(int64)
+    
+    if rowsAffected > 0 {
+        afterCommit(ctx, d.FlushAllCache)
+    }
+
+    return rowsAffected, nil
+}
+
+func (d *articleRepository) deleteByAuthor(ctx context.Context, authorId int64, limit int) (int64, error) {
+    const operation = "delete_by_author"
+    dbStart := time.Now()
+
+    // Inject the sanitized limit directly into the SQL string
+    query := `UPDATE articles SET deleted_at = NOW(), updated = NOW(), version = version + 1, slug = slug || '-del-' || gen_random_uuid()::text, uid = uid || '-del-' || gen_random_uuid()::text WHERE id IN (SELECT id FROM articles WHERE (author_id = ?) AND deleted_at IS NULL` + fmt.Sprintf(" LIMIT %d)", limit)
+    query = rebindPostgres(query)
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "article", true)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("article", operation)
+        return 0, dbErr
+    }
+
+    
+    res, err := db.ExecContext(ctx, query, authorId)
+    
+    
+    if err != nil {
+        d.telemetryProvider.IncDBError("article", operation)
+        return 0, fmt.Errorf("failed to execute custom delete %s: %w", operation, err)
+    }
+
+    rowsAffected, err := res.RowsAffected()
+    if err != nil {
+        d.telemetryProvider.IncDBError("article", operation)
+        return 0, fmt.Errorf("failed to get rows affected: %w", err)
+    }
+
+    d.telemetryProvider.IncDBRequest("article", operation)
+    d.telemetryProvider.ObserveDBLatency("article", operation, time.Since(dbStart).Seconds())
+    
+    return rowsAffected, nil
+}
+
+
+// HardDeleteByAuthor executes a permanent custom bulk delete operation.
+// 
+// PERFORMANCE NOTE: To prevent database locks, undo-log bloat, and replication lag, 
+// this operation requires a limit parameter. If the provided limit is <= 0 or > 5000, 
+// it will be automatically clamped to 5000.
+// 
+// Example usage for bulk deletion:
+//
+//	batchSize := 5000
+//	for {
+//		rowsAffected, err := repo.HardDeleteByAuthor(ctx, /* args */, batchSize)
+//		if err != nil {
+//			// handle error appropriately
+//			break
+//		}
+//		if rowsAffected == 0 {
+//			break // All matching rows have been processed
+//		}
+//		time.Sleep(100 * time.Millisecond) // Yield database resources between batches
+//	}
+func (d *articleRepository) HardDeleteByAuthor(ctx context.Context, authorId int64, limit int) (int64, error) {
+    if d.configProvider.BlockedWrites("article") {
+        return 0, ErrOperationBlocked
+    }
+
+    // Clamp the limit to a safe range
+    if limit <= 0 || limit > 5000 {
+        limit = 5000
+    }
+
+    const operation = "hard_delete_by_author"
+    d.telemetryProvider.IncDALOperation("article", operation)
+
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+        return d.hardDeleteByAuthor(ctx, authorId, limit)
+    })
+
+    if err != nil {
+        return 0, err
+    }
+
+    rowsAffected := result.(int64)
+    
+    // Only flush caches if the database state actually changed
+    if rowsAffected > 0 {
+        afterCommit(ctx, d.FlushAllCache)
+    }
+
+    return rowsAffected, nil
+}
+
+func (d *articleRepository) hardDeleteByAuthor(ctx context.Context, authorId int64, limit int) (int64, error) {
+    const operation = "hard_delete_by_author"
+    dbStart := time.Now()
+
+    // Inject the sanitized limit directly into the SQL string
+    query := `DELETE FROM articles WHERE id IN (SELECT id FROM articles WHERE author_id = ?` + fmt.Sprintf(" LIMIT %d)", limit)
+    query = rebindPostgres(query)
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "article", true)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("article", operation)
+        return 0, dbErr
+    }
+
+    
+    res, err := db.ExecContext(ctx, query, authorId)
+    
+    
+    if err != nil {
+        d.telemetryProvider.IncDBError("article", operation)
+        return 0, fmt.Errorf("failed to execute custom hard delete %s: %w", operation, err)
+    }
+
+    rowsAffected, err := res.RowsAffected()
+    if err != nil {
+        d.telemetryProvider.IncDBError("article", operation)
+        return 0, fmt.Errorf("failed to get rows affected: %w", err)
+    }
+
+    d.telemetryProvider.IncDBRequest("article", operation)
+    d.telemetryProvider.ObserveDBLatency("article", operation, time.Since(dbStart).Seconds())
+    
+    return rowsAffected, nil
+}
+
+
+
@@ -0,0 +1,123 @@
+package dal
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func TestDecimal(t *testing.T) {
+	for input, expected := range map[string]string{
+		"12.50":   "12.5",
+		"+3":      "3",
+		"-0.050":  "-0.05",
+		"007.":    "7",
+		".5":      "0.5",
+		"-0.00":   "0",
+		"1234567": "1234567",
+	} {
+		d, err := NewDecimal(input)
+		if err != nil {
+			t.Errorf("NewDecimal(%q) failed: %v", input, err)
+		} else if d.String() != expected {
+			t.Errorf("NewDecimal(%q) is %s, expected %s", input, d, expected)
+		}
+	}
+	for _, input := range []string{"", ".", "-", "--1", "1e3", "1.2.3", "12,5", " 1"} {
+		if _, err := NewDecimal(input); err == nil {
+			t.Errorf("expected NewDecimal(%q) to fail", input)
+		}
+	}
+
+	if MustDecimal("12.50") != MustDecimal("12.5") || MustDecimal("0.0") != (Decimal{}) {
+		t.Error("expected equal numbers to be equal Decimals")
+	}
+	if MustDecimal("9.99").Cmp(MustDecimal("10")) != -1 {
+		t.Error("expected 9.99 to be less than 10")
+	}
+	if got := MustDecimal("12.5").StringFixed(2); got != "12.50" {
+		t.Errorf("expected 12.50, got %s", got)
+	}
+
+	// Exact arithmetic through big.Rat, rounded back to a scale
+	sum := new(big.Rat).Add(MustDecimal("0.1").Rat(), MustDecimal("0.2").Rat())
+	if NewDecimalFromRat(sum, 2) != MustDecimal("0.3") {
+		t.Errorf("expected 0.3, got %s", NewDecimalFromRat(sum, 2))
+	}
+	if got := NewDecimalFromRat(big.NewRat(-1, 8), 2); got != MustDecimal("-0.13") {
+		t.Errorf("expected halves to round away from zero, got %s", got)
+	}
+
+	d, _ := NewDecimal("-1234.5678")
+	if before, after := d.digits(); before != 4 || after != 4 {
+		t.Errorf("expected 4 digits before and after the decimal point, got %d and %d", before, after)
+	}
+	if before, after := MustDecimal("0.5").digits(); before != 0 || after != 1 {
+		t.Errorf("expected 0 digits before and 1 after the decimal point, got %d and %d", before, after)
+	}
+}
+
+func TestDecimal_Scan(t *testing.T) {
+	for _, src := range []interface{}{[]byte("12.50"), "12.5", float64(12.5)} {
+		var d Decimal
+		if err := d.Scan(src); err != nil || d != MustDecimal("12.5") {
+			t.Errorf("Scan(%#v) gave %s: %v", src, d, err)
+		}
+	}
+	var d Decimal
+	if err := d.Scan(int64(42)); err != nil || d != NewDecimalFromInt(42) {
+		t.Errorf("Scan(42) gave %s: %v", d, err)
+	}
+	if err := d.Scan(true); err == nil {
+		t.Error("expected scanning a bool to fail")
+	}
+	if value, err := MustDecimal("-3.10").Value(); err != nil || value != "-3.1" {
+		t.Errorf("expected -3.1, got %v: %v", value, err)
+	}
+}
+
+func TestDecimal_JSON(t *testing.T) {
+	type price struct {
+		Amount  Decimal  `json:"amount"`
+		Nothing *Decimal `json:"nothing"`
+	}
+	encoded, err := json.Marshal(price{Amount: MustDecimal("19.90")})
+	if err != nil || string(encoded) != `{"amount":"19.9","nothing":null}` {
+		t.Fatalf("unexpected encoding %s: %v", encoded, err)
+	}
+
+	var decoded price
+	if err := json.Unmarshal([]byte(`{"amount": 0.10, "nothing": "5"}`), &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if decoded.Amount != MustDecimal("0.1") || decoded.Nothing == nil || *decoded.Nothing != NewDecimalFromInt(5) {
+		t.Errorf("unexpected decoding %+v", decoded)
+	}
+	if err := json.Unmarshal([]byte(`{"amount": "ten"}`), &decoded); err == nil {
+		t.Error("expected decoding an invalid number to fail")
+	}
+}
+
+func TestDecimal_Gob(t *testing.T) {
+	type price struct {
+		Amount  Decimal
+		Zero    Decimal
+		Nothing *Decimal
+		Some    *Decimal
+	}
+	five := NewDecimalFromInt(5)
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(price{Amount: MustDecimal("-19.90"), Some: &five}); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	var decoded price
+	if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if decoded.Amount != MustDecimal("-19.9") || !decoded.Zero.IsZero() || decoded.Nothing != nil || decoded.Some == nil || *decoded.Some != five {
+		t.Errorf("unexpected decoding %+v", decoded)
+	}
+}
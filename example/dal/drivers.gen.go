@@ -0,0 +1,15 @@
+/*
+NOTE! This code is autogenerated.
+Don't manually change this code.
+*/
+package dal
+
+// Database drivers of the dialects the entities are generated for.
+import (
+	// registers "mysql"
+	_ "github.com/go-sql-driver/mysql"
+	// registers "pgx" for PostgreSQL
+	_ "github.com/jackc/pgx/v5/stdlib"
+	// registers "sqlite", pure Go
+	_ "modernc.org/sqlite"
+)
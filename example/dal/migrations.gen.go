@@ -0,0 +1,23 @@
+/*
+NOTE! This code is autogenerated.
+Don't manually change this code.
+*/
+package dal
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migrations returns the schema migration files generated by `dalforge migrate`.
+// Pass them to NewMigrator to apply them on startup.
+func Migrations() fs.FS {
+	sub, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
@@ -0,0 +1,30 @@
+CREATE TABLE articles (
+    id BIGSERIAL PRIMARY KEY,
+    version INT DEFAULT 0,
+    attributes JSONB,
+    author_id BIGINT NOT NULL,
+    body text NOT NULL,
+    published_at TIMESTAMP,
+    rating DOUBLE PRECISION NOT NULL,
+    slug VARCHAR(255) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    uid VARCHAR(255) NOT NULL,
+    
+    deleted_at TIMESTAMP NULL,
+    
+    created TIMESTAMP,
+    updated TIMESTAMP
+);
+
+-- Unique indexes as they serve Get operations returning single entity
+CREATE UNIQUE INDEX idx_articles_slug ON articles (slug);
+CREATE UNIQUE INDEX idx_articles_uid ON articles (uid);
+
+-- Indexes that serve all operations
+CREATE INDEX idx_articles_author_id ON articles (author_id);
+CREATE INDEX idx_articles_author_id_created ON articles (author_id, created);
+CREATE INDEX idx_articles_author_id_slug ON articles (author_id, slug);
+
+
+
+CREATE INDEX idx_articles_deleted_at ON articles (deleted_at);
@@ -0,0 +1,3 @@
+-- Rollback of article from v2 to v1
+DROP INDEX idx_articles_author_id_created_id;
+CREATE INDEX idx_articles_author_id_created ON articles (author_id, created);
@@ -0,0 +1,3 @@
+-- Migration of article from v1 to v2
+DROP INDEX idx_articles_author_id_created;
+CREATE INDEX idx_articles_author_id_created_id ON articles (author_id, created, id);
@@ -0,0 +1,27 @@
+CREATE TABLE notes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    version INT DEFAULT 0,
+    body text NOT NULL,
+    meta TEXT,
+    owner_id BIGINT NOT NULL,
+    pinned BOOLEAN NOT NULL,
+    remind_at DATETIME,
+    slug VARCHAR(255) NOT NULL,
+    
+    deleted_at TIMESTAMP NULL,
+    
+    created TIMESTAMP,
+    updated TIMESTAMP
+);
+
+-- Unique indexes as they serve Get operations returning single entity
+CREATE UNIQUE INDEX idx_notes_slug ON notes (slug);
+
+-- Indexes that serve all operations
+CREATE INDEX idx_notes_owner_id ON notes (owner_id);
+CREATE INDEX idx_notes_owner_id_created ON notes (owner_id, created);
+CREATE INDEX idx_notes_owner_id_slug ON notes (owner_id, slug);
+
+
+
+CREATE INDEX idx_notes_deleted_at ON notes (deleted_at);
@@ -0,0 +1,3 @@
+-- Rollback of note from v2 to v1
+DROP INDEX idx_notes_owner_id_created_id;
+CREATE INDEX idx_notes_owner_id_created ON notes (owner_id, created);
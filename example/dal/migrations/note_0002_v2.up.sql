@@ -0,0 +1,3 @@
+-- Migration of note from v1 to v2
+DROP INDEX idx_notes_owner_id_created;
+CREATE INDEX idx_notes_owner_id_created_id ON notes (owner_id, created, id);
@@ -0,0 +1,2 @@
+-- Rollback of note from v3 to v2
+DROP INDEX idx_notes_owner_id_pinned;
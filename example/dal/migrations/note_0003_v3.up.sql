@@ -0,0 +1,2 @@
+-- Migration of note from v2 to v3
+CREATE INDEX idx_notes_owner_id_pinned ON notes (owner_id, pinned);
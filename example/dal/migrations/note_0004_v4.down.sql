@@ -0,0 +1,2 @@
+-- Rollback of note from v4 to v3
+DROP TABLE notes_history;
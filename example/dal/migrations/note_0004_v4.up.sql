@@ -0,0 +1,12 @@
+-- Migration of note from v3 to v4
+CREATE TABLE notes_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    entity_id BIGINT NOT NULL,
+    version INT NOT NULL,
+    operation VARCHAR(64) NOT NULL,
+    actor VARCHAR(255),
+    old_values TEXT,
+    new_values TEXT,
+    created TIMESTAMP NOT NULL
+);
+CREATE INDEX idx_notes_history_entity_id ON notes_history (entity_id, id);
@@ -0,0 +1,2 @@
+-- Rollback of note from v5 to v4
+
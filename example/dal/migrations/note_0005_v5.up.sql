@@ -0,0 +1,15 @@
+-- Migration of note from v4 to v5
+CREATE TABLE IF NOT EXISTS dal_outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    entity VARCHAR(64) NOT NULL,
+    entity_id BIGINT NOT NULL,
+    operation VARCHAR(64) NOT NULL,
+    version INT NOT NULL,
+    payload TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL,
+    delivered_at TIMESTAMP NULL,
+    created TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_dal_outbox_pending ON dal_outbox (delivered_at, next_attempt_at);
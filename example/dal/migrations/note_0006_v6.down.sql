@@ -0,0 +1,2 @@
+-- Rollback of note from v6 to v5
+ALTER TABLE notes DROP COLUMN visibility;
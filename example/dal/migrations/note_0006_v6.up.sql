@@ -0,0 +1,2 @@
+-- Migration of note from v5 to v6
+ALTER TABLE notes ADD COLUMN visibility VARCHAR(255) CHECK (visibility IN ('private', 'shared', 'public'));
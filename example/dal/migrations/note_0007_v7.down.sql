@@ -0,0 +1,3 @@
+-- Rollback of note from v7 to v6
+DROP INDEX idx_notes_owner_id_amount;
+ALTER TABLE notes DROP COLUMN amount;
@@ -0,0 +1,3 @@
+-- Migration of note from v6 to v7
+ALTER TABLE notes ADD COLUMN amount DECIMAL(10,2);
+CREATE INDEX idx_notes_owner_id_amount ON notes (owner_id, amount);
@@ -0,0 +1,3 @@
+# Rollback of post from v2 to v1
+DROP INDEX idx_deleted_target_age_created_id ON posts;
+CREATE INDEX idx_deleted_target_age_created ON posts (deleted, target_age, created);
@@ -0,0 +1,3 @@
+# Migration of post from v1 to v2
+DROP INDEX idx_deleted_target_age_created ON posts;
+CREATE INDEX idx_deleted_target_age_created_id ON posts (deleted, target_age, created, id);
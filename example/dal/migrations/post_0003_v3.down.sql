@@ -0,0 +1,2 @@
+# Rollback of post from v3 to v2
+DROP INDEX idx_ft_post ON posts;
@@ -0,0 +1,2 @@
+# Migration of post from v2 to v3
+CREATE FULLTEXT INDEX idx_ft_post ON posts (post);
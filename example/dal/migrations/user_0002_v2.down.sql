@@ -0,0 +1,7 @@
+# Rollback of user from v2 to v1
+DROP INDEX idx_age_created_id ON users;
+DROP INDEX idx_birthdate_id ON users;
+DROP INDEX idx_status_created_id ON users;
+CREATE INDEX idx_age_created ON users (age, created);
+CREATE INDEX idx_birthdate ON users (birthdate);
+CREATE INDEX idx_status_created ON users (status, created);
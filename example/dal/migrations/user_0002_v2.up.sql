@@ -0,0 +1,7 @@
+# Migration of user from v1 to v2
+DROP INDEX idx_age_created ON users;
+DROP INDEX idx_birthdate ON users;
+DROP INDEX idx_status_created ON users;
+CREATE INDEX idx_age_created_id ON users (age, created, id);
+CREATE INDEX idx_birthdate_id ON users (birthdate, id);
+CREATE INDEX idx_status_created_id ON users (status, created, id);
@@ -0,0 +1,2 @@
+# Rollback of user from v3 to v2
+DROP INDEX idx_status_age ON users;
@@ -0,0 +1,2 @@
+# Migration of user from v2 to v3
+CREATE INDEX idx_status_age ON users (status, age);
@@ -0,0 +1,2 @@
+# Rollback of user from v4 to v3
+DROP INDEX idx_age_status ON users;
@@ -0,0 +1,2 @@
+# Migration of user from v3 to v4
+CREATE INDEX idx_age_status ON users (age, status);
@@ -0,0 +1,2 @@
+# Rollback of user from v5 to v4
+DROP INDEX idx_created_id ON users;
@@ -0,0 +1,2 @@
+# Migration of user from v4 to v5
+CREATE INDEX idx_created_id ON users (created, id);
@@ -0,0 +1,2 @@
+# Rollback of user from v6 to v5
+DROP TABLE users_history;
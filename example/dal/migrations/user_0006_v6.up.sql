@@ -0,0 +1,12 @@
+# Migration of user from v5 to v6
+CREATE TABLE users_history (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    entity_id BIGINT NOT NULL,
+    version INT NOT NULL,
+    operation VARCHAR(64) NOT NULL,
+    actor VARCHAR(255),
+    old_values JSON,
+    new_values JSON,
+    created TIMESTAMP NOT NULL
+) ENGINE=InnoDB;
+CREATE INDEX idx_history_entity_id ON users_history (entity_id, id);
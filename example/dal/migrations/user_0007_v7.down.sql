@@ -0,0 +1,2 @@
+# Rollback of user from v7 to v6
+
@@ -0,0 +1,15 @@
+# Migration of user from v6 to v7
+CREATE TABLE IF NOT EXISTS dal_outbox (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    entity VARCHAR(64) NOT NULL,
+    entity_id BIGINT NOT NULL,
+    operation VARCHAR(64) NOT NULL,
+    version INT NOT NULL,
+    payload JSON NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL,
+    delivered_at TIMESTAMP NULL,
+    created TIMESTAMP NOT NULL,
+    INDEX idx_dal_outbox_pending (delivered_at, next_attempt_at)
+) ENGINE=InnoDB;
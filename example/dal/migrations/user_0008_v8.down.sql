@@ -0,0 +1,2 @@
+# Rollback of user from v8 to v7
+ALTER TABLE users MODIFY COLUMN status VARCHAR(255);
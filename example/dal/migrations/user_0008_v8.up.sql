@@ -0,0 +1,2 @@
+# Migration of user from v7 to v8
+ALTER TABLE users MODIFY COLUMN status ENUM('active', 'banned', 'pending', 'suspended', 'unknown');
@@ -0,0 +1,387 @@
+package dal
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	ErrMigrationChecksumMismatch = errors.New("applied migration was modified")
+	ErrMigrationLocked           = errors.New("could not acquire migration lock")
+)
+
+// migrationLockName is the advisory lock name guarding concurrent migrations.
+const migrationLockName = "dalforge_schema_migrations"
+
+// migrationFileRegexp matches up migration files generated by `dalforge migrate`, e.g. user_0002_v2.up.sql
+var migrationFileRegexp = regexp.MustCompile(`^(.+)_(\d+)_(v\d+)\.up\.sql$`)
+
+// Migrator applies migration files generated by `dalforge migrate` to every write database
+// of the entity they belong to, and records them in the schema_migrations table.
+type Migrator struct {
+	dbProvider  DBProvider
+	migrations  fs.FS
+	lockTimeout time.Duration
+}
+
+// migrationExecutor runs the statements of a migration, on the connection or in its transaction.
+type migrationExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+type migrationFile struct {
+	entity   string
+	number   int
+	name     string
+	sql      string
+	checksum string
+}
+
+// NewMigrator creates a Migrator for the given migration files. Use the generated Migrations()
+// function to get the embedded files, e.g. NewMigrator(dbProvider, Migrations()).
+func NewMigrator(provider DBProvider, migrations fs.FS) *Migrator {
+	return &Migrator{
+		dbProvider:  provider,
+		migrations:  migrations,
+		lockTimeout: 60 * time.Second,
+	}
+}
+
+// SetLockTimeout overrides how long Migrate waits for another instance holding the migration lock.
+func (m *Migrator) SetLockTimeout(timeout time.Duration) {
+	m.lockTimeout = timeout
+}
+
+// Migrate applies all pending migrations in order. Only one process migrates a database at a time;
+// others wait for the advisory lock. If an already applied migration file was changed,
+// Migrate refuses to apply anything and returns ErrMigrationChecksumMismatch.
+func (m *Migrator) Migrate(ctx context.Context) error {
+	files, err := m.loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	// Group migrations per database while keeping the file order. Entities often share a database.
+	var dbs []*sql.DB
+	pending := make(map[*sql.DB][]migrationFile)
+	for _, file := range files {
+		entityDBs := m.dbProvider.AllDatabases(file.entity, "write")
+		if len(entityDBs) == 0 {
+			return fmt.Errorf("no write databases found for entity: %s", file.entity)
+		}
+		for _, db := range entityDBs {
+			if _, exists := pending[db]; !exists {
+				dbs = append(dbs, db)
+			}
+			pending[db] = append(pending[db], file)
+		}
+	}
+
+	for i, db := range dbs {
+		if err := m.migrateDatabase(ctx, db, pending[db]); err != nil {
+			return fmt.Errorf("failed migrating write database #%d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func (m *Migrator) loadMigrations() ([]migrationFile, error) {
+	entries, err := fs.ReadDir(m.migrations, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	var files []migrationFile
+	for _, entry := range entries {
+		match := migrationFileRegexp.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		content, err := fs.ReadFile(m.migrations, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		number, _ := strconv.Atoi(match[2])
+		sum := sha256.Sum256(content)
+		files = append(files, migrationFile{
+			entity:   match[1],
+			number:   number,
+			name:     entry.Name(),
+			sql:      string(content),
+			checksum: hex.EncodeToString(sum[:]),
+		})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].entity != files[j].entity {
+			return files[i].entity < files[j].entity
+		}
+		return files[i].number < files[j].number
+	})
+
+	return files, nil
+}
+
+func (m *Migrator) migrateDatabase(ctx context.Context, db *sql.DB, files []migrationFile) error {
+	// Advisory locks belong to a session, so everything runs on one dedicated connection.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	dialect := databaseDialect(db)
+	if err := m.lock(ctx, conn, dialect); err != nil {
+		return err
+	}
+	defer m.unlock(conn, dialect)
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			entity VARCHAR(255) NOT NULL,
+			version INT NOT NULL,
+			name VARCHAR(255) NOT NULL,
+			checksum CHAR(64) NOT NULL,
+			applied_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (entity, version)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	applied, err := m.appliedMigrations(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	// Verify everything before applying anything, a modified migration means the ledger can't be trusted.
+	for _, file := range files {
+		checksum, exists := applied[migrationKey(file.entity, file.number)]
+		if exists && checksum != file.checksum {
+			return fmt.Errorf("%w: %s", ErrMigrationChecksumMismatch, file.name)
+		}
+	}
+
+	for _, file := range files {
+		if _, exists := applied[migrationKey(file.entity, file.number)]; exists {
+			continue
+		}
+
+		// A table created by CreateTable before adopting the Migrator is taken as the initial migration.
+		if file.number == 1 {
+			exists, err := tableExists(ctx, conn, dialect, file.entity)
+			if err != nil {
+				return err
+			}
+			if exists {
+				if err := recordMigration(ctx, conn, dialect, file); err != nil {
+					return err
+				}
+				log.Infof("Migrator: recorded migration [%s] of the existing table [%ss]", file.name, file.entity)
+				continue
+			}
+		}
+
+		if err := applyMigration(ctx, conn, dialect, file); err != nil {
+			return err
+		}
+
+		log.Infof("Migrator: applied migration [%s]", file.name)
+	}
+
+	return nil
+}
+
+// applyMigration runs the statements of a migration file and records it in schema_migrations. PostgreSQL and
+// SQLite run DDL in transactions, so there a failing statement rolls back the whole file. MySQL commits every
+// DDL statement implicitly, a failed migration there has to be cleaned up by hand.
+func applyMigration(ctx context.Context, conn *sql.Conn, dialect string, file migrationFile) (err error) {
+	var exec migrationExecutor = conn
+	if dialect == "postgres" || dialect == "sqlite" {
+		tx, beginErr := conn.BeginTx(ctx, nil)
+		if beginErr != nil {
+			return fmt.Errorf("failed to begin migration %s: %w", file.name, beginErr)
+		}
+		defer func() {
+			if err != nil {
+				_ = tx.Rollback()
+				return
+			}
+			if err = tx.Commit(); err != nil {
+				err = fmt.Errorf("failed to commit migration %s: %w", file.name, err)
+			}
+		}()
+		exec = tx
+	}
+
+	for _, stmt := range splitSQLStatements(file.sql) {
+		if _, err := exec.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to apply migration %s, statement %q: %w", file.name, stmt, err)
+		}
+	}
+	return recordMigration(ctx, exec, dialect, file)
+}
+
+// recordMigration adds the migration file to schema_migrations.
+func recordMigration(ctx context.Context, exec migrationExecutor, dialect string, file migrationFile) error {
+	insert := "INSERT INTO schema_migrations (entity, version, name, checksum, applied_at) VALUES (?, ?, ?, ?, ?)"
+	if dialect == "postgres" {
+		insert = rebindPostgres(insert)
+	}
+	if _, err := exec.ExecContext(ctx, insert, file.entity, file.number, file.name, file.checksum, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to record migration %s: %w", file.name, err)
+	}
+	return nil
+}
+
+// lock takes the session level advisory lock, waiting at most lockTimeout for other migrators.
+func (m *Migrator) lock(ctx context.Context, conn *sql.Conn, dialect string) error {
+	switch dialect {
+	case "sqlite":
+		// SQLite has no advisory locks; the database file is owned by a single process.
+		return nil
+	case "postgres":
+		// PostgreSQL's blocking pg_advisory_lock has no timeout, so poll the non-blocking variant instead.
+		deadline := time.Now().Add(m.lockTimeout)
+		for {
+			var locked bool
+			err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", migrationLockName).Scan(&locked)
+			if err != nil {
+				return fmt.Errorf("failed to acquire migration lock: %w", err)
+			}
+			if locked {
+				return nil
+			}
+			if time.Now().After(deadline) {
+				return ErrMigrationLocked
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(500 * time.Millisecond):
+			}
+		}
+	default:
+		var locked sql.NullInt64
+		err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", migrationLockName, int(m.lockTimeout.Seconds())).Scan(&locked)
+		if err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		if !locked.Valid || locked.Int64 != 1 {
+			return ErrMigrationLocked
+		}
+		return nil
+	}
+}
+
+func (m *Migrator) unlock(conn *sql.Conn, dialect string) {
+	switch dialect {
+	case "sqlite":
+	case "postgres":
+		_, _ = conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock(hashtext($1))", migrationLockName)
+	default:
+		_, _ = conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", migrationLockName)
+	}
+}
+
+func (m *Migrator) appliedMigrations(ctx context.Context, conn *sql.Conn) (map[string]string, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT entity, version, checksum FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[string]string)
+	for rows.Next() {
+		var entity, checksum string
+		var number int
+		if err := rows.Scan(&entity, &number, &checksum); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations: %w", err)
+		}
+		applied[migrationKey(entity, number)] = checksum
+	}
+
+	return applied, rows.Err()
+}
+
+// tableExists reports whether the table of the entity exists.
+func tableExists(ctx context.Context, conn *sql.Conn, dialect string, entity string) (bool, error) {
+	var query string
+	switch dialect {
+	case "sqlite":
+		query = "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?"
+	case "postgres":
+		query = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = $1"
+	default:
+		query = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?"
+	}
+
+	var count int
+	if err := conn.QueryRowContext(ctx, query, entity+"s").Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check if table %ss exists: %w", entity, err)
+	}
+	return count > 0, nil
+}
+
+// databaseDialect returns "mysql", "postgres" or "sqlite" depending on the driver the connection was opened with.
+// It looks at the package of the driver type, so the DAL only imports the drivers of dialects it was generated for.
+func databaseDialect(db *sql.DB) string {
+	driverType := reflect.TypeOf(db.Driver())
+	if driverType.Kind() == reflect.Ptr {
+		driverType = driverType.Elem()
+	}
+	switch driverType.PkgPath() {
+	case "github.com/jackc/pgx/v5/stdlib":
+		return "postgres"
+	case "modernc.org/sqlite":
+		return "sqlite"
+	default:
+		return "mysql"
+	}
+}
+
+func migrationKey(entity string, number int) string {
+	return fmt.Sprintf("%s:%d", entity, number)
+}
+
+// splitSQLStatements strips comment lines from a schema or migration file and splits it into statements.
+func splitSQLStatements(schema string) []string {
+	// 1. Strip out comment lines completely BEFORE splitting by semicolons
+	var cleanLines []string
+	for _, line := range strings.Split(schema, "\n") {
+		trimmedLine := strings.TrimSpace(line)
+		// Skip empty lines and comment lines ('#' for MySQL, '--' for PostgreSQL and SQLite)
+		if trimmedLine == "" || strings.HasPrefix(trimmedLine, "#") || strings.HasPrefix(trimmedLine, "--") {
+			continue
+		}
+		cleanLines = append(cleanLines, line)
+	}
+
+	// 2. Re-join the clean lines, then split by semicolon
+	var statements []string
+	for _, stmt := range strings.Split(strings.Join(cleanLines, "\n"), ";") {
+		stmt = strings.TrimSpace(stmt)
+		if stmt != "" {
+			statements = append(statements, stmt)
+		}
+	}
+	return statements
+}
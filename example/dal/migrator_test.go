@@ -0,0 +1,101 @@
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// migratorDBProvider exposes the test connection as the single write database of every entity.
+type migratorDBProvider struct {
+	*TestDBProvider
+}
+
+func (p migratorDBProvider) AllDatabases(_ string, _ string) []*sql.DB {
+	return []*sql.DB{p.connection}
+}
+
+// mysqlMigrations returns the embedded migrations of the MySQL entities only, article and note
+// use other dialects.
+func mysqlMigrations(t *testing.T) fstest.MapFS {
+	entries, err := fs.ReadDir(Migrations(), ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	migrations := fstest.MapFS{}
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "user_") && !strings.HasPrefix(entry.Name(), "post_") {
+			continue
+		}
+		data, err := fs.ReadFile(Migrations(), entry.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		migrations[entry.Name()] = &fstest.MapFile{Data: data}
+	}
+	return migrations
+}
+
+func TestMigrator(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB(t)
+
+	ctx := context.Background()
+	db, err := dbProvider.GetDatabase("", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// user.sql was already applied by setupTestDB, start from a clean database. Besides users it
+	// creates the history table of the audited entity and the outbox table, which migrations create too.
+	if _, err := db.ExecContext(ctx, "DROP TABLE users, users_history, dal_outbox"); err != nil {
+		t.Fatal(err)
+	}
+
+	migrator := NewMigrator(migratorDBProvider{dbProvider}, mysqlMigrations(t))
+	if err := migrator.Migrate(ctx); err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+
+	var count int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM schema_migrations").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	// user_0001 to user_0008 and post_0001 to post_0003
+	if count != 11 {
+		t.Errorf("expected 11 applied migrations, got %d", count)
+	}
+
+	// Running again must be a no-op.
+	if err := migrator.Migrate(ctx); err != nil {
+		t.Fatalf("second Migrate failed: %v", err)
+	}
+
+	// An existing table, e.g. created by CreateTable, is taken as the initial migration.
+	if _, err := db.ExecContext(ctx, "DELETE FROM schema_migrations WHERE entity = 'user' AND version = 1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Migrate(ctx); err != nil {
+		t.Fatalf("Migrate of an existing table failed: %v", err)
+	}
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM schema_migrations").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 11 {
+		t.Errorf("expected the initial migration to be recorded again, got %d applied migrations", count)
+	}
+
+	// A modified applied migration is rejected.
+	modified := fstest.MapFS{
+		"user_0001_v1.up.sql": &fstest.MapFile{Data: []byte("CREATE TABLE users (id BIGINT);")},
+	}
+	err = NewMigrator(migratorDBProvider{dbProvider}, modified).Migrate(ctx)
+	if !errors.Is(err, ErrMigrationChecksumMismatch) {
+		t.Errorf("expected ErrMigrationChecksumMismatch, got %v", err)
+	}
+}
@@ -0,0 +1,4640 @@
+/*
+NOTE! This code is autogenerated.
+Don't manually change this code.
+Version [c26a0e3ea1c5a3dde3d010ba02f2941f39d4d59b89e0ebb6f6c40036a0ca564c]
+*/
+package dal
+
+import (
+    "context"
+    "encoding/gob"
+    "database/sql"
+    _ "embed"
+    "errors"
+    "iter"
+    "strings"
+    "sync/atomic"
+
+    "fmt"
+    "time"
+    
+	"encoding/json"
+	
+    "maps"
+    "regexp"
+
+    log "github.com/sirupsen/logrus"
+    "github.com/patrickmn/go-cache"
+    "github.com/sony/gobreaker"
+)
+
+func init() {
+	gob.Register(Note{})
+}
+
+// Struct representing Note. Do not use these structs directly across services as this struct carries db specific information.
+// recommendation: create higher level service layer structs that might look same or very similar instead.
+type Note struct {
+    ID        int64 `json:"id"` // Auto-incremented number
+    Version   int32 `json:"version"` // Only change this value directly in very specific cases.  
+    
+    Amount *Decimal `json:"amount"`
+    Body string `json:"body"`
+    Meta *json.RawMessage `json:"meta"`
+    OwnerId int64 `json:"owner_id"`
+    Pinned bool `json:"pinned"`
+    RemindAt *time.Time `json:"remind_at"`
+    Slug string `json:"slug"`
+    Visibility *NoteVisibility `json:"visibility"`
+
+    Created   time.Time `json:"created"`
+    Updated   time.Time `json:"updated"`
+
+    DeletedAt *time.Time `json:"deleted_at"`
+
+}
+
+// NoteVisibility is a value of the visibility column of notes. The database, Validate and JSON decoding
+// reject values other than its constants.
+type NoteVisibility string
+
+const (
+    NoteVisibilityPrivate NoteVisibility = "private"
+    NoteVisibilityShared NoteVisibility = "shared"
+    NoteVisibilityPublic NoteVisibility = "public"
+)
+
+// NoteVisibilityValues returns the valid NoteVisibility values in the order of the column definition.
+func NoteVisibilityValues() []NoteVisibility {
+    return []NoteVisibility{NoteVisibilityPrivate, NoteVisibilityShared, NoteVisibilityPublic}
+}
+
+// IsValid reports whether the value is one of the NoteVisibility constants.
+func (e NoteVisibility) IsValid() bool {
+    switch e {
+    case NoteVisibilityPrivate, NoteVisibilityShared, NoteVisibilityPublic:
+        return true
+    }
+    return false
+}
+
+// MarshalJSON encodes the value as a JSON string, failing for invalid values.
+func (e NoteVisibility) MarshalJSON() ([]byte, error) {
+    if !e.IsValid() {
+        return nil, fmt.Errorf("invalid NoteVisibility %q", string(e))
+    }
+    return json.Marshal(string(e))
+}
+
+// UnmarshalJSON decodes a JSON string, failing for values other than the NoteVisibility constants.
+func (e *NoteVisibility) UnmarshalJSON(data []byte) error {
+    var value string
+    if err := json.Unmarshal(data, &value); err != nil {
+        return err
+    }
+    if !NoteVisibility(value).IsValid() {
+        return fmt.Errorf("invalid NoteVisibility %q, expected one of %v", value, NoteVisibilityValues())
+    }
+    *e = NoteVisibility(value)
+    return nil
+}
+
+var noteSlugPattern = regexp.MustCompile("^[a-z0-9-]+$")
+
+// Validate checks the Note against the validation rules of its columns. It returns a *ValidationError
+// listing every field breaking one, or nil. Create, CreateBulk, Update and upserts call it once the before hooks ran.
+func (n *Note) Validate() error {
+    v := validator{entity: "note"}
+    validateNoteAmount(&v, n.Amount)
+    validateNoteBody(&v, n.Body)
+    validateNoteSlug(&v, n.Slug)
+    validateNoteVisibility(&v, n.Visibility)
+    return v.err()
+}
+
+// validateNotes validates the entities of CreateBulk, naming the first invalid one by its index.
+func validateNotes(entities []*Note) error {
+    for i, entity := range entities {
+        if err := entity.Validate(); err != nil {
+            return fmt.Errorf("entity %d: %w", i, err)
+        }
+    }
+    return nil
+}
+
+// validateNoteAmount checks amount values against the rules of their column.
+func validateNoteAmount(v *validator, amount *Decimal) {
+    if amount == nil {
+        return
+    }
+    v.decimal("amount", *amount, 10, 2)
+}
+
+// validateNoteBody checks body values against the rules of their column.
+func validateNoteBody(v *validator, body string) {
+    v.maxLength("body", body, 2000)
+}
+
+// validateNoteSlug checks slug values against the rules of their column.
+func validateNoteSlug(v *validator, slug string) {
+    v.maxLength("slug", slug, 64)
+    v.pattern("slug", slug, noteSlugPattern)
+}
+
+// validateNoteVisibility checks visibility values against the rules of their column.
+func validateNoteVisibility(v *validator, visibility *NoteVisibility) {
+    if visibility == nil {
+        return
+    }
+    if !visibility.IsValid() {
+        v.fail("visibility", "values", "must be one of %v", NoteVisibilityValues())
+    }
+}
+
+// NoteRepository defines the interface for the Note.
+// Use this interface in your services to easily mock the database.
+type NoteRepository interface {
+    CreateTable(ctx context.Context) error
+    GetByID(ctx context.Context, id int64) (*Note, error)
+
+    InvalidateCache(entity *Note)
+    FlushListCache()
+    FlushAllCache()
+    Create(ctx context.Context, entity *Note) (*Note, error)
+    
+    // CreateBulk performs a batch insert of multiple Note entities.
+    //
+    // CACHING & PERFORMANCE NOTE:
+    // This operation safely batches inserts into chunks of 500 to avoid exceeding the 
+    // database driver's maximum placeholder limits.
+    // 
+    // Because new items are being added to the database, this function will automatically 
+    // call FlushListCache() to ensure that any cached paginated lists or count queries 
+    // are invalidated and recalculated on their next request.
+    CreateBulk(ctx context.Context, entities []*Note) ([]*Note, error)
+    
+    Update(ctx context.Context, entity *Note) error
+    // UpsertBySlug inserts the entity, or overwrites body, pinned of the row with the same slug.
+    UpsertBySlug(ctx context.Context, entity *Note) (*Note, error)
+    // UpsertBulkBySlug upserts the entities in chunks and reports whether each was inserted, updated or unchanged.
+    UpsertBulkBySlug(ctx context.Context, entities []*Note) ([]UpsertOutcome, error)
+    // PatchBody updates only body of the entity with the given id and version.
+    PatchBody(ctx context.Context, id int64, version int32, body string) error
+    Delete(ctx context.Context, entity *Note) error
+    HardDelete(ctx context.Context, entity *Note) error
+    GetBySlug(ctx context.Context, slug string) (*Note, error)
+    // GetByIds executes a bulk get operation.
+    //
+    // PERFORMANCE & CACHING NOTE:
+    // This function is highly optimized for caching. It first checks the local in-memory 
+    // cache for every individual key. Only the missing keys (cache misses) are fetched 
+    // from the database in safe chunks of 500. The newly fetched items are then cached 
+    // automatically.
+    //
+    // To prevent massive memory allocations and driver crashes, this operation is 
+    // hard-limited to 5000 items per call.
+    GetByIds(ctx context.Context, ids []int64) ([]*Note, error)
+    // UpdatePinnedByIds executes a bulk partial update using an IN clause.
+    //
+    // ⚠️ CAUTION - CACHE FLUSH NOTE ⚠️
+    // Because bulk updates modify multiple records simultaneously, it is difficult to 
+    // track and selectively invalidate individual cache entries safely. Therefore, if 
+    // this operation successfully updates one or more rows, it will trigger a global 
+    // FlushAllCache() to ensure local memory safety and broadcast cache invalidation 
+    // to peer nodes via Pub/Sub. Use this operation carefully in high-read environments.
+    //
+    // This operation is hard-limited to 5000 items and executes in database batches of 500.
+    UpdatePinnedByIds(ctx context.Context, pinned bool, ids []int64) error
+    // UpdateAmountByIds executes a bulk partial update using an IN clause.
+    //
+    // ⚠️ CAUTION - CACHE FLUSH NOTE ⚠️
+    // Because bulk updates modify multiple records simultaneously, it is difficult to 
+    // track and selectively invalidate individual cache entries safely. Therefore, if 
+    // this operation successfully updates one or more rows, it will trigger a global 
+    // FlushAllCache() to ensure local memory safety and broadcast cache invalidation 
+    // to peer nodes via Pub/Sub. Use this operation carefully in high-read environments.
+    //
+    // This operation is hard-limited to 5000 items and executes in database batches of 500.
+    UpdateAmountByIds(ctx context.Context, amount *Decimal, ids []int64) error
+    // ListByOwners executes a bulk list operation using an IN clause on the owner_id column.
+    //
+    // PERFORMANCE & CACHING NOTE:
+    // To prevent massive memory allocations and database driver crashes, this operation 
+    // is hard-limited to 5000 items and executes database queries in safe batches of 500.
+    // 
+    // Unlike standard list operations, this function does NOT use the listCache because 
+    // the permutations of dynamic input slices are practically infinite. Instead, it queries the 
+    // database directly but aggressively warms the local single-item cache with the 
+    // results. Subsequent fetches for any individual Note returned by this query 
+    // will be instant zero-latency cache hits.
+    ListByOwners(ctx context.Context, ownerIds []int64) ([]*Note, error)
+    // GetSlugsByOwner fetches a list of slug values.
+    // CACHING NOTE: Uses the shared listCache for invalidation.
+    GetSlugsByOwner(ctx context.Context, ownerId int64) ([]string, error)
+    // GetSlugsByOwners fetches a list of slug values.
+    // CACHING NOTE: Uses the shared listCache for invalidation.
+    GetSlugsByOwners(ctx context.Context, ownerIds []int64) ([]string, error)
+    // MaxOwnerId returns the max of owner_id, 0 when no rows match.
+    // CACHING NOTE: Uses the countCache for invalidation.
+    MaxOwnerId(ctx context.Context) (float64, error)
+    // SumAmountByOwnerIdAndMinAmount returns the sum of amount, 0 when no rows match.
+    // CACHING NOTE: Uses the countCache for invalidation.
+    SumAmountByOwnerIdAndMinAmount(ctx context.Context, ownerId int64, minAmount *Decimal) (Decimal, error)
+    // ExistsByOwnerIdAndPinned reports whether a row where owner_id = :owner_id AND pinned = :pinned exists, without loading it.
+    ExistsByOwnerIdAndPinned(ctx context.Context, ownerId int64, pinned bool) (bool, error)
+    // CountByPinnedGrouped counts the entities per pinned value.
+    // CACHING NOTE: Uses the countCache for invalidation.
+    CountByPinnedGrouped(ctx context.Context, ownerId int64) (map[bool]int64, error)
+    // ListByOwner returns a page of entities ordered by created and the cursor of the next page, nil after the last one.
+    ListByOwner(ctx context.Context, ownerId int64, cursor *NoteListByOwnerCursor, pageSize int) ([]*Note, *NoteListByOwnerCursor, error)
+    // ListByOwnerPage returns a page with the opaque token of the next one; pass "" for the first page.
+    ListByOwnerPage(ctx context.Context, ownerId int64, pageToken string, pageSize int) (Page[Note], error)
+    CountListByOwner(ctx context.Context, ownerId int64) (int64, error)
+    // IterateByListByOwner walks the whole list in batches on the read database, bypassing the caches.
+    IterateByListByOwner(ctx context.Context, ownerId int64, opts IterateOptions) iter.Seq2[*Note, error]
+    ListForOwners(ctx context.Context, ownerIds []int64, startID int64, pageSize int) ([]*Note, error)
+    // ListForOwnersPage returns a page with the opaque token of the next one; pass "" for the first page.
+    ListForOwnersPage(ctx context.Context, ownerIds []int64, pageToken string, pageSize int) (Page[Note], error)
+    CountListForOwners(ctx context.Context, ownerIds []int64) (int64, error)
+    // IterateByListForOwners walks the whole list in batches on the read database, bypassing the caches.
+    IterateByListForOwners(ctx context.Context, ownerIds []int64, opts IterateOptions) iter.Seq2[*Note, error]
+    // Search returns a page of entities ordered by created and the cursor of the next page, nil after the last one.
+    Search(ctx context.Context, filter NoteSearchFilter, cursor *NoteSearchCursor, pageSize int) ([]*Note, *NoteSearchCursor, error)
+    // SearchPage returns a page with the opaque token of the next one; pass "" for the first page.
+    SearchPage(ctx context.Context, filter NoteSearchFilter, pageToken string, pageSize int) (Page[Note], error)
+    CountSearch(ctx context.Context, filter NoteSearchFilter) (int64, error)
+    // IterateBySearch walks the whole list in batches on the read database, bypassing the caches.
+    IterateBySearch(ctx context.Context, filter NoteSearchFilter, opts IterateOptions) iter.Seq2[*Note, error]
+    // IterateAll walks every entity in ID order in batches on the read database, bypassing the caches.
+    IterateAll(ctx context.Context, opts IterateOptions) iter.Seq2[*Note, error]
+    // History returns the recorded changes of the entity with the given id, oldest first.
+    History(ctx context.Context, id int64, startID int64, pageSize int) ([]*HistoryEntry, error)
+    // DeleteByOwner executes a custom bulk delete operation.
+    // 
+    // ⚠️ CAUTION - CACHE FLUSH NOTE ⚠️
+    // Because bulk deletions modify multiple records simultaneously, it is difficult to 
+    // track and selectively invalidate individual cache entries safely. Therefore, if 
+    // this operation successfully deletes one or more rows, it will trigger a global 
+    // FlushAllCache() to ensure local memory safety and broadcast cache invalidation 
+    // to peer nodes via Pub/Sub. Use this operation carefully in high-read environments.
+    //
+    // PERFORMANCE NOTE: To prevent database locks, undo-log bloat, and replication lag, 
+    // this operation requires a limit parameter. If the provided limit is <= 0 or > 5000, 
+    // it will be automatically clamped to 5000.
+    DeleteByOwner(ctx context.Context, ownerId int64, limit int) (int64, error)
+    
+    // HardDeleteByOwner executes a permanent custom bulk delete operation.
+    // 
+    // ⚠️ CAUTION - CACHE FLUSH NOTE ⚠️
+    // Because bulk deletions modify multiple records simultaneously, it is difficult to 
+    // track and selectively invalidate individual cache entries safely. Therefore, if 
+    // this operation successfully deletes one or more rows, it will trigger a global 
+    // FlushAllCache() to ensure local memory safety and broadcast cache invalidation 
+    // to peer nodes via Pub/Sub. Use this operation carefully in high-read environments.
+    //
+    // PERFORMANCE NOTE: To prevent database locks, undo-log bloat, and replication lag, 
+    // this operation requires a limit parameter. If the provided limit is <= 0 or > 5000, 
+    // it will be automatically clamped to 5000.
+    HardDeleteByOwner(ctx context.Context, ownerId int64, limit int) (int64, error)
+
+    // DeleteByOwners executes a custom bulk delete operation.
+    // 
+    // ⚠️ CAUTION - CACHE FLUSH NOTE ⚠️
+    // Because bulk deletions modify multiple records simultaneously, it is difficult to 
+    // track and selectively invalidate individual cache entries safely. Therefore, if 
+    // this operation successfully deletes one or more rows, it will trigger a global 
+    // FlushAllCache() to ensure local memory safety and broadcast cache invalidation 
+    // to peer nodes via Pub/Sub. Use this operation carefully in high-read environments.
+    //
+    // PERFORMANCE NOTE: To prevent database locks, undo-log bloat, and replication lag, 
+    // this operation requires a limit parameter. If the provided limit is <= 0 or > 5000, 
+    // it will be automatically clamped to 5000.
+    DeleteByOwners(ctx context.Context, ownerIds []int64, limit int) (int64, error)
+    
+    // HardDeleteByOwners executes a permanent custom bulk delete operation.
+    // 
+    // ⚠️ CAUTION - CACHE FLUSH NOTE ⚠️
+    // Because bulk deletions modify multiple records simultaneously, it is difficult to 
+    // track and selectively invalidate individual cache entries safely. Therefore, if 
+    // this operation successfully deletes one or more rows, it will trigger a global 
+    // FlushAllCache() to ensure local memory safety and broadcast cache invalidation 
+    // to peer nodes via Pub/Sub. Use this operation carefully in high-read environments.
+    //
+    // PERFORMANCE NOTE: To prevent database locks, undo-log bloat, and replication lag, 
+    // this operation requires a limit parameter. If the provided limit is <= 0 or > 5000, 
+    // it will be automatically clamped to 5000.
+    HardDeleteByOwners(ctx context.Context, ownerIds []int64, limit int) (int64, error)
+
+}
+
+type noteRepository struct {
+    dbProvider          DBProvider
+    cache               *cache.Cache  // Simple TTL cache for single items, for inmemory cache management
+  	listCache           *cache.Cache // Cache for lists. Simple TTL cache, for inmemory cache management
+    countCache          *cache.Cache // Cache for counts. Simple TTL cache, for inmemory cache management
+    cacheProvider       CacheProvider
+    configProvider      ConfigProvider
+	dbBreaker           *gobreaker.CircuitBreaker
+    telemetryProvider TelemetryProvider
+    listEpoch         atomic.Int64
+    hooks             noteHooks
+}
+
+
+// NoteBeforeCreate is a hook run by Create and upserts before inserting the Note. Returning an error vetoes the insert.
+type NoteBeforeCreate interface {
+    BeforeCreate(ctx context.Context, entity *Note) error
+}
+
+// NoteAfterCreate is a hook run by Create and upserts after inserting the Note. Returning an error rolls the insert back.
+type NoteAfterCreate interface {
+    AfterCreate(ctx context.Context, entity *Note) error
+}
+
+// NoteBeforeCreateBulk is a hook run by CreateBulk and bulk upserts before inserting the Notes. Returning an error vetoes the inserts.
+type NoteBeforeCreateBulk interface {
+    BeforeCreateBulk(ctx context.Context, entities []*Note) error
+}
+
+// NoteAfterCreateBulk is a hook run by CreateBulk and bulk upserts after inserting the Notes. Returning an error rolls the inserts back.
+type NoteAfterCreateBulk interface {
+    AfterCreateBulk(ctx context.Context, entities []*Note) error
+}
+
+// NoteBeforeUpdate is a hook run by Update, upserts and patches before storing the Note. Returning an error vetoes the update.
+type NoteBeforeUpdate interface {
+    BeforeUpdate(ctx context.Context, entity *Note) error
+}
+
+// NoteAfterUpdate is a hook run by Update and upserts after storing the Note. Returning an error rolls the update back.
+type NoteAfterUpdate interface {
+    AfterUpdate(ctx context.Context, entity *Note) error
+}
+
+// NoteBeforeDelete is a hook run by Delete and HardDelete before removing the Note. Returning an error vetoes the delete.
+type NoteBeforeDelete interface {
+    BeforeDelete(ctx context.Context, entity *Note) error
+}
+
+// NoteAfterDelete is a hook run by Delete and HardDelete after removing the Note. Returning an error rolls the delete back.
+type NoteAfterDelete interface {
+    AfterDelete(ctx context.Context, entity *Note) error
+}
+
+// noteHooks holds the hooks of a noteRepository per operation: the methods of Note
+// implementing the entity hook interfaces first, then the hooks passed to NewNoteRepository in order.
+type noteHooks struct {
+    beforeCreate     []hookFunc[*Note]
+    afterCreate      []hookFunc[*Note]
+    beforeCreateBulk []hookFunc[[]*Note]
+    afterCreateBulk  []hookFunc[[]*Note]
+    beforeUpdate     []hookFunc[*Note]
+    afterUpdate      []hookFunc[*Note]
+    beforeDelete     []hookFunc[*Note]
+    afterDelete      []hookFunc[*Note]
+}
+
+// newNoteHooks sorts hooks by the Note hook interfaces they implement. It panics on a hook
+// implementing none of them, which is a programming error.
+func newNoteHooks(hooks []interface{}) noteHooks {
+    var h noteHooks
+    h.beforeCreate = appendEntityHook(h.beforeCreate, BeforeCreateHook.BeforeCreate)
+    h.afterCreate = appendEntityHook(h.afterCreate, AfterCreateHook.AfterCreate)
+    h.beforeCreateBulk = appendEntityBulkHook(h.beforeCreateBulk, BeforeCreateHook.BeforeCreate)
+    h.afterCreateBulk = appendEntityBulkHook(h.afterCreateBulk, AfterCreateHook.AfterCreate)
+    h.beforeUpdate = appendEntityHook(h.beforeUpdate, BeforeUpdateHook.BeforeUpdate)
+    h.afterUpdate = appendEntityHook(h.afterUpdate, AfterUpdateHook.AfterUpdate)
+    h.beforeDelete = appendEntityHook(h.beforeDelete, BeforeDeleteHook.BeforeDelete)
+    h.afterDelete = appendEntityHook(h.afterDelete, AfterDeleteHook.AfterDelete)
+
+    for _, hook := range hooks {
+        registered := false
+        if hook, ok := hook.(NoteBeforeCreate); ok {
+            h.beforeCreate, registered = append(h.beforeCreate, hook.BeforeCreate), true
+        }
+        if hook, ok := hook.(NoteAfterCreate); ok {
+            h.afterCreate, registered = append(h.afterCreate, hook.AfterCreate), true
+        }
+        if hook, ok := hook.(NoteBeforeCreateBulk); ok {
+            h.beforeCreateBulk, registered = append(h.beforeCreateBulk, hook.BeforeCreateBulk), true
+        }
+        if hook, ok := hook.(NoteAfterCreateBulk); ok {
+            h.afterCreateBulk, registered = append(h.afterCreateBulk, hook.AfterCreateBulk), true
+        }
+        if hook, ok := hook.(NoteBeforeUpdate); ok {
+            h.beforeUpdate, registered = append(h.beforeUpdate, hook.BeforeUpdate), true
+        }
+        if hook, ok := hook.(NoteAfterUpdate); ok {
+            h.afterUpdate, registered = append(h.afterUpdate, hook.AfterUpdate), true
+        }
+        if hook, ok := hook.(NoteBeforeDelete); ok {
+            h.beforeDelete, registered = append(h.beforeDelete, hook.BeforeDelete), true
+        }
+        if hook, ok := hook.(NoteAfterDelete); ok {
+            h.afterDelete, registered = append(h.afterDelete, hook.AfterDelete), true
+        }
+        if !registered {
+            panic(fmt.Sprintf("NewNoteRepository: hook %T implements none of the Note hook interfaces", hook))
+        }
+    }
+    return h
+}
+
+// NewNoteRepository now returns the NoteRepository interface.
+// Each of hooks implements one or more of the NoteBefore* and NoteAfter* hook interfaces.
+func NewNoteRepository(
+    provider DBProvider, 
+    cacheProvider CacheProvider,
+    configProvider ConfigProvider, 
+    dbSettings gobreaker.Settings, 
+    telemetry TelemetryProvider,
+    hooks ...interface{}) NoteRepository {
+
+    if configProvider == nil {
+        configProvider = DefaultConfigProvider{}
+    }
+
+    if cacheProvider == nil {
+        cacheProvider = NoopCacheProvider{}
+    }
+
+    if telemetry == nil {
+		telemetry = NoopTelemetryProvider{}
+	}
+
+    if dbSettings.Name == "" {
+        dbSettings.Name = "note_dal"
+    }
+    if dbSettings.ReadyToTrip == nil {
+        dbSettings.ReadyToTrip = func(counts gobreaker.Counts) bool {
+            return counts.ConsecutiveFailures > 4
+        }
+    }
+    if dbSettings.OnStateChange == nil {
+		dbSettings.OnStateChange = OnCircuitBreakerStateChange
+	}
+
+    dbSettings.IsSuccessful = func(err error) bool {
+		if err == nil {
+			return true
+		}
+		// If the record just doesn't exist, the database is perfectly healthy
+		if errors.Is(err, ErrNotFound) {
+			return true
+		}
+		// Neither does a hook vetoing the operation or an invalid entity
+		var hookErr *HookError
+		var validationErr *ValidationError
+		if errors.As(err, &hookErr) || errors.As(err, &validationErr) {
+			return true
+		}
+		return false
+	}
+
+
+    if dbSettings.Timeout == 0 {
+        dbSettings.Timeout = time.Second * 20
+    }
+
+    // Default expiration is 5 minutes and cleanup of 10 minutes
+   	singleCache := cache.New(5*time.Minute, 10*time.Minute)
+	listCache := cache.New(5*time.Minute, 10*time.Minute)
+    countCache := cache.New(5*time.Minute, 10*time.Minute)
+
+    
+
+    newDAL := &noteRepository{
+        dbProvider:     provider,
+        cache:          singleCache,
+        listCache:      listCache,
+        countCache:     countCache,
+        cacheProvider:  cacheProvider,
+        configProvider: configProvider,
+		dbBreaker:      gobreaker.NewCircuitBreaker(dbSettings),
+        telemetryProvider: telemetry,
+        hooks:          newNoteHooks(hooks),
+    }
+
+    // Initialize the epoch and register the Pub/Sub handler
+    newDAL.listEpoch.Store(time.Now().UnixNano())
+    cacheProvider.OnBumpEpoch("note", newDAL.onBumpEpoch)
+
+    // initialize cache invalidation handler
+    cacheProvider.OnCacheInvalidated("note", newDAL.onCacheInvalidated)
+    cacheProvider.OnCacheFlushList("note", newDAL.onCacheFlushList)
+    cacheProvider.OnCacheFlushItem("note", newDAL.onCacheFlushItem)
+
+    return newDAL
+}
+
+func (d *noteRepository) getCacheKey(id int64) string {
+    return fmt.Sprintf("note_id:%d", id)
+}
+
+
+
+func (d *noteRepository) getEpoch() int64 {
+    return d.listEpoch.Load()
+}
+
+func (d *noteRepository) bumpEpoch() {
+    d.listEpoch.Store(time.Now().UnixNano())
+}
+
+func (d *noteRepository) onBumpEpoch() {
+    d.bumpEpoch()
+}
+
+func (d *noteRepository) InvalidateCache(entity *Note) {
+    cacheKey := d.getCacheKey(entity.ID)
+	d.cache.Delete(cacheKey)
+
+	// Invalidate cache entry across instances
+	d.cacheProvider.InvalidateCache("note", cacheKey)
+}
+
+func (d *noteRepository) FlushListCache() {
+    d.listCache.Flush()
+    d.countCache.Flush()
+    d.cacheProvider.FlushListCache("note")
+}
+
+// FlushAllCache clears both list/count caches AND single-item caches locally, 
+// and broadcasts the flush to other instances.
+func (d *noteRepository) FlushAllCache() {
+	d.cache.Flush()
+	d.listCache.Flush()
+	d.countCache.Flush()
+	d.cacheProvider.FlushListCache("note")
+	d.cacheProvider.FlushItemCache("note")
+}
+
+// Handles cache_flush_item. Just clears the single items cache locally.
+func (d *noteRepository) onCacheFlushItem() {
+	d.cache.Flush()
+}
+
+// Handles cache invalidations. Just remove cached entry by key
+func (d *noteRepository) onCacheInvalidated(key string) {
+    d.cache.Delete(key)
+}
+
+// Handles cache_flush_list. Just clears all lists cache.
+func (d *noteRepository) onCacheFlushList() {
+    d.listCache.Flush()
+    d.countCache.Flush()
+}
+
+
+
+
+//go:embed note.sql
+var schemaNote string
+
+func (d *noteRepository) CreateTable(ctx context.Context) error {
+	// Get write database connection
+	dbs := d.dbProvider.AllDatabases("note", "write")
+
+	for _, db := range dbs {
+		// First check if table exists
+		exists, err := d.tableExists(ctx, db)
+		if err != nil {
+			return fmt.Errorf("failed to check table existence: %w", err)
+		}
+		if exists {
+		    log.Infof("CreateTable: table [%s] already exist", "note")
+			return nil
+		}
+
+		// 1. Strip out comment lines completely BEFORE splitting by semicolons
+		var cleanLines []string
+		for _, line := range strings.Split(schemaNote, "\n") {
+			trimmedLine := strings.TrimSpace(line)
+			// Skip empty lines and comment lines ('#' for MySQL, '--' for PostgreSQL and SQLite)
+			if trimmedLine == "" || strings.HasPrefix(trimmedLine, "#") || strings.HasPrefix(trimmedLine, "--") {
+				continue
+			}
+			cleanLines = append(cleanLines, line)
+		}
+		
+		// 2. Re-join the clean lines, then split by semicolon
+		cleanSchema := strings.Join(cleanLines, "\n")
+		statements := strings.Split(cleanSchema, ";")
+
+		// 3. Execute the actual statements
+		for _, stmt := range statements {
+			stmt = strings.TrimSpace(stmt)
+			if stmt == "" {
+				continue
+			}
+
+			_, err = db.ExecContext(ctx, stmt)
+			if err != nil {
+				return fmt.Errorf("failed to execute SQL statement %q: %w", stmt, err)
+			}
+		}
+	}
+	log.Infof("CreateTable: table [%s] created", "note")
+
+	return nil
+}
+
+func (d *noteRepository) tableExists(ctx context.Context, db *sql.DB) (bool, error) {
+	query := `
+		SELECT 1
+		FROM sqlite_master
+		WHERE type = 'table'
+		AND name = 'notes'
+	`
+
+	var exists bool
+	err := db.QueryRowContext(ctx, query).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to query table existence: %w", err)
+	}
+
+	return exists, nil
+}
+
+
+
+
+func (d *noteRepository) Create(ctx context.Context, entity *Note) (*Note, error) {
+    if d.configProvider.BlockedWrites("note") {
+        return nil, ErrOperationBlocked
+    }
+
+	if entity.ID > 0 {
+		return nil, fmt.Errorf("noteRepository.Create failed as ID > 0")
+	}
+
+	d.telemetryProvider.IncDALOperation("note", "create")
+
+	created, updated := entity.Created, entity.Updated
+	_, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return nil, withHooks(ctx, "Create", entity, d.hooks.beforeCreate, d.hooks.afterCreate, (*Note).Validate, func(ctx context.Context) error {
+			_, err := d.create(ctx, entity)
+			return err
+		})
+	})
+
+	if err != nil {
+		entity.ID, entity.Created, entity.Updated = 0, created, updated // An after hook may have rolled the insert back
+		return nil, err
+	}
+
+	// Inside a transaction caches are only touched once it commits.
+	cached := *entity
+	afterCommit(ctx, func() {
+		d.setCached(&cached)
+
+		// All lists cache should be flushed.
+		d.FlushListCache()
+	})
+
+	return entity, err
+}
+
+func (d *noteRepository) create(ctx context.Context, entity *Note) (*Note, error) {
+	if !inTx(ctx) {
+		return withinTx(ctx, func(ctx context.Context) (*Note, error) { return d.create(ctx, entity) })
+	}
+	const operation = "create"
+	start := time.Now()
+
+	now := time.Now().Truncate(time.Second)
+	entity.Created = now
+	entity.Updated = now
+	
+	// Auto-generate UID fields if they are not provided
+
+	query := `
+		INSERT INTO notes (amount,body,meta,owner_id,pinned,remind_at,slug,visibility,
+created,updated)
+		VALUES (?,?,?,?,?,?,?,?,?,?)
+	`
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "note", true)
+    if dbErr != nil {
+		d.telemetryProvider.IncDBError("note", operation)		
+        return nil, dbErr
+    }
+
+	result, err := db.ExecContext(ctx, query,entity.Amount, entity.Body, entity.Meta, entity.OwnerId, entity.Pinned, entity.RemindAt, entity.Slug, entity.Visibility,
+		entity.Created, entity.Updated)
+
+	if err != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return nil, fmt.Errorf("failed to insert Note: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return nil, fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+
+	entity.ID = id	
+    if auditErr := d.recordChanges(ctx, db, operation, nil, "id = ?", entity.ID); auditErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, auditErr
+    }
+
+	d.telemetryProvider.IncDBRequest("note", operation)	
+	d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(start).Seconds())	
+	return entity, nil
+}
+
+
+
+func (d *noteRepository) CreateBulk(ctx context.Context, entities []*Note) ([]*Note, error) {
+	if d.configProvider.BlockedWrites("note") {
+		return nil, ErrOperationBlocked
+	}
+
+
+	const operation = "create_bulk"
+	d.telemetryProvider.IncDALOperation("note", operation)
+
+	var created []*Note
+	_, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return nil, withHooks(ctx, "CreateBulk", entities, d.hooks.beforeCreateBulk, d.hooks.afterCreateBulk, validateNotes, func(ctx context.Context) error {
+			var err error
+			created, err = d.createBulk(ctx, entities)
+			return err
+		})
+	})
+
+	if err != nil {
+		// All chunks share a transaction with their history rows, which the error rolled back.
+		// A retry must not find the IDs of the vanished rows.
+		for _, entity := range entities {
+			entity.ID = 0
+		}
+		return nil, err
+	}
+
+	// Inside a transaction caches are only touched once it commits.
+	cached := make([]Note, len(created))
+	for i, entity := range created {
+		cached[i] = *entity
+	}
+	afterCommit(ctx, func() {
+		for i := range cached {
+			d.setCached(&cached[i])
+		}
+
+		// All lists cache should be flushed.
+		d.FlushListCache()
+	})
+
+	return created, err
+}
+
+func (d *noteRepository) createBulk(ctx context.Context, entities []*Note) ([]*Note, error) {
+    if !inTx(ctx) {
+        return withinTx(ctx, func(ctx context.Context) ([]*Note, error) { return d.createBulk(ctx, entities) })
+    }
+    const operation = "create_bulk"
+    start := time.Now()
+
+    if len(entities) == 0 {
+        return nil, fmt.Errorf("empty entities list")
+    }
+
+    // Get the database connection once, outside the loop
+    db, dbErr := databaseFor(ctx, d.dbProvider, "note", true)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, dbErr
+    }
+
+    now := time.Now().Truncate(time.Second)
+    batchSize := 500 // Safe limit to prevent exceeding MySQL's max placeholders
+
+    // Process entities in chunks
+    for i := 0; i < len(entities); i += batchSize {
+        end := i + batchSize
+        if end > len(entities) {
+            end = len(entities)
+        }
+        
+        chunk := entities[i:end]
+
+        // Prepare data and parameters scoped ONLY to this chunk
+        valuePlaceholders := make([]string, 0, len(chunk))
+        // Dynamically calculate fields per entity: custom columns + 2 (created, updated)
+        params := make([]interface{}, 0, len(chunk)*(8 + 2)) 
+
+        for _, entity := range chunk {
+            if entity.ID > 0 {
+                return nil, fmt.Errorf("entity with existing ID in bulk create")
+            }
+
+            entity.Created = now
+            entity.Updated = now
+
+			// Auto-generate UID fields if they are not provided
+
+            valuePlaceholders = append(valuePlaceholders, "(?,?,?,?,?,?,?,?,?,?)")
+            params = append(params,
+                entity.Amount,
+                entity.Body,
+                entity.Meta,
+                entity.OwnerId,
+                entity.Pinned,
+                entity.RemindAt,
+                entity.Slug,
+                entity.Visibility,
+                entity.Created,
+                entity.Updated,
+            )
+        }
+
+        query := fmt.Sprintf(`
+            INSERT INTO notes
+            (amount,body,meta,owner_id,pinned,remind_at,slug,visibility,
+created,updated)
+            VALUES %s
+        `, strings.Join(valuePlaceholders, ","))
+
+        result, err := db.ExecContext(ctx, query, params...)
+        if err != nil {
+            d.telemetryProvider.IncDBError("note", operation)
+            return nil, fmt.Errorf("failed to bulk insert notes chunk: %w", err)
+        }
+
+        // Get generated IDs. MySQL reports the first ID of a multi row INSERT, SQLite the last one.
+        lastID, err := result.LastInsertId()
+        if err != nil {
+            return nil, fmt.Errorf("failed to get last insert ID for chunk: %w", err)
+        }
+
+        rowsAffected, err := result.RowsAffected()
+        if err != nil {
+            return nil, fmt.Errorf("failed to get rows affected for chunk: %w", err)
+        }
+
+        if rowsAffected != int64(len(chunk)) {
+            return nil, fmt.Errorf("mismatch between inserted rows and entity count in chunk")
+        }
+        firstID := lastID - rowsAffected + 1
+
+        // Assign generated IDs sequentially to the current chunk
+        for j := range chunk {
+            chunk[j].ID = firstID + int64(j)
+        }
+
+        ids := make([]int64, len(chunk))
+        for j, entity := range chunk {
+            ids[j] = entity.ID
+        }
+    if auditErr := d.recordChanges(ctx, db, operation, nil, "id IN (?...)", inValues(ids)); auditErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, auditErr
+    }
+    }
+
+    d.telemetryProvider.IncDBRequest("note", operation) 
+    d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(start).Seconds())    
+    return entities, nil
+}
+
+
+
+// ErrNotFound is returned in case there was nothing to update; either missing id or newer version of entity is already stored.
+func (d *noteRepository) Update(ctx context.Context, entity *Note) error {
+    if d.configProvider.BlockedWrites("note") {
+        return ErrOperationBlocked
+    }
+
+	const operation = "update"
+	d.telemetryProvider.IncDALOperation("note", operation)
+	// 1. Get existing entity to check for changes. Required for proper cache invalidation.
+	existing, err := d.GetByID(ctx, entity.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get existing Note, id: %v, err: %w", entity.ID, err)
+	}
+
+	// Perform the update in DB.
+	version := entity.Version
+	_, err2 := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return nil, withHooks(ctx, "Update", entity, d.hooks.beforeUpdate, d.hooks.afterUpdate, (*Note).Validate, func(ctx context.Context) error {
+			if err := d.update(ctx, entity); err != nil {
+				return err
+			}
+			entity.Version++
+			return nil
+		})
+	})
+
+	if err2 != nil {
+		entity.Version = version // An after hook may have rolled the update back
+		return err2
+	}
+
+	// Compared once the before hooks ran, as they may change the unique values.
+	
+	var oldSlug string
+	if existing.Slug != entity.Slug {
+		oldSlug = existing.Slug
+	}
+		
+
+	// Inside a transaction caches are only touched once it commits.
+	cached := *entity
+	afterCommit(ctx, func() {
+		
+	if oldSlug != "" {
+		oldCacheKey := fmt.Sprintf("note_slug:%s", oldSlug)
+		d.cache.Delete(oldCacheKey)
+		d.cacheProvider.InvalidateCache("note", oldCacheKey)
+	}
+
+		// Lets clear item from cache on other instances and here
+		d.InvalidateCache(&cached)
+		d.FlushListCache()
+
+		// our entity can become part of local cache
+		d.setCached(&cached)
+	})
+
+	return nil
+}
+
+func (d *noteRepository) update(ctx context.Context, entity *Note) error {
+	if !inTx(ctx) {
+		return RunInTx(ctx, func(ctx context.Context) error { return d.update(ctx, entity) })
+	}
+	const operation = "update"
+	start := time.Now()
+
+	entity.Updated = time.Now()
+
+	query := `
+		UPDATE notes
+		SET amount = ?,body = ?,meta = ?,owner_id = ?,pinned = ?,remind_at = ?,slug = ?,visibility = ?, updated=?, version = version + 1
+		WHERE id = ? AND version = ? AND deleted_at IS NULL
+	`
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "note", true)
+    if dbErr != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+        return dbErr
+    }
+	
+    // Snapshot the rows before the write to record its changes
+    before, auditErr := d.auditSnapshot(ctx, db, "id = ?", entity.ID)
+    if auditErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return auditErr
+    }
+
+
+	res, err := db.ExecContext(ctx, query,entity.Amount, entity.Body, entity.Meta, entity.OwnerId, entity.Pinned, entity.RemindAt, entity.Slug, entity.Visibility,
+	entity.Updated, entity.ID, entity.Version)
+
+	if err != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return fmt.Errorf("failed to update Note: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		// clean out cache for specific ID to limit stale cache entries
+		stale := *entity
+		afterCommit(ctx, func() { d.InvalidateCache(&stale) })
+		return ErrNotFound
+	}
+	
+    if auditErr := d.recordChanges(ctx, db, operation, before, "id = ?", entity.ID); auditErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return auditErr
+    }
+
+
+	d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(start).Seconds())	
+
+	return nil
+}
+
+
+
+// UpsertBySlug inserts the entity, or overwrites body, pinned of the Note
+// with the same slug, bumping its version. The returned entity holds the stored row.
+// Unlike Update it doesn't check the version, the last upsert wins. The Create or Update hooks run depending
+// on whether a Note with the slug was found.
+func (d *noteRepository) UpsertBySlug(ctx context.Context, entity *Note) (*Note, error) {
+    if d.configProvider.BlockedWrites("note") {
+        return nil, ErrOperationBlocked
+    }
+
+	const operation = "upsert_by_slug"
+	d.telemetryProvider.IncDALOperation("note", operation)
+
+	// 1. Get existing entity to check for changes. Required for proper cache invalidation.
+	existing, err := d.GetBySlug(ctx, entity.Slug)
+	if errors.Is(err, ErrNotFound) {
+		// Nothing is cached for a row that doesn't exist yet.
+		existing = entity
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get existing Note, slug: %v, err: %w", entity.Slug, err)
+	}
+
+	// The stored row decides between the Create and Update hooks.
+	hookName, beforeHooks, afterHooks := "Create", d.hooks.beforeCreate, d.hooks.afterCreate
+	if existing != entity {
+		hookName, beforeHooks, afterHooks = "Update", d.hooks.beforeUpdate, d.hooks.afterUpdate
+	}
+
+	_, err = executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return nil, withHooks(ctx, hookName, entity, beforeHooks, afterHooks, (*Note).Validate, func(ctx context.Context) error {
+			_, err := d.upsertBySlug(ctx, entity)
+			return err
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Compared with the stored row once the before hooks ran, as they may change the unique values.
+	
+	var oldSlug string
+	if existing.Slug != entity.Slug {
+		oldSlug = existing.Slug
+	}
+		
+
+	// Inside a transaction caches are only touched once it commits.
+	cached := *entity
+	afterCommit(ctx, func() {
+		
+	if oldSlug != "" {
+		oldCacheKey := fmt.Sprintf("note_slug:%s", oldSlug)
+		d.cache.Delete(oldCacheKey)
+		d.cacheProvider.InvalidateCache("note", oldCacheKey)
+	}
+
+		d.InvalidateCache(&cached)
+		d.FlushListCache()
+
+		d.setCached(&cached)
+	})
+
+	return entity, nil
+}
+
+func (d *noteRepository) upsertBySlug(ctx context.Context, entity *Note) (*Note, error) {
+	if !inTx(ctx) {
+		return withinTx(ctx, func(ctx context.Context) (*Note, error) { return d.upsertBySlug(ctx, entity) })
+	}
+	const operation = "upsert_by_slug"
+	start := time.Now()
+
+	now := time.Now().Truncate(time.Second)
+	entity.Created = now
+	entity.Updated = now
+
+	// Auto-generate UID fields if they are not provided
+
+	query := `INSERT INTO notes (amount,body,meta,owner_id,pinned,remind_at,slug,visibility,created,updated) VALUES (?,?,?,?,?,?,?,?,?,?) ON CONFLICT (slug) DO UPDATE SET body = excluded.body, pinned = excluded.pinned, updated = CASE WHEN notes.body IS DISTINCT FROM excluded.body OR notes.pinned IS DISTINCT FROM excluded.pinned THEN excluded.updated ELSE notes.updated END, version = CASE WHEN notes.body IS DISTINCT FROM excluded.body OR notes.pinned IS DISTINCT FROM excluded.pinned THEN notes.version + 1 ELSE notes.version END RETURNING id, version, amount, body, meta, owner_id, pinned, remind_at, slug, visibility, created, updated, deleted_at`
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "note", true)
+    if dbErr != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+        return nil, dbErr
+    }
+	
+    // Snapshot the rows before the write to record its changes
+    before, auditErr := d.auditSnapshot(ctx, db, "slug = ?", entity.Slug)
+    if auditErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, auditErr
+    }
+
+
+	// The stored row is returned by the upsert itself.
+	{
+		row := db.QueryRowContext(ctx, query,entity.Amount, entity.Body, entity.Meta, entity.OwnerId, entity.Pinned, entity.RemindAt, entity.Slug, entity.Visibility,
+			entity.Created, entity.Updated)
+		err := row.Scan(
+			&entity.ID,
+			&entity.Version,&entity.Amount,&entity.Body,&entity.Meta,&entity.OwnerId,&entity.Pinned,&entity.RemindAt,&entity.Slug,&entity.Visibility,
+			&entity.Created,
+			&entity.Updated,
+			&entity.DeletedAt,
+		)
+		if err != nil {
+			d.telemetryProvider.IncDBError("note", operation)
+			return nil, fmt.Errorf("failed to upsert Note: %w", err)
+		}
+	}
+	
+    if auditErr := d.recordChanges(ctx, db, operation, before, "id = ?", entity.ID); auditErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, auditErr
+    }
+
+
+	d.telemetryProvider.IncDBRequest("note", operation)
+	d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(start).Seconds())
+	return entity, nil
+}
+
+
+
+// UpsertBulkBySlug upserts the entities by slug in chunks of 500 rows, overwriting
+// body, pinned of the existing Notes. Rows already holding the given values are not written.
+// The entities are filled from the stored rows, including the IDs of updated rows, and the outcome of
+// every entity is returned in the same order. Each chunk is written in a transaction; use RunInTx to make
+// the whole call atomic. Inserted rows run the CreateBulk hooks and updated ones the Update hooks.
+// Only the written rows are invalidated in the cache.
+func (d *noteRepository) UpsertBulkBySlug(ctx context.Context, entities []*Note) ([]UpsertOutcome, error) {
+	if d.configProvider.BlockedWrites("note") {
+		return nil, ErrOperationBlocked
+	}
+
+	const operation = "upsert_bulk_by_slug"
+	d.telemetryProvider.IncDALOperation("note", operation)
+
+	if len(entities) == 0 {
+		return nil, nil // Nothing to upsert
+	}
+
+	// A statement can't upsert the same row twice, and the outcome would be ambiguous.
+	seen := make(map[string]struct{}, len(entities))
+	for _, entity := range entities {
+		if _, exists := seen[entity.Slug]; exists {
+			return nil, fmt.Errorf("duplicate slug %v in bulk upsert", entity.Slug)
+		}
+		seen[entity.Slug] = struct{}{}
+	}
+
+	outcomes := make([]UpsertOutcome, len(entities))
+	var staleKeys []string
+	batchSize := 500 // Safe limit to prevent exceeding the placeholder limits
+
+	for i := 0; i < len(entities); i += batchSize {
+		end := i + batchSize
+		if end > len(entities) {
+			end = len(entities)
+		}
+
+		result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+			return d.upsertBulkBySlug(ctx, entities[i:end], outcomes[i:end])
+		})
+		if err != nil {
+			return nil, err // Fail fast, earlier chunks stay written
+		}
+		staleKeys = append(staleKeys, result.([]string)...)
+	}
+
+	// Inside a transaction caches are only touched once it commits.
+	var inserted, updated []Note
+	for i, entity := range entities {
+		switch outcomes[i] {
+		case UpsertInserted:
+			inserted = append(inserted, *entity)
+		case UpsertUpdated:
+			updated = append(updated, *entity)
+		}
+	}
+	afterCommit(ctx, func() {
+		for _, key := range staleKeys {
+			d.cache.Delete(key)
+			d.cacheProvider.InvalidateCache("note", key)
+		}
+		for i := range updated {
+			d.InvalidateCache(&updated[i])
+			d.setCached(&updated[i])
+		}
+		for i := range inserted {
+			d.setCached(&inserted[i])
+		}
+
+		if len(inserted) > 0 || len(updated) > 0 {
+			d.FlushListCache()
+		}
+	})
+
+	return outcomes, nil
+}
+
+// upsertBulkBySlug upserts a single chunk and returns the cache keys of overwritten unique values.
+func (d *noteRepository) upsertBulkBySlug(ctx context.Context, chunk []*Note, outcomes []UpsertOutcome) ([]string, error) {
+	// The stored rows stay locked from the read that classifies them until the write commits, and errors of
+	// the after hooks roll the chunk back.
+	if !inTx(ctx) {
+		return withinTx(ctx, func(ctx context.Context) ([]string, error) { return d.upsertBulkBySlug(ctx, chunk, outcomes) })
+	}
+	const operation = "upsert_bulk_by_slug"
+	start := time.Now()
+
+	db, dbErr := databaseFor(ctx, d.dbProvider, "note", true)
+	if dbErr != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return nil, dbErr
+	}
+
+	// 1. Load and lock the stored rows to tell inserts, updates and unchanged rows apart
+	keys := make([]interface{}, len(chunk))
+	for j, entity := range chunk {
+		keys[j] = entity.Slug
+	}
+	
+    // Snapshot the rows before the write to record its changes
+    before, auditErr := d.auditSnapshot(ctx, db, "slug IN (?...)", inValues(keys))
+    if auditErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, auditErr
+    }
+
+	stored, err := d.storedBySlugs(ctx, db, keys)
+	if err != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return nil, err
+	}
+
+	var staleKeys []string
+	var inserts, updates []*Note
+	for j, entity := range chunk {
+		existing, exists := stored[entity.Slug]
+		switch {
+		case !exists:
+			outcomes[j] = UpsertInserted
+			inserts = append(inserts, entity)
+		case sameValue(existing.Body, entity.Body) && sameValue(existing.Pinned, entity.Pinned):
+			outcomes[j] = UpsertUnchanged
+			*entity = *existing
+		default:
+			outcomes[j] = UpsertUpdated
+
+			// Keep the stored values of the columns that are not overwritten
+			merged := *existing
+			merged.Body = entity.Body
+			merged.Pinned = entity.Pinned
+			*entity = merged
+			updates = append(updates, entity)
+		}
+	}
+
+	// Inserts run the hooks of CreateBulk and updates the ones of Update, unchanged rows none.
+	if len(inserts) > 0 {
+		if err := runHooks(ctx, "BeforeCreateBulk", d.hooks.beforeCreateBulk, inserts); err != nil {
+			return nil, err
+		}
+	}
+	for _, entity := range updates {
+		if err := runHooks(ctx, "BeforeUpdate", d.hooks.beforeUpdate, entity); err != nil {
+			return nil, err
+		}
+	}
+
+	// Validated once the hooks ran, unchanged rows aren't written.
+	for _, written := range [][]*Note{inserts, updates} {
+		for _, entity := range written {
+			if err := entity.Validate(); err != nil {
+				return nil, fmt.Errorf("slug %v: %w", entity.Slug, err)
+			}
+		}
+	}
+
+	now := time.Now().Truncate(time.Second)
+	var writtenKeys []interface{}
+	valuePlaceholders := make([]string, 0, len(chunk))
+	params := make([]interface{}, 0, len(chunk)*(8 + 2))
+
+	for j, entity := range chunk {
+		if outcomes[j] == UpsertUnchanged {
+			continue
+		}
+
+		// Auto-generate UID fields of inserted rows if they are not provided
+
+		entity.Created = now
+		entity.Updated = now
+
+		writtenKeys = append(writtenKeys, entity.Slug)
+		valuePlaceholders = append(valuePlaceholders, "(?,?,?,?,?,?,?,?,?,?)")
+		params = append(params,
+			entity.Amount,
+			entity.Body,
+			entity.Meta,
+			entity.OwnerId,
+			entity.Pinned,
+			entity.RemindAt,
+			entity.Slug,
+			entity.Visibility,
+			entity.Created,
+			entity.Updated,
+		)
+	}
+
+	if len(writtenKeys) == 0 {
+		return nil, nil // Every row is unchanged
+	}
+
+	// 2. Write the inserted and updated rows in a single statement
+	query := fmt.Sprintf(`
+		INSERT INTO notes
+		(amount,body,meta,owner_id,pinned,remind_at,slug,visibility,
+created,updated)
+		VALUES %s
+	`, strings.Join(valuePlaceholders, ",")) + ` ON CONFLICT (slug) DO UPDATE SET body = excluded.body, pinned = excluded.pinned, updated = CASE WHEN notes.body IS DISTINCT FROM excluded.body OR notes.pinned IS DISTINCT FROM excluded.pinned THEN excluded.updated ELSE notes.updated END, version = CASE WHEN notes.body IS DISTINCT FROM excluded.body OR notes.pinned IS DISTINCT FROM excluded.pinned THEN notes.version + 1 ELSE notes.version END`
+
+	if _, err := db.ExecContext(ctx, query, params...); err != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return nil, fmt.Errorf("failed to bulk upsert notes chunk: %w", err)
+	}
+
+	// 3. LastInsertId can't identify the rows of a multi row upsert, so reload them by slug
+	stored, err = d.storedBySlugs(ctx, db, writtenKeys)
+	if err != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return nil, err
+	}
+
+	for j, entity := range chunk {
+		if outcomes[j] == UpsertUnchanged {
+			continue
+		}
+
+		row, exists := stored[entity.Slug]
+		if !exists {
+			// The database matched a row the key doesn't, e.g. one differing in accents or trailing spaces. Let it find the row
+			// of this slug alone rather than failing after the write.
+			again, err := d.storedBySlugs(ctx, db, []interface{}{entity.Slug})
+			if err != nil {
+				d.telemetryProvider.IncDBError("note", operation)
+				return nil, err
+			}
+			for _, match := range again {
+				row = match
+			}
+		}
+		if row == nil {
+			return nil, fmt.Errorf("upserted Note with slug %v not found", entity.Slug)
+		}
+
+		// A concurrent insert of the same slug turns our insert into an update.
+		if outcomes[j] == UpsertInserted && row.Version > 0 {
+			outcomes[j] = UpsertUpdated
+		}
+		*entity = *row
+	}
+	
+    if auditErr := d.recordChanges(ctx, db, operation, before, "slug IN (?...)", inValues(keys)); auditErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, auditErr
+    }
+
+
+	if len(inserts) > 0 {
+		if err := runHooks(ctx, "AfterCreateBulk", d.hooks.afterCreateBulk, inserts); err != nil {
+			return nil, err
+		}
+	}
+	for _, entity := range updates {
+		if err := runHooks(ctx, "AfterUpdate", d.hooks.afterUpdate, entity); err != nil {
+			return nil, err
+		}
+	}
+
+	d.telemetryProvider.IncDBRequest("note", operation)
+	d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(start).Seconds())
+	return staleKeys, nil
+}
+
+// storedBySlugs loads and locks the rows holding the given slug values from db, keyed by slug as the database compares it.
+func (d *noteRepository) storedBySlugs(ctx context.Context, db dbExecutor, keys []interface{}) (map[string]*Note, error) {
+	placeholders := make([]string, len(keys))
+	for i := range keys {
+		placeholders[i] = "?"
+	}
+
+	// Soft deleted rows have their unique values scrambled, so they never match.
+	query := fmt.Sprintf(`
+		SELECT id, version, amount, body, meta, owner_id, pinned, remind_at, slug, visibility, created, updated, deleted_at
+		FROM notes
+		WHERE slug IN (%s)
+	`, strings.Join(placeholders, ","))
+
+	rows, err := db.QueryContext(ctx, query, keys...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stored notes: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[string]*Note, len(keys))
+	for rows.Next() {
+		var entity Note
+		err := rows.Scan(
+			&entity.ID,
+			&entity.Version,
+			&entity.Amount,
+			&entity.Body,
+			&entity.Meta,
+			&entity.OwnerId,
+			&entity.Pinned,
+			&entity.RemindAt,
+			&entity.Slug,
+			&entity.Visibility,
+			&entity.Created,
+			&entity.Updated,
+			&entity.DeletedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan Note: %w", err)
+		}
+		result[entity.Slug] = &entity
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stored notes: %w", err)
+	}
+	return result, nil
+}
+
+
+
+// PatchBody updates only body of the Note with the given id and version,
+// bumping its version. Unlike Update it doesn't need the whole entity, BeforeUpdate hooks get the stored one.
+// ErrNotFound is returned in case there was nothing to update; either missing id or newer version of entity is already stored.
+func (d *noteRepository) PatchBody(ctx context.Context, id int64, version int32, body string) error {
+	if d.configProvider.BlockedWrites("note") {
+		return ErrOperationBlocked
+	}
+
+	const operation = "patch_body"
+	d.telemetryProvider.IncDALOperation("note", operation)
+
+	// BeforeUpdate hooks get the stored entity with the patched values.
+	var existing *Note
+	if len(d.hooks.beforeUpdate) > 0 {
+		var err error
+		if existing, err = d.GetByID(ctx, id); err != nil {
+			return fmt.Errorf("failed to get existing Note, id: %v, err: %w", id, err)
+		}
+	}
+
+	if _, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		if len(d.hooks.beforeUpdate) > 0 {
+			// Only the hooks' changes to the patched columns are written.
+			patched := *existing
+			patched.Version = version
+			patched.Body = body
+			if err := runHooks(ctx, "BeforeUpdate", d.hooks.beforeUpdate, &patched); err != nil {
+				return nil, err
+			}
+			body = patched.Body
+		}
+
+    v := validator{entity: "note"}
+    validateNoteBody(&v, body)
+    if err := v.err(); err != nil {
+        return nil, err
+    }
+		return nil, d.patchBody(ctx, id, version, body)
+	}); err != nil {
+		return err
+	}
+
+	// Inside a transaction caches are only touched once it commits.
+	afterCommit(ctx, func() {
+
+		d.InvalidateCache(&Note{ID: id})
+	})
+
+	return nil
+}
+
+func (d *noteRepository) patchBody(ctx context.Context, id int64, version int32, body string) error {
+	if !inTx(ctx) {
+		return RunInTx(ctx, func(ctx context.Context) error {
+			return d.patchBody(ctx, id, version, body)
+		})
+	}
+	const operation = "patch_body"
+	start := time.Now()
+
+	query := `
+		UPDATE notes
+		SET body = ?, updated = ?, version = version + 1
+		WHERE id = ? AND version = ? AND deleted_at IS NULL
+	`
+
+	db, dbErr := databaseFor(ctx, d.dbProvider, "note", true)
+	if dbErr != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return dbErr
+	}
+	
+    // Snapshot the rows before the write to record its changes
+    before, auditErr := d.auditSnapshot(ctx, db, "id = ?", id)
+    if auditErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return auditErr
+    }
+
+
+	res, err := db.ExecContext(ctx, query, body, time.Now(), id, version)
+	if err != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return fmt.Errorf("failed to patch Note: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		// clean out cache for specific ID to limit stale cache entries
+		afterCommit(ctx, func() { d.InvalidateCache(&Note{ID: id}) })
+		return ErrNotFound
+	}
+	
+    if auditErr := d.recordChanges(ctx, db, operation, before, "id = ?", id); auditErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return auditErr
+    }
+
+
+	d.telemetryProvider.IncDBRequest("note", operation)
+	d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(start).Seconds())
+	return nil
+}
+
+
+
+func (d *noteRepository) Delete(ctx context.Context, entity *Note) error {
+    if d.configProvider.BlockedWrites("note") {
+        return ErrOperationBlocked
+    }
+
+	if entity.ID == 0 {
+		// nothing can be deleted as entity is not created.
+		return ErrNotFound
+	}
+
+	const operation = "delete"
+	d.telemetryProvider.IncDALOperation("note", operation)
+
+	_, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return nil, withHooks(ctx, "Delete", entity, d.hooks.beforeDelete, d.hooks.afterDelete, nil, func(ctx context.Context) error {
+			return d.delete(ctx, entity.ID)
+		})
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// Lets clear item from cache and on next use use DB as source of truth
+	deleted := *entity
+	afterCommit(ctx, func() {
+		d.InvalidateCache(&deleted)
+		d.FlushListCache()
+	})
+
+	return err
+}
+
+func (d *noteRepository) delete(ctx context.Context, id int64) error {
+	if !inTx(ctx) {
+		return RunInTx(ctx, func(ctx context.Context) error { return d.delete(ctx, id) })
+	}
+	start := time.Now()
+	const operation = "delete"
+
+	
+	query := `
+		UPDATE notes
+		SET 
+			deleted_at = CURRENT_TIMESTAMP, 
+			updated = CURRENT_TIMESTAMP, 
+			version = version + 1
+			, slug = slug || '-del-' || lower(hex(randomblob(16)))
+		WHERE id = ? AND deleted_at IS NULL
+	`
+	
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "note", true)
+    if dbErr != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+        return dbErr
+    }
+	
+    // Snapshot the rows before the write to record its changes
+    before, auditErr := d.auditSnapshot(ctx, db, "id = ?", id)
+    if auditErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return auditErr
+    }
+
+
+	res, err := db.ExecContext(ctx, query, id)
+	if err != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return fmt.Errorf("failed to delete note: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	
+    if auditErr := d.recordChanges(ctx, db, operation, before, "id = ?", id); auditErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return auditErr
+    }
+
+
+	d.telemetryProvider.IncDBRequest("note", operation)	
+	d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(start).Seconds())	
+	return nil
+}
+
+
+// HardDelete permanently removes the entity from the database.
+// returns ErrNotFound in case nothing is deleted.
+func (d *noteRepository) HardDelete(ctx context.Context, entity *Note) error {
+    if d.configProvider.BlockedWrites("note") {
+        return ErrOperationBlocked
+    }
+
+	if entity.ID == 0 {
+		// nothing can be deleted as entity is not created.
+		return ErrNotFound
+	}
+
+	const operation = "hard_delete"
+	d.telemetryProvider.IncDALOperation("note", operation)
+
+	_, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return nil, withHooks(ctx, "Delete", entity, d.hooks.beforeDelete, d.hooks.afterDelete, nil, func(ctx context.Context) error {
+			return d.hardDelete(ctx, entity.ID)
+		})
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// Lets clear item from cache and on next use use DB as source of truth
+	deleted := *entity
+	afterCommit(ctx, func() {
+		d.InvalidateCache(&deleted)
+		d.FlushListCache()
+	})
+
+	return err
+}
+
+func (d *noteRepository) hardDelete(ctx context.Context, id int64) error {
+	if !inTx(ctx) {
+		return RunInTx(ctx, func(ctx context.Context) error { return d.hardDelete(ctx, id) })
+	}
+	start := time.Now()
+	const operation = "hard_delete"
+
+	query := `
+		DELETE FROM notes
+		WHERE id = ?
+	`
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "note", true)
+    if dbErr != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+        return dbErr
+    }
+	
+    // Snapshot the rows before the write to record its changes
+    before, auditErr := d.auditSnapshot(ctx, db, "id = ?", id)
+    if auditErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return auditErr
+    }
+
+
+	res, err := db.ExecContext(ctx, query, id)
+	if err != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return fmt.Errorf("failed to hard delete note: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	
+    if auditErr := d.recordChanges(ctx, db, operation, before, "id = ?", id); auditErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return auditErr
+    }
+
+
+	d.telemetryProvider.IncDBRequest("note", operation)	
+	d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(start).Seconds())	
+	return nil
+}
+
+
+
+
+
+func (d *noteRepository) GetByID(ctx context.Context, id int64) (*Note, error) {
+    if d.configProvider.BlockedReads("note") {
+        return nil, ErrOperationBlocked
+    }
+
+    const operation = "get_by_id"
+    d.telemetryProvider.IncDALOperation("note", operation)
+
+	// Load from cache. Transactions bypass caches to see their own writes.
+    if !inTx(ctx) {
+        cachedEntity, _ := d.getByIDCached(id)
+        if cachedEntity != nil {
+            return cachedEntity, nil
+        }
+    }
+
+	// Fallback to database if cache miss or decoding fails
+	result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return d.getByID(ctx, id)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	entity, ok := result.(*Note)
+    if !ok {
+        return nil, fmt.Errorf("invalid type")
+    }
+
+    if !inTx(ctx) {
+        _ = d.setCached(entity)
+    }
+
+    return entity, err
+}
+
+func (d *noteRepository) setCached(entity *Note) error {
+    // if we have not reached the maximum number of items set the cache. otherwise expirations will
+	// handle this
+    itemCount := d.cache.ItemCount()
+    if itemCount < 10000 {
+        cacheKey := d.getCacheKey(entity.ID)
+
+        // Store in cache with new format        
+        d.telemetryProvider.IncCacheWrite("note")
+        d.telemetryProvider.SetCacheSize("note", float64(itemCount+1))        
+
+        copy := *entity
+		d.cache.Set(cacheKey, &copy, time.Second*300)
+    }
+
+    return nil
+}
+
+// Gets entity from cache only or return nil
+func (d *noteRepository) getByIDCached(id int64) (*Note, error) {
+    const operation = "get_by_id"
+    cacheKey := d.getCacheKey(id)
+    val, found := d.cache.Get(cacheKey)
+    if found {
+        entity, ok := val.(*Note)
+        if !ok {
+            return nil, fmt.Errorf("noteRepository.GetById: Cache returned wrong type")
+        }
+
+        d.telemetryProvider.IncCacheHit("note", operation)        
+        copy := *entity
+        return &copy, nil
+    }
+
+    // Cache missed or error during fetching cached data
+    d.telemetryProvider.IncCacheMiss("note", operation)        	
+
+    return nil, nil
+}
+
+func (d *noteRepository) getByID(ctx context.Context, id int64) (*Note, error) {
+    const operation = "get_by_id"
+    dbStart := time.Now();
+
+    query := `
+        SELECT id, version, amount, body, meta, owner_id, pinned, remind_at, slug, visibility, created, updated, deleted_at
+        FROM notes
+        WHERE id = ? AND deleted_at IS NULL
+    `
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "note", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, dbErr
+    }
+
+    row := db.QueryRowContext(ctx, query, id)
+    var entity Note
+    err := row.Scan(
+        &entity.ID,
+        &entity.Version,&entity.Amount,&entity.Body,&entity.Meta,&entity.OwnerId,&entity.Pinned,&entity.RemindAt,&entity.Slug,&entity.Visibility,
+        &entity.Created,
+        &entity.Updated,
+        &entity.DeletedAt,
+    )
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return nil, ErrNotFound
+        }
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, fmt.Errorf("failed to get Note by ID: %w", err)
+    }
+
+    d.telemetryProvider.IncDBRequest("note", operation)	
+    d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(dbStart).Seconds())	
+    return &entity, nil
+}
+
+
+
+
+func (d *noteRepository) GetBySlug(ctx context.Context, slug string) (*Note, error) {
+    if d.configProvider.BlockedReads("note") {
+        return nil, ErrOperationBlocked
+    }
+
+    const operation = "get_by_slug"
+    d.telemetryProvider.IncDALOperation("note", operation)
+
+    // key would for example be: user_email:test@something.com, but in this case cache is only mapping email -> id
+    cacheKey := fmt.Sprintf("note_slug:%v", slug)
+
+    // Fetch from cache Slug -> ID mapping
+    // Transactions bypass caches to see their own writes.
+    val, found := d.cache.Get(cacheKey)
+    if found && !inTx(ctx) {
+        entityId, ok := val.(int64)
+        if !ok {
+            return nil, fmt.Errorf("noteRepository.GetBySlug: Cache returned wrong type; expected ID type")
+        }
+
+        d.telemetryProvider.IncCacheHit("note", operation)        
+        
+        // return entity by that id
+        return d.GetByID(ctx, entityId)
+    }
+
+    // Cache missed or error during fetching cached data
+    d.telemetryProvider.IncCacheMiss("note", operation)        	
+
+	// Fallback to database if cache miss or decoding fails
+	result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return d.getBySlug(ctx, slug)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	entity := result.(*Note)
+
+	// Store in cache Slug -> ID mapping
+    if !inTx(ctx) {
+        d.telemetryProvider.IncCacheWrite("note")    
+        d.cache.Set(cacheKey, entity.ID, time.Second*300)
+    }
+
+    return entity, err
+}
+
+func (d *noteRepository) getBySlug(ctx context.Context, slug string) (*Note, error) {
+    const operation = "get_by_slug"
+    dbStart := time.Now()
+
+    query := `
+        SELECT id, version, amount, body, meta, owner_id, pinned, remind_at, slug, visibility, created, updated, deleted_at
+        FROM notes
+        WHERE slug = ? AND deleted_at IS NULL
+    `
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "note", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, dbErr
+    }
+
+    row := db.QueryRowContext(ctx, query, slug)
+    var entity Note
+    err := row.Scan(
+        &entity.ID,
+        &entity.Version,
+        &entity.Amount,
+        &entity.Body,
+        &entity.Meta,
+        &entity.OwnerId,
+        &entity.Pinned,
+        &entity.RemindAt,
+        &entity.Slug,
+        &entity.Visibility,
+        &entity.Created,
+        &entity.Updated,
+        &entity.DeletedAt,
+    )
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return nil, ErrNotFound
+        }
+
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, fmt.Errorf("failed to get Note by Slug: %w", err)
+    }
+
+    d.telemetryProvider.IncDBRequest("note", operation)	
+    d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(dbStart).Seconds())	
+    return &entity, nil
+}
+
+
+
+
+// NoteListByOwnerCursor points at the last Note of a ListByOwner page. Pass it to ListByOwner to fetch the next page,
+// or nil for the first one.
+type NoteListByOwnerCursor struct {
+	Created time.Time
+	ID int64
+}
+
+// cacheKey identifies the page in list cache keys.
+func (c *NoteListByOwnerCursor) cacheKey() string {
+	if c == nil {
+		return "first"
+	}
+	return fmt.Sprintf("%v:%d", c.Created.UnixNano(), c.ID)
+}
+
+// newNoteListByOwnerCursor returns the cursor of the page following entities, or nil if it was the last page.
+func newNoteListByOwnerCursor(entities []*Note, pageSize int) *NoteListByOwnerCursor {
+	if len(entities) == 0 || len(entities) < pageSize {
+		return nil
+	}
+
+	last := entities[len(entities)-1]
+	return &NoteListByOwnerCursor{Created: last.Created, ID: last.ID}
+}
+
+// ListByOwner returns a page of Notes ordered by created descending, starting after cursor,
+// and the cursor of the next page, which is nil once the last page is reached.
+func (d *noteRepository) ListByOwner(ctx context.Context, ownerId int64, cursor *NoteListByOwnerCursor, pageSize int) ([]*Note, *NoteListByOwnerCursor, error) {
+	entities, err := d.listByOwnerWithCache(ctx, ownerId, cursor, pageSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entities, newNoteListByOwnerCursor(entities, pageSize), nil
+}
+
+func (d *noteRepository) listByOwnerWithCache(ctx context.Context, ownerId int64, cursor *NoteListByOwnerCursor, pageSize int) ([]*Note, error) {
+    if d.configProvider.BlockedReads("note") {
+        return nil, ErrOperationBlocked
+    }
+
+	const operation = "list_by_owner"
+	d.telemetryProvider.IncDALOperation("note", operation)
+
+    cacheKey := fmt.Sprintf("note_list_by_owner:%v:%s:%d", ownerId, cursor.cacheKey(), pageSize)
+    // Transactions bypass caches to see their own writes.
+    val, found := d.listCache.Get(cacheKey)
+    if found && !inTx(ctx) {
+        entityIDs, ok := val.([]int64)
+        if !ok {
+            return nil, fmt.Errorf("noteRepository.ListByOwner: Cache returned wrong type; expected array ID type")
+        }
+
+        var entities []*Note
+        missingEntries := false
+        for _, id := range entityIDs {
+            entity, err := d.getByIDCached(id)
+            if err != nil {
+                // failed using cache
+                return nil, err
+            }
+
+            if entity == nil {
+                // we have one or more missing entity in cache somehow. reload the whole list.
+                missingEntries = true
+                break
+            }
+
+            entities = append(entities, entity)
+        }
+
+        if !missingEntries {
+            d.telemetryProvider.IncCacheHit("note", operation)        
+            return entities, nil
+        }
+    }
+
+    // Otherwise, it's a cache miss or decode error
+     d.telemetryProvider.IncCacheMiss("note", operation)       
+
+    // 2) Fallback to DB
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+        return d.listByOwner(ctx, ownerId, cursor, pageSize)
+    })
+
+    if err != nil {
+        // DB also failed
+        return nil, err
+    }
+
+    entities := result.([]*Note)
+    if inTx(ctx) {
+        return entities, nil
+    }
+
+    // Store Cache. cacheKey = user_list_by_id:startID:pageSize.
+	// Stored is array of entity IDs.
+	var entityIDs []int64
+	for _, entity := range entities {
+		entityIDs = append(entityIDs, entity.ID)
+        // cache each entity individualy
+        d.setCached(entity)
+	}
+	d.listCache.Set(cacheKey, entityIDs, time.Second*60)
+
+    return entities, nil
+}
+
+func (d *noteRepository) listByOwner(ctx context.Context, ownerId int64, cursor *NoteListByOwnerCursor, pageSize int) ([]*Note, error) {
+    const operation = "list_by_owner"
+	dbStart := time.Now()
+
+    var query string
+
+    // the first page query is different for pagination
+    if cursor == nil {
+        query = `SELECT id, version, amount, body, meta, owner_id, pinned, remind_at, slug, visibility, created, updated, deleted_at FROM notes WHERE (owner_id = ?) AND deleted_at IS NULL ORDER BY created DESC, id DESC LIMIT ?`
+    } else {
+        query = `SELECT id, version, amount, body, meta, owner_id, pinned, remind_at, slug, visibility, created, updated, deleted_at FROM notes WHERE (owner_id = ?) AND deleted_at IS NULL AND (created, id) < (?, ?) ORDER BY created DESC, id DESC LIMIT ?`
+    }
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "note", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, dbErr
+    }
+
+    var rows *sql.Rows
+    var err error
+
+    if cursor == nil {
+	    rows, err = db.QueryContext(ctx, query, ownerId, pageSize)
+    } else {
+        rows, err = db.QueryContext(ctx, query, ownerId, cursor.Created, cursor.ID, pageSize)
+    }
+
+	if err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+		return nil, fmt.Errorf("failed to query notes: %w", err)
+	}
+	defer rows.Close()
+
+	var entities []*Note
+	for rows.Next() {
+		var entity Note
+		err := rows.Scan(
+			&entity.ID,
+            &entity.Version,
+            &entity.Amount,
+            &entity.Body,
+            &entity.Meta,
+            &entity.OwnerId,
+            &entity.Pinned,
+            &entity.RemindAt,
+            &entity.Slug,
+            &entity.Visibility,
+            &entity.Created,
+            &entity.Updated,
+            &entity.DeletedAt,
+		)
+		if err != nil {
+            d.telemetryProvider.IncDBError("note", operation)
+			return nil, fmt.Errorf("failed to scan Note: %w", err)
+		}
+		entities = append(entities, &entity)
+	}
+
+	if err = rows.Err(); err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+    d.telemetryProvider.IncDBRequest("note", operation)	
+    d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(dbStart).Seconds())	
+	return entities, nil
+}
+
+
+
+// ListByOwnerPage returns a page of ListByOwner with the opaque token of the next page.
+// Pass an empty pageToken for the first page. A token only continues the query it was issued for,
+// otherwise ErrInvalidPageToken is returned.
+func (d *noteRepository) ListByOwnerPage(ctx context.Context, ownerId int64, pageToken string, pageSize int) (Page[Note], error) {
+	if pageSize <= 0 {
+		return Page[Note]{}, fmt.Errorf("page size must be positive, got %d", pageSize)
+	}
+
+	paramsHash := pageParamsHash(ownerId)
+	var cursor *NoteListByOwnerCursor
+	if pageToken != "" {
+		cursor = &NoteListByOwnerCursor{}
+		if err := decodePageToken(pageToken, "note", "list_by_owner", paramsHash, cursor); err != nil {
+			return Page[Note]{}, err
+		}
+	}
+
+	// One extra row tells whether another page exists.
+	entities, err := d.listByOwnerWithCache(ctx, ownerId, cursor, pageSize+1)
+	if err != nil {
+		return Page[Note]{}, err
+	}
+
+	page := Page[Note]{Items: entities}
+	if len(entities) > pageSize {
+		page.Items = entities[:pageSize]
+		page.HasMore = true
+		next := newNoteListByOwnerCursor(page.Items, pageSize)
+		page.NextPageToken, err = encodePageToken("note", "list_by_owner", paramsHash, next)
+		if err != nil {
+			return Page[Note]{}, err
+		}
+	}
+
+	return page, nil
+}
+
+
+
+func (d *noteRepository) ListForOwners(ctx context.Context, ownerIds []int64, startID int64, pageSize int) ([]*Note, error) {
+    if d.configProvider.BlockedReads("note") {
+        return nil, ErrOperationBlocked
+    }
+    if err := checkInListSize("ownerIds", len(ownerIds)); err != nil {
+        return nil, err
+    }
+
+	const operation = "list_for_owners"
+	d.telemetryProvider.IncDALOperation("note", operation)
+
+    cacheKey := fmt.Sprintf("note_list_for_owners:%v:%d:%d", inListKey(ownerIds), startID, pageSize)
+    // Transactions bypass caches to see their own writes.
+    val, found := d.listCache.Get(cacheKey)
+    if found && !inTx(ctx) {
+        entityIDs, ok := val.([]int64)
+        if !ok {
+            return nil, fmt.Errorf("noteRepository.ListForOwners: Cache returned wrong type; expected array ID type")
+        }
+
+        var entities []*Note
+        missingEntries := false
+        for _, id := range entityIDs {
+            entity, err := d.getByIDCached(id)
+            if err != nil {
+                // failed using cache
+                return nil, err
+            }
+
+            if entity == nil {
+                // we have one or more missing entity in cache somehow. reload the whole list.
+                missingEntries = true
+                break
+            }
+
+            entities = append(entities, entity)
+        }
+
+        if !missingEntries {
+            d.telemetryProvider.IncCacheHit("note", operation)        
+            return entities, nil
+        }
+    }
+
+    // Otherwise, it's a cache miss or decode error
+     d.telemetryProvider.IncCacheMiss("note", operation)       
+
+    // 2) Fallback to DB
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+        return d.listForOwners(ctx, ownerIds, startID, pageSize)
+    })
+
+    if err != nil {
+        // DB also failed
+        return nil, err
+    }
+
+    entities := result.([]*Note)
+    if inTx(ctx) {
+        return entities, nil
+    }
+
+    // Store Cache. cacheKey = user_list_by_id:startID:pageSize.
+	// Stored is array of entity IDs.
+	var entityIDs []int64
+	for _, entity := range entities {
+		entityIDs = append(entityIDs, entity.ID)
+        // cache each entity individualy
+        d.setCached(entity)
+	}
+	d.listCache.Set(cacheKey, entityIDs, time.Second*60)
+
+    return entities, nil
+}
+
+func (d *noteRepository) listForOwners(ctx context.Context, ownerIds []int64, startID int64, pageSize int) ([]*Note, error) {
+    const operation = "list_for_owners"
+	dbStart := time.Now()
+
+    var query string
+
+    // the first page query is different for pagination
+    if startID == 0 {
+        query = `SELECT id, version, amount, body, meta, owner_id, pinned, remind_at, slug, visibility, created, updated, deleted_at FROM notes WHERE (owner_id IN (?...)) AND deleted_at IS NULL ORDER BY id LIMIT ?`
+    } else {
+        query = `SELECT id, version, amount, body, meta, owner_id, pinned, remind_at, slug, visibility, created, updated, deleted_at FROM notes WHERE (owner_id IN (?...)) AND deleted_at IS NULL AND id > ? ORDER BY id LIMIT ?`
+    }
+
+    // Expand the slice parameters into a placeholder per value
+    var args []interface{}
+    if startID == 0 {
+        query, args = expandInLists(query, inValues(ownerIds), pageSize)
+    } else {
+        query, args = expandInLists(query, inValues(ownerIds), startID, pageSize)
+    }
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "note", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, dbErr
+    }
+
+    var rows *sql.Rows
+    var err error
+
+    rows, err = db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+		return nil, fmt.Errorf("failed to query notes: %w", err)
+	}
+	defer rows.Close()
+
+	var entities []*Note
+	for rows.Next() {
+		var entity Note
+		err := rows.Scan(
+			&entity.ID,
+            &entity.Version,
+            &entity.Amount,
+            &entity.Body,
+            &entity.Meta,
+            &entity.OwnerId,
+            &entity.Pinned,
+            &entity.RemindAt,
+            &entity.Slug,
+            &entity.Visibility,
+            &entity.Created,
+            &entity.Updated,
+            &entity.DeletedAt,
+		)
+		if err != nil {
+            d.telemetryProvider.IncDBError("note", operation)
+			return nil, fmt.Errorf("failed to scan Note: %w", err)
+		}
+		entities = append(entities, &entity)
+	}
+
+	if err = rows.Err(); err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+    d.telemetryProvider.IncDBRequest("note", operation)	
+    d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(dbStart).Seconds())	
+	return entities, nil
+}
+
+
+
+// ListForOwnersPage returns a page of ListForOwners with the opaque token of the next page.
+// Pass an empty pageToken for the first page. A token only continues the query it was issued for,
+// otherwise ErrInvalidPageToken is returned.
+func (d *noteRepository) ListForOwnersPage(ctx context.Context, ownerIds []int64, pageToken string, pageSize int) (Page[Note], error) {
+	if pageSize <= 0 {
+		return Page[Note]{}, fmt.Errorf("page size must be positive, got %d", pageSize)
+	}
+
+	paramsHash := pageParamsHash(ownerIds)
+	var startID int64
+	if pageToken != "" {
+		if err := decodePageToken(pageToken, "note", "list_for_owners", paramsHash, &startID); err != nil {
+			return Page[Note]{}, err
+		}
+	}
+
+	// One extra row tells whether another page exists.
+	entities, err := d.ListForOwners(ctx, ownerIds, startID, pageSize+1)
+	if err != nil {
+		return Page[Note]{}, err
+	}
+
+	page := Page[Note]{Items: entities}
+	if len(entities) > pageSize {
+		page.Items = entities[:pageSize]
+		page.HasMore = true
+		next := page.Items[pageSize-1].ID
+		page.NextPageToken, err = encodePageToken("note", "list_for_owners", paramsHash, next)
+		if err != nil {
+			return Page[Note]{}, err
+		}
+	}
+
+	return page, nil
+}
+
+
+
+
+// NoteSearchFilter holds the filters of Search and CountSearch. An optional filter is left out of the query
+// while any of its fields is nil.
+type NoteSearchFilter struct {
+	OwnerId int64 // owner_id = :owner_id
+	Pinned *bool // optional: pinned = :pinned
+	Slugs []string // optional: slug IN (:slugs...)
+	RemindBefore *time.Time // optional: remind_at < :remind_before
+}
+
+// where returns the predicates of the active filters and their query arguments.
+func (f NoteSearchFilter) where() ([]string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	conditions = append(conditions, "(owner_id = ?)")
+	args = append(args, f.OwnerId)
+	if f.Pinned != nil {
+		conditions = append(conditions, "(pinned = ?)")
+		args = append(args, *f.Pinned)
+	}
+	if f.Slugs != nil {
+		conditions = append(conditions, "(slug IN (?...))")
+		args = append(args, inValues(f.Slugs))
+	}
+	if f.RemindBefore != nil {
+		conditions = append(conditions, "(remind_at < ?)")
+		args = append(args, *f.RemindBefore)
+	}
+	return conditions, args
+}
+
+// cacheKey identifies the active filters and their values in list and count cache keys.
+func (f NoteSearchFilter) cacheKey() string {
+	key := ""
+	key += fmt.Sprintf(":owner_id=%v", f.OwnerId)
+	if f.Pinned != nil {
+		key += fmt.Sprintf(":pinned=%v", *f.Pinned)
+	}
+	if f.Slugs != nil {
+		key += ":slugs=" + inListKey(f.Slugs)
+	}
+	if f.RemindBefore != nil {
+		key += fmt.Sprintf(":remind_before=%v", *f.RemindBefore)
+	}
+	return key
+}
+
+
+// NoteSearchCursor points at the last Note of a Search page. Pass it to Search to fetch the next page,
+// or nil for the first one.
+type NoteSearchCursor struct {
+	Created time.Time
+	ID int64
+}
+
+// cacheKey identifies the page in list cache keys.
+func (c *NoteSearchCursor) cacheKey() string {
+	if c == nil {
+		return "first"
+	}
+	return fmt.Sprintf("%v:%d", c.Created.UnixNano(), c.ID)
+}
+
+// newNoteSearchCursor returns the cursor of the page following entities, or nil if it was the last page.
+func newNoteSearchCursor(entities []*Note, pageSize int) *NoteSearchCursor {
+	if len(entities) == 0 || len(entities) < pageSize {
+		return nil
+	}
+
+	last := entities[len(entities)-1]
+	return &NoteSearchCursor{Created: last.Created, ID: last.ID}
+}
+
+// Search returns a page of Notes ordered by created descending, starting after cursor,
+// and the cursor of the next page, which is nil once the last page is reached.
+func (d *noteRepository) Search(ctx context.Context, filter NoteSearchFilter, cursor *NoteSearchCursor, pageSize int) ([]*Note, *NoteSearchCursor, error) {
+	entities, err := d.searchWithCache(ctx, filter, cursor, pageSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entities, newNoteSearchCursor(entities, pageSize), nil
+}
+
+func (d *noteRepository) searchWithCache(ctx context.Context, filter NoteSearchFilter, cursor *NoteSearchCursor, pageSize int) ([]*Note, error) {
+    if d.configProvider.BlockedReads("note") {
+        return nil, ErrOperationBlocked
+    }
+    if err := checkInListSize("slugs", len(filter.Slugs)); err != nil {
+        return nil, err
+    }
+
+	const operation = "search"
+	d.telemetryProvider.IncDALOperation("note", operation)
+
+    cacheKey := fmt.Sprintf("note_search:%s:%s:%d", filter.cacheKey(), cursor.cacheKey(), pageSize)
+    // Transactions bypass caches to see their own writes.
+    val, found := d.listCache.Get(cacheKey)
+    if found && !inTx(ctx) {
+        entityIDs, ok := val.([]int64)
+        if !ok {
+            return nil, fmt.Errorf("noteRepository.Search: Cache returned wrong type; expected array ID type")
+        }
+
+        var entities []*Note
+        missingEntries := false
+        for _, id := range entityIDs {
+            entity, err := d.getByIDCached(id)
+            if err != nil {
+                // failed using cache
+                return nil, err
+            }
+
+            if entity == nil {
+                // we have one or more missing entity in cache somehow. reload the whole list.
+                missingEntries = true
+                break
+            }
+
+            entities = append(entities, entity)
+        }
+
+        if !missingEntries {
+            d.telemetryProvider.IncCacheHit("note", operation)        
+            return entities, nil
+        }
+    }
+
+    // Otherwise, it's a cache miss or decode error
+     d.telemetryProvider.IncCacheMiss("note", operation)       
+
+    // 2) Fallback to DB
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+        return d.search(ctx, filter, cursor, pageSize)
+    })
+
+    if err != nil {
+        // DB also failed
+        return nil, err
+    }
+
+    entities := result.([]*Note)
+    if inTx(ctx) {
+        return entities, nil
+    }
+
+    // Store Cache. cacheKey = user_list_by_id:startID:pageSize.
+	// Stored is array of entity IDs.
+	var entityIDs []int64
+	for _, entity := range entities {
+		entityIDs = append(entityIDs, entity.ID)
+        // cache each entity individualy
+        d.setCached(entity)
+	}
+	d.listCache.Set(cacheKey, entityIDs, time.Second*60)
+
+    return entities, nil
+}
+
+func (d *noteRepository) search(ctx context.Context, filter NoteSearchFilter, cursor *NoteSearchCursor, pageSize int) ([]*Note, error) {
+    const operation = "search"
+	dbStart := time.Now()
+
+    var query string
+
+    // Only the active filters make it into the query
+    conditions, args := filter.where()
+    conditions = append(conditions, "deleted_at IS NULL")
+    if cursor != nil {
+        conditions = append(conditions, "(created, id) < (?, ?)")
+        args = append(args, cursor.Created, cursor.ID)
+    }
+    args = append(args, pageSize)
+
+    query = `SELECT id, version, amount, body, meta, owner_id, pinned, remind_at, slug, visibility, created, updated, deleted_at FROM notes`
+    if len(conditions) > 0 {
+        query += " WHERE " + strings.Join(conditions, " AND ")
+    }
+    query += ` ORDER BY created DESC, id DESC LIMIT ?`
+    query, args = expandInLists(query, args...)
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "note", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, dbErr
+    }
+
+    var rows *sql.Rows
+    var err error
+
+    rows, err = db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+		return nil, fmt.Errorf("failed to query notes: %w", err)
+	}
+	defer rows.Close()
+
+	var entities []*Note
+	for rows.Next() {
+		var entity Note
+		err := rows.Scan(
+			&entity.ID,
+            &entity.Version,
+            &entity.Amount,
+            &entity.Body,
+            &entity.Meta,
+            &entity.OwnerId,
+            &entity.Pinned,
+            &entity.RemindAt,
+            &entity.Slug,
+            &entity.Visibility,
+            &entity.Created,
+            &entity.Updated,
+            &entity.DeletedAt,
+		)
+		if err != nil {
+            d.telemetryProvider.IncDBError("note", operation)
+			return nil, fmt.Errorf("failed to scan Note: %w", err)
+		}
+		entities = append(entities, &entity)
+	}
+
+	if err = rows.Err(); err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+    d.telemetryProvider.IncDBRequest("note", operation)	
+    d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(dbStart).Seconds())	
+	return entities, nil
+}
+
+
+
+// SearchPage returns a page of Search with the opaque token of the next page.
+// Pass an empty pageToken for the first page. A token only continues the query it was issued for,
+// otherwise ErrInvalidPageToken is returned.
+func (d *noteRepository) SearchPage(ctx context.Context, filter NoteSearchFilter, pageToken string, pageSize int) (Page[Note], error) {
+	if pageSize <= 0 {
+		return Page[Note]{}, fmt.Errorf("page size must be positive, got %d", pageSize)
+	}
+
+	paramsHash := pageParamsHash(filter)
+	var cursor *NoteSearchCursor
+	if pageToken != "" {
+		cursor = &NoteSearchCursor{}
+		if err := decodePageToken(pageToken, "note", "search", paramsHash, cursor); err != nil {
+			return Page[Note]{}, err
+		}
+	}
+
+	// One extra row tells whether another page exists.
+	entities, err := d.searchWithCache(ctx, filter, cursor, pageSize+1)
+	if err != nil {
+		return Page[Note]{}, err
+	}
+
+	page := Page[Note]{Items: entities}
+	if len(entities) > pageSize {
+		page.Items = entities[:pageSize]
+		page.HasMore = true
+		next := newNoteSearchCursor(page.Items, pageSize)
+		page.NextPageToken, err = encodePageToken("note", "search", paramsHash, next)
+		if err != nil {
+			return Page[Note]{}, err
+		}
+	}
+
+	return page, nil
+}
+
+
+
+// Count function for the specific list
+func (d *noteRepository) CountListByOwner(ctx context.Context, ownerId int64) (int64, error) {
+	if d.configProvider.BlockedReads("note") {
+		return 0, ErrOperationBlocked
+	}
+
+	const operation = "count_list_by_owner"
+	d.telemetryProvider.IncDALOperation("note", operation)
+	
+	cacheKey := fmt.Sprintf("note_count_list_by_owner:%v", ownerId)
+	// Transactions bypass caches to see their own writes.
+	val, found := d.countCache.Get(cacheKey)
+	if found && !inTx(ctx) {
+		count, ok := val.(int64)
+		if !ok {
+			return 0, fmt.Errorf("noteRepository.CountListByOwner: Cache returned wrong type; expected int64")
+		}
+
+		d.telemetryProvider.IncCacheHit("note", operation)        
+		return count, nil
+	}
+
+	// Otherwise, it's a cache miss or decode error
+	 d.telemetryProvider.IncCacheMiss("note", operation)       
+
+	// 2) Fallback to DB
+	count, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return d.countListByOwner(ctx, ownerId)
+	})
+
+	if err != nil {
+		// DB also failed
+		return 0, err
+	}
+
+	if !inTx(ctx) {
+		d.countCache.Set(cacheKey, count, time.Second*60)
+	}
+
+	return count.(int64), nil
+}
+
+func (d *noteRepository) countListByOwner(ctx context.Context, ownerId int64) (int64, error) {
+	const operation = "count_list_by_owner"
+	dbStart := time.Now()
+
+	// if startID is zero then query is different for pagination
+	query := `SELECT count(*) FROM notes WHERE (owner_id = ?) AND deleted_at IS NULL`
+
+	db, dbErr := databaseFor(ctx, d.dbProvider, "note", false)
+	if dbErr != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return 0, dbErr
+	}
+
+	var err error
+	var count int64
+
+	row := db.QueryRowContext(ctx, query, ownerId)
+
+	err = row.Scan(
+		&count,
+	)
+
+	if err != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return 0, fmt.Errorf("failed to query notes: %w", err)
+	}
+
+	d.telemetryProvider.IncDBRequest("note", operation)	
+	d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(dbStart).Seconds())	
+	return count, nil
+}
+
+
+
+// Count function for the specific list
+func (d *noteRepository) CountListForOwners(ctx context.Context, ownerIds []int64) (int64, error) {
+	if d.configProvider.BlockedReads("note") {
+		return 0, ErrOperationBlocked
+	}
+	if err := checkInListSize("ownerIds", len(ownerIds)); err != nil {
+		return 0, err
+	}
+
+	const operation = "count_list_for_owners"
+	d.telemetryProvider.IncDALOperation("note", operation)
+	
+	cacheKey := fmt.Sprintf("note_count_list_for_owners:%v", inListKey(ownerIds))
+	// Transactions bypass caches to see their own writes.
+	val, found := d.countCache.Get(cacheKey)
+	if found && !inTx(ctx) {
+		count, ok := val.(int64)
+		if !ok {
+			return 0, fmt.Errorf("noteRepository.CountListForOwners: Cache returned wrong type; expected int64")
+		}
+
+		d.telemetryProvider.IncCacheHit("note", operation)        
+		return count, nil
+	}
+
+	// Otherwise, it's a cache miss or decode error
+	 d.telemetryProvider.IncCacheMiss("note", operation)       
+
+	// 2) Fallback to DB
+	count, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return d.countListForOwners(ctx, ownerIds)
+	})
+
+	if err != nil {
+		// DB also failed
+		return 0, err
+	}
+
+	if !inTx(ctx) {
+		d.countCache.Set(cacheKey, count, time.Second*60)
+	}
+
+	return count.(int64), nil
+}
+
+func (d *noteRepository) countListForOwners(ctx context.Context, ownerIds []int64) (int64, error) {
+	const operation = "count_list_for_owners"
+	dbStart := time.Now()
+
+	// if startID is zero then query is different for pagination
+	query := `SELECT count(*) FROM notes WHERE (owner_id IN (?...)) AND deleted_at IS NULL`
+	query, args := expandInLists(query, inValues(ownerIds))
+
+	db, dbErr := databaseFor(ctx, d.dbProvider, "note", false)
+	if dbErr != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return 0, dbErr
+	}
+
+	var err error
+	var count int64
+
+	row := db.QueryRowContext(ctx, query, args...)
+
+	err = row.Scan(
+		&count,
+	)
+
+	if err != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return 0, fmt.Errorf("failed to query notes: %w", err)
+	}
+
+	d.telemetryProvider.IncDBRequest("note", operation)	
+	d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(dbStart).Seconds())	
+	return count, nil
+}
+
+
+
+// Count function for the specific list
+func (d *noteRepository) CountSearch(ctx context.Context, filter NoteSearchFilter) (int64, error) {
+	if d.configProvider.BlockedReads("note") {
+		return 0, ErrOperationBlocked
+	}
+	if err := checkInListSize("slugs", len(filter.Slugs)); err != nil {
+		return 0, err
+	}
+
+	const operation = "count_search"
+	d.telemetryProvider.IncDALOperation("note", operation)
+	
+	cacheKey := fmt.Sprintf("note_count_search:%s", filter.cacheKey())
+	// Transactions bypass caches to see their own writes.
+	val, found := d.countCache.Get(cacheKey)
+	if found && !inTx(ctx) {
+		count, ok := val.(int64)
+		if !ok {
+			return 0, fmt.Errorf("noteRepository.CountSearch: Cache returned wrong type; expected int64")
+		}
+
+		d.telemetryProvider.IncCacheHit("note", operation)        
+		return count, nil
+	}
+
+	// Otherwise, it's a cache miss or decode error
+	 d.telemetryProvider.IncCacheMiss("note", operation)       
+
+	// 2) Fallback to DB
+	count, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return d.countSearch(ctx, filter)
+	})
+
+	if err != nil {
+		// DB also failed
+		return 0, err
+	}
+
+	if !inTx(ctx) {
+		d.countCache.Set(cacheKey, count, time.Second*60)
+	}
+
+	return count.(int64), nil
+}
+
+func (d *noteRepository) countSearch(ctx context.Context, filter NoteSearchFilter) (int64, error) {
+	const operation = "count_search"
+	dbStart := time.Now()
+
+	// Only the active filters make it into the query
+	conditions, args := filter.where()
+	conditions = append(conditions, "deleted_at IS NULL")
+
+	query := `SELECT count(*) FROM notes`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query, args = expandInLists(query, args...)
+
+	db, dbErr := databaseFor(ctx, d.dbProvider, "note", false)
+	if dbErr != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return 0, dbErr
+	}
+
+	var err error
+	var count int64
+
+	row := db.QueryRowContext(ctx, query, args...)
+
+	err = row.Scan(
+		&count,
+	)
+
+	if err != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return 0, fmt.Errorf("failed to query notes: %w", err)
+	}
+
+	d.telemetryProvider.IncDBRequest("note", operation)	
+	d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(dbStart).Seconds())	
+	return count, nil
+}
+
+
+
+
+// IterateAll walks every Note in ID order, a batch at a time, on the read database. It bypasses the
+// caches and leaves them untouched, so backfills and exports don't evict hot entries.
+func (d *noteRepository) IterateAll(ctx context.Context, opts IterateOptions) iter.Seq2[*Note, error] {
+    return iterateBatches(ctx, opts, func(after *Note, batchSize int) ([]*Note, error) {
+        if d.configProvider.BlockedReads("note") {
+            return nil, ErrOperationBlocked
+        }
+        d.telemetryProvider.IncDALOperation("note", "iterate_all")
+
+        startID := opts.StartAfterID
+        if after != nil {
+            startID = after.ID
+        }
+
+        result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+            return d.iterateAll(ctx, startID, batchSize)
+        })
+        if err != nil {
+            return nil, err
+        }
+        return result.([]*Note), nil
+    })
+}
+
+func (d *noteRepository) iterateAll(ctx context.Context, startID int64, batchSize int) ([]*Note, error) {
+    const operation = "iterate_all"
+    dbStart := time.Now()
+
+    query := `SELECT id, version, amount, body, meta, owner_id, pinned, remind_at, slug, visibility, created, updated, deleted_at FROM notes WHERE deleted_at IS NULL AND id > ? ORDER BY id LIMIT ?`
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "note", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, dbErr
+    }
+
+    rows, err := db.QueryContext(ctx, query, startID, batchSize)
+    if err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, fmt.Errorf("failed to iterate notes: %w", err)
+    }
+    defer rows.Close()
+
+    var entities []*Note
+    for rows.Next() {
+        var entity Note
+        err := rows.Scan(
+            &entity.ID,
+            &entity.Version,
+            &entity.Amount,
+            &entity.Body,
+            &entity.Meta,
+            &entity.OwnerId,
+            &entity.Pinned,
+            &entity.RemindAt,
+            &entity.Slug,
+            &entity.Visibility,
+            &entity.Created,
+            &entity.Updated,
+            &entity.DeletedAt,
+        )
+        if err != nil {
+            d.telemetryProvider.IncDBError("note", operation)
+            return nil, fmt.Errorf("failed to scan Note: %w", err)
+        }
+        entities = append(entities, &entity)
+    }
+
+    if err = rows.Err(); err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, fmt.Errorf("rows error: %w", err)
+    }
+
+    d.telemetryProvider.IncDBRequest("note", operation)
+    d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(dbStart).Seconds())
+    return entities, nil
+}
+
+
+
+// IterateByListByOwner walks every Note of ListByOwner in its order, a batch at a time, on the read
+// database. It bypasses the caches and leaves them untouched, so backfills and exports don't evict hot entries.
+func (d *noteRepository) IterateByListByOwner(ctx context.Context, ownerId int64, opts IterateOptions) iter.Seq2[*Note, error] {
+    return iterateBatches(ctx, opts, func(after *Note, batchSize int) ([]*Note, error) {
+        if d.configProvider.BlockedReads("note") {
+            return nil, ErrOperationBlocked
+        }
+        d.telemetryProvider.IncDALOperation("note", "iterate_by_list_by_owner")
+
+        var cursor *NoteListByOwnerCursor
+        if after != nil {
+            cursor = newNoteListByOwnerCursor([]*Note{after}, 1)
+        } else if opts.StartAfterID != 0 {
+            // Continue after the position of the entity in the list
+            entity, err := d.getByID(ctx, opts.StartAfterID)
+            if err != nil {
+                return nil, fmt.Errorf("failed to resume ListByOwner after Note %d: %w", opts.StartAfterID, err)
+            }
+            cursor = newNoteListByOwnerCursor([]*Note{entity}, 1)
+        }
+
+        result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+            return d.listByOwner(ctx, ownerId, cursor, batchSize)
+        })
+        if err != nil {
+            return nil, err
+        }
+        return result.([]*Note), nil
+    })
+}
+
+
+
+// IterateByListForOwners walks every Note of ListForOwners in its order, a batch at a time, on the read
+// database. It bypasses the caches and leaves them untouched, so backfills and exports don't evict hot entries.
+func (d *noteRepository) IterateByListForOwners(ctx context.Context, ownerIds []int64, opts IterateOptions) iter.Seq2[*Note, error] {
+    return iterateBatches(ctx, opts, func(after *Note, batchSize int) ([]*Note, error) {
+        if d.configProvider.BlockedReads("note") {
+            return nil, ErrOperationBlocked
+        }
+        if err := checkInListSize("ownerIds", len(ownerIds)); err != nil {
+            return nil, err
+        }
+        d.telemetryProvider.IncDALOperation("note", "iterate_by_list_for_owners")
+
+        startID := opts.StartAfterID
+        if after != nil {
+            startID = after.ID
+        }
+
+        result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+            return d.listForOwners(ctx, ownerIds, startID, batchSize)
+        })
+        if err != nil {
+            return nil, err
+        }
+        return result.([]*Note), nil
+    })
+}
+
+
+
+// IterateBySearch walks every Note of Search in its order, a batch at a time, on the read
+// database. It bypasses the caches and leaves them untouched, so backfills and exports don't evict hot entries.
+func (d *noteRepository) IterateBySearch(ctx context.Context, filter NoteSearchFilter, opts IterateOptions) iter.Seq2[*Note, error] {
+    return iterateBatches(ctx, opts, func(after *Note, batchSize int) ([]*Note, error) {
+        if d.configProvider.BlockedReads("note") {
+            return nil, ErrOperationBlocked
+        }
+        if err := checkInListSize("slugs", len(filter.Slugs)); err != nil {
+            return nil, err
+        }
+        d.telemetryProvider.IncDALOperation("note", "iterate_by_search")
+
+        var cursor *NoteSearchCursor
+        if after != nil {
+            cursor = newNoteSearchCursor([]*Note{after}, 1)
+        } else if opts.StartAfterID != 0 {
+            // Continue after the position of the entity in the list
+            entity, err := d.getByID(ctx, opts.StartAfterID)
+            if err != nil {
+                return nil, fmt.Errorf("failed to resume Search after Note %d: %w", opts.StartAfterID, err)
+            }
+            cursor = newNoteSearchCursor([]*Note{entity}, 1)
+        }
+
+        result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+            return d.search(ctx, filter, cursor, batchSize)
+        })
+        if err != nil {
+            return nil, err
+        }
+        return result.([]*Note), nil
+    })
+}
+
+
+
+func (d *noteRepository) GetByIds(ctx context.Context, ids []int64) ([]*Note, error) {
+    if d.configProvider.BlockedReads("note") {
+        return nil, ErrOperationBlocked
+    }
+
+    if len(ids) == 0 {
+        return nil, nil // Nothing to fetch
+    }
+    
+    // Hard limit to prevent massive memory allocations and driver crashes
+    if len(ids) > 5000 {
+        return nil, fmt.Errorf("bulk get operation exceeds maximum limit of 5000 items")
+    }
+
+    const operation = "get_bulk_by_id"
+    d.telemetryProvider.IncDALOperation("note", operation)
+
+    var results []*Note
+    var missingKeys []interface{}
+
+    // 1. Check local in-memory cache for each key. Transactions bypass caches to see their own writes.
+    for _, key := range ids {
+        if inTx(ctx) {
+            missingKeys = append(missingKeys, key)
+            continue
+        }
+        entity, _ := d.getByIDCached(key)
+        if entity != nil {
+            results = append(results, entity)
+        } else {
+            missingKeys = append(missingKeys, key)
+        }
+    }
+
+    if len(missingKeys) == 0 {
+        d.telemetryProvider.IncCacheHit("note", operation)
+        return results, nil
+    }
+
+    d.telemetryProvider.IncCacheMiss("note", operation)
+
+    // 2. Fetch cache misses from DB via Circuit Breaker in safe chunks
+    var dbEntities []*Note
+    batchSize := 500
+    
+    for i := 0; i < len(missingKeys); i += batchSize {
+        end := i + batchSize
+        if end > len(missingKeys) {
+            end = len(missingKeys)
+        }
+        chunk := missingKeys[i:end]
+
+        dbResult, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+            return d.getByIds(ctx, chunk)
+        })
+
+        if err != nil {
+            return nil, err // Fail fast if a chunk fails
+        }
+        
+        dbEntities = append(dbEntities, dbResult.([]*Note)...)
+    }
+
+    results = append(results, dbEntities...)
+
+    if inTx(ctx) {
+        return results, nil
+    }
+
+    // 3. Cache the newly fetched items for future zero-latency access
+    for _, entity := range dbEntities {
+        d.setCached(entity)
+    }
+
+    return results, nil
+}
+
+func (d *noteRepository) getByIds(ctx context.Context, missingKeys []interface{}) ([]*Note, error) {
+    const operation = "get_bulk_by_id"
+    dbStart := time.Now()
+
+    // Dynamically build ?,?,? placeholders for the IN clause
+    placeholders := make([]string, len(missingKeys))
+    for i := range missingKeys {
+        placeholders[i] = "?"
+    }
+
+    query := fmt.Sprintf(`
+        SELECT id, version, amount, body, meta, owner_id, pinned, remind_at, slug, visibility, created, updated, deleted_at
+        FROM notes
+        WHERE id IN (%s) AND deleted_at IS NULL
+    `, strings.Join(placeholders, ","))
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "note", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, dbErr
+    }
+
+    rows, err := db.QueryContext(ctx, query, missingKeys...)
+    if err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, fmt.Errorf("failed to bulk query notes: %w", err)
+    }
+    defer rows.Close()
+
+    var entities []*Note
+    for rows.Next() {
+        var entity Note
+        err := rows.Scan(
+            &entity.ID,
+            &entity.Version,
+            &entity.Amount,
+            &entity.Body,
+            &entity.Meta,
+            &entity.OwnerId,
+            &entity.Pinned,
+            &entity.RemindAt,
+            &entity.Slug,
+            &entity.Visibility,
+            &entity.Created,
+            &entity.Updated,
+            &entity.DeletedAt,
+        )
+        if err != nil {
+            d.telemetryProvider.IncDBError("note", operation)
+            return nil, fmt.Errorf("failed to scan Note: %w", err)
+        }
+        entities = append(entities, &entity)
+    }
+
+    d.telemetryProvider.IncDBRequest("note", operation)
+    d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(dbStart).Seconds())
+
+    return entities, nil
+}
+
+
+
+// UpdatePinnedByIds executes a bulk partial update using an IN clause.
+//
+// ⚠️ CAUTION - CACHE FLUSH NOTE ⚠️
+// Because bulk updates modify multiple records simultaneously, it is difficult to 
+// track and selectively invalidate individual cache entries safely. Therefore, if 
+// this operation successfully updates one or more rows, it will trigger a global 
+// FlushAllCache() to ensure local memory safety and broadcast cache invalidation 
+// to peer nodes via Pub/Sub. Use this operation carefully in high-read environments.
+//
+// This operation is hard-limited to 5000 items and executes in database batches of 500.
+func (d *noteRepository) UpdatePinnedByIds(ctx context.Context, pinned bool, ids []int64) error {
+    if d.configProvider.BlockedWrites("note") {
+        return ErrOperationBlocked
+    }
+
+    if len(ids) == 0 {
+        return nil // Nothing to update
+    }
+    
+    // Hard limit to prevent massive memory allocations and driver crashes
+    if len(ids) > 5000 {
+        return fmt.Errorf("bulk update operation exceeds maximum limit of 5000 items")
+    }
+
+    const operation = "update_bulk_update_pinned_by_ids"
+    d.telemetryProvider.IncDALOperation("note", operation)
+
+    var totalRowsAffected int64
+    batchSize := 500
+    
+    for i := 0; i < len(ids); i += batchSize {
+        end := i + batchSize
+        if end > len(ids) {
+            end = len(ids)
+        }
+        chunk := ids[i:end]
+        
+        result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+            // Pass the SET columns, then the chunk
+            return d.updatePinnedByIds(ctx, pinned, chunk)
+        })
+
+        if err != nil {
+            return err // Fail fast if a chunk fails
+        }
+        totalRowsAffected += result.(int64)
+    }
+
+    if totalRowsAffected > 0 {
+        // Since many rows changed at once, we perform a global flush to ensure local 
+        // node safety and trigger pub/sub notifications for peer nodes.
+        afterCommit(ctx, d.FlushAllCache)
+    }
+
+    return nil
+}
+
+func (d *noteRepository) updatePinnedByIds(ctx context.Context, pinned bool, ids []int64) (int64, error) {
+    if !inTx(ctx) {
+        return withinTx(ctx, func(ctx context.Context) (int64, error) {
+            return d.updatePinnedByIds(ctx, pinned, ids)
+        })
+    }
+    const operation = "update_bulk_update_pinned_by_ids"
+    dbStart := time.Now()
+    now := time.Now()
+
+    // 1. Dynamically build ?,?,? placeholders for the IN clause
+    placeholders := make([]string, len(ids))
+    for i := range ids {
+        placeholders[i] = "?"
+    }
+
+    query := fmt.Sprintf(`
+        UPDATE notes
+        SET
+            pinned = ?,
+            updated = ?,
+            version = version + 1
+        WHERE id IN (%s) AND deleted_at IS NULL
+    `, strings.Join(placeholders, ","))
+
+    // 2. Build the flat arguments array (SET arguments first, then WHERE IN arguments)
+    args := make([]interface{}, 0, 1 + 1 + len(ids))
+    args = append(args, pinned)
+    args = append(args, now) // the 'updated' timestamp
+
+    for _, val := range ids {
+        args = append(args, val)
+    }
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "note", true)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, dbErr
+    }
+    
+    // Snapshot the rows before the write to record its changes
+    before, auditErr := d.auditSnapshot(ctx, db, "id IN (?...)", inValues(ids))
+    if auditErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, auditErr
+    }
+
+
+    res, err := db.ExecContext(ctx, query, args...)
+    if err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, fmt.Errorf("failed to execute %s: %w", operation, err)
+    }
+
+    rowsAffected, err := res.RowsAffected()
+    if err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, fmt.Errorf("failed to get rows affected: %w", err)
+    }
+    
+    if auditErr := d.recordChanges(ctx, db, operation, before, "id IN (?...)", inValues(ids)); auditErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, auditErr
+    }
+
+
+    d.telemetryProvider.IncDBRequest("note", operation)
+    d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(dbStart).Seconds())
+
+    return rowsAffected, nil
+}
+
+
+
+// UpdateAmountByIds executes a bulk partial update using an IN clause.
+//
+// ⚠️ CAUTION - CACHE FLUSH NOTE ⚠️
+// Because bulk updates modify multiple records simultaneously, it is difficult to 
+// track and selectively invalidate individual cache entries safely. Therefore, if 
+// this operation successfully updates one or more rows, it will trigger a global 
+// FlushAllCache() to ensure local memory safety and broadcast cache invalidation 
+// to peer nodes via Pub/Sub. Use this operation carefully in high-read environments.
+//
+// This operation is hard-limited to 5000 items and executes in database batches of 500.
+func (d *noteRepository) UpdateAmountByIds(ctx context.Context, amount *Decimal, ids []int64) error {
+    if d.configProvider.BlockedWrites("note") {
+        return ErrOperationBlocked
+    }
+
+    v := validator{entity: "note"}
+    validateNoteAmount(&v, amount)
+    if err := v.err(); err != nil {
+        return err
+    }
+
+    if len(ids) == 0 {
+        return nil // Nothing to update
+    }
+    
+    // Hard limit to prevent massive memory allocations and driver crashes
+    if len(ids) > 5000 {
+        return fmt.Errorf("bulk update operation exceeds maximum limit of 5000 items")
+    }
+
+    const operation = "update_bulk_update_amount_by_ids"
+    d.telemetryProvider.IncDALOperation("note", operation)
+
+    var totalRowsAffected int64
+    batchSize := 500
+    
+    for i := 0; i < len(ids); i += batchSize {
+        end := i + batchSize
+        if end > len(ids) {
+            end = len(ids)
+        }
+        chunk := ids[i:end]
+        
+        result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+            // Pass the SET columns, then the chunk
+            return d.updateAmountByIds(ctx, amount, chunk)
+        })
+
+        if err != nil {
+            return err // Fail fast if a chunk fails
+        }
+        totalRowsAffected += result.(int64)
+    }
+
+    if totalRowsAffected > 0 {
+        // Since many rows changed at once, we perform a global flush to ensure local 
+        // node safety and trigger pub/sub notifications for peer nodes.
+        afterCommit(ctx, d.FlushAllCache)
+    }
+
+    return nil
+}
+
+func (d *noteRepository) updateAmountByIds(ctx context.Context, amount *Decimal, ids []int64) (int64, error) {
+    if !inTx(ctx) {
+        return withinTx(ctx, func(ctx context.Context) (int64, error) {
+            return d.updateAmountByIds(ctx, amount, ids)
+        })
+    }
+    const operation = "update_bulk_update_amount_by_ids"
+    dbStart := time.Now()
+    now := time.Now()
+
+    // 1. Dynamically build ?,?,? placeholders for the IN clause
+    placeholders := make([]string, len(ids))
+    for i := range ids {
+        placeholders[i] = "?"
+    }
+
+    query := fmt.Sprintf(`
+        UPDATE notes
+        SET
+            amount = ?,
+            updated = ?,
+            version = version + 1
+        WHERE id IN (%s) AND deleted_at IS NULL
+    `, strings.Join(placeholders, ","))
+
+    // 2. Build the flat arguments array (SET arguments first, then WHERE IN arguments)
+    args := make([]interface{}, 0, 1 + 1 + len(ids))
+    args = append(args, amount)
+    args = append(args, now) // the 'updated' timestamp
+
+    for _, val := range ids {
+        args = append(args, val)
+    }
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "note", true)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, dbErr
+    }
+    
+    // Snapshot the rows before the write to record its changes
+    before, auditErr := d.auditSnapshot(ctx, db, "id IN (?...)", inValues(ids))
+    if auditErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, auditErr
+    }
+
+
+    res, err := db.ExecContext(ctx, query, args...)
+    if err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, fmt.Errorf("failed to execute %s: %w", operation, err)
+    }
+
+    rowsAffected, err := res.RowsAffected()
+    if err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, fmt.Errorf("failed to get rows affected: %w", err)
+    }
+    
+    if auditErr := d.recordChanges(ctx, db, operation, before, "id IN (?...)", inValues(ids)); auditErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, auditErr
+    }
+
+
+    d.telemetryProvider.IncDBRequest("note", operation)
+    d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(dbStart).Seconds())
+
+    return rowsAffected, nil
+}
+
+
+
+func (d *noteRepository) ListByOwners(ctx context.Context, ownerIds []int64) ([]*Note, error) {
+    if d.configProvider.BlockedReads("note") {
+        return nil, ErrOperationBlocked
+    }
+
+    if len(ownerIds) == 0 {
+        return nil, nil // Nothing to fetch
+    }
+    
+    if len(ownerIds) > 5000 {
+        return nil, fmt.Errorf("bulk list operation exceeds maximum limit of 5000 items")
+    }
+
+    const operation = "list_bulk_list_by_owners"
+    d.telemetryProvider.IncDALOperation("note", operation)
+
+    var results []*Note
+    
+    batchSize := 500
+    
+    for i := 0; i < len(ownerIds); i += batchSize {
+        end := i + batchSize
+        if end > len(ownerIds) {
+            end = len(ownerIds)
+        }
+        chunk := ownerIds[i:end]
+
+        // Convert typed chunk to []interface{} for the variadic SQL args
+        args := make([]interface{}, len(chunk))
+        for j, v := range chunk {
+            args[j] = v
+        }
+
+        dbResult, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+            return d.listByOwners(ctx, args)
+        })
+
+        if err != nil {
+            return nil, err // Fail fast if a chunk fails
+        }
+        
+        dbEntities := dbResult.([]*Note)
+        results = append(results, dbEntities...)
+
+        // Cache the newly fetched items. Transactions bypass caches to see their own writes.
+        if !inTx(ctx) {
+            for _, entity := range dbEntities {
+                d.setCached(entity)
+            }
+        }
+    }
+
+    return results, nil
+}
+
+func (d *noteRepository) listByOwners(ctx context.Context, chunkArgs []interface{}) ([]*Note, error) {
+    const operation = "list_bulk_list_by_owners"
+    dbStart := time.Now()
+
+    // Dynamically build ?,?,? placeholders for the IN clause
+    placeholders := make([]string, len(chunkArgs))
+    for i := range chunkArgs {
+        placeholders[i] = "?"
+    }
+
+    query := fmt.Sprintf(`
+        SELECT id, version, amount, body, meta, owner_id, pinned, remind_at, slug, visibility, created, updated, deleted_at
+        FROM notes
+        WHERE owner_id IN (%s) AND deleted_at IS NULL
+    `, strings.Join(placeholders, ","))
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "note", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, dbErr
+    }
+
+    // Build the final arguments slice (Scalars first, then chunked IN values)
+    var queryArgs []interface{}
+    queryArgs = append(queryArgs, chunkArgs...)
+
+    rows, err := db.QueryContext(ctx, query, queryArgs...)
+    if err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, fmt.Errorf("failed to bulk query notes: %w", err)
+    }
+    defer rows.Close()
+
+    var entities []*Note
+    for rows.Next() {
+        var entity Note
+        err := rows.Scan(
+            &entity.ID,
+            &entity.Version,
+            &entity.Amount,
+            &entity.Body,
+            &entity.Meta,
+            &entity.OwnerId,
+            &entity.Pinned,
+            &entity.RemindAt,
+            &entity.Slug,
+            &entity.Visibility,
+            &entity.Created,
+            &entity.Updated,
+            &entity.DeletedAt,
+        )
+        if err != nil {
+            d.telemetryProvider.IncDBError("note", operation)
+            return nil, fmt.Errorf("failed to scan Note: %w", err)
+        }
+        entities = append(entities, &entity)
+    }
+
+    if err = rows.Err(); err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, fmt.Errorf("rows error: %w", err)
+    }
+
+    d.telemetryProvider.IncDBRequest("note", operation)
+    d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(dbStart).Seconds())
+
+    return entities, nil
+}
+
+
+
+func (d *noteRepository) GetSlugsByOwner(ctx context.Context, ownerId int64) ([]string, error) {
+    if d.configProvider.BlockedReads("note") {
+        return nil, ErrOperationBlocked
+    }
+
+    const operation = "pluck_get_slugs_by_owner"
+    d.telemetryProvider.IncDALOperation("note", operation)
+
+    cacheKey := fmt.Sprintf("note_pluck_get_slugs_by_owner:%v", ownerId)
+    // Transactions bypass caches to see their own writes.
+    val, found := d.listCache.Get(cacheKey)
+    if found && !inTx(ctx) {
+        cachedSlice, ok := val.([]string)
+        if !ok {
+            return nil, fmt.Errorf("noteRepository.GetSlugsByOwner: Cache returned wrong type")
+        }
+        d.telemetryProvider.IncCacheHit("note", operation)
+        return cachedSlice, nil
+    }
+
+    d.telemetryProvider.IncCacheMiss("note", operation)
+
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+        return d.getSlugsByOwner(ctx, ownerId)
+    })
+
+    if err != nil {
+        return nil, err
+    }
+
+    slicedResult := result.([]string)
+    
+    // Store in the shared listCache
+    if !inTx(ctx) {
+        d.listCache.Set(cacheKey, slicedResult, time.Second*time.Duration(60))
+    }
+
+    return slicedResult, nil
+}
+
+func (d *noteRepository) getSlugsByOwner(ctx context.Context, ownerId int64) ([]string, error) {
+    const operation = "pluck_get_slugs_by_owner"
+    dbStart := time.Now()
+
+    query := `SELECT slug FROM notes WHERE (owner_id = ?) AND deleted_at IS NULL`
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "note", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, dbErr
+    }
+
+    
+    rows, err := db.QueryContext(ctx, query, ownerId)
+    
+
+    if err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, fmt.Errorf("failed to query pluck %s: %w", operation, err)
+    }
+    defer rows.Close()
+
+    var results []string
+    for rows.Next() {
+        var item string
+        if err := rows.Scan(&item); err != nil {
+            d.telemetryProvider.IncDBError("note", operation)
+            return nil, fmt.Errorf("failed to scan pluck item: %w", err)
+        }
+        results = append(results, item)
+    }
+
+    if err = rows.Err(); err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, fmt.Errorf("rows error: %w", err)
+    }
+
+    d.telemetryProvider.IncDBRequest("note", operation)
+    d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(dbStart).Seconds())
+
+    return results, nil
+}
+
+
+
+func (d *noteRepository) GetSlugsByOwners(ctx context.Context, ownerIds []int64) ([]string, error) {
+    if d.configProvider.BlockedReads("note") {
+        return nil, ErrOperationBlocked
+    }
+    if err := checkInListSize("ownerIds", len(ownerIds)); err != nil {
+        return nil, err
+    }
+
+    const operation = "pluck_get_slugs_by_owners"
+    d.telemetryProvider.IncDALOperation("note", operation)
+
+    cacheKey := fmt.Sprintf("note_pluck_get_slugs_by_owners:%v", inListKey(ownerIds))
+    // Transactions bypass caches to see their own writes.
+    val, found := d.listCache.Get(cacheKey)
+    if found && !inTx(ctx) {
+        cachedSlice, ok := val.([]string)
+        if !ok {
+            return nil, fmt.Errorf("noteRepository.GetSlugsByOwners: Cache returned wrong type")
+        }
+        d.telemetryProvider.IncCacheHit("note", operation)
+        return cachedSlice, nil
+    }
+
+    d.telemetryProvider.IncCacheMiss("note", operation)
+
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+        return d.getSlugsByOwners(ctx, ownerIds)
+    })
+
+    if err != nil {
+        return nil, err
+    }
+
+    slicedResult := result.([]string)
+    
+    // Store in the shared listCache
+    if !inTx(ctx) {
+        d.listCache.Set(cacheKey, slicedResult, time.Second*time.Duration(60))
+    }
+
+    return slicedResult, nil
+}
+
+func (d *noteRepository) getSlugsByOwners(ctx context.Context, ownerIds []int64) ([]string, error) {
+    const operation = "pluck_get_slugs_by_owners"
+    dbStart := time.Now()
+
+    query := `SELECT slug FROM notes WHERE (owner_id IN (?...)) AND deleted_at IS NULL`
+    query, args := expandInLists(query, inValues(ownerIds))
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "note", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, dbErr
+    }
+
+    
+    rows, err := db.QueryContext(ctx, query, args...)
+    
+
+    if err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, fmt.Errorf("failed to query pluck %s: %w", operation, err)
+    }
+    defer rows.Close()
+
+    var results []string
+    for rows.Next() {
+        var item string
+        if err := rows.Scan(&item); err != nil {
+            d.telemetryProvider.IncDBError("note", operation)
+            return nil, fmt.Errorf("failed to scan pluck item: %w", err)
+        }
+        results = append(results, item)
+    }
+
+    if err = rows.Err(); err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, fmt.Errorf("rows error: %w", err)
+    }
+
+    d.telemetryProvider.IncDBRequest("note", operation)
+    d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(dbStart).Seconds())
+
+    return results, nil
+}
+
+
+
+// MaxOwnerId returns the max of owner_id, 0 when no rows match.
+// CACHING NOTE: Uses the countCache, invalidated like the counts of lists.
+func (d *noteRepository) MaxOwnerId(ctx context.Context) (float64, error) {
+	if d.configProvider.BlockedReads("note") {
+		return 0, ErrOperationBlocked
+	}
+
+	const operation = "aggregate_max_owner_id"
+	d.telemetryProvider.IncDALOperation("note", operation)
+
+	cacheKey := fmt.Sprintf("note_aggregate_max_owner_id")
+	// Transactions bypass caches to see their own writes.
+	val, found := d.countCache.Get(cacheKey)
+	if found && !inTx(ctx) {
+		value, ok := val.(float64)
+		if !ok {
+			return 0, fmt.Errorf("noteRepository.MaxOwnerId: Cache returned wrong type; expected float64")
+		}
+
+		d.telemetryProvider.IncCacheHit("note", operation)
+		return value, nil
+	}
+
+	d.telemetryProvider.IncCacheMiss("note", operation)
+
+	value, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return d.maxOwnerId(ctx)
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if !inTx(ctx) {
+		d.countCache.Set(cacheKey, value, time.Second*60)
+	}
+
+	return value.(float64), nil
+}
+
+func (d *noteRepository) maxOwnerId(ctx context.Context) (float64, error) {
+	const operation = "aggregate_max_owner_id"
+	dbStart := time.Now()
+
+	query := `SELECT MAX(owner_id) FROM notes WHERE deleted_at IS NULL`
+
+	db, dbErr := databaseFor(ctx, d.dbProvider, "note", false)
+	if dbErr != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return 0, dbErr
+	}
+
+	// The aggregate of no rows is NULL.
+	var value sql.NullFloat64
+	row := db.QueryRowContext(ctx, query)
+	if err := row.Scan(&value); err != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return 0, fmt.Errorf("failed to aggregate notes: %w", err)
+	}
+
+	d.telemetryProvider.IncDBRequest("note", operation)
+	d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(dbStart).Seconds())
+	return value.Float64, nil
+}
+
+
+
+// SumAmountByOwnerIdAndMinAmount returns the sum of amount where owner_id = :owner_id AND amount >= :min_amount, 0 when no rows match.
+// CACHING NOTE: Uses the countCache, invalidated like the counts of lists.
+func (d *noteRepository) SumAmountByOwnerIdAndMinAmount(ctx context.Context, ownerId int64, minAmount *Decimal) (Decimal, error) {
+	if d.configProvider.BlockedReads("note") {
+		return Decimal{}, ErrOperationBlocked
+	}
+
+	const operation = "aggregate_sum_amount_by_owner_id_and_min_amount"
+	d.telemetryProvider.IncDALOperation("note", operation)
+
+	cacheKey := fmt.Sprintf("note_aggregate_sum_amount_by_owner_id_and_min_amount:%v:%v", ownerId, func() interface{} { if minAmount == nil { return "<<null>>" }; return *minAmount }())
+	// Transactions bypass caches to see their own writes.
+	val, found := d.countCache.Get(cacheKey)
+	if found && !inTx(ctx) {
+		value, ok := val.(Decimal)
+		if !ok {
+			return Decimal{}, fmt.Errorf("noteRepository.SumAmountByOwnerIdAndMinAmount: Cache returned wrong type; expected Decimal")
+		}
+
+		d.telemetryProvider.IncCacheHit("note", operation)
+		return value, nil
+	}
+
+	d.telemetryProvider.IncCacheMiss("note", operation)
+
+	value, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return d.sumAmountByOwnerIdAndMinAmount(ctx, ownerId, minAmount)
+	})
+
+	if err != nil {
+		return Decimal{}, err
+	}
+
+	if !inTx(ctx) {
+		d.countCache.Set(cacheKey, value, time.Second*60)
+	}
+
+	return value.(Decimal), nil
+}
+
+func (d *noteRepository) sumAmountByOwnerIdAndMinAmount(ctx context.Context, ownerId int64, minAmount *Decimal) (Decimal, error) {
+	const operation = "aggregate_sum_amount_by_owner_id_and_min_amount"
+	dbStart := time.Now()
+
+	query := `SELECT SUM(amount) FROM notes WHERE (owner_id = ? AND amount >= ?) AND deleted_at IS NULL`
+
+	db, dbErr := databaseFor(ctx, d.dbProvider, "note", false)
+	if dbErr != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return Decimal{}, dbErr
+	}
+
+	// The aggregate of no rows is NULL.
+	var value *Decimal
+	row := db.QueryRowContext(ctx, query, ownerId, minAmount)
+	if err := row.Scan(&value); err != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return Decimal{}, fmt.Errorf("failed to aggregate notes: %w", err)
+	}
+
+	d.telemetryProvider.IncDBRequest("note", operation)
+	d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(dbStart).Seconds())
+	if value == nil {
+		return Decimal{}, nil
+	}
+	return *value, nil
+}
+
+
+
+// ExistsByOwnerIdAndPinned reports whether a note where owner_id = :owner_id AND pinned = :pinned exists, without loading the row.
+func (d *noteRepository) ExistsByOwnerIdAndPinned(ctx context.Context, ownerId int64, pinned bool) (bool, error) {
+	if d.configProvider.BlockedReads("note") {
+		return false, ErrOperationBlocked
+	}
+
+	const operation = "exists_by_owner_id_and_pinned"
+	d.telemetryProvider.IncDALOperation("note", operation)
+
+	found, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return d.existsByOwnerIdAndPinned(ctx, ownerId, pinned)
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return found.(bool), nil
+}
+
+func (d *noteRepository) existsByOwnerIdAndPinned(ctx context.Context, ownerId int64, pinned bool) (bool, error) {
+	const operation = "exists_by_owner_id_and_pinned"
+	dbStart := time.Now()
+
+	query := `SELECT 1 FROM notes WHERE (owner_id = ? AND pinned = ?) AND deleted_at IS NULL LIMIT 1`
+
+	db, dbErr := databaseFor(ctx, d.dbProvider, "note", false)
+	if dbErr != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return false, dbErr
+	}
+
+	var one int
+	err := db.QueryRowContext(ctx, query, ownerId, pinned).Scan(&one)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		d.telemetryProvider.IncDBError("note", operation)
+		return false, fmt.Errorf("failed to check note existence: %w", err)
+	}
+
+	d.telemetryProvider.IncDBRequest("note", operation)
+	d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(dbStart).Seconds())
+	return err == nil, nil
+}
+
+
+
+// CountByPinnedGrouped counts the notes where owner_id = :owner_id per pinned value.
+// Values without rows are missing from the map.
+// CACHING NOTE: Uses the countCache, invalidated like the counts of lists.
+func (d *noteRepository) CountByPinnedGrouped(ctx context.Context, ownerId int64) (map[bool]int64, error) {
+	if d.configProvider.BlockedReads("note") {
+		return nil, ErrOperationBlocked
+	}
+
+	const operation = "count_by_pinned_grouped"
+	d.telemetryProvider.IncDALOperation("note", operation)
+
+	cacheKey := fmt.Sprintf("note_group_count_by_pinned_grouped:%v", ownerId)
+	// Transactions bypass caches to see their own writes.
+	val, found := d.countCache.Get(cacheKey)
+	if found && !inTx(ctx) {
+		counts, ok := val.(map[bool]int64)
+		if !ok {
+			return nil, fmt.Errorf("noteRepository.CountByPinnedGrouped: Cache returned wrong type; expected map[bool]int64")
+		}
+
+		d.telemetryProvider.IncCacheHit("note", operation)
+		// Callers may modify the map, the cached one stays untouched.
+		return maps.Clone(counts), nil
+	}
+
+	d.telemetryProvider.IncCacheMiss("note", operation)
+
+	counts, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return d.countByPinnedGrouped(ctx, ownerId)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !inTx(ctx) {
+		d.countCache.Set(cacheKey, counts, time.Second*60)
+	}
+
+	return maps.Clone(counts.(map[bool]int64)), nil
+}
+
+func (d *noteRepository) countByPinnedGrouped(ctx context.Context, ownerId int64) (map[bool]int64, error) {
+	const operation = "count_by_pinned_grouped"
+	dbStart := time.Now()
+
+	query := `SELECT pinned, count(*) FROM notes WHERE (owner_id = ?) AND deleted_at IS NULL GROUP BY pinned`
+
+	db, dbErr := databaseFor(ctx, d.dbProvider, "note", false)
+	if dbErr != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return nil, dbErr
+	}
+
+	rows, err := db.QueryContext(ctx, query, ownerId)
+	if err != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return nil, fmt.Errorf("failed to count notes per pinned: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[bool]int64)
+	for rows.Next() {
+		var value bool
+		var count int64
+		if err := rows.Scan(&value, &count); err != nil {
+			d.telemetryProvider.IncDBError("note", operation)
+			return nil, fmt.Errorf("failed to scan pinned count: %w", err)
+		}
+		counts[value] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		d.telemetryProvider.IncDBError("note", operation)
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	d.telemetryProvider.IncDBRequest("note", operation)
+	d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(dbStart).Seconds())
+	return counts, nil
+}
+
+
+
+
+
+
+
+
+
+
+
+
+
+
+
+
+
+
+func (d *noteRepository) DeleteByOwner(ctx context.Context, ownerId int64, limit int) (int64, error) {
+    if d.configProvider.BlockedWrites("note") {
+        return 0, ErrOperationBlocked
+    }
+
+    // Clamp the limit to a safe range
+    if limit <= 0 || limit > 5000 {
+        limit = 5000
+    }
+
+    const operation = "delete_by_owner"
+    d.telemetryProvider.IncDALOperation("note", operation)
+
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+        return d.deleteByOwner(ctx, ownerId, limit)
+    })
+
+    if err != nil {
+        return 0, err
+    }
+
+    rowsAffected := result.(int64)
+    
+    if rowsAffected > 0 {
+        afterCommit(ctx, d.FlushAllCache)
+    }
+
+    return rowsAffected, nil
+}
+
+func (d *noteRepository) deleteByOwner(ctx context.Context, ownerId int64, limit int) (int64, error) {
+    if !inTx(ctx) {
+        return withinTx(ctx, func(ctx context.Context) (int64, error) {
+            return d.deleteByOwner(ctx, ownerId, limit)
+        })
+    }
+    const operation = "delete_by_owner"
+    dbStart := time.Now()
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "note", true)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, dbErr
+    }
+
+    // Snapshot the rows to delete for the history, then delete exactly those by id
+    before, auditErr := d.auditSnapshot(ctx, db, `(owner_id = ?) AND deleted_at IS NULL`+fmt.Sprintf(" ORDER BY id LIMIT %d", limit), ownerId)
+    if auditErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, auditErr
+    }
+    if len(before) == 0 {
+        return 0, nil
+    }
+
+    ids := make([]int64, 0, len(before))
+    for id := range before {
+        ids = append(ids, id)
+    }
+
+    query, args := expandInLists(`UPDATE notes SET deleted_at = CURRENT_TIMESTAMP, updated = CURRENT_TIMESTAMP, version = version + 1, slug = slug || '-del-' || lower(hex(randomblob(16))) WHERE id IN (?...)`, inValues(ids))
+
+    res, err := db.ExecContext(ctx, query, args...)
+    if err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, fmt.Errorf("failed to execute custom delete %s: %w", operation, err)
+    }
+
+    rowsAffected, err := res.RowsAffected()
+    if err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, fmt.Errorf("failed to get rows affected: %w", err)
+    }
+    
+    if auditErr := d.recordChanges(ctx, db, operation, before, "id IN (?...)", inValues(ids)); auditErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, auditErr
+    }
+
+    d.telemetryProvider.IncDBRequest("note", operation)
+    d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(dbStart).Seconds())
+    return rowsAffected, nil
+}
+
+
+// HardDeleteByOwner executes a permanent custom bulk delete operation.
+// 
+// PERFORMANCE NOTE: To prevent database locks, undo-log bloat, and replication lag, 
+// this operation requires a limit parameter. If the provided limit is <= 0 or > 5000, 
+// it will be automatically clamped to 5000.
+// 
+// Example usage for bulk deletion:
+//
+//	batchSize := 5000
+//	for {
+//		rowsAffected, err := repo.HardDeleteByOwner(ctx, /* args */, batchSize)
+//		if err != nil {
+//			// handle error appropriately
+//			break
+//		}
+//		if rowsAffected == 0 {
+//			break // All matching rows have been processed
+//		}
+//		time.Sleep(100 * time.Millisecond) // Yield database resources between batches
+//	}
+func (d *noteRepository) HardDeleteByOwner(ctx context.Context, ownerId int64, limit int) (int64, error) {
+    if d.configProvider.BlockedWrites("note") {
+        return 0, ErrOperationBlocked
+    }
+
+    // Clamp the limit to a safe range
+    if limit <= 0 || limit > 5000 {
+        limit = 5000
+    }
+
+    const operation = "hard_delete_by_owner"
+    d.telemetryProvider.IncDALOperation("note", operation)
+
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+        return d.hardDeleteByOwner(ctx, ownerId, limit)
+    })
+
+    if err != nil {
+        return 0, err
+    }
+
+    rowsAffected := result.(int64)
+    
+    // Only flush caches if the database state actually changed
+    if rowsAffected > 0 {
+        afterCommit(ctx, d.FlushAllCache)
+    }
+
+    return rowsAffected, nil
+}
+
+func (d *noteRepository) hardDeleteByOwner(ctx context.Context, ownerId int64, limit int) (int64, error) {
+    if !inTx(ctx) {
+        return withinTx(ctx, func(ctx context.Context) (int64, error) {
+            return d.hardDeleteByOwner(ctx, ownerId, limit)
+        })
+    }
+    const operation = "hard_delete_by_owner"
+    dbStart := time.Now()
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "note", true)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, dbErr
+    }
+
+    // Snapshot the rows to delete for the history, then delete exactly those by id
+    before, auditErr := d.auditSnapshot(ctx, db, `owner_id = ?`+fmt.Sprintf(" ORDER BY id LIMIT %d", limit), ownerId)
+    if auditErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, auditErr
+    }
+    if len(before) == 0 {
+        return 0, nil
+    }
+
+    ids := make([]int64, 0, len(before))
+    for id := range before {
+        ids = append(ids, id)
+    }
+
+    query, args := expandInLists(`DELETE FROM notes WHERE id IN (?...)`, inValues(ids))
+
+    res, err := db.ExecContext(ctx, query, args...)
+    if err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, fmt.Errorf("failed to execute custom hard delete %s: %w", operation, err)
+    }
+
+    rowsAffected, err := res.RowsAffected()
+    if err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, fmt.Errorf("failed to get rows affected: %w", err)
+    }
+    
+    if auditErr := d.recordChanges(ctx, db, operation, before, "id IN (?...)", inValues(ids)); auditErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, auditErr
+    }
+
+    d.telemetryProvider.IncDBRequest("note", operation)
+    d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(dbStart).Seconds())
+    return rowsAffected, nil
+}
+
+
+
+
+
+
+
+
+
+
+
+func (d *noteRepository) DeleteByOwners(ctx context.Context, ownerIds []int64, limit int) (int64, error) {
+    if d.configProvider.BlockedWrites("note") {
+        return 0, ErrOperationBlocked
+    }
+    if err := checkInListSize("ownerIds", len(ownerIds)); err != nil {
+        return 0, err
+    }
+
+    // Clamp the limit to a safe range
+    if limit <= 0 || limit > 5000 {
+        limit = 5000
+    }
+
+    const operation = "delete_by_owners"
+    d.telemetryProvider.IncDALOperation("note", operation)
+
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+        return d.deleteByOwners(ctx, ownerIds, limit)
+    })
+
+    if err != nil {
+        return 0, err
+    }
+
+    rowsAffected := result.(int64)
+    
+    if rowsAffected > 0 {
+        afterCommit(ctx, d.FlushAllCache)
+    }
+
+    return rowsAffected, nil
+}
+
+func (d *noteRepository) deleteByOwners(ctx context.Context, ownerIds []int64, limit int) (int64, error) {
+    if !inTx(ctx) {
+        return withinTx(ctx, func(ctx context.Context) (int64, error) {
+            return d.deleteByOwners(ctx, ownerIds, limit)
+        })
+    }
+    const operation = "delete_by_owners"
+    dbStart := time.Now()
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "note", true)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, dbErr
+    }
+
+    // Snapshot the rows to delete for the history, then delete exactly those by id
+    before, auditErr := d.auditSnapshot(ctx, db, `(owner_id IN (?...)) AND deleted_at IS NULL`+fmt.Sprintf(" ORDER BY id LIMIT %d", limit), inValues(ownerIds))
+    if auditErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, auditErr
+    }
+    if len(before) == 0 {
+        return 0, nil
+    }
+
+    ids := make([]int64, 0, len(before))
+    for id := range before {
+        ids = append(ids, id)
+    }
+
+    query, args := expandInLists(`UPDATE notes SET deleted_at = CURRENT_TIMESTAMP, updated = CURRENT_TIMESTAMP, version = version + 1, slug = slug || '-del-' || lower(hex(randomblob(16))) WHERE id IN (?...)`, inValues(ids))
+
+    res, err := db.ExecContext(ctx, query, args...)
+    if err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, fmt.Errorf("failed to execute custom delete %s: %w", operation, err)
+    }
+
+    rowsAffected, err := res.RowsAffected()
+    if err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, fmt.Errorf("failed to get rows affected: %w", err)
+    }
+    
+    if auditErr := d.recordChanges(ctx, db, operation, before, "id IN (?...)", inValues(ids)); auditErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, auditErr
+    }
+
+    d.telemetryProvider.IncDBRequest("note", operation)
+    d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(dbStart).Seconds())
+    return rowsAffected, nil
+}
+
+
+// HardDeleteByOwners executes a permanent custom bulk delete operation.
+// 
+// PERFORMANCE NOTE: To prevent database locks, undo-log bloat, and replication lag, 
+// this operation requires a limit parameter. If the provided limit is <= 0 or > 5000, 
+// it will be automatically clamped to 5000.
+// 
+// Example usage for bulk deletion:
+//
+//	batchSize := 5000
+//	for {
+//		rowsAffected, err := repo.HardDeleteByOwners(ctx, /* args */, batchSize)
+//		if err != nil {
+//			// handle error appropriately
+//			break
+//		}
+//		if rowsAffected == 0 {
+//			break // All matching rows have been processed
+//		}
+//		time.Sleep(100 * time.Millisecond) // Yield database resources between batches
+//	}
+func (d *noteRepository) HardDeleteByOwners(ctx context.Context, ownerIds []int64, limit int) (int64, error) {
+    if d.configProvider.BlockedWrites("note") {
+        return 0, ErrOperationBlocked
+    }
+    if err := checkInListSize("ownerIds", len(ownerIds)); err != nil {
+        return 0, err
+    }
+
+    // Clamp the limit to a safe range
+    if limit <= 0 || limit > 5000 {
+        limit = 5000
+    }
+
+    const operation = "hard_delete_by_owners"
+    d.telemetryProvider.IncDALOperation("note", operation)
+
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+        return d.hardDeleteByOwners(ctx, ownerIds, limit)
+    })
+
+    if err != nil {
+        return 0, err
+    }
+
+    rowsAffected := result.(int64)
+    
+    // Only flush caches if the database state actually changed
+    if rowsAffected > 0 {
+        afterCommit(ctx, d.FlushAllCache)
+    }
+
+    return rowsAffected, nil
+}
+
+func (d *noteRepository) hardDeleteByOwners(ctx context.Context, ownerIds []int64, limit int) (int64, error) {
+    if !inTx(ctx) {
+        return withinTx(ctx, func(ctx context.Context) (int64, error) {
+            return d.hardDeleteByOwners(ctx, ownerIds, limit)
+        })
+    }
+    const operation = "hard_delete_by_owners"
+    dbStart := time.Now()
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "note", true)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, dbErr
+    }
+
+    // Snapshot the rows to delete for the history, then delete exactly those by id
+    before, auditErr := d.auditSnapshot(ctx, db, `owner_id IN (?...)`+fmt.Sprintf(" ORDER BY id LIMIT %d", limit), inValues(ownerIds))
+    if auditErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, auditErr
+    }
+    if len(before) == 0 {
+        return 0, nil
+    }
+
+    ids := make([]int64, 0, len(before))
+    for id := range before {
+        ids = append(ids, id)
+    }
+
+    query, args := expandInLists(`DELETE FROM notes WHERE id IN (?...)`, inValues(ids))
+
+    res, err := db.ExecContext(ctx, query, args...)
+    if err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, fmt.Errorf("failed to execute custom hard delete %s: %w", operation, err)
+    }
+
+    rowsAffected, err := res.RowsAffected()
+    if err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, fmt.Errorf("failed to get rows affected: %w", err)
+    }
+    
+    if auditErr := d.recordChanges(ctx, db, operation, before, "id IN (?...)", inValues(ids)); auditErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return 0, auditErr
+    }
+
+    d.telemetryProvider.IncDBRequest("note", operation)
+    d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(dbStart).Seconds())
+    return rowsAffected, nil
+}
+
+
+
+
+
+
+// noteAuditValues returns the columns of entity recorded in its history.
+func noteAuditValues(entity *Note) map[string]interface{} {
+    return map[string]interface{}{
+        "amount": entity.Amount,
+        "body": entity.Body,
+        "meta": entity.Meta,
+        "owner_id": entity.OwnerId,
+        "pinned": entity.Pinned,
+        "remind_at": entity.RemindAt,
+        "slug": entity.Slug,
+        "visibility": entity.Visibility,
+        "deleted_at": entity.DeletedAt,
+    }
+}
+
+// History returns the recorded changes of the Note with the given id, oldest first. Pass 0 as startID
+// for the first page, then the ID of the last HistoryEntry of the previous page.
+func (d *noteRepository) History(ctx context.Context, id int64, startID int64, pageSize int) ([]*HistoryEntry, error) {
+    if d.configProvider.BlockedReads("note") {
+        return nil, ErrOperationBlocked
+    }
+
+    const operation = "history"
+    d.telemetryProvider.IncDALOperation("note", operation)
+
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+        return d.history(ctx, id, startID, pageSize)
+    })
+    if err != nil {
+        return nil, err
+    }
+    return result.([]*HistoryEntry), nil
+}
+
+func (d *noteRepository) history(ctx context.Context, id int64, startID int64, pageSize int) ([]*HistoryEntry, error) {
+    const operation = "history"
+    dbStart := time.Now()
+
+    query := `
+        SELECT id, entity_id, version, operation, actor, old_values, new_values, created
+        FROM notes_history
+        WHERE entity_id = ? AND id > ?
+        ORDER BY id
+        LIMIT ?
+    `
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "note", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, dbErr
+    }
+
+    rows, err := db.QueryContext(ctx, query, id, startID, pageSize)
+    if err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, fmt.Errorf("failed to query note history: %w", err)
+    }
+    defer rows.Close()
+
+    var entries []*HistoryEntry
+    for rows.Next() {
+        var entry HistoryEntry
+        var actor sql.NullString
+        var oldValues, newValues []byte
+        err := rows.Scan(&entry.ID, &entry.EntityID, &entry.Version, &entry.Operation, &actor, &oldValues, &newValues, &entry.Created)
+        if err != nil {
+            d.telemetryProvider.IncDBError("note", operation)
+            return nil, fmt.Errorf("failed to scan note history: %w", err)
+        }
+        entry.Actor = actor.String
+        entry.OldValues = oldValues
+        entry.NewValues = newValues
+        entries = append(entries, &entry)
+    }
+
+    if err = rows.Err(); err != nil {
+        d.telemetryProvider.IncDBError("note", operation)
+        return nil, fmt.Errorf("rows error: %w", err)
+    }
+
+    d.telemetryProvider.IncDBRequest("note", operation)
+    d.telemetryProvider.ObserveDBLatency("note", operation, time.Since(dbStart).Seconds())
+    return entries, nil
+}
+
+// auditSnapshot loads the Notes matching where from db, soft deleted ones included, keyed by id.
+// The rows stay locked until the transaction ends, so the history sees them as the write leaves them.
+func (d *noteRepository) auditSnapshot(ctx context.Context, db dbExecutor, where string, args ...interface{}) (map[int64]*Note, error) {
+    query, args := expandInLists(`SELECT id, version, amount, body, meta, owner_id, pinned, remind_at, slug, visibility, created, updated, deleted_at FROM notes WHERE `+where+``, args...)
+
+    rows, err := db.QueryContext(ctx, query, args...)
+    if err != nil {
+        return nil, fmt.Errorf("failed to snapshot notes: %w", err)
+    }
+    defer rows.Close()
+
+    result := make(map[int64]*Note)
+    for rows.Next() {
+        var entity Note
+        err := rows.Scan(
+            &entity.ID,
+            &entity.Version,
+            &entity.Amount,
+            &entity.Body,
+            &entity.Meta,
+            &entity.OwnerId,
+            &entity.Pinned,
+            &entity.RemindAt,
+            &entity.Slug,
+            &entity.Visibility,
+            &entity.Created,
+            &entity.Updated,
+            &entity.DeletedAt,
+        )
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan Note: %w", err)
+        }
+        result[entity.ID] = &entity
+    }
+
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("failed to snapshot notes: %w", err)
+    }
+    return result, nil
+}
+
+// recordChanges records, in the transaction of db, every Note matching where or found in before whose
+// columns changed, stamped with operation, in its history with the actor of ctx and as an outbox event.
+func (d *noteRepository) recordChanges(ctx context.Context, db dbExecutor, operation string, before map[int64]*Note, where string, args ...interface{}) error {
+    after, err := d.auditSnapshot(ctx, db, where, args...)
+    if err != nil {
+        return err
+    }
+
+    changes, err := auditChanges(before, after, noteAuditValues, func(entity *Note) int32 { return entity.Version })
+    if err != nil {
+        return err
+    }
+    now := time.Now()
+
+    query := `
+        INSERT INTO notes_history (entity_id, version, operation, actor, old_values, new_values, created)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
+    `
+
+    actor := nullableActor(ActorFromContext(ctx))
+    for _, change := range changes {
+        _, err := db.ExecContext(ctx, query, change.entityID, change.version, operation, actor, change.oldValues, change.newValues, now)
+        if err != nil {
+            return fmt.Errorf("failed to record Note history: %w", err)
+        }
+    }
+
+    outboxQuery := `
+        INSERT INTO dal_outbox (entity, entity_id, operation, version, payload, attempts, next_attempt_at, created)
+        VALUES (?, ?, ?, ?, ?, 0, ?, ?)
+    `
+
+    for _, change := range changes {
+        payload, err := json.Marshal(change.entity)
+        if err != nil {
+            return fmt.Errorf("failed to encode Note event: %w", err)
+        }
+        _, err = db.ExecContext(ctx, outboxQuery, "note", change.entityID, operation, change.version, string(payload), now, now)
+        if err != nil {
+            return fmt.Errorf("failed to record Note event: %w", err)
+        }
+    }
+    return nil
+}
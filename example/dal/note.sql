@@ -0,0 +1,61 @@
+CREATE TABLE notes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    version INT DEFAULT 0,
+    amount DECIMAL(10,2),
+    body text NOT NULL,
+    meta TEXT,
+    owner_id BIGINT NOT NULL,
+    pinned BOOLEAN NOT NULL,
+    remind_at DATETIME,
+    slug VARCHAR(255) NOT NULL,
+    visibility VARCHAR(255) CHECK (visibility IN ('private', 'shared', 'public')),
+    
+    deleted_at TIMESTAMP NULL,
+    
+    created TIMESTAMP,
+    updated TIMESTAMP
+);
+
+-- Unique indexes as they serve Get operations returning single entity
+CREATE UNIQUE INDEX idx_notes_slug ON notes (slug);
+
+-- Indexes that serve all operations
+CREATE INDEX idx_notes_owner_id ON notes (owner_id);
+CREATE INDEX idx_notes_owner_id_amount ON notes (owner_id, amount);
+CREATE INDEX idx_notes_owner_id_created_id ON notes (owner_id, created, id);
+CREATE INDEX idx_notes_owner_id_pinned ON notes (owner_id, pinned);
+CREATE INDEX idx_notes_owner_id_slug ON notes (owner_id, slug);
+-- WARNING: no index covers list 'search' filtered by owner_id, remind_at
+
+
+
+CREATE INDEX idx_notes_deleted_at ON notes (deleted_at);
+
+-- History of every change, written in the transaction of the change
+CREATE TABLE notes_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    entity_id BIGINT NOT NULL,
+    version INT NOT NULL,
+    operation VARCHAR(64) NOT NULL,
+    actor VARCHAR(255),
+    old_values TEXT,
+    new_values TEXT,
+    created TIMESTAMP NOT NULL
+);
+CREATE INDEX idx_notes_history_entity_id ON notes_history (entity_id, id);
+
+-- Change events, written in the transaction of the change and delivered by the OutboxRelay
+CREATE TABLE IF NOT EXISTS dal_outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    entity VARCHAR(64) NOT NULL,
+    entity_id BIGINT NOT NULL,
+    operation VARCHAR(64) NOT NULL,
+    version INT NOT NULL,
+    payload TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL,
+    delivered_at TIMESTAMP NULL,
+    created TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_dal_outbox_pending ON dal_outbox (delivered_at, next_attempt_at);
@@ -0,0 +1,1401 @@
+package dal
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sony/gobreaker"
+)
+
+// newSQLiteNoteRepository returns a NoteRepository backed by a private in-memory SQLite database.
+func newSQLiteNoteRepository(t *testing.T) NoteRepository {
+	t.Helper()
+	repo, _ := newSQLiteNoteRepositoryAndProvider(t)
+	return repo
+}
+
+// newSQLiteNoteRepositoryAndProvider is newSQLiteNoteRepository registering hooks and also returning the provider of the database.
+func newSQLiteNoteRepositoryAndProvider(t *testing.T, hooks ...interface{}) (NoteRepository, DBProvider) {
+	t.Helper()
+
+	instance := DBInstance{Driver: "sqlite", Database: ":memory:"}
+	provider, err := NewServerProvider(&ServerConfig{
+		ServerGroups: []ServerGroupConfig{{
+			Name:     "local",
+			Entities: []string{"note"},
+			Instances: InstancesConfig{
+				Reads:  []DBInstance{instance},
+				Writes: []DBInstance{instance},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := provider.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = provider.Disconnect() })
+
+	repo := NewNoteRepository(provider, nil, nil, gobreaker.Settings{}, nil, hooks...)
+	if err := repo.CreateTable(context.Background()); err != nil {
+		t.Fatalf("CreateTable failed: %v", err)
+	}
+	return repo, provider
+}
+
+func TestNoteSQLite_CRUD(t *testing.T) {
+	repo := newSQLiteNoteRepository(t)
+	ctx := context.Background()
+
+	meta := json.RawMessage(`{"color":"yellow"}`)
+	note, err := repo.Create(ctx, &Note{Slug: "groceries", OwnerId: 1, Body: "milk", Meta: &meta})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if note.ID == 0 {
+		t.Fatal("expected ID to be assigned")
+	}
+
+	fetched, err := repo.GetBySlug(ctx, "groceries")
+	if err != nil {
+		t.Fatalf("GetBySlug failed: %v", err)
+	}
+	if fetched.ID != note.ID || fetched.Body != "milk" || string(*fetched.Meta) != string(meta) {
+		t.Errorf("unexpected note %+v", fetched)
+	}
+
+	fetched.Body = "milk, eggs"
+	fetched.Pinned = true
+	if err := repo.Update(ctx, fetched); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	repo.FlushAllCache()
+
+	updated, err := repo.GetByID(ctx, note.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if updated.Body != "milk, eggs" || !updated.Pinned || updated.Version != 1 {
+		t.Errorf("unexpected updated note %+v", updated)
+	}
+
+	// Soft delete scrambles the unique slug, so it can be reused.
+	if err := repo.Delete(ctx, updated); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := repo.Create(ctx, &Note{Slug: "groceries", OwnerId: 1, Body: "bread"}); err != nil {
+		t.Fatalf("Create with the slug of a deleted note failed: %v", err)
+	}
+}
+
+func TestNoteSQLite_Bulk(t *testing.T) {
+	repo := newSQLiteNoteRepository(t)
+	ctx := context.Background()
+
+	if _, err := repo.Create(ctx, &Note{Slug: "first", OwnerId: 1}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	created, err := repo.CreateBulk(ctx, []*Note{
+		{Slug: "a", OwnerId: 1},
+		{Slug: "b", OwnerId: 2},
+		{Slug: "c", OwnerId: 2},
+	})
+	if err != nil {
+		t.Fatalf("CreateBulk failed: %v", err)
+	}
+
+	// IDs must match the rows actually inserted.
+	ids := make([]int64, 0, len(created))
+	for _, note := range created {
+		fetched, err := repo.GetByID(ctx, note.ID)
+		if err != nil {
+			t.Fatalf("GetByID(%d) failed: %v", note.ID, err)
+		}
+		if fetched.Slug != note.Slug {
+			t.Errorf("ID %d belongs to %q, expected %q", note.ID, fetched.Slug, note.Slug)
+		}
+		ids = append(ids, note.ID)
+	}
+
+	if err := repo.UpdatePinnedByIds(ctx, true, ids); err != nil {
+		t.Fatalf("UpdatePinnedByIds failed: %v", err)
+	}
+	notes, err := repo.GetByIds(ctx, ids)
+	if err != nil {
+		t.Fatalf("GetByIds failed: %v", err)
+	}
+	for _, note := range notes {
+		if !note.Pinned {
+			t.Errorf("expected note %d to be pinned", note.ID)
+		}
+	}
+
+	byOwners, err := repo.ListByOwners(ctx, []int64{2})
+	if err != nil {
+		t.Fatalf("ListByOwners failed: %v", err)
+	}
+	if len(byOwners) != 2 {
+		t.Errorf("expected 2 notes of owner 2, got %d", len(byOwners))
+	}
+
+	slugs, err := repo.GetSlugsByOwner(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetSlugsByOwner failed: %v", err)
+	}
+	if len(slugs) != 2 {
+		t.Errorf("expected 2 slugs of owner 1, got %v", slugs)
+	}
+
+	deleted, err := repo.DeleteByOwner(ctx, 2, 1)
+	if err != nil {
+		t.Fatalf("DeleteByOwner failed: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("expected limit to bound the delete to 1 row, got %d", deleted)
+	}
+
+	count, err := repo.CountListByOwner(ctx, 2)
+	if err != nil {
+		t.Fatalf("CountListByOwner failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 remaining note of owner 2, got %d", count)
+	}
+}
+
+func TestNoteSQLite_Aggregate(t *testing.T) {
+	repo := newSQLiteNoteRepository(t)
+	ctx := context.Background()
+
+	empty, err := repo.MaxOwnerId(ctx)
+	if err != nil {
+		t.Fatalf("MaxOwnerId failed: %v", err)
+	}
+	if empty != 0 {
+		t.Errorf("expected 0 without notes, got %v", empty)
+	}
+
+	for i, ownerID := range []int64{3, 7, 5} {
+		if _, err := repo.Create(ctx, &Note{Slug: fmt.Sprintf("agg-%d", i), OwnerId: ownerID}); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+	}
+
+	// Creates flush the count cache, so the cached 0 is gone.
+	highest, err := repo.MaxOwnerId(ctx)
+	if err != nil {
+		t.Fatalf("MaxOwnerId failed: %v", err)
+	}
+	if highest != 7 {
+		t.Errorf("expected max owner 7, got %v", highest)
+	}
+}
+
+func TestNoteSQLite_GroupCount(t *testing.T) {
+	repo := newSQLiteNoteRepository(t)
+	ctx := context.Background()
+
+	notes := []*Note{
+		{Slug: "group-1", OwnerId: 1, Pinned: true},
+		{Slug: "group-2", OwnerId: 1},
+		{Slug: "group-3", OwnerId: 1},
+		{Slug: "group-4", OwnerId: 2, Pinned: true},
+	}
+	if _, err := repo.CreateBulk(ctx, notes); err != nil {
+		t.Fatalf("CreateBulk failed: %v", err)
+	}
+
+	counts, err := repo.CountByPinnedGrouped(ctx, 1)
+	if err != nil {
+		t.Fatalf("CountByPinnedGrouped failed: %v", err)
+	}
+	if len(counts) != 2 || counts[true] != 1 || counts[false] != 2 {
+		t.Errorf("unexpected counts of owner 1: %v", counts)
+	}
+
+	// The cached map can't be changed through the returned one.
+	counts[true] = 100
+	cached, err := repo.CountByPinnedGrouped(ctx, 1)
+	if err != nil {
+		t.Fatalf("CountByPinnedGrouped failed: %v", err)
+	}
+	if cached[true] != 1 {
+		t.Errorf("expected the cached count to stay 1, got %d", cached[true])
+	}
+
+	empty, err := repo.CountByPinnedGrouped(ctx, 3)
+	if err != nil {
+		t.Fatalf("CountByPinnedGrouped failed: %v", err)
+	}
+	if len(empty) != 0 {
+		t.Errorf("expected no buckets for owner 3, got %v", empty)
+	}
+}
+
+func TestNoteSQLite_Exists(t *testing.T) {
+	repo := newSQLiteNoteRepository(t)
+	ctx := context.Background()
+
+	note, err := repo.Create(ctx, &Note{Slug: "exists", OwnerId: 4, Pinned: true})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	found, err := repo.ExistsByOwnerIdAndPinned(ctx, 4, true)
+	if err != nil || !found {
+		t.Errorf("expected a pinned note of owner 4, got %v, %v", found, err)
+	}
+	found, err = repo.ExistsByOwnerIdAndPinned(ctx, 4, false)
+	if err != nil || found {
+		t.Errorf("expected no unpinned note of owner 4, got %v, %v", found, err)
+	}
+
+	// Soft deleted rows don't exist anymore.
+	if err := repo.Delete(ctx, note); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	found, err = repo.ExistsByOwnerIdAndPinned(ctx, 4, true)
+	if err != nil || found {
+		t.Errorf("expected the deleted note to be gone, got %v, %v", found, err)
+	}
+}
+
+func TestNoteSQLite_Patch(t *testing.T) {
+	repo := newSQLiteNoteRepository(t)
+	ctx := context.Background()
+
+	note, err := repo.Create(ctx, &Note{Slug: "patch", OwnerId: 1, Body: "draft", Pinned: true})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	// Cache the note, the patch has to invalidate it.
+	if _, err := repo.GetByID(ctx, note.ID); err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+
+	if err := repo.PatchBody(ctx, note.ID, note.Version, "final"); err != nil {
+		t.Fatalf("PatchBody failed: %v", err)
+	}
+
+	patched, err := repo.GetByID(ctx, note.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if patched.Body != "final" || !patched.Pinned || patched.Version != note.Version+1 {
+		t.Errorf("expected only the body to change and the version to bump, got %+v", patched)
+	}
+
+	// The old version was already patched.
+	if err := repo.PatchBody(ctx, note.ID, note.Version, "stale"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a stale version, got %v", err)
+	}
+}
+
+func TestNoteSQLite_InLists(t *testing.T) {
+	repo := newSQLiteNoteRepository(t)
+	ctx := context.Background()
+
+	notes := []*Note{
+		{Slug: "in-1", OwnerId: 1},
+		{Slug: "in-2", OwnerId: 2},
+		{Slug: "in-3", OwnerId: 2},
+		{Slug: "in-4", OwnerId: 3},
+	}
+	if _, err := repo.CreateBulk(ctx, notes); err != nil {
+		t.Fatalf("CreateBulk failed: %v", err)
+	}
+
+	listed, err := repo.ListForOwners(ctx, []int64{1, 2}, 0, 10)
+	if err != nil {
+		t.Fatalf("ListForOwners failed: %v", err)
+	}
+	if len(listed) != 3 {
+		t.Errorf("expected 3 notes of owners 1 and 2, got %d", len(listed))
+	}
+
+	// The cache key doesn't depend on the order of the values, the result doesn't either.
+	reordered, err := repo.ListForOwners(ctx, []int64{2, 1}, 0, 10)
+	if err != nil || len(reordered) != 3 {
+		t.Errorf("expected the same 3 notes, got %d, %v", len(reordered), err)
+	}
+	only3, err := repo.ListForOwners(ctx, []int64{3}, 0, 10)
+	if err != nil || len(only3) != 1 || only3[0].Slug != "in-4" {
+		t.Errorf("expected the note of owner 3, got %v, %v", only3, err)
+	}
+
+	count, err := repo.CountListForOwners(ctx, []int64{2, 3})
+	if err != nil || count != 3 {
+		t.Errorf("expected 3 notes of owners 2 and 3, got %d, %v", count, err)
+	}
+
+	page, err := repo.ListForOwnersPage(ctx, []int64{1, 2}, "", 2)
+	if err != nil {
+		t.Fatalf("ListForOwnersPage failed: %v", err)
+	}
+	if _, err := repo.ListForOwnersPage(ctx, []int64{1, 3}, page.NextPageToken, 2); !errors.Is(err, ErrInvalidPageToken) {
+		t.Errorf("expected a token of other owners to be rejected, got %v", err)
+	}
+
+	slugs, err := repo.GetSlugsByOwners(ctx, []int64{3, 1})
+	if err != nil || len(slugs) != 2 {
+		t.Errorf("expected the slugs of owners 1 and 3, got %v, %v", slugs, err)
+	}
+
+	// An empty slice matches no rows.
+	empty, err := repo.ListForOwners(ctx, nil, 0, 10)
+	if err != nil || len(empty) != 0 {
+		t.Errorf("expected no notes for no owners, got %d, %v", len(empty), err)
+	}
+
+	tooMany := make([]int64, 501)
+	if _, err := repo.CountListForOwners(ctx, tooMany); err == nil {
+		t.Error("expected more than 500 values to be rejected")
+	}
+
+	deleted, err := repo.DeleteByOwners(ctx, []int64{2}, 0)
+	if err != nil || deleted != 2 {
+		t.Errorf("expected 2 notes of owner 2 to be deleted, got %d, %v", deleted, err)
+	}
+	count, err = repo.CountListForOwners(ctx, []int64{2, 3})
+	if err != nil || count != 1 {
+		t.Errorf("expected 1 note left for owners 2 and 3, got %d, %v", count, err)
+	}
+}
+
+func TestNoteSQLite_Filters(t *testing.T) {
+	repo := newSQLiteNoteRepository(t)
+	ctx := context.Background()
+
+	notes := []*Note{
+		{Slug: "f-1", OwnerId: 1, Pinned: true},
+		{Slug: "f-2", OwnerId: 1, Pinned: false},
+		{Slug: "f-3", OwnerId: 1, Pinned: true},
+		{Slug: "f-4", OwnerId: 2, Pinned: true},
+	}
+	if _, err := repo.CreateBulk(ctx, notes); err != nil {
+		t.Fatalf("CreateBulk failed: %v", err)
+	}
+
+	// Nil fields leave their filters out, only the required owner filter applies.
+	all, _, err := repo.Search(ctx, NoteSearchFilter{OwnerId: 1}, nil, 10)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(all) != 3 {
+		t.Errorf("expected 3 notes of owner 1, got %d", len(all))
+	}
+
+	pinned, _, err := repo.Search(ctx, NoteSearchFilter{OwnerId: 1, Pinned: Ptr(true)}, nil, 10)
+	if err != nil || len(pinned) != 2 {
+		t.Errorf("expected 2 pinned notes of owner 1, got %d, %v", len(pinned), err)
+	}
+
+	// The cache key holds the active filters, so the cached unfiltered page isn't returned.
+	bySlug, _, err := repo.Search(ctx, NoteSearchFilter{OwnerId: 1, Pinned: Ptr(true), Slugs: []string{"f-3", "f-4"}}, nil, 10)
+	if err != nil || len(bySlug) != 1 || bySlug[0].Slug != "f-3" {
+		t.Errorf("expected note f-3, got %v, %v", bySlug, err)
+	}
+
+	count, err := repo.CountSearch(ctx, NoteSearchFilter{OwnerId: 1, Pinned: Ptr(false)})
+	if err != nil || count != 1 {
+		t.Errorf("expected 1 unpinned note of owner 1, got %d, %v", count, err)
+	}
+	count, err = repo.CountSearch(ctx, NoteSearchFilter{OwnerId: 1})
+	if err != nil || count != 3 {
+		t.Errorf("expected 3 notes of owner 1, got %d, %v", count, err)
+	}
+
+	page, err := repo.SearchPage(ctx, NoteSearchFilter{OwnerId: 1}, "", 2)
+	if err != nil {
+		t.Fatalf("SearchPage failed: %v", err)
+	}
+	if len(page.Items) != 2 || !page.HasMore {
+		t.Fatalf("expected a full first page, got %+v", page)
+	}
+	next, err := repo.SearchPage(ctx, NoteSearchFilter{OwnerId: 1}, page.NextPageToken, 2)
+	if err != nil || len(next.Items) != 1 || next.HasMore {
+		t.Errorf("expected the last note on the second page, got %+v, %v", next, err)
+	}
+	if _, err := repo.SearchPage(ctx, NoteSearchFilter{OwnerId: 1, Pinned: Ptr(true)}, page.NextPageToken, 2); !errors.Is(err, ErrInvalidPageToken) {
+		t.Errorf("expected a token of other filters to be rejected, got %v", err)
+	}
+}
+
+func TestNoteSQLite_Upsert(t *testing.T) {
+	repo := newSQLiteNoteRepository(t)
+	ctx := context.Background()
+
+	inserted, err := repo.UpsertBySlug(ctx, &Note{Slug: "todo", OwnerId: 1, Body: "v1"})
+	if err != nil {
+		t.Fatalf("UpsertBySlug insert failed: %v", err)
+	}
+	if inserted.ID == 0 || inserted.Version != 0 {
+		t.Fatalf("unexpected inserted note %+v", inserted)
+	}
+
+	// Warm the slug cache, the upsert must not leave it stale.
+	if _, err := repo.GetBySlug(ctx, "todo"); err != nil {
+		t.Fatalf("GetBySlug failed: %v", err)
+	}
+
+	// owner_id isn't in the set, so the stored value is kept.
+	updated, err := repo.UpsertBySlug(ctx, &Note{Slug: "todo", OwnerId: 2, Body: "v2", Pinned: true})
+	if err != nil {
+		t.Fatalf("UpsertBySlug update failed: %v", err)
+	}
+	if updated.ID != inserted.ID || updated.Version != 1 || updated.OwnerId != 1 {
+		t.Errorf("expected the existing note to be updated, got %+v", updated)
+	}
+	if !updated.Created.Equal(inserted.Created) {
+		t.Errorf("expected created to be kept, got %v, want %v", updated.Created, inserted.Created)
+	}
+
+	fetched, err := repo.GetBySlug(ctx, "todo")
+	if err != nil {
+		t.Fatalf("GetBySlug failed: %v", err)
+	}
+	if fetched.Body != "v2" || !fetched.Pinned || fetched.Version != 1 {
+		t.Errorf("expected the cache to hold the upserted note, got %+v", fetched)
+	}
+
+	// Upserting the stored values changes nothing, not even the version.
+	unchanged, err := repo.UpsertBySlug(ctx, &Note{Slug: "todo", OwnerId: 3, Body: "v2", Pinned: true})
+	if err != nil {
+		t.Fatalf("UpsertBySlug of the stored values failed: %v", err)
+	}
+	if unchanged.Version != 1 || !unchanged.Updated.Equal(updated.Updated) {
+		t.Errorf("expected the version and updated to be kept, got %+v", unchanged)
+	}
+}
+
+func TestNoteSQLite_UpsertBulk(t *testing.T) {
+	repo := newSQLiteNoteRepository(t)
+	ctx := context.Background()
+
+	existing, err := repo.CreateBulk(ctx, []*Note{
+		{Slug: "kept", OwnerId: 1, Body: "same"},
+		{Slug: "changed", OwnerId: 1, Body: "old"},
+	})
+	if err != nil {
+		t.Fatalf("CreateBulk failed: %v", err)
+	}
+	if _, err := repo.GetBySlug(ctx, "changed"); err != nil {
+		t.Fatalf("GetBySlug failed: %v", err)
+	}
+
+	// More rows than a single chunk holds
+	notes := []*Note{
+		{Slug: "kept", OwnerId: 2, Body: "same"},
+		{Slug: "changed", OwnerId: 2, Body: "new"},
+	}
+	for i := 0; i < 600; i++ {
+		notes = append(notes, &Note{Slug: fmt.Sprintf("new-%d", i), OwnerId: 3})
+	}
+
+	outcomes, err := repo.UpsertBulkBySlug(ctx, notes)
+	if err != nil {
+		t.Fatalf("UpsertBulkBySlug failed: %v", err)
+	}
+	if len(outcomes) != len(notes) {
+		t.Fatalf("expected %d outcomes, got %d", len(notes), len(outcomes))
+	}
+	if outcomes[0] != UpsertUnchanged || outcomes[1] != UpsertUpdated {
+		t.Errorf("expected unchanged and updated, got %v and %v", outcomes[0], outcomes[1])
+	}
+
+	// Updated and unchanged rows carry their stored IDs and the columns that aren't overwritten.
+	if notes[0].ID != existing[0].ID || notes[0].Version != 0 || notes[0].OwnerId != 1 {
+		t.Errorf("unexpected unchanged note %+v", notes[0])
+	}
+	if notes[1].ID != existing[1].ID || notes[1].Version != 1 || notes[1].OwnerId != 1 {
+		t.Errorf("unexpected updated note %+v", notes[1])
+	}
+
+	for i, note := range notes[2:] {
+		if outcomes[i+2] != UpsertInserted {
+			t.Fatalf("expected %s to be inserted, got %v", note.Slug, outcomes[i+2])
+		}
+		fetched, err := repo.GetByID(ctx, note.ID)
+		if err != nil {
+			t.Fatalf("GetByID(%d) failed: %v", note.ID, err)
+		}
+		if fetched.Slug != note.Slug {
+			t.Fatalf("ID %d belongs to %q, expected %q", note.ID, fetched.Slug, note.Slug)
+		}
+	}
+
+	fetched, err := repo.GetBySlug(ctx, "changed")
+	if err != nil {
+		t.Fatalf("GetBySlug failed: %v", err)
+	}
+	if fetched.Body != "new" || fetched.Version != 1 {
+		t.Errorf("expected the cache to hold the updated note, got %+v", fetched)
+	}
+
+	if _, err := repo.UpsertBulkBySlug(ctx, []*Note{{Slug: "twice"}, {Slug: "twice"}}); err == nil {
+		t.Error("expected duplicate slugs to be rejected")
+	}
+}
+
+func TestNoteSQLite_ListCursor(t *testing.T) {
+	repo := newSQLiteNoteRepository(t)
+	ctx := context.Background()
+
+	var notes []*Note
+	for i := 0; i < 7; i++ {
+		notes = append(notes, &Note{Slug: fmt.Sprintf("note-%d", i), OwnerId: 1})
+	}
+	if _, err := repo.CreateBulk(ctx, notes); err != nil {
+		t.Fatalf("CreateBulk failed: %v", err)
+	}
+	if _, err := repo.Create(ctx, &Note{Slug: "other-owner", OwnerId: 2}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// Make created disagree with the id order and tie some notes, which paging by id alone gets wrong.
+	db, err := repo.(*noteRepository).dbProvider.GetDatabase("note", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, note := range notes {
+		created := base.Add(time.Duration((i*5)%3) * time.Hour)
+		if _, err := db.Exec("UPDATE notes SET created = ? WHERE id = ?", created, note.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	seen := make(map[int64]bool)
+	var previous *Note
+	var cursor *NoteListByOwnerCursor
+	pages := 0
+	for {
+		page, next, err := repo.ListByOwner(ctx, 1, cursor, 3)
+		if err != nil {
+			t.Fatalf("ListByOwner failed: %v", err)
+		}
+		for _, note := range page {
+			if seen[note.ID] {
+				t.Errorf("note %d returned twice", note.ID)
+			}
+			seen[note.ID] = true
+
+			// Descending by created, then by id
+			if previous != nil && (note.Created.After(previous.Created) || note.Created.Equal(previous.Created) && note.ID > previous.ID) {
+				t.Errorf("note %d is out of order after note %d", note.ID, previous.ID)
+			}
+			previous = note
+		}
+		pages++
+		if next == nil {
+			break
+		}
+		if pages > 3 {
+			t.Fatal("expected the cursor to reach the last page")
+		}
+		cursor = next
+	}
+
+	if len(seen) != len(notes) {
+		t.Errorf("expected %d notes across pages, got %d", len(notes), len(seen))
+	}
+}
+
+func TestNoteSQLite_Iterate(t *testing.T) {
+	repo := newSQLiteNoteRepository(t)
+	ctx := context.Background()
+
+	var notes []*Note
+	for i := 0; i < 7; i++ {
+		notes = append(notes, &Note{Slug: fmt.Sprintf("iter-%d", i), OwnerId: 1})
+	}
+	if _, err := repo.CreateBulk(ctx, notes); err != nil {
+		t.Fatalf("CreateBulk failed: %v", err)
+	}
+	if _, err := repo.Create(ctx, &Note{Slug: "iter-other", OwnerId: 2}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// Created runs against the id order, so ListByOwner's order differs from IterateAll's
+	db, err := repo.(*noteRepository).dbProvider.GetDatabase("note", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, note := range notes {
+		if _, err := db.Exec("UPDATE notes SET created = ? WHERE id = ?", base.Add(time.Duration(i)*time.Hour), note.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo.FlushAllCache()
+
+	collect := func(seq func(func(*Note, error) bool)) ([]int64, error) {
+		var ids []int64
+		for note, err := range seq {
+			if err != nil {
+				return ids, err
+			}
+			ids = append(ids, note.ID)
+		}
+		return ids, nil
+	}
+
+	ids, err := collect(repo.IterateAll(ctx, IterateOptions{BatchSize: 3}))
+	if err != nil {
+		t.Fatalf("IterateAll failed: %v", err)
+	}
+	if len(ids) != 8 || ids[0] != notes[0].ID {
+		t.Fatalf("expected all 8 notes in id order, got %v", ids)
+	}
+
+	// Iterators neither read nor fill the caches
+	internal := repo.(*noteRepository)
+	if internal.cache.ItemCount() != 0 || internal.listCache.ItemCount() != 0 {
+		t.Errorf("expected iteration to leave the caches empty, got %d entities and %d lists", internal.cache.ItemCount(), internal.listCache.ItemCount())
+	}
+
+	resumed, err := collect(repo.IterateAll(ctx, IterateOptions{BatchSize: 3, StartAfterID: ids[4]}))
+	if err != nil {
+		t.Fatalf("IterateAll failed: %v", err)
+	}
+	if fmt.Sprint(resumed) != fmt.Sprint(ids[5:]) {
+		t.Errorf("expected to resume with %v, got %v", ids[5:], resumed)
+	}
+
+	// Newest first, so the list walks the notes backwards
+	owned, err := collect(repo.IterateByListByOwner(ctx, 1, IterateOptions{BatchSize: 2}))
+	if err != nil {
+		t.Fatalf("IterateByListByOwner failed: %v", err)
+	}
+	if len(owned) != 7 || owned[0] != notes[6].ID || owned[6] != notes[0].ID {
+		t.Fatalf("expected the owner's 7 notes newest first, got %v", owned)
+	}
+
+	resumed, err = collect(repo.IterateByListByOwner(ctx, 1, IterateOptions{BatchSize: 2, StartAfterID: notes[3].ID}))
+	if err != nil {
+		t.Fatalf("IterateByListByOwner failed: %v", err)
+	}
+	if fmt.Sprint(resumed) != fmt.Sprint(owned[4:]) {
+		t.Errorf("expected to resume with %v, got %v", owned[4:], resumed)
+	}
+
+	if _, err := collect(repo.IterateByListByOwner(ctx, 1, IterateOptions{StartAfterID: 12345})); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected resuming after a missing note to fail with ErrNotFound, got %v", err)
+	}
+
+	// Breaking out of the loop stops the iteration
+	count := 0
+	for range repo.IterateAll(ctx, IterateOptions{BatchSize: 2}) {
+		count++
+		if count == 3 {
+			break
+		}
+	}
+	if count != 3 {
+		t.Errorf("expected to stop after 3 notes, got %d", count)
+	}
+
+	// A cancelled context ends the iteration with its error
+	cancelCtx, cancel := context.WithCancel(ctx)
+	read := 0
+	ids, err = collect(func(yield func(*Note, error) bool) {
+		for note, err := range repo.IterateAll(cancelCtx, IterateOptions{BatchSize: 2}) {
+			if read++; read == 2 {
+				cancel()
+			}
+			if !yield(note, err) {
+				return
+			}
+		}
+	})
+	if !errors.Is(err, context.Canceled) || len(ids) != 2 {
+		t.Errorf("expected the first batch and context.Canceled, got %v and %v", ids, err)
+	}
+
+	// 8 notes in batches of 2 at 100 rows per second wait at least 20ms after each full batch
+	start := time.Now()
+	if ids, err := collect(repo.IterateAll(ctx, IterateOptions{BatchSize: 2, RowsPerSecond: 100})); err != nil || len(ids) != 8 {
+		t.Fatalf("rate limited IterateAll failed: %v, %v", ids, err)
+	}
+	if elapsed := time.Since(start); elapsed < 70*time.Millisecond {
+		t.Errorf("expected the rate limit to slow the iteration down, took %v", elapsed)
+	}
+}
+
+func TestNoteSQLite_ListPage(t *testing.T) {
+	repo := newSQLiteNoteRepository(t)
+	ctx := context.Background()
+
+	var notes []*Note
+	for i := 0; i < 5; i++ {
+		notes = append(notes, &Note{Slug: fmt.Sprintf("page-%d", i), OwnerId: 1})
+	}
+	if _, err := repo.CreateBulk(ctx, notes); err != nil {
+		t.Fatalf("CreateBulk failed: %v", err)
+	}
+
+	first, err := repo.ListByOwnerPage(ctx, 1, "", 2)
+	if err != nil {
+		t.Fatalf("ListByOwnerPage failed: %v", err)
+	}
+	if len(first.Items) != 2 || !first.HasMore || first.NextPageToken == "" {
+		t.Fatalf("unexpected first page %+v", first)
+	}
+
+	// A token can't be replayed against other parameters.
+	if _, err := repo.ListByOwnerPage(ctx, 2, first.NextPageToken, 2); !errors.Is(err, ErrInvalidPageToken) {
+		t.Errorf("expected ErrInvalidPageToken for another owner, got %v", err)
+	}
+	if _, err := repo.ListByOwnerPage(ctx, 1, "not a token", 2); !errors.Is(err, ErrInvalidPageToken) {
+		t.Errorf("expected ErrInvalidPageToken for a malformed token, got %v", err)
+	}
+
+	// Nor against a list of the same name and parameters of another entity.
+	var decoded pageToken
+	data, _ := base64.RawURLEncoding.DecodeString(first.NextPageToken)
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	foreign, err := encodePageToken("user", decoded.List, decoded.Params, decoded.Cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.ListByOwnerPage(ctx, 1, foreign, 2); !errors.Is(err, ErrInvalidPageToken) {
+		t.Errorf("expected ErrInvalidPageToken for a token of another entity, got %v", err)
+	}
+
+	total := len(first.Items)
+	token := first.NextPageToken
+	for token != "" {
+		page, err := repo.ListByOwnerPage(ctx, 1, token, 2)
+		if err != nil {
+			t.Fatalf("ListByOwnerPage failed: %v", err)
+		}
+		if page.HasMore != (page.NextPageToken != "") {
+			t.Errorf("HasMore %v doesn't match the next token %q", page.HasMore, page.NextPageToken)
+		}
+		total += len(page.Items)
+		token = page.NextPageToken
+	}
+	if total != len(notes) {
+		t.Errorf("expected %d notes across pages, got %d", len(notes), total)
+	}
+}
+
+func TestNoteSQLite_RunInTx(t *testing.T) {
+	repo := newSQLiteNoteRepository(t)
+	ctx := context.Background()
+
+	note, err := repo.Create(ctx, &Note{Slug: "draft", OwnerId: 1, Body: "v1"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	breaker := repo.(*noteRepository).dbBreaker
+	requestsBefore := breaker.Counts().Requests
+
+	errAbort := errors.New("abort")
+	err = RunInTx(ctx, func(ctx context.Context) error {
+		fetched, err := repo.GetByID(ctx, note.ID)
+		if err != nil {
+			return err
+		}
+		fetched.Body = "v2"
+		if err := repo.Update(ctx, fetched); err != nil {
+			return err
+		}
+		if _, err := repo.Create(ctx, &Note{Slug: "scratch", OwnerId: 1}); err != nil {
+			return err
+		}
+
+		// The transaction reads its own writes, while the cache still holds the committed state.
+		inTx, err := repo.GetBySlug(ctx, "draft")
+		if err != nil {
+			return err
+		}
+		if inTx.Body != "v2" {
+			t.Errorf("expected the transaction to see its update, got %q", inTx.Body)
+		}
+		cached, _ := repo.(*noteRepository).getByIDCached(note.ID)
+		if cached == nil || cached.Body != "v1" {
+			t.Errorf("expected the cache to keep the committed note until commit, got %+v", cached)
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("expected the callback error, got %v", err)
+	}
+
+	if requests := breaker.Counts().Requests - requestsBefore; requests != 1 {
+		t.Errorf("expected the transaction to count as 1 breaker request, got %d", requests)
+	}
+
+	fetched, err := repo.GetByID(ctx, note.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if fetched.Body != "v1" {
+		t.Errorf("expected rolled back update to be discarded, got %q", fetched.Body)
+	}
+	if _, err := repo.GetBySlug(ctx, "scratch"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected rolled back create to be discarded, got %v", err)
+	}
+
+	err = RunInTx(ctx, func(ctx context.Context) error {
+		fetched.Body = "v3"
+		return repo.Update(ctx, fetched)
+	})
+	if err != nil {
+		t.Fatalf("RunInTx failed: %v", err)
+	}
+
+	// The cache is updated once the transaction commits.
+	cached, _ := repo.(*noteRepository).getByIDCached(note.ID)
+	if cached == nil || cached.Body != "v3" {
+		t.Errorf("expected the committed note in cache, got %+v", cached)
+	}
+}
+
+func TestNoteSQLite_History(t *testing.T) {
+	repo := newSQLiteNoteRepository(t)
+	ctx := WithActor(context.Background(), "admin:1")
+
+	note, err := repo.Create(ctx, &Note{Slug: "audited", OwnerId: 7, Body: "draft"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	note.Body = "final"
+	if err := repo.Update(ctx, note); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := repo.PatchBody(context.Background(), note.ID, note.Version, "patched"); err != nil {
+		t.Fatalf("PatchBody failed: %v", err)
+	}
+	// Setting the stored value changes nothing and records nothing
+	if err := repo.UpdatePinnedByIds(ctx, false, []int64{note.ID}); err != nil {
+		t.Fatalf("UpdatePinnedByIds failed: %v", err)
+	}
+	if deleted, err := repo.DeleteByOwner(ctx, 7, 10); err != nil || deleted != 1 {
+		t.Fatalf("DeleteByOwner deleted %d notes: %v", deleted, err)
+	}
+	if err := repo.HardDelete(ctx, note); err != nil {
+		t.Fatalf("HardDelete failed: %v", err)
+	}
+
+	history, err := repo.History(ctx, note.ID, 0, 10)
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	var operations []string
+	for _, entry := range history {
+		operations = append(operations, entry.Operation)
+	}
+	if fmt.Sprint(operations) != "[create update patch_body delete_by_owner hard_delete]" {
+		t.Fatalf("unexpected history operations %v", operations)
+	}
+
+	created, updated, patched, deleted, hardDeleted := history[0], history[1], history[2], history[3], history[4]
+	if created.OldValues != nil || !strings.Contains(string(created.NewValues), `"slug":"audited"`) {
+		t.Errorf("expected the create to record every column, got %s -> %s", created.OldValues, created.NewValues)
+	}
+	if string(updated.OldValues) != `{"body":"draft"}` || string(updated.NewValues) != `{"body":"final"}` || updated.Version != 1 {
+		t.Errorf("expected the update to record the changed body, got %+v", updated)
+	}
+	if updated.Actor != "admin:1" || patched.Actor != "" {
+		t.Errorf("expected the actors of the context, got %q and %q", updated.Actor, patched.Actor)
+	}
+	if !strings.Contains(string(deleted.OldValues), `"deleted_at":null`) || strings.Contains(string(deleted.NewValues), `"deleted_at":null`) {
+		t.Errorf("expected the soft delete to record deleted_at, got %s -> %s", deleted.OldValues, deleted.NewValues)
+	}
+	if hardDeleted.NewValues != nil || hardDeleted.Version != deleted.Version {
+		t.Errorf("expected the hard delete to record the last state, got %+v", hardDeleted)
+	}
+
+	page, err := repo.History(ctx, note.ID, updated.ID, 1)
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if len(page) != 1 || page[0].ID != patched.ID {
+		t.Errorf("expected the page after the update to hold the patch, got %+v", page)
+	}
+
+	// Upserts record an insert, then the overwritten columns.
+	upserted, err := repo.UpsertBySlug(ctx, &Note{Slug: "upserted", OwnerId: 7, Body: "one"})
+	if err != nil {
+		t.Fatalf("UpsertBySlug failed: %v", err)
+	}
+	if _, err := repo.UpsertBulkBySlug(ctx, []*Note{{Slug: "upserted", OwnerId: 7, Body: "two"}}); err != nil {
+		t.Fatalf("UpsertBulkBySlug failed: %v", err)
+	}
+	history, err = repo.History(ctx, upserted.ID, 0, 10)
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if len(history) != 2 || history[0].OldValues != nil || string(history[1].NewValues) != `{"body":"two"}` {
+		t.Errorf("unexpected upsert history %+v", history)
+	}
+
+	// History rows roll back with the write.
+	errAbort := errors.New("abort")
+	var scratch *Note
+	err = RunInTx(ctx, func(ctx context.Context) error {
+		scratch, err = repo.Create(ctx, &Note{Slug: "scratch", OwnerId: 7})
+		if err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("expected the callback error, got %v", err)
+	}
+	if history, err := repo.History(ctx, scratch.ID, 0, 10); err != nil || len(history) != 0 {
+		t.Errorf("expected no history for a rolled back create, got %d entries: %v", len(history), err)
+	}
+}
+
+// recordingPublisher collects the published events, failing while err is set.
+type recordingPublisher struct {
+	err    error
+	events []*OutboxEvent
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, events []*OutboxEvent) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.events = append(p.events, events...)
+	return nil
+}
+
+func TestNoteSQLite_Outbox(t *testing.T) {
+	repo, provider := newSQLiteNoteRepositoryAndProvider(t)
+	ctx := context.Background()
+
+	note, err := repo.Create(ctx, &Note{Slug: "published", OwnerId: 7, Body: "draft"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	note.Body = "final"
+	if err := repo.Update(ctx, note); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := repo.HardDelete(ctx, note); err != nil {
+		t.Fatalf("HardDelete failed: %v", err)
+	}
+
+	// Events roll back with the write.
+	errAbort := errors.New("abort")
+	err = RunInTx(ctx, func(ctx context.Context) error {
+		if _, err := repo.Create(ctx, &Note{Slug: "scratch", OwnerId: 7}); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("expected the callback error, got %v", err)
+	}
+
+	db, err := provider.GetDatabase("note", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publisher := &recordingPublisher{err: errors.New("broker down")}
+	relay := NewOutboxRelay(db, publisher, OutboxRelayOptions{BatchSize: 2, RetryDelay: time.Hour})
+
+	// A failed batch is retried after the delay, the rest of the outbox isn't held up.
+	if relayed, err := relay.RelayOnce(ctx); err == nil || relayed != 2 {
+		t.Fatalf("expected the publisher error for 2 events, got %d: %v", relayed, err)
+	}
+	var attempts int
+	var lastError string
+	if err := db.QueryRow("SELECT attempts, last_error FROM dal_outbox ORDER BY id LIMIT 1").Scan(&attempts, &lastError); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 1 || lastError != "broker down" {
+		t.Errorf("expected the failed attempt to be recorded, got %d attempts and %q", attempts, lastError)
+	}
+
+	publisher.err = nil
+	if relayed, err := relay.RelayOnce(ctx); err != nil || relayed != 1 {
+		t.Fatalf("expected the remaining event to be relayed, got %d: %v", relayed, err)
+	}
+	if relayed, err := relay.RelayOnce(ctx); err != nil || relayed != 0 {
+		t.Fatalf("expected nothing due, got %d: %v", relayed, err)
+	}
+	if len(publisher.events) != 1 || publisher.events[0].Operation != "hard_delete" {
+		t.Fatalf("expected the hard delete to be published, got %+v", publisher.events)
+	}
+
+	// Once due again, the failed batch goes out with its payloads.
+	if _, err := db.Exec("UPDATE dal_outbox SET next_attempt_at = ?", time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if relayed, err := relay.RelayOnce(ctx); err != nil || relayed != 2 {
+		t.Fatalf("expected the retried events to be relayed, got %d: %v", relayed, err)
+	}
+
+	var operations []string
+	for _, event := range publisher.events {
+		if event.Entity != "note" || event.EntityID != note.ID {
+			t.Errorf("unexpected event %+v", event)
+		}
+		operations = append(operations, event.Operation)
+	}
+	if fmt.Sprint(operations) != "[hard_delete create update]" {
+		t.Fatalf("unexpected published operations %v", operations)
+	}
+
+	var created, updated Note
+	if err := json.Unmarshal(publisher.events[1].Payload, &created); err != nil || created.Body != "draft" {
+		t.Errorf("expected the created note as payload, got %s: %v", publisher.events[1].Payload, err)
+	}
+	if err := json.Unmarshal(publisher.events[2].Payload, &updated); err != nil || updated.Body != "final" || publisher.events[2].Version != updated.Version {
+		t.Errorf("expected the updated note as payload, got %s: %v", publisher.events[2].Payload, err)
+	}
+	if publisher.events[1].Attempts != 1 {
+		t.Errorf("expected the retried events to carry their attempts, got %d", publisher.events[1].Attempts)
+	}
+}
+
+var errNoteVetoed = errors.New("vetoed")
+
+// BeforeUpdate is an entity hook of Note, refusing bodies reserved for TestNoteSQLite_Hooks.
+func (n *Note) BeforeUpdate(ctx context.Context) error {
+	if n.Body == "entity veto" {
+		return errNoteVetoed
+	}
+	return nil
+}
+
+// recordingNoteHooks normalizes and validates notes and records the hook calls.
+type recordingNoteHooks struct {
+	calls []string
+}
+
+func (h *recordingNoteHooks) BeforeCreate(ctx context.Context, entity *Note) error {
+	h.calls = append(h.calls, "BeforeCreate")
+	entity.Slug = strings.ToLower(strings.TrimSpace(entity.Slug))
+	if entity.Slug == "" {
+		return errNoteVetoed
+	}
+	return nil
+}
+
+func (h *recordingNoteHooks) AfterCreate(ctx context.Context, entity *Note) error {
+	h.calls = append(h.calls, fmt.Sprintf("AfterCreate %d", entity.ID))
+	if entity.Body == "after veto" {
+		return errNoteVetoed
+	}
+	return nil
+}
+
+func (h *recordingNoteHooks) BeforeCreateBulk(ctx context.Context, entities []*Note) error {
+	h.calls = append(h.calls, fmt.Sprintf("BeforeCreateBulk %d", len(entities)))
+	if len(entities) > 2 {
+		return errNoteVetoed
+	}
+	return nil
+}
+
+func (h *recordingNoteHooks) AfterUpdate(ctx context.Context, entity *Note) error {
+	h.calls = append(h.calls, fmt.Sprintf("AfterUpdate v%d", entity.Version))
+	if entity.Body == "after veto" {
+		return errNoteVetoed
+	}
+	return nil
+}
+
+func (h *recordingNoteHooks) BeforeDelete(ctx context.Context, entity *Note) error {
+	h.calls = append(h.calls, "BeforeDelete")
+	if entity.Pinned {
+		return errNoteVetoed
+	}
+	return nil
+}
+
+func TestNoteSQLite_Hooks(t *testing.T) {
+	hooks := &recordingNoteHooks{}
+	repo, _ := newSQLiteNoteRepositoryAndProvider(t, hooks)
+	ctx := context.Background()
+
+	// The slug is validated once BeforeCreate normalized it.
+	note, err := repo.Create(ctx, &Note{Slug: "  Hooked ", OwnerId: 7, Body: "draft"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if note.Slug != "hooked" {
+		t.Errorf("expected BeforeCreate to normalize the slug, got %q", note.Slug)
+	}
+
+	var hookErr *HookError
+	_, err = repo.Create(ctx, &Note{Slug: " ", OwnerId: 7})
+	if !errors.As(err, &hookErr) || hookErr.Hook != "BeforeCreate" || !errors.Is(err, errNoteVetoed) {
+		t.Fatalf("expected BeforeCreate to veto the create, got %v", err)
+	}
+	_, err = repo.CreateBulk(ctx, []*Note{{Slug: "a", OwnerId: 7}, {Slug: "b", OwnerId: 7}, {Slug: "c", OwnerId: 7}})
+	if !errors.Is(err, errNoteVetoed) {
+		t.Fatalf("expected BeforeCreateBulk to veto the create, got %v", err)
+	}
+
+	// An after hook's error rolls the write back, and the entity can be created again.
+	vetoed := &Note{Slug: "vetoed", OwnerId: 7, Body: "after veto"}
+	if _, err := repo.Create(ctx, vetoed); !errors.Is(err, errNoteVetoed) {
+		t.Fatalf("expected AfterCreate to veto the create, got %v", err)
+	}
+	if vetoed.ID != 0 || !vetoed.Created.IsZero() {
+		t.Errorf("expected the vetoed create to reset the entity, got %+v", vetoed)
+	}
+	vetoed.Body = "retried"
+	if _, err := repo.Create(ctx, vetoed); err != nil {
+		t.Fatalf("Create after the veto failed: %v", err)
+	}
+
+	note.Body = "after veto"
+	if err := repo.Update(ctx, note); !errors.Is(err, errNoteVetoed) {
+		t.Fatalf("expected AfterUpdate to veto the update, got %v", err)
+	}
+	stored, err := repo.GetByID(ctx, note.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if stored.Body != "draft" || note.Version != stored.Version {
+		t.Errorf("expected the update to be rolled back, got body %q and versions %d and %d", stored.Body, note.Version, stored.Version)
+	}
+
+	// Entity hooks run too.
+	stored.Body = "entity veto"
+	if err := repo.Update(ctx, stored); !errors.As(err, &hookErr) || hookErr.Hook != "BeforeUpdate" {
+		t.Fatalf("expected the BeforeUpdate of Note to veto the update, got %v", err)
+	}
+
+	stored.Pinned = true
+	stored.Body = "pinned"
+	if err := repo.Update(ctx, stored); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := repo.Delete(ctx, stored); !errors.Is(err, errNoteVetoed) {
+		t.Fatalf("expected BeforeDelete to veto the delete, got %v", err)
+	}
+
+	expected := fmt.Sprintf("[BeforeCreate AfterCreate %d BeforeCreate BeforeCreateBulk 3 BeforeCreate AfterCreate %d BeforeCreate AfterCreate %d AfterUpdate v1 AfterUpdate v1 BeforeDelete]", note.ID, vetoed.ID, vetoed.ID)
+	if fmt.Sprint(hooks.calls) != expected {
+		t.Errorf("unexpected hook calls %v", hooks.calls)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a hook implementing no hook interface to panic")
+		}
+	}()
+	NewNoteRepository(nil, nil, nil, gobreaker.Settings{}, nil, struct{}{})
+}
+
+func TestNoteSQLite_UpsertAndPatchHooks(t *testing.T) {
+	hooks := &recordingNoteHooks{}
+	repo, _ := newSQLiteNoteRepositoryAndProvider(t, hooks)
+	ctx := context.Background()
+
+	// Upserts run the create hooks when they insert and the update hooks when they update.
+	note, err := repo.UpsertBySlug(ctx, &Note{Slug: " Upserted ", OwnerId: 7, Body: "draft"})
+	if err != nil {
+		t.Fatalf("UpsertBySlug failed: %v", err)
+	}
+	if note.Slug != "upserted" {
+		t.Errorf("expected BeforeCreate to normalize the slug, got %q", note.Slug)
+	}
+	if _, err := repo.UpsertBySlug(ctx, &Note{Slug: "upserted", OwnerId: 7, Body: "after veto"}); !errors.Is(err, errNoteVetoed) {
+		t.Fatalf("expected AfterUpdate to veto the upsert, got %v", err)
+	}
+	var hookErr *HookError
+	if _, err := repo.UpsertBySlug(ctx, &Note{Slug: "upserted", OwnerId: 7, Body: "entity veto"}); !errors.As(err, &hookErr) || hookErr.Hook != "BeforeUpdate" {
+		t.Fatalf("expected the BeforeUpdate of Note to veto the upsert, got %v", err)
+	}
+
+	outcomes, err := repo.UpsertBulkBySlug(ctx, []*Note{{Slug: "upserted", OwnerId: 7, Body: "bulk"}, {Slug: "bulk", OwnerId: 7, Body: "new"}})
+	if err != nil {
+		t.Fatalf("UpsertBulkBySlug failed: %v", err)
+	}
+	if outcomes[0] != UpsertUpdated || outcomes[1] != UpsertInserted {
+		t.Errorf("unexpected outcomes %v", outcomes)
+	}
+	_, err = repo.UpsertBulkBySlug(ctx, []*Note{{Slug: "upserted", OwnerId: 7, Body: "after veto"}})
+	if !errors.Is(err, errNoteVetoed) {
+		t.Fatalf("expected AfterUpdate to veto the bulk upsert, got %v", err)
+	}
+	stored, err := repo.GetBySlug(ctx, "upserted")
+	if err != nil {
+		t.Fatalf("GetBySlug failed: %v", err)
+	}
+	if stored.Body != "bulk" {
+		t.Errorf("expected the vetoed upserts to be rolled back, got body %q", stored.Body)
+	}
+
+	// Patches run BeforeUpdate on the stored entity holding the patched values.
+	if err := repo.PatchBody(ctx, stored.ID, stored.Version, "entity veto"); !errors.As(err, &hookErr) || hookErr.Hook != "BeforeUpdate" {
+		t.Fatalf("expected the BeforeUpdate of Note to veto the patch, got %v", err)
+	}
+	if err := repo.PatchBody(ctx, stored.ID, stored.Version, "patched"); err != nil {
+		t.Fatalf("PatchBody failed: %v", err)
+	}
+
+	expected := fmt.Sprintf("[BeforeCreate AfterCreate %d AfterUpdate v1 BeforeCreateBulk 1 AfterUpdate v1 AfterUpdate v2]", note.ID)
+	if fmt.Sprint(hooks.calls) != expected {
+		t.Errorf("unexpected hook calls %v", hooks.calls)
+	}
+}
+
+func TestNoteSQLite_Validate(t *testing.T) {
+	repo := newSQLiteNoteRepository(t)
+	ctx := context.Background()
+
+	invalid := &Note{Slug: "Not A Slug", OwnerId: 7, Body: strings.Repeat("x", 2001)}
+	var validationErr *ValidationError
+	if !errors.As(invalid.Validate(), &validationErr) {
+		t.Fatalf("expected a ValidationError, got %v", invalid.Validate())
+	}
+	var rules []string
+	for _, field := range validationErr.Fields {
+		rules = append(rules, field.Field+":"+field.Rule)
+	}
+	if fmt.Sprint(rules) != "[body:maxLength slug:pattern]" {
+		t.Errorf("unexpected broken rules %v", rules)
+	}
+	if validationErr.Error() != "invalid note: body must be at most 2000 characters long; slug must match ^[a-z0-9-]+$" {
+		t.Errorf("unexpected message %q", validationErr.Error())
+	}
+
+	// Writes refuse invalid entities before reaching the database.
+	if _, err := repo.Create(ctx, invalid); !errors.As(err, &validationErr) {
+		t.Fatalf("expected Create to fail validation, got %v", err)
+	}
+	if _, err := repo.CreateBulk(ctx, []*Note{{Slug: "valid", OwnerId: 7}, invalid}); err == nil || !strings.HasPrefix(err.Error(), "entity 1: ") {
+		t.Fatalf("expected CreateBulk to name the invalid entity, got %v", err)
+	}
+	if _, err := repo.UpsertBySlug(ctx, invalid); !errors.As(err, &validationErr) {
+		t.Fatalf("expected UpsertBySlug to fail validation, got %v", err)
+	}
+	if _, err := repo.UpsertBulkBySlug(ctx, []*Note{{Slug: "valid", OwnerId: 7}, invalid}); err == nil || !strings.HasPrefix(err.Error(), "slug Not A Slug: ") {
+		t.Fatalf("expected UpsertBulkBySlug to name the invalid entity, got %v", err)
+	}
+	if total, err := repo.CountListByOwner(ctx, 7); err != nil || total != 0 {
+		t.Fatalf("expected nothing to be written, got %d: %v", total, err)
+	}
+
+	note, err := repo.Create(ctx, &Note{Slug: "valid", OwnerId: 7})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	note.Slug = "In-Valid"
+	if err := repo.Update(ctx, note); !errors.As(err, &validationErr) || validationErr.Fields[0].Field != "slug" {
+		t.Fatalf("expected Update to fail validation, got %v", err)
+	}
+	if err := repo.PatchBody(ctx, note.ID, note.Version, strings.Repeat("x", 2001)); !errors.As(err, &validationErr) {
+		t.Fatalf("expected PatchBody to fail validation, got %v", err)
+	}
+	if err := repo.PatchBody(ctx, note.ID, note.Version, "fine"); err != nil {
+		t.Fatalf("PatchBody failed: %v", err)
+	}
+}
+
+func TestNoteSQLite_Enum(t *testing.T) {
+	repo := newSQLiteNoteRepository(t)
+	ctx := context.Background()
+
+	note, err := repo.Create(ctx, &Note{Slug: "enum", OwnerId: 7, Visibility: Ptr(NoteVisibilityShared)})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	fetched, err := repo.GetByID(ctx, note.ID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if fetched.Visibility == nil || *fetched.Visibility != NoteVisibilityShared {
+		t.Fatalf("expected shared visibility, got %v", fetched.Visibility)
+	}
+	if fmt.Sprint(NoteVisibilityValues()) != "[private shared public]" {
+		t.Errorf("unexpected values %v", NoteVisibilityValues())
+	}
+
+	// JSON encodes the value as a string and refuses unknown ones either way.
+	encoded, err := json.Marshal(fetched.Visibility)
+	if err != nil || string(encoded) != `"shared"` {
+		t.Fatalf("expected \"shared\", got %s: %v", encoded, err)
+	}
+	var decoded Note
+	if err := json.Unmarshal([]byte(`{"visibility": "secret"}`), &decoded); err == nil {
+		t.Error("expected decoding an unknown visibility to fail")
+	}
+	if _, err := json.Marshal(NoteVisibility("secret")); err == nil {
+		t.Error("expected encoding an unknown visibility to fail")
+	}
+
+	// Writes refuse unknown values, and so does the CHECK constraint of the column for raw SQL.
+	var validationErr *ValidationError
+	note.Visibility = Ptr(NoteVisibility("secret"))
+	if err := repo.Update(ctx, note); !errors.As(err, &validationErr) || validationErr.Fields[0].Rule != "values" {
+		t.Fatalf("expected Update to fail validation, got %v", err)
+	}
+	db, err := repo.(*noteRepository).dbProvider.GetDatabase("note", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, "UPDATE notes SET visibility = 'secret' WHERE id = ?", note.ID); err == nil {
+		t.Error("expected the CHECK constraint to reject an unknown visibility")
+	}
+}
+
+func TestNoteSQLite_Decimal(t *testing.T) {
+	repo := newSQLiteNoteRepository(t)
+	ctx := context.Background()
+
+	var ids []int64
+	for i, amount := range []string{"0.10", "0.20", "12.5"} {
+		note, err := repo.Create(ctx, &Note{Slug: fmt.Sprintf("amount-%d", i), OwnerId: 7, Amount: Ptr(MustDecimal(amount))})
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		ids = append(ids, note.ID)
+	}
+
+	fetched, err := repo.GetByID(ctx, ids[2])
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if fetched.Amount == nil || fetched.Amount.StringFixed(2) != "12.50" {
+		t.Fatalf("expected an amount of 12.50, got %v", fetched.Amount)
+	}
+
+	sum, err := repo.SumAmountByOwnerIdAndMinAmount(ctx, 7, Ptr(MustDecimal("0.15")))
+	if err != nil || sum != MustDecimal("12.7") {
+		t.Fatalf("expected a sum of 12.7, got %s: %v", sum, err)
+	}
+	none, err := repo.SumAmountByOwnerIdAndMinAmount(ctx, 8, Ptr(Decimal{}))
+	if err != nil || !none.IsZero() {
+		t.Fatalf("expected 0 without rows, got %s: %v", none, err)
+	}
+
+	if err := repo.UpdateAmountByIds(ctx, Ptr(MustDecimal("99.99")), ids[:2]); err != nil {
+		t.Fatalf("UpdateAmountByIds failed: %v", err)
+	}
+	fetched, err = repo.GetByID(ctx, ids[0])
+	if err != nil || *fetched.Amount != MustDecimal("99.99") {
+		t.Fatalf("expected the bulk updated amount, got %v: %v", fetched.Amount, err)
+	}
+
+	// Values not fitting DECIMAL(10,2) are refused instead of rounded by the database.
+	var validationErr *ValidationError
+	if err := repo.UpdateAmountByIds(ctx, Ptr(MustDecimal("0.125")), ids[:1]); !errors.As(err, &validationErr) {
+		t.Fatalf("expected too many decimals to fail validation, got %v", err)
+	}
+	fetched.Amount = Ptr(MustDecimal("123456789"))
+	if err := repo.Update(ctx, fetched); !errors.As(err, &validationErr) || validationErr.Fields[0].Rule != "precision" {
+		t.Fatalf("expected too many digits to fail validation, got %v", err)
+	}
+}
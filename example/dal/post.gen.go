@@ -1,7 +1,7 @@
 /*
 NOTE! This code is autogenerated.
 Don't manually change this code.
-Version [4cf9ef9c5134f59be8d5a811f3608377bf4b3a6c058f413b9056eab9519fedd9]
+Version [c26a0e3ea1c5a3dde3d010ba02f2941f39d4d59b89e0ebb6f6c40036a0ca564c]
 */
 package dal
 
@@ -11,6 +11,7 @@ import (
     "database/sql"
     _ "embed"
     "errors"
+    "iter"
     "strings"
     "sync/atomic"
 
@@ -99,10 +100,23 @@ type PostRepository interface {
     // GetStoryUidsByUser fetches a list of story_uid values.
     // CACHING NOTE: Uses the shared listCache for invalidation.
     GetStoryUidsByUser(ctx context.Context, userId string) ([]string, error)
+    // SearchPosts returns the entities matching query in post, most relevant first.
+    // CACHING NOTE: Uses the listCache for 10 seconds, keyed by the normalized query.
+    SearchPosts(ctx context.Context, query string, startID int64, pageSize int) ([]*Post, error)
+    // SearchPostsForAge returns the entities matching query in post, most relevant first.
+    // CACHING NOTE: Uses the listCache for 5 seconds, keyed by the normalized query.
+    SearchPostsForAge(ctx context.Context, query string, targetAge int8, startID int64, pageSize int) ([]*Post, error)
     ListById(ctx context.Context, startID int64, pageSize int) ([]*Post, error)
     CountListById(ctx context.Context, ) (int64, error)
-    RecentPosts(ctx context.Context, targetAge int8, startID int64, pageSize int) ([]*Post, error)
+    // IterateByListById walks the whole list in batches on the read database, bypassing the caches.
+    IterateByListById(ctx context.Context, opts IterateOptions) iter.Seq2[*Post, error]
+    // RecentPosts returns a page of entities ordered by created and the cursor of the next page, nil after the last one.
+    RecentPosts(ctx context.Context, targetAge int8, cursor *PostRecentPostsCursor, pageSize int) ([]*Post, *PostRecentPostsCursor, error)
     CountRecentPosts(ctx context.Context, targetAge int8) (int64, error)
+    // IterateByRecentPosts walks the whole list in batches on the read database, bypassing the caches.
+    IterateByRecentPosts(ctx context.Context, targetAge int8, opts IterateOptions) iter.Seq2[*Post, error]
+    // IterateAll walks every entity in ID order in batches on the read database, bypassing the caches.
+    IterateAll(ctx context.Context, opts IterateOptions) iter.Seq2[*Post, error]
     // DeleteExpired executes a custom bulk delete operation.
     // 
     // ⚠️ CAUTION - CACHE FLUSH NOTE ⚠️
@@ -129,15 +143,118 @@ type postRepository struct {
 	dbBreaker           *gobreaker.CircuitBreaker
     telemetryProvider TelemetryProvider
     listEpoch         atomic.Int64
+    hooks             postHooks
+}
+
+
+// PostBeforeCreate is a hook run by Create and upserts before inserting the Post. Returning an error vetoes the insert.
+type PostBeforeCreate interface {
+    BeforeCreate(ctx context.Context, entity *Post) error
+}
+
+// PostAfterCreate is a hook run by Create and upserts after inserting the Post. Returning an error rolls the insert back.
+type PostAfterCreate interface {
+    AfterCreate(ctx context.Context, entity *Post) error
+}
+
+// PostBeforeCreateBulk is a hook run by CreateBulk and bulk upserts before inserting the Posts. Returning an error vetoes the inserts.
+type PostBeforeCreateBulk interface {
+    BeforeCreateBulk(ctx context.Context, entities []*Post) error
+}
+
+// PostAfterCreateBulk is a hook run by CreateBulk and bulk upserts after inserting the Posts. Returning an error rolls the inserts back.
+type PostAfterCreateBulk interface {
+    AfterCreateBulk(ctx context.Context, entities []*Post) error
+}
+
+// PostBeforeUpdate is a hook run by Update, upserts and patches before storing the Post. Returning an error vetoes the update.
+type PostBeforeUpdate interface {
+    BeforeUpdate(ctx context.Context, entity *Post) error
+}
+
+// PostAfterUpdate is a hook run by Update and upserts after storing the Post. Returning an error rolls the update back.
+type PostAfterUpdate interface {
+    AfterUpdate(ctx context.Context, entity *Post) error
+}
+
+// PostBeforeDelete is a hook run by Delete before removing the Post. Returning an error vetoes the delete.
+type PostBeforeDelete interface {
+    BeforeDelete(ctx context.Context, entity *Post) error
+}
+
+// PostAfterDelete is a hook run by Delete after removing the Post. Returning an error rolls the delete back.
+type PostAfterDelete interface {
+    AfterDelete(ctx context.Context, entity *Post) error
+}
+
+// postHooks holds the hooks of a postRepository per operation: the methods of Post
+// implementing the entity hook interfaces first, then the hooks passed to NewPostRepository in order.
+type postHooks struct {
+    beforeCreate     []hookFunc[*Post]
+    afterCreate      []hookFunc[*Post]
+    beforeCreateBulk []hookFunc[[]*Post]
+    afterCreateBulk  []hookFunc[[]*Post]
+    beforeUpdate     []hookFunc[*Post]
+    afterUpdate      []hookFunc[*Post]
+    beforeDelete     []hookFunc[*Post]
+    afterDelete      []hookFunc[*Post]
+}
+
+// newPostHooks sorts hooks by the Post hook interfaces they implement. It panics on a hook
+// implementing none of them, which is a programming error.
+func newPostHooks(hooks []interface{}) postHooks {
+    var h postHooks
+    h.beforeCreate = appendEntityHook(h.beforeCreate, BeforeCreateHook.BeforeCreate)
+    h.afterCreate = appendEntityHook(h.afterCreate, AfterCreateHook.AfterCreate)
+    h.beforeCreateBulk = appendEntityBulkHook(h.beforeCreateBulk, BeforeCreateHook.BeforeCreate)
+    h.afterCreateBulk = appendEntityBulkHook(h.afterCreateBulk, AfterCreateHook.AfterCreate)
+    h.beforeUpdate = appendEntityHook(h.beforeUpdate, BeforeUpdateHook.BeforeUpdate)
+    h.afterUpdate = appendEntityHook(h.afterUpdate, AfterUpdateHook.AfterUpdate)
+    h.beforeDelete = appendEntityHook(h.beforeDelete, BeforeDeleteHook.BeforeDelete)
+    h.afterDelete = appendEntityHook(h.afterDelete, AfterDeleteHook.AfterDelete)
+
+    for _, hook := range hooks {
+        registered := false
+        if hook, ok := hook.(PostBeforeCreate); ok {
+            h.beforeCreate, registered = append(h.beforeCreate, hook.BeforeCreate), true
+        }
+        if hook, ok := hook.(PostAfterCreate); ok {
+            h.afterCreate, registered = append(h.afterCreate, hook.AfterCreate), true
+        }
+        if hook, ok := hook.(PostBeforeCreateBulk); ok {
+            h.beforeCreateBulk, registered = append(h.beforeCreateBulk, hook.BeforeCreateBulk), true
+        }
+        if hook, ok := hook.(PostAfterCreateBulk); ok {
+            h.afterCreateBulk, registered = append(h.afterCreateBulk, hook.AfterCreateBulk), true
+        }
+        if hook, ok := hook.(PostBeforeUpdate); ok {
+            h.beforeUpdate, registered = append(h.beforeUpdate, hook.BeforeUpdate), true
+        }
+        if hook, ok := hook.(PostAfterUpdate); ok {
+            h.afterUpdate, registered = append(h.afterUpdate, hook.AfterUpdate), true
+        }
+        if hook, ok := hook.(PostBeforeDelete); ok {
+            h.beforeDelete, registered = append(h.beforeDelete, hook.BeforeDelete), true
+        }
+        if hook, ok := hook.(PostAfterDelete); ok {
+            h.afterDelete, registered = append(h.afterDelete, hook.AfterDelete), true
+        }
+        if !registered {
+            panic(fmt.Sprintf("NewPostRepository: hook %T implements none of the Post hook interfaces", hook))
+        }
+    }
+    return h
 }
 
 // NewPostRepository now returns the PostRepository interface.
+// Each of hooks implements one or more of the PostBefore* and PostAfter* hook interfaces.
 func NewPostRepository(
     provider DBProvider, 
     cacheProvider CacheProvider,
     configProvider ConfigProvider, 
     dbSettings gobreaker.Settings, 
-    telemetry TelemetryProvider) PostRepository {
+    telemetry TelemetryProvider,
+    hooks ...interface{}) PostRepository {
 
     if configProvider == nil {
         configProvider = DefaultConfigProvider{}
@@ -171,6 +288,12 @@ func NewPostRepository(
 		if errors.Is(err, ErrNotFound) {
 			return true
 		}
+		// Neither does a hook vetoing the operation or an invalid entity
+		var hookErr *HookError
+		var validationErr *ValidationError
+		if errors.As(err, &hookErr) || errors.As(err, &validationErr) {
+			return true
+		}
 		return false
 	}
 
@@ -195,6 +318,7 @@ func NewPostRepository(
         configProvider: configProvider,
 		dbBreaker:      gobreaker.NewCircuitBreaker(dbSettings),
         telemetryProvider: telemetry,
+        hooks:          newPostHooks(hooks),
     }
 
     // Initialize the epoch and register the Pub/Sub handler
@@ -289,8 +413,8 @@ func (d *postRepository) CreateTable(ctx context.Context) error {
 		var cleanLines []string
 		for _, line := range strings.Split(schemaPost, "\n") {
 			trimmedLine := strings.TrimSpace(line)
-			// Skip empty lines and lines that start with a comment hash
-			if trimmedLine == "" || strings.HasPrefix(trimmedLine, "#") {
+			// Skip empty lines and comment lines ('#' for MySQL, '--' for PostgreSQL and SQLite)
+			if trimmedLine == "" || strings.HasPrefix(trimmedLine, "#") || strings.HasPrefix(trimmedLine, "--") {
 				continue
 			}
 			cleanLines = append(cleanLines, line)
@@ -352,18 +476,27 @@ func (d *postRepository) Create(ctx context.Context, entity *Post) (*Post, error
 
 	d.telemetryProvider.IncDALOperation("post", "create")
 
-	_, err := d.dbBreaker.Execute(func() (interface{}, error) {
-		return d.create(ctx, entity)
+	created, updated := entity.Created, entity.Updated
+	_, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return nil, withHooks(ctx, "Create", entity, d.hooks.beforeCreate, d.hooks.afterCreate, nil, func(ctx context.Context) error {
+			_, err := d.create(ctx, entity)
+			return err
+		})
 	})
 
 	if err != nil {
+		entity.ID, entity.Created, entity.Updated = 0, created, updated // An after hook may have rolled the insert back
 		return nil, err
 	}
 
-	d.setCached(entity)
+	// Inside a transaction caches are only touched once it commits.
+	cached := *entity
+	afterCommit(ctx, func() {
+		d.setCached(&cached)
 
-	// All lists cache should be flushed.
-	d.FlushListCache()
+		// All lists cache should be flushed.
+		d.FlushListCache()
+	})
 
 	return entity, err
 }
@@ -384,7 +517,7 @@ created,updated)
 		VALUES (?,?,?,?,?,?,?,?,?,?)
 	`
 
-    db, dbErr := d.dbProvider.GetDatabase("post", true);
+    db, dbErr := databaseFor(ctx, d.dbProvider, "post", true)
     if dbErr != nil {
 		d.telemetryProvider.IncDBError("post", operation)		
         return nil, dbErr
@@ -417,21 +550,45 @@ func (d *postRepository) CreateBulk(ctx context.Context, entities []*Post) ([]*P
 		return nil, ErrOperationBlocked
 	}
 
+
 	const operation = "create_bulk"
 	d.telemetryProvider.IncDALOperation("post", operation)
 
-	result, err := d.dbBreaker.Execute(func() (interface{}, error) {
-		return d.createBulk(ctx, entities)
+	var created []*Post
+	_, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return nil, withHooks(ctx, "CreateBulk", entities, d.hooks.beforeCreateBulk, d.hooks.afterCreateBulk, nil, func(ctx context.Context) error {
+			var err error
+			created, err = d.createBulk(ctx, entities)
+			return err
+		})
 	})
 
 	if err != nil {
+		// With after hooks all chunks share a transaction, which the error rolled back.
+		// A retry must not find the IDs of the vanished rows.
+		if len(d.hooks.afterCreateBulk) > 0 {
+			for _, entity := range entities {
+				entity.ID = 0
+			}
+		}
 		return nil, err
 	}
 
-	// All lists cache should be flushed.
-	d.FlushListCache()
+	// Inside a transaction caches are only touched once it commits.
+	cached := make([]Post, len(created))
+	for i, entity := range created {
+		cached[i] = *entity
+	}
+	afterCommit(ctx, func() {
+		for i := range cached {
+			d.setCached(&cached[i])
+		}
+
+		// All lists cache should be flushed.
+		d.FlushListCache()
+	})
 
-	return result.([]*Post), err
+	return created, err
 }
 
 func (d *postRepository) createBulk(ctx context.Context, entities []*Post) ([]*Post, error) {
@@ -443,7 +600,7 @@ func (d *postRepository) createBulk(ctx context.Context, entities []*Post) ([]*P
     }
 
     // Get the database connection once, outside the loop
-    db, dbErr := d.dbProvider.GetDatabase("post", true)
+    db, dbErr := databaseFor(ctx, d.dbProvider, "post", true)
     if dbErr != nil {
         d.telemetryProvider.IncDBError("post", operation)
         return nil, dbErr
@@ -504,7 +661,7 @@ created,updated)
             return nil, fmt.Errorf("failed to bulk insert posts chunk: %w", err)
         }
 
-        // Get generated IDs (works for MySQL/SQLite)
+        // Get generated IDs. MySQL reports the first ID of a multi row INSERT, SQLite the last one.
         lastID, err := result.LastInsertId()
         if err != nil {
             return nil, fmt.Errorf("failed to get last insert ID for chunk: %w", err)
@@ -518,11 +675,11 @@ created,updated)
         if rowsAffected != int64(len(chunk)) {
             return nil, fmt.Errorf("mismatch between inserted rows and entity count in chunk")
         }
+        firstID := lastID
 
         // Assign generated IDs sequentially to the current chunk
         for j := range chunk {
-            chunk[j].ID = lastID + int64(j)
-			d.setCached(chunk[j])
+            chunk[j].ID = firstID + int64(j)
         }
     }
 
@@ -542,26 +699,38 @@ func (d *postRepository) Update(ctx context.Context, entity *Post) error {
 	const operation = "update"
 	d.telemetryProvider.IncDALOperation("post", operation)
 
-	
-
 	// Perform the update in DB.
-	_, err2 := d.dbBreaker.Execute(func() (interface{}, error) {
-		return nil, d.update(ctx, entity)
+	version := entity.Version
+	_, err2 := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return nil, withHooks(ctx, "Update", entity, d.hooks.beforeUpdate, d.hooks.afterUpdate, nil, func(ctx context.Context) error {
+			if err := d.update(ctx, entity); err != nil {
+				return err
+			}
+			entity.Version++
+			return nil
+		})
 	})
 
 	if err2 != nil {
+		entity.Version = version // An after hook may have rolled the update back
 		return err2
 	}
 
+	// Compared once the before hooks ran, as they may change the unique values.
 	
 
-	// Lets clear item from cache on other instances and here
-	d.InvalidateCache(entity)
-	d.FlushListCache()
+	// Inside a transaction caches are only touched once it commits.
+	cached := *entity
+	afterCommit(ctx, func() {
+		
+
+		// Lets clear item from cache on other instances and here
+		d.InvalidateCache(&cached)
+		d.FlushListCache()
 
-	entity.Version++
-	// our entity can become part of local cache
-	d.setCached(entity)
+		// our entity can become part of local cache
+		d.setCached(&cached)
+	})
 
 	return nil
 }
@@ -578,7 +747,7 @@ func (d *postRepository) update(ctx context.Context, entity *Post) error {
 		WHERE id = ? AND version = ? 
 	`
 
-    db, dbErr := d.dbProvider.GetDatabase("post", true);
+    db, dbErr := databaseFor(ctx, d.dbProvider, "post", true)
     if dbErr != nil {
 		d.telemetryProvider.IncDBError("post", operation)
         return dbErr
@@ -600,7 +769,8 @@ func (d *postRepository) update(ctx context.Context, entity *Post) error {
 
 	if rowsAffected == 0 {
 		// clean out cache for specific ID to limit stale cache entries
-		d.InvalidateCache(entity)
+		stale := *entity
+		afterCommit(ctx, func() { d.InvalidateCache(&stale) })
 		return ErrNotFound
 	}
 
@@ -624,8 +794,10 @@ func (d *postRepository) Delete(ctx context.Context, entity *Post) error {
 	const operation = "delete"
 	d.telemetryProvider.IncDALOperation("post", operation)
 
-	_, err := d.dbBreaker.Execute(func() (interface{}, error) {
-		return nil, d.delete(ctx, entity.ID)
+	_, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return nil, withHooks(ctx, "Delete", entity, d.hooks.beforeDelete, d.hooks.afterDelete, nil, func(ctx context.Context) error {
+			return d.delete(ctx, entity.ID)
+		})
 	})
 
 	if err != nil {
@@ -633,8 +805,11 @@ func (d *postRepository) Delete(ctx context.Context, entity *Post) error {
 	}
 
 	// Lets clear item from cache and on next use use DB as source of truth
-	d.InvalidateCache(entity)
-	d.FlushListCache()
+	deleted := *entity
+	afterCommit(ctx, func() {
+		d.InvalidateCache(&deleted)
+		d.FlushListCache()
+	})
 
 	return err
 }
@@ -650,7 +825,7 @@ func (d *postRepository) delete(ctx context.Context, id int64) error {
 	`
 	
 
-    db, dbErr := d.dbProvider.GetDatabase("post", true);
+    db, dbErr := databaseFor(ctx, d.dbProvider, "post", true)
     if dbErr != nil {
 		d.telemetryProvider.IncDBError("post", operation)
         return dbErr
@@ -690,14 +865,16 @@ func (d *postRepository) GetByID(ctx context.Context, id int64) (*Post, error) {
     const operation = "get_by_id"
     d.telemetryProvider.IncDALOperation("post", operation)
 
-	// Load from cache
-    cachedEntity, _ := d.getByIDCached(id)
-    if cachedEntity != nil {
-        return cachedEntity, nil
+	// Load from cache. Transactions bypass caches to see their own writes.
+    if !inTx(ctx) {
+        cachedEntity, _ := d.getByIDCached(id)
+        if cachedEntity != nil {
+            return cachedEntity, nil
+        }
     }
 
 	// Fallback to database if cache miss or decoding fails
-	result, err := d.dbBreaker.Execute(func() (interface{}, error) {
+	result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
 		return d.getByID(ctx, id)
 	})
 
@@ -710,7 +887,9 @@ func (d *postRepository) GetByID(ctx context.Context, id int64) (*Post, error) {
         return nil, fmt.Errorf("invalid type")
     }
 
-    _ = d.setCached(entity)
+    if !inTx(ctx) {
+        _ = d.setCached(entity)
+    }
 
     return entity, err
 }
@@ -765,7 +944,7 @@ func (d *postRepository) getByID(ctx context.Context, id int64) (*Post, error) {
         WHERE id = ? 
     `
 
-    db, dbErr := d.dbProvider.GetDatabase("post", false);
+    db, dbErr := databaseFor(ctx, d.dbProvider, "post", false)
     if dbErr != nil {
         d.telemetryProvider.IncDBError("post", operation)
         return nil, dbErr
@@ -805,8 +984,9 @@ func (d *postRepository) ListById(ctx context.Context, startID int64, pageSize i
 	d.telemetryProvider.IncDALOperation("post", operation)
 
     cacheKey := fmt.Sprintf("post_list_by_id:epoch_%d:%d:%d", d.getEpoch(), startID, pageSize)
+    // Transactions bypass caches to see their own writes.
     val, found := d.listCache.Get(cacheKey)
-    if found {
+    if found && !inTx(ctx) {
         entityIDs, ok := val.([]int64)
         if !ok {
             return nil, fmt.Errorf("postRepository.ListById: Cache returned wrong type; expected array ID type")
@@ -840,7 +1020,7 @@ func (d *postRepository) ListById(ctx context.Context, startID int64, pageSize i
      d.telemetryProvider.IncCacheMiss("post", operation)       
 
     // 2) Fallback to DB
-    result, err := d.dbBreaker.Execute(func() (interface{}, error) {
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
         return d.listById(ctx, startID, pageSize)
     })
 
@@ -850,6 +1030,10 @@ func (d *postRepository) ListById(ctx context.Context, startID int64, pageSize i
     }
 
     entities := result.([]*Post)
+    if inTx(ctx) {
+        return entities, nil
+    }
+
     // Store Cache. cacheKey = user_list_by_id:startID:pageSize.
 	// Stored is array of entity IDs.
 	var entityIDs []int64
@@ -869,14 +1053,14 @@ func (d *postRepository) listById(ctx context.Context, startID int64, pageSize i
 
     var query string
 
-    // if startID is zero then query is different for pagination
+    // the first page query is different for pagination
     if startID == 0 {
         query = `SELECT id, version, deleted, expires_at, language_id, post, revoked, story_uid, target_age, user_id, created, updated FROM posts ORDER BY id LIMIT ?`
     } else {
         query = `SELECT id, version, deleted, expires_at, language_id, post, revoked, story_uid, target_age, user_id, created, updated FROM posts WHERE id > ? ORDER BY id LIMIT ?`
     }
 
-    db, dbErr := d.dbProvider.GetDatabase("post", false);
+    db, dbErr := databaseFor(ctx, d.dbProvider, "post", false)
     if dbErr != nil {
         d.telemetryProvider.IncDBError("post", operation)
         return nil, dbErr
@@ -934,7 +1118,43 @@ func (d *postRepository) listById(ctx context.Context, startID int64, pageSize i
 
 
 
-func (d *postRepository) RecentPosts(ctx context.Context, targetAge int8, startID int64, pageSize int) ([]*Post, error) {
+
+// PostRecentPostsCursor points at the last Post of a RecentPosts page. Pass it to RecentPosts to fetch the next page,
+// or nil for the first one.
+type PostRecentPostsCursor struct {
+	Created time.Time
+	ID int64
+}
+
+// cacheKey identifies the page in list cache keys.
+func (c *PostRecentPostsCursor) cacheKey() string {
+	if c == nil {
+		return "first"
+	}
+	return fmt.Sprintf("%v:%d", c.Created.UnixNano(), c.ID)
+}
+
+// newPostRecentPostsCursor returns the cursor of the page following entities, or nil if it was the last page.
+func newPostRecentPostsCursor(entities []*Post, pageSize int) *PostRecentPostsCursor {
+	if len(entities) == 0 || len(entities) < pageSize {
+		return nil
+	}
+
+	last := entities[len(entities)-1]
+	return &PostRecentPostsCursor{Created: last.Created, ID: last.ID}
+}
+
+// RecentPosts returns a page of Posts ordered by created descending, starting after cursor,
+// and the cursor of the next page, which is nil once the last page is reached.
+func (d *postRepository) RecentPosts(ctx context.Context, targetAge int8, cursor *PostRecentPostsCursor, pageSize int) ([]*Post, *PostRecentPostsCursor, error) {
+	entities, err := d.recentPostsWithCache(ctx, targetAge, cursor, pageSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entities, newPostRecentPostsCursor(entities, pageSize), nil
+}
+
+func (d *postRepository) recentPostsWithCache(ctx context.Context, targetAge int8, cursor *PostRecentPostsCursor, pageSize int) ([]*Post, error) {
     if d.configProvider.BlockedReads("post") {
         return nil, ErrOperationBlocked
     }
@@ -942,9 +1162,10 @@ func (d *postRepository) RecentPosts(ctx context.Context, targetAge int8, startI
 	const operation = "recent_posts"
 	d.telemetryProvider.IncDALOperation("post", operation)
 
-    cacheKey := fmt.Sprintf("post_recent_posts:epoch_%d:%v:%d:%d", d.getEpoch(), targetAge, startID, pageSize)
+    cacheKey := fmt.Sprintf("post_recent_posts:epoch_%d:%v:%s:%d", d.getEpoch(), targetAge, cursor.cacheKey(), pageSize)
+    // Transactions bypass caches to see their own writes.
     val, found := d.listCache.Get(cacheKey)
-    if found {
+    if found && !inTx(ctx) {
         entityIDs, ok := val.([]int64)
         if !ok {
             return nil, fmt.Errorf("postRepository.RecentPosts: Cache returned wrong type; expected array ID type")
@@ -978,8 +1199,8 @@ func (d *postRepository) RecentPosts(ctx context.Context, targetAge int8, startI
      d.telemetryProvider.IncCacheMiss("post", operation)       
 
     // 2) Fallback to DB
-    result, err := d.dbBreaker.Execute(func() (interface{}, error) {
-        return d.recentPosts(ctx, targetAge, startID, pageSize)
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+        return d.recentPosts(ctx, targetAge, cursor, pageSize)
     })
 
     if err != nil {
@@ -988,6 +1209,10 @@ func (d *postRepository) RecentPosts(ctx context.Context, targetAge int8, startI
     }
 
     entities := result.([]*Post)
+    if inTx(ctx) {
+        return entities, nil
+    }
+
     // Store Cache. cacheKey = user_list_by_id:startID:pageSize.
 	// Stored is array of entity IDs.
 	var entityIDs []int64
@@ -1001,20 +1226,20 @@ func (d *postRepository) RecentPosts(ctx context.Context, targetAge int8, startI
     return entities, nil
 }
 
-func (d *postRepository) recentPosts(ctx context.Context, targetAge int8, startID int64, pageSize int) ([]*Post, error) {
+func (d *postRepository) recentPosts(ctx context.Context, targetAge int8, cursor *PostRecentPostsCursor, pageSize int) ([]*Post, error) {
     const operation = "recent_posts"
 	dbStart := time.Now()
 
     var query string
 
-    // if startID is zero then query is different for pagination
-    if startID == 0 {
+    // the first page query is different for pagination
+    if cursor == nil {
         query = `SELECT id, version, deleted, expires_at, language_id, post, revoked, story_uid, target_age, user_id, created, updated FROM posts WHERE (deleted = 0 and target_age = ?) ORDER BY created DESC, id DESC LIMIT ?`
     } else {
-        query = `SELECT id, version, deleted, expires_at, language_id, post, revoked, story_uid, target_age, user_id, created, updated FROM posts WHERE (deleted = 0 and target_age = ?) AND id < ? ORDER BY created DESC, id DESC LIMIT ?`
+        query = `SELECT id, version, deleted, expires_at, language_id, post, revoked, story_uid, target_age, user_id, created, updated FROM posts WHERE (deleted = 0 and target_age = ?) AND (created, id) < (?, ?) ORDER BY created DESC, id DESC LIMIT ?`
     }
 
-    db, dbErr := d.dbProvider.GetDatabase("post", false);
+    db, dbErr := databaseFor(ctx, d.dbProvider, "post", false)
     if dbErr != nil {
         d.telemetryProvider.IncDBError("post", operation)
         return nil, dbErr
@@ -1023,10 +1248,10 @@ func (d *postRepository) recentPosts(ctx context.Context, targetAge int8, startI
     var rows *sql.Rows
     var err error
 
-    if startID == 0 {
+    if cursor == nil {
 	    rows, err = db.QueryContext(ctx, query, targetAge, pageSize)
     } else {
-        rows, err = db.QueryContext(ctx, query, targetAge, startID, pageSize)
+        rows, err = db.QueryContext(ctx, query, targetAge, cursor.Created, cursor.ID, pageSize)
     }
 
 	if err != nil {
@@ -1082,8 +1307,9 @@ func (d *postRepository) CountListById(ctx context.Context, ) (int64, error) {
 	d.telemetryProvider.IncDALOperation("post", operation)
 	
 	cacheKey := fmt.Sprintf("post_count_list_by_id:epoch_%d", d.getEpoch())
+	// Transactions bypass caches to see their own writes.
 	val, found := d.countCache.Get(cacheKey)
-	if found {
+	if found && !inTx(ctx) {
 		count, ok := val.(int64)
 		if !ok {
 			return 0, fmt.Errorf("postRepository.CountListById: Cache returned wrong type; expected int64")
@@ -1097,7 +1323,7 @@ func (d *postRepository) CountListById(ctx context.Context, ) (int64, error) {
 	 d.telemetryProvider.IncCacheMiss("post", operation)       
 
 	// 2) Fallback to DB
-	count, err := d.dbBreaker.Execute(func() (interface{}, error) {
+	count, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
 		return d.countListById(ctx, )
 	})
 
@@ -1106,7 +1332,9 @@ func (d *postRepository) CountListById(ctx context.Context, ) (int64, error) {
 		return 0, err
 	}
 
-	d.countCache.Set(cacheKey, count, time.Second*60)
+	if !inTx(ctx) {
+		d.countCache.Set(cacheKey, count, time.Second*60)
+	}
 
 	return count.(int64), nil
 }
@@ -1118,7 +1346,7 @@ func (d *postRepository) countListById(ctx context.Context, ) (int64, error) {
 	// if startID is zero then query is different for pagination
 	query := `SELECT count(*) FROM posts`
 
-	db, dbErr := d.dbProvider.GetDatabase("post", false)
+	db, dbErr := databaseFor(ctx, d.dbProvider, "post", false)
 	if dbErr != nil {
 		d.telemetryProvider.IncDBError("post", operation)
 		return 0, dbErr
@@ -1155,8 +1383,9 @@ func (d *postRepository) CountRecentPosts(ctx context.Context, targetAge int8) (
 	d.telemetryProvider.IncDALOperation("post", operation)
 	
 	cacheKey := fmt.Sprintf("post_count_recent_posts:epoch_%d:%v", d.getEpoch(), targetAge)
+	// Transactions bypass caches to see their own writes.
 	val, found := d.countCache.Get(cacheKey)
-	if found {
+	if found && !inTx(ctx) {
 		count, ok := val.(int64)
 		if !ok {
 			return 0, fmt.Errorf("postRepository.CountRecentPosts: Cache returned wrong type; expected int64")
@@ -1170,7 +1399,7 @@ func (d *postRepository) CountRecentPosts(ctx context.Context, targetAge int8) (
 	 d.telemetryProvider.IncCacheMiss("post", operation)       
 
 	// 2) Fallback to DB
-	count, err := d.dbBreaker.Execute(func() (interface{}, error) {
+	count, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
 		return d.countRecentPosts(ctx, targetAge)
 	})
 
@@ -1179,7 +1408,9 @@ func (d *postRepository) CountRecentPosts(ctx context.Context, targetAge int8) (
 		return 0, err
 	}
 
-	d.countCache.Set(cacheKey, count, time.Second*60)
+	if !inTx(ctx) {
+		d.countCache.Set(cacheKey, count, time.Second*60)
+	}
 
 	return count.(int64), nil
 }
@@ -1191,7 +1422,7 @@ func (d *postRepository) countRecentPosts(ctx context.Context, targetAge int8) (
 	// if startID is zero then query is different for pagination
 	query := `SELECT count(*) FROM posts WHERE (deleted = 0 and target_age = ?)`
 
-	db, dbErr := d.dbProvider.GetDatabase("post", false)
+	db, dbErr := databaseFor(ctx, d.dbProvider, "post", false)
 	if dbErr != nil {
 		d.telemetryProvider.IncDBError("post", operation)
 		return 0, dbErr
@@ -1218,6 +1449,146 @@ func (d *postRepository) countRecentPosts(ctx context.Context, targetAge int8) (
 
 
 
+
+// IterateAll walks every Post in ID order, a batch at a time, on the read database. It bypasses the
+// caches and leaves them untouched, so backfills and exports don't evict hot entries.
+func (d *postRepository) IterateAll(ctx context.Context, opts IterateOptions) iter.Seq2[*Post, error] {
+    return iterateBatches(ctx, opts, func(after *Post, batchSize int) ([]*Post, error) {
+        if d.configProvider.BlockedReads("post") {
+            return nil, ErrOperationBlocked
+        }
+        d.telemetryProvider.IncDALOperation("post", "iterate_all")
+
+        startID := opts.StartAfterID
+        if after != nil {
+            startID = after.ID
+        }
+
+        result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+            return d.iterateAll(ctx, startID, batchSize)
+        })
+        if err != nil {
+            return nil, err
+        }
+        return result.([]*Post), nil
+    })
+}
+
+func (d *postRepository) iterateAll(ctx context.Context, startID int64, batchSize int) ([]*Post, error) {
+    const operation = "iterate_all"
+    dbStart := time.Now()
+
+    query := `SELECT id, version, deleted, expires_at, language_id, post, revoked, story_uid, target_age, user_id, created, updated FROM posts WHERE id > ? ORDER BY id LIMIT ?`
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "post", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("post", operation)
+        return nil, dbErr
+    }
+
+    rows, err := db.QueryContext(ctx, query, startID, batchSize)
+    if err != nil {
+        d.telemetryProvider.IncDBError("post", operation)
+        return nil, fmt.Errorf("failed to iterate posts: %w", err)
+    }
+    defer rows.Close()
+
+    var entities []*Post
+    for rows.Next() {
+        var entity Post
+        err := rows.Scan(
+            &entity.ID,
+            &entity.Version,
+            &entity.Deleted,
+            &entity.ExpiresAt,
+            &entity.LanguageId,
+            &entity.Post,
+            &entity.Revoked,
+            &entity.StoryUid,
+            &entity.TargetAge,
+            &entity.UserId,
+            &entity.Created,
+            &entity.Updated,
+            
+        )
+        if err != nil {
+            d.telemetryProvider.IncDBError("post", operation)
+            return nil, fmt.Errorf("failed to scan Post: %w", err)
+        }
+        entities = append(entities, &entity)
+    }
+
+    if err = rows.Err(); err != nil {
+        d.telemetryProvider.IncDBError("post", operation)
+        return nil, fmt.Errorf("rows error: %w", err)
+    }
+
+    d.telemetryProvider.IncDBRequest("post", operation)
+    d.telemetryProvider.ObserveDBLatency("post", operation, time.Since(dbStart).Seconds())
+    return entities, nil
+}
+
+
+
+// IterateByListById walks every Post of ListById in its order, a batch at a time, on the read
+// database. It bypasses the caches and leaves them untouched, so backfills and exports don't evict hot entries.
+func (d *postRepository) IterateByListById(ctx context.Context, opts IterateOptions) iter.Seq2[*Post, error] {
+    return iterateBatches(ctx, opts, func(after *Post, batchSize int) ([]*Post, error) {
+        if d.configProvider.BlockedReads("post") {
+            return nil, ErrOperationBlocked
+        }
+        d.telemetryProvider.IncDALOperation("post", "iterate_by_list_by_id")
+
+        startID := opts.StartAfterID
+        if after != nil {
+            startID = after.ID
+        }
+
+        result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+            return d.listById(ctx, startID, batchSize)
+        })
+        if err != nil {
+            return nil, err
+        }
+        return result.([]*Post), nil
+    })
+}
+
+
+
+// IterateByRecentPosts walks every Post of RecentPosts in its order, a batch at a time, on the read
+// database. It bypasses the caches and leaves them untouched, so backfills and exports don't evict hot entries.
+func (d *postRepository) IterateByRecentPosts(ctx context.Context, targetAge int8, opts IterateOptions) iter.Seq2[*Post, error] {
+    return iterateBatches(ctx, opts, func(after *Post, batchSize int) ([]*Post, error) {
+        if d.configProvider.BlockedReads("post") {
+            return nil, ErrOperationBlocked
+        }
+        d.telemetryProvider.IncDALOperation("post", "iterate_by_recent_posts")
+
+        var cursor *PostRecentPostsCursor
+        if after != nil {
+            cursor = newPostRecentPostsCursor([]*Post{after}, 1)
+        } else if opts.StartAfterID != 0 {
+            // Continue after the position of the entity in the list
+            entity, err := d.getByID(ctx, opts.StartAfterID)
+            if err != nil {
+                return nil, fmt.Errorf("failed to resume RecentPosts after Post %d: %w", opts.StartAfterID, err)
+            }
+            cursor = newPostRecentPostsCursor([]*Post{entity}, 1)
+        }
+
+        result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+            return d.recentPosts(ctx, targetAge, cursor, batchSize)
+        })
+        if err != nil {
+            return nil, err
+        }
+        return result.([]*Post), nil
+    })
+}
+
+
+
 func (d *postRepository) ListByLanguages(ctx context.Context, languageIds []string) ([]*Post, error) {
     if d.configProvider.BlockedReads("post") {
         return nil, ErrOperationBlocked
@@ -1251,7 +1622,7 @@ func (d *postRepository) ListByLanguages(ctx context.Context, languageIds []stri
             args[j] = v
         }
 
-        dbResult, err := d.dbBreaker.Execute(func() (interface{}, error) {
+        dbResult, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
             return d.listByLanguages(ctx, args)
         })
 
@@ -1262,9 +1633,11 @@ func (d *postRepository) ListByLanguages(ctx context.Context, languageIds []stri
         dbEntities := dbResult.([]*Post)
         results = append(results, dbEntities...)
 
-        // Cache the newly fetched items
-        for _, entity := range dbEntities {
-            d.setCached(entity)
+        // Cache the newly fetched items. Transactions bypass caches to see their own writes.
+        if !inTx(ctx) {
+            for _, entity := range dbEntities {
+                d.setCached(entity)
+            }
         }
     }
 
@@ -1287,7 +1660,7 @@ func (d *postRepository) listByLanguages(ctx context.Context, chunkArgs []interf
         WHERE language_id IN (%s)
     `, strings.Join(placeholders, ","))
 
-    db, dbErr := d.dbProvider.GetDatabase("post", false)
+    db, dbErr := databaseFor(ctx, d.dbProvider, "post", false)
     if dbErr != nil {
         d.telemetryProvider.IncDBError("post", operation)
         return nil, dbErr
@@ -1375,7 +1748,7 @@ func (d *postRepository) ListByUserAndStories(ctx context.Context, user string,
             args[j] = v
         }
 
-        dbResult, err := d.dbBreaker.Execute(func() (interface{}, error) {
+        dbResult, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
             return d.listByUserAndStories(ctx, user, args)
         })
 
@@ -1386,9 +1759,11 @@ func (d *postRepository) ListByUserAndStories(ctx context.Context, user string,
         dbEntities := dbResult.([]*Post)
         results = append(results, dbEntities...)
 
-        // Cache the newly fetched items
-        for _, entity := range dbEntities {
-            d.setCached(entity)
+        // Cache the newly fetched items. Transactions bypass caches to see their own writes.
+        if !inTx(ctx) {
+            for _, entity := range dbEntities {
+                d.setCached(entity)
+            }
         }
     }
 
@@ -1411,7 +1786,7 @@ func (d *postRepository) listByUserAndStories(ctx context.Context, user string,
         WHERE (user_id = ?) AND story_uid IN (%s)
     `, strings.Join(placeholders, ","))
 
-    db, dbErr := d.dbProvider.GetDatabase("post", false)
+    db, dbErr := databaseFor(ctx, d.dbProvider, "post", false)
     if dbErr != nil {
         d.telemetryProvider.IncDBError("post", operation)
         return nil, dbErr
@@ -1476,8 +1851,9 @@ func (d *postRepository) GetStoryUidsByUser(ctx context.Context, userId string)
     d.telemetryProvider.IncDALOperation("post", operation)
 
     cacheKey := fmt.Sprintf("post_pluck_get_story_uids_by_user:epoch_%d:%v", d.getEpoch(), userId)
+    // Transactions bypass caches to see their own writes.
     val, found := d.listCache.Get(cacheKey)
-    if found {
+    if found && !inTx(ctx) {
         cachedSlice, ok := val.([]string)
         if !ok {
             return nil, fmt.Errorf("postRepository.GetStoryUidsByUser: Cache returned wrong type")
@@ -1488,7 +1864,7 @@ func (d *postRepository) GetStoryUidsByUser(ctx context.Context, userId string)
 
     d.telemetryProvider.IncCacheMiss("post", operation)
 
-    result, err := d.dbBreaker.Execute(func() (interface{}, error) {
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
         return d.getStoryUidsByUser(ctx, userId)
     })
 
@@ -1499,7 +1875,9 @@ func (d *postRepository) GetStoryUidsByUser(ctx context.Context, userId string)
     slicedResult := result.([]string)
     
     // Store in the shared listCache
-    d.listCache.Set(cacheKey, slicedResult, time.Second*time.Duration(60))
+    if !inTx(ctx) {
+        d.listCache.Set(cacheKey, slicedResult, time.Second*time.Duration(60))
+    }
 
     return slicedResult, nil
 }
@@ -1510,7 +1888,7 @@ func (d *postRepository) getStoryUidsByUser(ctx context.Context, userId string)
 
     query := `SELECT story_uid FROM posts WHERE (user_id = ?)`
 
-    db, dbErr := d.dbProvider.GetDatabase("post", false)
+    db, dbErr := databaseFor(ctx, d.dbProvider, "post", false)
     if dbErr != nil {
         d.telemetryProvider.IncDBError("post", operation)
         return nil, dbErr
@@ -1549,6 +1927,271 @@ func (d *postRepository) getStoryUidsByUser(ctx context.Context, userId string)
 
 
 
+// SearchPosts returns the Posts matching query, most relevant first. Pass 0 as startID for
+// the first page, then the ID of the last Post of the previous page.
+func (d *postRepository) SearchPosts(ctx context.Context, query string, startID int64, pageSize int) ([]*Post, error) {
+    if d.configProvider.BlockedReads("post") {
+        return nil, ErrOperationBlocked
+    }
+
+    searchQuery := normalizeSearchQuery(query)
+    if searchQuery == "" {
+        return nil, nil
+    }
+
+    const operation = "search_posts"
+    d.telemetryProvider.IncDALOperation("post", operation)
+
+    cacheKey := fmt.Sprintf("%s:%q:%d:%d", fmt.Sprintf("post_search_posts:epoch_%d", d.getEpoch()), searchQuery, startID, pageSize)
+    // Transactions bypass caches to see their own writes.
+    val, found := d.listCache.Get(cacheKey)
+    if found && !inTx(ctx) {
+        entityIDs, ok := val.([]int64)
+        if !ok {
+            return nil, fmt.Errorf("postRepository.SearchPosts: Cache returned wrong type; expected array ID type")
+        }
+
+        var entities []*Post
+        missingEntries := false
+        for _, id := range entityIDs {
+            entity, err := d.getByIDCached(id)
+            if err != nil {
+                return nil, err
+            }
+
+            if entity == nil {
+                // An entity left the cache, reload the whole page.
+                missingEntries = true
+                break
+            }
+
+            entities = append(entities, entity)
+        }
+
+        if !missingEntries {
+            d.telemetryProvider.IncCacheHit("post", operation)
+            return entities, nil
+        }
+    }
+
+    d.telemetryProvider.IncCacheMiss("post", operation)
+
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+        return d.searchPosts(ctx, searchQuery, startID, pageSize)
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    entities := result.([]*Post)
+    if inTx(ctx) {
+        return entities, nil
+    }
+
+    // Every query is a new key, so results are kept shorter than lists.
+    var entityIDs []int64
+    for _, entity := range entities {
+        entityIDs = append(entityIDs, entity.ID)
+        d.setCached(entity)
+    }
+    d.listCache.Set(cacheKey, entityIDs, time.Second*10)
+
+    return entities, nil
+}
+
+func (d *postRepository) searchPosts(ctx context.Context, searchQuery string, startID int64, pageSize int) ([]*Post, error) {
+    const operation = "search_posts"
+    dbStart := time.Now()
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "post", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("post", operation)
+        return nil, dbErr
+    }
+
+    var rows *sql.Rows
+    var err error
+    if startID == 0 {
+        rows, err = db.QueryContext(ctx, `SELECT id, version, deleted, expires_at, language_id, post, revoked, story_uid, target_age, user_id, created, updated FROM posts WHERE MATCH (post) AGAINST (? IN NATURAL LANGUAGE MODE) ORDER BY MATCH (post) AGAINST (? IN NATURAL LANGUAGE MODE) DESC, id LIMIT ?`, searchQuery, searchQuery, pageSize)
+    } else {
+        rows, err = db.QueryContext(ctx, `SELECT id, version, deleted, expires_at, language_id, post, revoked, story_uid, target_age, user_id, created, updated FROM posts WHERE MATCH (post) AGAINST (? IN NATURAL LANGUAGE MODE) AND (MATCH (post) AGAINST (? IN NATURAL LANGUAGE MODE), -id) < (SELECT MATCH (post) AGAINST (? IN NATURAL LANGUAGE MODE), -id FROM posts WHERE id = ?) ORDER BY MATCH (post) AGAINST (? IN NATURAL LANGUAGE MODE) DESC, id LIMIT ?`, searchQuery, searchQuery, searchQuery, startID, searchQuery, pageSize)
+    }
+    if err != nil {
+        d.telemetryProvider.IncDBError("post", operation)
+        return nil, fmt.Errorf("failed to search posts: %w", err)
+    }
+    defer rows.Close()
+
+    var entities []*Post
+    for rows.Next() {
+        var entity Post
+        err := rows.Scan(
+            &entity.ID,
+            &entity.Version,
+            &entity.Deleted,
+            &entity.ExpiresAt,
+            &entity.LanguageId,
+            &entity.Post,
+            &entity.Revoked,
+            &entity.StoryUid,
+            &entity.TargetAge,
+            &entity.UserId,
+            &entity.Created,
+            &entity.Updated,
+            
+        )
+        if err != nil {
+            d.telemetryProvider.IncDBError("post", operation)
+            return nil, fmt.Errorf("failed to scan Post: %w", err)
+        }
+        entities = append(entities, &entity)
+    }
+
+    if err = rows.Err(); err != nil {
+        d.telemetryProvider.IncDBError("post", operation)
+        return nil, fmt.Errorf("rows error: %w", err)
+    }
+
+    d.telemetryProvider.IncDBRequest("post", operation)
+    d.telemetryProvider.ObserveDBLatency("post", operation, time.Since(dbStart).Seconds())
+    return entities, nil
+}
+
+
+
+// SearchPostsForAge returns the Posts matching query in boolean mode, most relevant first. Pass 0 as startID for
+// the first page, then the ID of the last Post of the previous page.
+func (d *postRepository) SearchPostsForAge(ctx context.Context, query string, targetAge int8, startID int64, pageSize int) ([]*Post, error) {
+    if d.configProvider.BlockedReads("post") {
+        return nil, ErrOperationBlocked
+    }
+
+    searchQuery := sanitizeBooleanSearch(normalizeSearchQuery(query))
+    if searchQuery == "" {
+        return nil, nil
+    }
+
+    const operation = "search_posts_for_age"
+    d.telemetryProvider.IncDALOperation("post", operation)
+
+    cacheKey := fmt.Sprintf("%s:%q:%d:%d", fmt.Sprintf("post_search_posts_for_age:epoch_%d:%v", d.getEpoch(), targetAge), searchQuery, startID, pageSize)
+    // Transactions bypass caches to see their own writes.
+    val, found := d.listCache.Get(cacheKey)
+    if found && !inTx(ctx) {
+        entityIDs, ok := val.([]int64)
+        if !ok {
+            return nil, fmt.Errorf("postRepository.SearchPostsForAge: Cache returned wrong type; expected array ID type")
+        }
+
+        var entities []*Post
+        missingEntries := false
+        for _, id := range entityIDs {
+            entity, err := d.getByIDCached(id)
+            if err != nil {
+                return nil, err
+            }
+
+            if entity == nil {
+                // An entity left the cache, reload the whole page.
+                missingEntries = true
+                break
+            }
+
+            entities = append(entities, entity)
+        }
+
+        if !missingEntries {
+            d.telemetryProvider.IncCacheHit("post", operation)
+            return entities, nil
+        }
+    }
+
+    d.telemetryProvider.IncCacheMiss("post", operation)
+
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+        return d.searchPostsForAge(ctx, searchQuery, targetAge, startID, pageSize)
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    entities := result.([]*Post)
+    if inTx(ctx) {
+        return entities, nil
+    }
+
+    // Every query is a new key, so results are kept shorter than lists.
+    var entityIDs []int64
+    for _, entity := range entities {
+        entityIDs = append(entityIDs, entity.ID)
+        d.setCached(entity)
+    }
+    d.listCache.Set(cacheKey, entityIDs, time.Second*5)
+
+    return entities, nil
+}
+
+func (d *postRepository) searchPostsForAge(ctx context.Context, searchQuery string, targetAge int8, startID int64, pageSize int) ([]*Post, error) {
+    const operation = "search_posts_for_age"
+    dbStart := time.Now()
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "post", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("post", operation)
+        return nil, dbErr
+    }
+
+    var rows *sql.Rows
+    var err error
+    if startID == 0 {
+        rows, err = db.QueryContext(ctx, `SELECT id, version, deleted, expires_at, language_id, post, revoked, story_uid, target_age, user_id, created, updated FROM posts WHERE MATCH (post) AGAINST (? IN BOOLEAN MODE) AND (deleted = 0 and target_age = ?) ORDER BY MATCH (post) AGAINST (? IN BOOLEAN MODE) DESC, id LIMIT ?`, searchQuery, targetAge, searchQuery, pageSize)
+    } else {
+        rows, err = db.QueryContext(ctx, `SELECT id, version, deleted, expires_at, language_id, post, revoked, story_uid, target_age, user_id, created, updated FROM posts WHERE MATCH (post) AGAINST (? IN BOOLEAN MODE) AND (deleted = 0 and target_age = ?) AND (MATCH (post) AGAINST (? IN BOOLEAN MODE), -id) < (SELECT MATCH (post) AGAINST (? IN BOOLEAN MODE), -id FROM posts WHERE id = ?) ORDER BY MATCH (post) AGAINST (? IN BOOLEAN MODE) DESC, id LIMIT ?`, searchQuery, targetAge, searchQuery, searchQuery, startID, searchQuery, pageSize)
+    }
+    if err != nil {
+        d.telemetryProvider.IncDBError("post", operation)
+        return nil, fmt.Errorf("failed to search posts: %w", err)
+    }
+    defer rows.Close()
+
+    var entities []*Post
+    for rows.Next() {
+        var entity Post
+        err := rows.Scan(
+            &entity.ID,
+            &entity.Version,
+            &entity.Deleted,
+            &entity.ExpiresAt,
+            &entity.LanguageId,
+            &entity.Post,
+            &entity.Revoked,
+            &entity.StoryUid,
+            &entity.TargetAge,
+            &entity.UserId,
+            &entity.Created,
+            &entity.Updated,
+            
+        )
+        if err != nil {
+            d.telemetryProvider.IncDBError("post", operation)
+            return nil, fmt.Errorf("failed to scan Post: %w", err)
+        }
+        entities = append(entities, &entity)
+    }
+
+    if err = rows.Err(); err != nil {
+        d.telemetryProvider.IncDBError("post", operation)
+        return nil, fmt.Errorf("rows error: %w", err)
+    }
+
+    d.telemetryProvider.IncDBRequest("post", operation)
+    d.telemetryProvider.ObserveDBLatency("post", operation, time.Since(dbStart).Seconds())
+    return entities, nil
+}
+
+
+
+
 
 
 
@@ -1576,7 +2219,7 @@ func (d *postRepository) DeleteExpired(ctx context.Context, cutoff time.Time, li
     const operation = "delete_expired"
     d.telemetryProvider.IncDALOperation("post", operation)
 
-    result, err := d.dbBreaker.Execute(func() (interface{}, error) {
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
         return d.deleteExpired(ctx, cutoff, limit)
     })
 
@@ -1587,7 +2230,7 @@ func (d *postRepository) DeleteExpired(ctx context.Context, cutoff time.Time, li
     rowsAffected := result.(int64)
     
     if rowsAffected > 0 {
-        d.FlushAllCache() 
+        afterCommit(ctx, d.FlushAllCache)
     }
 
     return rowsAffected, nil
@@ -1600,7 +2243,7 @@ func (d *postRepository) deleteExpired(ctx context.Context, cutoff time.Time, li
     // Inject the sanitized limit directly into the SQL string
     query := `DELETE FROM posts WHERE expires_at < ? OR (revoked = true AND updated < ?)` + fmt.Sprintf(" LIMIT %d", limit)
 
-    db, dbErr := d.dbProvider.GetDatabase("post", true)
+    db, dbErr := databaseFor(ctx, d.dbProvider, "post", true)
     if dbErr != nil {
         d.telemetryProvider.IncDBError("post", operation)
         return 0, dbErr
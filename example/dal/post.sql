@@ -17,8 +17,9 @@ CREATE TABLE posts (
 # Unique indexes as they serve Get operations returning single entity
 
 # Indexes that serve all operations
-CREATE INDEX idx_deleted_target_age_created ON posts (deleted, target_age, created);
+CREATE INDEX idx_deleted_target_age_created_id ON posts (deleted, target_age, created, id);
 CREATE INDEX idx_expires_at_revoked_updated ON posts (expires_at, revoked, updated);
+CREATE FULLTEXT INDEX idx_ft_post ON posts (post);
 CREATE INDEX idx_language_id ON posts (language_id);
 CREATE INDEX idx_user_id_story_uid ON posts (user_id, story_uid);
 
@@ -0,0 +1,102 @@
+package dal
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sony/gobreaker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostSearch(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB(t)
+
+	ctx := context.Background()
+	db, err := dbProvider.GetDatabase("", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := os.ReadFile("post.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, string(schema)); err != nil {
+		t.Fatal(err)
+	}
+
+	postDAL := NewPostRepository(dbProvider, nil, nil, gobreaker.Settings{}, PrometheusTelemetryProvider{})
+
+	generics, err := postDAL.Create(ctx, &Post{Post: "golang generics tutorial", TargetAge: 18, ExpiresAt: time.Now()})
+	assert.NoError(t, err)
+	concurrency, err := postDAL.Create(ctx, &Post{Post: "golang concurrency patterns, more golang channels", TargetAge: 18, ExpiresAt: time.Now()})
+	assert.NoError(t, err)
+	_, err = postDAL.Create(ctx, &Post{Post: "rust ownership explained", TargetAge: 18, ExpiresAt: time.Now()})
+	assert.NoError(t, err)
+
+	t.Run("NaturalLanguage", func(t *testing.T) {
+		// Mentioning golang twice makes the concurrency post more relevant
+		posts, err := postDAL.SearchPosts(ctx, "  GoLang ", 0, 10)
+		assert.NoError(t, err)
+		if assert.Len(t, posts, 2) {
+			assert.Equal(t, concurrency.ID, posts[0].ID)
+			assert.Equal(t, generics.ID, posts[1].ID)
+		}
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		first, err := postDAL.SearchPosts(ctx, "golang", 0, 1)
+		assert.NoError(t, err)
+		if !assert.Len(t, first, 1) {
+			return
+		}
+		assert.Equal(t, concurrency.ID, first[0].ID)
+
+		second, err := postDAL.SearchPosts(ctx, "golang", first[0].ID, 1)
+		assert.NoError(t, err)
+		if !assert.Len(t, second, 1) {
+			return
+		}
+		assert.Equal(t, generics.ID, second[0].ID)
+
+		last, err := postDAL.SearchPosts(ctx, "golang", second[0].ID, 1)
+		assert.NoError(t, err)
+		assert.Empty(t, last)
+	})
+
+	t.Run("BooleanMode", func(t *testing.T) {
+		posts, err := postDAL.SearchPostsForAge(ctx, "+golang -concurrency", 18, 0, 10)
+		assert.NoError(t, err)
+		if assert.Len(t, posts, 1) {
+			assert.Equal(t, generics.ID, posts[0].ID)
+		}
+
+		// Stray operators are dropped instead of failing the query
+		posts, err = postDAL.SearchPostsForAge(ctx, `golang* ((>"tutorial`, 18, 0, 10)
+		assert.NoError(t, err)
+		assert.Len(t, posts, 2)
+
+		posts, err = postDAL.SearchPostsForAge(ctx, "golang", 30, 0, 10)
+		assert.NoError(t, err)
+		assert.Empty(t, posts)
+
+		posts, err = postDAL.SearchPostsForAge(ctx, "(( ~ ))", 18, 0, 10)
+		assert.NoError(t, err)
+		assert.Nil(t, posts)
+	})
+
+	t.Run("WritesInvalidateResults", func(t *testing.T) {
+		posts, err := postDAL.SearchPosts(ctx, "rust", 0, 10)
+		assert.NoError(t, err)
+		assert.Len(t, posts, 1)
+
+		_, err = postDAL.Create(ctx, &Post{Post: "rust async runtimes", TargetAge: 18, ExpiresAt: time.Now()})
+		assert.NoError(t, err)
+
+		posts, err = postDAL.SearchPosts(ctx, "rust", 0, 10)
+		assert.NoError(t, err)
+		assert.Len(t, posts, 2)
+	})
+}
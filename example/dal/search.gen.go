@@ -0,0 +1,69 @@
+package dal
+
+import (
+	"strings"
+	"unicode"
+)
+
+// normalizeSearchQuery lowercases a full-text query and collapses its whitespace, so queries
+// differing only in case or spacing share cache entries. FULLTEXT matching ignores case in
+// the default collations.
+func normalizeSearchQuery(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
+
+// sanitizeBooleanSearch keeps the boolean mode operators typed on purpose, a leading + or - on a
+// word or "quoted phrase" and a trailing * on a word, and drops every other operator, so input
+// can't make MATCH ... AGAINST invalid or change its meaning with stray (, ), <, >, ~ or @.
+func sanitizeBooleanSearch(query string) string {
+	// An unclosed quote would turn the rest of the query into a phrase
+	if strings.Count(query, `"`)%2 == 1 {
+		query = strings.ReplaceAll(query, `"`, " ")
+	}
+
+	isWordRune := func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+	}
+
+	var terms []string
+	prefix := ""
+	for i, part := range strings.Split(query, `"`) {
+		if i%2 == 1 {
+			// Inside a phrase only the words count
+			if words := strings.FieldsFunc(part, func(r rune) bool { return !isWordRune(r) }); len(words) > 0 {
+				terms = append(terms, prefix+`"`+strings.Join(words, " ")+`"`)
+			}
+			prefix = ""
+			continue
+		}
+
+		fields := strings.Fields(part)
+		// A + or - right before the quote applies to the phrase
+		if strings.HasSuffix(part, "+") || strings.HasSuffix(part, "-") {
+			prefix = part[len(part)-1:]
+			if len(fields) > 0 {
+				fields[len(fields)-1] = strings.TrimRight(fields[len(fields)-1], "+-")
+			}
+		}
+
+		for _, field := range fields {
+			words := strings.FieldsFunc(field, func(r rune) bool { return !isWordRune(r) })
+			if len(words) == 0 {
+				continue
+			}
+			term := strings.Join(words, " ")
+			if len(words) > 1 {
+				// Words joined by operators, e.g. e-mail, are searched as a phrase
+				term = `"` + term + `"`
+			}
+			if field[0] == '+' || field[0] == '-' {
+				term = field[:1] + term
+			}
+			if len(words) == 1 && strings.HasSuffix(field, "*") {
+				term += "*"
+			}
+			terms = append(terms, term)
+		}
+	}
+	return strings.Join(terms, " ")
+}
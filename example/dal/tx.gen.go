@@ -0,0 +1,198 @@
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/sony/gobreaker"
+)
+
+// ErrTxMultipleDatabases is returned when a transaction touches entities stored in different databases.
+var ErrTxMultipleDatabases = errors.New("transaction spans multiple databases")
+
+type txContextKey struct{}
+
+// dbExecutor is the part of *sql.DB and *sql.Tx used by repository queries.
+type dbExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// txState is the transaction carried by the context passed to the RunInTx callback.
+type txState struct {
+	ctx         context.Context
+	mu          sync.Mutex
+	db          *sql.DB
+	tx          *sql.Tx
+	afterCommit []func()
+	breakers    map[*gobreaker.CircuitBreaker]error
+}
+
+// RunInTx runs fn in a single database transaction. Every repository method called with the ctx
+// passed to fn executes inside the transaction, so writes across repositories commit or roll back together:
+//
+//	err := dal.RunInTx(ctx, func(ctx context.Context) error {
+//		if _, err := posts.Create(ctx, post); err != nil {
+//			return err
+//		}
+//		return users.Update(ctx, user)
+//	})
+//
+// The transaction begins on the write database of the first entity used. All entities used in it must
+// share that database, otherwise ErrTxMultipleDatabases is returned. Reads inside the transaction go to
+// the write database and bypass the caches. Cache updates, invalidations and their Pub/Sub broadcasts are
+// buffered and applied only after a successful commit; they are discarded on rollback.
+// Each repository's circuit breaker is consulted before its first query and records the whole
+// transaction as a single request when it finishes.
+//
+// The transaction is rolled back when fn returns an error or panics. Calling RunInTx with a ctx
+// that already carries a transaction runs fn as part of that transaction.
+func RunInTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if txFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	state := &txState{
+		ctx:      ctx,
+		breakers: make(map[*gobreaker.CircuitBreaker]error),
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			state.rollback()
+			state.reportBreakers(fmt.Errorf("transaction panicked: %v", p))
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txContextKey{}, state)); err != nil {
+		state.rollback()
+		state.reportBreakers(nil)
+		return err
+	}
+
+	if err := state.commit(); err != nil {
+		state.reportBreakers(err)
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	state.reportBreakers(nil)
+
+	for _, apply := range state.afterCommit {
+		apply()
+	}
+	return nil
+}
+
+func txFromContext(ctx context.Context) *txState {
+	state, _ := ctx.Value(txContextKey{}).(*txState)
+	return state
+}
+
+// inTx reports whether ctx carries a transaction started by RunInTx.
+func inTx(ctx context.Context) bool {
+	return txFromContext(ctx) != nil
+}
+
+// databaseFor returns the transaction carried by ctx, or the database the provider picks for the entity.
+func databaseFor(ctx context.Context, provider DBProvider, entityName string, isWriteOperation bool) (dbExecutor, error) {
+	state := txFromContext(ctx)
+	if state == nil {
+		db, err := provider.GetDatabase(entityName, isWriteOperation)
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	}
+
+	// Reads must see the transaction's own writes, so everything goes to the write database.
+	db, err := provider.GetDatabase(entityName, true)
+	if err != nil {
+		return nil, err
+	}
+	return state.begin(db)
+}
+
+// afterCommit runs apply once the transaction carried by ctx commits, or right away outside of transactions.
+func afterCommit(ctx context.Context, apply func()) {
+	state := txFromContext(ctx)
+	if state == nil {
+		apply()
+		return
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.afterCommit = append(state.afterCommit, apply)
+}
+
+// executeWithBreaker runs fn through the circuit breaker. Inside a transaction fn runs directly as long as
+// the breaker isn't open, and its outcome is reported to the breaker when the transaction finishes.
+func executeWithBreaker(ctx context.Context, breaker *gobreaker.CircuitBreaker, fn func() (interface{}, error)) (interface{}, error) {
+	state := txFromContext(ctx)
+	if state == nil {
+		return breaker.Execute(fn)
+	}
+
+	if breaker.State() == gobreaker.StateOpen {
+		return nil, gobreaker.ErrOpenState
+	}
+
+	result, err := fn()
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if err != nil || state.breakers[breaker] == nil {
+		state.breakers[breaker] = err
+	}
+	return result, err
+}
+
+func (s *txState) begin(db *sql.DB) (*sql.Tx, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tx != nil {
+		if s.db != db {
+			return nil, ErrTxMultipleDatabases
+		}
+		return s.tx, nil
+	}
+
+	tx, err := db.BeginTx(s.ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	s.db = db
+	s.tx = tx
+	return tx, nil
+}
+
+func (s *txState) commit() error {
+	if s.tx == nil {
+		return nil
+	}
+	return s.tx.Commit()
+}
+
+func (s *txState) rollback() {
+	if s.tx != nil {
+		_ = s.tx.Rollback()
+	}
+}
+
+// reportBreakers records the transaction as a single request on every breaker used in it.
+// The last query error seen by a breaker wins over finishErr.
+func (s *txState) reportBreakers(finishErr error) {
+	for breaker, err := range s.breakers {
+		if err == nil {
+			err = finishErr
+		}
+		_, _ = breaker.Execute(func() (interface{}, error) {
+			return nil, err
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package dal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sony/gobreaker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunInTx(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB(t)
+
+	ctx := context.Background()
+	db, err := dbProvider.GetDatabase("", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := os.ReadFile("post.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, string(schema)); err != nil {
+		t.Fatal(err)
+	}
+
+	userDAL := NewUserRepository(dbProvider, nil, nil, gobreaker.Settings{}, nil)
+	postDAL := NewPostRepository(dbProvider, nil, nil, gobreaker.Settings{}, nil)
+
+	t.Run("Commit", func(t *testing.T) {
+		var user *User
+		var post *Post
+		err := RunInTx(ctx, func(ctx context.Context) error {
+			var err error
+			user, err = userDAL.Create(ctx, &User{Email: "tx-commit@example.com", Age: 30})
+			if err != nil {
+				return err
+			}
+			post, err = postDAL.Create(ctx, &Post{UserId: user.Uid, Post: "hello", ExpiresAt: time.Now()})
+			return err
+		})
+		assert.NoError(t, err)
+
+		fetchedUser, err := userDAL.GetByID(ctx, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "tx-commit@example.com", fetchedUser.Email)
+
+		fetchedPost, err := postDAL.GetByID(ctx, post.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, user.Uid, fetchedPost.UserId)
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		errAbort := errors.New("abort")
+		var user *User
+		var post *Post
+		err := RunInTx(ctx, func(ctx context.Context) error {
+			var err error
+			user, err = userDAL.Create(ctx, &User{Email: "tx-rollback@example.com", Age: 30})
+			if err != nil {
+				return err
+			}
+			post, err = postDAL.Create(ctx, &Post{UserId: user.Uid, Post: "gone", ExpiresAt: time.Now()})
+			if err != nil {
+				return err
+			}
+
+			// Reads inside the transaction see its writes.
+			fetched, err := userDAL.GetByEmail(ctx, "tx-rollback@example.com")
+			if err != nil {
+				return err
+			}
+			assert.Equal(t, user.ID, fetched.ID)
+			return errAbort
+		})
+		assert.ErrorIs(t, err, errAbort)
+
+		// Neither the rows nor their cache entries survive the rollback.
+		_, err = userDAL.GetByID(ctx, user.ID)
+		assert.ErrorIs(t, err, ErrNotFound)
+		_, err = postDAL.GetByID(ctx, post.ID)
+		assert.ErrorIs(t, err, ErrNotFound)
+		_, err = userDAL.GetByEmail(ctx, "tx-rollback@example.com")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
@@ -0,0 +1,55 @@
+package dal
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// UpsertOutcome tells what a bulk upsert did with an entity.
+type UpsertOutcome int
+
+const (
+	// UpsertUnchanged means the stored row already held the given values and wasn't written.
+	UpsertUnchanged UpsertOutcome = iota
+	// UpsertInserted means a new row was inserted.
+	UpsertInserted
+	// UpsertUpdated means the columns of an existing row were overwritten.
+	UpsertUpdated
+)
+
+func (o UpsertOutcome) String() string {
+	switch o {
+	case UpsertInserted:
+		return "inserted"
+	case UpsertUpdated:
+		return "updated"
+	default:
+		return "unchanged"
+	}
+}
+
+// sameValue reports whether a stored column value equals the given one. Pointers are followed
+// and times are compared by instant, as drivers return them in a different location.
+func sameValue(stored, given interface{}) bool {
+	a, b := reflect.ValueOf(stored), reflect.ValueOf(given)
+	for a.Kind() == reflect.Pointer && b.Kind() == reflect.Pointer {
+		if a.IsNil() || b.IsNil() {
+			return a.IsNil() == b.IsNil()
+		}
+		a, b = a.Elem(), b.Elem()
+	}
+
+	if at, ok := a.Interface().(time.Time); ok {
+		bt, ok := b.Interface().(time.Time)
+		return ok && at.Equal(bt)
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+// collationKey folds a string the way MySQL's default case insensitive collations compare it, so bulk
+// upserts match entities to the rows the database matched. Only case is folded: accents and, with the older
+// PAD SPACE collations, trailing spaces are ignored by the database too, those rows are looked up again.
+func collationKey(s string) string {
+	return strings.ToLower(s)
+}
@@ -1,7 +1,7 @@
 /*
 NOTE! This code is autogenerated.
 Don't manually change this code.
-Version [4cf9ef9c5134f59be8d5a811f3608377bf4b3a6c058f413b9056eab9519fedd9]
+Version [c26a0e3ea1c5a3dde3d010ba02f2941f39d4d59b89e0ebb6f6c40036a0ca564c]
 */
 package dal
 
@@ -11,6 +11,7 @@ import (
     "database/sql"
     _ "embed"
     "errors"
+    "iter"
     "strings"
     "sync/atomic"
 
@@ -19,6 +20,7 @@ import (
     
 	"encoding/json"
 	
+    "maps"
 
     log "github.com/sirupsen/logrus"
     "github.com/patrickmn/go-cache"
@@ -39,7 +41,7 @@ type User struct {
     Birthdate *time.Time `json:"birthdate"`
     Email string `json:"email"`
     Meta *json.RawMessage `json:"meta"`
-    Status *string `json:"status"`
+    Status *UserStatus `json:"status"`
     Uid string `json:"uid"`
 
     Created   time.Time `json:"created"`
@@ -49,6 +51,95 @@ type User struct {
 
 }
 
+// UserStatus is a value of the status column of users. The database, Validate and JSON decoding
+// reject values other than its constants.
+type UserStatus string
+
+const (
+    UserStatusActive UserStatus = "active"
+    UserStatusBanned UserStatus = "banned"
+    UserStatusPending UserStatus = "pending"
+    UserStatusSuspended UserStatus = "suspended"
+    UserStatusUnknown UserStatus = "unknown"
+)
+
+// UserStatusValues returns the valid UserStatus values in the order of the column definition.
+func UserStatusValues() []UserStatus {
+    return []UserStatus{UserStatusActive, UserStatusBanned, UserStatusPending, UserStatusSuspended, UserStatusUnknown}
+}
+
+// IsValid reports whether the value is one of the UserStatus constants.
+func (e UserStatus) IsValid() bool {
+    switch e {
+    case UserStatusActive, UserStatusBanned, UserStatusPending, UserStatusSuspended, UserStatusUnknown:
+        return true
+    }
+    return false
+}
+
+// MarshalJSON encodes the value as a JSON string, failing for invalid values.
+func (e UserStatus) MarshalJSON() ([]byte, error) {
+    if !e.IsValid() {
+        return nil, fmt.Errorf("invalid UserStatus %q", string(e))
+    }
+    return json.Marshal(string(e))
+}
+
+// UnmarshalJSON decodes a JSON string, failing for values other than the UserStatus constants.
+func (e *UserStatus) UnmarshalJSON(data []byte) error {
+    var value string
+    if err := json.Unmarshal(data, &value); err != nil {
+        return err
+    }
+    if !UserStatus(value).IsValid() {
+        return fmt.Errorf("invalid UserStatus %q, expected one of %v", value, UserStatusValues())
+    }
+    *e = UserStatus(value)
+    return nil
+}
+
+// Validate checks the User against the validation rules of its columns. It returns a *ValidationError
+// listing every field breaking one, or nil. Create, CreateBulk, Update and upserts call it once the before hooks ran.
+func (u *User) Validate() error {
+    v := validator{entity: "user"}
+    validateUserAge(&v, u.Age)
+    validateUserEmail(&v, u.Email)
+    validateUserStatus(&v, u.Status)
+    return v.err()
+}
+
+// validateUsers validates the entities of CreateBulk, naming the first invalid one by its index.
+func validateUsers(entities []*User) error {
+    for i, entity := range entities {
+        if err := entity.Validate(); err != nil {
+            return fmt.Errorf("entity %d: %w", i, err)
+        }
+    }
+    return nil
+}
+
+// validateUserAge checks age values against the rules of their column.
+func validateUserAge(v *validator, age int8) {
+    checkMin(v, "age", age, 0)
+    checkMax(v, "age", age, 120)
+}
+
+// validateUserEmail checks email values against the rules of their column.
+func validateUserEmail(v *validator, email string) {
+    v.maxLength("email", email, 255)
+    v.email("email", email)
+}
+
+// validateUserStatus checks status values against the rules of their column.
+func validateUserStatus(v *validator, status *UserStatus) {
+    if status == nil {
+        return
+    }
+    if !status.IsValid() {
+        v.fail("status", "values", "must be one of %v", UserStatusValues())
+    }
+}
+
 // UserRepository defines the interface for the User.
 // Use this interface in your services to easily mock the database.
 type UserRepository interface {
@@ -72,6 +163,16 @@ type UserRepository interface {
     CreateBulk(ctx context.Context, entities []*User) ([]*User, error)
     
     Update(ctx context.Context, entity *User) error
+    // UpsertByEmail inserts the entity, or overwrites status, age of the row with the same email.
+    UpsertByEmail(ctx context.Context, entity *User) (*User, error)
+    // UpsertBulkByEmail upserts the entities in chunks and reports whether each was inserted, updated or unchanged.
+    UpsertBulkByEmail(ctx context.Context, entities []*User) ([]UpsertOutcome, error)
+    // PatchStatus updates only status of the entity with the given id and version.
+    PatchStatus(ctx context.Context, id int64, version int32, status *UserStatus) error
+    // PatchEmail updates only email of the entity with the given id and version.
+    PatchEmail(ctx context.Context, id int64, version int32, email string) error
+    // IncrementAge atomically adds delta to age and returns the new value.
+    IncrementAge(ctx context.Context, id int64, delta int64) (int64, error)
     Delete(ctx context.Context, entity *User) error
     HardDelete(ctx context.Context, entity *User) error
     GetByEmail(ctx context.Context, email string) (*User, error)
@@ -108,7 +209,7 @@ type UserRepository interface {
     // to peer nodes via Pub/Sub. Use this operation carefully in high-read environments.
     //
     // This operation is hard-limited to 5000 items and executes in database batches of 500.
-    UpdateStatusByUids(ctx context.Context, status *string, uids []string) error
+    UpdateStatusByUids(ctx context.Context, status *UserStatus, uids []string) error
     // UpdateAgeByIds executes a bulk partial update using an IN clause.
     //
     // ⚠️ CAUTION - CACHE FLUSH NOTE ⚠️
@@ -120,14 +221,53 @@ type UserRepository interface {
     //
     // This operation is hard-limited to 5000 items and executes in database batches of 500.
     UpdateAgeByIds(ctx context.Context, age int8, ids []int64) error
+    // SumAgeByStatus returns the sum of age, 0 when no rows match.
+    // CACHING NOTE: Uses the countCache for invalidation.
+    SumAgeByStatus(ctx context.Context, status *UserStatus) (float64, error)
+    // ExistsByEmail reports whether a row where email = :email exists, without loading it.
+    ExistsByEmail(ctx context.Context, email string) (bool, error)
+    // CountByStatusGrouped counts the entities per status value.
+    // CACHING NOTE: Uses the countCache for invalidation.
+    CountByStatusGrouped(ctx context.Context, minage int8) (map[UserStatus]int64, error)
     ListById(ctx context.Context, startID int64, pageSize int) ([]*User, error)
+    // ListByIdPage returns a page with the opaque token of the next one; pass "" for the first page.
+    ListByIdPage(ctx context.Context, pageToken string, pageSize int) (Page[User], error)
     CountListById(ctx context.Context, ) (int64, error)
-    ListByBday(ctx context.Context, birthdate *time.Time, startID int64, pageSize int) ([]*User, error)
+    // IterateByListById walks the whole list in batches on the read database, bypassing the caches.
+    IterateByListById(ctx context.Context, opts IterateOptions) iter.Seq2[*User, error]
+    // ListByBday returns a page of entities ordered by birthdate and the cursor of the next page, nil after the last one.
+    ListByBday(ctx context.Context, birthdate *time.Time, cursor *UserListByBdayCursor, pageSize int) ([]*User, *UserListByBdayCursor, error)
     CountListByBday(ctx context.Context, birthdate *time.Time) (int64, error)
-    ListByAge(ctx context.Context, minage int8, startID int64, pageSize int) ([]*User, error)
+    // IterateByListByBday walks the whole list in batches on the read database, bypassing the caches.
+    IterateByListByBday(ctx context.Context, birthdate *time.Time, opts IterateOptions) iter.Seq2[*User, error]
+    // ListByAge returns a page of entities ordered by created and the cursor of the next page, nil after the last one.
+    ListByAge(ctx context.Context, minage int8, cursor *UserListByAgeCursor, pageSize int) ([]*User, *UserListByAgeCursor, error)
+    // ListByAgePage returns a page with the opaque token of the next one; pass "" for the first page.
+    ListByAgePage(ctx context.Context, minage int8, pageToken string, pageSize int) (Page[User], error)
     CountListByAge(ctx context.Context, minage int8) (int64, error)
-    ListByStatus(ctx context.Context, status *string, startID int64, pageSize int) ([]*User, error)
-    CountListByStatus(ctx context.Context, status *string) (int64, error)
+    // IterateByListByAge walks the whole list in batches on the read database, bypassing the caches.
+    IterateByListByAge(ctx context.Context, minage int8, opts IterateOptions) iter.Seq2[*User, error]
+    // ListByStatus returns a page of entities ordered by created and the cursor of the next page, nil after the last one.
+    ListByStatus(ctx context.Context, status *UserStatus, cursor *UserListByStatusCursor, pageSize int) ([]*User, *UserListByStatusCursor, error)
+    CountListByStatus(ctx context.Context, status *UserStatus) (int64, error)
+    // IterateByListByStatus walks the whole list in batches on the read database, bypassing the caches.
+    IterateByListByStatus(ctx context.Context, status *UserStatus, opts IterateOptions) iter.Seq2[*User, error]
+    // ListByStatuses returns a page of entities ordered by created and the cursor of the next page, nil after the last one.
+    ListByStatuses(ctx context.Context, statuses []UserStatus, cursor *UserListByStatusesCursor, pageSize int) ([]*User, *UserListByStatusesCursor, error)
+    CountListByStatuses(ctx context.Context, statuses []UserStatus) (int64, error)
+    // IterateByListByStatuses walks the whole list in batches on the read database, bypassing the caches.
+    IterateByListByStatuses(ctx context.Context, statuses []UserStatus, opts IterateOptions) iter.Seq2[*User, error]
+    // Search returns a page of entities ordered by created and the cursor of the next page, nil after the last one.
+    Search(ctx context.Context, filter UserSearchFilter, cursor *UserSearchCursor, pageSize int) ([]*User, *UserSearchCursor, error)
+    // SearchPage returns a page with the opaque token of the next one; pass "" for the first page.
+    SearchPage(ctx context.Context, filter UserSearchFilter, pageToken string, pageSize int) (Page[User], error)
+    CountSearch(ctx context.Context, filter UserSearchFilter) (int64, error)
+    // IterateBySearch walks the whole list in batches on the read database, bypassing the caches.
+    IterateBySearch(ctx context.Context, filter UserSearchFilter, opts IterateOptions) iter.Seq2[*User, error]
+    // IterateAll walks every entity in ID order in batches on the read database, bypassing the caches.
+    IterateAll(ctx context.Context, opts IterateOptions) iter.Seq2[*User, error]
+    // History returns the recorded changes of the entity with the given id, oldest first.
+    History(ctx context.Context, id int64, startID int64, pageSize int) ([]*HistoryEntry, error)
     // DeleteOlder executes a custom bulk delete operation.
     // 
     // ⚠️ CAUTION - CACHE FLUSH NOTE ⚠️
@@ -168,15 +308,118 @@ type userRepository struct {
 	dbBreaker           *gobreaker.CircuitBreaker
     telemetryProvider TelemetryProvider
     listEpoch         atomic.Int64
+    hooks             userHooks
+}
+
+
+// UserBeforeCreate is a hook run by Create and upserts before inserting the User. Returning an error vetoes the insert.
+type UserBeforeCreate interface {
+    BeforeCreate(ctx context.Context, entity *User) error
+}
+
+// UserAfterCreate is a hook run by Create and upserts after inserting the User. Returning an error rolls the insert back.
+type UserAfterCreate interface {
+    AfterCreate(ctx context.Context, entity *User) error
+}
+
+// UserBeforeCreateBulk is a hook run by CreateBulk and bulk upserts before inserting the Users. Returning an error vetoes the inserts.
+type UserBeforeCreateBulk interface {
+    BeforeCreateBulk(ctx context.Context, entities []*User) error
+}
+
+// UserAfterCreateBulk is a hook run by CreateBulk and bulk upserts after inserting the Users. Returning an error rolls the inserts back.
+type UserAfterCreateBulk interface {
+    AfterCreateBulk(ctx context.Context, entities []*User) error
+}
+
+// UserBeforeUpdate is a hook run by Update, upserts and patches before storing the User. Returning an error vetoes the update.
+type UserBeforeUpdate interface {
+    BeforeUpdate(ctx context.Context, entity *User) error
+}
+
+// UserAfterUpdate is a hook run by Update and upserts after storing the User. Returning an error rolls the update back.
+type UserAfterUpdate interface {
+    AfterUpdate(ctx context.Context, entity *User) error
+}
+
+// UserBeforeDelete is a hook run by Delete and HardDelete before removing the User. Returning an error vetoes the delete.
+type UserBeforeDelete interface {
+    BeforeDelete(ctx context.Context, entity *User) error
+}
+
+// UserAfterDelete is a hook run by Delete and HardDelete after removing the User. Returning an error rolls the delete back.
+type UserAfterDelete interface {
+    AfterDelete(ctx context.Context, entity *User) error
+}
+
+// userHooks holds the hooks of a userRepository per operation: the methods of User
+// implementing the entity hook interfaces first, then the hooks passed to NewUserRepository in order.
+type userHooks struct {
+    beforeCreate     []hookFunc[*User]
+    afterCreate      []hookFunc[*User]
+    beforeCreateBulk []hookFunc[[]*User]
+    afterCreateBulk  []hookFunc[[]*User]
+    beforeUpdate     []hookFunc[*User]
+    afterUpdate      []hookFunc[*User]
+    beforeDelete     []hookFunc[*User]
+    afterDelete      []hookFunc[*User]
+}
+
+// newUserHooks sorts hooks by the User hook interfaces they implement. It panics on a hook
+// implementing none of them, which is a programming error.
+func newUserHooks(hooks []interface{}) userHooks {
+    var h userHooks
+    h.beforeCreate = appendEntityHook(h.beforeCreate, BeforeCreateHook.BeforeCreate)
+    h.afterCreate = appendEntityHook(h.afterCreate, AfterCreateHook.AfterCreate)
+    h.beforeCreateBulk = appendEntityBulkHook(h.beforeCreateBulk, BeforeCreateHook.BeforeCreate)
+    h.afterCreateBulk = appendEntityBulkHook(h.afterCreateBulk, AfterCreateHook.AfterCreate)
+    h.beforeUpdate = appendEntityHook(h.beforeUpdate, BeforeUpdateHook.BeforeUpdate)
+    h.afterUpdate = appendEntityHook(h.afterUpdate, AfterUpdateHook.AfterUpdate)
+    h.beforeDelete = appendEntityHook(h.beforeDelete, BeforeDeleteHook.BeforeDelete)
+    h.afterDelete = appendEntityHook(h.afterDelete, AfterDeleteHook.AfterDelete)
+
+    for _, hook := range hooks {
+        registered := false
+        if hook, ok := hook.(UserBeforeCreate); ok {
+            h.beforeCreate, registered = append(h.beforeCreate, hook.BeforeCreate), true
+        }
+        if hook, ok := hook.(UserAfterCreate); ok {
+            h.afterCreate, registered = append(h.afterCreate, hook.AfterCreate), true
+        }
+        if hook, ok := hook.(UserBeforeCreateBulk); ok {
+            h.beforeCreateBulk, registered = append(h.beforeCreateBulk, hook.BeforeCreateBulk), true
+        }
+        if hook, ok := hook.(UserAfterCreateBulk); ok {
+            h.afterCreateBulk, registered = append(h.afterCreateBulk, hook.AfterCreateBulk), true
+        }
+        if hook, ok := hook.(UserBeforeUpdate); ok {
+            h.beforeUpdate, registered = append(h.beforeUpdate, hook.BeforeUpdate), true
+        }
+        if hook, ok := hook.(UserAfterUpdate); ok {
+            h.afterUpdate, registered = append(h.afterUpdate, hook.AfterUpdate), true
+        }
+        if hook, ok := hook.(UserBeforeDelete); ok {
+            h.beforeDelete, registered = append(h.beforeDelete, hook.BeforeDelete), true
+        }
+        if hook, ok := hook.(UserAfterDelete); ok {
+            h.afterDelete, registered = append(h.afterDelete, hook.AfterDelete), true
+        }
+        if !registered {
+            panic(fmt.Sprintf("NewUserRepository: hook %T implements none of the User hook interfaces", hook))
+        }
+    }
+    return h
 }
 
 // NewUserRepository now returns the UserRepository interface.
+// Each of hooks implements one or more of the UserBefore* and UserAfter* hook interfaces.
 func NewUserRepository(
     provider DBProvider, 
     cacheProvider CacheProvider,
     configProvider ConfigProvider, 
     dbSettings gobreaker.Settings, 
-    telemetry TelemetryProvider) UserRepository {
+    telemetry TelemetryProvider,
+    hooks ...interface{}) UserRepository {
 
     if configProvider == nil {
         configProvider = DefaultConfigProvider{}
@@ -210,6 +453,12 @@ func NewUserRepository(
 		if errors.Is(err, ErrNotFound) {
 			return true
 		}
+		// Neither does a hook vetoing the operation or an invalid entity
+		var hookErr *HookError
+		var validationErr *ValidationError
+		if errors.As(err, &hookErr) || errors.As(err, &validationErr) {
+			return true
+		}
 		return false
 	}
 
@@ -234,6 +483,7 @@ func NewUserRepository(
         configProvider: configProvider,
 		dbBreaker:      gobreaker.NewCircuitBreaker(dbSettings),
         telemetryProvider: telemetry,
+        hooks:          newUserHooks(hooks),
     }
 
     // Initialize the epoch and register the Pub/Sub handler
@@ -328,8 +578,8 @@ func (d *userRepository) CreateTable(ctx context.Context) error {
 		var cleanLines []string
 		for _, line := range strings.Split(schemaUser, "\n") {
 			trimmedLine := strings.TrimSpace(line)
-			// Skip empty lines and lines that start with a comment hash
-			if trimmedLine == "" || strings.HasPrefix(trimmedLine, "#") {
+			// Skip empty lines and comment lines ('#' for MySQL, '--' for PostgreSQL and SQLite)
+			if trimmedLine == "" || strings.HasPrefix(trimmedLine, "#") || strings.HasPrefix(trimmedLine, "--") {
 				continue
 			}
 			cleanLines = append(cleanLines, line)
@@ -391,23 +641,35 @@ func (d *userRepository) Create(ctx context.Context, entity *User) (*User, error
 
 	d.telemetryProvider.IncDALOperation("user", "create")
 
-	_, err := d.dbBreaker.Execute(func() (interface{}, error) {
-		return d.create(ctx, entity)
+	created, updated := entity.Created, entity.Updated
+	_, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return nil, withHooks(ctx, "Create", entity, d.hooks.beforeCreate, d.hooks.afterCreate, (*User).Validate, func(ctx context.Context) error {
+			_, err := d.create(ctx, entity)
+			return err
+		})
 	})
 
 	if err != nil {
+		entity.ID, entity.Created, entity.Updated = 0, created, updated // An after hook may have rolled the insert back
 		return nil, err
 	}
 
-	d.setCached(entity)
+	// Inside a transaction caches are only touched once it commits.
+	cached := *entity
+	afterCommit(ctx, func() {
+		d.setCached(&cached)
 
-	// All lists cache should be flushed.
-	d.FlushListCache()
+		// All lists cache should be flushed.
+		d.FlushListCache()
+	})
 
 	return entity, err
 }
 
 func (d *userRepository) create(ctx context.Context, entity *User) (*User, error) {
+	if !inTx(ctx) {
+		return withinTx(ctx, func(ctx context.Context) (*User, error) { return d.create(ctx, entity) })
+	}
 	const operation = "create"
 	start := time.Now()
 
@@ -426,7 +688,7 @@ created,updated)
 		VALUES (?,?,?,?,?,?,?,?)
 	`
 
-    db, dbErr := d.dbProvider.GetDatabase("user", true);
+    db, dbErr := databaseFor(ctx, d.dbProvider, "user", true)
     if dbErr != nil {
 		d.telemetryProvider.IncDBError("user", operation)		
         return nil, dbErr
@@ -447,6 +709,11 @@ created,updated)
 	}
 
 	entity.ID = id	
+    if auditErr := d.recordChanges(ctx, db, operation, nil, "id = ?", entity.ID); auditErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return nil, auditErr
+    }
+
 	d.telemetryProvider.IncDBRequest("user", operation)	
 	d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(start).Seconds())	
 	return entity, nil
@@ -459,24 +726,49 @@ func (d *userRepository) CreateBulk(ctx context.Context, entities []*User) ([]*U
 		return nil, ErrOperationBlocked
 	}
 
+
 	const operation = "create_bulk"
 	d.telemetryProvider.IncDALOperation("user", operation)
 
-	result, err := d.dbBreaker.Execute(func() (interface{}, error) {
-		return d.createBulk(ctx, entities)
+	var created []*User
+	_, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return nil, withHooks(ctx, "CreateBulk", entities, d.hooks.beforeCreateBulk, d.hooks.afterCreateBulk, validateUsers, func(ctx context.Context) error {
+			var err error
+			created, err = d.createBulk(ctx, entities)
+			return err
+		})
 	})
 
 	if err != nil {
+		// All chunks share a transaction with their history rows, which the error rolled back.
+		// A retry must not find the IDs of the vanished rows.
+		for _, entity := range entities {
+			entity.ID = 0
+		}
 		return nil, err
 	}
 
-	// All lists cache should be flushed.
-	d.FlushListCache()
+	// Inside a transaction caches are only touched once it commits.
+	cached := make([]User, len(created))
+	for i, entity := range created {
+		cached[i] = *entity
+	}
+	afterCommit(ctx, func() {
+		for i := range cached {
+			d.setCached(&cached[i])
+		}
+
+		// All lists cache should be flushed.
+		d.FlushListCache()
+	})
 
-	return result.([]*User), err
+	return created, err
 }
 
 func (d *userRepository) createBulk(ctx context.Context, entities []*User) ([]*User, error) {
+    if !inTx(ctx) {
+        return withinTx(ctx, func(ctx context.Context) ([]*User, error) { return d.createBulk(ctx, entities) })
+    }
     const operation = "create_bulk"
     start := time.Now()
 
@@ -485,7 +777,7 @@ func (d *userRepository) createBulk(ctx context.Context, entities []*User) ([]*U
     }
 
     // Get the database connection once, outside the loop
-    db, dbErr := d.dbProvider.GetDatabase("user", true)
+    db, dbErr := databaseFor(ctx, d.dbProvider, "user", true)
     if dbErr != nil {
         d.telemetryProvider.IncDBError("user", operation)
         return nil, dbErr
@@ -547,7 +839,7 @@ created,updated)
             return nil, fmt.Errorf("failed to bulk insert users chunk: %w", err)
         }
 
-        // Get generated IDs (works for MySQL/SQLite)
+        // Get generated IDs. MySQL reports the first ID of a multi row INSERT, SQLite the last one.
         lastID, err := result.LastInsertId()
         if err != nil {
             return nil, fmt.Errorf("failed to get last insert ID for chunk: %w", err)
@@ -561,12 +853,21 @@ created,updated)
         if rowsAffected != int64(len(chunk)) {
             return nil, fmt.Errorf("mismatch between inserted rows and entity count in chunk")
         }
+        firstID := lastID
 
         // Assign generated IDs sequentially to the current chunk
         for j := range chunk {
-            chunk[j].ID = lastID + int64(j)
-			d.setCached(chunk[j])
+            chunk[j].ID = firstID + int64(j)
         }
+
+        ids := make([]int64, len(chunk))
+        for j, entity := range chunk {
+            ids[j] = entity.ID
+        }
+    if auditErr := d.recordChanges(ctx, db, operation, nil, "id IN (?...)", inValues(ids)); auditErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return nil, auditErr
+    }
     }
 
     d.telemetryProvider.IncDBRequest("user", operation) 
@@ -590,6 +891,24 @@ func (d *userRepository) Update(ctx context.Context, entity *User) error {
 		return fmt.Errorf("failed to get existing User, id: %v, err: %w", entity.ID, err)
 	}
 
+	// Perform the update in DB.
+	version := entity.Version
+	_, err2 := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return nil, withHooks(ctx, "Update", entity, d.hooks.beforeUpdate, d.hooks.afterUpdate, (*User).Validate, func(ctx context.Context) error {
+			if err := d.update(ctx, entity); err != nil {
+				return err
+			}
+			entity.Version++
+			return nil
+		})
+	})
+
+	if err2 != nil {
+		entity.Version = version // An after hook may have rolled the update back
+		return err2
+	}
+
+	// Compared once the before hooks ran, as they may change the unique values.
 	
 	var oldEmail string
 	if existing.Email != entity.Email {
@@ -602,16 +921,10 @@ func (d *userRepository) Update(ctx context.Context, entity *User) error {
 	}
 		
 
-	// Perform the update in DB.
-	_, err2 := d.dbBreaker.Execute(func() (interface{}, error) {
-		return nil, d.update(ctx, entity)
-	})
-
-	if err2 != nil {
-		return err2
-	}
-
-	
+	// Inside a transaction caches are only touched once it commits.
+	cached := *entity
+	afterCommit(ctx, func() {
+		
 	if oldEmail != "" {
 		oldCacheKey := fmt.Sprintf("user_email:%s", oldEmail)
 		d.cache.Delete(oldCacheKey)
@@ -623,18 +936,21 @@ func (d *userRepository) Update(ctx context.Context, entity *User) error {
 		d.cacheProvider.InvalidateCache("user", oldCacheKey)
 	}
 
-	// Lets clear item from cache on other instances and here
-	d.InvalidateCache(entity)
-	d.FlushListCache()
+		// Lets clear item from cache on other instances and here
+		d.InvalidateCache(&cached)
+		d.FlushListCache()
 
-	entity.Version++
-	// our entity can become part of local cache
-	d.setCached(entity)
+		// our entity can become part of local cache
+		d.setCached(&cached)
+	})
 
 	return nil
 }
 
 func (d *userRepository) update(ctx context.Context, entity *User) error {
+	if !inTx(ctx) {
+		return RunInTx(ctx, func(ctx context.Context) error { return d.update(ctx, entity) })
+	}
 	const operation = "update"
 	start := time.Now()
 
@@ -646,11 +962,19 @@ func (d *userRepository) update(ctx context.Context, entity *User) error {
 		WHERE id = ? AND version = ? AND deleted_at IS NULL
 	`
 
-    db, dbErr := d.dbProvider.GetDatabase("user", true);
+    db, dbErr := databaseFor(ctx, d.dbProvider, "user", true)
     if dbErr != nil {
 		d.telemetryProvider.IncDBError("user", operation)
         return dbErr
     }
+	
+    // Snapshot the rows before the write to record its changes
+    before, auditErr := d.auditSnapshot(ctx, db, "id = ?", entity.ID)
+    if auditErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return auditErr
+    }
+
 
 	res, err := db.ExecContext(ctx, query,entity.Age, entity.Birthdate, entity.Email, entity.Meta, entity.Status, entity.Uid,
 	entity.Updated, entity.ID, entity.Version)
@@ -668,9 +992,16 @@ func (d *userRepository) update(ctx context.Context, entity *User) error {
 
 	if rowsAffected == 0 {
 		// clean out cache for specific ID to limit stale cache entries
-		d.InvalidateCache(entity)
+		stale := *entity
+		afterCommit(ctx, func() { d.InvalidateCache(&stale) })
 		return ErrNotFound
 	}
+	
+    if auditErr := d.recordChanges(ctx, db, operation, before, "id = ?", entity.ID); auditErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return auditErr
+    }
+
 
 	d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(start).Seconds())	
 
@@ -679,333 +1010,1268 @@ func (d *userRepository) update(ctx context.Context, entity *User) error {
 
 
 
-func (d *userRepository) Delete(ctx context.Context, entity *User) error {
+// UpsertByEmail inserts the entity, or overwrites status, age of the User
+// with the same email, bumping its version. The returned entity holds the stored row.
+// Unlike Update it doesn't check the version, the last upsert wins. The Create or Update hooks run depending
+// on whether a User with the email was found.
+func (d *userRepository) UpsertByEmail(ctx context.Context, entity *User) (*User, error) {
     if d.configProvider.BlockedWrites("user") {
-        return ErrOperationBlocked
+        return nil, ErrOperationBlocked
     }
 
-	if entity.ID == 0 {
-		// nothing can be deleted as entity is not created.
-		return ErrNotFound
+	const operation = "upsert_by_email"
+	d.telemetryProvider.IncDALOperation("user", operation)
+
+	// 1. Get existing entity to check for changes. Required for proper cache invalidation.
+	existing, err := d.GetByEmail(ctx, entity.Email)
+	if errors.Is(err, ErrNotFound) {
+		// Nothing is cached for a row that doesn't exist yet.
+		existing = entity
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get existing User, email: %v, err: %w", entity.Email, err)
 	}
 
-	const operation = "delete"
-	d.telemetryProvider.IncDALOperation("user", operation)
+	// The stored row decides between the Create and Update hooks.
+	hookName, beforeHooks, afterHooks := "Create", d.hooks.beforeCreate, d.hooks.afterCreate
+	if existing != entity {
+		hookName, beforeHooks, afterHooks = "Update", d.hooks.beforeUpdate, d.hooks.afterUpdate
+	}
 
-	_, err := d.dbBreaker.Execute(func() (interface{}, error) {
-		return nil, d.delete(ctx, entity.ID)
+	_, err = executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return nil, withHooks(ctx, hookName, entity, beforeHooks, afterHooks, (*User).Validate, func(ctx context.Context) error {
+			_, err := d.upsertByEmail(ctx, entity)
+			return err
+		})
 	})
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Lets clear item from cache and on next use use DB as source of truth
-	d.InvalidateCache(entity)
-	d.FlushListCache()
+	// Compared with the stored row once the before hooks ran, as they may change the unique values.
+	
+	var oldEmail string
+	if existing.Email != entity.Email {
+		oldEmail = existing.Email
+	}
+		
+	var oldUid string
+	if existing.Uid != entity.Uid {
+		oldUid = existing.Uid
+	}
+		
 
-	return err
+	// Inside a transaction caches are only touched once it commits.
+	cached := *entity
+	afterCommit(ctx, func() {
+		
+	if oldEmail != "" {
+		oldCacheKey := fmt.Sprintf("user_email:%s", oldEmail)
+		d.cache.Delete(oldCacheKey)
+		d.cacheProvider.InvalidateCache("user", oldCacheKey)
+	}
+	if oldUid != "" {
+		oldCacheKey := fmt.Sprintf("user_uid:%s", oldUid)
+		d.cache.Delete(oldCacheKey)
+		d.cacheProvider.InvalidateCache("user", oldCacheKey)
+	}
+
+		d.InvalidateCache(&cached)
+		d.FlushListCache()
+
+		d.setCached(&cached)
+	})
+
+	return entity, nil
 }
 
-func (d *userRepository) delete(ctx context.Context, id int64) error {
+func (d *userRepository) upsertByEmail(ctx context.Context, entity *User) (*User, error) {
+	if !inTx(ctx) {
+		return withinTx(ctx, func(ctx context.Context) (*User, error) { return d.upsertByEmail(ctx, entity) })
+	}
+	const operation = "upsert_by_email"
 	start := time.Now()
-	const operation = "delete"
 
-	
-	query := `
-		UPDATE users
-		SET 
-			deleted_at = NOW(), 
-			updated = NOW(), 
-			version = version + 1
-			, uid = CONCAT(uid, '-del-', UUID())
-			, email = CONCAT(email, '-del-', UUID())
-		WHERE id = ? AND deleted_at IS NULL
-	`
-	
+	now := time.Now().Truncate(time.Second)
+	entity.Created = now
+	entity.Updated = now
+
+	// Auto-generate UID fields if they are not provided
+    if entity.Uid == "" {
+        entity.Uid = GenerateUID("user")
+    }
 
-    db, dbErr := d.dbProvider.GetDatabase("user", true);
+	query := `INSERT INTO users (age,birthdate,email,meta,status,uid,created,updated) VALUES (?,?,?,?,?,?,?,?) ON DUPLICATE KEY UPDATE version = IF(status <=> VALUES(status) AND age <=> VALUES(age), version, version + 1), updated = IF(status <=> VALUES(status) AND age <=> VALUES(age), updated, VALUES(updated)), status = VALUES(status), age = VALUES(age), id = LAST_INSERT_ID(id)`
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "user", true)
     if dbErr != nil {
 		d.telemetryProvider.IncDBError("user", operation)
-        return dbErr
+        return nil, dbErr
+    }
+	
+    // Snapshot the rows before the write to record its changes
+    before, auditErr := d.auditSnapshot(ctx, db, "email = ?", entity.Email)
+    if auditErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return nil, auditErr
     }
 
-	res, err := db.ExecContext(ctx, query, id)
+
+	result, err := db.ExecContext(ctx, query,entity.Age, entity.Birthdate, entity.Email, entity.Meta, entity.Status, entity.Uid,
+		entity.Created, entity.Updated)
 	if err != nil {
 		d.telemetryProvider.IncDBError("user", operation)
-		return fmt.Errorf("failed to delete user: %w", err)
+		return nil, fmt.Errorf("failed to upsert User: %w", err)
 	}
 
-	rowsAffected, err := res.RowsAffected()
+	// LAST_INSERT_ID(id) makes LastInsertId report the existing row on update.
+	id, err := result.LastInsertId()
 	if err != nil {
 		d.telemetryProvider.IncDBError("user", operation)
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return nil, fmt.Errorf("failed to get last insert ID: %w", err)
 	}
+	entity.ID = id
+	entity.Version = 0
 
-	if rowsAffected == 0 {
-		return ErrNotFound
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
 	}
 
-	d.telemetryProvider.IncDBRequest("user", operation)	
-	d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(start).Seconds())	
-	return nil
+	// MySQL reports 1 affected row for an insert and 2 for an update. Updated rows keep the columns
+	// that are not overwritten, so reload them from the write database.
+	if rowsAffected != 1 {
+		query = `SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at FROM users WHERE id = ?`
+		row := db.QueryRowContext(ctx, query, id)
+		err := row.Scan(
+			&entity.ID,
+			&entity.Version,&entity.Age,&entity.Birthdate,&entity.Email,&entity.Meta,&entity.Status,&entity.Uid,
+			&entity.Created,
+			&entity.Updated,
+			&entity.DeletedAt,
+		)
+		if err != nil {
+			d.telemetryProvider.IncDBError("user", operation)
+			return nil, fmt.Errorf("failed to upsert User: %w", err)
+		}
+	}
+	
+    if auditErr := d.recordChanges(ctx, db, operation, before, "id = ?", entity.ID); auditErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return nil, auditErr
+    }
+
+
+	d.telemetryProvider.IncDBRequest("user", operation)
+	d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(start).Seconds())
+	return entity, nil
 }
 
 
-// HardDelete permanently removes the entity from the database.
-// returns ErrNotFound in case nothing is deleted.
-func (d *userRepository) HardDelete(ctx context.Context, entity *User) error {
-    if d.configProvider.BlockedWrites("user") {
-        return ErrOperationBlocked
-    }
 
-	if entity.ID == 0 {
-		// nothing can be deleted as entity is not created.
-		return ErrNotFound
+// UpsertBulkByEmail upserts the entities by email in chunks of 500 rows, overwriting
+// status, age of the existing Users. Rows already holding the given values are not written.
+// The entities are filled from the stored rows, including the IDs of updated rows, and the outcome of
+// every entity is returned in the same order. Each chunk is written in a transaction; use RunInTx to make
+// the whole call atomic. Inserted rows run the CreateBulk hooks and updated ones the Update hooks.
+// Only the written rows are invalidated in the cache.
+// MySQL's ON DUPLICATE KEY UPDATE fires on any unique key, so an entity must not collide with another
+// User on a unique column other than email.
+func (d *userRepository) UpsertBulkByEmail(ctx context.Context, entities []*User) ([]UpsertOutcome, error) {
+	if d.configProvider.BlockedWrites("user") {
+		return nil, ErrOperationBlocked
 	}
 
-	const operation = "hard_delete"
+	const operation = "upsert_bulk_by_email"
 	d.telemetryProvider.IncDALOperation("user", operation)
 
-	_, err := d.dbBreaker.Execute(func() (interface{}, error) {
-		return nil, d.hardDelete(ctx, entity.ID)
-	})
+	if len(entities) == 0 {
+		return nil, nil // Nothing to upsert
+	}
 
-	if err != nil {
-		return err
+	// A statement can't upsert the same row twice, and the outcome would be ambiguous.
+	seen := make(map[string]struct{}, len(entities))
+	for _, entity := range entities {
+		if _, exists := seen[collationKey(entity.Email)]; exists {
+			return nil, fmt.Errorf("duplicate email %v in bulk upsert", entity.Email)
+		}
+		seen[collationKey(entity.Email)] = struct{}{}
 	}
 
-	// Lets clear item from cache and on next use use DB as source of truth
-	d.InvalidateCache(entity)
-	d.FlushListCache()
+	outcomes := make([]UpsertOutcome, len(entities))
+	var staleKeys []string
+	batchSize := 500 // Safe limit to prevent exceeding the placeholder limits
 
-	return err
+	for i := 0; i < len(entities); i += batchSize {
+		end := i + batchSize
+		if end > len(entities) {
+			end = len(entities)
+		}
+
+		result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+			return d.upsertBulkByEmail(ctx, entities[i:end], outcomes[i:end])
+		})
+		if err != nil {
+			return nil, err // Fail fast, earlier chunks stay written
+		}
+		staleKeys = append(staleKeys, result.([]string)...)
+	}
+
+	// Inside a transaction caches are only touched once it commits.
+	var inserted, updated []User
+	for i, entity := range entities {
+		switch outcomes[i] {
+		case UpsertInserted:
+			inserted = append(inserted, *entity)
+		case UpsertUpdated:
+			updated = append(updated, *entity)
+		}
+	}
+	afterCommit(ctx, func() {
+		for _, key := range staleKeys {
+			d.cache.Delete(key)
+			d.cacheProvider.InvalidateCache("user", key)
+		}
+		for i := range updated {
+			d.InvalidateCache(&updated[i])
+			d.setCached(&updated[i])
+		}
+		for i := range inserted {
+			d.setCached(&inserted[i])
+		}
+
+		if len(inserted) > 0 || len(updated) > 0 {
+			d.FlushListCache()
+		}
+	})
+
+	return outcomes, nil
 }
 
-func (d *userRepository) hardDelete(ctx context.Context, id int64) error {
+// upsertBulkByEmail upserts a single chunk and returns the cache keys of overwritten unique values.
+func (d *userRepository) upsertBulkByEmail(ctx context.Context, chunk []*User, outcomes []UpsertOutcome) ([]string, error) {
+	// The stored rows stay locked from the read that classifies them until the write commits, and errors of
+	// the after hooks roll the chunk back.
+	if !inTx(ctx) {
+		return withinTx(ctx, func(ctx context.Context) ([]string, error) { return d.upsertBulkByEmail(ctx, chunk, outcomes) })
+	}
+	const operation = "upsert_bulk_by_email"
 	start := time.Now()
-	const operation = "hard_delete"
-
-	query := `
-		DELETE FROM users
-		WHERE id = ?
-	`
 
-    db, dbErr := d.dbProvider.GetDatabase("user", true);
-    if dbErr != nil {
+	db, dbErr := databaseFor(ctx, d.dbProvider, "user", true)
+	if dbErr != nil {
 		d.telemetryProvider.IncDBError("user", operation)
-        return dbErr
-    }
+		return nil, dbErr
+	}
 
-	res, err := db.ExecContext(ctx, query, id)
-	if err != nil {
-		d.telemetryProvider.IncDBError("user", operation)
-		return fmt.Errorf("failed to hard delete user: %w", err)
+	// 1. Load and lock the stored rows to tell inserts, updates and unchanged rows apart
+	keys := make([]interface{}, len(chunk))
+	for j, entity := range chunk {
+		keys[j] = entity.Email
 	}
+	
+    // Snapshot the rows before the write to record its changes
+    before, auditErr := d.auditSnapshot(ctx, db, "email IN (?...)", inValues(keys))
+    if auditErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return nil, auditErr
+    }
 
-	rowsAffected, err := res.RowsAffected()
+	stored, err := d.storedByEmails(ctx, db, keys)
 	if err != nil {
 		d.telemetryProvider.IncDBError("user", operation)
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return nil, err
 	}
 
-	if rowsAffected == 0 {
-		return ErrNotFound
+	var staleKeys []string
+	var inserts, updates []*User
+	for j, entity := range chunk {
+		existing, exists := stored[collationKey(entity.Email)]
+		switch {
+		case !exists:
+			outcomes[j] = UpsertInserted
+			inserts = append(inserts, entity)
+		case sameValue(existing.Status, entity.Status) && sameValue(existing.Age, entity.Age):
+			outcomes[j] = UpsertUnchanged
+			*entity = *existing
+		default:
+			outcomes[j] = UpsertUpdated
+
+			// Keep the stored values of the columns that are not overwritten
+			merged := *existing
+			merged.Status = entity.Status
+			merged.Age = entity.Age
+			*entity = merged
+			updates = append(updates, entity)
+		}
 	}
 
-	d.telemetryProvider.IncDBRequest("user", operation)	
-	d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(start).Seconds())	
-	return nil
-}
+	// Inserts run the hooks of CreateBulk and updates the ones of Update, unchanged rows none.
+	if len(inserts) > 0 {
+		if err := runHooks(ctx, "BeforeCreateBulk", d.hooks.beforeCreateBulk, inserts); err != nil {
+			return nil, err
+		}
+	}
+	for _, entity := range updates {
+		if err := runHooks(ctx, "BeforeUpdate", d.hooks.beforeUpdate, entity); err != nil {
+			return nil, err
+		}
+	}
 
+	// Validated once the hooks ran, unchanged rows aren't written.
+	for _, written := range [][]*User{inserts, updates} {
+		for _, entity := range written {
+			if err := entity.Validate(); err != nil {
+				return nil, fmt.Errorf("email %v: %w", entity.Email, err)
+			}
+		}
+	}
 
+	now := time.Now().Truncate(time.Second)
+	var writtenKeys []interface{}
+	valuePlaceholders := make([]string, 0, len(chunk))
+	params := make([]interface{}, 0, len(chunk)*(6 + 2))
 
+	for j, entity := range chunk {
+		if outcomes[j] == UpsertUnchanged {
+			continue
+		}
 
+		// Auto-generate UID fields of inserted rows if they are not provided
+		if outcomes[j] == UpsertInserted && entity.Uid == "" {
+			entity.Uid = GenerateUID("user")
+		}
 
-func (d *userRepository) GetByID(ctx context.Context, id int64) (*User, error) {
-    if d.configProvider.BlockedReads("user") {
-        return nil, ErrOperationBlocked
-    }
+		entity.Created = now
+		entity.Updated = now
+
+		writtenKeys = append(writtenKeys, entity.Email)
+		valuePlaceholders = append(valuePlaceholders, "(?,?,?,?,?,?,?,?)")
+		params = append(params,
+			entity.Age,
+			entity.Birthdate,
+			entity.Email,
+			entity.Meta,
+			entity.Status,
+			entity.Uid,
+			entity.Created,
+			entity.Updated,
+		)
+	}
 
-    const operation = "get_by_id"
-    d.telemetryProvider.IncDALOperation("user", operation)
+	if len(writtenKeys) == 0 {
+		return nil, nil // Every row is unchanged
+	}
 
-	// Load from cache
-    cachedEntity, _ := d.getByIDCached(id)
-    if cachedEntity != nil {
-        return cachedEntity, nil
-    }
+	// 2. Write the inserted and updated rows in a single statement
+	query := fmt.Sprintf(`
+		INSERT INTO users
+		(age,birthdate,email,meta,status,uid,
+created,updated)
+		VALUES %s
+	`, strings.Join(valuePlaceholders, ",")) + ` ON DUPLICATE KEY UPDATE version = IF(status <=> VALUES(status) AND age <=> VALUES(age), version, version + 1), updated = IF(status <=> VALUES(status) AND age <=> VALUES(age), updated, VALUES(updated)), status = VALUES(status), age = VALUES(age)`
 
-	// Fallback to database if cache miss or decoding fails
-	result, err := d.dbBreaker.Execute(func() (interface{}, error) {
-		return d.getByID(ctx, id)
-	})
+	if _, err := db.ExecContext(ctx, query, params...); err != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+		return nil, fmt.Errorf("failed to bulk upsert users chunk: %w", err)
+	}
 
+	// 3. LastInsertId can't identify the rows of a multi row upsert, so reload them by email
+	stored, err = d.storedByEmails(ctx, db, writtenKeys)
 	if err != nil {
+		d.telemetryProvider.IncDBError("user", operation)
 		return nil, err
 	}
 
-	entity, ok := result.(*User)
-    if !ok {
-        return nil, fmt.Errorf("invalid type")
+	for j, entity := range chunk {
+		if outcomes[j] == UpsertUnchanged {
+			continue
+		}
+
+		row, exists := stored[collationKey(entity.Email)]
+		if !exists {
+			// The database matched a row the key doesn't, e.g. one differing in accents or trailing spaces. Let it find the row
+			// of this email alone rather than failing after the write.
+			again, err := d.storedByEmails(ctx, db, []interface{}{entity.Email})
+			if err != nil {
+				d.telemetryProvider.IncDBError("user", operation)
+				return nil, err
+			}
+			for _, match := range again {
+				row = match
+			}
+		}
+		if row == nil {
+			return nil, fmt.Errorf("upserted User with email %v not found", entity.Email)
+		}
+
+		// A concurrent insert of the same email turns our insert into an update.
+		if outcomes[j] == UpsertInserted && row.Version > 0 {
+			outcomes[j] = UpsertUpdated
+		}
+		*entity = *row
+	}
+	
+    if auditErr := d.recordChanges(ctx, db, operation, before, "email IN (?...)", inValues(keys)); auditErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return nil, auditErr
     }
 
-    _ = d.setCached(entity)
 
-    return entity, err
+	if len(inserts) > 0 {
+		if err := runHooks(ctx, "AfterCreateBulk", d.hooks.afterCreateBulk, inserts); err != nil {
+			return nil, err
+		}
+	}
+	for _, entity := range updates {
+		if err := runHooks(ctx, "AfterUpdate", d.hooks.afterUpdate, entity); err != nil {
+			return nil, err
+		}
+	}
+
+	d.telemetryProvider.IncDBRequest("user", operation)
+	d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(start).Seconds())
+	return staleKeys, nil
 }
 
-func (d *userRepository) setCached(entity *User) error {
-    // if we have not reached the maximum number of items set the cache. otherwise expirations will
-	// handle this
-    itemCount := d.cache.ItemCount()
-    if itemCount < 100000 {
-        cacheKey := d.getCacheKey(entity.ID)
+// storedByEmails loads and locks the rows holding the given email values from db, keyed by email as the database compares it.
+func (d *userRepository) storedByEmails(ctx context.Context, db dbExecutor, keys []interface{}) (map[string]*User, error) {
+	placeholders := make([]string, len(keys))
+	for i := range keys {
+		placeholders[i] = "?"
+	}
 
-        // Store in cache with new format        
-        d.telemetryProvider.IncCacheWrite("user")
-        d.telemetryProvider.SetCacheSize("user", float64(itemCount+1))        
+	// Soft deleted rows have their unique values scrambled, so they never match.
+	query := fmt.Sprintf(`
+		SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at
+		FROM users
+		WHERE email IN (%s) FOR UPDATE
+	`, strings.Join(placeholders, ","))
 
-        copy := *entity
-		d.cache.Set(cacheKey, &copy, time.Second*300)
-    }
+	rows, err := db.QueryContext(ctx, query, keys...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stored users: %w", err)
+	}
+	defer rows.Close()
 
-    return nil
+	result := make(map[string]*User, len(keys))
+	for rows.Next() {
+		var entity User
+		err := rows.Scan(
+			&entity.ID,
+			&entity.Version,
+			&entity.Age,
+			&entity.Birthdate,
+			&entity.Email,
+			&entity.Meta,
+			&entity.Status,
+			&entity.Uid,
+			&entity.Created,
+			&entity.Updated,
+			&entity.DeletedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan User: %w", err)
+		}
+		result[collationKey(entity.Email)] = &entity
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read stored users: %w", err)
+	}
+	return result, nil
 }
 
-// Gets entity from cache only or return nil
-func (d *userRepository) getByIDCached(id int64) (*User, error) {
-    const operation = "get_by_id"
-    cacheKey := d.getCacheKey(id)
-    val, found := d.cache.Get(cacheKey)
-    if found {
-        entity, ok := val.(*User)
-        if !ok {
-            return nil, fmt.Errorf("userRepository.GetById: Cache returned wrong type")
-        }
 
-        d.telemetryProvider.IncCacheHit("user", operation)        
-        copy := *entity
-        return &copy, nil
+
+// PatchStatus updates only status of the User with the given id and version,
+// bumping its version. Unlike Update it doesn't need the whole entity, BeforeUpdate hooks get the stored one.
+// ErrNotFound is returned in case there was nothing to update; either missing id or newer version of entity is already stored.
+func (d *userRepository) PatchStatus(ctx context.Context, id int64, version int32, status *UserStatus) error {
+	if d.configProvider.BlockedWrites("user") {
+		return ErrOperationBlocked
+	}
+
+	const operation = "patch_status"
+	d.telemetryProvider.IncDALOperation("user", operation)
+
+	// BeforeUpdate hooks get the stored entity with the patched values.
+	var existing *User
+	if len(d.hooks.beforeUpdate) > 0 {
+		var err error
+		if existing, err = d.GetByID(ctx, id); err != nil {
+			return fmt.Errorf("failed to get existing User, id: %v, err: %w", id, err)
+		}
+	}
+
+	if _, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		if len(d.hooks.beforeUpdate) > 0 {
+			// Only the hooks' changes to the patched columns are written.
+			patched := *existing
+			patched.Version = version
+			patched.Status = status
+			if err := runHooks(ctx, "BeforeUpdate", d.hooks.beforeUpdate, &patched); err != nil {
+				return nil, err
+			}
+			status = patched.Status
+		}
+
+    v := validator{entity: "user"}
+    validateUserStatus(&v, status)
+    if err := v.err(); err != nil {
+        return nil, err
     }
+		return nil, d.patchStatus(ctx, id, version, status)
+	}); err != nil {
+		return err
+	}
 
-    // Cache missed or error during fetching cached data
-    d.telemetryProvider.IncCacheMiss("user", operation)        	
+	// Inside a transaction caches are only touched once it commits.
+	afterCommit(ctx, func() {
 
-    return nil, nil
+		d.InvalidateCache(&User{ID: id})
+		// Lists filter or order by a patched column.
+		d.FlushListCache()
+	})
+
+	return nil
 }
 
-func (d *userRepository) getByID(ctx context.Context, id int64) (*User, error) {
-    const operation = "get_by_id"
-    dbStart := time.Now();
+func (d *userRepository) patchStatus(ctx context.Context, id int64, version int32, status *UserStatus) error {
+	if !inTx(ctx) {
+		return RunInTx(ctx, func(ctx context.Context) error {
+			return d.patchStatus(ctx, id, version, status)
+		})
+	}
+	const operation = "patch_status"
+	start := time.Now()
 
-    query := `
-        SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at
-        FROM users
-        WHERE id = ? AND deleted_at IS NULL
-    `
+	query := `
+		UPDATE users
+		SET status = ?, updated = ?, version = version + 1
+		WHERE id = ? AND version = ? AND deleted_at IS NULL
+	`
 
-    db, dbErr := d.dbProvider.GetDatabase("user", false);
-    if dbErr != nil {
+	db, dbErr := databaseFor(ctx, d.dbProvider, "user", true)
+	if dbErr != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+		return dbErr
+	}
+	
+    // Snapshot the rows before the write to record its changes
+    before, auditErr := d.auditSnapshot(ctx, db, "id = ?", id)
+    if auditErr != nil {
         d.telemetryProvider.IncDBError("user", operation)
-        return nil, dbErr
+        return auditErr
     }
 
-    row := db.QueryRowContext(ctx, query, id)
-    var entity User
-    err := row.Scan(
-        &entity.ID,
-        &entity.Version,&entity.Age,&entity.Birthdate,&entity.Email,&entity.Meta,&entity.Status,&entity.Uid,
-        &entity.Created,
-        &entity.Updated,
-        &entity.DeletedAt,
-    )
-    if err != nil {
-        if errors.Is(err, sql.ErrNoRows) {
-            return nil, ErrNotFound
-        }
+
+	res, err := db.ExecContext(ctx, query, status, time.Now(), id, version)
+	if err != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+		return fmt.Errorf("failed to patch User: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		// clean out cache for specific ID to limit stale cache entries
+		afterCommit(ctx, func() { d.InvalidateCache(&User{ID: id}) })
+		return ErrNotFound
+	}
+	
+    if auditErr := d.recordChanges(ctx, db, operation, before, "id = ?", id); auditErr != nil {
         d.telemetryProvider.IncDBError("user", operation)
-        return nil, fmt.Errorf("failed to get User by ID: %w", err)
+        return auditErr
     }
 
-    d.telemetryProvider.IncDBRequest("user", operation)	
-    d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(dbStart).Seconds())	
-    return &entity, nil
-}
 
+	d.telemetryProvider.IncDBRequest("user", operation)
+	d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(start).Seconds())
+	return nil
+}
 
 
 
-func (d *userRepository) GetByEmail(ctx context.Context, email string) (*User, error) {
-    if d.configProvider.BlockedReads("user") {
-        return nil, ErrOperationBlocked
-    }
+// PatchEmail updates only email of the User with the given id and version,
+// bumping its version. Unlike Update it doesn't need the whole entity, BeforeUpdate hooks get the stored one.
+// ErrNotFound is returned in case there was nothing to update; either missing id or newer version of entity is already stored.
+func (d *userRepository) PatchEmail(ctx context.Context, id int64, version int32, email string) error {
+	if d.configProvider.BlockedWrites("user") {
+		return ErrOperationBlocked
+	}
 
-    const operation = "get_by_email"
-    d.telemetryProvider.IncDALOperation("user", operation)
+	const operation = "patch_email"
+	d.telemetryProvider.IncDALOperation("user", operation)
 
-    // key would for example be: user_email:test@something.com, but in this case cache is only mapping email -> id
-    cacheKey := fmt.Sprintf("user_email:%v", email)
+	// 1. Get existing entity, its unique values are cached as mappings that may go stale.
+	existing, err := d.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get existing User, id: %v, err: %w", id, err)
+	}
 
-    // Fetch from cache Email -> ID mapping
-    val, found := d.cache.Get(cacheKey)
-    if found {
-        entityId, ok := val.(int64)
-        if !ok {
-            return nil, fmt.Errorf("userRepository.GetByEmail: Cache returned wrong type; expected ID type")
-        }
+	if _, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		if len(d.hooks.beforeUpdate) > 0 {
+			// Only the hooks' changes to the patched columns are written.
+			patched := *existing
+			patched.Version = version
+			patched.Email = email
+			if err := runHooks(ctx, "BeforeUpdate", d.hooks.beforeUpdate, &patched); err != nil {
+				return nil, err
+			}
+			email = patched.Email
+		}
 
-        d.telemetryProvider.IncCacheHit("user", operation)        
-        
-        // return entity by that id
-        return d.GetByID(ctx, entityId)
+    v := validator{entity: "user"}
+    validateUserEmail(&v, email)
+    if err := v.err(); err != nil {
+        return nil, err
     }
+		return nil, d.patchEmail(ctx, id, version, email)
+	}); err != nil {
+		return err
+	}
 
-    // Cache missed or error during fetching cached data
-    d.telemetryProvider.IncCacheMiss("user", operation)        	
+	// Inside a transaction caches are only touched once it commits.
+	afterCommit(ctx, func() {
+		if !sameValue(existing.Email, email) {
+			oldCacheKey := fmt.Sprintf("user_email:%v", existing.Email)
+			d.cache.Delete(oldCacheKey)
+			d.cacheProvider.InvalidateCache("user", oldCacheKey)
+		}
 
-	// Fallback to database if cache miss or decoding fails
-	result, err := d.dbBreaker.Execute(func() (interface{}, error) {
-		return d.getByEmail(ctx, email)
+		d.InvalidateCache(&User{ID: id})
 	})
 
-	if err != nil {
-		return nil, err
-	}
-	entity := result.(*User)
-
-	// Store in cache Email -> ID mapping
-    d.telemetryProvider.IncCacheWrite("user")    
-    d.cache.Set(cacheKey, entity.ID, time.Second*300)
-
-    return entity, err
+	return nil
 }
 
-func (d *userRepository) getByEmail(ctx context.Context, email string) (*User, error) {
-    const operation = "get_by_email"
-    dbStart := time.Now()
+func (d *userRepository) patchEmail(ctx context.Context, id int64, version int32, email string) error {
+	if !inTx(ctx) {
+		return RunInTx(ctx, func(ctx context.Context) error {
+			return d.patchEmail(ctx, id, version, email)
+		})
+	}
+	const operation = "patch_email"
+	start := time.Now()
 
-    query := `
-        SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at
-        FROM users
-        WHERE email = ? AND deleted_at IS NULL
-    `
+	query := `
+		UPDATE users
+		SET email = ?, updated = ?, version = version + 1
+		WHERE id = ? AND version = ? AND deleted_at IS NULL
+	`
 
-    db, dbErr := d.dbProvider.GetDatabase("user", false);
-    if dbErr != nil {
+	db, dbErr := databaseFor(ctx, d.dbProvider, "user", true)
+	if dbErr != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+		return dbErr
+	}
+	
+    // Snapshot the rows before the write to record its changes
+    before, auditErr := d.auditSnapshot(ctx, db, "id = ?", id)
+    if auditErr != nil {
         d.telemetryProvider.IncDBError("user", operation)
-        return nil, dbErr
+        return auditErr
     }
 
-    row := db.QueryRowContext(ctx, query, email)
-    var entity User
-    err := row.Scan(
-        &entity.ID,
-        &entity.Version,
-        &entity.Age,
-        &entity.Birthdate,
-        &entity.Email,
-        &entity.Meta,
+
+	res, err := db.ExecContext(ctx, query, email, time.Now(), id, version)
+	if err != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+		return fmt.Errorf("failed to patch User: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		// clean out cache for specific ID to limit stale cache entries
+		afterCommit(ctx, func() { d.InvalidateCache(&User{ID: id}) })
+		return ErrNotFound
+	}
+	
+    if auditErr := d.recordChanges(ctx, db, operation, before, "id = ?", id); auditErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return auditErr
+    }
+
+
+	d.telemetryProvider.IncDBRequest("user", operation)
+	d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(start).Seconds())
+	return nil
+}
+
+
+
+// IncrementAge atomically adds delta to age of the User with the given id and returns
+// the new value; pass a negative delta to decrement. The result is clamped to a floor of 0 and a ceiling of 120.
+// Concurrent increments don't conflict as no version is checked, but the version is bumped so an
+// Update of an older version can't overwrite the counter. ErrNotFound is returned for a missing id.
+func (d *userRepository) IncrementAge(ctx context.Context, id int64, delta int64) (int64, error) {
+	if d.configProvider.BlockedWrites("user") {
+		return 0, ErrOperationBlocked
+	}
+
+	const operation = "increment_age"
+	d.telemetryProvider.IncDALOperation("user", operation)
+
+	value, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return d.incrementAge(ctx, id, delta)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Inside a transaction caches are only touched once it commits.
+	afterCommit(ctx, func() {
+		d.InvalidateCache(&User{ID: id})
+		// Lists filter or order by age or updated.
+		d.FlushListCache()
+	})
+
+	return value.(int64), nil
+}
+
+func (d *userRepository) incrementAge(ctx context.Context, id int64, delta int64) (int64, error) {
+	if !inTx(ctx) {
+		return withinTx(ctx, func(ctx context.Context) (int64, error) { return d.incrementAge(ctx, id, delta) })
+	}
+	const operation = "increment_age"
+	start := time.Now()
+
+	query := `UPDATE users SET age = LAST_INSERT_ID(LEAST(GREATEST(age + ?, 0), 120)), updated = ?, version = version + 1 WHERE id = ? AND deleted_at IS NULL`
+
+	db, dbErr := databaseFor(ctx, d.dbProvider, "user", true)
+	if dbErr != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+		return 0, dbErr
+	}
+	
+    // Snapshot the rows before the write to record its changes
+    before, auditErr := d.auditSnapshot(ctx, db, "id = ?", id)
+    if auditErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return 0, auditErr
+    }
+
+
+	var value int64
+	result, err := db.ExecContext(ctx, query, delta, time.Now(), id)
+	if err != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+		return 0, fmt.Errorf("failed to increment age of User: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return 0, ErrNotFound
+	}
+
+	// LAST_INSERT_ID(expr) makes LastInsertId report the new value.
+	value, err = result.LastInsertId()
+	if err != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+		return 0, fmt.Errorf("failed to get incremented value: %w", err)
+	}
+	
+    if auditErr := d.recordChanges(ctx, db, operation, before, "id = ?", id); auditErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return 0, auditErr
+    }
+
+
+	d.telemetryProvider.IncDBRequest("user", operation)
+	d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(start).Seconds())
+	return value, nil
+}
+
+
+
+func (d *userRepository) Delete(ctx context.Context, entity *User) error {
+    if d.configProvider.BlockedWrites("user") {
+        return ErrOperationBlocked
+    }
+
+	if entity.ID == 0 {
+		// nothing can be deleted as entity is not created.
+		return ErrNotFound
+	}
+
+	const operation = "delete"
+	d.telemetryProvider.IncDALOperation("user", operation)
+
+	_, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return nil, withHooks(ctx, "Delete", entity, d.hooks.beforeDelete, d.hooks.afterDelete, nil, func(ctx context.Context) error {
+			return d.delete(ctx, entity.ID)
+		})
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// Lets clear item from cache and on next use use DB as source of truth
+	deleted := *entity
+	afterCommit(ctx, func() {
+		d.InvalidateCache(&deleted)
+		d.FlushListCache()
+	})
+
+	return err
+}
+
+func (d *userRepository) delete(ctx context.Context, id int64) error {
+	if !inTx(ctx) {
+		return RunInTx(ctx, func(ctx context.Context) error { return d.delete(ctx, id) })
+	}
+	start := time.Now()
+	const operation = "delete"
+
+	
+	query := `
+		UPDATE users
+		SET 
+			deleted_at = NOW(), 
+			updated = NOW(), 
+			version = version + 1
+			, email = CONCAT(email, '-del-', UUID())
+			, uid = CONCAT(uid, '-del-', UUID())
+		WHERE id = ? AND deleted_at IS NULL
+	`
+	
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "user", true)
+    if dbErr != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+        return dbErr
+    }
+	
+    // Snapshot the rows before the write to record its changes
+    before, auditErr := d.auditSnapshot(ctx, db, "id = ?", id)
+    if auditErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return auditErr
+    }
+
+
+	res, err := db.ExecContext(ctx, query, id)
+	if err != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	
+    if auditErr := d.recordChanges(ctx, db, operation, before, "id = ?", id); auditErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return auditErr
+    }
+
+
+	d.telemetryProvider.IncDBRequest("user", operation)	
+	d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(start).Seconds())	
+	return nil
+}
+
+
+// HardDelete permanently removes the entity from the database.
+// returns ErrNotFound in case nothing is deleted.
+func (d *userRepository) HardDelete(ctx context.Context, entity *User) error {
+    if d.configProvider.BlockedWrites("user") {
+        return ErrOperationBlocked
+    }
+
+	if entity.ID == 0 {
+		// nothing can be deleted as entity is not created.
+		return ErrNotFound
+	}
+
+	const operation = "hard_delete"
+	d.telemetryProvider.IncDALOperation("user", operation)
+
+	_, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return nil, withHooks(ctx, "Delete", entity, d.hooks.beforeDelete, d.hooks.afterDelete, nil, func(ctx context.Context) error {
+			return d.hardDelete(ctx, entity.ID)
+		})
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// Lets clear item from cache and on next use use DB as source of truth
+	deleted := *entity
+	afterCommit(ctx, func() {
+		d.InvalidateCache(&deleted)
+		d.FlushListCache()
+	})
+
+	return err
+}
+
+func (d *userRepository) hardDelete(ctx context.Context, id int64) error {
+	if !inTx(ctx) {
+		return RunInTx(ctx, func(ctx context.Context) error { return d.hardDelete(ctx, id) })
+	}
+	start := time.Now()
+	const operation = "hard_delete"
+
+	query := `
+		DELETE FROM users
+		WHERE id = ?
+	`
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "user", true)
+    if dbErr != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+        return dbErr
+    }
+	
+    // Snapshot the rows before the write to record its changes
+    before, auditErr := d.auditSnapshot(ctx, db, "id = ?", id)
+    if auditErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return auditErr
+    }
+
+
+	res, err := db.ExecContext(ctx, query, id)
+	if err != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+		return fmt.Errorf("failed to hard delete user: %w", err)
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	
+    if auditErr := d.recordChanges(ctx, db, operation, before, "id = ?", id); auditErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return auditErr
+    }
+
+
+	d.telemetryProvider.IncDBRequest("user", operation)	
+	d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(start).Seconds())	
+	return nil
+}
+
+
+
+
+
+func (d *userRepository) GetByID(ctx context.Context, id int64) (*User, error) {
+    if d.configProvider.BlockedReads("user") {
+        return nil, ErrOperationBlocked
+    }
+
+    const operation = "get_by_id"
+    d.telemetryProvider.IncDALOperation("user", operation)
+
+	// Load from cache. Transactions bypass caches to see their own writes.
+    if !inTx(ctx) {
+        cachedEntity, _ := d.getByIDCached(id)
+        if cachedEntity != nil {
+            return cachedEntity, nil
+        }
+    }
+
+	// Fallback to database if cache miss or decoding fails
+	result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return d.getByID(ctx, id)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	entity, ok := result.(*User)
+    if !ok {
+        return nil, fmt.Errorf("invalid type")
+    }
+
+    if !inTx(ctx) {
+        _ = d.setCached(entity)
+    }
+
+    return entity, err
+}
+
+func (d *userRepository) setCached(entity *User) error {
+    // if we have not reached the maximum number of items set the cache. otherwise expirations will
+	// handle this
+    itemCount := d.cache.ItemCount()
+    if itemCount < 100000 {
+        cacheKey := d.getCacheKey(entity.ID)
+
+        // Store in cache with new format        
+        d.telemetryProvider.IncCacheWrite("user")
+        d.telemetryProvider.SetCacheSize("user", float64(itemCount+1))        
+
+        copy := *entity
+		d.cache.Set(cacheKey, &copy, time.Second*300)
+    }
+
+    return nil
+}
+
+// Gets entity from cache only or return nil
+func (d *userRepository) getByIDCached(id int64) (*User, error) {
+    const operation = "get_by_id"
+    cacheKey := d.getCacheKey(id)
+    val, found := d.cache.Get(cacheKey)
+    if found {
+        entity, ok := val.(*User)
+        if !ok {
+            return nil, fmt.Errorf("userRepository.GetById: Cache returned wrong type")
+        }
+
+        d.telemetryProvider.IncCacheHit("user", operation)        
+        copy := *entity
+        return &copy, nil
+    }
+
+    // Cache missed or error during fetching cached data
+    d.telemetryProvider.IncCacheMiss("user", operation)        	
+
+    return nil, nil
+}
+
+func (d *userRepository) getByID(ctx context.Context, id int64) (*User, error) {
+    const operation = "get_by_id"
+    dbStart := time.Now();
+
+    query := `
+        SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at
+        FROM users
+        WHERE id = ? AND deleted_at IS NULL
+    `
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "user", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return nil, dbErr
+    }
+
+    row := db.QueryRowContext(ctx, query, id)
+    var entity User
+    err := row.Scan(
+        &entity.ID,
+        &entity.Version,&entity.Age,&entity.Birthdate,&entity.Email,&entity.Meta,&entity.Status,&entity.Uid,
+        &entity.Created,
+        &entity.Updated,
+        &entity.DeletedAt,
+    )
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return nil, ErrNotFound
+        }
+        d.telemetryProvider.IncDBError("user", operation)
+        return nil, fmt.Errorf("failed to get User by ID: %w", err)
+    }
+
+    d.telemetryProvider.IncDBRequest("user", operation)	
+    d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(dbStart).Seconds())	
+    return &entity, nil
+}
+
+
+
+
+func (d *userRepository) GetByEmail(ctx context.Context, email string) (*User, error) {
+    if d.configProvider.BlockedReads("user") {
+        return nil, ErrOperationBlocked
+    }
+
+    const operation = "get_by_email"
+    d.telemetryProvider.IncDALOperation("user", operation)
+
+    // key would for example be: user_email:test@something.com, but in this case cache is only mapping email -> id
+    cacheKey := fmt.Sprintf("user_email:%v", email)
+
+    // Fetch from cache Email -> ID mapping
+    // Transactions bypass caches to see their own writes.
+    val, found := d.cache.Get(cacheKey)
+    if found && !inTx(ctx) {
+        entityId, ok := val.(int64)
+        if !ok {
+            return nil, fmt.Errorf("userRepository.GetByEmail: Cache returned wrong type; expected ID type")
+        }
+
+        d.telemetryProvider.IncCacheHit("user", operation)        
+        
+        // return entity by that id
+        return d.GetByID(ctx, entityId)
+    }
+
+    // Cache missed or error during fetching cached data
+    d.telemetryProvider.IncCacheMiss("user", operation)        	
+
+	// Fallback to database if cache miss or decoding fails
+	result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return d.getByEmail(ctx, email)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	entity := result.(*User)
+
+	// Store in cache Email -> ID mapping
+    if !inTx(ctx) {
+        d.telemetryProvider.IncCacheWrite("user")    
+        d.cache.Set(cacheKey, entity.ID, time.Second*300)
+    }
+
+    return entity, err
+}
+
+func (d *userRepository) getByEmail(ctx context.Context, email string) (*User, error) {
+    const operation = "get_by_email"
+    dbStart := time.Now()
+
+    query := `
+        SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at
+        FROM users
+        WHERE email = ? AND deleted_at IS NULL
+    `
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "user", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return nil, dbErr
+    }
+
+    row := db.QueryRowContext(ctx, query, email)
+    var entity User
+    err := row.Scan(
+        &entity.ID,
+        &entity.Version,
+        &entity.Age,
+        &entity.Birthdate,
+        &entity.Email,
+        &entity.Meta,
+        &entity.Status,
+        &entity.Uid,
+        &entity.Created,
+        &entity.Updated,
+        &entity.DeletedAt,
+    )
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return nil, ErrNotFound
+        }
+
+        d.telemetryProvider.IncDBError("user", operation)
+        return nil, fmt.Errorf("failed to get User by Email: %w", err)
+    }
+
+    d.telemetryProvider.IncDBRequest("user", operation)	
+    d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(dbStart).Seconds())	
+    return &entity, nil
+}
+
+
+
+func (d *userRepository) GetByUid(ctx context.Context, uid string) (*User, error) {
+    if d.configProvider.BlockedReads("user") {
+        return nil, ErrOperationBlocked
+    }
+
+    const operation = "get_by_uid"
+    d.telemetryProvider.IncDALOperation("user", operation)
+
+    // key would for example be: user_email:test@something.com, but in this case cache is only mapping email -> id
+    cacheKey := fmt.Sprintf("user_uid:%v", uid)
+
+    // Fetch from cache Uid -> ID mapping
+    // Transactions bypass caches to see their own writes.
+    val, found := d.cache.Get(cacheKey)
+    if found && !inTx(ctx) {
+        entityId, ok := val.(int64)
+        if !ok {
+            return nil, fmt.Errorf("userRepository.GetByUid: Cache returned wrong type; expected ID type")
+        }
+
+        d.telemetryProvider.IncCacheHit("user", operation)        
+        
+        // return entity by that id
+        return d.GetByID(ctx, entityId)
+    }
+
+    // Cache missed or error during fetching cached data
+    d.telemetryProvider.IncCacheMiss("user", operation)        	
+
+	// Fallback to database if cache miss or decoding fails
+	result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return d.getByUid(ctx, uid)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	entity := result.(*User)
+
+	// Store in cache Uid -> ID mapping
+    if !inTx(ctx) {
+        d.telemetryProvider.IncCacheWrite("user")    
+        d.cache.Set(cacheKey, entity.ID, time.Second*300)
+    }
+
+    return entity, err
+}
+
+func (d *userRepository) getByUid(ctx context.Context, uid string) (*User, error) {
+    const operation = "get_by_uid"
+    dbStart := time.Now()
+
+    query := `
+        SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at
+        FROM users
+        WHERE uid = ? AND deleted_at IS NULL
+    `
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "user", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return nil, dbErr
+    }
+
+    row := db.QueryRowContext(ctx, query, uid)
+    var entity User
+    err := row.Scan(
+        &entity.ID,
+        &entity.Version,
+        &entity.Age,
+        &entity.Birthdate,
+        &entity.Email,
+        &entity.Meta,
         &entity.Status,
         &entity.Uid,
         &entity.Created,
@@ -1018,122 +2284,642 @@ func (d *userRepository) getByEmail(ctx context.Context, email string) (*User, e
         }
 
         d.telemetryProvider.IncDBError("user", operation)
-        return nil, fmt.Errorf("failed to get User by Email: %w", err)
+        return nil, fmt.Errorf("failed to get User by Uid: %w", err)
+    }
+
+    d.telemetryProvider.IncDBRequest("user", operation)	
+    d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(dbStart).Seconds())	
+    return &entity, nil
+}
+
+
+
+func (d *userRepository) ListById(ctx context.Context, startID int64, pageSize int) ([]*User, error) {
+    if d.configProvider.BlockedReads("user") {
+        return nil, ErrOperationBlocked
+    }
+
+	const operation = "list_by_id"
+	d.telemetryProvider.IncDALOperation("user", operation)
+
+    cacheKey := fmt.Sprintf("user_list_by_id:epoch_%d:%d:%d", d.getEpoch(), startID, pageSize)
+    // Transactions bypass caches to see their own writes.
+    val, found := d.listCache.Get(cacheKey)
+    if found && !inTx(ctx) {
+        entityIDs, ok := val.([]int64)
+        if !ok {
+            return nil, fmt.Errorf("userRepository.ListById: Cache returned wrong type; expected array ID type")
+        }
+
+        var entities []*User
+        missingEntries := false
+        for _, id := range entityIDs {
+            entity, err := d.getByIDCached(id)
+            if err != nil {
+                // failed using cache
+                return nil, err
+            }
+
+            if entity == nil {
+                // we have one or more missing entity in cache somehow. reload the whole list.
+                missingEntries = true
+                break
+            }
+
+            entities = append(entities, entity)
+        }
+
+        if !missingEntries {
+            d.telemetryProvider.IncCacheHit("user", operation)        
+            return entities, nil
+        }
+    }
+
+    // Otherwise, it's a cache miss or decode error
+     d.telemetryProvider.IncCacheMiss("user", operation)       
+
+    // 2) Fallback to DB
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+        return d.listById(ctx, startID, pageSize)
+    })
+
+    if err != nil {
+        // DB also failed
+        return nil, err
+    }
+
+    entities := result.([]*User)
+    if inTx(ctx) {
+        return entities, nil
+    }
+
+    // Store Cache. cacheKey = user_list_by_id:startID:pageSize.
+	// Stored is array of entity IDs.
+	var entityIDs []int64
+	for _, entity := range entities {
+		entityIDs = append(entityIDs, entity.ID)
+        // cache each entity individualy
+        d.setCached(entity)
+	}
+	d.listCache.Set(cacheKey, entityIDs, time.Second*60)
+
+    return entities, nil
+}
+
+func (d *userRepository) listById(ctx context.Context, startID int64, pageSize int) ([]*User, error) {
+    const operation = "list_by_id"
+	dbStart := time.Now()
+
+    var query string
+
+    // the first page query is different for pagination
+    if startID == 0 {
+        query = `SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at FROM users WHERE deleted_at IS NULL ORDER BY id LIMIT ?`
+    } else {
+        query = `SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at FROM users WHERE deleted_at IS NULL AND id > ? ORDER BY id LIMIT ?`
+    }
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "user", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return nil, dbErr
+    }
+
+    var rows *sql.Rows
+    var err error
+
+    if startID == 0 {
+	    rows, err = db.QueryContext(ctx, query, pageSize)
+    } else {
+        rows, err = db.QueryContext(ctx, query, startID, pageSize)
+    }
+
+	if err != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	var entities []*User
+	for rows.Next() {
+		var entity User
+		err := rows.Scan(
+			&entity.ID,
+            &entity.Version,
+            &entity.Age,
+            &entity.Birthdate,
+            &entity.Email,
+            &entity.Meta,
+            &entity.Status,
+            &entity.Uid,
+            &entity.Created,
+            &entity.Updated,
+            &entity.DeletedAt,
+		)
+		if err != nil {
+            d.telemetryProvider.IncDBError("user", operation)
+			return nil, fmt.Errorf("failed to scan User: %w", err)
+		}
+		entities = append(entities, &entity)
+	}
+
+	if err = rows.Err(); err != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+    d.telemetryProvider.IncDBRequest("user", operation)	
+    d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(dbStart).Seconds())	
+	return entities, nil
+}
+
+
+
+// ListByIdPage returns a page of ListById with the opaque token of the next page.
+// Pass an empty pageToken for the first page. A token only continues the query it was issued for,
+// otherwise ErrInvalidPageToken is returned.
+func (d *userRepository) ListByIdPage(ctx context.Context, pageToken string, pageSize int) (Page[User], error) {
+	if pageSize <= 0 {
+		return Page[User]{}, fmt.Errorf("page size must be positive, got %d", pageSize)
+	}
+
+	paramsHash := pageParamsHash()
+	var startID int64
+	if pageToken != "" {
+		if err := decodePageToken(pageToken, "user", "list_by_id", paramsHash, &startID); err != nil {
+			return Page[User]{}, err
+		}
+	}
+
+	// One extra row tells whether another page exists.
+	entities, err := d.ListById(ctx, startID, pageSize+1)
+	if err != nil {
+		return Page[User]{}, err
+	}
+
+	page := Page[User]{Items: entities}
+	if len(entities) > pageSize {
+		page.Items = entities[:pageSize]
+		page.HasMore = true
+		next := page.Items[pageSize-1].ID
+		page.NextPageToken, err = encodePageToken("user", "list_by_id", paramsHash, next)
+		if err != nil {
+			return Page[User]{}, err
+		}
+	}
+
+	return page, nil
+}
+
+
+
+
+// UserListByBdayCursor points at the last User of a ListByBday page. Pass it to ListByBday to fetch the next page,
+// or nil for the first one.
+type UserListByBdayCursor struct {
+	Birthdate time.Time
+	ID int64
+}
+
+// cacheKey identifies the page in list cache keys.
+func (c *UserListByBdayCursor) cacheKey() string {
+	if c == nil {
+		return "first"
+	}
+	return fmt.Sprintf("%v:%d", c.Birthdate.UnixNano(), c.ID)
+}
+
+// newUserListByBdayCursor returns the cursor of the page following entities, or nil if it was the last page.
+func newUserListByBdayCursor(entities []*User, pageSize int) *UserListByBdayCursor {
+	if len(entities) == 0 || len(entities) < pageSize {
+		return nil
+	}
+
+	last := entities[len(entities)-1]
+	if last.Birthdate == nil {
+		return nil // The where clause keeps NULLs out of the list
+	}
+	return &UserListByBdayCursor{Birthdate: *last.Birthdate, ID: last.ID}
+}
+
+// ListByBday returns a page of Users ordered by birthdate descending, starting after cursor,
+// and the cursor of the next page, which is nil once the last page is reached.
+func (d *userRepository) ListByBday(ctx context.Context, birthdate *time.Time, cursor *UserListByBdayCursor, pageSize int) ([]*User, *UserListByBdayCursor, error) {
+	entities, err := d.listByBdayWithCache(ctx, birthdate, cursor, pageSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entities, newUserListByBdayCursor(entities, pageSize), nil
+}
+
+func (d *userRepository) listByBdayWithCache(ctx context.Context, birthdate *time.Time, cursor *UserListByBdayCursor, pageSize int) ([]*User, error) {
+    if d.configProvider.BlockedReads("user") {
+        return nil, ErrOperationBlocked
+    }
+
+	const operation = "list_by_bday"
+	d.telemetryProvider.IncDALOperation("user", operation)
+
+    cacheKey := fmt.Sprintf("user_list_by_bday:epoch_%d:%v:%s:%d", d.getEpoch(), func() interface{} { if birthdate == nil { return "<<null>>" }; return *birthdate }(), cursor.cacheKey(), pageSize)
+    // Transactions bypass caches to see their own writes.
+    val, found := d.listCache.Get(cacheKey)
+    if found && !inTx(ctx) {
+        entityIDs, ok := val.([]int64)
+        if !ok {
+            return nil, fmt.Errorf("userRepository.ListByBday: Cache returned wrong type; expected array ID type")
+        }
+
+        var entities []*User
+        missingEntries := false
+        for _, id := range entityIDs {
+            entity, err := d.getByIDCached(id)
+            if err != nil {
+                // failed using cache
+                return nil, err
+            }
+
+            if entity == nil {
+                // we have one or more missing entity in cache somehow. reload the whole list.
+                missingEntries = true
+                break
+            }
+
+            entities = append(entities, entity)
+        }
+
+        if !missingEntries {
+            d.telemetryProvider.IncCacheHit("user", operation)        
+            return entities, nil
+        }
+    }
+
+    // Otherwise, it's a cache miss or decode error
+     d.telemetryProvider.IncCacheMiss("user", operation)       
+
+    // 2) Fallback to DB
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+        return d.listByBday(ctx, birthdate, cursor, pageSize)
+    })
+
+    if err != nil {
+        // DB also failed
+        return nil, err
+    }
+
+    entities := result.([]*User)
+    if inTx(ctx) {
+        return entities, nil
+    }
+
+    // Store Cache. cacheKey = user_list_by_id:startID:pageSize.
+	// Stored is array of entity IDs.
+	var entityIDs []int64
+	for _, entity := range entities {
+		entityIDs = append(entityIDs, entity.ID)
+        // cache each entity individualy
+        d.setCached(entity)
+	}
+	d.listCache.Set(cacheKey, entityIDs, time.Second*60)
+
+    return entities, nil
+}
+
+func (d *userRepository) listByBday(ctx context.Context, birthdate *time.Time, cursor *UserListByBdayCursor, pageSize int) ([]*User, error) {
+    const operation = "list_by_bday"
+	dbStart := time.Now()
+
+    var query string
+
+    // the first page query is different for pagination
+    if cursor == nil {
+        query = `SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at FROM users WHERE (birthdate < ?) AND deleted_at IS NULL ORDER BY birthdate DESC, id DESC LIMIT ?`
+    } else {
+        query = `SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at FROM users WHERE (birthdate < ?) AND deleted_at IS NULL AND (birthdate, id) < (?, ?) ORDER BY birthdate DESC, id DESC LIMIT ?`
+    }
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "user", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return nil, dbErr
+    }
+
+    var rows *sql.Rows
+    var err error
+
+    if cursor == nil {
+	    rows, err = db.QueryContext(ctx, query, birthdate, pageSize)
+    } else {
+        rows, err = db.QueryContext(ctx, query, birthdate, cursor.Birthdate, cursor.ID, pageSize)
     }
 
+	if err != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	var entities []*User
+	for rows.Next() {
+		var entity User
+		err := rows.Scan(
+			&entity.ID,
+            &entity.Version,
+            &entity.Age,
+            &entity.Birthdate,
+            &entity.Email,
+            &entity.Meta,
+            &entity.Status,
+            &entity.Uid,
+            &entity.Created,
+            &entity.Updated,
+            &entity.DeletedAt,
+		)
+		if err != nil {
+            d.telemetryProvider.IncDBError("user", operation)
+			return nil, fmt.Errorf("failed to scan User: %w", err)
+		}
+		entities = append(entities, &entity)
+	}
+
+	if err = rows.Err(); err != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
     d.telemetryProvider.IncDBRequest("user", operation)	
     d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(dbStart).Seconds())	
-    return &entity, nil
+	return entities, nil
 }
 
 
 
-func (d *userRepository) GetByUid(ctx context.Context, uid string) (*User, error) {
+
+// UserListByAgeCursor points at the last User of a ListByAge page. Pass it to ListByAge to fetch the next page,
+// or nil for the first one.
+type UserListByAgeCursor struct {
+	Created time.Time
+	ID int64
+}
+
+// cacheKey identifies the page in list cache keys.
+func (c *UserListByAgeCursor) cacheKey() string {
+	if c == nil {
+		return "first"
+	}
+	return fmt.Sprintf("%v:%d", c.Created.UnixNano(), c.ID)
+}
+
+// newUserListByAgeCursor returns the cursor of the page following entities, or nil if it was the last page.
+func newUserListByAgeCursor(entities []*User, pageSize int) *UserListByAgeCursor {
+	if len(entities) == 0 || len(entities) < pageSize {
+		return nil
+	}
+
+	last := entities[len(entities)-1]
+	return &UserListByAgeCursor{Created: last.Created, ID: last.ID}
+}
+
+// ListByAge returns a page of Users ordered by created, starting after cursor,
+// and the cursor of the next page, which is nil once the last page is reached.
+func (d *userRepository) ListByAge(ctx context.Context, minage int8, cursor *UserListByAgeCursor, pageSize int) ([]*User, *UserListByAgeCursor, error) {
+	entities, err := d.listByAgeWithCache(ctx, minage, cursor, pageSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entities, newUserListByAgeCursor(entities, pageSize), nil
+}
+
+func (d *userRepository) listByAgeWithCache(ctx context.Context, minage int8, cursor *UserListByAgeCursor, pageSize int) ([]*User, error) {
     if d.configProvider.BlockedReads("user") {
         return nil, ErrOperationBlocked
     }
 
-    const operation = "get_by_uid"
-    d.telemetryProvider.IncDALOperation("user", operation)
-
-    // key would for example be: user_email:test@something.com, but in this case cache is only mapping email -> id
-    cacheKey := fmt.Sprintf("user_uid:%v", uid)
+	const operation = "list_by_age"
+	d.telemetryProvider.IncDALOperation("user", operation)
 
-    // Fetch from cache Uid -> ID mapping
-    val, found := d.cache.Get(cacheKey)
-    if found {
-        entityId, ok := val.(int64)
+    cacheKey := fmt.Sprintf("user_list_by_age:epoch_%d:%v:%s:%d", d.getEpoch(), minage, cursor.cacheKey(), pageSize)
+    // Transactions bypass caches to see their own writes.
+    val, found := d.listCache.Get(cacheKey)
+    if found && !inTx(ctx) {
+        entityIDs, ok := val.([]int64)
         if !ok {
-            return nil, fmt.Errorf("userRepository.GetByUid: Cache returned wrong type; expected ID type")
+            return nil, fmt.Errorf("userRepository.ListByAge: Cache returned wrong type; expected array ID type")
         }
 
-        d.telemetryProvider.IncCacheHit("user", operation)        
-        
-        // return entity by that id
-        return d.GetByID(ctx, entityId)
+        var entities []*User
+        missingEntries := false
+        for _, id := range entityIDs {
+            entity, err := d.getByIDCached(id)
+            if err != nil {
+                // failed using cache
+                return nil, err
+            }
+
+            if entity == nil {
+                // we have one or more missing entity in cache somehow. reload the whole list.
+                missingEntries = true
+                break
+            }
+
+            entities = append(entities, entity)
+        }
+
+        if !missingEntries {
+            d.telemetryProvider.IncCacheHit("user", operation)        
+            return entities, nil
+        }
     }
 
-    // Cache missed or error during fetching cached data
-    d.telemetryProvider.IncCacheMiss("user", operation)        	
+    // Otherwise, it's a cache miss or decode error
+     d.telemetryProvider.IncCacheMiss("user", operation)       
 
-	// Fallback to database if cache miss or decoding fails
-	result, err := d.dbBreaker.Execute(func() (interface{}, error) {
-		return d.getByUid(ctx, uid)
-	})
+    // 2) Fallback to DB
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+        return d.listByAge(ctx, minage, cursor, pageSize)
+    })
 
-	if err != nil {
-		return nil, err
-	}
-	entity := result.(*User)
+    if err != nil {
+        // DB also failed
+        return nil, err
+    }
 
-	// Store in cache Uid -> ID mapping
-    d.telemetryProvider.IncCacheWrite("user")    
-    d.cache.Set(cacheKey, entity.ID, time.Second*300)
+    entities := result.([]*User)
+    if inTx(ctx) {
+        return entities, nil
+    }
 
-    return entity, err
+    // Store Cache. cacheKey = user_list_by_id:startID:pageSize.
+	// Stored is array of entity IDs.
+	var entityIDs []int64
+	for _, entity := range entities {
+		entityIDs = append(entityIDs, entity.ID)
+        // cache each entity individualy
+        d.setCached(entity)
+	}
+	d.listCache.Set(cacheKey, entityIDs, time.Second*60)
+
+    return entities, nil
 }
 
-func (d *userRepository) getByUid(ctx context.Context, uid string) (*User, error) {
-    const operation = "get_by_uid"
-    dbStart := time.Now()
+func (d *userRepository) listByAge(ctx context.Context, minage int8, cursor *UserListByAgeCursor, pageSize int) ([]*User, error) {
+    const operation = "list_by_age"
+	dbStart := time.Now()
 
-    query := `
-        SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at
-        FROM users
-        WHERE uid = ? AND deleted_at IS NULL
-    `
+    var query string
+
+    // the first page query is different for pagination
+    if cursor == nil {
+        query = `SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at FROM users WHERE (age = ? OR age > ?) AND deleted_at IS NULL ORDER BY created, id LIMIT ?`
+    } else {
+        query = `SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at FROM users WHERE (age = ? OR age > ?) AND deleted_at IS NULL AND (created, id) > (?, ?) ORDER BY created, id LIMIT ?`
+    }
 
-    db, dbErr := d.dbProvider.GetDatabase("user", false);
+    db, dbErr := databaseFor(ctx, d.dbProvider, "user", false)
     if dbErr != nil {
         d.telemetryProvider.IncDBError("user", operation)
         return nil, dbErr
     }
 
-    row := db.QueryRowContext(ctx, query, uid)
-    var entity User
-    err := row.Scan(
-        &entity.ID,
-        &entity.Version,
-        &entity.Age,
-        &entity.Birthdate,
-        &entity.Email,
-        &entity.Meta,
-        &entity.Status,
-        &entity.Uid,
-        &entity.Created,
-        &entity.Updated,
-        &entity.DeletedAt,
-    )
-    if err != nil {
-        if errors.Is(err, sql.ErrNoRows) {
-            return nil, ErrNotFound
-        }
+    var rows *sql.Rows
+    var err error
 
-        d.telemetryProvider.IncDBError("user", operation)
-        return nil, fmt.Errorf("failed to get User by Uid: %w", err)
+    if cursor == nil {
+	    rows, err = db.QueryContext(ctx, query, minage, minage, pageSize)
+    } else {
+        rows, err = db.QueryContext(ctx, query, minage, minage, cursor.Created, cursor.ID, pageSize)
     }
 
+	if err != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	var entities []*User
+	for rows.Next() {
+		var entity User
+		err := rows.Scan(
+			&entity.ID,
+            &entity.Version,
+            &entity.Age,
+            &entity.Birthdate,
+            &entity.Email,
+            &entity.Meta,
+            &entity.Status,
+            &entity.Uid,
+            &entity.Created,
+            &entity.Updated,
+            &entity.DeletedAt,
+		)
+		if err != nil {
+            d.telemetryProvider.IncDBError("user", operation)
+			return nil, fmt.Errorf("failed to scan User: %w", err)
+		}
+		entities = append(entities, &entity)
+	}
+
+	if err = rows.Err(); err != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
     d.telemetryProvider.IncDBRequest("user", operation)	
     d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(dbStart).Seconds())	
-    return &entity, nil
+	return entities, nil
+}
+
+
+
+// ListByAgePage returns a page of ListByAge with the opaque token of the next page.
+// Pass an empty pageToken for the first page. A token only continues the query it was issued for,
+// otherwise ErrInvalidPageToken is returned.
+func (d *userRepository) ListByAgePage(ctx context.Context, minage int8, pageToken string, pageSize int) (Page[User], error) {
+	if pageSize <= 0 {
+		return Page[User]{}, fmt.Errorf("page size must be positive, got %d", pageSize)
+	}
+
+	paramsHash := pageParamsHash(minage)
+	var cursor *UserListByAgeCursor
+	if pageToken != "" {
+		cursor = &UserListByAgeCursor{}
+		if err := decodePageToken(pageToken, "user", "list_by_age", paramsHash, cursor); err != nil {
+			return Page[User]{}, err
+		}
+	}
+
+	// One extra row tells whether another page exists.
+	entities, err := d.listByAgeWithCache(ctx, minage, cursor, pageSize+1)
+	if err != nil {
+		return Page[User]{}, err
+	}
+
+	page := Page[User]{Items: entities}
+	if len(entities) > pageSize {
+		page.Items = entities[:pageSize]
+		page.HasMore = true
+		next := newUserListByAgeCursor(page.Items, pageSize)
+		page.NextPageToken, err = encodePageToken("user", "list_by_age", paramsHash, next)
+		if err != nil {
+			return Page[User]{}, err
+		}
+	}
+
+	return page, nil
+}
+
+
+
+
+// UserListByStatusCursor points at the last User of a ListByStatus page. Pass it to ListByStatus to fetch the next page,
+// or nil for the first one.
+type UserListByStatusCursor struct {
+	Created time.Time
+	ID int64
+}
+
+// cacheKey identifies the page in list cache keys.
+func (c *UserListByStatusCursor) cacheKey() string {
+	if c == nil {
+		return "first"
+	}
+	return fmt.Sprintf("%v:%d", c.Created.UnixNano(), c.ID)
 }
 
+// newUserListByStatusCursor returns the cursor of the page following entities, or nil if it was the last page.
+func newUserListByStatusCursor(entities []*User, pageSize int) *UserListByStatusCursor {
+	if len(entities) == 0 || len(entities) < pageSize {
+		return nil
+	}
 
+	last := entities[len(entities)-1]
+	return &UserListByStatusCursor{Created: last.Created, ID: last.ID}
+}
 
-func (d *userRepository) ListById(ctx context.Context, startID int64, pageSize int) ([]*User, error) {
+// ListByStatus returns a page of Users ordered by created, starting after cursor,
+// and the cursor of the next page, which is nil once the last page is reached.
+func (d *userRepository) ListByStatus(ctx context.Context, status *UserStatus, cursor *UserListByStatusCursor, pageSize int) ([]*User, *UserListByStatusCursor, error) {
+	entities, err := d.listByStatusWithCache(ctx, status, cursor, pageSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entities, newUserListByStatusCursor(entities, pageSize), nil
+}
+
+func (d *userRepository) listByStatusWithCache(ctx context.Context, status *UserStatus, cursor *UserListByStatusCursor, pageSize int) ([]*User, error) {
     if d.configProvider.BlockedReads("user") {
         return nil, ErrOperationBlocked
     }
 
-	const operation = "list_by_id"
+	const operation = "list_by_status"
 	d.telemetryProvider.IncDALOperation("user", operation)
 
-    cacheKey := fmt.Sprintf("user_list_by_id:epoch_%d:%d:%d", d.getEpoch(), startID, pageSize)
+    cacheKey := fmt.Sprintf("user_list_by_status:epoch_%d:%v:%s:%d", d.getEpoch(), func() interface{} { if status == nil { return "<<null>>" }; return *status }(), cursor.cacheKey(), pageSize)
+    // Transactions bypass caches to see their own writes.
     val, found := d.listCache.Get(cacheKey)
-    if found {
+    if found && !inTx(ctx) {
         entityIDs, ok := val.([]int64)
         if !ok {
-            return nil, fmt.Errorf("userRepository.ListById: Cache returned wrong type; expected array ID type")
+            return nil, fmt.Errorf("userRepository.ListByStatus: Cache returned wrong type; expected array ID type")
         }
 
         var entities []*User
@@ -1164,8 +2950,8 @@ func (d *userRepository) ListById(ctx context.Context, startID int64, pageSize i
      d.telemetryProvider.IncCacheMiss("user", operation)       
 
     // 2) Fallback to DB
-    result, err := d.dbBreaker.Execute(func() (interface{}, error) {
-        return d.listById(ctx, startID, pageSize)
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+        return d.listByStatus(ctx, status, cursor, pageSize)
     })
 
     if err != nil {
@@ -1174,6 +2960,10 @@ func (d *userRepository) ListById(ctx context.Context, startID int64, pageSize i
     }
 
     entities := result.([]*User)
+    if inTx(ctx) {
+        return entities, nil
+    }
+
     // Store Cache. cacheKey = user_list_by_id:startID:pageSize.
 	// Stored is array of entity IDs.
 	var entityIDs []int64
@@ -1187,20 +2977,20 @@ func (d *userRepository) ListById(ctx context.Context, startID int64, pageSize i
     return entities, nil
 }
 
-func (d *userRepository) listById(ctx context.Context, startID int64, pageSize int) ([]*User, error) {
-    const operation = "list_by_id"
+func (d *userRepository) listByStatus(ctx context.Context, status *UserStatus, cursor *UserListByStatusCursor, pageSize int) ([]*User, error) {
+    const operation = "list_by_status"
 	dbStart := time.Now()
 
     var query string
 
-    // if startID is zero then query is different for pagination
-    if startID == 0 {
-        query = `SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at FROM users WHERE deleted_at IS NULL ORDER BY id LIMIT ?`
+    // the first page query is different for pagination
+    if cursor == nil {
+        query = `SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at FROM users WHERE (status = ?) AND deleted_at IS NULL ORDER BY created, id LIMIT ?`
     } else {
-        query = `SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at FROM users WHERE deleted_at IS NULL AND id > ? ORDER BY id LIMIT ?`
+        query = `SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at FROM users WHERE (status = ?) AND deleted_at IS NULL AND (created, id) > (?, ?) ORDER BY created, id LIMIT ?`
     }
 
-    db, dbErr := d.dbProvider.GetDatabase("user", false);
+    db, dbErr := databaseFor(ctx, d.dbProvider, "user", false)
     if dbErr != nil {
         d.telemetryProvider.IncDBError("user", operation)
         return nil, dbErr
@@ -1209,10 +2999,10 @@ func (d *userRepository) listById(ctx context.Context, startID int64, pageSize i
     var rows *sql.Rows
     var err error
 
-    if startID == 0 {
-	    rows, err = db.QueryContext(ctx, query, pageSize)
+    if cursor == nil {
+	    rows, err = db.QueryContext(ctx, query, status, pageSize)
     } else {
-        rows, err = db.QueryContext(ctx, query, startID, pageSize)
+        rows, err = db.QueryContext(ctx, query, status, cursor.Created, cursor.ID, pageSize)
     }
 
 	if err != nil {
@@ -1256,20 +3046,60 @@ func (d *userRepository) listById(ctx context.Context, startID int64, pageSize i
 
 
 
-func (d *userRepository) ListByBday(ctx context.Context, birthdate *time.Time, startID int64, pageSize int) ([]*User, error) {
+
+// UserListByStatusesCursor points at the last User of a ListByStatuses page. Pass it to ListByStatuses to fetch the next page,
+// or nil for the first one.
+type UserListByStatusesCursor struct {
+	Created time.Time
+	ID int64
+}
+
+// cacheKey identifies the page in list cache keys.
+func (c *UserListByStatusesCursor) cacheKey() string {
+	if c == nil {
+		return "first"
+	}
+	return fmt.Sprintf("%v:%d", c.Created.UnixNano(), c.ID)
+}
+
+// newUserListByStatusesCursor returns the cursor of the page following entities, or nil if it was the last page.
+func newUserListByStatusesCursor(entities []*User, pageSize int) *UserListByStatusesCursor {
+	if len(entities) == 0 || len(entities) < pageSize {
+		return nil
+	}
+
+	last := entities[len(entities)-1]
+	return &UserListByStatusesCursor{Created: last.Created, ID: last.ID}
+}
+
+// ListByStatuses returns a page of Users ordered by created, starting after cursor,
+// and the cursor of the next page, which is nil once the last page is reached.
+func (d *userRepository) ListByStatuses(ctx context.Context, statuses []UserStatus, cursor *UserListByStatusesCursor, pageSize int) ([]*User, *UserListByStatusesCursor, error) {
+	entities, err := d.listByStatusesWithCache(ctx, statuses, cursor, pageSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entities, newUserListByStatusesCursor(entities, pageSize), nil
+}
+
+func (d *userRepository) listByStatusesWithCache(ctx context.Context, statuses []UserStatus, cursor *UserListByStatusesCursor, pageSize int) ([]*User, error) {
     if d.configProvider.BlockedReads("user") {
         return nil, ErrOperationBlocked
     }
+    if err := checkInListSize("statuses", len(statuses)); err != nil {
+        return nil, err
+    }
 
-	const operation = "list_by_bday"
+	const operation = "list_by_statuses"
 	d.telemetryProvider.IncDALOperation("user", operation)
 
-    cacheKey := fmt.Sprintf("user_list_by_bday:epoch_%d:%v:%d:%d", d.getEpoch(), func() interface{} { if birthdate == nil { return "<<null>>" }; return *birthdate }(), startID, pageSize)
+    cacheKey := fmt.Sprintf("user_list_by_statuses:epoch_%d:%v:%s:%d", d.getEpoch(), inListKey(statuses), cursor.cacheKey(), pageSize)
+    // Transactions bypass caches to see their own writes.
     val, found := d.listCache.Get(cacheKey)
-    if found {
+    if found && !inTx(ctx) {
         entityIDs, ok := val.([]int64)
         if !ok {
-            return nil, fmt.Errorf("userRepository.ListByBday: Cache returned wrong type; expected array ID type")
+            return nil, fmt.Errorf("userRepository.ListByStatuses: Cache returned wrong type; expected array ID type")
         }
 
         var entities []*User
@@ -1300,8 +3130,8 @@ func (d *userRepository) ListByBday(ctx context.Context, birthdate *time.Time, s
      d.telemetryProvider.IncCacheMiss("user", operation)       
 
     // 2) Fallback to DB
-    result, err := d.dbBreaker.Execute(func() (interface{}, error) {
-        return d.listByBday(ctx, birthdate, startID, pageSize)
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+        return d.listByStatuses(ctx, statuses, cursor, pageSize)
     })
 
     if err != nil {
@@ -1310,6 +3140,10 @@ func (d *userRepository) ListByBday(ctx context.Context, birthdate *time.Time, s
     }
 
     entities := result.([]*User)
+    if inTx(ctx) {
+        return entities, nil
+    }
+
     // Store Cache. cacheKey = user_list_by_id:startID:pageSize.
 	// Stored is array of entity IDs.
 	var entityIDs []int64
@@ -1323,20 +3157,28 @@ func (d *userRepository) ListByBday(ctx context.Context, birthdate *time.Time, s
     return entities, nil
 }
 
-func (d *userRepository) listByBday(ctx context.Context, birthdate *time.Time, startID int64, pageSize int) ([]*User, error) {
-    const operation = "list_by_bday"
+func (d *userRepository) listByStatuses(ctx context.Context, statuses []UserStatus, cursor *UserListByStatusesCursor, pageSize int) ([]*User, error) {
+    const operation = "list_by_statuses"
 	dbStart := time.Now()
 
     var query string
 
-    // if startID is zero then query is different for pagination
-    if startID == 0 {
-        query = `SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at FROM users WHERE (birthdate < ?) AND deleted_at IS NULL ORDER BY birthdate DESC, id DESC LIMIT ?`
+    // the first page query is different for pagination
+    if cursor == nil {
+        query = `SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at FROM users WHERE (status IN (?...)) AND deleted_at IS NULL ORDER BY created, id LIMIT ?`
+    } else {
+        query = `SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at FROM users WHERE (status IN (?...)) AND deleted_at IS NULL AND (created, id) > (?, ?) ORDER BY created, id LIMIT ?`
+    }
+
+    // Expand the slice parameters into a placeholder per value
+    var args []interface{}
+    if cursor == nil {
+        query, args = expandInLists(query, inValues(statuses), pageSize)
     } else {
-        query = `SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at FROM users WHERE (birthdate < ?) AND deleted_at IS NULL AND id < ? ORDER BY birthdate DESC, id DESC LIMIT ?`
+        query, args = expandInLists(query, inValues(statuses), cursor.Created, cursor.ID, pageSize)
     }
 
-    db, dbErr := d.dbProvider.GetDatabase("user", false);
+    db, dbErr := databaseFor(ctx, d.dbProvider, "user", false)
     if dbErr != nil {
         d.telemetryProvider.IncDBError("user", operation)
         return nil, dbErr
@@ -1345,11 +3187,7 @@ func (d *userRepository) listByBday(ctx context.Context, birthdate *time.Time, s
     var rows *sql.Rows
     var err error
 
-    if startID == 0 {
-	    rows, err = db.QueryContext(ctx, query, birthdate, pageSize)
-    } else {
-        rows, err = db.QueryContext(ctx, query, birthdate, startID, pageSize)
-    }
+    rows, err = db.QueryContext(ctx, query, args...)
 
 	if err != nil {
         d.telemetryProvider.IncDBError("user", operation)
@@ -1392,20 +3230,100 @@ func (d *userRepository) listByBday(ctx context.Context, birthdate *time.Time, s
 
 
 
-func (d *userRepository) ListByAge(ctx context.Context, minage int8, startID int64, pageSize int) ([]*User, error) {
+
+// UserSearchFilter holds the filters of Search and CountSearch. An optional filter is left out of the query
+// while any of its fields is nil.
+type UserSearchFilter struct {
+	Status *UserStatus // optional: status = :status
+	MinAge *int8 // optional: age >= :min_age
+	MaxAge *int8 // optional: age <= :max_age
+}
+
+// where returns the predicates of the active filters and their query arguments.
+func (f UserSearchFilter) where() ([]string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if f.Status != nil {
+		conditions = append(conditions, "(status = ?)")
+		args = append(args, *f.Status)
+	}
+	if f.MinAge != nil {
+		conditions = append(conditions, "(age >= ?)")
+		args = append(args, *f.MinAge)
+	}
+	if f.MaxAge != nil {
+		conditions = append(conditions, "(age <= ?)")
+		args = append(args, *f.MaxAge)
+	}
+	return conditions, args
+}
+
+// cacheKey identifies the active filters and their values in list and count cache keys.
+func (f UserSearchFilter) cacheKey() string {
+	key := ""
+	if f.Status != nil {
+		key += fmt.Sprintf(":status=%v", *f.Status)
+	}
+	if f.MinAge != nil {
+		key += fmt.Sprintf(":min_age=%v", *f.MinAge)
+	}
+	if f.MaxAge != nil {
+		key += fmt.Sprintf(":max_age=%v", *f.MaxAge)
+	}
+	return key
+}
+
+
+// UserSearchCursor points at the last User of a Search page. Pass it to Search to fetch the next page,
+// or nil for the first one.
+type UserSearchCursor struct {
+	Created time.Time
+	ID int64
+}
+
+// cacheKey identifies the page in list cache keys.
+func (c *UserSearchCursor) cacheKey() string {
+	if c == nil {
+		return "first"
+	}
+	return fmt.Sprintf("%v:%d", c.Created.UnixNano(), c.ID)
+}
+
+// newUserSearchCursor returns the cursor of the page following entities, or nil if it was the last page.
+func newUserSearchCursor(entities []*User, pageSize int) *UserSearchCursor {
+	if len(entities) == 0 || len(entities) < pageSize {
+		return nil
+	}
+
+	last := entities[len(entities)-1]
+	return &UserSearchCursor{Created: last.Created, ID: last.ID}
+}
+
+// Search returns a page of Users ordered by created, starting after cursor,
+// and the cursor of the next page, which is nil once the last page is reached.
+func (d *userRepository) Search(ctx context.Context, filter UserSearchFilter, cursor *UserSearchCursor, pageSize int) ([]*User, *UserSearchCursor, error) {
+	entities, err := d.searchWithCache(ctx, filter, cursor, pageSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entities, newUserSearchCursor(entities, pageSize), nil
+}
+
+func (d *userRepository) searchWithCache(ctx context.Context, filter UserSearchFilter, cursor *UserSearchCursor, pageSize int) ([]*User, error) {
     if d.configProvider.BlockedReads("user") {
         return nil, ErrOperationBlocked
     }
 
-	const operation = "list_by_age"
+	const operation = "search"
 	d.telemetryProvider.IncDALOperation("user", operation)
 
-    cacheKey := fmt.Sprintf("user_list_by_age:epoch_%d:%v:%d:%d", d.getEpoch(), minage, startID, pageSize)
+    cacheKey := fmt.Sprintf("user_search:epoch_%d:%s:%s:%d", d.getEpoch(), filter.cacheKey(), cursor.cacheKey(), pageSize)
+    // Transactions bypass caches to see their own writes.
     val, found := d.listCache.Get(cacheKey)
-    if found {
+    if found && !inTx(ctx) {
         entityIDs, ok := val.([]int64)
         if !ok {
-            return nil, fmt.Errorf("userRepository.ListByAge: Cache returned wrong type; expected array ID type")
+            return nil, fmt.Errorf("userRepository.Search: Cache returned wrong type; expected array ID type")
         }
 
         var entities []*User
@@ -1436,8 +3354,8 @@ func (d *userRepository) ListByAge(ctx context.Context, minage int8, startID int
      d.telemetryProvider.IncCacheMiss("user", operation)       
 
     // 2) Fallback to DB
-    result, err := d.dbBreaker.Execute(func() (interface{}, error) {
-        return d.listByAge(ctx, minage, startID, pageSize)
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+        return d.search(ctx, filter, cursor, pageSize)
     })
 
     if err != nil {
@@ -1446,6 +3364,10 @@ func (d *userRepository) ListByAge(ctx context.Context, minage int8, startID int
     }
 
     entities := result.([]*User)
+    if inTx(ctx) {
+        return entities, nil
+    }
+
     // Store Cache. cacheKey = user_list_by_id:startID:pageSize.
 	// Stored is array of entity IDs.
 	var entityIDs []int64
@@ -1459,20 +3381,29 @@ func (d *userRepository) ListByAge(ctx context.Context, minage int8, startID int
     return entities, nil
 }
 
-func (d *userRepository) listByAge(ctx context.Context, minage int8, startID int64, pageSize int) ([]*User, error) {
-    const operation = "list_by_age"
+func (d *userRepository) search(ctx context.Context, filter UserSearchFilter, cursor *UserSearchCursor, pageSize int) ([]*User, error) {
+    const operation = "search"
 	dbStart := time.Now()
 
     var query string
 
-    // if startID is zero then query is different for pagination
-    if startID == 0 {
-        query = `SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at FROM users WHERE (age = ? OR age > ?) AND deleted_at IS NULL ORDER BY created, id LIMIT ?`
-    } else {
-        query = `SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at FROM users WHERE (age = ? OR age > ?) AND deleted_at IS NULL AND id > ? ORDER BY created, id LIMIT ?`
+    // Only the active filters make it into the query
+    conditions, args := filter.where()
+    conditions = append(conditions, "deleted_at IS NULL")
+    if cursor != nil {
+        conditions = append(conditions, "(created, id) > (?, ?)")
+        args = append(args, cursor.Created, cursor.ID)
     }
+    args = append(args, pageSize)
 
-    db, dbErr := d.dbProvider.GetDatabase("user", false);
+    query = `SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at FROM users`
+    if len(conditions) > 0 {
+        query += " WHERE " + strings.Join(conditions, " AND ")
+    }
+    query += ` ORDER BY created, id LIMIT ?`
+    query, args = expandInLists(query, args...)
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "user", false)
     if dbErr != nil {
         d.telemetryProvider.IncDBError("user", operation)
         return nil, dbErr
@@ -1481,11 +3412,7 @@ func (d *userRepository) listByAge(ctx context.Context, minage int8, startID int
     var rows *sql.Rows
     var err error
 
-    if startID == 0 {
-	    rows, err = db.QueryContext(ctx, query, minage, minage, pageSize)
-    } else {
-        rows, err = db.QueryContext(ctx, query, minage, minage, startID, pageSize)
-    }
+    rows, err = db.QueryContext(ctx, query, args...)
 
 	if err != nil {
         d.telemetryProvider.IncDBError("user", operation)
@@ -1528,157 +3455,213 @@ func (d *userRepository) listByAge(ctx context.Context, minage int8, startID int
 
 
 
-func (d *userRepository) ListByStatus(ctx context.Context, status *string, startID int64, pageSize int) ([]*User, error) {
-    if d.configProvider.BlockedReads("user") {
-        return nil, ErrOperationBlocked
-    }
+// SearchPage returns a page of Search with the opaque token of the next page.
+// Pass an empty pageToken for the first page. A token only continues the query it was issued for,
+// otherwise ErrInvalidPageToken is returned.
+func (d *userRepository) SearchPage(ctx context.Context, filter UserSearchFilter, pageToken string, pageSize int) (Page[User], error) {
+	if pageSize <= 0 {
+		return Page[User]{}, fmt.Errorf("page size must be positive, got %d", pageSize)
+	}
 
-	const operation = "list_by_status"
+	paramsHash := pageParamsHash(filter)
+	var cursor *UserSearchCursor
+	if pageToken != "" {
+		cursor = &UserSearchCursor{}
+		if err := decodePageToken(pageToken, "user", "search", paramsHash, cursor); err != nil {
+			return Page[User]{}, err
+		}
+	}
+
+	// One extra row tells whether another page exists.
+	entities, err := d.searchWithCache(ctx, filter, cursor, pageSize+1)
+	if err != nil {
+		return Page[User]{}, err
+	}
+
+	page := Page[User]{Items: entities}
+	if len(entities) > pageSize {
+		page.Items = entities[:pageSize]
+		page.HasMore = true
+		next := newUserSearchCursor(page.Items, pageSize)
+		page.NextPageToken, err = encodePageToken("user", "search", paramsHash, next)
+		if err != nil {
+			return Page[User]{}, err
+		}
+	}
+
+	return page, nil
+}
+
+
+
+// Count function for the specific list
+func (d *userRepository) CountListById(ctx context.Context, ) (int64, error) {
+	if d.configProvider.BlockedReads("user") {
+		return 0, ErrOperationBlocked
+	}
+
+	const operation = "count_list_by_id"
 	d.telemetryProvider.IncDALOperation("user", operation)
+	
+	cacheKey := fmt.Sprintf("user_count_list_by_id:epoch_%d", d.getEpoch())
+	// Transactions bypass caches to see their own writes.
+	val, found := d.countCache.Get(cacheKey)
+	if found && !inTx(ctx) {
+		count, ok := val.(int64)
+		if !ok {
+			return 0, fmt.Errorf("userRepository.CountListById: Cache returned wrong type; expected int64")
+		}
 
-    cacheKey := fmt.Sprintf("user_list_by_status:epoch_%d:%v:%d:%d", d.getEpoch(), func() interface{} { if status == nil { return "<<null>>" }; return *status }(), startID, pageSize)
-    val, found := d.listCache.Get(cacheKey)
-    if found {
-        entityIDs, ok := val.([]int64)
-        if !ok {
-            return nil, fmt.Errorf("userRepository.ListByStatus: Cache returned wrong type; expected array ID type")
-        }
+		d.telemetryProvider.IncCacheHit("user", operation)        
+		return count, nil
+	}
 
-        var entities []*User
-        missingEntries := false
-        for _, id := range entityIDs {
-            entity, err := d.getByIDCached(id)
-            if err != nil {
-                // failed using cache
-                return nil, err
-            }
+	// Otherwise, it's a cache miss or decode error
+	 d.telemetryProvider.IncCacheMiss("user", operation)       
 
-            if entity == nil {
-                // we have one or more missing entity in cache somehow. reload the whole list.
-                missingEntries = true
-                break
-            }
+	// 2) Fallback to DB
+	count, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return d.countListById(ctx, )
+	})
 
-            entities = append(entities, entity)
-        }
+	if err != nil {
+		// DB also failed
+		return 0, err
+	}
 
-        if !missingEntries {
-            d.telemetryProvider.IncCacheHit("user", operation)        
-            return entities, nil
-        }
-    }
+	if !inTx(ctx) {
+		d.countCache.Set(cacheKey, count, time.Second*60)
+	}
 
-    // Otherwise, it's a cache miss or decode error
-     d.telemetryProvider.IncCacheMiss("user", operation)       
+	return count.(int64), nil
+}
 
-    // 2) Fallback to DB
-    result, err := d.dbBreaker.Execute(func() (interface{}, error) {
-        return d.listByStatus(ctx, status, startID, pageSize)
-    })
+func (d *userRepository) countListById(ctx context.Context, ) (int64, error) {
+	const operation = "count_list_by_id"
+	dbStart := time.Now()
 
-    if err != nil {
-        // DB also failed
-        return nil, err
-    }
+	// if startID is zero then query is different for pagination
+	query := `SELECT count(*) FROM users WHERE deleted_at IS NULL`
 
-    entities := result.([]*User)
-    // Store Cache. cacheKey = user_list_by_id:startID:pageSize.
-	// Stored is array of entity IDs.
-	var entityIDs []int64
-	for _, entity := range entities {
-		entityIDs = append(entityIDs, entity.ID)
-        // cache each entity individualy
-        d.setCached(entity)
+	db, dbErr := databaseFor(ctx, d.dbProvider, "user", false)
+	if dbErr != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+		return 0, dbErr
+	}
+
+	var err error
+	var count int64
+
+	row := db.QueryRowContext(ctx, query, )
+
+	err = row.Scan(
+		&count,
+	)
+
+	if err != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+		return 0, fmt.Errorf("failed to query users: %w", err)
+	}
+
+	d.telemetryProvider.IncDBRequest("user", operation)	
+	d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(dbStart).Seconds())	
+	return count, nil
+}
+
+
+
+// Count function for the specific list
+func (d *userRepository) CountListByBday(ctx context.Context, birthdate *time.Time) (int64, error) {
+	if d.configProvider.BlockedReads("user") {
+		return 0, ErrOperationBlocked
+	}
+
+	const operation = "count_list_by_bday"
+	d.telemetryProvider.IncDALOperation("user", operation)
+	
+	cacheKey := fmt.Sprintf("user_count_list_by_bday:epoch_%d:%v", d.getEpoch(), func() interface{} { if birthdate == nil { return "<<null>>" }; return *birthdate }())
+	// Transactions bypass caches to see their own writes.
+	val, found := d.countCache.Get(cacheKey)
+	if found && !inTx(ctx) {
+		count, ok := val.(int64)
+		if !ok {
+			return 0, fmt.Errorf("userRepository.CountListByBday: Cache returned wrong type; expected int64")
+		}
+
+		d.telemetryProvider.IncCacheHit("user", operation)        
+		return count, nil
+	}
+
+	// Otherwise, it's a cache miss or decode error
+	 d.telemetryProvider.IncCacheMiss("user", operation)       
+
+	// 2) Fallback to DB
+	count, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return d.countListByBday(ctx, birthdate)
+	})
+
+	if err != nil {
+		// DB also failed
+		return 0, err
+	}
+
+	if !inTx(ctx) {
+		d.countCache.Set(cacheKey, count, time.Second*60)
 	}
-	d.listCache.Set(cacheKey, entityIDs, time.Second*60)
 
-    return entities, nil
+	return count.(int64), nil
 }
 
-func (d *userRepository) listByStatus(ctx context.Context, status *string, startID int64, pageSize int) ([]*User, error) {
-    const operation = "list_by_status"
+func (d *userRepository) countListByBday(ctx context.Context, birthdate *time.Time) (int64, error) {
+	const operation = "count_list_by_bday"
 	dbStart := time.Now()
 
-    var query string
+	// if startID is zero then query is different for pagination
+	query := `SELECT count(*) FROM users WHERE (birthdate < ?) AND deleted_at IS NULL`
 
-    // if startID is zero then query is different for pagination
-    if startID == 0 {
-        query = `SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at FROM users WHERE (status = ?) AND deleted_at IS NULL ORDER BY created, id LIMIT ?`
-    } else {
-        query = `SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at FROM users WHERE (status = ?) AND deleted_at IS NULL AND id > ? ORDER BY created, id LIMIT ?`
-    }
+	db, dbErr := databaseFor(ctx, d.dbProvider, "user", false)
+	if dbErr != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+		return 0, dbErr
+	}
 
-    db, dbErr := d.dbProvider.GetDatabase("user", false);
-    if dbErr != nil {
-        d.telemetryProvider.IncDBError("user", operation)
-        return nil, dbErr
-    }
+	var err error
+	var count int64
 
-    var rows *sql.Rows
-    var err error
+	row := db.QueryRowContext(ctx, query, birthdate)
 
-    if startID == 0 {
-	    rows, err = db.QueryContext(ctx, query, status, pageSize)
-    } else {
-        rows, err = db.QueryContext(ctx, query, status, startID, pageSize)
-    }
+	err = row.Scan(
+		&count,
+	)
 
 	if err != nil {
-        d.telemetryProvider.IncDBError("user", operation)
-		return nil, fmt.Errorf("failed to query users: %w", err)
-	}
-	defer rows.Close()
-
-	var entities []*User
-	for rows.Next() {
-		var entity User
-		err := rows.Scan(
-			&entity.ID,
-            &entity.Version,
-            &entity.Age,
-            &entity.Birthdate,
-            &entity.Email,
-            &entity.Meta,
-            &entity.Status,
-            &entity.Uid,
-            &entity.Created,
-            &entity.Updated,
-            &entity.DeletedAt,
-		)
-		if err != nil {
-            d.telemetryProvider.IncDBError("user", operation)
-			return nil, fmt.Errorf("failed to scan User: %w", err)
-		}
-		entities = append(entities, &entity)
-	}
-
-	if err = rows.Err(); err != nil {
-        d.telemetryProvider.IncDBError("user", operation)
-		return nil, fmt.Errorf("rows error: %w", err)
+		d.telemetryProvider.IncDBError("user", operation)
+		return 0, fmt.Errorf("failed to query users: %w", err)
 	}
 
-    d.telemetryProvider.IncDBRequest("user", operation)	
-    d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(dbStart).Seconds())	
-	return entities, nil
+	d.telemetryProvider.IncDBRequest("user", operation)	
+	d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(dbStart).Seconds())	
+	return count, nil
 }
 
 
 
 // Count function for the specific list
-func (d *userRepository) CountListById(ctx context.Context, ) (int64, error) {
+func (d *userRepository) CountListByAge(ctx context.Context, minage int8) (int64, error) {
 	if d.configProvider.BlockedReads("user") {
 		return 0, ErrOperationBlocked
 	}
 
-	const operation = "count_list_by_id"
+	const operation = "count_list_by_age"
 	d.telemetryProvider.IncDALOperation("user", operation)
 	
-	cacheKey := fmt.Sprintf("user_count_list_by_id:epoch_%d", d.getEpoch())
+	cacheKey := fmt.Sprintf("user_count_list_by_age:epoch_%d:%v", d.getEpoch(), minage)
+	// Transactions bypass caches to see their own writes.
 	val, found := d.countCache.Get(cacheKey)
-	if found {
+	if found && !inTx(ctx) {
 		count, ok := val.(int64)
 		if !ok {
-			return 0, fmt.Errorf("userRepository.CountListById: Cache returned wrong type; expected int64")
+			return 0, fmt.Errorf("userRepository.CountListByAge: Cache returned wrong type; expected int64")
 		}
 
 		d.telemetryProvider.IncCacheHit("user", operation)        
@@ -1689,8 +3672,8 @@ func (d *userRepository) CountListById(ctx context.Context, ) (int64, error) {
 	 d.telemetryProvider.IncCacheMiss("user", operation)       
 
 	// 2) Fallback to DB
-	count, err := d.dbBreaker.Execute(func() (interface{}, error) {
-		return d.countListById(ctx, )
+	count, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return d.countListByAge(ctx, minage)
 	})
 
 	if err != nil {
@@ -1698,19 +3681,21 @@ func (d *userRepository) CountListById(ctx context.Context, ) (int64, error) {
 		return 0, err
 	}
 
-	d.countCache.Set(cacheKey, count, time.Second*60)
+	if !inTx(ctx) {
+		d.countCache.Set(cacheKey, count, time.Second*60)
+	}
 
 	return count.(int64), nil
 }
 
-func (d *userRepository) countListById(ctx context.Context, ) (int64, error) {
-	const operation = "count_list_by_id"
+func (d *userRepository) countListByAge(ctx context.Context, minage int8) (int64, error) {
+	const operation = "count_list_by_age"
 	dbStart := time.Now()
 
 	// if startID is zero then query is different for pagination
-	query := `SELECT count(*) FROM users WHERE deleted_at IS NULL`
+	query := `SELECT count(*) FROM users WHERE (age = ? OR age > ?) AND deleted_at IS NULL`
 
-	db, dbErr := d.dbProvider.GetDatabase("user", false)
+	db, dbErr := databaseFor(ctx, d.dbProvider, "user", false)
 	if dbErr != nil {
 		d.telemetryProvider.IncDBError("user", operation)
 		return 0, dbErr
@@ -1719,7 +3704,7 @@ func (d *userRepository) countListById(ctx context.Context, ) (int64, error) {
 	var err error
 	var count int64
 
-	row := db.QueryRowContext(ctx, query, )
+	row := db.QueryRowContext(ctx, query, minage, minage)
 
 	err = row.Scan(
 		&count,
@@ -1738,20 +3723,21 @@ func (d *userRepository) countListById(ctx context.Context, ) (int64, error) {
 
 
 // Count function for the specific list
-func (d *userRepository) CountListByBday(ctx context.Context, birthdate *time.Time) (int64, error) {
+func (d *userRepository) CountListByStatus(ctx context.Context, status *UserStatus) (int64, error) {
 	if d.configProvider.BlockedReads("user") {
 		return 0, ErrOperationBlocked
 	}
 
-	const operation = "count_list_by_bday"
+	const operation = "count_list_by_status"
 	d.telemetryProvider.IncDALOperation("user", operation)
 	
-	cacheKey := fmt.Sprintf("user_count_list_by_bday:epoch_%d:%v", d.getEpoch(), func() interface{} { if birthdate == nil { return "<<null>>" }; return *birthdate }())
+	cacheKey := fmt.Sprintf("user_count_list_by_status:epoch_%d:%v", d.getEpoch(), func() interface{} { if status == nil { return "<<null>>" }; return *status }())
+	// Transactions bypass caches to see their own writes.
 	val, found := d.countCache.Get(cacheKey)
-	if found {
+	if found && !inTx(ctx) {
 		count, ok := val.(int64)
 		if !ok {
-			return 0, fmt.Errorf("userRepository.CountListByBday: Cache returned wrong type; expected int64")
+			return 0, fmt.Errorf("userRepository.CountListByStatus: Cache returned wrong type; expected int64")
 		}
 
 		d.telemetryProvider.IncCacheHit("user", operation)        
@@ -1762,8 +3748,8 @@ func (d *userRepository) CountListByBday(ctx context.Context, birthdate *time.Ti
 	 d.telemetryProvider.IncCacheMiss("user", operation)       
 
 	// 2) Fallback to DB
-	count, err := d.dbBreaker.Execute(func() (interface{}, error) {
-		return d.countListByBday(ctx, birthdate)
+	count, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return d.countListByStatus(ctx, status)
 	})
 
 	if err != nil {
@@ -1771,19 +3757,21 @@ func (d *userRepository) CountListByBday(ctx context.Context, birthdate *time.Ti
 		return 0, err
 	}
 
-	d.countCache.Set(cacheKey, count, time.Second*60)
+	if !inTx(ctx) {
+		d.countCache.Set(cacheKey, count, time.Second*60)
+	}
 
 	return count.(int64), nil
 }
 
-func (d *userRepository) countListByBday(ctx context.Context, birthdate *time.Time) (int64, error) {
-	const operation = "count_list_by_bday"
+func (d *userRepository) countListByStatus(ctx context.Context, status *UserStatus) (int64, error) {
+	const operation = "count_list_by_status"
 	dbStart := time.Now()
 
 	// if startID is zero then query is different for pagination
-	query := `SELECT count(*) FROM users WHERE (birthdate < ?) AND deleted_at IS NULL`
+	query := `SELECT count(*) FROM users WHERE (status = ?) AND deleted_at IS NULL`
 
-	db, dbErr := d.dbProvider.GetDatabase("user", false)
+	db, dbErr := databaseFor(ctx, d.dbProvider, "user", false)
 	if dbErr != nil {
 		d.telemetryProvider.IncDBError("user", operation)
 		return 0, dbErr
@@ -1792,7 +3780,7 @@ func (d *userRepository) countListByBday(ctx context.Context, birthdate *time.Ti
 	var err error
 	var count int64
 
-	row := db.QueryRowContext(ctx, query, birthdate)
+	row := db.QueryRowContext(ctx, query, status)
 
 	err = row.Scan(
 		&count,
@@ -1811,20 +3799,24 @@ func (d *userRepository) countListByBday(ctx context.Context, birthdate *time.Ti
 
 
 // Count function for the specific list
-func (d *userRepository) CountListByAge(ctx context.Context, minage int8) (int64, error) {
+func (d *userRepository) CountListByStatuses(ctx context.Context, statuses []UserStatus) (int64, error) {
 	if d.configProvider.BlockedReads("user") {
 		return 0, ErrOperationBlocked
 	}
+	if err := checkInListSize("statuses", len(statuses)); err != nil {
+		return 0, err
+	}
 
-	const operation = "count_list_by_age"
+	const operation = "count_list_by_statuses"
 	d.telemetryProvider.IncDALOperation("user", operation)
 	
-	cacheKey := fmt.Sprintf("user_count_list_by_age:epoch_%d:%v", d.getEpoch(), minage)
+	cacheKey := fmt.Sprintf("user_count_list_by_statuses:epoch_%d:%v", d.getEpoch(), inListKey(statuses))
+	// Transactions bypass caches to see their own writes.
 	val, found := d.countCache.Get(cacheKey)
-	if found {
+	if found && !inTx(ctx) {
 		count, ok := val.(int64)
 		if !ok {
-			return 0, fmt.Errorf("userRepository.CountListByAge: Cache returned wrong type; expected int64")
+			return 0, fmt.Errorf("userRepository.CountListByStatuses: Cache returned wrong type; expected int64")
 		}
 
 		d.telemetryProvider.IncCacheHit("user", operation)        
@@ -1835,8 +3827,8 @@ func (d *userRepository) CountListByAge(ctx context.Context, minage int8) (int64
 	 d.telemetryProvider.IncCacheMiss("user", operation)       
 
 	// 2) Fallback to DB
-	count, err := d.dbBreaker.Execute(func() (interface{}, error) {
-		return d.countListByAge(ctx, minage)
+	count, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return d.countListByStatuses(ctx, statuses)
 	})
 
 	if err != nil {
@@ -1844,19 +3836,22 @@ func (d *userRepository) CountListByAge(ctx context.Context, minage int8) (int64
 		return 0, err
 	}
 
-	d.countCache.Set(cacheKey, count, time.Second*60)
+	if !inTx(ctx) {
+		d.countCache.Set(cacheKey, count, time.Second*60)
+	}
 
 	return count.(int64), nil
 }
 
-func (d *userRepository) countListByAge(ctx context.Context, minage int8) (int64, error) {
-	const operation = "count_list_by_age"
+func (d *userRepository) countListByStatuses(ctx context.Context, statuses []UserStatus) (int64, error) {
+	const operation = "count_list_by_statuses"
 	dbStart := time.Now()
 
 	// if startID is zero then query is different for pagination
-	query := `SELECT count(*) FROM users WHERE (age = ? OR age > ?) AND deleted_at IS NULL`
+	query := `SELECT count(*) FROM users WHERE (status IN (?...)) AND deleted_at IS NULL`
+	query, args := expandInLists(query, inValues(statuses))
 
-	db, dbErr := d.dbProvider.GetDatabase("user", false)
+	db, dbErr := databaseFor(ctx, d.dbProvider, "user", false)
 	if dbErr != nil {
 		d.telemetryProvider.IncDBError("user", operation)
 		return 0, dbErr
@@ -1865,7 +3860,7 @@ func (d *userRepository) countListByAge(ctx context.Context, minage int8) (int64
 	var err error
 	var count int64
 
-	row := db.QueryRowContext(ctx, query, minage, minage)
+	row := db.QueryRowContext(ctx, query, args...)
 
 	err = row.Scan(
 		&count,
@@ -1884,20 +3879,21 @@ func (d *userRepository) countListByAge(ctx context.Context, minage int8) (int64
 
 
 // Count function for the specific list
-func (d *userRepository) CountListByStatus(ctx context.Context, status *string) (int64, error) {
+func (d *userRepository) CountSearch(ctx context.Context, filter UserSearchFilter) (int64, error) {
 	if d.configProvider.BlockedReads("user") {
 		return 0, ErrOperationBlocked
 	}
 
-	const operation = "count_list_by_status"
+	const operation = "count_search"
 	d.telemetryProvider.IncDALOperation("user", operation)
 	
-	cacheKey := fmt.Sprintf("user_count_list_by_status:epoch_%d:%v", d.getEpoch(), func() interface{} { if status == nil { return "<<null>>" }; return *status }())
+	cacheKey := fmt.Sprintf("user_count_search:epoch_%d:%s", d.getEpoch(), filter.cacheKey())
+	// Transactions bypass caches to see their own writes.
 	val, found := d.countCache.Get(cacheKey)
-	if found {
+	if found && !inTx(ctx) {
 		count, ok := val.(int64)
 		if !ok {
-			return 0, fmt.Errorf("userRepository.CountListByStatus: Cache returned wrong type; expected int64")
+			return 0, fmt.Errorf("userRepository.CountSearch: Cache returned wrong type; expected int64")
 		}
 
 		d.telemetryProvider.IncCacheHit("user", operation)        
@@ -1908,8 +3904,8 @@ func (d *userRepository) CountListByStatus(ctx context.Context, status *string)
 	 d.telemetryProvider.IncCacheMiss("user", operation)       
 
 	// 2) Fallback to DB
-	count, err := d.dbBreaker.Execute(func() (interface{}, error) {
-		return d.countListByStatus(ctx, status)
+	count, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return d.countSearch(ctx, filter)
 	})
 
 	if err != nil {
@@ -1917,41 +3913,326 @@ func (d *userRepository) CountListByStatus(ctx context.Context, status *string)
 		return 0, err
 	}
 
-	d.countCache.Set(cacheKey, count, time.Second*60)
+	if !inTx(ctx) {
+		d.countCache.Set(cacheKey, count, time.Second*60)
+	}
+
+	return count.(int64), nil
+}
+
+func (d *userRepository) countSearch(ctx context.Context, filter UserSearchFilter) (int64, error) {
+	const operation = "count_search"
+	dbStart := time.Now()
+
+	// Only the active filters make it into the query
+	conditions, args := filter.where()
+	conditions = append(conditions, "deleted_at IS NULL")
+
+	query := `SELECT count(*) FROM users`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query, args = expandInLists(query, args...)
+
+	db, dbErr := databaseFor(ctx, d.dbProvider, "user", false)
+	if dbErr != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+		return 0, dbErr
+	}
+
+	var err error
+	var count int64
+
+	row := db.QueryRowContext(ctx, query, args...)
+
+	err = row.Scan(
+		&count,
+	)
+
+	if err != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+		return 0, fmt.Errorf("failed to query users: %w", err)
+	}
+
+	d.telemetryProvider.IncDBRequest("user", operation)	
+	d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(dbStart).Seconds())	
+	return count, nil
+}
+
+
+
+
+// IterateAll walks every User in ID order, a batch at a time, on the read database. It bypasses the
+// caches and leaves them untouched, so backfills and exports don't evict hot entries.
+func (d *userRepository) IterateAll(ctx context.Context, opts IterateOptions) iter.Seq2[*User, error] {
+    return iterateBatches(ctx, opts, func(after *User, batchSize int) ([]*User, error) {
+        if d.configProvider.BlockedReads("user") {
+            return nil, ErrOperationBlocked
+        }
+        d.telemetryProvider.IncDALOperation("user", "iterate_all")
+
+        startID := opts.StartAfterID
+        if after != nil {
+            startID = after.ID
+        }
+
+        result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+            return d.iterateAll(ctx, startID, batchSize)
+        })
+        if err != nil {
+            return nil, err
+        }
+        return result.([]*User), nil
+    })
+}
+
+func (d *userRepository) iterateAll(ctx context.Context, startID int64, batchSize int) ([]*User, error) {
+    const operation = "iterate_all"
+    dbStart := time.Now()
+
+    query := `SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at FROM users WHERE deleted_at IS NULL AND id > ? ORDER BY id LIMIT ?`
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "user", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return nil, dbErr
+    }
+
+    rows, err := db.QueryContext(ctx, query, startID, batchSize)
+    if err != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return nil, fmt.Errorf("failed to iterate users: %w", err)
+    }
+    defer rows.Close()
+
+    var entities []*User
+    for rows.Next() {
+        var entity User
+        err := rows.Scan(
+            &entity.ID,
+            &entity.Version,
+            &entity.Age,
+            &entity.Birthdate,
+            &entity.Email,
+            &entity.Meta,
+            &entity.Status,
+            &entity.Uid,
+            &entity.Created,
+            &entity.Updated,
+            &entity.DeletedAt,
+        )
+        if err != nil {
+            d.telemetryProvider.IncDBError("user", operation)
+            return nil, fmt.Errorf("failed to scan User: %w", err)
+        }
+        entities = append(entities, &entity)
+    }
+
+    if err = rows.Err(); err != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return nil, fmt.Errorf("rows error: %w", err)
+    }
+
+    d.telemetryProvider.IncDBRequest("user", operation)
+    d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(dbStart).Seconds())
+    return entities, nil
+}
+
+
+
+// IterateByListById walks every User of ListById in its order, a batch at a time, on the read
+// database. It bypasses the caches and leaves them untouched, so backfills and exports don't evict hot entries.
+func (d *userRepository) IterateByListById(ctx context.Context, opts IterateOptions) iter.Seq2[*User, error] {
+    return iterateBatches(ctx, opts, func(after *User, batchSize int) ([]*User, error) {
+        if d.configProvider.BlockedReads("user") {
+            return nil, ErrOperationBlocked
+        }
+        d.telemetryProvider.IncDALOperation("user", "iterate_by_list_by_id")
+
+        startID := opts.StartAfterID
+        if after != nil {
+            startID = after.ID
+        }
+
+        result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+            return d.listById(ctx, startID, batchSize)
+        })
+        if err != nil {
+            return nil, err
+        }
+        return result.([]*User), nil
+    })
+}
+
+
+
+// IterateByListByBday walks every User of ListByBday in its order, a batch at a time, on the read
+// database. It bypasses the caches and leaves them untouched, so backfills and exports don't evict hot entries.
+func (d *userRepository) IterateByListByBday(ctx context.Context, birthdate *time.Time, opts IterateOptions) iter.Seq2[*User, error] {
+    return iterateBatches(ctx, opts, func(after *User, batchSize int) ([]*User, error) {
+        if d.configProvider.BlockedReads("user") {
+            return nil, ErrOperationBlocked
+        }
+        d.telemetryProvider.IncDALOperation("user", "iterate_by_list_by_bday")
+
+        var cursor *UserListByBdayCursor
+        if after != nil {
+            cursor = newUserListByBdayCursor([]*User{after}, 1)
+        } else if opts.StartAfterID != 0 {
+            // Continue after the position of the entity in the list
+            entity, err := d.getByID(ctx, opts.StartAfterID)
+            if err != nil {
+                return nil, fmt.Errorf("failed to resume ListByBday after User %d: %w", opts.StartAfterID, err)
+            }
+            cursor = newUserListByBdayCursor([]*User{entity}, 1)
+            if cursor == nil {
+                return nil, fmt.Errorf("failed to resume ListByBday after User %d: %w", opts.StartAfterID, ErrNotFound)
+            }
+        }
+
+        result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+            return d.listByBday(ctx, birthdate, cursor, batchSize)
+        })
+        if err != nil {
+            return nil, err
+        }
+        return result.([]*User), nil
+    })
+}
+
+
+
+// IterateByListByAge walks every User of ListByAge in its order, a batch at a time, on the read
+// database. It bypasses the caches and leaves them untouched, so backfills and exports don't evict hot entries.
+func (d *userRepository) IterateByListByAge(ctx context.Context, minage int8, opts IterateOptions) iter.Seq2[*User, error] {
+    return iterateBatches(ctx, opts, func(after *User, batchSize int) ([]*User, error) {
+        if d.configProvider.BlockedReads("user") {
+            return nil, ErrOperationBlocked
+        }
+        d.telemetryProvider.IncDALOperation("user", "iterate_by_list_by_age")
+
+        var cursor *UserListByAgeCursor
+        if after != nil {
+            cursor = newUserListByAgeCursor([]*User{after}, 1)
+        } else if opts.StartAfterID != 0 {
+            // Continue after the position of the entity in the list
+            entity, err := d.getByID(ctx, opts.StartAfterID)
+            if err != nil {
+                return nil, fmt.Errorf("failed to resume ListByAge after User %d: %w", opts.StartAfterID, err)
+            }
+            cursor = newUserListByAgeCursor([]*User{entity}, 1)
+        }
+
+        result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+            return d.listByAge(ctx, minage, cursor, batchSize)
+        })
+        if err != nil {
+            return nil, err
+        }
+        return result.([]*User), nil
+    })
+}
+
+
+
+// IterateByListByStatus walks every User of ListByStatus in its order, a batch at a time, on the read
+// database. It bypasses the caches and leaves them untouched, so backfills and exports don't evict hot entries.
+func (d *userRepository) IterateByListByStatus(ctx context.Context, status *UserStatus, opts IterateOptions) iter.Seq2[*User, error] {
+    return iterateBatches(ctx, opts, func(after *User, batchSize int) ([]*User, error) {
+        if d.configProvider.BlockedReads("user") {
+            return nil, ErrOperationBlocked
+        }
+        d.telemetryProvider.IncDALOperation("user", "iterate_by_list_by_status")
+
+        var cursor *UserListByStatusCursor
+        if after != nil {
+            cursor = newUserListByStatusCursor([]*User{after}, 1)
+        } else if opts.StartAfterID != 0 {
+            // Continue after the position of the entity in the list
+            entity, err := d.getByID(ctx, opts.StartAfterID)
+            if err != nil {
+                return nil, fmt.Errorf("failed to resume ListByStatus after User %d: %w", opts.StartAfterID, err)
+            }
+            cursor = newUserListByStatusCursor([]*User{entity}, 1)
+        }
 
-	return count.(int64), nil
+        result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+            return d.listByStatus(ctx, status, cursor, batchSize)
+        })
+        if err != nil {
+            return nil, err
+        }
+        return result.([]*User), nil
+    })
 }
 
-func (d *userRepository) countListByStatus(ctx context.Context, status *string) (int64, error) {
-	const operation = "count_list_by_status"
-	dbStart := time.Now()
 
-	// if startID is zero then query is different for pagination
-	query := `SELECT count(*) FROM users WHERE (status = ?) AND deleted_at IS NULL`
 
-	db, dbErr := d.dbProvider.GetDatabase("user", false)
-	if dbErr != nil {
-		d.telemetryProvider.IncDBError("user", operation)
-		return 0, dbErr
-	}
+// IterateByListByStatuses walks every User of ListByStatuses in its order, a batch at a time, on the read
+// database. It bypasses the caches and leaves them untouched, so backfills and exports don't evict hot entries.
+func (d *userRepository) IterateByListByStatuses(ctx context.Context, statuses []UserStatus, opts IterateOptions) iter.Seq2[*User, error] {
+    return iterateBatches(ctx, opts, func(after *User, batchSize int) ([]*User, error) {
+        if d.configProvider.BlockedReads("user") {
+            return nil, ErrOperationBlocked
+        }
+        if err := checkInListSize("statuses", len(statuses)); err != nil {
+            return nil, err
+        }
+        d.telemetryProvider.IncDALOperation("user", "iterate_by_list_by_statuses")
+
+        var cursor *UserListByStatusesCursor
+        if after != nil {
+            cursor = newUserListByStatusesCursor([]*User{after}, 1)
+        } else if opts.StartAfterID != 0 {
+            // Continue after the position of the entity in the list
+            entity, err := d.getByID(ctx, opts.StartAfterID)
+            if err != nil {
+                return nil, fmt.Errorf("failed to resume ListByStatuses after User %d: %w", opts.StartAfterID, err)
+            }
+            cursor = newUserListByStatusesCursor([]*User{entity}, 1)
+        }
 
-	var err error
-	var count int64
+        result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+            return d.listByStatuses(ctx, statuses, cursor, batchSize)
+        })
+        if err != nil {
+            return nil, err
+        }
+        return result.([]*User), nil
+    })
+}
 
-	row := db.QueryRowContext(ctx, query, status)
 
-	err = row.Scan(
-		&count,
-	)
 
-	if err != nil {
-		d.telemetryProvider.IncDBError("user", operation)
-		return 0, fmt.Errorf("failed to query users: %w", err)
-	}
+// IterateBySearch walks every User of Search in its order, a batch at a time, on the read
+// database. It bypasses the caches and leaves them untouched, so backfills and exports don't evict hot entries.
+func (d *userRepository) IterateBySearch(ctx context.Context, filter UserSearchFilter, opts IterateOptions) iter.Seq2[*User, error] {
+    return iterateBatches(ctx, opts, func(after *User, batchSize int) ([]*User, error) {
+        if d.configProvider.BlockedReads("user") {
+            return nil, ErrOperationBlocked
+        }
+        d.telemetryProvider.IncDALOperation("user", "iterate_by_search")
+
+        var cursor *UserSearchCursor
+        if after != nil {
+            cursor = newUserSearchCursor([]*User{after}, 1)
+        } else if opts.StartAfterID != 0 {
+            // Continue after the position of the entity in the list
+            entity, err := d.getByID(ctx, opts.StartAfterID)
+            if err != nil {
+                return nil, fmt.Errorf("failed to resume Search after User %d: %w", opts.StartAfterID, err)
+            }
+            cursor = newUserSearchCursor([]*User{entity}, 1)
+        }
 
-	d.telemetryProvider.IncDBRequest("user", operation)	
-	d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(dbStart).Seconds())	
-	return count, nil
+        result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+            return d.search(ctx, filter, cursor, batchSize)
+        })
+        if err != nil {
+            return nil, err
+        }
+        return result.([]*User), nil
+    })
 }
 
 
@@ -1976,8 +4257,12 @@ func (d *userRepository) GetByUids(ctx context.Context, uids []string) ([]*User,
     var results []*User
     var missingKeys []interface{}
 
-    // 1. Check local in-memory cache for each key
+    // 1. Check local in-memory cache for each key. Transactions bypass caches to see their own writes.
     for _, key := range uids {
+        if inTx(ctx) {
+            missingKeys = append(missingKeys, key)
+            continue
+        }
         cacheKey := fmt.Sprintf("user_uid:%v", key)
         val, found := d.cache.Get(cacheKey)
         if found {
@@ -2011,7 +4296,7 @@ func (d *userRepository) GetByUids(ctx context.Context, uids []string) ([]*User,
         }
         chunk := missingKeys[i:end]
 
-        dbResult, err := d.dbBreaker.Execute(func() (interface{}, error) {
+        dbResult, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
             return d.getByUids(ctx, chunk)
         })
 
@@ -2024,6 +4309,10 @@ func (d *userRepository) GetByUids(ctx context.Context, uids []string) ([]*User,
 
     results = append(results, dbEntities...)
 
+    if inTx(ctx) {
+        return results, nil
+    }
+
     // 3. Cache the newly fetched items for future zero-latency access
     for _, entity := range dbEntities {
         d.setCached(entity)
@@ -2050,7 +4339,7 @@ func (d *userRepository) getByUids(ctx context.Context, missingKeys []interface{
         WHERE uid IN (%s) AND deleted_at IS NULL
     `, strings.Join(placeholders, ","))
 
-    db, dbErr := d.dbProvider.GetDatabase("user", false)
+    db, dbErr := databaseFor(ctx, d.dbProvider, "user", false)
     if dbErr != nil {
         d.telemetryProvider.IncDBError("user", operation)
         return nil, dbErr
@@ -2114,8 +4403,12 @@ func (d *userRepository) GetByIds(ctx context.Context, ids []int64) ([]*User, er
     var results []*User
     var missingKeys []interface{}
 
-    // 1. Check local in-memory cache for each key
+    // 1. Check local in-memory cache for each key. Transactions bypass caches to see their own writes.
     for _, key := range ids {
+        if inTx(ctx) {
+            missingKeys = append(missingKeys, key)
+            continue
+        }
         entity, _ := d.getByIDCached(key)
         if entity != nil {
             results = append(results, entity)
@@ -2142,7 +4435,7 @@ func (d *userRepository) GetByIds(ctx context.Context, ids []int64) ([]*User, er
         }
         chunk := missingKeys[i:end]
 
-        dbResult, err := d.dbBreaker.Execute(func() (interface{}, error) {
+        dbResult, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
             return d.getByIds(ctx, chunk)
         })
 
@@ -2155,6 +4448,10 @@ func (d *userRepository) GetByIds(ctx context.Context, ids []int64) ([]*User, er
 
     results = append(results, dbEntities...)
 
+    if inTx(ctx) {
+        return results, nil
+    }
+
     // 3. Cache the newly fetched items for future zero-latency access
     for _, entity := range dbEntities {
         d.setCached(entity)
@@ -2179,7 +4476,7 @@ func (d *userRepository) getByIds(ctx context.Context, missingKeys []interface{}
         WHERE id IN (%s) AND deleted_at IS NULL
     `, strings.Join(placeholders, ","))
 
-    db, dbErr := d.dbProvider.GetDatabase("user", false)
+    db, dbErr := databaseFor(ctx, d.dbProvider, "user", false)
     if dbErr != nil {
         d.telemetryProvider.IncDBError("user", operation)
         return nil, dbErr
@@ -2233,11 +4530,17 @@ func (d *userRepository) getByIds(ctx context.Context, missingKeys []interface{}
 // to peer nodes via Pub/Sub. Use this operation carefully in high-read environments.
 //
 // This operation is hard-limited to 5000 items and executes in database batches of 500.
-func (d *userRepository) UpdateStatusByUids(ctx context.Context, status *string, uids []string) error {
+func (d *userRepository) UpdateStatusByUids(ctx context.Context, status *UserStatus, uids []string) error {
     if d.configProvider.BlockedWrites("user") {
         return ErrOperationBlocked
     }
 
+    v := validator{entity: "user"}
+    validateUserStatus(&v, status)
+    if err := v.err(); err != nil {
+        return err
+    }
+
     if len(uids) == 0 {
         return nil // Nothing to update
     }
@@ -2260,7 +4563,7 @@ func (d *userRepository) UpdateStatusByUids(ctx context.Context, status *string,
         }
         chunk := uids[i:end]
         
-        result, err := d.dbBreaker.Execute(func() (interface{}, error) {
+        result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
             // Pass the SET columns, then the chunk
             return d.updateStatusByUids(ctx, status, chunk)
         })
@@ -2274,13 +4577,18 @@ func (d *userRepository) UpdateStatusByUids(ctx context.Context, status *string,
     if totalRowsAffected > 0 {
         // Since many rows changed at once, we perform a global flush to ensure local 
         // node safety and trigger pub/sub notifications for peer nodes.
-        d.FlushAllCache()
+        afterCommit(ctx, d.FlushAllCache)
     }
 
     return nil
 }
 
-func (d *userRepository) updateStatusByUids(ctx context.Context, status *string, uids []string) (int64, error) {
+func (d *userRepository) updateStatusByUids(ctx context.Context, status *UserStatus, uids []string) (int64, error) {
+    if !inTx(ctx) {
+        return withinTx(ctx, func(ctx context.Context) (int64, error) {
+            return d.updateStatusByUids(ctx, status, uids)
+        })
+    }
     const operation = "update_bulk_update_status_by_uids"
     dbStart := time.Now()
     now := time.Now()
@@ -2309,11 +4617,156 @@ func (d *userRepository) updateStatusByUids(ctx context.Context, status *string,
         args = append(args, val)
     }
 
-    db, dbErr := d.dbProvider.GetDatabase("user", true)
+    db, dbErr := databaseFor(ctx, d.dbProvider, "user", true)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return 0, dbErr
+    }
+    
+    // Snapshot the rows before the write to record its changes
+    before, auditErr := d.auditSnapshot(ctx, db, "uid IN (?...)", inValues(uids))
+    if auditErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return 0, auditErr
+    }
+
+
+    res, err := db.ExecContext(ctx, query, args...)
+    if err != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return 0, fmt.Errorf("failed to execute %s: %w", operation, err)
+    }
+
+    rowsAffected, err := res.RowsAffected()
+    if err != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return 0, fmt.Errorf("failed to get rows affected: %w", err)
+    }
+    
+    if auditErr := d.recordChanges(ctx, db, operation, before, "uid IN (?...)", inValues(uids)); auditErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return 0, auditErr
+    }
+
+
+    d.telemetryProvider.IncDBRequest("user", operation)
+    d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(dbStart).Seconds())
+
+    return rowsAffected, nil
+}
+
+
+
+// UpdateAgeByIds executes a bulk partial update using an IN clause.
+//
+// ⚠️ CAUTION - CACHE FLUSH NOTE ⚠️
+// Because bulk updates modify multiple records simultaneously, it is difficult to 
+// track and selectively invalidate individual cache entries safely. Therefore, if 
+// this operation successfully updates one or more rows, it will trigger a global 
+// FlushAllCache() to ensure local memory safety and broadcast cache invalidation 
+// to peer nodes via Pub/Sub. Use this operation carefully in high-read environments.
+//
+// This operation is hard-limited to 5000 items and executes in database batches of 500.
+func (d *userRepository) UpdateAgeByIds(ctx context.Context, age int8, ids []int64) error {
+    if d.configProvider.BlockedWrites("user") {
+        return ErrOperationBlocked
+    }
+
+    v := validator{entity: "user"}
+    validateUserAge(&v, age)
+    if err := v.err(); err != nil {
+        return err
+    }
+
+    if len(ids) == 0 {
+        return nil // Nothing to update
+    }
+    
+    // Hard limit to prevent massive memory allocations and driver crashes
+    if len(ids) > 5000 {
+        return fmt.Errorf("bulk update operation exceeds maximum limit of 5000 items")
+    }
+
+    const operation = "update_bulk_update_age_by_ids"
+    d.telemetryProvider.IncDALOperation("user", operation)
+
+    var totalRowsAffected int64
+    batchSize := 500
+    
+    for i := 0; i < len(ids); i += batchSize {
+        end := i + batchSize
+        if end > len(ids) {
+            end = len(ids)
+        }
+        chunk := ids[i:end]
+        
+        result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+            // Pass the SET columns, then the chunk
+            return d.updateAgeByIds(ctx, age, chunk)
+        })
+
+        if err != nil {
+            return err // Fail fast if a chunk fails
+        }
+        totalRowsAffected += result.(int64)
+    }
+
+    if totalRowsAffected > 0 {
+        // Since many rows changed at once, we perform a global flush to ensure local 
+        // node safety and trigger pub/sub notifications for peer nodes.
+        afterCommit(ctx, d.FlushAllCache)
+    }
+
+    return nil
+}
+
+func (d *userRepository) updateAgeByIds(ctx context.Context, age int8, ids []int64) (int64, error) {
+    if !inTx(ctx) {
+        return withinTx(ctx, func(ctx context.Context) (int64, error) {
+            return d.updateAgeByIds(ctx, age, ids)
+        })
+    }
+    const operation = "update_bulk_update_age_by_ids"
+    dbStart := time.Now()
+    now := time.Now()
+
+    // 1. Dynamically build ?,?,? placeholders for the IN clause
+    placeholders := make([]string, len(ids))
+    for i := range ids {
+        placeholders[i] = "?"
+    }
+
+    query := fmt.Sprintf(`
+        UPDATE users
+        SET
+            age = ?,
+            updated = ?,
+            version = version + 1
+        WHERE id IN (%s) AND deleted_at IS NULL
+    `, strings.Join(placeholders, ","))
+
+    // 2. Build the flat arguments array (SET arguments first, then WHERE IN arguments)
+    args := make([]interface{}, 0, 1 + 1 + len(ids))
+    args = append(args, age)
+    args = append(args, now) // the 'updated' timestamp
+
+    for _, val := range ids {
+        args = append(args, val)
+    }
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "user", true)
     if dbErr != nil {
         d.telemetryProvider.IncDBError("user", operation)
         return 0, dbErr
     }
+    
+    // Snapshot the rows before the write to record its changes
+    before, auditErr := d.auditSnapshot(ctx, db, "id IN (?...)", inValues(ids))
+    if auditErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return 0, auditErr
+    }
+
 
     res, err := db.ExecContext(ctx, query, args...)
     if err != nil {
@@ -2326,123 +4779,231 @@ func (d *userRepository) updateStatusByUids(ctx context.Context, status *string,
         d.telemetryProvider.IncDBError("user", operation)
         return 0, fmt.Errorf("failed to get rows affected: %w", err)
     }
+    
+    if auditErr := d.recordChanges(ctx, db, operation, before, "id IN (?...)", inValues(ids)); auditErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return 0, auditErr
+    }
+
 
     d.telemetryProvider.IncDBRequest("user", operation)
     d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(dbStart).Seconds())
 
-    return rowsAffected, nil
-}
+    return rowsAffected, nil
+}
+
+
+
+// SumAgeByStatus returns the sum of age where status = :status, 0 when no rows match.
+// CACHING NOTE: Uses the countCache, invalidated like the counts of lists.
+func (d *userRepository) SumAgeByStatus(ctx context.Context, status *UserStatus) (float64, error) {
+	if d.configProvider.BlockedReads("user") {
+		return 0, ErrOperationBlocked
+	}
+
+	const operation = "aggregate_sum_age_by_status"
+	d.telemetryProvider.IncDALOperation("user", operation)
+
+	cacheKey := fmt.Sprintf("user_aggregate_sum_age_by_status:epoch_%d:%v", d.getEpoch(), func() interface{} { if status == nil { return "<<null>>" }; return *status }())
+	// Transactions bypass caches to see their own writes.
+	val, found := d.countCache.Get(cacheKey)
+	if found && !inTx(ctx) {
+		value, ok := val.(float64)
+		if !ok {
+			return 0, fmt.Errorf("userRepository.SumAgeByStatus: Cache returned wrong type; expected float64")
+		}
+
+		d.telemetryProvider.IncCacheHit("user", operation)
+		return value, nil
+	}
+
+	d.telemetryProvider.IncCacheMiss("user", operation)
+
+	value, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return d.sumAgeByStatus(ctx, status)
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if !inTx(ctx) {
+		d.countCache.Set(cacheKey, value, time.Second*60)
+	}
+
+	return value.(float64), nil
+}
+
+func (d *userRepository) sumAgeByStatus(ctx context.Context, status *UserStatus) (float64, error) {
+	const operation = "aggregate_sum_age_by_status"
+	dbStart := time.Now()
+
+	query := `SELECT SUM(age) FROM users WHERE (status = ?) AND deleted_at IS NULL`
+
+	db, dbErr := databaseFor(ctx, d.dbProvider, "user", false)
+	if dbErr != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+		return 0, dbErr
+	}
+
+	// The aggregate of no rows is NULL.
+	var value sql.NullFloat64
+	row := db.QueryRowContext(ctx, query, status)
+	if err := row.Scan(&value); err != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+		return 0, fmt.Errorf("failed to aggregate users: %w", err)
+	}
+
+	d.telemetryProvider.IncDBRequest("user", operation)
+	d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(dbStart).Seconds())
+	return value.Float64, nil
+}
+
+
+
+// ExistsByEmail reports whether a user where email = :email exists, without loading the row.
+// A email cached by GetByEmail answers true without a query; misses aren't cached.
+func (d *userRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	if d.configProvider.BlockedReads("user") {
+		return false, ErrOperationBlocked
+	}
+
+	const operation = "exists_by_email"
+	d.telemetryProvider.IncDALOperation("user", operation)
+
+	// Transactions bypass caches to see their own writes.
+	if !inTx(ctx) {
+		// Deletes leave the email -> id mapping behind, so the cached entity has to confirm it.
+		if val, found := d.cache.Get(fmt.Sprintf("user_email:%v", email)); found {
+			if id, ok := val.(int64); ok {
+				if cached, found := d.cache.Get(d.getCacheKey(id)); found {
+					if entity, ok := cached.(*User); ok && entity.Email == email {
+						d.telemetryProvider.IncCacheHit("user", operation)
+						return true, nil
+					}
+				}
+			}
+		}
+		d.telemetryProvider.IncCacheMiss("user", operation)
+	}
+
+	found, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return d.existsByEmail(ctx, email)
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return found.(bool), nil
+}
+
+func (d *userRepository) existsByEmail(ctx context.Context, email string) (bool, error) {
+	const operation = "exists_by_email"
+	dbStart := time.Now()
+
+	query := `SELECT 1 FROM users WHERE (email = ?) AND deleted_at IS NULL LIMIT 1`
+
+	db, dbErr := databaseFor(ctx, d.dbProvider, "user", false)
+	if dbErr != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+		return false, dbErr
+	}
+
+	var one int
+	err := db.QueryRowContext(ctx, query, email).Scan(&one)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		d.telemetryProvider.IncDBError("user", operation)
+		return false, fmt.Errorf("failed to check user existence: %w", err)
+	}
 
+	d.telemetryProvider.IncDBRequest("user", operation)
+	d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(dbStart).Seconds())
+	return err == nil, nil
+}
 
 
-// UpdateAgeByIds executes a bulk partial update using an IN clause.
-//
-// ⚠️ CAUTION - CACHE FLUSH NOTE ⚠️
-// Because bulk updates modify multiple records simultaneously, it is difficult to 
-// track and selectively invalidate individual cache entries safely. Therefore, if 
-// this operation successfully updates one or more rows, it will trigger a global 
-// FlushAllCache() to ensure local memory safety and broadcast cache invalidation 
-// to peer nodes via Pub/Sub. Use this operation carefully in high-read environments.
-//
-// This operation is hard-limited to 5000 items and executes in database batches of 500.
-func (d *userRepository) UpdateAgeByIds(ctx context.Context, age int8, ids []int64) error {
-    if d.configProvider.BlockedWrites("user") {
-        return ErrOperationBlocked
-    }
 
-    if len(ids) == 0 {
-        return nil // Nothing to update
-    }
-    
-    // Hard limit to prevent massive memory allocations and driver crashes
-    if len(ids) > 5000 {
-        return fmt.Errorf("bulk update operation exceeds maximum limit of 5000 items")
-    }
+// CountByStatusGrouped counts the users where age >= :minAge per status value.
+// Values without rows are missing from the map, and rows with a NULL status aren't counted.
+// CACHING NOTE: Uses the countCache, invalidated like the counts of lists.
+func (d *userRepository) CountByStatusGrouped(ctx context.Context, minage int8) (map[UserStatus]int64, error) {
+	if d.configProvider.BlockedReads("user") {
+		return nil, ErrOperationBlocked
+	}
 
-    const operation = "update_bulk_update_age_by_ids"
-    d.telemetryProvider.IncDALOperation("user", operation)
+	const operation = "count_by_status_grouped"
+	d.telemetryProvider.IncDALOperation("user", operation)
 
-    var totalRowsAffected int64
-    batchSize := 500
-    
-    for i := 0; i < len(ids); i += batchSize {
-        end := i + batchSize
-        if end > len(ids) {
-            end = len(ids)
-        }
-        chunk := ids[i:end]
-        
-        result, err := d.dbBreaker.Execute(func() (interface{}, error) {
-            // Pass the SET columns, then the chunk
-            return d.updateAgeByIds(ctx, age, chunk)
-        })
+	cacheKey := fmt.Sprintf("user_group_count_by_status_grouped:epoch_%d:%v", d.getEpoch(), minage)
+	// Transactions bypass caches to see their own writes.
+	val, found := d.countCache.Get(cacheKey)
+	if found && !inTx(ctx) {
+		counts, ok := val.(map[UserStatus]int64)
+		if !ok {
+			return nil, fmt.Errorf("userRepository.CountByStatusGrouped: Cache returned wrong type; expected map[UserStatus]int64")
+		}
 
-        if err != nil {
-            return err // Fail fast if a chunk fails
-        }
-        totalRowsAffected += result.(int64)
-    }
+		d.telemetryProvider.IncCacheHit("user", operation)
+		// Callers may modify the map, the cached one stays untouched.
+		return maps.Clone(counts), nil
+	}
 
-    if totalRowsAffected > 0 {
-        // Since many rows changed at once, we perform a global flush to ensure local 
-        // node safety and trigger pub/sub notifications for peer nodes.
-        d.FlushAllCache()
-    }
+	d.telemetryProvider.IncCacheMiss("user", operation)
 
-    return nil
-}
+	counts, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+		return d.countByStatusGrouped(ctx, minage)
+	})
 
-func (d *userRepository) updateAgeByIds(ctx context.Context, age int8, ids []int64) (int64, error) {
-    const operation = "update_bulk_update_age_by_ids"
-    dbStart := time.Now()
-    now := time.Now()
+	if err != nil {
+		return nil, err
+	}
 
-    // 1. Dynamically build ?,?,? placeholders for the IN clause
-    placeholders := make([]string, len(ids))
-    for i := range ids {
-        placeholders[i] = "?"
-    }
+	if !inTx(ctx) {
+		d.countCache.Set(cacheKey, counts, time.Second*60)
+	}
 
-    query := fmt.Sprintf(`
-        UPDATE users
-        SET
-            age = ?,
-            updated = ?,
-            version = version + 1
-        WHERE id IN (%s) AND deleted_at IS NULL
-    `, strings.Join(placeholders, ","))
+	return maps.Clone(counts.(map[UserStatus]int64)), nil
+}
 
-    // 2. Build the flat arguments array (SET arguments first, then WHERE IN arguments)
-    args := make([]interface{}, 0, 1 + 1 + len(ids))
-    args = append(args, age)
-    args = append(args, now) // the 'updated' timestamp
+func (d *userRepository) countByStatusGrouped(ctx context.Context, minage int8) (map[UserStatus]int64, error) {
+	const operation = "count_by_status_grouped"
+	dbStart := time.Now()
 
-    for _, val := range ids {
-        args = append(args, val)
-    }
+	query := `SELECT status, count(*) FROM users WHERE (age >= ?) AND deleted_at IS NULL AND status IS NOT NULL GROUP BY status`
 
-    db, dbErr := d.dbProvider.GetDatabase("user", true)
-    if dbErr != nil {
-        d.telemetryProvider.IncDBError("user", operation)
-        return 0, dbErr
-    }
+	db, dbErr := databaseFor(ctx, d.dbProvider, "user", false)
+	if dbErr != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+		return nil, dbErr
+	}
 
-    res, err := db.ExecContext(ctx, query, args...)
-    if err != nil {
-        d.telemetryProvider.IncDBError("user", operation)
-        return 0, fmt.Errorf("failed to execute %s: %w", operation, err)
-    }
+	rows, err := db.QueryContext(ctx, query, minage)
+	if err != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+		return nil, fmt.Errorf("failed to count users per status: %w", err)
+	}
+	defer rows.Close()
 
-    rowsAffected, err := res.RowsAffected()
-    if err != nil {
-        d.telemetryProvider.IncDBError("user", operation)
-        return 0, fmt.Errorf("failed to get rows affected: %w", err)
-    }
+	counts := make(map[UserStatus]int64)
+	for rows.Next() {
+		var value UserStatus
+		var count int64
+		if err := rows.Scan(&value, &count); err != nil {
+			d.telemetryProvider.IncDBError("user", operation)
+			return nil, fmt.Errorf("failed to scan status count: %w", err)
+		}
+		counts[value] = count
+	}
 
-    d.telemetryProvider.IncDBRequest("user", operation)
-    d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(dbStart).Seconds())
+	if err := rows.Err(); err != nil {
+		d.telemetryProvider.IncDBError("user", operation)
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
 
-    return rowsAffected, nil
+	d.telemetryProvider.IncDBRequest("user", operation)
+	d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(dbStart).Seconds())
+	return counts, nil
 }
 
 
@@ -2461,6 +5022,7 @@ func (d *userRepository) updateAgeByIds(ctx context.Context, age int8, ids []int
 
 
 
+
 func (d *userRepository) DeleteOlder(ctx context.Context, age int8, limit int) (int64, error) {
     if d.configProvider.BlockedWrites("user") {
         return 0, ErrOperationBlocked
@@ -2474,7 +5036,7 @@ func (d *userRepository) DeleteOlder(ctx context.Context, age int8, limit int) (
     const operation = "delete_older"
     d.telemetryProvider.IncDALOperation("user", operation)
 
-    result, err := d.dbBreaker.Execute(func() (interface{}, error) {
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
         return d.deleteOlder(ctx, age, limit)
     })
 
@@ -2485,29 +5047,45 @@ func (d *userRepository) DeleteOlder(ctx context.Context, age int8, limit int) (
     rowsAffected := result.(int64)
     
     if rowsAffected > 0 {
-        d.FlushAllCache() 
+        afterCommit(ctx, d.FlushAllCache)
     }
 
     return rowsAffected, nil
 }
 
 func (d *userRepository) deleteOlder(ctx context.Context, age int8, limit int) (int64, error) {
+    if !inTx(ctx) {
+        return withinTx(ctx, func(ctx context.Context) (int64, error) {
+            return d.deleteOlder(ctx, age, limit)
+        })
+    }
     const operation = "delete_older"
     dbStart := time.Now()
 
-    // Inject the sanitized limit directly into the SQL string
-    query := `UPDATE users SET deleted_at = NOW(), updated = NOW(), version = version + 1, uid = CONCAT(uid, '-del-', UUID()), email = CONCAT(email, '-del-', UUID()) WHERE (age > ?) AND deleted_at IS NULL` + fmt.Sprintf(" LIMIT %d", limit)
-
-    db, dbErr := d.dbProvider.GetDatabase("user", true)
+    db, dbErr := databaseFor(ctx, d.dbProvider, "user", true)
     if dbErr != nil {
         d.telemetryProvider.IncDBError("user", operation)
         return 0, dbErr
     }
 
-    
-    res, err := db.ExecContext(ctx, query, age)
-    
-    
+    // Snapshot the rows to delete for the history, then delete exactly those by id
+    before, auditErr := d.auditSnapshot(ctx, db, `(age > ?) AND deleted_at IS NULL`+fmt.Sprintf(" ORDER BY id LIMIT %d", limit), age)
+    if auditErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return 0, auditErr
+    }
+    if len(before) == 0 {
+        return 0, nil
+    }
+
+    ids := make([]int64, 0, len(before))
+    for id := range before {
+        ids = append(ids, id)
+    }
+
+    query, args := expandInLists(`UPDATE users SET deleted_at = NOW(), updated = NOW(), version = version + 1, email = CONCAT(email, '-del-', UUID()), uid = CONCAT(uid, '-del-', UUID()) WHERE id IN (?...)`, inValues(ids))
+
+    res, err := db.ExecContext(ctx, query, args...)
     if err != nil {
         d.telemetryProvider.IncDBError("user", operation)
         return 0, fmt.Errorf("failed to execute custom delete %s: %w", operation, err)
@@ -2518,10 +5096,14 @@ func (d *userRepository) deleteOlder(ctx context.Context, age int8, limit int) (
         d.telemetryProvider.IncDBError("user", operation)
         return 0, fmt.Errorf("failed to get rows affected: %w", err)
     }
+    
+    if auditErr := d.recordChanges(ctx, db, operation, before, "id IN (?...)", inValues(ids)); auditErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return 0, auditErr
+    }
 
     d.telemetryProvider.IncDBRequest("user", operation)
     d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(dbStart).Seconds())
-    
     return rowsAffected, nil
 }
 
@@ -2559,7 +5141,7 @@ func (d *userRepository) HardDeleteOlder(ctx context.Context, age int8, limit in
     const operation = "hard_delete_older"
     d.telemetryProvider.IncDALOperation("user", operation)
 
-    result, err := d.dbBreaker.Execute(func() (interface{}, error) {
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
         return d.hardDeleteOlder(ctx, age, limit)
     })
 
@@ -2571,29 +5153,45 @@ func (d *userRepository) HardDeleteOlder(ctx context.Context, age int8, limit in
     
     // Only flush caches if the database state actually changed
     if rowsAffected > 0 {
-        d.FlushAllCache() 
+        afterCommit(ctx, d.FlushAllCache)
     }
 
     return rowsAffected, nil
 }
 
 func (d *userRepository) hardDeleteOlder(ctx context.Context, age int8, limit int) (int64, error) {
+    if !inTx(ctx) {
+        return withinTx(ctx, func(ctx context.Context) (int64, error) {
+            return d.hardDeleteOlder(ctx, age, limit)
+        })
+    }
     const operation = "hard_delete_older"
     dbStart := time.Now()
 
-    // Inject the sanitized limit directly into the SQL string
-    query := `DELETE FROM users WHERE age > ?` + fmt.Sprintf(" LIMIT %d", limit)
-
-    db, dbErr := d.dbProvider.GetDatabase("user", true)
+    db, dbErr := databaseFor(ctx, d.dbProvider, "user", true)
     if dbErr != nil {
         d.telemetryProvider.IncDBError("user", operation)
         return 0, dbErr
     }
 
-    
-    res, err := db.ExecContext(ctx, query, age)
-    
-    
+    // Snapshot the rows to delete for the history, then delete exactly those by id
+    before, auditErr := d.auditSnapshot(ctx, db, `age > ?`+fmt.Sprintf(" ORDER BY id LIMIT %d", limit), age)
+    if auditErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return 0, auditErr
+    }
+    if len(before) == 0 {
+        return 0, nil
+    }
+
+    ids := make([]int64, 0, len(before))
+    for id := range before {
+        ids = append(ids, id)
+    }
+
+    query, args := expandInLists(`DELETE FROM users WHERE id IN (?...)`, inValues(ids))
+
+    res, err := db.ExecContext(ctx, query, args...)
     if err != nil {
         d.telemetryProvider.IncDBError("user", operation)
         return 0, fmt.Errorf("failed to execute custom hard delete %s: %w", operation, err)
@@ -2604,12 +5202,185 @@ func (d *userRepository) hardDeleteOlder(ctx context.Context, age int8, limit in
         d.telemetryProvider.IncDBError("user", operation)
         return 0, fmt.Errorf("failed to get rows affected: %w", err)
     }
+    
+    if auditErr := d.recordChanges(ctx, db, operation, before, "id IN (?...)", inValues(ids)); auditErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return 0, auditErr
+    }
 
     d.telemetryProvider.IncDBRequest("user", operation)
     d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(dbStart).Seconds())
-    
     return rowsAffected, nil
 }
 
 
 
+
+
+
+// userAuditValues returns the columns of entity recorded in its history.
+func userAuditValues(entity *User) map[string]interface{} {
+    return map[string]interface{}{
+        "age": entity.Age,
+        "birthdate": entity.Birthdate,
+        "email": entity.Email,
+        "meta": entity.Meta,
+        "status": entity.Status,
+        "uid": entity.Uid,
+        "deleted_at": entity.DeletedAt,
+    }
+}
+
+// History returns the recorded changes of the User with the given id, oldest first. Pass 0 as startID
+// for the first page, then the ID of the last HistoryEntry of the previous page.
+func (d *userRepository) History(ctx context.Context, id int64, startID int64, pageSize int) ([]*HistoryEntry, error) {
+    if d.configProvider.BlockedReads("user") {
+        return nil, ErrOperationBlocked
+    }
+
+    const operation = "history"
+    d.telemetryProvider.IncDALOperation("user", operation)
+
+    result, err := executeWithBreaker(ctx, d.dbBreaker, func() (interface{}, error) {
+        return d.history(ctx, id, startID, pageSize)
+    })
+    if err != nil {
+        return nil, err
+    }
+    return result.([]*HistoryEntry), nil
+}
+
+func (d *userRepository) history(ctx context.Context, id int64, startID int64, pageSize int) ([]*HistoryEntry, error) {
+    const operation = "history"
+    dbStart := time.Now()
+
+    query := `
+        SELECT id, entity_id, version, operation, actor, old_values, new_values, created
+        FROM users_history
+        WHERE entity_id = ? AND id > ?
+        ORDER BY id
+        LIMIT ?
+    `
+
+    db, dbErr := databaseFor(ctx, d.dbProvider, "user", false)
+    if dbErr != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return nil, dbErr
+    }
+
+    rows, err := db.QueryContext(ctx, query, id, startID, pageSize)
+    if err != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return nil, fmt.Errorf("failed to query user history: %w", err)
+    }
+    defer rows.Close()
+
+    var entries []*HistoryEntry
+    for rows.Next() {
+        var entry HistoryEntry
+        var actor sql.NullString
+        var oldValues, newValues []byte
+        err := rows.Scan(&entry.ID, &entry.EntityID, &entry.Version, &entry.Operation, &actor, &oldValues, &newValues, &entry.Created)
+        if err != nil {
+            d.telemetryProvider.IncDBError("user", operation)
+            return nil, fmt.Errorf("failed to scan user history: %w", err)
+        }
+        entry.Actor = actor.String
+        entry.OldValues = oldValues
+        entry.NewValues = newValues
+        entries = append(entries, &entry)
+    }
+
+    if err = rows.Err(); err != nil {
+        d.telemetryProvider.IncDBError("user", operation)
+        return nil, fmt.Errorf("rows error: %w", err)
+    }
+
+    d.telemetryProvider.IncDBRequest("user", operation)
+    d.telemetryProvider.ObserveDBLatency("user", operation, time.Since(dbStart).Seconds())
+    return entries, nil
+}
+
+// auditSnapshot loads the Users matching where from db, soft deleted ones included, keyed by id.
+// The rows stay locked until the transaction ends, so the history sees them as the write leaves them.
+func (d *userRepository) auditSnapshot(ctx context.Context, db dbExecutor, where string, args ...interface{}) (map[int64]*User, error) {
+    query, args := expandInLists(`SELECT id, version, age, birthdate, email, meta, status, uid, created, updated, deleted_at FROM users WHERE `+where+` FOR UPDATE`, args...)
+
+    rows, err := db.QueryContext(ctx, query, args...)
+    if err != nil {
+        return nil, fmt.Errorf("failed to snapshot users: %w", err)
+    }
+    defer rows.Close()
+
+    result := make(map[int64]*User)
+    for rows.Next() {
+        var entity User
+        err := rows.Scan(
+            &entity.ID,
+            &entity.Version,
+            &entity.Age,
+            &entity.Birthdate,
+            &entity.Email,
+            &entity.Meta,
+            &entity.Status,
+            &entity.Uid,
+            &entity.Created,
+            &entity.Updated,
+            &entity.DeletedAt,
+        )
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan User: %w", err)
+        }
+        result[entity.ID] = &entity
+    }
+
+    if err := rows.Err(); err != nil {
+        return nil, fmt.Errorf("failed to snapshot users: %w", err)
+    }
+    return result, nil
+}
+
+// recordChanges records, in the transaction of db, every User matching where or found in before whose
+// columns changed, stamped with operation, in its history with the actor of ctx and as an outbox event.
+func (d *userRepository) recordChanges(ctx context.Context, db dbExecutor, operation string, before map[int64]*User, where string, args ...interface{}) error {
+    after, err := d.auditSnapshot(ctx, db, where, args...)
+    if err != nil {
+        return err
+    }
+
+    changes, err := auditChanges(before, after, userAuditValues, func(entity *User) int32 { return entity.Version })
+    if err != nil {
+        return err
+    }
+    now := time.Now()
+
+    query := `
+        INSERT INTO users_history (entity_id, version, operation, actor, old_values, new_values, created)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
+    `
+
+    actor := nullableActor(ActorFromContext(ctx))
+    for _, change := range changes {
+        _, err := db.ExecContext(ctx, query, change.entityID, change.version, operation, actor, change.oldValues, change.newValues, now)
+        if err != nil {
+            return fmt.Errorf("failed to record User history: %w", err)
+        }
+    }
+
+    outboxQuery := `
+        INSERT INTO dal_outbox (entity, entity_id, operation, version, payload, attempts, next_attempt_at, created)
+        VALUES (?, ?, ?, ?, ?, 0, ?, ?)
+    `
+
+    for _, change := range changes {
+        payload, err := json.Marshal(change.entity)
+        if err != nil {
+            return fmt.Errorf("failed to encode User event: %w", err)
+        }
+        _, err = db.ExecContext(ctx, outboxQuery, "user", change.entityID, operation, change.version, string(payload), now, now)
+        if err != nil {
+            return fmt.Errorf("failed to record User event: %w", err)
+        }
+    }
+    return nil
+}
@@ -5,7 +5,7 @@ CREATE TABLE users (
     birthdate DATE,
     email VARCHAR(255) NOT NULL,
     meta JSON,
-    status VARCHAR(255),
+    status ENUM('active', 'banned', 'pending', 'suspended', 'unknown'),
     uid VARCHAR(255) NOT NULL,
     
     deleted_at TIMESTAMP NULL,
@@ -20,10 +20,42 @@ CREATE UNIQUE INDEX idx_uid ON users (uid);
 
 # Indexes that serve all operations
 CREATE INDEX idx_age ON users (age);
-CREATE INDEX idx_age_created ON users (age, created);
-CREATE INDEX idx_birthdate ON users (birthdate);
-CREATE INDEX idx_status_created ON users (status, created);
+CREATE INDEX idx_age_created_id ON users (age, created, id);
+CREATE INDEX idx_age_status ON users (age, status);
+CREATE INDEX idx_birthdate_id ON users (birthdate, id);
+CREATE INDEX idx_created_id ON users (created, id);
+CREATE INDEX idx_status_age ON users (status, age);
+CREATE INDEX idx_status_created_id ON users (status, created, id);
 
 
 
 CREATE INDEX idx_deleted_at ON users (deleted_at);
+
+# History of every change, written in the transaction of the change
+CREATE TABLE users_history (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    entity_id BIGINT NOT NULL,
+    version INT NOT NULL,
+    operation VARCHAR(64) NOT NULL,
+    actor VARCHAR(255),
+    old_values JSON,
+    new_values JSON,
+    created TIMESTAMP NOT NULL
+) ENGINE=InnoDB;
+CREATE INDEX idx_history_entity_id ON users_history (entity_id, id);
+
+# Change events, written in the transaction of the change and delivered by the OutboxRelay
+CREATE TABLE IF NOT EXISTS dal_outbox (
+    id BIGINT PRIMARY KEY AUTO_INCREMENT,
+    entity VARCHAR(64) NOT NULL,
+    entity_id BIGINT NOT NULL,
+    operation VARCHAR(64) NOT NULL,
+    version INT NOT NULL,
+    payload JSON NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL,
+    delivered_at TIMESTAMP NULL,
+    created TIMESTAMP NOT NULL,
+    INDEX idx_dal_outbox_pending (delivered_at, next_attempt_at)
+) ENGINE=InnoDB;
@@ -110,7 +110,7 @@ func TestPubSubCacheInvalidation(t *testing.T) {
 
 		// Create a user so that it is cached.
 		now := time.Now()
-		s := "active"
+		s := UserStatusActive
 		newUser := &User{
 			Age:       25,
 			Email:     "pubsub_test@example.com",
@@ -204,7 +204,7 @@ func TestRedisPubSubInvalidationBetweenInstances(t *testing.T) {
 	newUser := &User{
 		Age:       25,
 		Email:     "instance_test@example.com",
-		Status:    Ptr("active"),
+		Status:    Ptr(UserStatusActive),
 		Birthdate: Ptr(time.Now()),
 	}
 	created, err := userDAL_A.Create(ctx, newUser)
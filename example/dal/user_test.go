@@ -5,10 +5,12 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -193,7 +195,7 @@ func TestUserCRUD(t *testing.T) {
 		newUser := &User{
 			Age:       25,
 			Email:     "test@example.com",
-			Status:    Ptr("active"),
+			Status:    Ptr(UserStatusActive),
 			Birthdate: Ptr(time.Now()),
 			Meta:      &initialMeta,
 		}
@@ -287,7 +289,7 @@ func TestUserCreateBulk(t *testing.T) {
 			newUser := &User{
 				Age:       25,
 				Email:     fmt.Sprintf("test_%02d@example.com", i),
-				Status:    Ptr("active"),
+				Status:    Ptr(UserStatusActive),
 				Birthdate: Ptr(time.Now()),
 				Meta:      &metaData,
 			}
@@ -353,7 +355,7 @@ func TestUserBlockedReadsAndWrites(t *testing.T) {
 		newUser := &User{
 			Age:       25,
 			Email:     "test@example.com",
-			Status:    Ptr("active"),
+			Status:    Ptr(UserStatusActive),
 			Birthdate: Ptr(time.Now()),
 		}
 
@@ -391,7 +393,7 @@ func TestUserGetEmail(t *testing.T) {
 		newUser := &User{
 			Age:       25,
 			Email:     "test@example.com",
-			Status:    Ptr("active"),
+			Status:    Ptr(UserStatusActive),
 			Birthdate: Ptr(time.Now()),
 		}
 
@@ -473,7 +475,7 @@ func TestListById(t *testing.T) {
 			newUser := &User{
 				Age:       25,
 				Email:     fmt.Sprintf("test_%d@example.com", i),
-				Status:    Ptr("active"),
+				Status:    Ptr(UserStatusActive),
 				Birthdate: Ptr(time.Now()),
 			}
 
@@ -531,6 +533,374 @@ func TestListById(t *testing.T) {
 	})
 }
 
+func TestListByIdPage(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB(t)
+
+	userDAL := NewUserRepository(
+		dbProvider,
+		nil,
+		nil,
+		gobreaker.Settings{},
+		PrometheusTelemetryProvider{},
+	)
+	ctx := context.Background()
+
+	const numEntries = 25
+	for i := 1; i <= numEntries; i++ {
+		_, err := userDAL.Create(ctx, &User{
+			Age:    25,
+			Email:  fmt.Sprintf("page_%d@example.com", i),
+			Status: Ptr(UserStatusActive),
+		})
+		assert.NoError(t, err)
+	}
+
+	// Walk all pages by token; the last page has no token.
+	var seen []int64
+	pageToken := ""
+	for {
+		page, err := userDAL.ListByIdPage(ctx, pageToken, 10)
+		assert.NoError(t, err)
+		for _, user := range page.Items {
+			seen = append(seen, user.ID)
+		}
+		assert.Equal(t, page.HasMore, page.NextPageToken != "")
+		if !page.HasMore {
+			assert.Len(t, page.Items, 5)
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+	assert.Len(t, seen, numEntries)
+	for i := 1; i < len(seen); i++ {
+		assert.True(t, seen[i] > seen[i-1], "Expected IDs to be in ascending order")
+	}
+
+	// A token of another list is rejected.
+	agePage, err := userDAL.ListByAgePage(ctx, 18, "", 10)
+	assert.NoError(t, err)
+	_, err = userDAL.ListByIdPage(ctx, agePage.NextPageToken, 10)
+	assert.ErrorIs(t, err, ErrInvalidPageToken)
+}
+
+func TestUserSumAgeByStatus(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB(t)
+
+	userDAL := NewUserRepository(
+		dbProvider,
+		nil,
+		nil,
+		gobreaker.Settings{},
+		PrometheusTelemetryProvider{},
+	)
+	ctx := context.Background()
+
+	for i, age := range []int8{20, 30, 40} {
+		status := UserStatusActive
+		if i == 2 {
+			status = UserStatusBanned
+		}
+		_, err := userDAL.Create(ctx, &User{
+			Age:    age,
+			Email:  fmt.Sprintf("sum_%d@example.com", i),
+			Status: Ptr(status),
+		})
+		assert.NoError(t, err)
+	}
+
+	sum, err := userDAL.SumAgeByStatus(ctx, Ptr(UserStatusActive))
+	assert.NoError(t, err)
+	assert.Equal(t, 50.0, sum)
+
+	// Cached until a write bumps the epoch.
+	sum, err = userDAL.SumAgeByStatus(ctx, Ptr(UserStatusActive))
+	assert.NoError(t, err)
+	assert.Equal(t, 50.0, sum)
+	cacheHits := testutil.ToFloat64(dalCacheHitsCounter.WithLabelValues("user", "aggregate_sum_age_by_status"))
+	assert.Equal(t, 1.0, cacheHits)
+
+	_, err = userDAL.Create(ctx, &User{Age: 5, Email: "sum_new@example.com", Status: Ptr(UserStatusActive)})
+	assert.NoError(t, err)
+	sum, err = userDAL.SumAgeByStatus(ctx, Ptr(UserStatusActive))
+	assert.NoError(t, err)
+	assert.Equal(t, 55.0, sum)
+
+	none, err := userDAL.SumAgeByStatus(ctx, Ptr(UserStatusUnknown))
+	assert.NoError(t, err)
+	assert.Zero(t, none)
+}
+
+func TestUserCountByStatusGrouped(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB(t)
+
+	userDAL := NewUserRepository(
+		dbProvider,
+		nil,
+		nil,
+		gobreaker.Settings{},
+		PrometheusTelemetryProvider{},
+	)
+	ctx := context.Background()
+
+	users := []*User{
+		{Age: 30, Email: "group_1@example.com", Status: Ptr(UserStatusActive)},
+		{Age: 40, Email: "group_2@example.com", Status: Ptr(UserStatusActive)},
+		{Age: 50, Email: "group_3@example.com", Status: Ptr(UserStatusBanned)},
+		{Age: 60, Email: "group_4@example.com"}, // NULL status isn't counted
+		{Age: 10, Email: "group_5@example.com", Status: Ptr(UserStatusActive)},
+	}
+	for _, user := range users {
+		_, err := userDAL.Create(ctx, user)
+		assert.NoError(t, err)
+	}
+
+	counts, err := userDAL.CountByStatusGrouped(ctx, 18)
+	assert.NoError(t, err)
+	assert.Equal(t, map[UserStatus]int64{UserStatusActive: 2, UserStatusBanned: 1}, counts)
+
+	// A write bumps the epoch, so the next call sees the new user.
+	_, err = userDAL.Create(ctx, &User{Age: 20, Email: "group_6@example.com", Status: Ptr(UserStatusBanned)})
+	assert.NoError(t, err)
+	counts, err = userDAL.CountByStatusGrouped(ctx, 18)
+	assert.NoError(t, err)
+	assert.Equal(t, map[UserStatus]int64{UserStatusActive: 2, UserStatusBanned: 2}, counts)
+}
+
+func TestUserExistsByEmail(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB(t)
+
+	userDAL := NewUserRepository(
+		dbProvider,
+		nil,
+		nil,
+		gobreaker.Settings{},
+		PrometheusTelemetryProvider{},
+	)
+	ctx := context.Background()
+
+	found, err := userDAL.ExistsByEmail(ctx, "exists@example.com")
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	user, err := userDAL.Create(ctx, &User{Age: 30, Email: "exists@example.com", Status: Ptr(UserStatusActive)})
+	assert.NoError(t, err)
+
+	found, err = userDAL.ExistsByEmail(ctx, "exists@example.com")
+	assert.NoError(t, err)
+	assert.True(t, found)
+
+	// Once GetByEmail cached the user the check is answered from the cache.
+	_, err = userDAL.GetByEmail(ctx, "exists@example.com")
+	assert.NoError(t, err)
+	found, err = userDAL.ExistsByEmail(ctx, "exists@example.com")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	cacheHits := testutil.ToFloat64(dalCacheHitsCounter.WithLabelValues("user", "exists_by_email"))
+	assert.Equal(t, 1.0, cacheHits)
+
+	// The email mapping outlives the delete, the query has the last word.
+	assert.NoError(t, userDAL.Delete(ctx, user))
+	found, err = userDAL.ExistsByEmail(ctx, "exists@example.com")
+	assert.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestUserPatches(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB(t)
+
+	userDAL := NewUserRepository(
+		dbProvider,
+		nil,
+		nil,
+		gobreaker.Settings{},
+		PrometheusTelemetryProvider{},
+	)
+	ctx := context.Background()
+
+	user, err := userDAL.Create(ctx, &User{Age: 30, Email: "patch@example.com", Status: Ptr(UserStatusActive)})
+	assert.NoError(t, err)
+
+	// Cache the list by status, patching the status has to invalidate it.
+	listed, _, err := userDAL.ListByStatus(ctx, Ptr(UserStatusActive), nil, 10)
+	assert.NoError(t, err)
+	assert.Len(t, listed, 1)
+
+	assert.NoError(t, userDAL.PatchStatus(ctx, user.ID, user.Version, Ptr(UserStatusBanned)))
+	listed, _, err = userDAL.ListByStatus(ctx, Ptr(UserStatusActive), nil, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, listed)
+
+	// Cache the email mapping, patching the email has to drop it.
+	_, err = userDAL.GetByEmail(ctx, "patch@example.com")
+	assert.NoError(t, err)
+	assert.NoError(t, userDAL.PatchEmail(ctx, user.ID, user.Version+1, "patched@example.com"))
+
+	_, err = userDAL.GetByEmail(ctx, "patch@example.com")
+	assert.ErrorIs(t, err, ErrNotFound)
+	patched, err := userDAL.GetByEmail(ctx, "patched@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, UserStatusBanned, *patched.Status)
+	assert.Equal(t, int8(30), patched.Age)
+	assert.Equal(t, user.Version+2, patched.Version)
+
+	assert.ErrorIs(t, userDAL.PatchStatus(ctx, user.ID, user.Version, Ptr(UserStatusActive)), ErrNotFound)
+}
+
+func TestUserIncrementAge(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB(t)
+
+	userDAL := NewUserRepository(
+		dbProvider,
+		nil,
+		nil,
+		gobreaker.Settings{},
+		PrometheusTelemetryProvider{},
+	)
+	ctx := context.Background()
+
+	user, err := userDAL.Create(ctx, &User{Age: 30, Email: "increment@example.com", Status: Ptr(UserStatusActive)})
+	assert.NoError(t, err)
+
+	// Concurrent increments don't conflict.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := userDAL.IncrementAge(ctx, user.ID, 2)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	age, err := userDAL.IncrementAge(ctx, user.ID, -5)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(45), age)
+
+	fetched, err := userDAL.GetByID(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int8(45), fetched.Age)
+
+	// The result is clamped to the floor and ceiling.
+	age, err = userDAL.IncrementAge(ctx, user.ID, -100)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), age)
+	age, err = userDAL.IncrementAge(ctx, user.ID, 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(127), age)
+
+	// Updates of the version read before the increments fail.
+	assert.ErrorIs(t, userDAL.Update(ctx, fetched), ErrNotFound)
+
+	_, err = userDAL.IncrementAge(ctx, 999999, 1)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestUserListByStatuses(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB(t)
+
+	userDAL := NewUserRepository(
+		dbProvider,
+		nil,
+		nil,
+		gobreaker.Settings{},
+		PrometheusTelemetryProvider{},
+	)
+	ctx := context.Background()
+
+	for i, status := range []UserStatus{UserStatusActive, UserStatusBanned, UserStatusPending, UserStatusActive} {
+		_, err := userDAL.Create(ctx, &User{
+			Age:    20,
+			Email:  fmt.Sprintf("in_%d@example.com", i),
+			Status: Ptr(status),
+		})
+		assert.NoError(t, err)
+	}
+
+	users, next, err := userDAL.ListByStatuses(ctx, []UserStatus{UserStatusActive, UserStatusPending}, nil, 10)
+	assert.NoError(t, err)
+	assert.Nil(t, next)
+	assert.Len(t, users, 3)
+
+	count, err := userDAL.CountListByStatuses(ctx, []UserStatus{UserStatusBanned, UserStatusPending})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// The values are part of the cache key, in any order.
+	users, _, err = userDAL.ListByStatuses(ctx, []UserStatus{UserStatusPending, UserStatusActive}, nil, 10)
+	assert.NoError(t, err)
+	assert.Len(t, users, 3)
+	users, _, err = userDAL.ListByStatuses(ctx, []UserStatus{UserStatusBanned}, nil, 10)
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+
+	users, _, err = userDAL.ListByStatuses(ctx, nil, nil, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, users)
+
+	_, _, err = userDAL.ListByStatuses(ctx, make([]UserStatus, 501), nil, 10)
+	assert.Error(t, err)
+}
+
+func TestUserSearch(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB(t)
+
+	userDAL := NewUserRepository(
+		dbProvider,
+		nil,
+		nil,
+		gobreaker.Settings{},
+		PrometheusTelemetryProvider{},
+	)
+	ctx := context.Background()
+
+	for i, age := range []int8{18, 25, 32, 40} {
+		status := UserStatusActive
+		if i%2 == 1 {
+			status = UserStatusPending
+		}
+		_, err := userDAL.Create(ctx, &User{
+			Age:    age,
+			Email:  fmt.Sprintf("search_%d@example.com", i),
+			Status: Ptr(status),
+		})
+		assert.NoError(t, err)
+	}
+
+	// Without filters every user is listed.
+	users, _, err := userDAL.Search(ctx, UserSearchFilter{}, nil, 10)
+	assert.NoError(t, err)
+	assert.Len(t, users, 4)
+
+	users, _, err = userDAL.Search(ctx, UserSearchFilter{Status: Ptr(UserStatusActive)}, nil, 10)
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+
+	users, _, err = userDAL.Search(ctx, UserSearchFilter{MinAge: Ptr(int8(20)), MaxAge: Ptr(int8(35))}, nil, 10)
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+
+	count, err := userDAL.CountSearch(ctx, UserSearchFilter{Status: Ptr(UserStatusPending), MinAge: Ptr(int8(30))})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	page, err := userDAL.SearchPage(ctx, UserSearchFilter{Status: Ptr(UserStatusActive)}, "", 1)
+	assert.NoError(t, err)
+	assert.True(t, page.HasMore)
+	page, err = userDAL.SearchPage(ctx, UserSearchFilter{Status: Ptr(UserStatusActive)}, page.NextPageToken, 1)
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 1)
+	assert.False(t, page.HasMore)
+}
+
 func TestListByIdCachingAndInvalidation(t *testing.T) {
 	t.Run("TestListByIdCachingAndInvalidation", func(t *testing.T) {
 		setupTestDB(t)
@@ -553,7 +923,7 @@ func TestListByIdCachingAndInvalidation(t *testing.T) {
 			newUser := &User{
 				Age:       25,
 				Email:     fmt.Sprintf("cache_test_%d@example.com", i),
-				Status:    Ptr("active"),
+				Status:    Ptr(UserStatusActive),
 				Birthdate: Ptr(time.Now()),
 			}
 			created, err := userDAL.Create(ctx, newUser)
@@ -621,7 +991,7 @@ func TestListByBday(t *testing.T) {
 			newUser := &User{
 				Age:       30,
 				Email:     fmt.Sprintf("user_%02d@example.com", month),
-				Status:    Ptr("active"),
+				Status:    Ptr(UserStatusActive),
 				Birthdate: &birthdate,
 			}
 			created, err := userDAL.Create(ctx, newUser)
@@ -639,13 +1009,14 @@ func TestListByBday(t *testing.T) {
 		assert.Equal(t, int64(4), count)
 
 		// First call: should load from DB and populate the cache.
-		results, err := userDAL.ListByBday(ctx, nullBday, 0, pageSize)
+		results, next, err := userDAL.ListByBday(ctx, nullBday, nil, pageSize)
 		assert.NoError(t, err)
 		assert.Len(t, results, 4, "Expected 4 users for birthdate %v", bdayToTest)
 		assert.Equal(t, "user_04@example.com", results[0].Email)
+		assert.Nil(t, next, "Expected no cursor after the last page")
 
 		// Second call: should hit the cache.
-		resultsCached, err := userDAL.ListByBday(ctx, nullBday, 0, pageSize)
+		resultsCached, _, err := userDAL.ListByBday(ctx, nullBday, nil, pageSize)
 		assert.NoError(t, err)
 		assert.Len(t, resultsCached, 4, "Expected 4 users from cache for birthdate %v", bdayToTest)
 		assert.Equal(t, "user_04@example.com", resultsCached[0].Email)
@@ -653,6 +1024,22 @@ func TestListByBday(t *testing.T) {
 		// Verify that the telemetry counter for "list_by_bday" has incremented by 2.
 		listByBdayCounter := testutil.ToFloat64(dalOperationsTotalCounter.WithLabelValues("user", "list_by_bday"))
 		assert.Equal(t, 2.0, listByBdayCounter, "Expected list_by_bday counter to equal 2 after two calls")
+
+		// Page through the list with the returned cursors.
+		var paged []string
+		var cursor *UserListByBdayCursor
+		for {
+			page, next, err := userDAL.ListByBday(ctx, nullBday, cursor, 3)
+			assert.NoError(t, err)
+			for _, u := range page {
+				paged = append(paged, u.Email)
+			}
+			if next == nil {
+				break
+			}
+			cursor = next
+		}
+		assert.Equal(t, []string{"user_04@example.com", "user_03@example.com", "user_02@example.com", "user_01@example.com"}, paged)
 	})
 }
 
@@ -676,7 +1063,7 @@ func TestOptimisticLocking(t *testing.T) {
 		newUser := &User{
 			Age:       25,
 			Email:     "optimistic@example.com",
-			Status:    Ptr("active"),
+			Status:    Ptr(UserStatusActive),
 			Birthdate: Ptr(time.Now()),
 		}
 		created, err := userDAL.Create(ctx, newUser)
@@ -726,7 +1113,7 @@ func TestUserSoftAndHardDelete(t *testing.T) {
 		ghostUser := &User{
 			Age:       25,
 			Email:     originalEmail,
-			Status:    Ptr("active"),
+			Status:    Ptr(UserStatusActive),
 			Birthdate: Ptr(time.Now()),
 		}
 
@@ -762,7 +1149,7 @@ func TestUserSoftAndHardDelete(t *testing.T) {
 		returningUser := &User{
 			Age:       30,
 			Email:     originalEmail, // Using the exact same email!
-			Status:    Ptr("active"),
+			Status:    Ptr(UserStatusActive),
 			Birthdate: Ptr(time.Now()),
 		}
 
@@ -799,7 +1186,7 @@ func TestCustomBulkDeletes(t *testing.T) {
 			_, err := userDAL.Create(ctx, &User{
 				Age:       age,
 				Email:     fmt.Sprintf("user_age_%d@example.com", age),
-				Status:    Ptr("active"),
+				Status:    Ptr(UserStatusActive),
 				Birthdate: Ptr(time.Now()),
 			})
 			assert.NoError(t, err)
@@ -871,7 +1258,7 @@ func TestUserBulkGets(t *testing.T) {
 			u, err := userDAL.Create(ctx, &User{
 				Age:       int8(20 + i),
 				Email:     fmt.Sprintf("bulkget_%d@example.com", i),
-				Status:    Ptr("active"),
+				Status:    Ptr(UserStatusActive),
 				Birthdate: Ptr(time.Now()),
 			})
 			assert.NoError(t, err)
@@ -935,7 +1322,7 @@ func TestUserBulkUpdates(t *testing.T) {
 			u, err := userDAL.Create(ctx, &User{
 				Age:       25,
 				Email:     fmt.Sprintf("bulkupdate_%d@example.com", i),
-				Status:    Ptr("active"),
+				Status:    Ptr(UserStatusActive),
 				Birthdate: Ptr(time.Now()),
 			})
 			assert.NoError(t, err)
@@ -956,7 +1343,7 @@ func TestUserBulkUpdates(t *testing.T) {
 		assert.NoError(t, err)
 
 		// 3. Test UpdateStatusByUids
-		newStatus := "suspended"
+		newStatus := UserStatusSuspended
 		err = userDAL.UpdateStatusByUids(ctx, &newStatus, uidsToUpdate)
 		assert.NoError(t, err)
 
@@ -965,14 +1352,14 @@ func TestUserBulkUpdates(t *testing.T) {
 
 		// Fetch u1 to verify the update worked (this triggers the cache miss because of the flush)
 		u1, _ := userDAL.GetByUid(ctx, uidsToUpdate[0])
-		assert.Equal(t, "suspended", *u1.Status)
+		assert.Equal(t, UserStatusSuspended, *u1.Status)
 
 		missesAfter := testutil.ToFloat64(dalCacheMissesCounter.WithLabelValues("user", "get_by_uid"))
 		assert.Greater(t, missesAfter, missesBefore, "Expected cache miss after bulk update flushed the cache")
 
 		// Verify control user was NOT updated
 		u3, _ := userDAL.GetByUid(ctx, controlUser.Uid)
-		assert.Equal(t, "active", *u3.Status)
+		assert.Equal(t, UserStatusActive, *u3.Status)
 
 		// 5. Test UpdateAgeByIds
 		newAge := int8(99)
@@ -992,3 +1379,257 @@ func TestUserBulkUpdates(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+func TestUserUpsert(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB(t)
+
+	userDAL := NewUserRepository(
+		dbProvider,
+		nil, nil, gobreaker.Settings{}, PrometheusTelemetryProvider{},
+	)
+	ctx := context.Background()
+
+	// 1. Upsert of a new email inserts the user
+	inserted, err := userDAL.UpsertByEmail(ctx, &User{
+		Age:    20,
+		Email:  "upsert@example.com",
+		Status: Ptr(UserStatusActive),
+	})
+	assert.NoError(t, err)
+	assert.NotZero(t, inserted.ID)
+	assert.Equal(t, int64(0), inserted.Version)
+	insertedUid := inserted.Uid
+
+	// 2. Upsert of the same email overwrites status and age only
+	updated, err := userDAL.UpsertByEmail(ctx, &User{
+		Age:       30,
+		Email:     "upsert@example.com",
+		Status:    Ptr(UserStatusSuspended),
+		Birthdate: Ptr(time.Now()),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, inserted.ID, updated.ID, "LAST_INSERT_ID should point at the existing row")
+	assert.Equal(t, int64(1), updated.Version)
+	assert.Equal(t, insertedUid, updated.Uid, "uid is not overwritten")
+	assert.Nil(t, updated.Birthdate, "birthdate is not overwritten")
+
+	// 3. Cached lookups see the upserted values
+	fetched, err := userDAL.GetByEmail(ctx, "upsert@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, int8(30), fetched.Age)
+	assert.Equal(t, UserStatusSuspended, *fetched.Status)
+
+	// 4. Upserting the stored values doesn't bump the version
+	unchanged, err := userDAL.UpsertByEmail(ctx, &User{
+		Age:    30,
+		Email:  "upsert@example.com",
+		Status: Ptr(UserStatusSuspended),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, inserted.ID, unchanged.ID)
+	assert.Equal(t, int64(1), unchanged.Version)
+
+	// 5. The bumped version keeps optimistic locking working for Update
+	fetched.Age = 31
+	assert.NoError(t, userDAL.Update(ctx, fetched))
+}
+
+func TestUserUpsertBulk(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB(t)
+
+	userDAL := NewUserRepository(
+		dbProvider,
+		nil, nil, gobreaker.Settings{}, PrometheusTelemetryProvider{},
+	)
+	ctx := context.Background()
+
+	// 1. Seed rows that will stay unchanged and be updated
+	kept, err := userDAL.Create(ctx, &User{Age: 20, Email: "bulkupsert_kept@example.com", Status: Ptr(UserStatusActive)})
+	assert.NoError(t, err)
+	changed, err := userDAL.Create(ctx, &User{Age: 20, Email: "bulkupsert_changed@example.com", Status: Ptr(UserStatusActive)})
+	assert.NoError(t, err)
+
+	// Warm the cache of the untouched row
+	_, err = userDAL.GetByID(ctx, kept.ID)
+	assert.NoError(t, err)
+
+	// 2. Upsert more rows than a single chunk holds
+	users := []*User{
+		{Age: 20, Email: "bulkupsert_kept@example.com", Status: Ptr(UserStatusActive)},
+		{Age: 21, Email: "bulkupsert_changed@example.com", Status: Ptr(UserStatusActive)},
+	}
+	for i := 0; i < 600; i++ {
+		users = append(users, &User{Age: 30, Email: fmt.Sprintf("bulkupsert_%d@example.com", i)})
+	}
+
+	outcomes, err := userDAL.UpsertBulkByEmail(ctx, users)
+	assert.NoError(t, err)
+	assert.Len(t, outcomes, len(users))
+	assert.Equal(t, UpsertUnchanged, outcomes[0])
+	assert.Equal(t, UpsertUpdated, outcomes[1])
+
+	// 3. Real IDs are resolved for updated and inserted rows
+	assert.Equal(t, kept.ID, users[0].ID)
+	assert.Equal(t, changed.ID, users[1].ID)
+	assert.Equal(t, int64(1), users[1].Version)
+	assert.Equal(t, changed.Uid, users[1].Uid)
+
+	for i, u := range users[2:] {
+		assert.Equal(t, UpsertInserted, outcomes[i+2])
+		fetched, err := userDAL.GetByID(ctx, u.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, u.Email, fetched.Email)
+	}
+
+	// 4. The updated row is invalidated, the unchanged one keeps its cache entry
+	fetched, err := userDAL.GetByEmail(ctx, "bulkupsert_changed@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, int8(21), fetched.Age)
+
+	hitsBefore := testutil.ToFloat64(dalCacheHitsCounter.WithLabelValues("user", "get_by_id"))
+	_, err = userDAL.GetByID(ctx, kept.ID)
+	assert.NoError(t, err)
+	hitsAfter := testutil.ToFloat64(dalCacheHitsCounter.WithLabelValues("user", "get_by_id"))
+	assert.Greater(t, hitsAfter, hitsBefore, "Expected the unchanged row to stay cached")
+
+	// 5. Emails are matched like the collation compares them, ignoring case and accents
+	users = []*User{{Age: 22, Email: "BulkUpsert_Changed@Example.com", Status: Ptr(UserStatusActive)}}
+	outcomes, err = userDAL.UpsertBulkByEmail(ctx, users)
+	assert.NoError(t, err)
+	assert.Equal(t, []UpsertOutcome{UpsertUpdated}, outcomes)
+	assert.Equal(t, changed.ID, users[0].ID)
+	assert.Equal(t, int8(22), users[0].Age)
+
+	users = []*User{{Age: 23, Email: "bulkupsert_chánged@example.com", Status: Ptr(UserStatusActive)}}
+	outcomes, err = userDAL.UpsertBulkByEmail(ctx, users)
+	assert.NoError(t, err)
+	assert.Equal(t, []UpsertOutcome{UpsertUpdated}, outcomes)
+	assert.Equal(t, changed.ID, users[0].ID)
+
+	_, err = userDAL.UpsertBulkByEmail(ctx, []*User{{Email: "twice@example.com"}, {Email: "TWICE@example.com"}})
+	assert.Error(t, err)
+}
+
+func TestUserHistory(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB(t)
+
+	userDAL := NewUserRepository(
+		dbProvider,
+		nil,
+		nil,
+		gobreaker.Settings{},
+		PrometheusTelemetryProvider{},
+	)
+	ctx := WithActor(context.Background(), "support:7")
+
+	user, err := userDAL.Create(ctx, &User{Age: 90, Email: "history@example.com", Status: Ptr(UserStatusActive)})
+	assert.NoError(t, err)
+
+	user.Status = Ptr(UserStatusSuspended)
+	assert.NoError(t, userDAL.Update(ctx, user))
+	_, err = userDAL.IncrementAge(ctx, user.ID, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, userDAL.UpdateStatusByUids(ctx, Ptr(UserStatusActive), []string{user.Uid}))
+
+	deleted, err := userDAL.DeleteOlder(ctx, 90, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	deleted, err = userDAL.HardDeleteOlder(ctx, 90, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	history, err := userDAL.History(ctx, user.ID, 0, 10)
+	assert.NoError(t, err)
+	if !assert.Len(t, history, 6) {
+		return
+	}
+
+	var operations []string
+	for _, entry := range history {
+		operations = append(operations, entry.Operation)
+		assert.Equal(t, "support:7", entry.Actor)
+	}
+	assert.Equal(t, []string{"create", "update", "increment_age", "update_bulk_update_status_by_uids", "delete_older", "hard_delete_older"}, operations)
+
+	assert.Nil(t, history[0].OldValues)
+	assert.JSONEq(t, `{"status": "active"}`, string(history[1].OldValues))
+	assert.JSONEq(t, `{"status": "suspended"}`, string(history[1].NewValues))
+	assert.Equal(t, int32(1), history[1].Version)
+	assert.JSONEq(t, `{"age": 91}`, string(history[2].NewValues))
+	assert.Nil(t, history[5].NewValues)
+
+	// Pages continue after the last entry of the previous page.
+	page, err := userDAL.History(ctx, user.ID, history[3].ID, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, page, 2) {
+		assert.Equal(t, history[4].ID, page[0].ID)
+	}
+}
+
+// outboxPublisher collects the published events, failing while err is set.
+type outboxPublisher struct {
+	err    error
+	events []*OutboxEvent
+}
+
+func (p *outboxPublisher) Publish(ctx context.Context, events []*OutboxEvent) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.events = append(p.events, events...)
+	return nil
+}
+
+func TestUserOutbox(t *testing.T) {
+	setupTestDB(t)
+	defer teardownTestDB(t)
+
+	userDAL := NewUserRepository(
+		dbProvider,
+		nil,
+		nil,
+		gobreaker.Settings{},
+		PrometheusTelemetryProvider{},
+	)
+	ctx := context.Background()
+
+	user, err := userDAL.Create(ctx, &User{Age: 30, Email: "outbox@example.com", Status: Ptr(UserStatusActive)})
+	assert.NoError(t, err)
+	_, err = userDAL.IncrementAge(ctx, user.ID, 1)
+	assert.NoError(t, err)
+
+	db, err := dbProvider.GetDatabase("user", true)
+	assert.NoError(t, err)
+	publisher := &outboxPublisher{err: errors.New("broker down")}
+	relay := NewOutboxRelay(db, publisher, OutboxRelayOptions{RetryDelay: time.Hour})
+
+	// A failed batch waits for its retry.
+	relayed, err := relay.RelayOnce(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, 2, relayed)
+	publisher.err = nil
+	relayed, err = relay.RelayOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, relayed)
+
+	_, err = db.ExecContext(ctx, "UPDATE dal_outbox SET next_attempt_at = ?", time.Now().Add(-time.Second))
+	assert.NoError(t, err)
+	relayed, err = relay.RelayOnce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, relayed)
+
+	if !assert.Len(t, publisher.events, 2) {
+		return
+	}
+	assert.Equal(t, "create", publisher.events[0].Operation)
+	assert.Equal(t, "increment_age", publisher.events[1].Operation)
+	assert.Equal(t, 1, publisher.events[1].Attempts)
+
+	var incremented User
+	assert.NoError(t, json.Unmarshal(publisher.events[1].Payload, &incremented))
+	assert.Equal(t, user.ID, incremented.ID)
+	assert.Equal(t, int8(31), incremented.Age)
+}
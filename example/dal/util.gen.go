@@ -5,6 +5,8 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 )
 
 var (
@@ -29,3 +31,28 @@ func GenerateUID(prefix string) string {
 	randomStr := hex.EncodeToString(b)
 	return fmt.Sprintf("%s_%s", prefix, randomStr)
 }
+
+// rebindPostgres converts '?' placeholders into PostgreSQL's positional $1, $2, ... placeholders.
+// Question marks inside single quoted string literals are left untouched.
+func rebindPostgres(query string) string {
+	var b strings.Builder
+	b.Grow(len(query) + 16)
+
+	position := 0
+	inLiteral := false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'':
+			inLiteral = !inLiteral
+			b.WriteByte(c)
+		case c == '?' && !inLiteral:
+			position++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(position))
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,118 @@
+package dal
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError is a validation rule broken by a field of an entity.
+type FieldError struct {
+	Field   string `json:"field"`   // Column name, e.g. "email"
+	Rule    string `json:"rule"`    // Rule of the column YAML, e.g. "maxLength" or "format"
+	Message string `json:"message"` // e.g. "must be at most 255 characters long"
+}
+
+// ValidationError is returned by the Validate methods of entities, and by the writes calling them,
+// listing every field breaking a validation rule of its column.
+type ValidationError struct {
+	Entity string       `json:"entity"`
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		messages[i] = field.Field + " " + field.Message
+	}
+	return fmt.Sprintf("invalid %s: %s", e.Entity, strings.Join(messages, "; "))
+}
+
+// validator collects the rules broken by the fields of an entity.
+type validator struct {
+	entity string
+	fields []FieldError
+}
+
+func (v *validator) fail(field, rule, format string, args ...interface{}) {
+	v.fields = append(v.fields, FieldError{Field: field, Rule: rule, Message: fmt.Sprintf(format, args...)})
+}
+
+// err returns the *ValidationError listing the broken rules, or nil when there are none.
+func (v *validator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Entity: v.entity, Fields: v.fields}
+}
+
+func (v *validator) minLength(field, value string, min int) {
+	if utf8.RuneCountInString(value) < min {
+		v.fail(field, "minLength", "must be at least %d characters long", min)
+	}
+}
+
+func (v *validator) maxLength(field, value string, max int) {
+	if utf8.RuneCountInString(value) > max {
+		v.fail(field, "maxLength", "must be at most %d characters long", max)
+	}
+}
+
+func (v *validator) pattern(field, value string, pattern *regexp.Regexp) {
+	if !pattern.MatchString(value) {
+		v.fail(field, "pattern", "must match %s", pattern)
+	}
+}
+
+// email accepts a bare address like "jane@example.com", without a display name.
+func (v *validator) email(field, value string) {
+	address, err := mail.ParseAddress(value)
+	if err != nil || address.Address != value {
+		v.fail(field, "format", "must be a valid email address")
+	}
+}
+
+// url accepts absolute URLs with a scheme and a host.
+func (v *validator) url(field, value string) {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		v.fail(field, "format", "must be a valid URL")
+	}
+}
+
+// decimal checks the value fits a DECIMAL(precision, scale) column, which would reject extra digits before
+// the decimal point and round extra ones after it.
+func (v *validator) decimal(field string, value Decimal, precision, scale int) {
+	intDigits, fracDigits := value.digits()
+	if intDigits > precision-scale || fracDigits > scale {
+		v.fail(field, "precision", "must have at most %d digits before and %d after the decimal point", precision-scale, scale)
+	}
+}
+
+type validatedNumber interface {
+	~int8 | ~int32 | ~int64 | ~float64
+}
+
+func checkMin[N validatedNumber](v *validator, field string, value, min N) {
+	if value < min {
+		v.fail(field, "min", "must be at least %v", min)
+	}
+}
+
+func checkMax[N validatedNumber](v *validator, field string, value, max N) {
+	if value > max {
+		v.fail(field, "max", "must be at most %v", max)
+	}
+}
+
+func checkOneOf[T comparable](v *validator, field string, value T, allowed ...T) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.fail(field, "oneOf", "must be one of %v", allowed)
+}
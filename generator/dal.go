@@ -4,9 +4,11 @@ import (
 	"crypto/sha256"
 	"embed"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
+	"strconv"
 	"strings"
 	"text/template"
 
@@ -18,8 +20,11 @@ import (
 var templateFS embed.FS
 
 type Generator struct {
-	dalTemplate *template.Template
-	sqlTemplate *template.Template
+	dalTemplate        *template.Template
+	sqlTemplate        *template.Template
+	migrationsTemplate *template.Template
+	driversTemplate    *template.Template
+	dialect            string // default dialect for entities that don't define one
 }
 
 func NewGenerator() (*Generator, error) {
@@ -30,8 +35,12 @@ func NewGenerator() (*Generator, error) {
 		"camelCase":                    CamelCaser,
 		"goField":                      PascalCaser,
 		"goColumn":                     toColumnName,
-		"toGoType":                     toGoType,
+		"columnGoType":                 columnGoType,
 		"toSQLType":                    toSQLType,
+		"columnDefinition":             columnDefinition,
+		"hasEnumColumn":                hasEnumColumn,
+		"enumConstant":                 enumConstant,
+		"aggregateGoType":              aggregateGoType,
 		"dict":                         dict,
 		"join":                         join,
 		"keys":                         keys,
@@ -49,13 +58,35 @@ func NewGenerator() (*Generator, error) {
 		"countFuncParams":              countFuncParams,
 		"countFuncCallParams":          countFuncCallParams,
 		"listCacheKey":                 listCacheKey,
+		"listUsesCursor":               listUsesCursor,
+		"listCursorType":               listCursorType,
+		"listOrderGoType":              listOrderGoType,
+		"listPageCondition":            listPageCondition,
+		"listOrderBy":                  listOrderBy,
+		"listFilterType":               listFilterType,
+		"listFilterFields":             listFilterFields,
+		"listFilters":                  listFilters,
 		"countCacheKey":                countCacheKey,
 		"listSQLIndexes":               listSQLIndexes,
+		"historyTableSQL":              historyTableSQL,
+		"outboxTableSQL":               outboxTableSQL,
+		"tracksChanges":                tracksChanges,
+		"uniqueSQLIndexes":             uniqueSQLIndexes,
 		"checkColumnsChanged":          checkColumnsChanged,
 		"invalidateUniqueColumnsCache": invalidateUniqueColumnsCache,
 		"hasJSONColumn":                hasJSONColumn,
+		"hasValidation":                hasValidation,
+		"hasRules":                     Column.hasRules,
+		"columnsHaveRules":             columnsHaveRules,
+		"hasPatternRule":               hasPatternRule,
+		"isTextColumn":                 isTextColumn,
+		"ruleNumber":                   ruleNumber,
+		"ruleOneOf":                    ruleOneOf,
+		"quote":                        strconv.Quote,
 		"uniqueStringColumns":          uniqueStringColumns,
 		"deleteQuery":                  deleteQuery,
+		"auditDeleteWhere":             auditDeleteWhere,
+		"auditDeleteQuery":             auditDeleteQuery,
 		"deleteFuncParams":             deleteFuncParams,
 		"deleteFuncCallParams":         deleteFuncCallParams,
 		"deleteQueryParams":            deleteQueryParams,
@@ -67,12 +98,51 @@ func NewGenerator() (*Generator, error) {
 		"listBulkInnerCallArgs":        listBulkInnerCallArgs,
 		"listBulkQueryWhere":           listBulkQueryWhere,
 		"extractParams":                extractParams,
+		"whereSliceParams":             whereSliceParams,
 		"trimPrefix":                   strings.TrimPrefix,
 		"pluckFuncParams":              pluckFuncParams,
 		"pluckFuncCallParams":          pluckFuncCallParams,
 		"pluckQueryParams":             pluckQueryParams,
 		"pluckCacheKey":                pluckCacheKey,
 		"pluckQueryWhere":              pluckQueryWhere,
+		"aggregateName":                aggregateName,
+		"aggregateList":                aggregateList,
+		"aggregateQuery":               aggregateQuery,
+		"aggregateCacheKey":            aggregateCacheKey,
+		"groupCountName":               groupCountName,
+		"groupCountList":               groupCountList,
+		"groupCountQuery":              groupCountQuery,
+		"groupCountCacheKey":           groupCountCacheKey,
+		"patchName":                    patchName,
+		"patchFuncParams":              patchFuncParams,
+		"patchMappedSetColumns":        patchMappedSetColumns,
+		"patchTouchesLists":            patchTouchesLists,
+		"incrementQuery":               incrementQuery,
+		"incrementTouchesLists":        incrementTouchesLists,
+		"existsName":                   existsName,
+		"existsList":                   existsList,
+		"existsQuery":                  existsQuery,
+		"existsUniqueColumn":           existsUniqueColumn,
+		"iterateAllList":               iterateAllList,
+		"searchList":                   searchList,
+		"searchQuery":                  searchQuery,
+		"searchQueryParams":            searchQueryParams,
+		"searchCacheKey":               searchCacheKey,
+		"isPostgres":                   isPostgres,
+		"isSQLite":                     isSQLite,
+		"isMySQL":                      isMySQL,
+		"upsertQuery":                  upsertQuery,
+		"upsertBulkConflictClause":     upsertBulkConflictClause,
+		"upsertMappedSetColumns":       upsertMappedSetColumns,
+		"sqlNow":                       sqlNow,
+		"sqlComment":                   sqlComment,
+		"primaryKeyDefinition":         primaryKeyDefinition,
+		"tableOptions":                 tableOptions,
+		"indexName":                    indexName,
+		"bindQuery":                    bindQuery,
+		"lockingRead":                  lockingRead,
+		"deleteLimitClause":            deleteLimitClause,
+		"scrambleUniqueColumn":         scrambleUniqueColumn,
 	}
 
 	dalTmpl, err := template.New("dal").Funcs(funcMap).ParseFS(templateFS, "templates/dal/*.tmpl")
@@ -85,12 +155,36 @@ func NewGenerator() (*Generator, error) {
 		return nil, fmt.Errorf("failed to parse SQL template: %w", err)
 	}
 
+	migrationsTmpl, err := template.New("migrations").ParseFS(templateFS, "templates/migrations/*.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse migrations template: %w", err)
+	}
+
+	driversTmpl, err := template.New("drivers").ParseFS(templateFS, "templates/drivers/*.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse drivers template: %w", err)
+	}
+
 	return &Generator{
-		dalTemplate: dalTmpl,
-		sqlTemplate: sqlTmpl,
+		dalTemplate:        dalTmpl,
+		sqlTemplate:        sqlTmpl,
+		migrationsTemplate: migrationsTmpl,
+		driversTemplate:    driversTmpl,
+		dialect:            DialectMySQL,
 	}, nil
 }
 
+// SetDialect changes the dialect used for entities that don't set 'dialect' in their YAML.
+func (g *Generator) SetDialect(dialect string) error {
+	if errs := validateDialect(dialect); len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+	if dialect != "" {
+		g.dialect = dialect
+	}
+	return nil
+}
+
 func (g *Generator) GenerateDAL(yamlInput string) (string, error) {
 	config, err := g.parseYAML(yamlInput)
 	if err != nil {
@@ -110,11 +204,48 @@ func (g *Generator) GenerateDAL(yamlInput string) (string, error) {
 	return buf.String(), nil
 }
 
+// EntityDialect returns the dialect an entity is generated for, its own 'dialect' or the generation default.
+func (g *Generator) EntityDialect(yamlInput string) (string, error) {
+	var config struct {
+		Dialect string `yaml:"dialect"`
+	}
+	if err := yaml.Unmarshal([]byte(yamlInput), &config); err != nil {
+		return "", fmt.Errorf("YAML parsing failed: %w", err)
+	}
+	if config.Dialect == "" {
+		return g.dialect, nil
+	}
+	return config.Dialect, nil
+}
+
+// GenerateDrivers builds the Go file importing the database/sql drivers of the given dialects,
+// so a DAL only depends on the drivers its entities use.
+func (g *Generator) GenerateDrivers(dialects []string) (string, error) {
+	var data struct{ MySQL, Postgres, SQLite bool }
+	for _, dialect := range dialects {
+		switch dialect {
+		case DialectMySQL:
+			data.MySQL = true
+		case DialectPostgres:
+			data.Postgres = true
+		case DialectSQLite:
+			data.SQLite = true
+		default:
+			return "", fmt.Errorf("unsupported dialect: %s", dialect)
+		}
+	}
+
+	var buf strings.Builder
+	if err := g.driversTemplate.ExecuteTemplate(&buf, "drivers.tmpl", data); err != nil {
+		return "", fmt.Errorf("drivers generation failed: %w", err)
+	}
+	return buf.String(), nil
+}
+
 // Add this helper function in generator/dal.go
 func printScalabilityWarnings(config EntityConfig) {
 	checkOrClause := func(opType, opName, where string) {
-		// Look for " OR " with spaces to avoid matching words like "ORDER"
-		if strings.Contains(strings.ToUpper(where), " OR ") {
+		if whereHasOr(where) {
 			fmt.Printf("⚠️  SCALABILITY WARNING: %s operation '%s' in entity '%s' contains an 'OR' clause.\n", opType, opName, config.Name)
 			fmt.Printf("   -> MySQL struggles to optimize 'OR' conditions and may resort to Full Table Scans.\n")
 			fmt.Printf("   -> For large datasets (30M+ rows), consider splitting this into separate operations.\n\n")
@@ -122,7 +253,7 @@ func printScalabilityWarnings(config EntityConfig) {
 	}
 
 	for _, l := range config.Operations.Lists {
-		checkOrClause("List", l.Name, l.Where)
+		checkOrClause("List", l.Name, listWhere(l))
 	}
 	for _, lb := range config.Operations.ListsBulk {
 		checkOrClause("ListBulk", lb.Name, lb.Where)
@@ -130,9 +261,24 @@ func printScalabilityWarnings(config EntityConfig) {
 	for _, p := range config.Operations.Plucks {
 		checkOrClause("Pluck", p.Name, p.Where)
 	}
+	for _, a := range config.Operations.Aggregates {
+		checkOrClause("Aggregate", aggregateName(a), a.Where)
+	}
+	for _, g := range config.Operations.GroupCounts {
+		checkOrClause("GroupCount", groupCountName(g), g.Where)
+	}
+	for _, e := range config.Operations.Exists {
+		checkOrClause("Exists", existsName(e), e.Where)
+	}
 	for _, d := range config.Operations.Deletes {
 		checkOrClause("Delete", d.Name, d.Where)
 	}
+	for _, s := range config.Operations.Searches {
+		checkOrClause("Search", s.Name, s.Where)
+	}
+	for _, warning := range filterIndexWarnings(config) {
+		fmt.Printf("⚠️  SCALABILITY WARNING: %s in entity '%s'.\n\n", warning, config.Name)
+	}
 }
 
 func (g *Generator) parseYAML(yamlInput string) (EntityConfig, error) {
@@ -153,14 +299,36 @@ func (g *Generator) parseYAML(yamlInput string) (EntityConfig, error) {
 		}
 	}
 
+	// Entity level dialect wins over the generation level default
+	if config.Dialect == "" {
+		config.Dialect = g.dialect
+	}
+
 	// Set default value for list invalidation
 	if config.Caching.ListInvalidation == "" {
 		config.Caching.ListInvalidation = "flush"
 	}
 
+	// Searches keep their results for 10 seconds by default
+	for i := range config.Operations.Searches {
+		if config.Operations.Searches[i].CacheSeconds == 0 {
+			config.Operations.Searches[i].CacheSeconds = 10
+		}
+	}
+
+	// Enum values get a named type per column, prefixed with the entity so columns of entities don't collide
+	for colName, col := range config.Columns {
+		if col.Type == "enum" {
+			col.EnumType = PascalCaser(config.Name) + PascalCaser(colName)
+			config.Columns[colName] = col
+		}
+	}
+
 	err := ValidateEntityConfig(config)
 
 	if err == nil {
+		// Parameters compared with a column are typed like it
+		inferTypeMappings(&config)
 		printScalabilityWarnings(config)
 	}
 
@@ -205,13 +373,17 @@ func getDirectoryHash(efs embed.FS, inputDir string) (string, error) {
 }
 
 type EntityConfig struct {
-	TemplateVersion string               // This item is not loaded from yaml but is calculated at runtime
-	Name            string               `yaml:"name"`
-	Version         string               `yaml:"version"`
-	Columns         map[string]Column    `yaml:"columns"`
-	Operations      OperationConfig      `yaml:"operations"`
-	Caching         CachingConfig        `yaml:"caching"`
-	CircuitBreaker  CircuitBreakerConfig `yaml:"circuitbreaker"`
+	TemplateVersion  string               // This item is not loaded from yaml but is calculated at runtime
+	Name             string               `yaml:"name"`
+	Version          string               `yaml:"version"`
+	Dialect          string               `yaml:"dialect"` // mysql (default), postgres or sqlite
+	Columns          map[string]Column    `yaml:"columns"`
+	Audit            bool                 `yaml:"audit"`  // records every change in a <table>_history table
+	Outbox           bool                 `yaml:"outbox"` // records every change as an event in the dal_outbox table
+	Operations       OperationConfig      `yaml:"operations"`
+	Caching          CachingConfig        `yaml:"caching"`
+	CircuitBreaker   CircuitBreakerConfig `yaml:"circuitbreaker"`
+	GeneratedIndexes map[string]string    `yaml:"generatedIndexes"` // CREATE INDEX statements by name, only recorded in lock files
 }
 
 type CachingConfig struct {
@@ -232,6 +404,23 @@ type Column struct {
 	AllowNull bool   `yaml:"allowNull"`
 	Unique    bool   `yaml:"unique"`
 	Prefix    string `yaml:"prefix"` //
+
+	// Values are the allowed values of an enum column, each generating a constant of its Go type.
+	Values   []string `yaml:"values"`
+	EnumType string   `yaml:"-"` // Go type of enum values, e.g. UserStatus. Not loaded from yaml but set by parseYAML
+
+	// Precision and Scale of a decimal column: its total number of digits and the digits after the decimal point.
+	Precision int `yaml:"precision"`
+	Scale     int `yaml:"scale"`
+
+	// Validation rules checked by the generated Validate method of the entity. Unset rules aren't checked.
+	MinLength *int     `yaml:"minLength"` // varchar and text, in characters
+	MaxLength *int     `yaml:"maxLength"` // varchar and text, in characters
+	Pattern   string   `yaml:"pattern"`   // varchar and text, a Go regular expression the value must match
+	Format    string   `yaml:"format"`    // varchar and text, email or url
+	Min       *float64 `yaml:"min"`       // integers and float
+	Max       *float64 `yaml:"max"`       // integers and float
+	OneOf     []string `yaml:"oneOf"`     // varchar, text and integers, the allowed values
 }
 
 // UpdateBulkConfig defines a bulk partial update operation
@@ -248,6 +437,48 @@ type PluckConfig struct {
 	TypeMapping map[string]string `yaml:"typeMapping"`
 }
 
+// AggregateConfig generates a method returning Function(Column) over the rows matching Where,
+// named after them, e.g. SumAgeByStatus for sum of age where status = :status.
+type AggregateConfig struct {
+	Function    string            `yaml:"function"` // sum, avg, min or max
+	Column      string            `yaml:"column"`
+	Where       string            `yaml:"where"`
+	TypeMapping map[string]string `yaml:"typeMapping"`
+}
+
+// GroupCountConfig generates Count<By Column>Grouped, counting the rows matching Where per Column value.
+type GroupCountConfig struct {
+	Column      string            `yaml:"column"`
+	Where       string            `yaml:"where"`
+	TypeMapping map[string]string `yaml:"typeMapping"`
+}
+
+// PatchConfig generates Patch<Set>, updating only the Set columns of a single entity by id and version.
+type PatchConfig struct {
+	Set []string `yaml:"set"`
+}
+
+// IncrementConfig generates Increment<Column>, atomically adding a delta to an integer column.
+// The result is clamped to Floor and Ceiling when they are set.
+type IncrementConfig struct {
+	Column  string `yaml:"column"`
+	Floor   *int64 `yaml:"floor"`
+	Ceiling *int64 `yaml:"ceiling"`
+}
+
+// ExistsConfig generates ExistsBy<Params>, checking whether a row matches Where without loading it.
+type ExistsConfig struct {
+	Where       string            `yaml:"where"`
+	TypeMapping map[string]string `yaml:"typeMapping"`
+}
+
+// UpsertConfig generates UpsertBy<Column>, inserting an entity or overwriting the Set columns
+// of the row that already holds the same unique Column value.
+type UpsertConfig struct {
+	Column string   `yaml:"column"`
+	Set    []string `yaml:"set"`
+}
+
 type OperationConfig struct {
 	Gets        []string           `yaml:"gets"`
 	GetsBulk    []string           `yaml:"getsBulk"` // <-- NEW: For batch gets via IN clause
@@ -255,12 +486,31 @@ type OperationConfig struct {
 	ListsBulk   []ListBulkConfig   `yaml:"listsBulk"`
 	Deletes     []DeleteConfig     `yaml:"deletes"`
 	UpdatesBulk []UpdateBulkConfig `yaml:"updatesBulk"` // <-- NEW: For bulk partial updates
+	Upserts     []UpsertConfig     `yaml:"upserts"`
+	Patches     []PatchConfig      `yaml:"patches"`
+	Increments  []IncrementConfig  `yaml:"increments"`
 	Plucks      []PluckConfig      `yaml:"plucks"`
+	Aggregates  []AggregateConfig  `yaml:"aggregates"`
+	GroupCounts []GroupCountConfig `yaml:"groupCounts"`
+	Exists      []ExistsConfig     `yaml:"exists"`
+	Searches    []SearchConfig     `yaml:"searches"`
 	Write       bool               `yaml:"write"`
 	Delete      bool               `yaml:"delete"`
 	SoftDelete  bool               `yaml:"softDelete"`
 }
 
+// SearchConfig generates a relevance ordered full-text search over text columns, served by a
+// FULLTEXT index on exactly those columns. Mode is "natural" (default) or "boolean".
+type SearchConfig struct {
+	Name        string            `yaml:"name"`
+	Columns     []string          `yaml:"columns"`
+	Mode        string            `yaml:"mode"`
+	Where       string            `yaml:"where"`
+	TypeMapping map[string]string `yaml:"typeMapping"`
+	// CacheSeconds keeps results shorter than lists, as every query is a new cache key. Defaults to 10.
+	CacheSeconds int32 `yaml:"cacheSeconds"`
+}
+
 // DeleteConfig mirrors ListConfig but is tailored for bulk deletion operations
 type DeleteConfig struct {
 	Name        string            `yaml:"name"`
@@ -274,6 +524,17 @@ type ListConfig struct {
 	Order       string            `yaml:"order"`
 	Descending  bool              `yaml:"descending"`
 	TypeMapping map[string]string `yaml:"typeMapping"`
+	// Pagination "token" additionally generates <Name>Page returning a Page with an opaque next page token.
+	Pagination string `yaml:"pagination"`
+	// Filters replace Where with predicates built at runtime from a <Entity><Name>Filter struct.
+	Filters []FilterConfig `yaml:"filters"`
+}
+
+// FilterConfig is a predicate of a filtered list. Optional predicates are left out of the query
+// while their parameters are nil.
+type FilterConfig struct {
+	Where    string `yaml:"where"`
+	Optional bool   `yaml:"optional"`
 }
 
 // Add this new struct definition to generator/dal.go
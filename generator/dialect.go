@@ -0,0 +1,206 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Supported SQL dialects. MySQL is the default when neither the entity nor the generator sets one.
+const (
+	DialectMySQL    = "mysql"
+	DialectPostgres = "postgres"
+	DialectSQLite   = "sqlite"
+)
+
+var supportedDialects = []string{DialectMySQL, DialectPostgres, DialectSQLite}
+
+func isPostgres(dialect string) bool {
+	return dialect == DialectPostgres
+}
+
+func isSQLite(dialect string) bool {
+	return dialect == DialectSQLite
+}
+
+// isMySQL treats an unset dialect as MySQL, the historical default.
+func isMySQL(dialect string) bool {
+	return !isPostgres(dialect) && !isSQLite(dialect)
+}
+
+// sqlComment returns the line comment prefix used in generated .sql files.
+// MySQL keeps the historical '#', PostgreSQL and SQLite only understand '--'.
+func sqlComment(dialect string) string {
+	if isMySQL(dialect) {
+		return "#"
+	}
+	return "--"
+}
+
+// sqlNow returns the SQL expression for the current timestamp.
+func sqlNow(dialect string) string {
+	if isSQLite(dialect) {
+		return "CURRENT_TIMESTAMP"
+	}
+	return "NOW()"
+}
+
+// primaryKeyDefinition renders the type of the auto incremented id column.
+func primaryKeyDefinition(dialect string) string {
+	switch {
+	case isPostgres(dialect):
+		return "BIGSERIAL PRIMARY KEY"
+	case isSQLite(dialect):
+		// Only INTEGER PRIMARY KEY aliases the rowid
+		return "INTEGER PRIMARY KEY AUTOINCREMENT"
+	default:
+		return "BIGINT PRIMARY KEY AUTO_INCREMENT"
+	}
+}
+
+// tableOptions renders anything that goes after the closing bracket of CREATE TABLE.
+func tableOptions(dialect string) string {
+	if isMySQL(dialect) {
+		return " ENGINE=InnoDB"
+	}
+	return ""
+}
+
+// scrambleUniqueColumn renders the SET expression used by soft deletes to free up a unique value.
+func scrambleUniqueColumn(dialect string, col string) string {
+	switch {
+	case isPostgres(dialect):
+		return fmt.Sprintf("%s = %s || '-del-' || gen_random_uuid()::text", col, col)
+	case isSQLite(dialect):
+		return fmt.Sprintf("%s = %s || '-del-' || lower(hex(randomblob(16)))", col, col)
+	default:
+		return fmt.Sprintf("%s = CONCAT(%s, '-del-', UUID())", col, col)
+	}
+}
+
+// bindQuery emits the statement converting '?' placeholders of the query variable
+// into the dialect's native placeholders. MySQL and SQLite need no conversion.
+func bindQuery(dialect string) string {
+	if isPostgres(dialect) {
+		return "\n    query = rebindPostgres(query)"
+	}
+	return ""
+}
+
+// lockingRead renders the clause locking the rows read by a SELECT until the transaction ends.
+// SQLite has no row locks; its writes already lock the whole database.
+func lockingRead(dialect string) string {
+	if isSQLite(dialect) {
+		return ""
+	}
+	return " FOR UPDATE"
+}
+
+// limitsWithSubselect reports whether UPDATE/DELETE statements must be bounded through an id sub-select.
+// PostgreSQL has no UPDATE/DELETE ... LIMIT and SQLite only has it behind a compile time option.
+func limitsWithSubselect(dialect string) bool {
+	return !isMySQL(dialect)
+}
+
+// deleteLimitClause renders the format string appended to custom delete queries to bound them.
+// When limitsWithSubselect, deleteQuery opens an id sub-select which is closed here.
+func deleteLimitClause(dialect string) string {
+	if limitsWithSubselect(dialect) {
+		return " LIMIT %d)"
+	}
+	return " LIMIT %d"
+}
+
+// dropIndexStatement renders DROP INDEX for the dialect. PostgreSQL and SQLite index names are schema wide.
+func dropIndexStatement(dialect, indexName, tableName string) string {
+	if isMySQL(dialect) {
+		return fmt.Sprintf("DROP INDEX %s ON %s;", indexName, tableName)
+	}
+	return fmt.Sprintf("DROP INDEX %s;", indexName)
+}
+
+// modifyColumnStatement renders the statement changing the type or nullability of an existing column.
+// SQLite can't alter columns, GenerateMigration rejects such changes before getting here.
+func modifyColumnStatement(dialect, tableName, colName string, from, col columnSpec) string {
+	if isPostgres(dialect) {
+		nullability := "DROP NOT NULL"
+		if !col.AllowNull {
+			nullability = "SET NOT NULL"
+		}
+		statement := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s, ALTER COLUMN %s %s",
+			tableName, colName, col.SQLType, colName, nullability)
+		// PostgreSQL names the CHECK constraint of a column <table>_<column>_check
+		if from.Check != "" {
+			statement += fmt.Sprintf(", DROP CONSTRAINT %s_%s_check", tableName, colName)
+		}
+		if col.Check != "" {
+			statement += fmt.Sprintf(", ADD CONSTRAINT %s_%s_check CHECK (%s)", tableName, colName, col.Check)
+		}
+		return statement + ";"
+	}
+	return fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s;", tableName, colName, col.definition())
+}
+
+// indexName prefixes index names with the table name on PostgreSQL and SQLite, where index names
+// must be unique per schema instead of per table, and truncates them to the identifier limit.
+func indexName(config EntityConfig, signature string) string {
+	name := fmt.Sprintf("idx_%s", signature)
+	maxLength := 64 // MySQL's index name limit
+	if !isMySQL(config.Dialect) {
+		name = fmt.Sprintf("idx_%ss_%s", SnakeCaser(config.Name), signature)
+		maxLength = 63 // PostgreSQL's identifier limit, SQLite has none
+	}
+	if len(name) > maxLength {
+		name = name[:maxLength]
+	}
+	return name
+}
+
+// upsertConflictClause renders the clause turning an INSERT into an upsert on conflictColumn,
+// overwriting the set columns. MySQL's ON DUPLICATE KEY UPDATE fires on any unique key. The version
+// and updated timestamp only change when a set column does, so an upsert of the stored values keeps
+// the version callers hold for Update.
+func upsertConflictClause(dialect, tableName, conflictColumn string, set []string) string {
+	var assignments, comparisons []string
+	if isMySQL(dialect) {
+		for _, col := range set {
+			comparisons = append(comparisons, fmt.Sprintf("%s <=> VALUES(%s)", SnakeCaser(col), SnakeCaser(col)))
+		}
+		unchanged := strings.Join(comparisons, " AND ")
+
+		// MySQL assigns from left to right, so version and updated compare the set columns before they are overwritten.
+		assignments = append(assignments,
+			fmt.Sprintf("version = IF(%s, version, version + 1)", unchanged),
+			fmt.Sprintf("updated = IF(%s, updated, VALUES(updated))", unchanged))
+		for _, col := range set {
+			assignments = append(assignments, fmt.Sprintf("%s = VALUES(%s)", SnakeCaser(col), SnakeCaser(col)))
+		}
+		return " ON DUPLICATE KEY UPDATE " + strings.Join(assignments, ", ")
+	}
+
+	for _, col := range set {
+		comparisons = append(comparisons, fmt.Sprintf("%s.%s IS DISTINCT FROM excluded.%s", tableName, SnakeCaser(col), SnakeCaser(col)))
+		assignments = append(assignments, fmt.Sprintf("%s = excluded.%s", SnakeCaser(col), SnakeCaser(col)))
+	}
+	changed := strings.Join(comparisons, " OR ")
+	assignments = append(assignments,
+		fmt.Sprintf("updated = CASE WHEN %s THEN excluded.updated ELSE %s.updated END", changed, tableName),
+		fmt.Sprintf("version = CASE WHEN %s THEN %s.version + 1 ELSE %s.version END", changed, tableName, tableName))
+	return fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s", conflictColumn, strings.Join(assignments, ", "))
+}
+
+// clampExpression bounds expr to floor and ceiling when they are set. SQLite's
+// multi argument MIN and MAX are scalar functions like GREATEST and LEAST elsewhere.
+func clampExpression(dialect, expr string, floor, ceiling *int64) string {
+	greatest, least := "GREATEST", "LEAST"
+	if isSQLite(dialect) {
+		greatest, least = "MAX", "MIN"
+	}
+
+	if floor != nil {
+		expr = fmt.Sprintf("%s(%s, %d)", greatest, expr, *floor)
+	}
+	if ceiling != nil {
+		expr = fmt.Sprintf("%s(%s, %d)", least, expr, *ceiling)
+	}
+	return expr
+}
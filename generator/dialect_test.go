@@ -0,0 +1,280 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+const postgresYAML = `
+name: account
+version: v1
+dialect: postgres
+columns:
+  email:
+    type: varchar
+    unique: true
+  age:
+    type: int8
+operations:
+  write: true
+  softDelete: true
+  gets:
+    - email
+  deletes:
+    - name: delete_older
+      where: age > :age
+  upserts:
+    - column: email
+      set:
+        - age
+  lists:
+    - name: list_by_age
+      where: age > :age
+      order: age
+      pagination: token
+circuitbreaker:
+  timeoutSeconds: 20
+  consecutiveFailures: 4
+caching:
+  type: memory
+  singleExpirationSeconds: 300
+  listExpirationSeconds: 60
+  maxItemsCount: 1000
+`
+
+func TestGenerateSQL_Postgres(t *testing.T) {
+	gen, err := NewGenerator()
+	if err != nil {
+		t.Fatalf("failed creating generator: %v", err)
+	}
+
+	schema, err := gen.GenerateSQL(postgresYAML)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"id BIGSERIAL PRIMARY KEY,",
+		"age SMALLINT NOT NULL,",
+		"-- Unique indexes",
+		"CREATE UNIQUE INDEX idx_accounts_email ON accounts (email);",
+		"CREATE INDEX idx_accounts_deleted_at ON accounts (deleted_at);",
+	}
+	for _, part := range expected {
+		if !strings.Contains(schema, part) {
+			t.Errorf("expected schema to contain %q, got:\n%s", part, schema)
+		}
+	}
+
+	for _, mysqlOnly := range []string{"AUTO_INCREMENT", "ENGINE=InnoDB", "TINYINT", "#"} {
+		if strings.Contains(schema, mysqlOnly) {
+			t.Errorf("postgres schema must not contain %q, got:\n%s", mysqlOnly, schema)
+		}
+	}
+}
+
+func TestGenerateDAL_Postgres(t *testing.T) {
+	gen, err := NewGenerator()
+	if err != nil {
+		t.Fatalf("failed creating generator: %v", err)
+	}
+
+	dal, err := gen.GenerateDAL(postgresYAML)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"RETURNING id",
+		"query = rebindPostgres(query)",
+		"email = email || '-del-' || gen_random_uuid()::text",
+		"WHERE id IN (SELECT id FROM accounts WHERE (age > ?) AND deleted_at IS NULL` + fmt.Sprintf(\" LIMIT %d)\", limit)",
+		"table_schema = current_schema()",
+		"ON CONFLICT (email) DO UPDATE SET age = excluded.age, " +
+			"updated = CASE WHEN accounts.age IS DISTINCT FROM excluded.age THEN excluded.updated ELSE accounts.updated END, " +
+			"version = CASE WHEN accounts.age IS DISTINCT FROM excluded.age THEN accounts.version + 1 ELSE accounts.version END " +
+			"RETURNING id, version, age, email, created, updated, deleted_at",
+		"` ON CONFLICT (email) DO UPDATE SET age = excluded.age, " +
+			"updated = CASE WHEN accounts.age IS DISTINCT FROM excluded.age THEN excluded.updated ELSE accounts.updated END, " +
+			"version = CASE WHEN accounts.age IS DISTINCT FROM excluded.age THEN accounts.version + 1 ELSE accounts.version END`",
+		"ListByAgePage(ctx context.Context, age int8, pageToken string, pageSize int) (Page[Account], error)",
+		"decodePageToken(pageToken, \"account\", \"list_by_age\", paramsHash, cursor)",
+	}
+	for _, part := range expected {
+		if !strings.Contains(dal, part) {
+			t.Errorf("expected generated DAL to contain %q", part)
+		}
+	}
+
+	for _, mysqlOnly := range []string{"result.LastInsertId()", "UUID())"} {
+		if strings.Contains(dal, mysqlOnly) {
+			t.Errorf("postgres DAL must not contain %q", mysqlOnly)
+		}
+	}
+}
+
+func TestGenerator_SetDialect(t *testing.T) {
+	gen, err := NewGenerator()
+	if err != nil {
+		t.Fatalf("failed creating generator: %v", err)
+	}
+
+	if err := gen.SetDialect("oracle"); err == nil {
+		t.Errorf("expected error for unsupported dialect")
+	}
+
+	if err := gen.SetDialect(DialectPostgres); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	config, err := gen.parseYAML(migrationBaseYAML)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.Dialect != DialectPostgres {
+		t.Errorf("expected entity without dialect to use generator default, got %q", config.Dialect)
+	}
+}
+
+func TestGenerateDrivers(t *testing.T) {
+	gen, err := NewGenerator()
+	if err != nil {
+		t.Fatalf("failed creating generator: %v", err)
+	}
+
+	mysqlDialect, err := gen.EntityDialect(migrationBaseYAML)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	postgresDialect, err := gen.EntityDialect(postgresYAML)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	drivers, err := gen.GenerateDrivers([]string{mysqlDialect, postgresDialect, mysqlDialect})
+	if err != nil {
+		t.Fatalf("GenerateDrivers failed: %v", err)
+	}
+	for _, expected := range []string{`_ "github.com/go-sql-driver/mysql"`, `_ "github.com/jackc/pgx/v5/stdlib"`} {
+		if strings.Count(drivers, expected) != 1 {
+			t.Errorf("expected drivers to import %s once:\n%s", expected, drivers)
+		}
+	}
+	if strings.Contains(drivers, "modernc.org/sqlite") {
+		t.Errorf("expected no SQLite driver without SQLite entities:\n%s", drivers)
+	}
+
+	if _, err := gen.GenerateDrivers([]string{"oracle"}); err == nil {
+		t.Errorf("expected error for unsupported dialect")
+	}
+}
+
+func TestGenerateDAL_SQLite(t *testing.T) {
+	gen, err := NewGenerator()
+	if err != nil {
+		t.Fatalf("failed creating generator: %v", err)
+	}
+
+	sqliteYAML := strings.Replace(postgresYAML, "dialect: postgres", "dialect: sqlite", 1)
+
+	schema, err := gen.GenerateSQL(sqliteYAML)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, part := range []string{"id INTEGER PRIMARY KEY AUTOINCREMENT,", "-- Unique indexes", "CREATE UNIQUE INDEX idx_accounts_email ON accounts (email);"} {
+		if !strings.Contains(schema, part) {
+			t.Errorf("expected schema to contain %q, got:\n%s", part, schema)
+		}
+	}
+
+	dal, err := gen.GenerateDAL(sqliteYAML)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"email = email || '-del-' || lower(hex(randomblob(16)))",
+		"deleted_at = CURRENT_TIMESTAMP",
+		"FROM sqlite_master",
+		"firstID := lastID - rowsAffected + 1",
+		"WHERE id IN (SELECT id FROM accounts WHERE (age > ?) AND deleted_at IS NULL` + fmt.Sprintf(\" LIMIT %d)\", limit)",
+	}
+	for _, part := range expected {
+		if !strings.Contains(dal, part) {
+			t.Errorf("expected generated DAL to contain %q", part)
+		}
+	}
+
+	for _, otherDialect := range []string{"rebindPostgres(query)", "NOW()", "UUID())"} {
+		if strings.Contains(dal, otherDialect) {
+			t.Errorf("sqlite DAL must not contain %q", otherDialect)
+		}
+	}
+}
+
+func TestGenerateDAL_MySQLUpsert(t *testing.T) {
+	gen, err := NewGenerator()
+	if err != nil {
+		t.Fatalf("failed creating generator: %v", err)
+	}
+
+	mysqlYAML := strings.Replace(postgresYAML, "dialect: postgres", "dialect: mysql", 1)
+	dal, err := gen.GenerateDAL(mysqlYAML)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		"UpsertByEmail(ctx context.Context, entity *Account) (*Account, error)",
+		"ON DUPLICATE KEY UPDATE version = IF(age <=> VALUES(age), version, version + 1), " +
+			"updated = IF(age <=> VALUES(age), updated, VALUES(updated)), age = VALUES(age), id = LAST_INSERT_ID(id)",
+		"UpsertBulkByEmail(ctx context.Context, entities []*Account) ([]UpsertOutcome, error)",
+		"` ON DUPLICATE KEY UPDATE version = IF(age <=> VALUES(age), version, version + 1), " +
+			"updated = IF(age <=> VALUES(age), updated, VALUES(updated)), age = VALUES(age)`",
+		"case sameValue(existing.Age, entity.Age):",
+		`oldCacheKey := fmt.Sprintf("account_email:%s", oldEmail)`,
+	}
+	for _, part := range expected {
+		if !strings.Contains(dal, part) {
+			t.Errorf("expected generated DAL to contain %q", part)
+		}
+	}
+
+	if strings.Contains(dal, "ON CONFLICT") {
+		t.Errorf("mysql DAL must not contain ON CONFLICT")
+	}
+}
+
+func TestIncrementQuery(t *testing.T) {
+	floor, ceiling := int64(0), int64(100)
+	bounded := IncrementConfig{Column: "views", Floor: &floor, Ceiling: &ceiling}
+
+	tests := []struct {
+		dialect  string
+		inc      IncrementConfig
+		expected string
+	}{
+		{
+			dialect:  DialectMySQL,
+			inc:      bounded,
+			expected: "UPDATE posts SET views = LAST_INSERT_ID(LEAST(GREATEST(views + ?, 0), 100)), updated = ?, version = version + 1 WHERE id = ? AND deleted_at IS NULL",
+		},
+		{
+			dialect:  DialectPostgres,
+			inc:      IncrementConfig{Column: "views"},
+			expected: "UPDATE posts SET views = views + ?, updated = ?, version = version + 1 WHERE id = ? AND deleted_at IS NULL RETURNING views",
+		},
+		{
+			dialect:  DialectSQLite,
+			inc:      bounded,
+			expected: "UPDATE posts SET views = MIN(MAX(views + ?, 0), 100), updated = ?, version = version + 1 WHERE id = ? AND deleted_at IS NULL RETURNING views",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect, func(t *testing.T) {
+			if got := incrementQuery("post", tt.inc, true, tt.dialect); got != tt.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Migration holds the reversible schema change between two revisions of an entity.
+type Migration struct {
+	Entity  string
+	Version string
+	Number  int
+	Up      string
+	Down    string
+}
+
+// FileName returns the migration file name for the given direction ("up" or "down").
+// Example: user_0002_v2.up.sql
+func (m Migration) FileName(direction string) string {
+	return fmt.Sprintf("%s_%04d_%s.%s.sql", SnakeCaser(m.Entity), m.Number, m.Version, direction)
+}
+
+// GenerateMigration compares the previous revision of an entity YAML with the current one and
+// returns the ALTER TABLE / CREATE INDEX statements needed to move between them.
+// An empty previousYAML produces the initial migration creating the table.
+// It returns nil when the schema did not change.
+func (g *Generator) GenerateMigration(previousYAML, currentYAML string) (*Migration, error) {
+	current, err := g.parseYAML(currentYAML)
+	if err != nil {
+		return nil, err
+	}
+	current.GeneratedIndexes = nil // The current revision gets the indexes of the current generator
+
+	currentNumber, err := versionNumber(current.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	migration := &Migration{
+		Entity:  current.Name,
+		Version: current.Version,
+		Number:  currentNumber,
+	}
+
+	if strings.TrimSpace(previousYAML) == "" {
+		up, err := g.GenerateSQL(currentYAML)
+		if err != nil {
+			return nil, err
+		}
+		migration.Up = up
+		migration.Down = fmt.Sprintf("DROP TABLE %ss;\n", SnakeCaser(current.Name))
+		if current.Audit {
+			migration.Down = fmt.Sprintf("DROP TABLE %ss_history;\n", SnakeCaser(current.Name)) + migration.Down
+		}
+		return migration, nil
+	}
+
+	previous, err := g.parseYAML(previousYAML)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing previous revision: %w", err)
+	}
+
+	if previous.Name != current.Name {
+		return nil, fmt.Errorf("entity was renamed from '%s' to '%s'; renames are not supported by migrations", previous.Name, current.Name)
+	}
+	if previous.Dialect != current.Dialect {
+		return nil, fmt.Errorf("entity '%s' dialect changed from '%s' to '%s'; moving between databases is not supported by migrations", current.Name, previous.Dialect, current.Dialect)
+	}
+
+	up := schemaDiff(previous, current)
+	if len(up) == 0 {
+		return nil, nil
+	}
+
+	if isSQLite(current.Dialect) {
+		if changed := changedColumns(previous, current); len(changed) > 0 {
+			return nil, fmt.Errorf("entity '%s' changes the type of columns %s; SQLite can't alter existing columns", current.Name, strings.Join(changed, ", "))
+		}
+	}
+
+	previousNumber, err := versionNumber(previous.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing previous revision: %w", err)
+	}
+	if currentNumber <= previousNumber {
+		return nil, fmt.Errorf("entity '%s' schema changed but version was not bumped (previous %s, current %s)", current.Name, previous.Version, current.Version)
+	}
+
+	comment := sqlComment(current.Dialect)
+	migration.Up = fmt.Sprintf("%s Migration of %s from %s to %s\n", comment, current.Name, previous.Version, current.Version) +
+		strings.Join(up, "\n") + "\n"
+	migration.Down = fmt.Sprintf("%s Rollback of %s from %s to %s\n", comment, current.Name, current.Version, previous.Version) +
+		strings.Join(schemaDiff(current, previous), "\n") + "\n"
+
+	return migration, nil
+}
+
+// GenerateLock returns the lock file content of an entity revision: its YAML followed by the indexes the
+// generator creates for it. The next migration diffs against these recorded indexes instead of deriving them
+// again, so indexes changed by a newer generator get migrated too.
+func (g *Generator) GenerateLock(currentYAML string) (string, error) {
+	current, err := g.parseYAML(currentYAML)
+	if err != nil {
+		return "", err
+	}
+	current.GeneratedIndexes = nil
+
+	var recorded strings.Builder
+	encoder := yaml.NewEncoder(&recorded)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(map[string]map[string]string{"generatedIndexes": indexDefinitions(current)}); err != nil {
+		return "", fmt.Errorf("failed encoding generated indexes: %w", err)
+	}
+	return strings.TrimRight(currentYAML, "\n") + "\n\n# Written by `dalforge migrate`, don't edit\n" + recorded.String(), nil
+}
+
+// GenerateMigrationsEmbed builds the Go file embedding the migrations directory into the DAL package.
+func (g *Generator) GenerateMigrationsEmbed() (string, error) {
+	var buf strings.Builder
+	if err := g.migrationsTemplate.ExecuteTemplate(&buf, "migrations.tmpl", nil); err != nil {
+		return "", fmt.Errorf("migrations embed generation failed: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// versionNumber extracts the number from a "v<number>" entity version.
+func versionNumber(version string) (int, error) {
+	if errs := validateVersion(version); len(errs) > 0 {
+		return 0, fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	return strconv.Atoi(strings.TrimPrefix(version, "v"))
+}
+
+// columnSpec is a table column as created by the generated schema.
+type columnSpec struct {
+	SQLType   string
+	Check     string // Condition of the column's CHECK constraint, e.g. "status IN ('active', 'banned')"
+	AllowNull bool
+}
+
+// definition renders the column type as used in CREATE TABLE.
+func (c columnSpec) definition() string {
+	definition := c.SQLType
+	if c.Check != "" {
+		definition += " CHECK (" + c.Check + ")"
+	}
+	if c.AllowNull {
+		return definition
+	}
+	return definition + " NOT NULL"
+}
+
+// newColumnSpec returns the SQL definition of a user defined column. Enums are ENUM columns on MySQL,
+// and VARCHAR columns with a CHECK constraint on their values on PostgreSQL and SQLite.
+func newColumnSpec(colName string, col Column, dialect string) columnSpec {
+	if col.Type == "decimal" {
+		return columnSpec{SQLType: fmt.Sprintf("DECIMAL(%d,%d)", col.Precision, col.Scale), AllowNull: col.AllowNull}
+	}
+	if col.Type != "enum" {
+		return columnSpec{SQLType: toSQLType(col.Type, dialect), AllowNull: col.AllowNull}
+	}
+
+	values := make([]string, len(col.Values))
+	for i, value := range col.Values {
+		values[i] = "'" + value + "'" // Values are snake_case, nothing to escape
+	}
+	if isMySQL(dialect) {
+		return columnSpec{SQLType: "ENUM(" + strings.Join(values, ", ") + ")", AllowNull: col.AllowNull}
+	}
+	check := fmt.Sprintf("%s IN (%s)", SnakeCaser(colName), strings.Join(values, ", "))
+	return columnSpec{SQLType: "VARCHAR(255)", Check: check, AllowNull: col.AllowNull}
+}
+
+// columnDefinition renders a user defined column as used in CREATE TABLE.
+func columnDefinition(colName string, col Column, dialect string) string {
+	return newColumnSpec(colName, col, dialect).definition()
+}
+
+// tableColumns returns all user defined and optional built-in columns with their SQL definition.
+func tableColumns(config EntityConfig) map[string]columnSpec {
+	result := make(map[string]columnSpec)
+	for colName, col := range config.Columns {
+		result[SnakeCaser(colName)] = newColumnSpec(colName, col, config.Dialect)
+	}
+	if config.Operations.SoftDelete {
+		result["deleted_at"] = columnSpec{SQLType: "TIMESTAMP", AllowNull: true}
+	}
+	return result
+}
+
+// changedColumns returns the sorted names of columns present in both schemas with a different definition.
+func changedColumns(from, to EntityConfig) []string {
+	fromColumns := tableColumns(from)
+	toColumns := tableColumns(to)
+
+	var changed []string
+	for _, name := range sortedKeys(toColumns) {
+		if oldDef, exists := fromColumns[name]; exists && oldDef != toColumns[name] {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+// schemaDiff returns the statements that transform the "from" schema into the "to" schema.
+// Indexes are dropped first and created last so that column changes never conflict with them.
+// Turning audit on or off creates or drops the history table at the end.
+func schemaDiff(from, to EntityConfig) []string {
+	var statements []string
+	tableName := SnakeCaser(to.Name) + "s"
+
+	fromIndexes := indexDefinitions(from)
+	toIndexes := indexDefinitions(to)
+
+	// 1. Drop indexes that disappeared or changed definition
+	for _, name := range sortedKeys(fromIndexes) {
+		if definition, exists := toIndexes[name]; !exists || definition != fromIndexes[name] {
+			statements = append(statements, dropIndexStatement(to.Dialect, name, tableName))
+		}
+	}
+
+	fromColumns := tableColumns(from)
+	toColumns := tableColumns(to)
+
+	// 2. Add new columns and modify changed ones
+	for _, name := range sortedKeys(toColumns) {
+		oldDef, exists := fromColumns[name]
+		if !exists {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", tableName, name, toColumns[name].definition()))
+		} else if oldDef != toColumns[name] {
+			statements = append(statements, modifyColumnStatement(to.Dialect, tableName, name, oldDef, toColumns[name]))
+		}
+	}
+
+	// 3. Drop removed columns
+	for _, name := range sortedKeys(fromColumns) {
+		if _, exists := toColumns[name]; !exists {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", tableName, name))
+		}
+	}
+
+	// 4. Create new or redefined indexes
+	for _, name := range sortedKeys(toIndexes) {
+		if definition, exists := fromIndexes[name]; !exists || definition != toIndexes[name] {
+			statements = append(statements, toIndexes[name])
+		}
+	}
+
+	// 5. Create or drop the history table
+	if to.Audit && !from.Audit {
+		statements = append(statements, historyTableSQL(to))
+	} else if from.Audit && !to.Audit {
+		statements = append(statements, fmt.Sprintf("DROP TABLE %s_history;", tableName))
+	}
+
+	// 6. Create the outbox table, it's shared between entities so it's never dropped
+	if to.Outbox && !from.Outbox {
+		statements = append(statements, outboxTableSQL(to.Dialect))
+	}
+
+	return statements
+}
+
+// indexDefinitions returns the CREATE INDEX statements of the entity table keyed by index name. A revision
+// read from a lock file has the indexes recorded when it was migrated, which may differ from the ones the
+// current generator derives from the same YAML.
+func indexDefinitions(config EntityConfig) map[string]string {
+	if len(config.GeneratedIndexes) > 0 {
+		return config.GeneratedIndexes
+	}
+
+	result := make(map[string]string)
+	for _, idx := range entityIndexes(config) {
+		result[idx.Name] = idx.definition(config.Name)
+	}
+	return result
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
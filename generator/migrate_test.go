@@ -0,0 +1,384 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+const migrationBaseYAML = `
+name: account
+version: v1
+columns:
+  email:
+    type: varchar
+    unique: true
+  age:
+    type: int8
+operations:
+  write: true
+  gets:
+    - email
+  lists:
+    - name: list_by_age
+      where: age > :age
+circuitbreaker:
+  timeoutSeconds: 20
+  consecutiveFailures: 4
+caching:
+  type: memory
+  singleExpirationSeconds: 300
+  listExpirationSeconds: 60
+  maxItemsCount: 1000
+`
+
+func TestGenerateMigration_Initial(t *testing.T) {
+	gen, err := NewGenerator()
+	if err != nil {
+		t.Fatalf("failed creating generator: %v", err)
+	}
+
+	migration, err := gen.GenerateMigration("", migrationBaseYAML)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if migration.FileName("up") != "account_0001_v1.up.sql" {
+		t.Errorf("unexpected up file name %q", migration.FileName("up"))
+	}
+	if !strings.Contains(migration.Up, "CREATE TABLE accounts") {
+		t.Errorf("initial migration should create the table, got:\n%s", migration.Up)
+	}
+	if strings.TrimSpace(migration.Down) != "DROP TABLE accounts;" {
+		t.Errorf("initial down migration should drop the table, got:\n%s", migration.Down)
+	}
+}
+
+func TestGenerateMigration_Diff(t *testing.T) {
+	gen, err := NewGenerator()
+	if err != nil {
+		t.Fatalf("failed creating generator: %v", err)
+	}
+
+	changed := strings.NewReplacer(
+		"version: v1", "version: v2",
+		"  age:\n    type: int8\n", "  age:\n    type: int32\n  nickname:\n    type: varchar\n    allowNull: true\n",
+		"where: age > :age", "where: nickname = :nickname",
+	).Replace(migrationBaseYAML)
+
+	migration, err := gen.GenerateMigration(migrationBaseYAML, changed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedUp := []string{
+		"DROP INDEX idx_age ON accounts;",
+		"ALTER TABLE accounts MODIFY COLUMN age INT NOT NULL;",
+		"ALTER TABLE accounts ADD COLUMN nickname VARCHAR(255);",
+		"CREATE INDEX idx_nickname ON accounts (nickname);",
+	}
+	for _, stmt := range expectedUp {
+		if !strings.Contains(migration.Up, stmt) {
+			t.Errorf("expected up migration to contain %q, got:\n%s", stmt, migration.Up)
+		}
+	}
+
+	expectedDown := []string{
+		"DROP INDEX idx_nickname ON accounts;",
+		"ALTER TABLE accounts MODIFY COLUMN age TINYINT NOT NULL;",
+		"ALTER TABLE accounts DROP COLUMN nickname;",
+		"CREATE INDEX idx_age ON accounts (age);",
+	}
+	for _, stmt := range expectedDown {
+		if !strings.Contains(migration.Down, stmt) {
+			t.Errorf("expected down migration to contain %q, got:\n%s", stmt, migration.Down)
+		}
+	}
+
+	if migration.FileName("down") != "account_0002_v2.down.sql" {
+		t.Errorf("unexpected down file name %q", migration.FileName("down"))
+	}
+}
+
+func TestGenerateMigration_NoChanges(t *testing.T) {
+	gen, err := NewGenerator()
+	if err != nil {
+		t.Fatalf("failed creating generator: %v", err)
+	}
+
+	migration, err := gen.GenerateMigration(migrationBaseYAML, migrationBaseYAML)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if migration != nil {
+		t.Errorf("expected no migration, got:\n%s", migration.Up)
+	}
+}
+
+func TestGenerateMigration_VersionNotBumped(t *testing.T) {
+	gen, err := NewGenerator()
+	if err != nil {
+		t.Fatalf("failed creating generator: %v", err)
+	}
+
+	changed := strings.Replace(migrationBaseYAML, "type: int8", "type: int64", 1)
+	_, err = gen.GenerateMigration(migrationBaseYAML, changed)
+	if err == nil || !strings.Contains(err.Error(), "version was not bumped") {
+		t.Errorf("expected version bump error, got %v", err)
+	}
+}
+
+func TestGenerateMigration_GeneratedIndexes(t *testing.T) {
+	gen, err := NewGenerator()
+	if err != nil {
+		t.Fatalf("failed creating generator: %v", err)
+	}
+
+	lock, err := gen.GenerateLock(migrationBaseYAML)
+	if err != nil {
+		t.Fatalf("GenerateLock failed: %v", err)
+	}
+	if !strings.Contains(lock, "  idx_age: CREATE INDEX idx_age ON accounts (age);\n") {
+		t.Fatalf("expected the lock to record the generated indexes, got:\n%s", lock)
+	}
+
+	migration, err := gen.GenerateMigration(lock, migrationBaseYAML)
+	if err != nil || migration != nil {
+		t.Fatalf("expected no migration against an up to date lock, got %v: %v", migration, err)
+	}
+
+	// A lock written by an older generator, which created the list index without id
+	oldLock := strings.Replace(lock, "idx_age ON accounts (age);", "idx_age ON accounts (age, created);", 1)
+	_, err = gen.GenerateMigration(oldLock, migrationBaseYAML)
+	if err == nil || !strings.Contains(err.Error(), "version was not bumped") {
+		t.Fatalf("expected version bump error, got %v", err)
+	}
+
+	migration, err = gen.GenerateMigration(oldLock, strings.Replace(migrationBaseYAML, "version: v1", "version: v2", 1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedUp := "DROP INDEX idx_age ON accounts;\nCREATE INDEX idx_age ON accounts (age);\n"
+	if !strings.HasSuffix(migration.Up, expectedUp) {
+		t.Errorf("expected up migration to recreate the index, got:\n%s", migration.Up)
+	}
+	if !strings.Contains(migration.Down, "CREATE INDEX idx_age ON accounts (age, created);") {
+		t.Errorf("expected down migration to restore the recorded index, got:\n%s", migration.Down)
+	}
+}
+
+func TestGenerateMigration_Audit(t *testing.T) {
+	gen, err := NewGenerator()
+	if err != nil {
+		t.Fatalf("failed creating generator: %v", err)
+	}
+
+	audited := strings.NewReplacer(
+		"version: v1", "version: v2",
+		"operations:", "audit: true\noperations:",
+	).Replace(migrationBaseYAML)
+
+	migration, err := gen.GenerateMigration(migrationBaseYAML, audited)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, stmt := range []string{
+		"CREATE TABLE accounts_history (",
+		"old_values JSON,",
+		"CREATE INDEX idx_history_entity_id ON accounts_history (entity_id, id);",
+	} {
+		if !strings.Contains(migration.Up, stmt) {
+			t.Errorf("up migration missing %q, got:\n%s", stmt, migration.Up)
+		}
+	}
+	if !strings.Contains(migration.Down, "DROP TABLE accounts_history;") {
+		t.Errorf("down migration should drop the history table, got:\n%s", migration.Down)
+	}
+
+	initial, err := gen.GenerateMigration("", audited)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(initial.Up, "CREATE TABLE accounts_history (") {
+		t.Errorf("initial migration should create the history table, got:\n%s", initial.Up)
+	}
+	if strings.TrimSpace(initial.Down) != "DROP TABLE accounts_history;\nDROP TABLE accounts;" {
+		t.Errorf("initial down migration should drop both tables, got:\n%s", initial.Down)
+	}
+}
+
+func TestGenerateMigration_Outbox(t *testing.T) {
+	gen, err := NewGenerator()
+	if err != nil {
+		t.Fatalf("failed creating generator: %v", err)
+	}
+
+	withOutbox := strings.NewReplacer(
+		"version: v1", "version: v2",
+		"operations:", "outbox: true\noperations:",
+	).Replace(migrationBaseYAML)
+
+	migration, err := gen.GenerateMigration(migrationBaseYAML, withOutbox)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, stmt := range []string{
+		"CREATE TABLE IF NOT EXISTS dal_outbox (",
+		"payload JSON NOT NULL,",
+		"INDEX idx_dal_outbox_pending (delivered_at, next_attempt_at)",
+	} {
+		if !strings.Contains(migration.Up, stmt) {
+			t.Errorf("up migration missing %q, got:\n%s", stmt, migration.Up)
+		}
+	}
+	// Other entities may still write to the shared table
+	if strings.Contains(migration.Down, "dal_outbox") {
+		t.Errorf("down migration shouldn't drop the outbox table, got:\n%s", migration.Down)
+	}
+
+	removed, err := gen.GenerateMigration(withOutbox, strings.Replace(migrationBaseYAML, "version: v1", "version: v3", 1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if removed != nil {
+		t.Errorf("turning the outbox off shouldn't drop the shared table, got:\n%s", removed.Up)
+	}
+
+	if err := gen.SetDialect(DialectPostgres); err != nil {
+		t.Fatalf("failed setting dialect: %v", err)
+	}
+	initial, err := gen.GenerateMigration("", withOutbox)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, stmt := range []string{
+		"payload JSONB NOT NULL,",
+		"CREATE INDEX IF NOT EXISTS idx_dal_outbox_pending ON dal_outbox (delivered_at, next_attempt_at);",
+	} {
+		if !strings.Contains(initial.Up, stmt) {
+			t.Errorf("initial migration missing %q, got:\n%s", stmt, initial.Up)
+		}
+	}
+}
+
+func TestGenerateMigration_PostgresDiff(t *testing.T) {
+	gen, err := NewGenerator()
+	if err != nil {
+		t.Fatalf("failed creating generator: %v", err)
+	}
+	if err := gen.SetDialect(DialectPostgres); err != nil {
+		t.Fatalf("failed setting dialect: %v", err)
+	}
+
+	changed := strings.NewReplacer(
+		"version: v1", "version: v2",
+		"  age:\n    type: int8\n", "  age:\n    type: int32\n    allowNull: true\n",
+	).Replace(migrationBaseYAML)
+
+	migration, err := gen.GenerateMigration(migrationBaseYAML, changed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedUp := []string{
+		"-- Migration of account from v1 to v2",
+		"ALTER TABLE accounts ALTER COLUMN age TYPE INT, ALTER COLUMN age DROP NOT NULL;",
+	}
+	for _, stmt := range expectedUp {
+		if !strings.Contains(migration.Up, stmt) {
+			t.Errorf("expected up migration to contain %q, got:\n%s", stmt, migration.Up)
+		}
+	}
+
+	expectedDown := "ALTER TABLE accounts ALTER COLUMN age TYPE SMALLINT, ALTER COLUMN age SET NOT NULL;"
+	if !strings.Contains(migration.Down, expectedDown) {
+		t.Errorf("expected down migration to contain %q, got:\n%s", expectedDown, migration.Down)
+	}
+}
+
+func TestGenerateMigration_Enum(t *testing.T) {
+	gen, err := NewGenerator()
+	if err != nil {
+		t.Fatalf("failed creating generator: %v", err)
+	}
+
+	withStatus := strings.Replace(migrationBaseYAML, "  age:\n", "  status:\n    type: varchar\n  age:\n", 1)
+	enum := strings.NewReplacer(
+		"version: v1", "version: v2",
+		"    type: varchar\n  age:", "    type: enum\n    values: [active, banned]\n  age:",
+	).Replace(withStatus)
+
+	migration, err := gen.GenerateMigration(withStatus, enum)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "ALTER TABLE accounts MODIFY COLUMN status ENUM('active', 'banned') NOT NULL;"; !strings.Contains(migration.Up, expected) {
+		t.Errorf("expected up migration to contain %q, got:\n%s", expected, migration.Up)
+	}
+	if expected := "ALTER TABLE accounts MODIFY COLUMN status VARCHAR(255) NOT NULL;"; !strings.Contains(migration.Down, expected) {
+		t.Errorf("expected down migration to contain %q, got:\n%s", expected, migration.Down)
+	}
+
+	// PostgreSQL keeps the values in the CHECK constraint of the column, replaced when they change
+	if err := gen.SetDialect(DialectPostgres); err != nil {
+		t.Fatalf("failed setting dialect: %v", err)
+	}
+	moreValues := strings.NewReplacer("version: v2", "version: v3", "[active, banned]", "[active, banned, pending]").Replace(enum)
+	migration, err = gen.GenerateMigration(enum, moreValues)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "ALTER TABLE accounts ALTER COLUMN status TYPE VARCHAR(255), ALTER COLUMN status SET NOT NULL, " +
+		"DROP CONSTRAINT accounts_status_check, ADD CONSTRAINT accounts_status_check CHECK (status IN ('active', 'banned', 'pending'));"
+	if !strings.Contains(migration.Up, expected) {
+		t.Errorf("expected up migration to contain %q, got:\n%s", expected, migration.Up)
+	}
+
+	initial, err := gen.GenerateMigration("", moreValues)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "status VARCHAR(255) CHECK (status IN ('active', 'banned', 'pending')) NOT NULL,"; !strings.Contains(initial.Up, expected) {
+		t.Errorf("expected the table to contain %q, got:\n%s", expected, initial.Up)
+	}
+}
+
+func TestGenerateMigration_Decimal(t *testing.T) {
+	gen, err := NewGenerator()
+	if err != nil {
+		t.Fatalf("failed creating generator: %v", err)
+	}
+
+	withBalance := strings.Replace(migrationBaseYAML, "  age:\n", "  balance:\n    type: decimal\n    precision: 10\n    scale: 2\n  age:\n", 1)
+	initial, err := gen.GenerateMigration("", withBalance)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(initial.Up, "balance DECIMAL(10,2) NOT NULL,") {
+		t.Errorf("expected a DECIMAL(10,2) column, got:\n%s", initial.Up)
+	}
+
+	wider := strings.NewReplacer("version: v1", "version: v2", "precision: 10", "precision: 14").Replace(withBalance)
+	migration, err := gen.GenerateMigration(withBalance, wider)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "ALTER TABLE accounts MODIFY COLUMN balance DECIMAL(14,2) NOT NULL;"; !strings.Contains(migration.Up, expected) {
+		t.Errorf("expected up migration to contain %q, got:\n%s", expected, migration.Up)
+	}
+}
+
+func TestGenerateMigration_SQLiteRejectsColumnChanges(t *testing.T) {
+	gen, err := NewGenerator()
+	if err != nil {
+		t.Fatalf("failed creating generator: %v", err)
+	}
+	if err := gen.SetDialect(DialectSQLite); err != nil {
+		t.Fatalf("failed setting dialect: %v", err)
+	}
+
+	changed := strings.NewReplacer("version: v1", "version: v2", "type: int8", "type: int64").Replace(migrationBaseYAML)
+	_, err = gen.GenerateMigration(migrationBaseYAML, changed)
+	if err == nil || !strings.Contains(err.Error(), "SQLite can't alter existing columns") {
+		t.Errorf("expected SQLite column change error, got %v", err)
+	}
+}
@@ -3,6 +3,7 @@ package generator
 import (
 	"fmt"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 )
@@ -32,18 +33,14 @@ func querySelect(columns map[string]Column, softDelete bool) string {
 
 // if given input: "deleted = 0 AND target = :target AND age = :age"
 // gives output: ["target", "age"]
+// Colons inside string literals are not parameters.
 func extractParams(input string) []string {
-	// Regular expression to match placeholders starting with ':'
-	re := regexp.MustCompile(`:(\w+)`)
+	tokens, _ := lexWhere(input)
 
-	// Find all matches
-	matches := re.FindAllStringSubmatch(input, -1)
-
-	// Extract parameter names
 	var params []string
-	for _, match := range matches {
-		if len(match) > 1 {
-			params = append(params, match[1])
+	for _, tok := range tokens {
+		if tok.Kind == tokenParam {
+			params = append(params, tok.Text)
 		}
 	}
 
@@ -68,9 +65,43 @@ func extractUniqueParams(input string) []string {
 }
 
 // replaceParams replaces all named parameters (e.g., :target) with "?" for SQL placeholders.
+// Slice parameters (e.g., :statuses...) become "?...", which expandInLists expands at runtime.
 func replaceParams(query string) string {
-	re := regexp.MustCompile(`:(\w+)`)
-	return re.ReplaceAllString(query, "?")
+	tokens, _ := lexWhere(query)
+	src := []rune(query)
+
+	var result strings.Builder
+	last := 0
+	for _, tok := range tokens {
+		if tok.Kind == tokenParam {
+			result.WriteString(string(src[last:tok.Pos]))
+			result.WriteString("?")
+			if tok.Slice {
+				result.WriteString("...")
+			}
+			last = tok.End
+		}
+	}
+	result.WriteString(string(src[last:]))
+	return result.String()
+}
+
+// whereParamType returns the Go type of a where parameter compared with a column of goType.
+// Slice parameters take a slice of the non-null type, e.g. []string.
+func whereParamType(where string, param string, goType string) string {
+	if slices.Contains(whereSliceParams(where), param) {
+		return "[]" + strings.TrimPrefix(goType, "*")
+	}
+	return goType
+}
+
+// whereQueryArg returns the query argument of a where parameter. Slice parameters are
+// wrapped for expandInLists, e.g. inValues(statuses).
+func whereQueryArg(where string, param string) string {
+	if slices.Contains(whereSliceParams(where), param) {
+		return fmt.Sprintf("inValues(%s)", CamelCaser(param))
+	}
+	return CamelCaser(param)
 }
 
 /*
@@ -81,6 +112,7 @@ query = SELECT * FROM `posts` WHERE
 	`deleted` = 0 and `age` = ?  # if where != nil; validate those columns are valid and indexed properly
 	AND id < ?  # Pagination if descending is true and startId != 0
 	AND id > ?  # Pagination if descending is false and startId != 0
+	AND (birthdate, id) < (?, ?)  # Cursor pagination if ordered by birthdate and cursor != nil
 */
 func listWhereQuery(isStartIdZero bool, list ListConfig, softDelete bool) string {
 	result := ""
@@ -97,30 +129,36 @@ func listWhereQuery(isStartIdZero bool, list ListConfig, softDelete bool) string
 		result += "deleted_at IS NULL"
 	}
 
-	if list.Descending && !isStartIdZero && strings.TrimSpace(list.Order) != "" {
+	if page := listPageCondition(list); page != "" && !isStartIdZero {
 		if result != "" {
 			result += " AND "
 		}
-		result += "id < ?"
-	} else if !list.Descending && !isStartIdZero {
-		if result != "" {
-			result += " AND "
-		}
-		result += "id > ?"
+		result += page
 	}
 
 	return result
 }
 
-func listQuery(isStartIdZero bool, entityName string, list ListConfig, columns map[string]Column, softDelete bool) string {
-	result := ""
-	where := listWhereQuery(isStartIdZero, list, softDelete)
-
-	result = fmt.Sprintf("SELECT %s FROM %ss", querySelect(columns, softDelete), SnakeCaser(entityName))
-	if where != "" {
-		result += fmt.Sprintf(" WHERE %s", where)
+// listPageCondition returns the condition selecting the rows after the previous page.
+func listPageCondition(list ListConfig) string {
+	if listUsesCursor(list) {
+		// Keyset pagination on (order, id), the id breaks ties of equal order values
+		comparison := ">"
+		if list.Descending {
+			comparison = "<"
+		}
+		return fmt.Sprintf("(%s, id) %s (?, ?)", strings.TrimSpace(list.Order), comparison)
+	} else if list.Descending && strings.TrimSpace(list.Order) != "" {
+		return "id < ?"
+	} else if !list.Descending {
+		return "id > ?"
 	}
+	return ""
+}
 
+// listOrderBy returns the ORDER BY and LIMIT clauses of a list query.
+func listOrderBy(list ListConfig) string {
+	result := ""
 	if strings.TrimSpace(list.Order) != "" {
 		result += fmt.Sprintf(" ORDER BY %s", list.Order)
 		if list.Descending {
@@ -132,8 +170,19 @@ func listQuery(isStartIdZero bool, entityName string, list ListConfig, columns m
 		result += " ORDER BY id"
 	}
 
-	result += " LIMIT ?"
-	return result
+	return result + " LIMIT ?"
+}
+
+func listQuery(isStartIdZero bool, entityName string, list ListConfig, columns map[string]Column, softDelete bool) string {
+	result := ""
+	where := listWhereQuery(isStartIdZero, list, softDelete)
+
+	result = fmt.Sprintf("SELECT %s FROM %ss", querySelect(columns, softDelete), SnakeCaser(entityName))
+	if where != "" {
+		result += fmt.Sprintf(" WHERE %s", where)
+	}
+
+	return result + listOrderBy(list)
 }
 
 func countQuery(entityName string, list ListConfig, columns map[string]Column, softDelete bool) string {
@@ -193,13 +242,15 @@ func listQueryParams(isStartIdZero bool, list ListConfig, columns map[string]Col
 	result := ""
 	params := extractParams(list.Where)
 	for _, param := range params {
-		result += fmt.Sprintf("%s, ", CamelCaser(param))
+		result += fmt.Sprintf("%s, ", whereQueryArg(list.Where, param))
 	}
 
-	if !isStartIdZero {
-		result += "startID, pageSize"
-	} else {
+	if isStartIdZero {
 		result += "pageSize"
+	} else if listUsesCursor(list) {
+		result += fmt.Sprintf("cursor.%s, cursor.ID, pageSize", PascalCaser(strings.TrimSpace(list.Order)))
+	} else {
+		result += "startID, pageSize"
 	}
 
 	return result
@@ -216,11 +267,16 @@ func listFuncCallParams(isStartIdZero bool, list ListConfig, columns map[string]
 
 		result += fmt.Sprintf("%s, ", CamelCaser(param))
 	}
+	if len(list.Filters) > 0 {
+		result += "filter, "
+	}
 
-	if !isStartIdZero {
-		result += "startID, pageSize"
-	} else {
+	if isStartIdZero {
 		result += "pageSize"
+	} else if listUsesCursor(list) {
+		result += "cursor, pageSize"
+	} else {
+		result += "startID, pageSize"
 	}
 
 	return result
@@ -234,7 +290,7 @@ func countQueryParams(list ListConfig, columns map[string]Column) string {
 	result := ""
 	params := extractParams(list.Where)
 	for _, param := range params {
-		result += fmt.Sprintf("%s, ", CamelCaser(param))
+		result += fmt.Sprintf("%s, ", whereQueryArg(list.Where, param))
 	}
 
 	// Clean up the trailing comma and space
@@ -251,16 +307,21 @@ func countFuncCallParams(list ListConfig, columns map[string]Column) string {
 	for _, param := range params {
 		result += fmt.Sprintf("%s, ", CamelCaser(param))
 	}
+	if len(list.Filters) > 0 {
+		result += "filter, "
+	}
 
 	// Clean up the trailing comma and space
 	return strings.TrimSuffix(result, ", ")
 }
 
 // for input:
-// func (d *UserDAL) listByAge(ctx context.Context, {{listFuncParams .List .Root.Columns}}) ([]*User, error) {
+// func (d *UserDAL) listByAge(ctx context.Context, {{listFuncParams "user" .List .Root.Columns}}) ([]*User, error) {
 // outputs:
 // func (d *UserDAL) listByAge(ctx context.Context, age int, startID int64, pageSize int) ([]*User, error) {
-func listFuncParams(list ListConfig, columns map[string]Column) (string, error) {
+// or for lists with an order column:
+// func (d *UserDAL) listByAge(ctx context.Context, age int, cursor *UserListByAgeCursor, pageSize int) ([]*User, error) {
+func listFuncParams(entityName string, list ListConfig, columns map[string]Column) (string, error) {
 	result := ""
 	params := extractUniqueParams(list.Where) // Deduplicated!
 	for _, param := range params {
@@ -277,18 +338,180 @@ func listFuncParams(list ListConfig, columns map[string]Column) (string, error)
 		} else if colName == "created" || colName == "updated" {
 			goType = "time.Time"
 		} else if col, ok := columns[colName]; ok {
-			goType = toGoType(col.Type, col.AllowNull)
+			goType = columnGoType(col, col.AllowNull)
 		} else {
 			return "", fmt.Errorf("dal yaml definition error. missing column %s, which is used in where under list %s", colName, list.Name)
 		}
 
-		result += fmt.Sprintf("%s %s, ", CamelCaser(param), goType)
+		result += fmt.Sprintf("%s %s, ", CamelCaser(param), whereParamType(list.Where, param, goType))
+	}
+	if len(list.Filters) > 0 {
+		result += fmt.Sprintf("filter %s, ", listFilterType(entityName, list))
 	}
 
-	result += "startID int64, pageSize int"
+	if listUsesCursor(list) {
+		result += fmt.Sprintf("cursor *%s, pageSize int", listCursorType(entityName, list))
+	} else {
+		result += "startID int64, pageSize int"
+	}
 	return result, nil
 }
 
+// listUsesCursor reports whether a list pages with a (order column, id) cursor instead of a bare startID.
+// Order values aren't monotonic with id, so paging by id alone skips or repeats rows.
+func listUsesCursor(list ListConfig) bool {
+	order := strings.TrimSpace(list.Order)
+	return order != "" && order != "id"
+}
+
+// listCursorType returns the name of the cursor struct of a list, e.g. UserListByBdayCursor
+func listCursorType(entityName string, list ListConfig) string {
+	return PascalCaser(entityName) + PascalCaser(list.Name) + "Cursor"
+}
+
+// listOrderGoType returns the non-null Go type of the list's order column, as held by its cursor.
+func listOrderGoType(list ListConfig, columns map[string]Column) string {
+	order := strings.TrimSpace(list.Order)
+	if order == "created" || order == "updated" {
+		return "time.Time"
+	}
+	return columnGoType(columns[order], false)
+}
+
+// listWhere returns the where clause of a list, or the predicates of its filters joined with AND.
+func listWhere(list ListConfig) string {
+	return filtersWhere(list, true)
+}
+
+// listRequiredWhere returns the part of a list's where clause that applies to every query,
+// leaving out optional filters.
+func listRequiredWhere(list ListConfig) string {
+	return filtersWhere(list, false)
+}
+
+func filtersWhere(list ListConfig, withOptional bool) string {
+	if len(list.Filters) == 0 {
+		return list.Where
+	}
+
+	var predicates []string
+	for _, filter := range list.Filters {
+		if filter.Optional && !withOptional {
+			continue
+		}
+		predicates = append(predicates, "("+strings.TrimSpace(filter.Where)+")")
+	}
+	return strings.Join(predicates, " AND ")
+}
+
+// listFilterType returns the name of the filter struct of a filtered list, e.g. UserSearchFilter
+func listFilterType(entityName string, list ListConfig) string {
+	return PascalCaser(entityName) + PascalCaser(list.Name) + "Filter"
+}
+
+// filterField is a field of the filter struct of a filtered list, one per parameter.
+type filterField struct {
+	Name     string // Go field name, e.g. MinAge
+	Param    string
+	Type     string
+	Where    string // predicate of the parameter
+	Optional bool
+	Slice    bool
+	// Key renders the field in filter cache keys, e.g. fmt.Sprintf(":minAge=%v", *f.MinAge)
+	Key string
+}
+
+// listFilterFields returns the fields of the filter struct of a filtered list. Parameters of optional
+// filters are pointers, or nil slices, so that nil leaves their filter out of the query.
+func listFilterFields(list ListConfig, columns map[string]Column) ([]filterField, error) {
+	var fields []filterField
+	for _, filter := range list.Filters {
+		sliceParams := whereSliceParams(filter.Where)
+		for _, param := range extractUniqueParams(filter.Where) {
+			colName := param
+			if mappedCol, ok := list.TypeMapping[param]; ok {
+				colName = mappedCol
+			}
+
+			var goType string
+			allowNull := false
+			if colName == "id" {
+				goType = "int64"
+			} else if colName == "created" || colName == "updated" {
+				goType = "time.Time"
+			} else if col, ok := columns[colName]; ok {
+				goType = columnGoType(col, false)
+				allowNull = col.AllowNull
+			} else {
+				return nil, fmt.Errorf("dal yaml definition error. missing column %s, which is used in filters under list %s", colName, list.Name)
+			}
+
+			field := filterField{
+				Name:     PascalCaser(param),
+				Param:    param,
+				Where:    strings.TrimSpace(filter.Where),
+				Optional: filter.Optional,
+				Slice:    slices.Contains(sliceParams, param),
+			}
+			value := "f." + field.Name
+			switch {
+			case field.Slice:
+				field.Type = "[]" + goType
+				field.Key = fmt.Sprintf(`":%s=" + inListKey(%s)`, param, value)
+			case filter.Optional:
+				field.Type = "*" + goType
+				field.Key = fmt.Sprintf(`fmt.Sprintf(":%s=%%v", *%s)`, param, value)
+			case allowNull:
+				field.Type = "*" + goType
+				field.Key = fmt.Sprintf(`fmt.Sprintf(":%s=%%v", func() interface{} { if %s == nil { return "<<null>>" }; return *%s }())`, param, value, value)
+			default:
+				field.Type = goType
+				field.Key = fmt.Sprintf(`fmt.Sprintf(":%s=%%v", %s)`, param, value)
+			}
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// listFilter is a predicate of a filtered list as the generated where method adds it to the query.
+type listFilter struct {
+	Condition string // predicate with placeholders, e.g. (age >= ?)
+	Active    string // switches an optional predicate on, e.g. f.MinAge != nil
+	Args      string // query arguments in placeholder order, e.g. *f.MinAge, *f.MaxAge
+}
+
+// listFilters returns the predicates of a filtered list in declaration order.
+func listFilters(list ListConfig) []listFilter {
+	var result []listFilter
+	for _, filter := range list.Filters {
+		sliceParams := whereSliceParams(filter.Where)
+		predicate := listFilter{Condition: "(" + replaceParams(strings.TrimSpace(filter.Where)) + ")"}
+
+		var args []string
+		for _, param := range extractParams(filter.Where) {
+			value := "f." + PascalCaser(param)
+			if slices.Contains(sliceParams, param) {
+				value = fmt.Sprintf("inValues(%s)", value)
+			} else if filter.Optional {
+				value = "*" + value
+			}
+			args = append(args, value)
+		}
+		predicate.Args = strings.Join(args, ", ")
+
+		if filter.Optional {
+			var active []string
+			for _, param := range extractUniqueParams(filter.Where) {
+				active = append(active, fmt.Sprintf("f.%s != nil", PascalCaser(param)))
+			}
+			predicate.Active = strings.Join(active, " && ")
+		}
+		result = append(result, predicate)
+	}
+	return result
+}
+
 // for input:
 // func (d *UserDAL) countListByAge(ctx context.Context, {{listFuncParams .List .Root.Columns}}) (int64, error) {
 // outputs:
@@ -310,12 +533,12 @@ func countFuncParams(list ListConfig, columns map[string]Column) (string, error)
 		} else if colName == "created" || colName == "updated" {
 			goType = "time.Time"
 		} else if col, ok := columns[colName]; ok {
-			goType = toGoType(col.Type, col.AllowNull)
+			goType = columnGoType(col, col.AllowNull)
 		} else {
 			return "", fmt.Errorf("dal yaml definition error. missing column %s, which is used in where under count list %s", colName, list.Name)
 		}
 
-		result += fmt.Sprintf("%s %s, ", CamelCaser(param), goType)
+		result += fmt.Sprintf("%s %s, ", CamelCaser(param), whereParamType(list.Where, param, goType))
 	}
 
 	return strings.TrimSuffix(result, ", "), nil
@@ -323,9 +546,11 @@ func countFuncParams(list ListConfig, columns map[string]Column) (string, error)
 
 // Create cache key similar to this:
 // fmt.Sprintf("{{$entityTableName}}_{{.List.Name | snakeCase}}:%v:%d:%d", age, startID, pageSize)
+// Lists paging with a cursor use cursor.cacheKey() in place of startID.
 func listCacheKey(entityName string, list ListConfig, columns map[string]Column, caching CachingConfig) string {
 	key := fmt.Sprintf("%s_%s", SnakeCaser(entityName), SnakeCaser(list.Name))
 	params := extractUniqueParams(list.Where)
+	sliceParams := whereSliceParams(list.Where)
 
 	if caching.ListInvalidation == "epoch" {
 		key += ":epoch_%d"
@@ -333,7 +558,14 @@ func listCacheKey(entityName string, list ListConfig, columns map[string]Column,
 	for range params {
 		key += ":%v"
 	}
-	key += ":%d:%d"
+	if len(list.Filters) > 0 {
+		key += ":%s"
+	}
+	if listUsesCursor(list) {
+		key += ":%s:%d"
+	} else {
+		key += ":%d:%d"
+	}
 
 	paramStr := ""
 	if caching.ListInvalidation == "epoch" {
@@ -347,21 +579,44 @@ func listCacheKey(entityName string, list ListConfig, columns map[string]Column,
 		}
 		col := columns[colName]
 		goName := CamelCaser(param)
-		if col.AllowNull {
+		if slices.Contains(sliceParams, param) {
+			paramStr += fmt.Sprintf("inListKey(%s), ", goName)
+		} else if col.AllowNull {
 			paramStr += fmt.Sprintf(`func() interface{} { if %s == nil { return "<<null>>" }; return *%s }(), `, goName, goName)
 		} else {
 			paramStr += fmt.Sprintf("%s, ", goName)
 		}
 	}
-	paramStr += "startID, pageSize"
+	if len(list.Filters) > 0 {
+		paramStr += "filter.cacheKey(), "
+	}
+	if listUsesCursor(list) {
+		paramStr += "cursor.cacheKey(), pageSize"
+	} else {
+		paramStr += "startID, pageSize"
+	}
 	return fmt.Sprintf(`fmt.Sprintf("%s", %s)`, key, paramStr)
 }
 
 // Create cache key similar to this:
 // fmt.Sprintf("{{$entityTableName}}_{{.List.Name | snakeCase}}:%v", age)
+// Filtered lists key by the active filters, e.g. fmt.Sprintf("user_count_search:%s", filter.cacheKey())
 func countCacheKey(entityName string, list ListConfig, columns map[string]Column, caching CachingConfig) string {
 	key := fmt.Sprintf("%s_count_%s", SnakeCaser(entityName), SnakeCaser(list.Name))
-	params := extractUniqueParams(list.Where)
+	if len(list.Filters) > 0 {
+		if caching.ListInvalidation == "epoch" {
+			return fmt.Sprintf(`fmt.Sprintf("%s:epoch_%%d:%%s", d.getEpoch(), filter.cacheKey())`, key)
+		}
+		return fmt.Sprintf(`fmt.Sprintf("%s:%%s", filter.cacheKey())`, key)
+	}
+	return whereCacheKey(key, list.Where, list.TypeMapping, columns, caching)
+}
+
+// whereCacheKey appends the epoch and the parameters of the where clause to key, e.g.
+// fmt.Sprintf("user_count_list_by_age:epoch_%d:%v", d.getEpoch(), age)
+func whereCacheKey(key string, where string, typeMapping map[string]string, columns map[string]Column, caching CachingConfig) string {
+	params := extractUniqueParams(where)
+	sliceParams := whereSliceParams(where)
 
 	if caching.ListInvalidation == "epoch" {
 		key += ":epoch_%d"
@@ -377,12 +632,14 @@ func countCacheKey(entityName string, list ListConfig, columns map[string]Column
 
 	for _, param := range params {
 		colName := param
-		if mappedCol, ok := list.TypeMapping[param]; ok {
+		if mappedCol, ok := typeMapping[param]; ok {
 			colName = mappedCol
 		}
 		col := columns[colName]
 		goName := CamelCaser(param)
-		if col.AllowNull {
+		if slices.Contains(sliceParams, param) {
+			paramStr += fmt.Sprintf("inListKey(%s), ", goName)
+		} else if col.AllowNull {
 			paramStr += fmt.Sprintf(`func() interface{} { if %s == nil { return "<<null>>" }; return *%s }(), `, goName, goName)
 		} else {
 			paramStr += fmt.Sprintf("%s, ", goName)
@@ -396,44 +653,415 @@ func countCacheKey(entityName string, list ListConfig, columns map[string]Column
 	return fmt.Sprintf(`fmt.Sprintf("%s", %s)`, key, paramStr)
 }
 
+// aggregateName returns the snake_case name of an aggregate, e.g. sum_age_by_status for the sum
+// of age where status = :status. Its PascalCase form is the generated method name.
+func aggregateName(agg AggregateConfig) string {
+	name := strings.ToLower(agg.Function) + "_" + SnakeCaser(agg.Column)
+
+	params := extractUniqueParams(agg.Where)
+	for i, param := range params {
+		if i == 0 {
+			name += "_by_"
+		} else {
+			name += "_and_"
+		}
+		name += SnakeCaser(param)
+	}
+	return name
+}
+
+// aggregateList adapts an aggregate to a ListConfig, so its where parameters are handled
+// exactly like the ones of count lists.
+func aggregateList(agg AggregateConfig) ListConfig {
+	return ListConfig{Name: aggregateName(agg), Where: agg.Where, TypeMapping: agg.TypeMapping}
+}
+
+// aggregateGoType returns the Go type of an aggregate's result: Decimal over decimal columns, so sums of
+// money stay exact, float64 otherwise.
+func aggregateGoType(agg AggregateConfig, columns map[string]Column) string {
+	if columns[agg.Column].Type == "decimal" {
+		return "Decimal"
+	}
+	return "float64"
+}
+
+// Input: "user", { function: sum, column: age, where: status = :status }
+// Output: SELECT SUM(age) FROM users WHERE (status = ?) AND deleted_at IS NULL
+func aggregateQuery(entityName string, agg AggregateConfig, softDelete bool) string {
+	result := fmt.Sprintf("SELECT %s(%s) FROM %ss", strings.ToUpper(agg.Function), SnakeCaser(agg.Column), SnakeCaser(entityName))
+	if where := listWhereQuery(true, aggregateList(agg), softDelete); where != "" {
+		result += fmt.Sprintf(" WHERE %s", where)
+	}
+	return result
+}
+
+// aggregateCacheKey creates the count cache key of an aggregate, e.g.
+// fmt.Sprintf("user_aggregate_sum_age_by_status:%v", status)
+func aggregateCacheKey(entityName string, agg AggregateConfig, columns map[string]Column, caching CachingConfig) string {
+	key := fmt.Sprintf("%s_aggregate_%s", SnakeCaser(entityName), aggregateName(agg))
+	return whereCacheKey(key, agg.Where, agg.TypeMapping, columns, caching)
+}
+
+// groupCountName returns the snake_case name of a group count, e.g. count_by_status_grouped.
+func groupCountName(group GroupCountConfig) string {
+	return "count_by_" + SnakeCaser(group.Column) + "_grouped"
+}
+
+// groupCountList adapts a group count to a ListConfig, so its where parameters are handled
+// exactly like the ones of count lists.
+func groupCountList(group GroupCountConfig) ListConfig {
+	return ListConfig{Name: groupCountName(group), Where: group.Where, TypeMapping: group.TypeMapping}
+}
+
+// Input: "user", { column: status, where: age > :age }, status allows null
+// Output: SELECT status, count(*) FROM users WHERE (age > ?) AND deleted_at IS NULL AND status IS NOT NULL GROUP BY status
+func groupCountQuery(entityName string, group GroupCountConfig, columns map[string]Column, softDelete bool) string {
+	column := SnakeCaser(group.Column)
+	where := listWhereQuery(true, groupCountList(group), softDelete)
+
+	// NULL has no map key, so those rows aren't counted.
+	if columns[group.Column].AllowNull {
+		if where != "" {
+			where += " AND "
+		}
+		where += column + " IS NOT NULL"
+	}
+
+	result := fmt.Sprintf("SELECT %s, count(*) FROM %ss", column, SnakeCaser(entityName))
+	if where != "" {
+		result += fmt.Sprintf(" WHERE %s", where)
+	}
+	return result + " GROUP BY " + column
+}
+
+// groupCountCacheKey creates the count cache key of a group count, e.g.
+// fmt.Sprintf("user_group_count_by_status_grouped:%v", age)
+func groupCountCacheKey(entityName string, group GroupCountConfig, columns map[string]Column, caching CachingConfig) string {
+	key := fmt.Sprintf("%s_group_%s", SnakeCaser(entityName), groupCountName(group))
+	return whereCacheKey(key, group.Where, group.TypeMapping, columns, caching)
+}
+
+// existsName returns the snake_case name of an exists operation, e.g. exists_by_email
+// for email = :email. Its PascalCase form is the generated method name.
+func existsName(exists ExistsConfig) string {
+	name := "exists"
+	for i, param := range extractUniqueParams(exists.Where) {
+		if i == 0 {
+			name += "_by_"
+		} else {
+			name += "_and_"
+		}
+		name += SnakeCaser(param)
+	}
+	return name
+}
+
+// existsList adapts an exists operation to a ListConfig, so its where parameters are handled
+// exactly like the ones of count lists.
+func existsList(exists ExistsConfig) ListConfig {
+	return ListConfig{Name: existsName(exists), Where: exists.Where, TypeMapping: exists.TypeMapping}
+}
+
+// Input: "user", { where: email = :email }
+// Output: SELECT 1 FROM users WHERE (email = ?) AND deleted_at IS NULL LIMIT 1
+func existsQuery(entityName string, exists ExistsConfig, softDelete bool) string {
+	result := fmt.Sprintf("SELECT 1 FROM %ss", SnakeCaser(entityName))
+	if where := listWhereQuery(true, existsList(exists), softDelete); where != "" {
+		result += fmt.Sprintf(" WHERE %s", where)
+	}
+	return result + " LIMIT 1"
+}
+
+// iterateAllList is the list IterateAll walks through, every row in id order.
+func iterateAllList() ListConfig {
+	return ListConfig{Name: "iterate_all"}
+}
+
+// searchList returns the search as a list, to share the where parameter helpers of lists.
+func searchList(search SearchConfig) ListConfig {
+	return ListConfig{Name: search.Name, Where: search.Where, TypeMapping: search.TypeMapping}
+}
+
+// searchMatch returns the MATCH ... AGAINST expression of a search, e.g.
+// MATCH (post) AGAINST (? IN NATURAL LANGUAGE MODE)
+func searchMatch(search SearchConfig) string {
+	mode := "NATURAL LANGUAGE"
+	if search.Mode == "boolean" {
+		mode = "BOOLEAN"
+	}
+	return fmt.Sprintf("MATCH (%s) AGAINST (? IN %s MODE)", strings.Join(search.Columns, ", "), mode)
+}
+
+// searchQuery generates the SELECT of a search, most relevant rows first. Relevance isn't
+// monotonic with id, so following pages continue after the (relevance, id) of the startID row:
+//
+//	SELECT ... FROM posts WHERE MATCH (post) AGAINST (? IN NATURAL LANGUAGE MODE)
+//	  AND (MATCH (post) AGAINST (? IN NATURAL LANGUAGE MODE), -id) < (SELECT MATCH (post) AGAINST (? IN NATURAL LANGUAGE MODE), -id FROM posts WHERE id = ?)
+//	  ORDER BY MATCH (post) AGAINST (? IN NATURAL LANGUAGE MODE) DESC, id LIMIT ?
+func searchQuery(isStartIdZero bool, entityName string, search SearchConfig, columns map[string]Column, softDelete bool) string {
+	tableName := SnakeCaser(entityName) + "s"
+	match := searchMatch(search)
+
+	result := fmt.Sprintf("SELECT %s FROM %s WHERE %s", querySelect(columns, softDelete), tableName, match)
+	if where := listWhereQuery(true, searchList(search), softDelete); where != "" {
+		result += " AND " + where
+	}
+	if !isStartIdZero {
+		result += fmt.Sprintf(" AND (%s, -id) < (SELECT %s, -id FROM %s WHERE id = ?)", match, match, tableName)
+	}
+	return result + fmt.Sprintf(" ORDER BY %s DESC, id LIMIT ?", match)
+}
+
+// searchQueryParams returns the arguments of searchQuery, e.g. searchQuery, targetAge, searchQuery, pageSize
+func searchQueryParams(isStartIdZero bool, search SearchConfig) string {
+	result := "searchQuery, "
+	for _, param := range extractParams(search.Where) {
+		result += fmt.Sprintf("%s, ", CamelCaser(param))
+	}
+	if !isStartIdZero {
+		result += "searchQuery, searchQuery, startID, "
+	}
+	return result + "searchQuery, pageSize"
+}
+
+// searchCacheKey keys a page of search results by the normalized query, e.g.
+// fmt.Sprintf("%s:%q:%d:%d", fmt.Sprintf("post_search_posts:%v", targetAge), searchQuery, startID, pageSize)
+func searchCacheKey(entityName string, search SearchConfig, columns map[string]Column, caching CachingConfig) string {
+	key := fmt.Sprintf("%s_%s", SnakeCaser(entityName), SnakeCaser(search.Name))
+	whereKey := whereCacheKey(key, search.Where, search.TypeMapping, columns, caching)
+	// %q keeps the query from running into the page parts of the key
+	return fmt.Sprintf(`fmt.Sprintf("%%s:%%q:%%d:%%d", %s, searchQuery, startID, pageSize)`, whereKey)
+}
+
+// existsUniqueColumn returns the column of an exists operation whose where is a single equality
+// on a non null column cached by a get operation, e.g. email for email = :email. Such checks can be
+// answered from the unique key cache. It returns an empty string for any other where.
+func existsUniqueColumn(exists ExistsConfig, ops OperationConfig, columns map[string]Column) string {
+	re := regexp.MustCompile(`^\s*(\w+)\s*=\s*:(\w+)\s*$`)
+	match := re.FindStringSubmatch(exists.Where)
+	if match == nil {
+		return ""
+	}
+
+	colName := match[1]
+	if mappedCol, ok := exists.TypeMapping[match[2]]; ok && mappedCol != colName {
+		return ""
+	}
+	if col, ok := columns[colName]; !ok || col.AllowNull || !slices.Contains(ops.Gets, colName) {
+		return ""
+	}
+	return colName
+}
+
+// upsertQuery generates the INSERT of an upsert operation, overwriting the Set columns of the row
+// that already holds the same unique value.
+// PostgreSQL and SQLite return the stored row, MySQL points LAST_INSERT_ID() at it instead.
+func upsertQuery(entityName string, upsert UpsertConfig, columns map[string]Column, softDelete bool, dialect string) string {
+	tableName := SnakeCaser(entityName) + "s"
+
+	var names []string
+	for _, name := range sortedKeys(columns) {
+		names = append(names, SnakeCaser(name))
+	}
+	placeholders := strings.Repeat("?,", len(names)) + "?,?"
+
+	query := fmt.Sprintf("INSERT INTO %s (%s,created,updated) VALUES (%s)", tableName, strings.Join(names, ","), placeholders) +
+		upsertConflictClause(dialect, tableName, SnakeCaser(upsert.Column), upsert.Set)
+	if isMySQL(dialect) {
+		return query + ", id = LAST_INSERT_ID(id)"
+	}
+	return query + " RETURNING " + querySelect(columns, softDelete)
+}
+
+// upsertMappedSetColumns returns the set columns of an upsert that gets caches as unique key -> id
+// mappings. Overwriting them leaves the mapping of the previous value stale.
+func upsertMappedSetColumns(upsert UpsertConfig, gets []string) []string {
+	var result []string
+	for _, col := range upsert.Set {
+		if slices.Contains(gets, col) {
+			result = append(result, col)
+		}
+	}
+	return result
+}
+
+// patchName returns the snake_case name of a patch, e.g. patch_status or patch_status_and_age.
+// Its PascalCase form is the generated method name.
+func patchName(patch PatchConfig) string {
+	parts := make([]string, len(patch.Set))
+	for i, col := range patch.Set {
+		parts[i] = SnakeCaser(col)
+	}
+	return "patch_" + strings.Join(parts, "_and_")
+}
+
+// for input:
+// func (d *UserDAL) PatchStatus(ctx context.Context, {{patchFuncParams .Patch .Root.Columns}}) error {
+// outputs:
+// func (d *UserDAL) PatchStatus(ctx context.Context, id int64, version int32, status *string) error {
+func patchFuncParams(patch PatchConfig, columns map[string]Column) (string, error) {
+	result := "id int64, version int32"
+	for _, setCol := range patch.Set {
+		col, ok := columns[setCol]
+		if !ok {
+			return "", fmt.Errorf("column %s not found for patch", setCol)
+		}
+		result += fmt.Sprintf(", %s %s", CamelCaser(setCol), columnGoType(col, col.AllowNull))
+	}
+	return result, nil
+}
+
+// patchMappedSetColumns returns the set columns of a patch that gets caches as unique key -> id mappings.
+func patchMappedSetColumns(patch PatchConfig, gets []string) []string {
+	return upsertMappedSetColumns(UpsertConfig{Set: patch.Set}, gets)
+}
+
+// patchTouchesLists reports whether a patched column, or the updated timestamp every patch sets,
+// is referenced by an operation kept in the list or count caches. Only then the patch invalidates them.
+func patchTouchesLists(patch PatchConfig, ops OperationConfig, columns map[string]Column) bool {
+	var referenced []string
+	for _, list := range ops.Lists {
+		referenced = append(referenced, extractIndexColumns(listWhere(list), list.Order, columns)...)
+	}
+	for _, list := range ops.ListsBulk {
+		referenced = append(referenced, extractIndexColumns(list.Where, "", columns)...)
+		referenced = append(referenced, list.WhereIn)
+	}
+	for _, pluck := range ops.Plucks {
+		referenced = append(referenced, extractIndexColumns(pluck.Where, "", columns)...)
+		referenced = append(referenced, pluck.Column)
+	}
+	for _, agg := range ops.Aggregates {
+		referenced = append(referenced, extractIndexColumns(agg.Where, "", columns)...)
+		referenced = append(referenced, agg.Column)
+	}
+	for _, group := range ops.GroupCounts {
+		referenced = append(referenced, extractIndexColumns(group.Where, "", columns)...)
+		referenced = append(referenced, group.Column)
+	}
+	for _, search := range ops.Searches {
+		referenced = append(referenced, extractIndexColumns(search.Where, "", columns)...)
+		referenced = append(referenced, search.Columns...)
+	}
+
+	patched := append([]string{"updated"}, patch.Set...)
+	for _, col := range referenced {
+		if slices.Contains(patched, col) {
+			return true
+		}
+	}
+	return false
+}
+
+// incrementQuery generates the UPDATE adding a delta to the increment column, bumping the version
+// so a concurrent Update of an older version fails instead of overwriting the counter.
+// PostgreSQL and SQLite return the new value, MySQL passes it through LAST_INSERT_ID() instead.
+func incrementQuery(entityName string, inc IncrementConfig, softDelete bool, dialect string) string {
+	column := SnakeCaser(inc.Column)
+	value := clampExpression(dialect, column+" + ?", inc.Floor, inc.Ceiling)
+	if isMySQL(dialect) {
+		value = fmt.Sprintf("LAST_INSERT_ID(%s)", value)
+	}
+
+	query := fmt.Sprintf("UPDATE %ss SET %s = %s, updated = ?, version = version + 1 WHERE id = ?", SnakeCaser(entityName), column, value)
+	if softDelete {
+		query += " AND deleted_at IS NULL"
+	}
+	if !isMySQL(dialect) {
+		query += " RETURNING " + column
+	}
+	return query
+}
+
+// incrementTouchesLists reports whether the list or count caches reference the incremented column or updated.
+func incrementTouchesLists(inc IncrementConfig, ops OperationConfig, columns map[string]Column) bool {
+	return patchTouchesLists(PatchConfig{Set: []string{inc.Column}}, ops, columns)
+}
+
+// upsertBulkConflictClause generates the clause appended to the multi row INSERT of a bulk upsert.
+// Stored IDs are looked up afterwards, so it neither returns rows nor touches LAST_INSERT_ID().
+func upsertBulkConflictClause(entityName string, upsert UpsertConfig, dialect string) string {
+	return upsertConflictClause(dialect, SnakeCaser(entityName)+"s", SnakeCaser(upsert.Column), upsert.Set)
+}
+
 // deleteQuery generates the raw SQL for a custom bulk delete operation.
 // It handles both soft deletes (via UPDATE) and hard deletes (via DELETE FROM).
-func deleteQuery(entityName string, del DeleteConfig, columns map[string]Column, softDelete bool, isHardDelete bool) string {
-	where := ""
-	if strings.TrimSpace(del.Where) != "" {
-		where = replaceParams(del.Where)
-	}
+// The LIMIT is appended by the template, see deleteLimitClause.
+func deleteQuery(entityName string, del DeleteConfig, columns map[string]Column, softDelete bool, isHardDelete bool, dialect string) string {
+	tableName := SnakeCaser(entityName) + "s"
 
+	// Note: We removed the hardcoded limit here.
+	// It is now dynamically appended in the delete_custom.tmpl file.
+	return deleteStatement(tableName, columns, softDelete, isHardDelete, dialect) +
+		limitedWhere(tableName, deleteWhere(del, softDelete, isHardDelete), dialect)
+}
+
+// deleteStatement renders the statement of a custom delete without its WHERE: an UPDATE setting
+// deleted_at for soft deletes, a DELETE FROM otherwise.
+func deleteStatement(tableName string, columns map[string]Column, softDelete bool, isHardDelete bool, dialect string) string {
 	// Soft Delete Logic
 	if softDelete && !isHardDelete {
-		result := fmt.Sprintf("UPDATE %ss SET deleted_at = NOW(), updated = NOW(), version = version + 1", SnakeCaser(entityName))
+		now := sqlNow(dialect)
+		result := fmt.Sprintf("UPDATE %s SET deleted_at = %s, updated = %s, version = version + 1", tableName, now, now)
 
 		// Scramble unique string columns to free up the unique constraint
 		uniqueCols := uniqueStringColumns(columns)
 		for _, col := range uniqueCols {
-			result += fmt.Sprintf(", %s = CONCAT(%s, '-del-', UUID())", col, col)
+			result += ", " + scrambleUniqueColumn(dialect, col)
 		}
+		return result
+	}
+
+	// Hard Delete Logic
+	return fmt.Sprintf("DELETE FROM %s", tableName)
+}
 
+// deleteWhere renders the condition of a custom delete. Soft deletes skip the rows already deleted.
+func deleteWhere(del DeleteConfig, softDelete bool, isHardDelete bool) string {
+	where := ""
+	if strings.TrimSpace(del.Where) != "" {
+		where = replaceParams(del.Where)
+	}
+
+	if softDelete && !isHardDelete {
 		if where != "" {
-			result += fmt.Sprintf(" WHERE (%s) AND deleted_at IS NULL", where)
-		} else {
-			result += " WHERE deleted_at IS NULL"
+			return fmt.Sprintf("(%s) AND deleted_at IS NULL", where)
 		}
+		return "deleted_at IS NULL"
+	}
+	return where
+}
 
-		// Note: We removed the hardcoded limit here.
-		// It is now dynamically appended in the delete_custom.tmpl file.
-		return result
+// auditDeleteWhere renders the condition picking the rows a custom delete of an audited entity removes.
+// They are snapshotted first and then deleted by id, see auditDeleteQuery.
+func auditDeleteWhere(del DeleteConfig, softDelete bool, isHardDelete bool) string {
+	if where := deleteWhere(del, softDelete, isHardDelete); where != "" {
+		return where
 	}
+	return "1 = 1"
+}
 
-	// Hard Delete Logic
-	result := fmt.Sprintf("DELETE FROM %ss", SnakeCaser(entityName))
-	if where != "" {
-		result += fmt.Sprintf(" WHERE %s", where)
+// auditDeleteQuery renders the custom delete of an audited entity, removing the snapshotted rows by id.
+func auditDeleteQuery(entityName string, columns map[string]Column, softDelete bool, isHardDelete bool, dialect string) string {
+	tableName := SnakeCaser(entityName) + "s"
+	return deleteStatement(tableName, columns, softDelete, isHardDelete, dialect) + " WHERE id IN (?...)"
+}
+
+// limitedWhere renders the WHERE part of a statement that is bounded by a LIMIT.
+// Where UPDATE and DELETE can't be limited (see limitsWithSubselect) the rows are picked by
+// an id sub-select which deleteLimitClause closes after the LIMIT.
+func limitedWhere(tableName string, where string, dialect string) string {
+	if limitsWithSubselect(dialect) {
+		if where != "" {
+			return fmt.Sprintf(" WHERE id IN (SELECT id FROM %s WHERE %s", tableName, where)
+		}
+		return fmt.Sprintf(" WHERE id IN (SELECT id FROM %s", tableName)
 	}
 
-	// Note: We removed the hardcoded limit here.
-	// It is now dynamically appended in the delete_custom.tmpl file.
-	return result
+	if where != "" {
+		return fmt.Sprintf(" WHERE %s", where)
+	}
+	return ""
 }
 
 // deleteFuncParams generates the typed arguments for the Go function signature.
@@ -453,12 +1081,12 @@ func deleteFuncParams(del DeleteConfig, columns map[string]Column) (string, erro
 		} else if colName == "created" || colName == "updated" {
 			goType = "time.Time"
 		} else if col, ok := columns[colName]; ok {
-			goType = toGoType(col.Type, col.AllowNull)
+			goType = columnGoType(col, col.AllowNull)
 		} else {
 			return "", fmt.Errorf("dal yaml definition error: missing column %s", colName)
 		}
 
-		result += fmt.Sprintf("%s %s, ", CamelCaser(param), goType)
+		result += fmt.Sprintf("%s %s, ", CamelCaser(param), whereParamType(del.Where, param, goType))
 	}
 
 	// Always append the limit parameter
@@ -483,7 +1111,7 @@ func deleteQueryParams(del DeleteConfig) string {
 	result := ""
 	params := extractParams(del.Where)
 	for _, param := range params {
-		result += fmt.Sprintf("%s, ", CamelCaser(param))
+		result += fmt.Sprintf("%s, ", whereQueryArg(del.Where, param))
 	}
 
 	return strings.TrimSuffix(result, ", ")
@@ -507,7 +1135,7 @@ func bulkFuncParams(colName string, columns map[string]Column) (string, error) {
 		goType = "int64"
 	} else if col, ok := columns[colName]; ok {
 		// We force allowNull to false here because IN clauses typically use non-pointer slices
-		goType = strings.TrimPrefix(toGoType(col.Type, false), "*")
+		goType = strings.TrimPrefix(columnGoType(col, false), "*")
 	} else {
 		return "", fmt.Errorf("column %s not found for bulk operation", colName)
 	}
@@ -527,7 +1155,7 @@ func bulkUpdateFuncParams(upd UpdateBulkConfig, columns map[string]Column) (stri
 		if !ok {
 			return "", fmt.Errorf("column %s not found for bulk update", setCol)
 		}
-		goType := toGoType(col.Type, col.AllowNull)
+		goType := columnGoType(col, col.AllowNull)
 		result += fmt.Sprintf("%s %s, ", CamelCaser(setCol), goType)
 	}
 
@@ -536,7 +1164,7 @@ func bulkUpdateFuncParams(upd UpdateBulkConfig, columns map[string]Column) (stri
 	if upd.WhereIn == "id" {
 		whereType = "int64"
 	} else if col, ok := columns[upd.WhereIn]; ok {
-		whereType = strings.TrimPrefix(toGoType(col.Type, false), "*")
+		whereType = strings.TrimPrefix(columnGoType(col, false), "*")
 	} else {
 		return "", fmt.Errorf("column %s not found for bulk update whereIn", upd.WhereIn)
 	}
@@ -580,7 +1208,7 @@ func listBulkFuncParams(list ListBulkConfig, columns map[string]Column) (string,
 			} else if colName == "created" || colName == "updated" {
 				goType = "time.Time"
 			} else if col, ok := columns[colName]; ok {
-				goType = toGoType(col.Type, col.AllowNull)
+				goType = columnGoType(col, col.AllowNull)
 			} else {
 				return "", fmt.Errorf("dal yaml definition error. missing column %s used in listsBulk %s", colName, list.Name)
 			}
@@ -594,7 +1222,7 @@ func listBulkFuncParams(list ListBulkConfig, columns map[string]Column) (string,
 	if list.WhereIn == "id" {
 		inGoType = "int64"
 	} else if col, ok := columns[list.WhereIn]; ok {
-		inGoType = strings.TrimPrefix(toGoType(col.Type, false), "*")
+		inGoType = strings.TrimPrefix(columnGoType(col, false), "*")
 	} else {
 		return "", fmt.Errorf("column %s not found for listsBulk whereIn", list.WhereIn)
 	}
@@ -623,7 +1251,7 @@ func listBulkInternalFuncParams(list ListBulkConfig, columns map[string]Column)
 			} else if colName == "created" || colName == "updated" {
 				goType = "time.Time"
 			} else if col, ok := columns[colName]; ok {
-				goType = toGoType(col.Type, col.AllowNull)
+				goType = columnGoType(col, col.AllowNull)
 			}
 			result += fmt.Sprintf("%s %s, ", CamelCaser(param), goType)
 		}
@@ -677,11 +1305,11 @@ func pluckFuncParams(pluck PluckConfig, columns map[string]Column) (string, erro
 		} else if colName == "created" || colName == "updated" {
 			goType = "time.Time"
 		} else if col, ok := columns[colName]; ok {
-			goType = toGoType(col.Type, col.AllowNull)
+			goType = columnGoType(col, col.AllowNull)
 		} else {
 			return "", fmt.Errorf("missing column %s in pluck %s", colName, pluck.Name)
 		}
-		result += fmt.Sprintf("%s %s, ", CamelCaser(param), goType)
+		result += fmt.Sprintf("%s %s, ", CamelCaser(param), whereParamType(pluck.Where, param, goType))
 	}
 	return strings.TrimSuffix(result, ", "), nil
 }
@@ -699,7 +1327,7 @@ func pluckQueryParams(pluck PluckConfig) string {
 	result := ""
 	params := extractParams(pluck.Where)
 	for _, param := range params {
-		result += fmt.Sprintf("%s, ", CamelCaser(param))
+		result += fmt.Sprintf("%s, ", whereQueryArg(pluck.Where, param))
 	}
 	return strings.TrimSuffix(result, ", ")
 }
@@ -707,6 +1335,7 @@ func pluckQueryParams(pluck PluckConfig) string {
 func pluckCacheKey(entityName string, pluck PluckConfig, columns map[string]Column, caching CachingConfig) string {
 	key := fmt.Sprintf("%s_pluck_%s", SnakeCaser(entityName), SnakeCaser(pluck.Name))
 	params := extractUniqueParams(pluck.Where)
+	sliceParams := whereSliceParams(pluck.Where)
 
 	if caching.ListInvalidation == "epoch" {
 		key += ":epoch_%d"
@@ -727,7 +1356,9 @@ func pluckCacheKey(entityName string, pluck PluckConfig, columns map[string]Colu
 		}
 		col := columns[colName]
 		goName := CamelCaser(param)
-		if col.AllowNull {
+		if slices.Contains(sliceParams, param) {
+			paramStr += fmt.Sprintf("inListKey(%s), ", goName)
+		} else if col.AllowNull {
 			paramStr += fmt.Sprintf(`func() interface{} { if %s == nil { return "<<null>>" }; return *%s }(), `, goName, goName)
 		} else {
 			paramStr += fmt.Sprintf("%s, ", goName)
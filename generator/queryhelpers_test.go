@@ -0,0 +1,376 @@
+package generator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestListQuery_KeysetPagination(t *testing.T) {
+	columns := map[string]Column{
+		"age":       {Type: "int8"},
+		"birthdate": {Type: "date", AllowNull: true},
+	}
+
+	byBday := ListConfig{Name: "list_by_bday", Where: "birthdate < :birthdate", Order: "birthdate", Descending: true}
+	tests := []struct {
+		name          string
+		isStartIdZero bool
+		list          ListConfig
+		expected      string
+	}{
+		{
+			name:          "first page of an ordered list",
+			isStartIdZero: true,
+			list:          byBday,
+			expected:      "SELECT id, version, age, birthdate, created, updated FROM users WHERE (birthdate < ?) ORDER BY birthdate DESC, id DESC LIMIT ?",
+		},
+		{
+			name:     "next page of an ordered list",
+			list:     byBday,
+			expected: "SELECT id, version, age, birthdate, created, updated FROM users WHERE (birthdate < ?) AND (birthdate, id) < (?, ?) ORDER BY birthdate DESC, id DESC LIMIT ?",
+		},
+		{
+			name:     "next page of an ascending ordered list",
+			list:     ListConfig{Name: "list_by_age", Where: "age = :age", Order: "created"},
+			expected: "SELECT id, version, age, birthdate, created, updated FROM users WHERE (age = ?) AND (created, id) > (?, ?) ORDER BY created, id LIMIT ?",
+		},
+		{
+			name:     "next page of a list without order",
+			list:     ListConfig{Name: "list_by_id"},
+			expected: "SELECT id, version, age, birthdate, created, updated FROM users WHERE id > ? ORDER BY id LIMIT ?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := listQuery(tt.isStartIdZero, "user", tt.list, columns, false); got != tt.expected {
+				t.Errorf("unexpected query\n got: %s\nwant: %s", got, tt.expected)
+			}
+		})
+	}
+
+	if got := listQueryParams(false, byBday, columns); got != "birthdate, cursor.Birthdate, cursor.ID, pageSize" {
+		t.Errorf("unexpected query params %q", got)
+	}
+	params, err := listFuncParams("user", byBday, columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params != "birthdate *time.Time, cursor *UserListByBdayCursor, pageSize int" {
+		t.Errorf("unexpected func params %q", params)
+	}
+}
+
+func TestExtractIndexColumns_IdTiebreaker(t *testing.T) {
+	columns := map[string]Column{"age": {Type: "int8"}}
+
+	if got := extractIndexColumns("age = :age", "created", columns); !reflect.DeepEqual(got, []string{"age", "created", "id"}) {
+		t.Errorf("expected id to break ties of the order column, got %v", got)
+	}
+	if got := extractIndexColumns("age = :age", "", columns); !reflect.DeepEqual(got, []string{"age"}) {
+		t.Errorf("expected no id without order, got %v", got)
+	}
+}
+
+func TestSliceParams(t *testing.T) {
+	columns := map[string]Column{"status": {Type: "varchar", AllowNull: true}, "age": {Type: "int8"}}
+	list := ListConfig{
+		Name:        "list_by_statuses",
+		Where:       "status IN (:statuses...) AND age > :age",
+		TypeMapping: map[string]string{"statuses": "status"},
+	}
+
+	if got := listQuery(true, "user", list, columns, false); got != "SELECT id, version, age, status, created, updated FROM users WHERE (status IN (?...) AND age > ?) ORDER BY id LIMIT ?" {
+		t.Errorf("unexpected query %q", got)
+	}
+	if got := listQueryParams(false, list, columns); got != "inValues(statuses), age, startID, pageSize" {
+		t.Errorf("unexpected query params %q", got)
+	}
+
+	params, err := countFuncParams(list, columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params != "statuses []string, age int8" {
+		t.Errorf("unexpected func params %q", params)
+	}
+
+	expectedKey := `fmt.Sprintf("user_list_by_statuses:%v:%v:%d:%d", inListKey(statuses), age, startID, pageSize)`
+	if got := listCacheKey("user", list, columns, CachingConfig{}); got != expectedKey {
+		t.Errorf("unexpected cache key\n got: %s\nwant: %s", got, expectedKey)
+	}
+
+	del := DeleteConfig{Name: "delete_by_statuses", Where: list.Where, TypeMapping: list.TypeMapping}
+	if got := deleteQueryParams(del); got != "inValues(statuses), age" {
+		t.Errorf("unexpected delete params %q", got)
+	}
+}
+
+func TestAuditDeleteQuery(t *testing.T) {
+	columns := map[string]Column{"email": {Type: "varchar", Unique: true}, "age": {Type: "int8"}}
+	del := DeleteConfig{Name: "delete_older", Where: "age > :age"}
+
+	if got := auditDeleteWhere(del, true, false); got != "(age > ?) AND deleted_at IS NULL" {
+		t.Errorf("unexpected soft delete where %q", got)
+	}
+	if got := auditDeleteWhere(del, true, true); got != "age > ?" {
+		t.Errorf("unexpected hard delete where %q", got)
+	}
+	if got := auditDeleteWhere(DeleteConfig{Name: "delete_all"}, false, false); got != "1 = 1" {
+		t.Errorf("unexpected where without condition %q", got)
+	}
+
+	expected := "UPDATE users SET deleted_at = NOW(), updated = NOW(), version = version + 1, email = CONCAT(email, '-del-', UUID()) WHERE id IN (?...)"
+	if got := auditDeleteQuery("user", columns, true, false, "mysql"); got != expected {
+		t.Errorf("unexpected soft delete query\n got: %s\nwant: %s", got, expected)
+	}
+	if got := auditDeleteQuery("user", columns, true, true, "postgres"); got != "DELETE FROM users WHERE id IN (?...)" {
+		t.Errorf("unexpected hard delete query %q", got)
+	}
+}
+
+func TestListFilters(t *testing.T) {
+	columns := map[string]Column{"status": {Type: "varchar", AllowNull: true}, "age": {Type: "int8"}}
+	list := ListConfig{
+		Name: "search",
+		Filters: []FilterConfig{
+			{Where: "age > :age"},
+			{Where: "status IN (:statuses...)", Optional: true},
+			{Where: "age BETWEEN :low AND :high", Optional: true},
+		},
+		Order:       "created",
+		TypeMapping: map[string]string{"statuses": "status", "low": "age", "high": "age"},
+	}
+
+	fields, err := listFilterFields(list, columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for _, field := range fields {
+		types = append(types, field.Name+" "+field.Type)
+	}
+	if !reflect.DeepEqual(types, []string{"Age int8", "Statuses []string", "Low *int8", "High *int8"}) {
+		t.Errorf("unexpected filter fields %v", types)
+	}
+
+	expected := []listFilter{
+		{Condition: "(age > ?)", Args: "f.Age"},
+		{Condition: "(status IN (?...))", Active: "f.Statuses != nil", Args: "inValues(f.Statuses)"},
+		{Condition: "(age BETWEEN ? AND ?)", Active: "f.Low != nil && f.High != nil", Args: "*f.Low, *f.High"},
+	}
+	if got := listFilters(list); !reflect.DeepEqual(got, expected) {
+		t.Errorf("unexpected filters\n got: %+v\nwant: %+v", got, expected)
+	}
+
+	params, err := listFuncParams("user", list, columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params != "filter UserSearchFilter, cursor *UserSearchCursor, pageSize int" {
+		t.Errorf("unexpected func params %q", params)
+	}
+
+	expectedKey := `fmt.Sprintf("user_search:%s:%s:%d", filter.cacheKey(), cursor.cacheKey(), pageSize)`
+	if got := listCacheKey("user", list, columns, CachingConfig{}); got != expectedKey {
+		t.Errorf("unexpected cache key\n got: %s\nwant: %s", got, expectedKey)
+	}
+
+	// Only the required filter and the order are indexed, the optional ones are warned about
+	config := EntityConfig{Name: "user", Columns: columns, Operations: OperationConfig{Lists: []ListConfig{list}}}
+	if got := listIndexes(config); len(got) != 1 || !reflect.DeepEqual(got[0].Columns, []string{"age", "created", "id"}) {
+		t.Errorf("unexpected indexes %+v", got)
+	}
+	if got := filterIndexWarnings(config); !reflect.DeepEqual(got, []string{"no index covers list 'search' filtered by age, status"}) {
+		t.Errorf("unexpected warnings %q", got)
+	}
+}
+
+func TestAggregateQuery(t *testing.T) {
+	tests := []struct {
+		agg          AggregateConfig
+		expectedName string
+		expectedSQL  string
+	}{
+		{
+			agg:          AggregateConfig{Function: "sum", Column: "age", Where: "status = :status"},
+			expectedName: "sum_age_by_status",
+			expectedSQL:  "SELECT SUM(age) FROM users WHERE (status = ?) AND deleted_at IS NULL",
+		},
+		{
+			agg:          AggregateConfig{Function: "avg", Column: "age", Where: "status = :status AND age > :minAge"},
+			expectedName: "avg_age_by_status_and_min_age",
+			expectedSQL:  "SELECT AVG(age) FROM users WHERE (status = ? AND age > ?) AND deleted_at IS NULL",
+		},
+		{
+			agg:          AggregateConfig{Function: "max", Column: "age"},
+			expectedName: "max_age",
+			expectedSQL:  "SELECT MAX(age) FROM users WHERE deleted_at IS NULL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expectedName, func(t *testing.T) {
+			if got := aggregateName(tt.agg); got != tt.expectedName {
+				t.Errorf("expected name %q, got %q", tt.expectedName, got)
+			}
+			if got := aggregateQuery("user", tt.agg, true); got != tt.expectedSQL {
+				t.Errorf("expected query:\n%s\ngot:\n%s", tt.expectedSQL, got)
+			}
+		})
+	}
+}
+
+func TestGroupCountQuery(t *testing.T) {
+	config := EntityConfig{
+		Name: "user",
+		Columns: map[string]Column{
+			"age":    {Type: "int8"},
+			"status": {Type: "varchar", AllowNull: true},
+		},
+		Operations: OperationConfig{
+			SoftDelete:  true,
+			GroupCounts: []GroupCountConfig{{Column: "status", Where: "age > :age"}},
+		},
+	}
+	group := config.Operations.GroupCounts[0]
+
+	if got := groupCountName(group); got != "count_by_status_grouped" {
+		t.Errorf("expected count_by_status_grouped, got %q", got)
+	}
+
+	expected := "SELECT status, count(*) FROM users WHERE (age > ?) AND deleted_at IS NULL AND status IS NOT NULL GROUP BY status"
+	if got := groupCountQuery("user", group, config.Columns, true); got != expected {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expected, got)
+	}
+
+	// The index serves the where clause first and the grouping last.
+	expectedIndex := "CREATE INDEX idx_age_status ON users (age, status);\n"
+	if got := listSQLIndexes(config); got != expectedIndex {
+		t.Errorf("expected index %q, got %q", expectedIndex, got)
+	}
+}
+
+func TestExistsQuery(t *testing.T) {
+	columns := map[string]Column{
+		"email":    {Type: "varchar", Unique: true},
+		"nickname": {Type: "varchar", Unique: true, AllowNull: true},
+		"status":   {Type: "varchar"},
+	}
+	ops := OperationConfig{Gets: []string{"email", "nickname"}}
+
+	byEmail := ExistsConfig{Where: "email = :email"}
+	if got := existsName(byEmail); got != "exists_by_email" {
+		t.Errorf("expected exists_by_email, got %q", got)
+	}
+	expected := "SELECT 1 FROM users WHERE (email = ?) AND deleted_at IS NULL LIMIT 1"
+	if got := existsQuery("user", byEmail, true); got != expected {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expected, got)
+	}
+
+	tests := []struct {
+		exists   ExistsConfig
+		expected string
+	}{
+		{exists: byEmail, expected: "email"},
+		{exists: ExistsConfig{Where: "email = :addr", TypeMapping: map[string]string{"addr": "email"}}, expected: "email"},
+		{exists: ExistsConfig{Where: "email = :email AND status = :status"}},
+		{exists: ExistsConfig{Where: "status = :status"}},     // not unique
+		{exists: ExistsConfig{Where: "nickname = :nickname"}}, // nullable
+		{exists: ExistsConfig{Where: "email > :email"}},
+	}
+	for _, tt := range tests {
+		if got := existsUniqueColumn(tt.exists, ops, columns); got != tt.expected {
+			t.Errorf("existsUniqueColumn(%q) = %q, expected %q", tt.exists.Where, got, tt.expected)
+		}
+	}
+}
+
+func TestSearchQuery(t *testing.T) {
+	columns := map[string]Column{"body": {Type: "text"}, "age": {Type: "int8"}}
+	search := SearchConfig{Name: "search_posts", Columns: []string{"body"}, Mode: "boolean", Where: "age = :age"}
+
+	match := "MATCH (body) AGAINST (? IN BOOLEAN MODE)"
+	if got := searchMatch(search); got != match {
+		t.Errorf("unexpected match %q", got)
+	}
+
+	expected := "SELECT id, version, age, body, created, updated, deleted_at FROM posts WHERE " + match + " AND (age = ?) AND deleted_at IS NULL ORDER BY " + match + " DESC, id LIMIT ?"
+	if got := searchQuery(true, "post", search, columns, true); got != expected {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expected, got)
+	}
+	if got := searchQueryParams(true, search); got != "searchQuery, age, searchQuery, pageSize" {
+		t.Errorf("unexpected params %q", got)
+	}
+
+	// Later pages continue after the relevance and id of startID
+	expected = "SELECT id, version, age, body, created, updated FROM posts WHERE " + match + " AND (age = ?) AND (" + match + ", -id) < (SELECT " + match + ", -id FROM posts WHERE id = ?) ORDER BY " + match + " DESC, id LIMIT ?"
+	if got := searchQuery(false, "post", search, columns, false); got != expected {
+		t.Errorf("expected query:\n%s\ngot:\n%s", expected, got)
+	}
+	if got := searchQueryParams(false, search); got != "searchQuery, age, searchQuery, searchQuery, startID, searchQuery, pageSize" {
+		t.Errorf("unexpected params %q", got)
+	}
+
+	expectedKey := `fmt.Sprintf("%s:%q:%d:%d", fmt.Sprintf("post_search_posts:%v", age), searchQuery, startID, pageSize)`
+	if got := searchCacheKey("post", search, columns, CachingConfig{}); got != expectedKey {
+		t.Errorf("unexpected cache key\n got: %s\nwant: %s", got, expectedKey)
+	}
+
+	// The FULLTEXT index covers the matched columns only
+	config := EntityConfig{Name: "post", Columns: columns, Operations: OperationConfig{Searches: []SearchConfig{search}}}
+	expectedIndexes := []sqlIndex{{Name: "idx_ft_body", Columns: []string{"body"}, FullText: true}}
+	if got := listIndexes(config); !reflect.DeepEqual(got, expectedIndexes) {
+		t.Errorf("unexpected indexes %+v", got)
+	}
+	if got := expectedIndexes[0].definition("post"); got != "CREATE FULLTEXT INDEX idx_ft_body ON posts (body);" {
+		t.Errorf("unexpected index definition %q", got)
+	}
+}
+
+func TestPatchTouchesLists(t *testing.T) {
+	columns := map[string]Column{
+		"age":    {Type: "int8"},
+		"body":   {Type: "text"},
+		"status": {Type: "varchar"},
+	}
+
+	tests := []struct {
+		name     string
+		ops      OperationConfig
+		set      []string
+		expected bool
+	}{
+		{
+			name:     "list filters by the patched column",
+			ops:      OperationConfig{Lists: []ListConfig{{Name: "list_by_status", Where: "status = :status"}}},
+			set:      []string{"status"},
+			expected: true,
+		},
+		{
+			name: "lists don't reference the patched column",
+			ops:  OperationConfig{Lists: []ListConfig{{Name: "list_by_status", Where: "status = :status", Order: "created"}}},
+			set:  []string{"body"},
+		},
+		{
+			name:     "list orders by updated, which every patch sets",
+			ops:      OperationConfig{Lists: []ListConfig{{Name: "recently_updated", Order: "updated"}}},
+			set:      []string{"body"},
+			expected: true,
+		},
+		{
+			name:     "aggregate over the patched column",
+			ops:      OperationConfig{Aggregates: []AggregateConfig{{Function: "sum", Column: "age"}}},
+			set:      []string{"age"},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := patchTouchesLists(PatchConfig{Set: tt.set}, tt.ops, columns); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
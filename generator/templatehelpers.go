@@ -2,8 +2,9 @@ package generator
 
 import (
 	"fmt"
-	"regexp"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"unicode"
 
@@ -88,7 +89,7 @@ func toGoType(yamlType string, allowNull bool) string {
 		return "int64"
 	case "float":
 		if allowNull {
-			return "*float"
+			return "*float64"
 		}
 		return "float64"
 	case "uid":
@@ -121,12 +122,50 @@ func toGoType(yamlType string, allowNull bool) string {
 			return "*json.RawMessage"
 		}
 		return "json.RawMessage"
+	case "decimal":
+		if allowNull {
+			return "*Decimal"
+		}
+		return "Decimal"
 	default:
 		return "interface{}" // Fallback for unknown types
 	}
 }
 
-func toSQLType(yamlType string) string {
+// columnGoType returns the Go type of the column values, the named type of the column for enums.
+func columnGoType(col Column, allowNull bool) string {
+	if col.Type != "enum" {
+		return toGoType(col.Type, allowNull)
+	}
+	if allowNull {
+		return "*" + col.EnumType
+	}
+	return col.EnumType
+}
+
+func toSQLType(yamlType string, dialect string) string {
+	if isSQLite(dialect) {
+		switch yamlType {
+		case "time":
+			return "DATETIME" // the driver only parses DATE, DATETIME and TIMESTAMP columns into time.Time
+		case "json":
+			return "TEXT"
+		}
+	}
+
+	if isPostgres(dialect) {
+		switch yamlType {
+		case "int8":
+			return "SMALLINT" // PostgreSQL has no single byte integer
+		case "float":
+			return "DOUBLE PRECISION"
+		case "datetime":
+			return "TIMESTAMP"
+		case "json":
+			return "JSONB"
+		}
+	}
+
 	switch yamlType {
 	case "int8":
 		return "TINYINT"
@@ -203,45 +242,63 @@ func add(a, b int) int {
 	return a + b
 }
 
-// extractIndexColumns smartly extracts recognized column names from SQL strings
+// extractIndexColumns extracts the columns an index needs from the parsed where clause,
+// followed by the order column
 func extractIndexColumns(where string, order string, columns map[string]Column) []string {
 	var cols []string
 	seen := make(map[string]bool)
 
-	// Regex to match whole words (potential column names)
-	re := regexp.MustCompile(`\b[a-zA-Z_][a-zA-Z0-9_]*\b`)
+	collect := func(names []string) {
+		for _, w := range names {
+			isBuiltIn := w == "id" || w == "created" || w == "updated" || w == "deleted_at"
+			_, isCustom := columns[w]
 
-	// 1. Extract from WHERE clause
-	whereWords := re.FindAllString(where, -1)
-	for _, w := range whereWords {
-		isBuiltIn := w == "id" || w == "created" || w == "updated" || w == "deleted_at"
-		_, isCustom := columns[w]
-
-		if (isCustom || isBuiltIn) && !seen[w] {
-			seen[w] = true
-			cols = append(cols, w)
+			if (isCustom || isBuiltIn) && !seen[w] {
+				seen[w] = true
+				cols = append(cols, w)
+			}
 		}
 	}
 
+	// 1. Extract from WHERE clause
+	collect(whereColumns(where))
+
 	// 2. Extract from ORDER clause (appended to the end of the index)
-	orderWords := re.FindAllString(order, -1)
-	for _, w := range orderWords {
-		isBuiltIn := w == "id" || w == "created" || w == "updated" || w == "deleted_at"
-		_, isCustom := columns[w]
+	orderCols := whereColumns(order)
+	collect(orderCols)
 
-		if (isCustom || isBuiltIn) && !seen[w] {
-			seen[w] = true
-			cols = append(cols, w)
-		}
+	// 3. Keyset pagination breaks ties of the order column by id
+	if len(orderCols) > 0 && !seen["id"] {
+		cols = append(cols, "id")
 	}
 
 	return cols
 }
 
-// Replace the existing listSQLIndexes function in generator/templatehelpers.go
-func listSQLIndexes(tableName string, columns map[string]Column, lists []ListConfig, listsBulk []ListBulkConfig, plucks []PluckConfig, deletes []DeleteConfig) string {
+// sqlIndex describes a single index the generated schema needs.
+type sqlIndex struct {
+	Name     string
+	Columns  []string
+	Unique   bool
+	FullText bool
+}
+
+// definition renders the CREATE INDEX statement for the index on the given table.
+func (idx sqlIndex) definition(tableName string) string {
+	kind := "INDEX"
+	if idx.Unique {
+		kind = "UNIQUE INDEX"
+	} else if idx.FullText {
+		kind = "FULLTEXT INDEX"
+	}
+	return fmt.Sprintf("CREATE %s %s ON %ss (%s);", kind, idx.Name, SnakeCaser(tableName), strings.Join(idx.Columns, ", "))
+}
+
+// listIndexes computes the deduplicated, deterministically ordered composite indexes
+// that serve lists, bulk lists, plucks, aggregates, group counts, exists, deletes and searches.
+func listIndexes(config EntityConfig) []sqlIndex {
 	// Use a map to deduplicate identical composite indexes across different operations
-	indexMap := make(map[string]string)
+	indexMap := make(map[string]sqlIndex)
 
 	addIndex := func(cols []string) {
 		if len(cols) == 0 {
@@ -251,23 +308,19 @@ func listSQLIndexes(tableName string, columns map[string]Column, lists []ListCon
 		// Create a unique signature for this column combination
 		colSignature := strings.Join(cols, "_")
 
-		// Truncate signature if it exceeds MySQL's 64 character index name limit
-		idxName := fmt.Sprintf("idx_%s", colSignature)
-		if len(idxName) > 64 {
-			idxName = idxName[:64]
-		}
-
-		colStr := strings.Join(cols, ", ")
-		indexMap[colSignature] = fmt.Sprintf("CREATE INDEX %s ON %ss (%s);\n", idxName, SnakeCaser(tableName), colStr)
+		indexMap[colSignature] = sqlIndex{Name: indexName(config, colSignature), Columns: cols}
 	}
 
-	// 1. Process Standard Lists
-	for _, list := range lists {
-		addIndex(extractIndexColumns(list.Where, list.Order, columns))
+	columns := config.Columns
+	ops := config.Operations
+
+	// 1. Process Standard Lists. Optional filters are left out, see filterIndexWarnings.
+	for _, list := range ops.Lists {
+		addIndex(extractIndexColumns(listRequiredWhere(list), list.Order, columns))
 	}
 
 	// 2. Process Bulk Lists
-	for _, listBulk := range listsBulk {
+	for _, listBulk := range ops.ListsBulk {
 		cols := extractIndexColumns(listBulk.Where, "", columns)
 		if listBulk.WhereIn != "" && listBulk.WhereIn != "id" {
 			// Append the IN column to the end of the index
@@ -277,7 +330,7 @@ func listSQLIndexes(tableName string, columns map[string]Column, lists []ListCon
 	}
 
 	// 3. Process Plucks (Covering Indexes)
-	for _, pluck := range plucks {
+	for _, pluck := range ops.Plucks {
 		cols := extractIndexColumns(pluck.Where, "", columns)
 		// Append the selected column to the index to create a "Covering Index"
 		if pluck.Column != "id" {
@@ -286,11 +339,45 @@ func listSQLIndexes(tableName string, columns map[string]Column, lists []ListCon
 		addIndex(cols)
 	}
 
-	// 4. Process Deletes
-	for _, del := range deletes {
+	// 4. Process Aggregates (Covering Indexes, like plucks)
+	for _, agg := range ops.Aggregates {
+		cols := extractIndexColumns(agg.Where, "", columns)
+		if !slices.Contains(cols, agg.Column) {
+			cols = append(cols, agg.Column)
+		}
+		addIndex(cols)
+	}
+
+	// 5. Process Group Counts, grouping by the last index column after the where columns
+	for _, group := range ops.GroupCounts {
+		cols := extractIndexColumns(group.Where, "", columns)
+		if !slices.Contains(cols, group.Column) {
+			cols = append(cols, group.Column)
+		}
+		addIndex(cols)
+	}
+
+	// 6. Process Exists. A single get column is already served by its unique index.
+	for _, exists := range ops.Exists {
+		cols := extractIndexColumns(exists.Where, "", columns)
+		if len(cols) == 1 && slices.Contains(ops.Gets, cols[0]) {
+			continue
+		}
+		addIndex(cols)
+	}
+
+	// 7. Process Deletes
+	for _, del := range ops.Deletes {
 		addIndex(extractIndexColumns(del.Where, "", columns))
 	}
 
+	// 8. Process Searches. MATCH needs a FULLTEXT index on exactly its columns; the where
+	// clause only narrows the full-text matches down, so it isn't indexed.
+	for _, search := range ops.Searches {
+		signature := "ft_" + strings.Join(search.Columns, "_")
+		indexMap[signature] = sqlIndex{Name: indexName(config, signature), Columns: search.Columns, FullText: true}
+	}
+
 	// Sort the map keys to ensure deterministic SQL generation output
 	var keys []string
 	for k := range indexMap {
@@ -298,14 +385,150 @@ func listSQLIndexes(tableName string, columns map[string]Column, lists []ListCon
 	}
 	sort.Strings(keys)
 
-	var builder strings.Builder
+	result := make([]sqlIndex, 0, len(keys))
 	for _, k := range keys {
-		builder.WriteString(indexMap[k])
+		result = append(result, indexMap[k])
 	}
+	return result
+}
 
+// uniqueIndexes returns the unique indexes backing single entity Get operations.
+func uniqueIndexes(config EntityConfig) []sqlIndex {
+	var result []sqlIndex
+	for _, colName := range config.Operations.Gets {
+		result = append(result, sqlIndex{
+			Name:    indexName(config, SnakeCaser(colName)),
+			Columns: []string{SnakeCaser(colName)},
+			Unique:  true,
+		})
+	}
+	return result
+}
+
+// entityIndexes returns every index of the entity table: unique, operation and soft delete indexes.
+func entityIndexes(config EntityConfig) []sqlIndex {
+	result := uniqueIndexes(config)
+	result = append(result, listIndexes(config)...)
+	if config.Operations.SoftDelete {
+		result = append(result, sqlIndex{Name: indexName(config, "deleted_at"), Columns: []string{"deleted_at"}})
+	}
+	return result
+}
+
+func renderSQLIndexes(tableName string, indexes []sqlIndex) string {
+	var builder strings.Builder
+	for _, idx := range indexes {
+		builder.WriteString(idx.definition(tableName))
+		builder.WriteString("\n")
+	}
 	return builder.String()
 }
 
+// uniqueSQLIndexes renders CREATE UNIQUE INDEX statements for all Get operations.
+func uniqueSQLIndexes(config EntityConfig) string {
+	return strings.TrimSuffix(renderSQLIndexes(config.Name, uniqueIndexes(config)), "\n")
+}
+
+// filterIndexWarnings reports the optional filters of filtered lists that no index serves together
+// with the required filters. Indexing every combination doesn't scale, so filtered lists only get
+// the index of their required filters and order, and likely combinations are checked instead.
+func filterIndexWarnings(config EntityConfig) []string {
+	indexes := entityIndexes(config)
+	covered := func(cols []string) bool {
+		for _, idx := range indexes {
+			if idx.FullText || len(idx.Columns) < len(cols) {
+				continue
+			}
+			leading := idx.Columns[:len(cols)]
+			if !slices.ContainsFunc(cols, func(col string) bool { return !slices.Contains(leading, col) }) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var warnings []string
+	for _, list := range config.Operations.Lists {
+		required := listRequiredWhere(list)
+		for _, filter := range list.Filters {
+			if !filter.Optional {
+				continue
+			}
+
+			where := "(" + strings.TrimSpace(filter.Where) + ")"
+			if required != "" {
+				where = required + " AND " + where
+			}
+			cols := extractIndexColumns(where, "", config.Columns)
+			if len(cols) > 0 && !covered(cols) {
+				warnings = append(warnings, fmt.Sprintf("no index covers list '%s' filtered by %s", list.Name, strings.Join(cols, ", ")))
+			}
+		}
+	}
+	return warnings
+}
+
+// listSQLIndexes renders CREATE INDEX statements for all list-like operations,
+// followed by the filterIndexWarnings as comments.
+func listSQLIndexes(config EntityConfig) string {
+	result := renderSQLIndexes(config.Name, listIndexes(config))
+	for _, warning := range filterIndexWarnings(config) {
+		result += fmt.Sprintf("%s WARNING: %s\n", sqlComment(config.Dialect), warning)
+	}
+	return result
+}
+
+// historyTableSQL renders the table of an audited entity recording a row per change, with the changed
+// columns before and after as JSON, and its index serving History.
+func historyTableSQL(config EntityConfig) string {
+	tableName := SnakeCaser(config.Name) + "s_history"
+	jsonType := toSQLType("json", config.Dialect)
+	return fmt.Sprintf(`CREATE TABLE %s (
+    id %s,
+    entity_id BIGINT NOT NULL,
+    version INT NOT NULL,
+    operation VARCHAR(64) NOT NULL,
+    actor VARCHAR(255),
+    old_values %s,
+    new_values %s,
+    created TIMESTAMP NOT NULL
+)%s;
+CREATE INDEX %s ON %s (entity_id, id);`,
+		tableName, primaryKeyDefinition(config.Dialect), jsonType, jsonType, tableOptions(config.Dialect),
+		indexName(config, "history_entity_id"), tableName)
+}
+
+// tracksChanges reports whether the writes of the entity snapshot the rows they touch, to record
+// them in its history or in the outbox.
+func tracksChanges(config EntityConfig) bool {
+	return config.Audit || config.Outbox
+}
+
+// outboxTableSQL renders the dal_outbox table shared by the entities with the outbox option, holding
+// a row per change until the OutboxRelay delivers it, and its index serving the relay's poll.
+func outboxTableSQL(dialect string) string {
+	index := ",\n    INDEX idx_dal_outbox_pending (delivered_at, next_attempt_at)"
+	createIndex := ""
+	if !isMySQL(dialect) {
+		index = ""
+		createIndex = "\nCREATE INDEX IF NOT EXISTS idx_dal_outbox_pending ON dal_outbox (delivered_at, next_attempt_at);"
+	}
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS dal_outbox (
+    id %s,
+    entity VARCHAR(64) NOT NULL,
+    entity_id BIGINT NOT NULL,
+    operation VARCHAR(64) NOT NULL,
+    version INT NOT NULL,
+    payload %s NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL,
+    delivered_at TIMESTAMP NULL,
+    created TIMESTAMP NOT NULL%s
+)%s;%s`,
+		primaryKeyDefinition(dialect), toSQLType("json", dialect), index, tableOptions(dialect), createIndex)
+}
+
 /*
 	 Would output something like this for any column that has get operation:
 		var oldEmail string
@@ -322,7 +545,7 @@ func checkColumnsChanged(config EntityConfig) string {
 	if existing.%s != entity.%s {
 		old%s = existing.%s
 	}
-		`, PascalCaser(colName), toGoType(col.Type, col.AllowNull), PascalCaser(colName), PascalCaser(colName),
+		`, PascalCaser(colName), columnGoType(col, col.AllowNull), PascalCaser(colName), PascalCaser(colName),
 			PascalCaser(colName), PascalCaser(colName))
 	}
 
@@ -335,10 +558,10 @@ func invalidateUniqueColumnsCache(config EntityConfig) string {
 	for _, colName := range config.Operations.Gets {
 		result += fmt.Sprintf(`
 	if old%s != "" {
-		oldCacheKey := fmt.Sprintf("user_%s:%%s", old%s)
+		oldCacheKey := fmt.Sprintf("%s_%s:%%s", old%s)
 		d.cache.Delete(oldCacheKey)
 		d.cacheProvider.InvalidateCache("%s", oldCacheKey)
-	}`, PascalCaser(colName), SnakeCaser(colName), PascalCaser(colName), SnakeCaser(config.Name))
+	}`, PascalCaser(colName), SnakeCaser(config.Name), SnakeCaser(colName), PascalCaser(colName), SnakeCaser(config.Name))
 	}
 
 	return result
@@ -355,6 +578,92 @@ func hasJSONColumn(columns map[string]Column) bool {
 	return false
 }
 
+func hasEnumColumn(columns map[string]Column) bool {
+	for _, col := range columns {
+		if col.Type == "enum" {
+			return true
+		}
+	}
+	return false
+}
+
+// enumConstant returns the name of the Go constant of an enum value, e.g. UserStatusActive.
+func enumConstant(col Column, value string) string {
+	return col.EnumType + PascalCaser(value)
+}
+
+// isTextColumn reports whether values of the column type are free text, which length, pattern and format rules apply to.
+func isTextColumn(colType string) bool {
+	return colType == "varchar" || colType == "text"
+}
+
+// integerBits returns the size of the integer column type, or 0 for other types.
+func integerBits(colType string) int {
+	switch colType {
+	case "int8":
+		return 8
+	case "int32":
+		return 32
+	case "int64":
+		return 64
+	}
+	return 0
+}
+
+// hasRules reports whether the column has validation rules, which the values of enum columns and the
+// digits of decimal columns are.
+func (c Column) hasRules() bool {
+	return c.MinLength != nil || c.MaxLength != nil || c.Pattern != "" || c.Format != "" ||
+		c.Min != nil || c.Max != nil || len(c.OneOf) > 0 || c.Type == "enum" || c.Type == "decimal"
+}
+
+// hasValidation reports whether any column of the entity has validation rules, which generates its Validate method.
+func hasValidation(config EntityConfig) bool {
+	for _, col := range config.Columns {
+		if col.hasRules() {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPatternRule reports whether a text column of the entity has a pattern rule, whose regexp the entity file compiles.
+func hasPatternRule(config EntityConfig) bool {
+	for _, col := range config.Columns {
+		if isTextColumn(col.Type) && col.Pattern != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// columnsHaveRules reports whether any of the named columns has validation rules.
+func columnsHaveRules(columns map[string]Column, names []string) bool {
+	for _, name := range names {
+		if columns[name].hasRules() {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleNumber renders the bound of a min or max rule as a Go constant.
+func ruleNumber(bound *float64) string {
+	return strconv.FormatFloat(*bound, 'f', -1, 64)
+}
+
+// ruleOneOf renders the allowed values of a oneOf rule as Go arguments, quoting them for text columns.
+func ruleOneOf(col Column) string {
+	values := make([]string, len(col.OneOf))
+	for i, value := range col.OneOf {
+		if isTextColumn(col.Type) {
+			value = strconv.Quote(value)
+		}
+		values[i] = value
+	}
+	return strings.Join(values, ", ")
+}
+
 func uniqueStringColumns(columns map[string]Column) []string {
 	var uniqueCols []string
 	for name, col := range columns {
@@ -363,5 +672,6 @@ func uniqueStringColumns(columns map[string]Column) []string {
 			uniqueCols = append(uniqueCols, name)
 		}
 	}
+	sort.Strings(uniqueCols) // Keep generated output deterministic
 	return uniqueCols
 }
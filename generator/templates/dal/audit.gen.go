@@ -0,0 +1,145 @@
+package dal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+type actorContextKey struct{}
+
+// WithActor returns a copy of ctx naming who makes the changes, recorded in the history of audited entities:
+//
+//	ctx = dal.WithActor(ctx, "admin:42")
+//	err := users.Update(ctx, user)
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// ActorFromContext returns the actor set by WithActor, or "" when there is none.
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorContextKey{}).(string)
+	return actor
+}
+
+// HistoryEntry is a change of an audited entity, as returned by the History method of its repository.
+type HistoryEntry struct {
+	ID       int64 `json:"id"`
+	EntityID int64 `json:"entity_id"`
+	Version  int32 `json:"version"` // Version of the entity after the change, before it for hard deletes
+	// Operation is the repository operation making the change, e.g. "update" or "hard_delete".
+	Operation string `json:"operation"`
+	Actor     string `json:"actor"`
+	// OldValues and NewValues hold the changed columns before and after the change. OldValues is
+	// empty for inserts and NewValues for hard deletes, which record every column instead.
+	OldValues json.RawMessage `json:"old_values"`
+	NewValues json.RawMessage `json:"new_values"`
+	Created   time.Time       `json:"created"`
+}
+
+// withinTx runs fn in a transaction, so the history rows of an audited entity commit or roll back with its write.
+func withinTx[T any](ctx context.Context, fn func(ctx context.Context) (T, error)) (T, error) {
+	var result T
+	err := RunInTx(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = fn(ctx)
+		return err
+	})
+	return result, err
+}
+
+// auditChange is a history row or outbox event about to be written.
+type auditChange struct {
+	entityID  int64
+	version   int32
+	oldValues interface{}
+	newValues interface{}
+	entity    interface{} // The entity after the change, before it for hard deletes
+}
+
+// auditChanges compares the snapshots of the rows touched by a write, keyed by id, and returns a change
+// per row that differs. Columns are compared by their JSON encoding and only the changed ones are kept,
+// except for rows appearing or disappearing, which keep all of them. Rows whose columns all kept their
+// values, e.g. an update writing the stored values, get neither a history row nor an outbox event.
+func auditChanges[T any](before, after map[int64]*T, values func(*T) map[string]interface{}, version func(*T) int32) ([]auditChange, error) {
+	ids := make([]int64, 0, len(before)+len(after))
+	for id := range before {
+		ids = append(ids, id)
+	}
+	for id := range after {
+		if _, exists := before[id]; !exists {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var changes []auditChange
+	for _, id := range ids {
+		oldEntity, newEntity := before[id], after[id]
+		change := auditChange{entityID: id}
+
+		switch {
+		case oldEntity == nil:
+			encoded, err := json.Marshal(values(newEntity))
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode history of %d: %w", id, err)
+			}
+			change.version = version(newEntity)
+			change.newValues = string(encoded)
+			change.entity = newEntity
+		case newEntity == nil:
+			encoded, err := json.Marshal(values(oldEntity))
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode history of %d: %w", id, err)
+			}
+			change.version = version(oldEntity)
+			change.oldValues = string(encoded)
+			change.entity = oldEntity
+		default:
+			oldValues, newValues := values(oldEntity), values(newEntity)
+			for column, oldValue := range oldValues {
+				oldEncoded, err := json.Marshal(oldValue)
+				if err != nil {
+					return nil, fmt.Errorf("failed to encode history of %d: %w", id, err)
+				}
+				newEncoded, err := json.Marshal(newValues[column])
+				if err != nil {
+					return nil, fmt.Errorf("failed to encode history of %d: %w", id, err)
+				}
+				if bytes.Equal(oldEncoded, newEncoded) {
+					delete(oldValues, column)
+					delete(newValues, column)
+				}
+			}
+			if len(oldValues) == 0 {
+				continue // Nothing changed, e.g. a bulk update setting the stored value
+			}
+
+			oldEncoded, err := json.Marshal(oldValues)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode history of %d: %w", id, err)
+			}
+			newEncoded, err := json.Marshal(newValues)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode history of %d: %w", id, err)
+			}
+			change.version = version(newEntity)
+			change.entity = newEntity
+			change.oldValues = string(oldEncoded)
+			change.newValues = string(newEncoded)
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// nullableActor stores a missing actor as NULL.
+func nullableActor(actor string) interface{} {
+	if actor == "" {
+		return nil
+	}
+	return actor
+}
@@ -0,0 +1,188 @@
+package dal
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact decimal number, the Go type of decimal columns, e.g. a price or a balance. It keeps the
+// digits of the number instead of a binary approximation, so 0.1 plus 0.2 is exactly 0.3. Equal numbers are
+// equal Decimals whatever their trailing zeros, which makes them usable with == and as map keys. The zero
+// value is 0.
+//
+// Decimal has no arithmetic of its own: Rat returns the number for exact math/big arithmetic and
+// NewDecimalFromRat rounds the result back to the scale of a column.
+type Decimal struct {
+	value string // e.g. "-12.5", without leading or trailing zeros; "" is 0
+}
+
+// NewDecimal parses a decimal number such as "12.50", "-0.5" or "+3". Exponents aren't supported.
+func NewDecimal(s string) (Decimal, error) {
+	digits := strings.TrimLeft(s, "+-")
+	if len(s)-len(digits) > 1 {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	negative := strings.HasPrefix(s, "-")
+
+	intPart, fracPart, _ := strings.Cut(digits, ".")
+	if intPart == "" && fracPart == "" || !onlyDigits(intPart) || !onlyDigits(fracPart) {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	intPart = strings.TrimLeft(intPart, "0")
+	fracPart = strings.TrimRight(fracPart, "0")
+	if intPart == "" && fracPart == "" {
+		return Decimal{}, nil
+	}
+
+	value := intPart
+	if value == "" {
+		value = "0"
+	}
+	if fracPart != "" {
+		value += "." + fracPart
+	}
+	if negative {
+		value = "-" + value
+	}
+	return Decimal{value: value}, nil
+}
+
+// MustDecimal is NewDecimal panicking on invalid numbers, for constants.
+func MustDecimal(s string) Decimal {
+	d, err := NewDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// NewDecimalFromInt returns the Decimal of an integer.
+func NewDecimalFromInt(i int64) Decimal {
+	return MustDecimal(strconv.FormatInt(i, 10))
+}
+
+// NewDecimalFromRat rounds r to scale digits after the decimal point, halves away from zero.
+func NewDecimalFromRat(r *big.Rat, scale int) Decimal {
+	return MustDecimal(r.FloatString(scale))
+}
+
+func onlyDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the shortest representation of the number, e.g. "12.5" for 12.50.
+func (d Decimal) String() string {
+	if d.value == "" {
+		return "0"
+	}
+	return d.value
+}
+
+// StringFixed returns the number rounded to scale digits after the decimal point, e.g. "12.50" for a scale of 2.
+func (d Decimal) StringFixed(scale int) string {
+	return d.Rat().FloatString(scale)
+}
+
+// Rat returns the exact value of the number.
+func (d Decimal) Rat() *big.Rat {
+	r, _ := new(big.Rat).SetString(d.String())
+	return r
+}
+
+// Cmp compares the numbers, returning -1, 0 or +1 like big.Rat.Cmp.
+func (d Decimal) Cmp(other Decimal) int {
+	return d.Rat().Cmp(other.Rat())
+}
+
+func (d Decimal) IsZero() bool {
+	return d.value == ""
+}
+
+// digits returns the number of digits before and after the decimal point, not counting the 0 of 0.5.
+func (d Decimal) digits() (int, int) {
+	intPart, fracPart, _ := strings.Cut(strings.TrimPrefix(d.value, "-"), ".")
+	if intPart == "0" {
+		return 0, len(fracPart)
+	}
+	return len(intPart), len(fracPart)
+}
+
+// Scan implements sql.Scanner. MySQL and PostgreSQL return the digits of DECIMAL columns, SQLite has no
+// decimal storage and returns integers and floats, exact up to 15 significant digits.
+func (d *Decimal) Scan(src interface{}) error {
+	var err error
+	switch v := src.(type) {
+	case []byte:
+		*d, err = NewDecimal(string(v))
+	case string:
+		*d, err = NewDecimal(v)
+	case int64:
+		*d = NewDecimalFromInt(v)
+	case float64:
+		*d, err = NewDecimal(strconv.FormatFloat(v, 'f', -1, 64))
+	default:
+		err = fmt.Errorf("can't scan %T into Decimal", src)
+	}
+	return err
+}
+
+// Value implements driver.Valuer, passing the digits as a string so the database parses them exactly.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// MarshalJSON encodes the number as a JSON string, which JSON decoders don't round to a float.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON decodes a JSON string or number.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+	parsed, err := NewDecimal(text)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler, used by map keys of JSON objects and text formats like YAML.
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Decimal) UnmarshalText(text []byte) error {
+	parsed, err := NewDecimal(string(text))
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder. Without it gob would refuse Decimal for having no exported fields.
+func (d Decimal) GobEncode() ([]byte, error) {
+	return d.MarshalText()
+}
+
+func (d *Decimal) GobDecode(data []byte) error {
+	return d.UnmarshalText(data)
+}
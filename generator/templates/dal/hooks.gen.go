@@ -0,0 +1,118 @@
+package dal
+
+import (
+	"context"
+	"fmt"
+)
+
+// BeforeCreateHook is implemented by entities validating or normalizing themselves before their repository
+// inserts them, with Create, CreateBulk or an upsert. Declare the method in a file of your own in this package:
+//
+//	func (u *User) BeforeCreate(ctx context.Context) error {
+//		u.Email = strings.ToLower(u.Email)
+//		return nil
+//	}
+type BeforeCreateHook interface {
+	BeforeCreate(ctx context.Context) error
+}
+
+// AfterCreateHook is implemented by entities reacting to being inserted by Create, CreateBulk or an upsert.
+type AfterCreateHook interface {
+	AfterCreate(ctx context.Context) error
+}
+
+// BeforeUpdateHook is implemented by entities validating or normalizing themselves before Update, an upsert or a
+// patch stores them. Patches only write the changes to the columns they set.
+type BeforeUpdateHook interface {
+	BeforeUpdate(ctx context.Context) error
+}
+
+// AfterUpdateHook is implemented by entities reacting to being stored by Update or an upsert.
+type AfterUpdateHook interface {
+	AfterUpdate(ctx context.Context) error
+}
+
+// BeforeDeleteHook is implemented by entities checking whether Delete or HardDelete may remove them.
+type BeforeDeleteHook interface {
+	BeforeDelete(ctx context.Context) error
+}
+
+// AfterDeleteHook is implemented by entities reacting to being removed by Delete or HardDelete.
+type AfterDeleteHook interface {
+	AfterDelete(ctx context.Context) error
+}
+
+// HookError wraps the error of a hook vetoing an operation. Circuit breakers don't count it as a failure.
+type HookError struct {
+	Hook string // e.g. "BeforeCreate" or "AfterCreateBulk"
+	Err  error
+}
+
+func (e *HookError) Error() string {
+	return fmt.Sprintf("%s hook: %v", e.Hook, e.Err)
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// hookFunc is a hook registered on a repository for an entity or a slice of them.
+type hookFunc[T any] func(ctx context.Context, value T) error
+
+// appendEntityHook appends the hook H of the entity type T, when *T implements it.
+func appendEntityHook[T any, H any](hooks []hookFunc[*T], call func(hook H, ctx context.Context) error) []hookFunc[*T] {
+	if _, ok := any((*T)(nil)).(H); !ok {
+		return hooks
+	}
+	return append(hooks, func(ctx context.Context, entity *T) error {
+		return call(any(entity).(H), ctx)
+	})
+}
+
+// appendEntityBulkHook appends the hook H of the entity type T, when *T implements it, run for every entity of a slice.
+func appendEntityBulkHook[T any, H any](hooks []hookFunc[[]*T], call func(hook H, ctx context.Context) error) []hookFunc[[]*T] {
+	if _, ok := any((*T)(nil)).(H); !ok {
+		return hooks
+	}
+	return append(hooks, func(ctx context.Context, entities []*T) error {
+		for _, entity := range entities {
+			if err := call(any(entity).(H), ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// runHooks runs hooks in order, stopping at the first error.
+func runHooks[T any](ctx context.Context, name string, hooks []hookFunc[T], value T) error {
+	for _, hook := range hooks {
+		if err := hook(ctx, value); err != nil {
+			return &HookError{Hook: name, Err: err}
+		}
+	}
+	return nil
+}
+
+// withHooks runs write between the Before<name> and After<name> hooks of value, and validate, unless nil,
+// once the before hooks ran so it sees their changes. With after hooks, write and them share a transaction,
+// the one of ctx if there is one, so an after hook's error rolls the write back.
+func withHooks[T any](ctx context.Context, name string, value T, before, after []hookFunc[T], validate func(T) error, write func(ctx context.Context) error) error {
+	if err := runHooks(ctx, "Before"+name, before, value); err != nil {
+		return err
+	}
+	if validate != nil {
+		if err := validate(value); err != nil {
+			return err
+		}
+	}
+	if len(after) == 0 {
+		return write(ctx)
+	}
+	return RunInTx(ctx, func(ctx context.Context) error {
+		if err := write(ctx); err != nil {
+			return err
+		}
+		return runHooks(ctx, "After"+name, after, value)
+	})
+}
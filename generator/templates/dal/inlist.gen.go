@@ -0,0 +1,76 @@
+package dal
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxInListValues bounds the values of a slice parameter like the chunks of bulk operations,
+// keeping statements below the placeholder limits of the databases.
+const maxInListValues = 500
+
+// inList holds the values of a slice parameter (:statuses...) until expandInLists spreads them.
+type inList []interface{}
+
+func inValues[T any](values []T) inList {
+	list := make(inList, len(values))
+	for i, value := range values {
+		list[i] = value
+	}
+	return list
+}
+
+// checkInListSize rejects slice parameters with more than maxInListValues values.
+func checkInListSize(param string, size int) error {
+	if size > maxInListValues {
+		return fmt.Errorf("%s has %d values, exceeding the maximum of %d", param, size, maxInListValues)
+	}
+	return nil
+}
+
+// expandInLists replaces the ?... placeholder of every slice parameter in query with a placeholder
+// per value, in order, and spreads their values into the arguments. An empty slice expands to NULL,
+// which matches no rows.
+func expandInLists(query string, args ...interface{}) (string, []interface{}) {
+	expanded := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		list, ok := arg.(inList)
+		if !ok {
+			expanded = append(expanded, arg)
+			continue
+		}
+
+		placeholders := "NULL"
+		if len(list) > 0 {
+			placeholders = strings.TrimSuffix(strings.Repeat("?,", len(list)), ",")
+		}
+		query = strings.Replace(query, "?...", placeholders, 1)
+		expanded = append(expanded, list...)
+	}
+	return query, expanded
+}
+
+// inListKey hashes the values of a slice parameter for cache keys. Values are sorted, as their
+// order doesn't change the rows an IN list matches.
+func inListKey[T any](values []T) string {
+	formatted := make([]string, len(values))
+	for i, value := range values {
+		if t, ok := any(value).(time.Time); ok {
+			formatted[i] = fmt.Sprint(t.UnixNano())
+		} else {
+			formatted[i] = fmt.Sprint(value)
+		}
+	}
+	sort.Strings(formatted)
+
+	h := sha256.New()
+	for _, value := range formatted {
+		h.Write([]byte(value))
+		h.Write([]byte{0})
+	}
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil)[:12])
+}
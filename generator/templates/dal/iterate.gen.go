@@ -0,0 +1,89 @@
+package dal
+
+import (
+	"context"
+	"iter"
+	"time"
+)
+
+// defaultIterateBatchSize is the number of rows an iterator reads per query when IterateOptions.BatchSize is 0.
+const defaultIterateBatchSize = 500
+
+// IterateOptions configures the IterateAll and IterateBy<List> iterators of the repositories.
+type IterateOptions struct {
+	// BatchSize is the number of rows read per query. Defaults to 500.
+	BatchSize int
+	// StartAfterID resumes an iteration after the entity with this ID, e.g. the last one an interrupted
+	// run yielded. Lists ordered by another column look the entity up to continue after its position.
+	StartAfterID int64
+	// RowsPerSecond limits how fast rows are read to spare the database. 0 means no limit.
+	RowsPerSecond float64
+}
+
+func (o IterateOptions) batchSize() int {
+	if o.BatchSize <= 0 {
+		return defaultIterateBatchSize
+	}
+	return o.BatchSize
+}
+
+// iterateThrottle spaces out the batches of an iterator to keep it under RowsPerSecond.
+type iterateThrottle struct {
+	rowsPerSecond float64
+	last          time.Time
+}
+
+// wait blocks until the rows read by the previous batch fit the rate, or ctx is done.
+func (t *iterateThrottle) wait(ctx context.Context, rows int) error {
+	if t.rowsPerSecond > 0 {
+		budget := time.Duration(float64(rows) / t.rowsPerSecond * float64(time.Second))
+		if delay := budget - time.Since(t.last); delay > 0 {
+			timer := time.NewTimer(delay)
+			defer timer.Stop()
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+		t.last = time.Now()
+	}
+	return ctx.Err()
+}
+
+// iterateBatches yields the entities of consecutive batches until a batch comes back short, fetching
+// fails, ctx is done or the loop breaks. fetch reads the batch following after, which is nil for the
+// first batch. Errors, including the context's, are yielded once and end the iteration.
+func iterateBatches[T any](ctx context.Context, opts IterateOptions, fetch func(after *T, batchSize int) ([]*T, error)) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		batchSize := opts.batchSize()
+		throttle := iterateThrottle{rowsPerSecond: opts.RowsPerSecond, last: time.Now()}
+
+		var after *T
+		read := 0
+		for {
+			if err := throttle.wait(ctx, read); err != nil {
+				yield(nil, err)
+				return
+			}
+
+			entities, err := fetch(after, batchSize)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			for _, entity := range entities {
+				if !yield(entity, nil) {
+					return
+				}
+			}
+
+			if len(entities) < batchSize {
+				return
+			}
+			after = entities[len(entities)-1]
+			read = len(entities)
+		}
+	}
+}
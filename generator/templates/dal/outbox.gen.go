@@ -0,0 +1,218 @@
+package dal
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// OutboxEvent is a change of an entity with the outbox option, written to the dal_outbox table in the
+// transaction of the change and handed to the EventPublisher by the OutboxRelay.
+type OutboxEvent struct {
+	// ID is unique, use it to deduplicate. Events of one entity get increasing IDs in the order of its changes.
+	// Across entities IDs follow the order the events were written in, not the order their transactions committed in.
+	ID       int64  `json:"id"`
+	Entity   string `json:"entity"`
+	EntityID int64  `json:"entity_id"`
+	// Operation is the repository operation making the change, e.g. "update" or "hard_delete".
+	Operation string `json:"operation"`
+	Version   int32  `json:"version"` // Version of the entity after the change, before it for hard deletes
+	// Payload is the JSON encoded entity after the change, before it for hard deletes.
+	Payload  json.RawMessage `json:"payload"`
+	Attempts int             `json:"attempts"` // Number of failed deliveries so far
+	Created  time.Time       `json:"created"`
+}
+
+// EventPublisher delivers outbox events to a broker. Events may be delivered more than once, when the
+// relay dies between Publish and marking them delivered, so consumers should deduplicate on ID.
+type EventPublisher interface {
+	// Publish delivers the events, in ID order. A later batch may still hold lower IDs of transactions that were
+	// running while this one was claimed. Returning an error retries the whole batch later.
+	Publish(ctx context.Context, events []*OutboxEvent) error
+}
+
+// OutboxRelayOptions configures an OutboxRelay. Zero values take the defaults.
+type OutboxRelayOptions struct {
+	// BatchSize is the number of events handed to the publisher at once. Defaults to 100.
+	BatchSize int
+	// PollInterval is the time Run waits for new events once the outbox is drained. Defaults to 1s.
+	PollInterval time.Duration
+	// RetryDelay is the time before retrying a failed batch, doubled after every failed attempt. Defaults to 1s.
+	RetryDelay time.Duration
+	// MaxRetryDelay caps the growing delay between attempts. Defaults to 5m.
+	MaxRetryDelay time.Duration
+}
+
+// OutboxRelay moves the events of the dal_outbox table of a database to an EventPublisher. Several relays
+// can share a database on MySQL and PostgreSQL, each claims different rows with FOR UPDATE SKIP LOCKED.
+type OutboxRelay struct {
+	db        *sql.DB
+	dialect   string
+	publisher EventPublisher
+	opts      OutboxRelayOptions
+}
+
+// NewOutboxRelay returns a relay for the dal_outbox table of db, which is the write database of the entities
+// with the outbox option, e.g. the result of DBProvider.GetDatabase("user", true).
+func NewOutboxRelay(db *sql.DB, publisher EventPublisher, opts OutboxRelayOptions) *OutboxRelay {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = time.Second
+	}
+	if opts.MaxRetryDelay <= 0 {
+		opts.MaxRetryDelay = 5 * time.Minute
+	}
+	return &OutboxRelay{
+		db:        db,
+		dialect:   databaseDialect(db),
+		publisher: publisher,
+		opts:      opts,
+	}
+}
+
+// Run relays events until ctx is done, then returns its error. Failures are logged and retried.
+func (r *OutboxRelay) Run(ctx context.Context) error {
+	for {
+		relayed, err := r.RelayOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Errorf("Failed to relay outbox events: %v\n", err)
+		}
+		if err == nil && relayed == r.opts.BatchSize {
+			continue // There may be more waiting
+		}
+
+		timer := time.NewTimer(r.opts.PollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// RelayOnce publishes a batch of the pending events whose next attempt is due and returns how many it claimed.
+// Delivered events are marked as such, a failed batch is scheduled for a retry with the publisher's error.
+func (r *OutboxRelay) RelayOnce(ctx context.Context) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin outbox transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }() // No-op once committed
+
+	events, err := r.pendingEvents(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, tx.Commit()
+	}
+
+	publishErr := r.publisher.Publish(ctx, events)
+	if publishErr != nil {
+		err = r.scheduleRetry(ctx, tx, events, publishErr)
+	} else {
+		err = r.markDelivered(ctx, tx, events)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit outbox transaction: %w", err)
+	}
+	if publishErr != nil {
+		return len(events), fmt.Errorf("failed to publish outbox events: %w", publishErr)
+	}
+	return len(events), nil
+}
+
+// pendingEvents claims the next batch of undelivered events whose attempt is due, oldest first.
+func (r *OutboxRelay) pendingEvents(ctx context.Context, tx *sql.Tx) ([]*OutboxEvent, error) {
+	query := `
+		SELECT id, entity, entity_id, operation, version, payload, attempts, created
+		FROM dal_outbox
+		WHERE delivered_at IS NULL AND next_attempt_at <= ?
+		ORDER BY id
+		LIMIT ?`
+	if r.dialect != "sqlite" {
+		// SQLite has no row locks, its writes already lock the whole database
+		query += " FOR UPDATE SKIP LOCKED"
+	}
+	query = r.rebind(query)
+
+	rows, err := tx.QueryContext(ctx, query, time.Now(), r.opts.BatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*OutboxEvent
+	for rows.Next() {
+		var event OutboxEvent
+		var payload []byte
+		err := rows.Scan(&event.ID, &event.Entity, &event.EntityID, &event.Operation, &event.Version, &payload, &event.Attempts, &event.Created)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		event.Payload = payload
+		events = append(events, &event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return events, nil
+}
+
+func (r *OutboxRelay) markDelivered(ctx context.Context, tx *sql.Tx, events []*OutboxEvent) error {
+	ids := make([]int64, len(events))
+	for i, event := range events {
+		ids[i] = event.ID
+	}
+
+	query, args := expandInLists("UPDATE dal_outbox SET delivered_at = ? WHERE id IN (?...)", time.Now(), inValues(ids))
+	if _, err := tx.ExecContext(ctx, r.rebind(query), args...); err != nil {
+		return fmt.Errorf("failed to mark outbox events delivered: %w", err)
+	}
+	return nil
+}
+
+// scheduleRetry records the failed attempt of every event and postpones its next one by its backoff.
+func (r *OutboxRelay) scheduleRetry(ctx context.Context, tx *sql.Tx, events []*OutboxEvent, publishErr error) error {
+	query := r.rebind("UPDATE dal_outbox SET attempts = ?, last_error = ?, next_attempt_at = ? WHERE id = ?")
+	now := time.Now()
+	for _, event := range events {
+		attempts := event.Attempts + 1
+		_, err := tx.ExecContext(ctx, query, attempts, publishErr.Error(), now.Add(r.retryDelay(attempts)), event.ID)
+		if err != nil {
+			return fmt.Errorf("failed to schedule outbox event %d: %w", event.ID, err)
+		}
+	}
+	return nil
+}
+
+// retryDelay returns the backoff after the given number of failed attempts.
+func (r *OutboxRelay) retryDelay(attempts int) time.Duration {
+	delay := r.opts.RetryDelay
+	for i := 1; i < attempts && delay < r.opts.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, r.opts.MaxRetryDelay)
+}
+
+func (r *OutboxRelay) rebind(query string) string {
+	if r.dialect == "postgres" {
+		return rebindPostgres(query)
+	}
+	return query
+}
@@ -0,0 +1,102 @@
+package dal
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// ErrInvalidPageToken is returned when a page token is malformed or belongs to a different entity, list or parameters.
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// Page is a page of a list operation generated with `pagination: token`.
+type Page[T any] struct {
+	Items []*T
+	// NextPageToken fetches the following page. It is empty on the last page.
+	NextPageToken string
+	HasMore       bool
+}
+
+// pageToken is the content of the opaque token. Entity, List and Params bind it to the query it continues.
+type pageToken struct {
+	Entity string          `json:"e"`
+	List   string          `json:"l"`
+	Params string          `json:"p"`
+	Cursor json.RawMessage `json:"c"`
+}
+
+// pageParamsHash hashes the parameters of a list call. Pointers are followed, times are
+// compared by instant, slices value by value and filters by their active filters, so equal
+// parameters always produce the same hash.
+func pageParamsHash(params ...interface{}) string {
+	h := sha256.New()
+	for _, param := range params {
+		if filter, ok := param.(interface{ cacheKey() string }); ok {
+			fmt.Fprint(h, filter.cacheKey())
+			h.Write([]byte{0})
+			continue
+		}
+
+		value := reflect.ValueOf(param)
+		for value.Kind() == reflect.Pointer && !value.IsNil() {
+			value = value.Elem()
+		}
+
+		switch {
+		case !value.IsValid() || value.Kind() == reflect.Pointer:
+			fmt.Fprint(h, "<<null>>")
+		case value.Type() == reflect.TypeOf(time.Time{}):
+			fmt.Fprint(h, value.Interface().(time.Time).UnixNano())
+		case value.Kind() == reflect.Slice:
+			// Slice parameters, hashed value by value so ["a b"] and ["a", "b"] differ
+			fmt.Fprintf(h, "%d", value.Len())
+			for i := 0; i < value.Len(); i++ {
+				fmt.Fprintf(h, "\x1f%v", value.Index(i).Interface())
+			}
+		default:
+			fmt.Fprintf(h, "%v", value.Interface())
+		}
+		h.Write([]byte{0})
+	}
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil)[:12])
+}
+
+// encodePageToken builds the opaque token pointing at cursor in the list of the entity called with the hashed params.
+func encodePageToken(entity, list, paramsHash string, cursor interface{}) (string, error) {
+	encodedCursor, err := json.Marshal(cursor)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode page cursor: %w", err)
+	}
+
+	token, err := json.Marshal(pageToken{Entity: entity, List: list, Params: paramsHash, Cursor: encodedCursor})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode page token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+// decodePageToken decodes the cursor of a token into cursor. Tokens of another entity, another list
+// or other parameters are rejected with ErrInvalidPageToken.
+func decodePageToken(token, entity, list, paramsHash string, cursor interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPageToken, err)
+	}
+
+	var decoded pageToken
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPageToken, err)
+	}
+	if decoded.Entity != entity || decoded.List != list || decoded.Params != paramsHash {
+		return fmt.Errorf("%w: issued for a different query", ErrInvalidPageToken)
+	}
+
+	if err := json.Unmarshal(decoded.Cursor, cursor); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPageToken, err)
+	}
+	return nil
+}
@@ -4,9 +4,8 @@ import (
 	"database/sql"
 	"fmt"
 	"math/rand"
+	"net/url"
 	"strings"
-
-	_ "github.com/go-sql-driver/mysql" // or another driver if desired
 )
 
 // DBProvider defines the interface your ServerProvider must implement.
@@ -22,6 +21,8 @@ type ServerProvider struct {
 	config *ServerConfig
 	// groups stores references to each server group keyed by the group name.
 	groups map[string]*dbGroup
+	// sqliteDBs shares one connection per SQLite database, so reads and writes see the same data.
+	sqliteDBs map[string]*sql.DB
 }
 
 // dbGroup holds the databases for a particular group of entities.
@@ -52,7 +53,10 @@ type InstancesConfig struct {
 }
 
 // DBInstance represents one database connection (read or write).
+// Driver is "mysql" (default), "postgres" or "sqlite" and must match the dialect the entities were generated with.
+// For sqlite, Database is a file path or ":memory:" and Server and Credentials are ignored.
 type DBInstance struct {
+	Driver      string            `yaml:"driver" json:"driver"`
 	Server      string            `yaml:"server" json:"server"`
 	Database    string            `yaml:"database" json:"database"`
 	Credentials CredentialsConfig `yaml:"credentials" json:"credentials"`
@@ -95,8 +99,9 @@ func NewServerProvider(cfg *ServerConfig) (*ServerProvider, error) {
 	}
 
 	return &ServerProvider{
-		config: cfg,
-		groups: make(map[string]*dbGroup),
+		config:    cfg,
+		groups:    make(map[string]*dbGroup),
+		sqliteDBs: make(map[string]*sql.DB),
 	}, nil
 }
 
@@ -135,19 +140,28 @@ func (s *ServerProvider) Connect() error {
 
 // connectInstance creates a *sql.DB for the given instance.
 func (s *ServerProvider) connectInstance(inst DBInstance) (*sql.DB, error) {
-	// Build DataSourceName for MySQL driver.
-	dsn := fmt.Sprintf("%s:%s@tcp(%s)/%s?parseTime=true",
-		inst.Credentials.User,
-		inst.Credentials.Pass,
-		inst.Server,
-		inst.Database,
-	)
-
-	db, err := sql.Open("mysql", dsn)
+	driverName, dsn, err := instanceDSN(inst)
+	if err != nil {
+		return nil, err
+	}
+
+	if driverName == "sqlite" {
+		if db, exists := s.sqliteDBs[dsn]; exists {
+			return db, nil
+		}
+	}
+
+	db, err := sql.Open(driverName, dsn)
 	if err != nil {
 		return nil, err
 	}
 
+	if driverName == "sqlite" {
+		// SQLite allows a single writer, and every connection to ":memory:" opens a new empty database.
+		db.SetMaxOpenConns(1)
+		s.sqliteDBs[dsn] = db
+	}
+
 	// Test the connection
 	if err := db.Ping(); err != nil {
 		db.Close()
@@ -157,6 +171,32 @@ func (s *ServerProvider) connectInstance(inst DBInstance) (*sql.DB, error) {
 	return db, nil
 }
 
+// instanceDSN returns the database/sql driver name and DataSourceName for the instance.
+func instanceDSN(inst DBInstance) (string, string, error) {
+	switch strings.ToLower(inst.Driver) {
+	case "", "mysql":
+		// Build DataSourceName for MySQL driver.
+		return "mysql", fmt.Sprintf("%s:%s@tcp(%s)/%s?parseTime=true",
+			inst.Credentials.User,
+			inst.Credentials.Pass,
+			inst.Server,
+			inst.Database,
+		), nil
+	case "sqlite", "sqlite3":
+		return "sqlite", inst.Database + "?_pragma=busy_timeout(5000)", nil
+	case "postgres", "postgresql":
+		dsn := url.URL{
+			Scheme: "postgres",
+			User:   url.UserPassword(inst.Credentials.User, inst.Credentials.Pass),
+			Host:   inst.Server,
+			Path:   "/" + inst.Database,
+		}
+		return "pgx", dsn.String(), nil
+	default:
+		return "", "", fmt.Errorf("unsupported database driver: %s", inst.Driver)
+	}
+}
+
 // Disconnect closes all DBs in all groups.
 func (s *ServerProvider) Disconnect() error {
 	for _, group := range s.groups {
@@ -167,6 +207,7 @@ func (s *ServerProvider) Disconnect() error {
 			_ = w.Close()
 		}
 	}
+	s.sqliteDBs = make(map[string]*sql.DB)
 	return nil
 }
 
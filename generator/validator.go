@@ -3,7 +3,10 @@ package generator
 import (
 	"errors"
 	"fmt"
+	"math"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -17,12 +20,25 @@ func ValidateEntityConfig(entity EntityConfig) error {
 	// Validate version.
 	errs = append(errs, validateVersion(entity.Version)...)
 
+	// Validate dialect.
+	errs = append(errs, validateDialect(entity.Dialect)...)
+
 	// Validate columns.
 	errs = append(errs, validateColumns(entity.Columns)...)
 
 	// Validate operations.
 	errs = append(errs, validateOperationConfig(entity.Operations, entity.Columns)...)
 
+	// Validate the where clauses only use SQL of the dialect.
+	errs = append(errs, validateWhereDialect(entity)...)
+
+	// Validate searches, which depend on the dialect and the list cache expiration.
+	errs = append(errs, validateSearches(entity)...)
+
+	// Validate audit, which records the writes of the operations.
+	errs = append(errs, validateAudit(entity)...)
+	errs = append(errs, validateOutbox(entity)...)
+
 	// Validate caching config.
 	errs = append(errs, validateCachingConfig(entity.Caching)...)
 
@@ -64,6 +80,19 @@ func validateVersion(version string) []string {
 	return errs
 }
 
+// validateDialect ensures the dialect is one of the supported SQL dialects. Empty means the default.
+func validateDialect(dialect string) []string {
+	if dialect == "" {
+		return nil
+	}
+	for _, supported := range supportedDialects {
+		if dialect == supported {
+			return nil
+		}
+	}
+	return []string{fmt.Sprintf("dialect must be one of '%s', got '%s'", strings.Join(supportedDialects, "', '"), dialect)}
+}
+
 // validateColumns checks that each column name is snake_cased, has no spaces,
 // and the type is supported.
 func validateColumns(columns map[string]Column) []string {
@@ -82,6 +111,8 @@ func validateColumns(columns map[string]Column) []string {
 		"datetime": true,
 		"uid":      true,
 		"json":     true,
+		"enum":     true,
+		"decimal":  true,
 	}
 
 	for colName, col := range columns {
@@ -103,6 +134,143 @@ func validateColumns(columns map[string]Column) []string {
 				errs = append(errs, fmt.Sprintf("prefix '%s' for column '%s' must be in snake_case", col.Prefix, colName))
 			}
 		}
+
+		errs = append(errs, validateEnumValues(colName, col)...)
+		errs = append(errs, validateDecimalDigits(colName, col)...)
+		errs = append(errs, validateColumnRules(colName, col)...)
+	}
+	return errs
+}
+
+// validateEnumValues ensures enum columns list unique values, which name Go constants and SQL literals.
+func validateEnumValues(colName string, col Column) []string {
+	if col.Type != "enum" {
+		if len(col.Values) > 0 {
+			return []string{fmt.Sprintf("column '%s' of type '%s' doesn't support values, only enum columns do", colName, col.Type)}
+		}
+		return nil
+	}
+
+	var errs []string
+	if len(col.Values) == 0 {
+		errs = append(errs, fmt.Sprintf("enum column '%s' requires at least one value", colName))
+	}
+	if col.Unique {
+		errs = append(errs, fmt.Sprintf("enum column '%s' can't be unique", colName))
+	}
+	seen := make(map[string]bool)
+	for _, value := range col.Values {
+		if !isSnakeCase(value) {
+			errs = append(errs, fmt.Sprintf("value '%s' of enum column '%s' must be in snake_case", value, colName))
+		} else if seen[value] {
+			errs = append(errs, fmt.Sprintf("value '%s' of enum column '%s' is listed twice", value, colName))
+		}
+		seen[value] = true
+	}
+	return errs
+}
+
+// validateDecimalDigits ensures decimal columns have a precision and scale all dialects support.
+func validateDecimalDigits(colName string, col Column) []string {
+	if col.Type != "decimal" {
+		if col.Precision != 0 || col.Scale != 0 {
+			return []string{fmt.Sprintf("column '%s' of type '%s' doesn't support precision and scale, only decimal columns do", colName, col.Type)}
+		}
+		return nil
+	}
+
+	var errs []string
+	if col.Precision < 1 || col.Precision > 65 {
+		errs = append(errs, fmt.Sprintf("precision of decimal column '%s' must be between 1 and 65, got %d", colName, col.Precision))
+	}
+	if col.Scale < 0 || col.Scale > 30 {
+		errs = append(errs, fmt.Sprintf("scale of decimal column '%s' must be between 0 and 30, got %d", colName, col.Scale))
+	} else if col.Scale > col.Precision {
+		errs = append(errs, fmt.Sprintf("scale of decimal column '%s' is greater than its precision", colName))
+	}
+	return errs
+}
+
+// validateColumnRules ensures the validation rules of a column fit its type and can be satisfied.
+func validateColumnRules(colName string, col Column) []string {
+	var errs []string
+	unsupported := func(rule string) {
+		errs = append(errs, fmt.Sprintf("column '%s' of type '%s' doesn't support the %s rule", colName, col.Type, rule))
+	}
+
+	if isTextColumn(col.Type) {
+		if col.MinLength != nil && *col.MinLength < 0 {
+			errs = append(errs, fmt.Sprintf("minLength of column '%s' must not be negative", colName))
+		}
+		if col.MaxLength != nil && *col.MaxLength < 0 {
+			errs = append(errs, fmt.Sprintf("maxLength of column '%s' must not be negative", colName))
+		}
+		if col.MinLength != nil && col.MaxLength != nil && *col.MinLength > *col.MaxLength {
+			errs = append(errs, fmt.Sprintf("minLength of column '%s' is greater than its maxLength", colName))
+		}
+		if col.Type == "varchar" && col.MaxLength != nil && *col.MaxLength > 255 {
+			errs = append(errs, fmt.Sprintf("maxLength of column '%s' exceeds the 255 characters of varchar, use text", colName))
+		}
+		if col.Pattern != "" {
+			if _, err := regexp.Compile(col.Pattern); err != nil {
+				errs = append(errs, fmt.Sprintf("pattern of column '%s' is invalid: %v", colName, err))
+			}
+		}
+		if col.Format != "" && col.Format != "email" && col.Format != "url" {
+			errs = append(errs, fmt.Sprintf("format of column '%s' must be 'email' or 'url', got '%s'", colName, col.Format))
+		}
+	} else {
+		if col.MinLength != nil {
+			unsupported("minLength")
+		}
+		if col.MaxLength != nil {
+			unsupported("maxLength")
+		}
+		if col.Pattern != "" {
+			unsupported("pattern")
+		}
+		if col.Format != "" {
+			unsupported("format")
+		}
+	}
+
+	bits := integerBits(col.Type)
+	if bits > 0 || col.Type == "float" {
+		for _, rule := range []struct {
+			name  string
+			bound *float64
+		}{{"min", col.Min}, {"max", col.Max}} {
+			if rule.bound == nil || bits == 0 {
+				continue
+			}
+			if *rule.bound != math.Trunc(*rule.bound) {
+				errs = append(errs, fmt.Sprintf("%s of column '%s' must be a whole number", rule.name, colName))
+			} else if limit := math.Ldexp(1, bits-1); *rule.bound < -limit || *rule.bound >= limit {
+				errs = append(errs, fmt.Sprintf("%s of column '%s' is out of range for %s", rule.name, colName, col.Type))
+			}
+		}
+		if col.Min != nil && col.Max != nil && *col.Min > *col.Max {
+			errs = append(errs, fmt.Sprintf("min of column '%s' is greater than its max", colName))
+		}
+	} else {
+		if col.Min != nil {
+			unsupported("min")
+		}
+		if col.Max != nil {
+			unsupported("max")
+		}
+	}
+
+	switch {
+	case len(col.OneOf) == 0:
+	case bits > 0:
+		for _, value := range col.OneOf {
+			if _, err := strconv.ParseInt(value, 10, bits); err != nil {
+				errs = append(errs, fmt.Sprintf("oneOf value '%s' of column '%s' isn't a valid %s", value, colName, col.Type))
+			}
+		}
+	case !isTextColumn(col.Type):
+		unsupported("oneOf")
 	}
 	return errs
 }
@@ -136,6 +304,10 @@ func validateOperationConfig(ops OperationConfig, columns map[string]Column) []s
 	// Check all explicitly named operations for collisions
 	for _, l := range ops.Lists {
 		checkName(l.Name, "lists")
+		if l.Pagination == "token" {
+			checkName(l.Name+"_page", "lists")
+		}
+		checkName("iterate_by_"+l.Name, "lists")
 	}
 	for _, lb := range ops.ListsBulk {
 		checkName(lb.Name, "listsBulk")
@@ -149,6 +321,27 @@ func validateOperationConfig(ops OperationConfig, columns map[string]Column) []s
 	for _, p := range ops.Plucks {
 		checkName(p.Name, "plucks")
 	}
+	for _, u := range ops.Upserts {
+		checkName("upsert_by_"+u.Column, "upserts")
+	}
+	for _, p := range ops.Patches {
+		checkName(patchName(p), "patches")
+	}
+	for _, i := range ops.Increments {
+		checkName("increment_"+i.Column, "increments")
+	}
+	for _, a := range ops.Aggregates {
+		checkName(aggregateName(a), "aggregates")
+	}
+	for _, g := range ops.GroupCounts {
+		checkName(groupCountName(g), "groupCounts")
+	}
+	for _, e := range ops.Exists {
+		checkName(existsName(e), "exists")
+	}
+	for _, s := range ops.Searches {
+		checkName(s.Name, "searches")
+	}
 
 	// Validate Gets.
 	for _, colName := range ops.Gets {
@@ -177,6 +370,127 @@ func validateOperationConfig(ops OperationConfig, columns map[string]Column) []s
 
 	errs = append(errs, validatePlucks(ops.Plucks, columns)...)
 
+	errs = append(errs, validateAggregates(ops.Aggregates, columns)...)
+
+	errs = append(errs, validateGroupCounts(ops.GroupCounts, columns)...)
+
+	errs = append(errs, validateExists(ops.Exists, columns)...)
+
+	errs = append(errs, validateUpserts(ops, columns)...)
+
+	errs = append(errs, validatePatches(ops, columns)...)
+
+	errs = append(errs, validateIncrements(ops, columns)...)
+
+	return errs
+}
+
+// validateUpserts ensures upserts target a unique column that is also listed in gets, which
+// the generated code uses to invalidate cached unique keys, and only overwrite known columns.
+func validateUpserts(ops OperationConfig, columns map[string]Column) []string {
+	var errs []string
+
+	if len(ops.Upserts) > 0 && !ops.Write {
+		errs = append(errs, "upserts require 'write: true'")
+	}
+
+	for _, upsert := range ops.Upserts {
+		col, exists := columns[upsert.Column]
+		if !exists {
+			errs = append(errs, fmt.Sprintf("upsert operation refers to unknown column '%s'", upsert.Column))
+			continue
+		}
+		if !col.Unique {
+			errs = append(errs, fmt.Sprintf("upsert operation requires column '%s' to be unique", upsert.Column))
+		}
+		if col.AllowNull {
+			// NULLs never conflict, every upsert would insert a new row.
+			errs = append(errs, fmt.Sprintf("upsert column '%s' cannot allow null", upsert.Column))
+		}
+		if !slices.Contains(ops.Gets, upsert.Column) {
+			errs = append(errs, fmt.Sprintf("upsert column '%s' must also be listed in gets", upsert.Column))
+		}
+
+		if len(upsert.Set) == 0 {
+			errs = append(errs, fmt.Sprintf("upsert by '%s' must specify at least one column in 'set'", upsert.Column))
+		}
+		for _, setCol := range upsert.Set {
+			if setCol == "id" || setCol == "created" || setCol == "updated" || setCol == upsert.Column {
+				errs = append(errs, fmt.Sprintf("upsert by '%s' cannot overwrite column '%s'", upsert.Column, setCol))
+			} else if _, exists := columns[setCol]; !exists {
+				errs = append(errs, fmt.Sprintf("upsert by '%s' refers to unknown set column '%s'", upsert.Column, setCol))
+			}
+		}
+	}
+	return errs
+}
+
+// validatePatches ensures patches only set known columns the entity lets callers change.
+func validatePatches(ops OperationConfig, columns map[string]Column) []string {
+	var errs []string
+
+	if len(ops.Patches) > 0 && !ops.Write {
+		errs = append(errs, "patches require 'write: true'")
+	}
+
+	for _, patch := range ops.Patches {
+		if len(patch.Set) == 0 {
+			errs = append(errs, "patch must specify at least one column in 'set'")
+		}
+		for _, setCol := range patch.Set {
+			if setCol == "id" || setCol == "version" || setCol == "created" || setCol == "updated" {
+				errs = append(errs, fmt.Sprintf("patch '%s' cannot set column '%s'", patchName(patch), setCol))
+			} else if _, exists := columns[setCol]; !exists {
+				errs = append(errs, fmt.Sprintf("patch '%s' refers to unknown set column '%s'", patchName(patch), setCol))
+			}
+		}
+	}
+	return errs
+}
+
+// validateIncrements ensures increments target non null integer columns and have sane bounds.
+func validateIncrements(ops OperationConfig, columns map[string]Column) []string {
+	var errs []string
+	integerTypes := map[string]bool{"int8": true, "int32": true, "int64": true}
+
+	if len(ops.Increments) > 0 && !ops.Write {
+		errs = append(errs, "increments require 'write: true'")
+	}
+
+	for _, inc := range ops.Increments {
+		col, exists := columns[inc.Column]
+		switch {
+		case !exists:
+			errs = append(errs, fmt.Sprintf("increment refers to unknown column '%s'", inc.Column))
+		case !integerTypes[col.Type]:
+			errs = append(errs, fmt.Sprintf("increment column '%s' must be an integer, got %s", inc.Column, col.Type))
+		case col.AllowNull:
+			// NULL + delta stays NULL.
+			errs = append(errs, fmt.Sprintf("increment column '%s' cannot allow null", inc.Column))
+		case col.Unique:
+			errs = append(errs, fmt.Sprintf("increment column '%s' cannot be unique", inc.Column))
+		}
+
+		if inc.Floor != nil && inc.Ceiling != nil && *inc.Floor > *inc.Ceiling {
+			errs = append(errs, fmt.Sprintf("increment of '%s' has floor %d above ceiling %d", inc.Column, *inc.Floor, *inc.Ceiling))
+		}
+
+		// Increments don't call Validate, their bounds must keep the column within its min and max.
+		if col.Min != nil {
+			if inc.Floor == nil {
+				errs = append(errs, fmt.Sprintf("increment of '%s' needs a floor, the column has a min of %g", inc.Column, *col.Min))
+			} else if float64(*inc.Floor) < *col.Min {
+				errs = append(errs, fmt.Sprintf("increment of '%s' has floor %d below the min %g of the column", inc.Column, *inc.Floor, *col.Min))
+			}
+		}
+		if col.Max != nil {
+			if inc.Ceiling == nil {
+				errs = append(errs, fmt.Sprintf("increment of '%s' needs a ceiling, the column has a max of %g", inc.Column, *col.Max))
+			} else if float64(*inc.Ceiling) > *col.Max {
+				errs = append(errs, fmt.Sprintf("increment of '%s' has ceiling %d above the max %g of the column", inc.Column, *inc.Ceiling, *col.Max))
+			}
+		}
+	}
 	return errs
 }
 
@@ -203,6 +517,173 @@ func validatePlucks(plucks []PluckConfig, columns map[string]Column) []string {
 				errs = append(errs, fmt.Sprintf("typeMapping column '%s' for param '%s' in pluck '%s' is not defined", mappedCol, paramName, pluck.Name))
 			}
 		}
+
+		errs = append(errs, validateWhere("pluck", pluck.Name, pluck.Where, pluck.TypeMapping, columns)...)
+	}
+	return errs
+}
+
+// validateAggregates ensures aggregates use a supported function over a numeric column.
+func validateAggregates(aggregates []AggregateConfig, columns map[string]Column) []string {
+	var errs []string
+	allowedDefaults := map[string]bool{"created": true, "id": true, "updated": true}
+	numericTypes := map[string]bool{"int8": true, "int32": true, "int64": true, "float": true, "decimal": true}
+
+	for _, agg := range aggregates {
+		if !slices.Contains([]string{"sum", "avg", "min", "max"}, agg.Function) {
+			errs = append(errs, fmt.Sprintf("aggregate function '%s' on column '%s' must be one of sum, avg, min, max", agg.Function, agg.Column))
+		}
+
+		if col, exists := columns[agg.Column]; !exists {
+			errs = append(errs, fmt.Sprintf("aggregate '%s' refers to unknown column '%s'", aggregateName(agg), agg.Column))
+		} else if !numericTypes[col.Type] {
+			errs = append(errs, fmt.Sprintf("aggregate '%s' requires a numeric column, '%s' is %s", aggregateName(agg), agg.Column, col.Type))
+		}
+
+		for paramName, mappedCol := range agg.TypeMapping {
+			if _, exists := columns[mappedCol]; !exists && !allowedDefaults[mappedCol] {
+				errs = append(errs, fmt.Sprintf("typeMapping column '%s' for param '%s' in aggregate '%s' is not defined", mappedCol, paramName, aggregateName(agg)))
+			}
+		}
+
+		errs = append(errs, validateWhere("aggregate", aggregateName(agg), agg.Where, agg.TypeMapping, columns)...)
+		for _, param := range whereSliceParams(agg.Where) {
+			errs = append(errs, fmt.Sprintf("aggregate '%s' doesn't support slice parameter ':%s...'", aggregateName(agg), param))
+		}
+	}
+	return errs
+}
+
+// validateAudit ensures audited entities have writes to record and that no list or search
+// collides with the generated History method.
+func validateAudit(entity EntityConfig) []string {
+	var errs []string
+	if !entity.Audit {
+		return errs
+	}
+	if !entity.Operations.Write {
+		errs = append(errs, "audit requires write operations")
+	}
+	for _, l := range entity.Operations.Lists {
+		if PascalCaser(l.Name) == "History" {
+			errs = append(errs, fmt.Sprintf("list '%s' collides with the History method of audited entities", l.Name))
+		}
+	}
+	for _, s := range entity.Operations.Searches {
+		if PascalCaser(s.Name) == "History" {
+			errs = append(errs, fmt.Sprintf("search '%s' collides with the History method of audited entities", s.Name))
+		}
+	}
+	return errs
+}
+
+// validateOutbox ensures entities publishing their changes have writes to publish.
+func validateOutbox(entity EntityConfig) []string {
+	if entity.Outbox && !entity.Operations.Write {
+		return []string{"outbox requires write operations"}
+	}
+	return nil
+}
+
+// validateSearches ensures searches match text columns through MySQL FULLTEXT indexes and
+// don't keep their results longer than lists.
+func validateSearches(entity EntityConfig) []string {
+	var errs []string
+	allowedDefaults := map[string]bool{"created": true, "id": true, "updated": true}
+
+	for _, search := range entity.Operations.Searches {
+		if len(search.Name) <= 4 {
+			errs = append(errs, fmt.Sprintf("search name '%s' must be longer than 4 characters", search.Name))
+		}
+		if !isSnakeCase(search.Name) {
+			errs = append(errs, fmt.Sprintf("search name '%s' must be in snake_case. eg. search_posts", search.Name))
+		}
+		if !isMySQL(entity.Dialect) {
+			errs = append(errs, fmt.Sprintf("search '%s' requires FULLTEXT indexes, which only the mysql dialect supports", search.Name))
+		}
+
+		if len(search.Columns) == 0 {
+			errs = append(errs, fmt.Sprintf("search '%s' must match at least one column", search.Name))
+		}
+		for _, colName := range search.Columns {
+			if col, exists := entity.Columns[colName]; !exists {
+				errs = append(errs, fmt.Sprintf("search '%s' refers to unknown column '%s'", search.Name, colName))
+			} else if col.Type != "text" && col.Type != "varchar" {
+				errs = append(errs, fmt.Sprintf("search '%s' can only match text and varchar columns, '%s' is %s", search.Name, colName, col.Type))
+			}
+		}
+
+		if search.Mode != "" && search.Mode != "natural" && search.Mode != "boolean" {
+			errs = append(errs, fmt.Sprintf("search '%s' mode must be 'natural', 'boolean' or empty, got '%s'", search.Name, search.Mode))
+		}
+
+		if search.CacheSeconds < 1 || search.CacheSeconds > entity.Caching.ListExpirationSeconds {
+			errs = append(errs, fmt.Sprintf("search '%s' cacheSeconds must be between 1 and listExpirationSeconds (%d), got %d", search.Name, entity.Caching.ListExpirationSeconds, search.CacheSeconds))
+		}
+
+		for paramName, mappedCol := range search.TypeMapping {
+			if _, exists := entity.Columns[mappedCol]; !exists && !allowedDefaults[mappedCol] {
+				errs = append(errs, fmt.Sprintf("typeMapping column '%s' for param '%s' in search '%s' is not defined", mappedCol, paramName, search.Name))
+			}
+		}
+
+		errs = append(errs, validateWhere("search", search.Name, search.Where, search.TypeMapping, entity.Columns)...)
+		for _, param := range whereSliceParams(search.Where) {
+			errs = append(errs, fmt.Sprintf("search '%s' doesn't support slice parameter ':%s...'", search.Name, param))
+		}
+		if slices.Contains(extractParams(search.Where), "query") {
+			errs = append(errs, fmt.Sprintf("search '%s' can't use ':query' in its where, it is the search query", search.Name))
+		}
+	}
+	return errs
+}
+
+// validateGroupCounts ensures group counts group by a column that can be a map key.
+func validateGroupCounts(groups []GroupCountConfig, columns map[string]Column) []string {
+	var errs []string
+	allowedDefaults := map[string]bool{"created": true, "id": true, "updated": true}
+
+	for _, group := range groups {
+		if col, exists := columns[group.Column]; !exists {
+			errs = append(errs, fmt.Sprintf("groupCount refers to unknown column '%s'", group.Column))
+		} else if col.Type == "json" {
+			errs = append(errs, fmt.Sprintf("groupCount can't group by json column '%s'", group.Column))
+		}
+
+		for paramName, mappedCol := range group.TypeMapping {
+			if _, exists := columns[mappedCol]; !exists && !allowedDefaults[mappedCol] {
+				errs = append(errs, fmt.Sprintf("typeMapping column '%s' for param '%s' in groupCount '%s' is not defined", mappedCol, paramName, groupCountName(group)))
+			}
+		}
+
+		errs = append(errs, validateWhere("groupCount", groupCountName(group), group.Where, group.TypeMapping, columns)...)
+		for _, param := range whereSliceParams(group.Where) {
+			errs = append(errs, fmt.Sprintf("groupCount '%s' doesn't support slice parameter ':%s...'", groupCountName(group), param))
+		}
+	}
+	return errs
+}
+
+// validateExists ensures exists operations filter by at least one parameter, which also names them.
+func validateExists(existsOps []ExistsConfig, columns map[string]Column) []string {
+	var errs []string
+	allowedDefaults := map[string]bool{"created": true, "id": true, "updated": true}
+
+	for _, exists := range existsOps {
+		if len(extractParams(exists.Where)) == 0 {
+			errs = append(errs, fmt.Sprintf("exists where '%s' must use at least one :parameter", exists.Where))
+		}
+
+		for paramName, mappedCol := range exists.TypeMapping {
+			if _, ok := columns[mappedCol]; !ok && !allowedDefaults[mappedCol] {
+				errs = append(errs, fmt.Sprintf("typeMapping column '%s' for param '%s' in exists '%s' is not defined", mappedCol, paramName, existsName(exists)))
+			}
+		}
+
+		errs = append(errs, validateWhere("exists", existsName(exists), exists.Where, exists.TypeMapping, columns)...)
+		for _, param := range whereSliceParams(exists.Where) {
+			errs = append(errs, fmt.Sprintf("exists '%s' doesn't support slice parameter ':%s...'", existsName(exists), param))
+		}
 	}
 	return errs
 }
@@ -245,6 +726,11 @@ func validateListsBulk(lists []ListBulkConfig, columns map[string]Column) []stri
 				errs = append(errs, fmt.Sprintf("typeMapping column '%s' for param '%s' in listsBulk '%s' is not defined", mappedCol, paramName, list.Name))
 			}
 		}
+
+		errs = append(errs, validateWhere("listsBulk", list.Name, list.Where, list.TypeMapping, columns)...)
+		for _, param := range whereSliceParams(list.Where) {
+			errs = append(errs, fmt.Sprintf("listsBulk '%s' doesn't support slice parameter ':%s...', use whereIn", list.Name, param))
+		}
 	}
 	return errs
 }
@@ -326,14 +812,26 @@ func validateDeleteConfigs(deletes []DeleteConfig, columns map[string]Column) []
 				errs = append(errs, fmt.Sprintf("typeMapping column '%s' for param '%s' in delete '%s' is not defined", mappedCol, paramName, del.Name))
 			}
 		}
+
+		errs = append(errs, validateWhere("delete", del.Name, del.Where, del.TypeMapping, columns)...)
 	}
 	return errs
 }
 
+// whereRejectsNull reports whether the where clause never matches a row whose column is NULL, see rejectsNull.
+// A clause like "column IS NULL OR column > :x" lets NULLs through.
+func whereRejectsNull(where, column string) bool {
+	expr, err := parseWhere(where)
+	return err == nil && expr != nil && expr.rejectsNull(column)
+}
+
 // validateListConfigs validates each list config:
 //   - List name must be longer than 4 characters, snake_cased, and have no spaces.
 //   - Order: if provided, must contain exactly one column that exists in the columns map
-//     or is one of the allowed defaults: "created", "id", or "updated".
+//     or is one of the allowed defaults: "created", "id", or "updated". The where clause of
+//     a nullable order column must reject NULLs, as cursors can't point past them.
+//   - Pagination: if provided, must be "token".
+//   - Filters: replace where. Optional filters need a parameter and no parameter is shared by two filters.
 func validateListConfigs(lists []ListConfig, columns map[string]Column) []string {
 	var errs []string
 	allowedDefaults := map[string]bool{"created": true, "id": true, "updated": true}
@@ -359,9 +857,14 @@ func validateListConfigs(lists []ListConfig, columns map[string]Column) []string
 				if strings.Contains(orderCol, " ") {
 					errs = append(errs, fmt.Sprintf("order column '%s' in list '%s' must not contain spaces", orderCol, list.Name))
 				}
-				if _, exists := columns[orderCol]; !exists && !allowedDefaults[orderCol] {
+				col, exists := columns[orderCol]
+				if !exists && !allowedDefaults[orderCol] {
 					errs = append(errs, fmt.Sprintf("order column '%s' in list '%s' is not defined and not a default column", orderCol, list.Name))
 				}
+				// Cursors can't point past NULL order values, so the where clause has to filter them out.
+				if exists && col.AllowNull && !whereRejectsNull(listRequiredWhere(list), orderCol) {
+					errs = append(errs, fmt.Sprintf("order column '%s' in list '%s' allows null; the where clause must exclude NULLs, e.g. %s > :%s", orderCol, list.Name, orderCol, orderCol))
+				}
 			}
 		}
 
@@ -371,6 +874,47 @@ func validateListConfigs(lists []ListConfig, columns map[string]Column) []string
 				errs = append(errs, fmt.Sprintf("typeMapping column '%s' for param '%s' in list '%s' is not defined", mappedCol, paramName, list.Name))
 			}
 		}
+
+		errs = append(errs, validateWhere("list", list.Name, list.Where, list.TypeMapping, columns)...)
+		errs = append(errs, validateFilters(list, columns)...)
+
+		if list.Pagination != "" && list.Pagination != "token" {
+			errs = append(errs, fmt.Sprintf("list '%s' pagination must be 'token' or empty, got '%s'", list.Name, list.Pagination))
+		}
+	}
+	return errs
+}
+
+// validateFilters validates the filters of a filtered list. A filter is switched on by its own
+// parameters, so they can't be shared with another filter.
+func validateFilters(list ListConfig, columns map[string]Column) []string {
+	if len(list.Filters) == 0 {
+		return nil
+	}
+
+	var errs []string
+	if strings.TrimSpace(list.Where) != "" {
+		errs = append(errs, fmt.Sprintf("list '%s' can't have both where and filters; add the where as a filter that isn't optional", list.Name))
+	}
+
+	seen := make(map[string]bool)
+	for i, filter := range list.Filters {
+		if strings.TrimSpace(filter.Where) == "" {
+			errs = append(errs, fmt.Sprintf("filter %d of list '%s' has no where", i+1, list.Name))
+			continue
+		}
+		errs = append(errs, validateWhere("list", list.Name, filter.Where, list.TypeMapping, columns)...)
+
+		params := extractUniqueParams(filter.Where)
+		if filter.Optional && len(params) == 0 {
+			errs = append(errs, fmt.Sprintf("optional filter '%s' of list '%s' has no parameter to switch it on", filter.Where, list.Name))
+		}
+		for _, param := range params {
+			if seen[param] {
+				errs = append(errs, fmt.Sprintf("parameter ':%s' of list '%s' is used by more than one filter", param, list.Name))
+			}
+			seen[param] = true
+		}
 	}
 	return errs
 }
@@ -424,6 +968,70 @@ func validateCircuitBreakerConfig(c CircuitBreakerConfig) []string {
 	return errs
 }
 
+// validateWhere parses the where clause of an operation and reports syntax errors, unknown
+// columns and functions and parameters that don't resolve to a column.
+func validateWhere(opType, opName, where string, typeMapping map[string]string, columns map[string]Column) []string {
+	expr, err := parseWhere(where)
+	if err != nil {
+		return []string{fmt.Sprintf("where of %s '%s' is invalid: %v", opType, opName, err)}
+	}
+	if expr == nil {
+		return nil
+	}
+
+	var errs []string
+	_, whereErrs := checkWhere(expr, typeMapping, columns)
+	for _, err := range whereErrs {
+		errs = append(errs, fmt.Sprintf("where of %s '%s' is invalid: %v", opType, opName, err))
+	}
+	return errs
+}
+
+// validateWhereDialect checks the where clauses of every operation against the dialect of the entity.
+// Clauses that don't parse are reported by validateWhere.
+func validateWhereDialect(entity EntityConfig) []string {
+	var errs []string
+	check := func(opType, opName, where string) {
+		expr, err := parseWhere(where)
+		if err != nil || expr == nil {
+			return
+		}
+		for _, err := range checkWhereDialect(expr, entity.Dialect) {
+			errs = append(errs, fmt.Sprintf("where of %s '%s' is invalid: %v", opType, opName, err))
+		}
+	}
+
+	ops := entity.Operations
+	for _, list := range ops.Lists {
+		check("list", list.Name, list.Where)
+		for _, filter := range list.Filters {
+			check("list", list.Name, filter.Where)
+		}
+	}
+	for _, list := range ops.ListsBulk {
+		check("listsBulk", list.Name, list.Where)
+	}
+	for _, del := range ops.Deletes {
+		check("delete", del.Name, del.Where)
+	}
+	for _, pluck := range ops.Plucks {
+		check("pluck", pluck.Name, pluck.Where)
+	}
+	for _, agg := range ops.Aggregates {
+		check("aggregate", aggregateName(agg), agg.Where)
+	}
+	for _, group := range ops.GroupCounts {
+		check("groupCount", groupCountName(group), group.Where)
+	}
+	for _, exists := range ops.Exists {
+		check("exists", existsName(exists), exists.Where)
+	}
+	for _, search := range ops.Searches {
+		check("search", search.Name, search.Where)
+	}
+	return errs
+}
+
 // isSnakeCase checks if a string is in snake_case: only lowercase letters, numbers, and underscores.
 func isSnakeCase(s string) bool {
 	re := regexp.MustCompile(`^[a-z0-9]+(_[a-z0-9]+)*$`)
@@ -6,6 +6,8 @@ import (
 )
 
 func TestValidateEntityConfig_Valid(t *testing.T) {
+	maxLength, minLogins := 255, 0.0
+	minIncrement := int64(0)
 	validConfig := EntityConfig{
 		Name:    "user", // snake_case, >3 chars
 		Version: "v1",   // non-empty and valid format
@@ -14,7 +16,11 @@ func TestValidateEntityConfig_Valid(t *testing.T) {
 			"public_id":   {Type: "uid", Prefix: "user", AllowNull: false, Unique: true},
 			"email":       {Type: "varchar", AllowNull: false, Unique: true},
 			"preferences": {Type: "json", AllowNull: true, Unique: false},
-			"status":      {Type: "varchar", AllowNull: false, Unique: false},
+			"status":      {Type: "varchar", AllowNull: false, Unique: false, OneOf: []string{"active", "banned"}},
+			"website":     {Type: "varchar", AllowNull: true, MaxLength: &maxLength, Format: "url", Pattern: "^https://"},
+			"logins":      {Type: "int32", Min: &minLogins},
+			"plan":        {Type: "enum", AllowNull: true, Values: []string{"free", "pro_yearly"}},
+			"balance":     {Type: "decimal", Precision: 12, Scale: 2},
 			"created":     {Type: "datetime", AllowNull: false, Unique: false},
 			"updated":     {Type: "datetime", AllowNull: false, Unique: false},
 		},
@@ -67,6 +73,44 @@ func TestValidateEntityConfig_Valid(t *testing.T) {
 					},
 				},
 			},
+			Upserts: []UpsertConfig{
+				{
+					Column: "email",
+					Set:    []string{"status", "preferences"},
+				},
+			},
+			Aggregates: []AggregateConfig{
+				{
+					Function: "max",
+					Column:   "id",
+					Where:    "status = :status",
+				},
+			},
+			Patches: []PatchConfig{
+				{
+					Set: []string{"status", "preferences"},
+				},
+			},
+			Increments: []IncrementConfig{
+				{
+					Column: "logins",
+					Floor:  &minIncrement,
+				},
+			},
+			Exists: []ExistsConfig{
+				{
+					Where: "email = :email",
+				},
+			},
+			GroupCounts: []GroupCountConfig{
+				{
+					Column: "status",
+					Where:  "created > :since",
+					TypeMapping: map[string]string{
+						"since": "created",
+					},
+				},
+			},
 		},
 		CircuitBreaker: CircuitBreakerConfig{
 			TimeoutSeconds:      30,
@@ -88,9 +132,15 @@ func TestValidateEntityConfig_Valid(t *testing.T) {
 }
 
 func TestValidateEntityConfig_Invalid(t *testing.T) {
+	floor, ceiling := int64(10), int64(5)
+	minLength, maxLength, negativeLength := 10, 300, -1
+	minAge, maxAge := -200.0, 10.5
+	minScore, maxScore, scoreCeiling := 0.0, 100.0, int64(200)
 	invalidConfig := EntityConfig{
-		Name:    "User", // not snake_case
-		Version: "",     // empty version (error)
+		Name:    "User",   // not snake_case
+		Version: "",       // empty version (error)
+		Dialect: "oracle", // error: unsupported dialect
+		Audit:   true,     // error: the list named history collides with History
 		Columns: map[string]Column{
 			"id":             {Type: "int64", AllowNull: false, Unique: true},
 			"email":          {Type: "varchar", AllowNull: false, Unique: false},                   // error: used in gets but not unique
@@ -99,6 +149,21 @@ func TestValidateEntityConfig_Invalid(t *testing.T) {
 			"legacy_uuid":    {Type: "uuid", AllowNull: false, Unique: false},                      // error: uuid is no longer supported
 			"missing_prefix": {Type: "uid", Prefix: "", AllowNull: false, Unique: true},            // error: uid requires a prefix when unique
 			"bad_prefix":     {Type: "uid", Prefix: "User Prefix", AllowNull: false, Unique: true}, // error: prefix must be snake_case when unique
+			"nickname":       {Type: "varchar", AllowNull: true, Unique: true},
+			"settings":       {Type: "json", AllowNull: true},
+			"bio":            {Type: "varchar", MinLength: &minLength, MaxLength: &maxLength, Pattern: "([", Format: "phone"}, // error: maxLength beyond varchar, invalid pattern and format
+			"motto":          {Type: "text", MinLength: &maxLength, MaxLength: &minLength},                                    // error: minLength > maxLength
+			"shoe_size":      {Type: "text", MaxLength: &negativeLength},                                                      // error: negative length
+			"age":            {Type: "int8", Min: &minAge, Max: &maxAge},                                                      // error: min out of range, max not whole
+			"verified":       {Type: "bool", OneOf: []string{"true"}, MinLength: &minLength},                                  // error: rules don't fit bool
+			"level":          {Type: "int32", OneOf: []string{"1", "high"}},                                                   // error: high isn't an int32
+			"score":          {Type: "int32", Min: &minScore, Max: &maxScore},
+			"stage":          {Type: "enum", Unique: true, Values: []string{"Draft", "done", "done"}, MaxLength: &maxLength}, // error: unique, Draft not snake_case, done twice, maxLength
+			"mood":           {Type: "enum"},                                                                                 // error: no values
+			"color":          {Type: "varchar", Values: []string{"red"}},                                                     // error: values on a varchar
+			"price":          {Type: "decimal", Precision: 70, Scale: 31},                                                    // error: precision and scale too large
+			"fee":            {Type: "decimal", Precision: 4, Scale: 6},                                                      // error: scale above precision
+			"ratio":          {Type: "float", Precision: 5},                                                                  // error: precision on a float
 		},
 		Operations: OperationConfig{
 			Gets: []string{"id", "email", "non_existent"}, // non_existent: error; email: error due to not unique.
@@ -120,6 +185,20 @@ func TestValidateEntityConfig_Invalid(t *testing.T) {
 					Order:      "unknown", // error: order column not defined in columns and not a default
 					Descending: true,
 				},
+				{
+					Name:  "history", // error: collides with History of audited entities
+					Order: "created",
+				},
+				{
+					Name:       "list_by_nickname",
+					Order:      "nickname", // error: nullable order column not filtered by where
+					Pagination: "offset",   // error: only token pagination is supported
+				},
+				{
+					Name:  "list_by_nickname_or_null",
+					Where: "nickname IS NULL OR nickname > :nickname",
+					Order: "nickname", // error: the where clause lets NULLs through
+				},
 				{
 					Name:       "invalid_mapping_list",
 					Where:      "email = :badParam",
@@ -129,11 +208,37 @@ func TestValidateEntityConfig_Invalid(t *testing.T) {
 						"badParam": "ghost_column", // error: mapping to a column that doesn't exist
 					},
 				},
+				{
+					Name:  "list_with_typo",
+					Where: "agee > :age AND SLEEP(1) = 0", // error: unknown column and function
+				},
+				{
+					Name:  "list_by_limit",
+					Where: "nickname = 'ann' AND :limit > 0", // error: parameter without a column
+				},
+				{
+					Name:  "search_users",
+					Where: "email IS NOT NULL", // error: where and filters
+					Filters: []FilterConfig{
+						{Where: "email = :email", Optional: true},
+						{Where: "nickname IS NULL", Optional: true}, // error: nothing switches it on
+						{Where: "email LIKE :email"},                // error: shares :email
+						{Where: "agee > :age"},                      // error: unknown column
+					},
+				},
 			},
 			Deletes: []DeleteConfig{
 				{
 					Name: "del", // error: too short
 				},
+				{
+					Name:  "delete_unbalanced",
+					Where: "(email = :email OR nickname = :nickname", // error: missing ')'
+				},
+				{
+					Name:  "delete_injected",
+					Where: "email = :email; DROP TABLE users", // error: multiple statements
+				},
 				{
 					Name: "delete invalid mapping", // error: spaces, not snake_case
 					TypeMapping: map[string]string{
@@ -160,6 +265,11 @@ func TestValidateEntityConfig_Invalid(t *testing.T) {
 					Name:    "list_by_unique_column",
 					WhereIn: "id", // id is unique but we also define a custom unique below
 				},
+				{
+					Name:    "list_by_nicknames",
+					Where:   "nickname IN (:nicknames...)", // error: slice parameters need whereIn
+					WhereIn: "email",
+				},
 			},
 			// 🚀 NEW: Added invalid plucks
 			Plucks: []PluckConfig{
@@ -189,6 +299,81 @@ func TestValidateEntityConfig_Invalid(t *testing.T) {
 					},
 				},
 			},
+			Upserts: []UpsertConfig{
+				{
+					Column: "email",                             // error: not unique
+					Set:    []string{"created", "ghost_column"}, // error: created is managed; ghost_column doesn't exist
+				},
+				{
+					Column: "firstName", // error: not listed in gets
+				}, // error: empty set
+				{
+					Column: "ghost_column", // error: column doesn't exist
+				},
+				{
+					Column: "nickname", // error: nullable
+					Set:    []string{"email"},
+				},
+			},
+			Aggregates: []AggregateConfig{
+				{
+					Function: "median", // error: unsupported function
+					Column:   "id",
+				},
+				{
+					Function: "sum",
+					Column:   "email", // error: not numeric
+				},
+				{
+					Function: "avg",
+					Column:   "ghost_column", // error: column doesn't exist
+				},
+			},
+			Patches: []PatchConfig{
+				{
+					Set: []string{"version", "ghost_column"}, // error: version is managed; ghost_column doesn't exist
+				},
+				{}, // error: empty set
+			},
+			Increments: []IncrementConfig{
+				{
+					Column:  "email", // error: not an integer
+					Floor:   &floor,
+					Ceiling: &ceiling, // error: below floor
+				},
+				{
+					Column: "ghost_column", // error: column doesn't exist
+				},
+				{
+					Column:  "score",       // error: no floor although the column has a min
+					Ceiling: &scoreCeiling, // error: above the max of the column
+				},
+			},
+			Exists: []ExistsConfig{
+				{
+					Where: "email IS NOT NULL", // error: no parameter
+				},
+			},
+			GroupCounts: []GroupCountConfig{
+				{
+					Column: "ghost_column", // error: column doesn't exist
+				},
+				{
+					Column: "settings", // error: json can't be a map key
+				},
+			},
+			Searches: []SearchConfig{
+				{
+					Name:         "search_users",
+					Columns:      []string{"email", "id"}, // error: id isn't text
+					Mode:         "fuzzy",                 // error: unsupported mode
+					Where:        "email IN (:emails...)", // error: slice parameter
+					CacheSeconds: 300,                     // error: longer than listExpirationSeconds
+				},
+				{
+					Name: "find", // error: too short, no columns
+				},
+			},
 		},
 		CircuitBreaker: CircuitBreakerConfig{
 			TimeoutSeconds:      0,
@@ -242,6 +427,69 @@ func TestValidateEntityConfig_Invalid(t *testing.T) {
 		"listsBulk 'list_by_public_ids' uses whereIn on 'id' which is unique. Use 'getsBulk' instead.",
 		"listsBulk 'list_by_unique_column' uses whereIn on 'id' which is unique. Use 'getsBulk' instead.",
 		"when listInvalidation is 'epoch', listExpirationSeconds must be 60 or less",
+		"dialect must be one of 'mysql', 'postgres', 'sqlite', got 'oracle'",
+		"upsert operation requires column 'email' to be unique",
+		"upsert by 'email' cannot overwrite column 'created'",
+		"upsert by 'email' refers to unknown set column 'ghost_column'",
+		"upsert column 'firstName' must also be listed in gets",
+		"upsert by 'firstName' must specify at least one column in 'set'",
+		"upsert operation refers to unknown column 'ghost_column'",
+		"upsert column 'nickname' cannot allow null",
+		"order column 'nickname' in list 'list_by_nickname' allows null; the where clause must exclude NULLs",
+		"order column 'nickname' in list 'list_by_nickname_or_null' allows null; the where clause must exclude NULLs",
+		"list 'list_by_nickname' pagination must be 'token' or empty, got 'offset'",
+		"aggregate function 'median' on column 'id' must be one of sum, avg, min, max",
+		"aggregate 'sum_email' requires a numeric column, 'email' is varchar",
+		"aggregate 'avg_ghost_column' refers to unknown column 'ghost_column'",
+		"groupCount refers to unknown column 'ghost_column'",
+		"exists where 'email IS NOT NULL' must use at least one :parameter",
+		"patch 'patch_version_and_ghost_column' cannot set column 'version'",
+		"patch 'patch_version_and_ghost_column' refers to unknown set column 'ghost_column'",
+		"patch must specify at least one column in 'set'",
+		"increment column 'email' must be an integer, got varchar",
+		"increment of 'email' has floor 10 above ceiling 5",
+		"increment refers to unknown column 'ghost_column'",
+		"groupCount can't group by json column 'settings'",
+		"where of list 'list_with_typo' is invalid: unknown column 'agee' at offset 0",
+		"where of list 'list_with_typo' is invalid: unknown function 'SLEEP' at offset 16",
+		"where of list 'list_by_limit' is invalid: parameter ':limit' doesn't resolve to a column; compare it with a column or add it to typeMapping at offset 21",
+		"where of delete 'delete_unbalanced' is invalid: unbalanced '(' at offset 0",
+		"where of delete 'delete_injected' is invalid: multiple statements are not allowed at offset 14",
+		"listsBulk 'list_by_nicknames' doesn't support slice parameter ':nicknames...', use whereIn",
+		"list 'search_users' can't have both where and filters",
+		"optional filter 'nickname IS NULL' of list 'search_users' has no parameter to switch it on",
+		"parameter ':email' of list 'search_users' is used by more than one filter",
+		"where of list 'search_users' is invalid: unknown column 'agee' at offset 0",
+		"duplicate operation name 'search_users' found in 'searches'",
+		"search 'search_users' can only match text and varchar columns, 'id' is int64",
+		"search 'search_users' mode must be 'natural', 'boolean' or empty, got 'fuzzy'",
+		"search 'search_users' doesn't support slice parameter ':emails...'",
+		"search 'search_users' cacheSeconds must be between 1 and listExpirationSeconds (120), got 300",
+		"search name 'find' must be longer than 4 characters",
+		"search 'find' must match at least one column",
+		"list 'history' collides with the History method of audited entities",
+		"maxLength of column 'bio' exceeds the 255 characters of varchar, use text",
+		"pattern of column 'bio' is invalid: error parsing regexp",
+		"format of column 'bio' must be 'email' or 'url', got 'phone'",
+		"minLength of column 'motto' is greater than its maxLength",
+		"maxLength of column 'shoe_size' must not be negative",
+		"min of column 'age' is out of range for int8",
+		"max of column 'age' must be a whole number",
+		"column 'verified' of type 'bool' doesn't support the minLength rule",
+		"column 'verified' of type 'bool' doesn't support the oneOf rule",
+		"oneOf value 'high' of column 'level' isn't a valid int32",
+		"increment of 'score' needs a floor, the column has a min of 0",
+		"increment of 'score' has ceiling 200 above the max 100 of the column",
+		"enum column 'stage' can't be unique",
+		"value 'Draft' of enum column 'stage' must be in snake_case",
+		"value 'done' of enum column 'stage' is listed twice",
+		"column 'stage' of type 'enum' doesn't support the maxLength rule",
+		"enum column 'mood' requires at least one value",
+		"column 'color' of type 'varchar' doesn't support values, only enum columns do",
+		"precision of decimal column 'price' must be between 1 and 65, got 70",
+		"scale of decimal column 'price' must be between 0 and 30, got 31",
+		"scale of decimal column 'fee' is greater than its precision",
+		"column 'ratio' of type 'float' doesn't support precision and scale, only decimal columns do",
 	}
 
 	for _, expectedError := range expectedErrors {
@@ -250,3 +498,22 @@ func TestValidateEntityConfig_Invalid(t *testing.T) {
 		}
 	}
 }
+
+func TestWhereRejectsNull(t *testing.T) {
+	tests := map[string]bool{
+		"birthdate < :birthdate":                                  true,
+		"birthdate IS NOT NULL":                                   true,
+		":cutoff >= birthdate":                                    true,
+		"age > :age AND (birthdate BETWEEN :from AND :to)":        true,
+		"age > :age":                                              false,
+		"birthdate IS NULL OR birthdate > :birthdate":             false,
+		"birthdate <=> :birthdate":                                false,
+		"age > :age AND (birthdate > :from OR birthdate IS NULL)": false,
+		"birthdate_note = :note":                                  false,
+	}
+	for where, expected := range tests {
+		if got := whereRejectsNull(where, "birthdate"); got != expected {
+			t.Errorf("whereRejectsNull(%q) = %v, expected %v", where, got, expected)
+		}
+	}
+}
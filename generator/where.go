@@ -0,0 +1,761 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// whereError is a problem found in a where clause, located by its character offset.
+type whereError struct {
+	Offset  int
+	Message string
+}
+
+func (e *whereError) Error() string {
+	return fmt.Sprintf("%s at offset %d", e.Message, e.Offset)
+}
+
+type whereTokenKind int
+
+const (
+	tokenEOF         whereTokenKind = iota
+	tokenIdent                      // column names, keywords and function names
+	tokenQuotedIdent                // `column`
+	tokenNumber
+	tokenString
+	tokenParam // :name
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+// whereToken is a lexed token. Pos and End are character offsets in the where clause.
+type whereToken struct {
+	Kind  whereTokenKind
+	Text  string // the token as written, the bare name for parameters and quoted identifiers
+	Slice bool   // a slice parameter, :name...
+	Pos   int
+	End   int
+}
+
+// written returns the token as it is written in the where clause.
+func (t whereToken) written() string {
+	switch t.Kind {
+	case tokenParam:
+		if t.Slice {
+			return ":" + t.Text + "..."
+		}
+		return ":" + t.Text
+	case tokenQuotedIdent:
+		return "`" + t.Text + "`"
+	}
+	return t.Text
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// lexWhere splits a where clause into tokens ending with tokenEOF. On error the tokens
+// lexed so far are returned with it.
+func lexWhere(where string) ([]whereToken, error) {
+	src := []rune(where)
+	var tokens []whereToken
+
+	for i := 0; i < len(src); {
+		r := src[i]
+		start := i
+		var next rune
+		if i+1 < len(src) {
+			next = src[i+1]
+		}
+
+		kind := tokenOperator
+		text := ""
+		slice := false
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == ';':
+			return tokens, &whereError{i, "multiple statements are not allowed"}
+		case r == '#', r == '-' && next == '-', r == '/' && next == '*':
+			return tokens, &whereError{i, "comments are not allowed"}
+		case r == '\'':
+			kind = tokenString
+			for i++; ; i++ {
+				if i >= len(src) {
+					return tokens, &whereError{start, "unterminated string"}
+				}
+				if src[i] == '\\' {
+					i++
+				} else if src[i] == '\'' {
+					if i+1 < len(src) && src[i+1] == '\'' {
+						i++ // '' escapes a quote
+						continue
+					}
+					break
+				}
+			}
+			i++
+		case r == '`':
+			kind = tokenQuotedIdent
+			end := slices.Index(src[i+1:], '`')
+			if end < 0 {
+				return tokens, &whereError{start, "unterminated quoted identifier"}
+			}
+			text = string(src[i+1 : i+1+end])
+			i += end + 2
+		case r == ':':
+			kind = tokenParam
+			for i++; i < len(src) && isIdentRune(src[i]); i++ {
+			}
+			if i == start+1 {
+				return tokens, &whereError{start, "expected a parameter name after ':'"}
+			}
+			text = string(src[start+1 : i])
+			if strings.HasPrefix(string(src[i:]), "...") {
+				slice = true
+				i += 3
+			}
+		case unicode.IsDigit(r), r == '.' && unicode.IsDigit(next):
+			kind = tokenNumber
+			dot := false
+			for ; i < len(src) && (unicode.IsDigit(src[i]) || src[i] == '.' && !dot); i++ {
+				dot = dot || src[i] == '.'
+			}
+		case isIdentStart(r):
+			kind = tokenIdent
+			for ; i < len(src) && isIdentRune(src[i]); i++ {
+			}
+		case r == '(':
+			kind = tokenLParen
+			i++
+		case r == ')':
+			kind = tokenRParen
+			i++
+		case r == ',':
+			kind = tokenComma
+			i++
+		case slices.Contains([]string{"<=", ">=", "<>", "!=", "||"}, string([]rune{r, next})):
+			i += 2
+		case strings.ContainsRune("=<>+-*/%", r):
+			i++
+		default:
+			return tokens, &whereError{i, fmt.Sprintf("unexpected character '%c'", r)}
+		}
+
+		if text == "" {
+			text = string(src[start:i])
+		}
+		tokens = append(tokens, whereToken{Kind: kind, Text: text, Slice: slice, Pos: start, End: i})
+	}
+
+	return append(tokens, whereToken{Kind: tokenEOF, Pos: len(src), End: len(src)}), nil
+}
+
+type whereExprKind int
+
+const (
+	exprColumn whereExprKind = iota
+	exprParam
+	exprLiteral
+	exprCall     // Text is the upper-cased function name
+	exprUnary    // NOT or a sign
+	exprBinary   // AND, OR, comparisons, LIKE and arithmetic
+	exprIn       // Args[0] IN (Args[1:]...)
+	exprBetween  // Args[0] BETWEEN Args[1] AND Args[2]
+	exprIsNull   // Args[0] IS NULL
+	exprInterval // INTERVAL Args[0] Text, no Text for INTERVAL '7 days'
+)
+
+// whereExpr is a node of a parsed where clause.
+type whereExpr struct {
+	Kind   whereExprKind
+	Text   string // column, parameter or function name, operator or literal
+	Not    bool   // NOT IN, NOT BETWEEN, NOT LIKE, IS NOT NULL
+	Slice  bool   // a slice parameter, :name...
+	Quoted bool   // a column written as `column`
+	Pos    int
+	Args   []*whereExpr
+}
+
+// walk calls fn for the expression and all of its descendants in the order they are written.
+func (e *whereExpr) walk(fn func(*whereExpr)) {
+	if e == nil {
+		return
+	}
+	fn(e)
+	for _, arg := range e.Args {
+		arg.walk(fn)
+	}
+}
+
+// rejectsNull reports whether the expression is never true for a row whose column is NULL: column IS NOT NULL,
+// a comparison, IN, BETWEEN or LIKE on the column, ANDed with anything or ORed with another such predicate.
+func (e *whereExpr) rejectsNull(column string) bool {
+	isColumn := func(arg *whereExpr) bool { return arg.Kind == exprColumn && arg.Text == column }
+
+	switch e.Kind {
+	case exprBinary:
+		switch {
+		case e.Text == "AND":
+			return e.Args[0].rejectsNull(column) || e.Args[1].rejectsNull(column)
+		case e.Text == "OR":
+			return e.Args[0].rejectsNull(column) && e.Args[1].rejectsNull(column)
+		case e.Text == "LIKE" || slices.Contains(comparisonOperators, e.Text):
+			return isColumn(e.Args[0]) || isColumn(e.Args[1])
+		}
+	case exprIn, exprBetween:
+		return isColumn(e.Args[0])
+	case exprIsNull:
+		return e.Not && isColumn(e.Args[0])
+	}
+	return false
+}
+
+// whereKeywords can't be used as column names.
+var whereKeywords = []string{"AND", "BETWEEN", "FALSE", "IN", "INTERVAL", "IS", "LIKE", "NOT", "NULL", "OR", "TRUE"}
+
+// whereFunctions are the SQL functions where clauses may call. The CURRENT_ ones are called without parentheses.
+var whereFunctions = []string{"ABS", "COALESCE", "CURRENT_DATE", "CURRENT_TIME", "CURRENT_TIMESTAMP", "DATE", "LENGTH", "LOWER", "NOW", "ROUND", "TRIM", "UPPER"}
+
+// unsupportedWhereFunctions are the whereFunctions a dialect doesn't have.
+var unsupportedWhereFunctions = map[string][]string{
+	DialectSQLite: {"NOW"},
+}
+
+// intervalUnits are the units of MySQL INTERVAL expressions, e.g. NOW() - INTERVAL 7 DAY.
+// PostgreSQL writes the unit in the string instead, e.g. NOW() - INTERVAL '7 days'.
+var intervalUnits = []string{"SECOND", "MINUTE", "HOUR", "DAY", "WEEK", "MONTH", "YEAR"}
+
+var comparisonOperators = []string{"=", "<>", "!=", "<", "<=", ">", ">="}
+
+type whereParser struct {
+	tokens []whereToken
+	pos    int
+}
+
+// parseWhere parses a where clause into its expression tree. An empty clause returns nil.
+func parseWhere(where string) (*whereExpr, error) {
+	tokens, err := lexWhere(where)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 1 {
+		return nil, nil
+	}
+
+	p := &whereParser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok := p.peek(); tok.Kind {
+	case tokenEOF:
+		return expr, nil
+	case tokenRParen:
+		return nil, &whereError{tok.Pos, "unbalanced ')'"}
+	default:
+		return nil, &whereError{tok.Pos, fmt.Sprintf("unexpected '%s'", tok.written())}
+	}
+}
+
+func (p *whereParser) peek() whereToken {
+	return p.tokens[p.pos]
+}
+
+func (p *whereParser) next() whereToken {
+	tok := p.tokens[p.pos]
+	if tok.Kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// keyword reports whether the next token is one of the given keywords.
+func (p *whereParser) keyword(keywords ...string) bool {
+	tok := p.peek()
+	return tok.Kind == tokenIdent && slices.Contains(keywords, strings.ToUpper(tok.Text))
+}
+
+func (p *whereParser) binary(left *whereExpr, tok whereToken, right func() (*whereExpr, error)) (*whereExpr, error) {
+	r, err := right()
+	if err != nil {
+		return nil, err
+	}
+	return &whereExpr{Kind: exprBinary, Text: strings.ToUpper(tok.Text), Pos: tok.Pos, Args: []*whereExpr{left, r}}, nil
+}
+
+func (p *whereParser) parseOr() (*whereExpr, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.keyword("OR") {
+		left, err = p.binary(left, p.next(), p.parseAnd)
+	}
+	return left, err
+}
+
+func (p *whereParser) parseAnd() (*whereExpr, error) {
+	left, err := p.parseNot()
+	for err == nil && p.keyword("AND") {
+		left, err = p.binary(left, p.next(), p.parseNot)
+	}
+	return left, err
+}
+
+func (p *whereParser) parseNot() (*whereExpr, error) {
+	if !p.keyword("NOT") {
+		return p.parsePredicate()
+	}
+	tok := p.next()
+	expr, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	return &whereExpr{Kind: exprUnary, Text: "NOT", Pos: tok.Pos, Args: []*whereExpr{expr}}, nil
+}
+
+func (p *whereParser) parsePredicate() (*whereExpr, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	tok := p.peek()
+	switch {
+	case tok.Kind == tokenOperator && slices.Contains(comparisonOperators, tok.Text):
+		return p.binary(left, p.next(), p.parseAdditive)
+	case p.keyword("IS"):
+		p.next()
+		not := p.keyword("NOT")
+		if not {
+			p.next()
+		}
+		if !p.keyword("NULL") {
+			return nil, &whereError{p.peek().Pos, "expected NULL after IS"}
+		}
+		p.next()
+		return &whereExpr{Kind: exprIsNull, Not: not, Pos: tok.Pos, Args: []*whereExpr{left}}, nil
+	case p.keyword("NOT", "IN", "BETWEEN", "LIKE"):
+		not := p.keyword("NOT")
+		if not {
+			p.next()
+			if !p.keyword("IN", "BETWEEN", "LIKE") {
+				return nil, &whereError{p.peek().Pos, "expected IN, BETWEEN or LIKE after NOT"}
+			}
+		}
+
+		op := p.next()
+		switch strings.ToUpper(op.Text) {
+		case "IN":
+			return p.parseInList(left, op, not)
+		case "BETWEEN":
+			low, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			if !p.keyword("AND") {
+				return nil, &whereError{p.peek().Pos, "expected AND in BETWEEN"}
+			}
+			p.next()
+			high, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			return &whereExpr{Kind: exprBetween, Not: not, Pos: op.Pos, Args: []*whereExpr{left, low, high}}, nil
+		default:
+			expr, err := p.binary(left, op, p.parseAdditive)
+			if err != nil {
+				return nil, err
+			}
+			expr.Not = not
+			return expr, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *whereParser) parseInList(left *whereExpr, op whereToken, not bool) (*whereExpr, error) {
+	open := p.next()
+	if open.Kind != tokenLParen {
+		return nil, &whereError{open.Pos, "expected '(' after IN"}
+	}
+
+	expr := &whereExpr{Kind: exprIn, Not: not, Pos: op.Pos, Args: []*whereExpr{left}}
+	for {
+		item, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		expr.Args = append(expr.Args, item)
+
+		switch tok := p.next(); tok.Kind {
+		case tokenComma:
+			continue
+		case tokenRParen:
+			return expr, nil
+		default:
+			return nil, &whereError{open.Pos, "unbalanced '('"}
+		}
+	}
+}
+
+func (p *whereParser) parseAdditive() (*whereExpr, error) {
+	left, err := p.parseMultiplicative()
+	for err == nil && p.peek().Kind == tokenOperator && slices.Contains([]string{"+", "-", "||"}, p.peek().Text) {
+		left, err = p.binary(left, p.next(), p.parseMultiplicative)
+	}
+	return left, err
+}
+
+func (p *whereParser) parseMultiplicative() (*whereExpr, error) {
+	left, err := p.parseUnary()
+	for err == nil && p.peek().Kind == tokenOperator && slices.Contains([]string{"*", "/", "%"}, p.peek().Text) {
+		left, err = p.binary(left, p.next(), p.parseUnary)
+	}
+	return left, err
+}
+
+func (p *whereParser) parseUnary() (*whereExpr, error) {
+	tok := p.peek()
+	if tok.Kind != tokenOperator || (tok.Text != "-" && tok.Text != "+") {
+		return p.parsePrimary()
+	}
+	p.next()
+	expr, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return &whereExpr{Kind: exprUnary, Text: tok.Text, Pos: tok.Pos, Args: []*whereExpr{expr}}, nil
+}
+
+func (p *whereParser) parsePrimary() (*whereExpr, error) {
+	tok := p.next()
+	switch tok.Kind {
+	case tokenNumber, tokenString:
+		return &whereExpr{Kind: exprLiteral, Text: tok.Text, Pos: tok.Pos}, nil
+	case tokenParam:
+		return &whereExpr{Kind: exprParam, Text: tok.Text, Slice: tok.Slice, Pos: tok.Pos}, nil
+	case tokenQuotedIdent:
+		return &whereExpr{Kind: exprColumn, Text: tok.Text, Quoted: true, Pos: tok.Pos}, nil
+	case tokenLParen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().Kind != tokenRParen {
+			return nil, &whereError{tok.Pos, "unbalanced '('"}
+		}
+		p.next()
+		return expr, nil
+	case tokenRParen:
+		return nil, &whereError{tok.Pos, "unbalanced ')'"}
+	case tokenEOF:
+		return nil, &whereError{tok.Pos, "unexpected end of where clause"}
+	case tokenIdent:
+		return p.parseIdent(tok)
+	default:
+		return nil, &whereError{tok.Pos, fmt.Sprintf("expected an expression, found '%s'", tok.written())}
+	}
+}
+
+// parseIdent parses an identifier: a literal keyword, an INTERVAL, a function call or a column.
+func (p *whereParser) parseIdent(tok whereToken) (*whereExpr, error) {
+	upper := strings.ToUpper(tok.Text)
+	switch {
+	case upper == "NULL" || upper == "TRUE" || upper == "FALSE":
+		return &whereExpr{Kind: exprLiteral, Text: upper, Pos: tok.Pos}, nil
+	case strings.HasPrefix(upper, "CURRENT_") && p.peek().Kind != tokenLParen:
+		return &whereExpr{Kind: exprCall, Text: upper, Pos: tok.Pos}, nil
+	case upper == "INTERVAL":
+		value, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if value.Kind == exprLiteral && strings.HasPrefix(value.Text, "'") && !p.keyword(intervalUnits...) {
+			// INTERVAL '7 days', the unit is part of the string
+			return &whereExpr{Kind: exprInterval, Pos: tok.Pos, Args: []*whereExpr{value}}, nil
+		}
+		unit := p.next()
+		if unit.Kind != tokenIdent || !slices.Contains(intervalUnits, strings.ToUpper(unit.Text)) {
+			return nil, &whereError{unit.Pos, fmt.Sprintf("expected an INTERVAL unit (%s)", strings.Join(intervalUnits, ", "))}
+		}
+		return &whereExpr{Kind: exprInterval, Text: strings.ToUpper(unit.Text), Pos: tok.Pos, Args: []*whereExpr{value}}, nil
+	case slices.Contains(whereKeywords, upper):
+		return nil, &whereError{tok.Pos, fmt.Sprintf("expected an expression, found '%s'", tok.Text)}
+	case p.peek().Kind != tokenLParen:
+		return &whereExpr{Kind: exprColumn, Text: tok.Text, Pos: tok.Pos}, nil
+	}
+
+	// Function call
+	open := p.next()
+	call := &whereExpr{Kind: exprCall, Text: upper, Pos: tok.Pos}
+	if p.peek().Kind == tokenRParen {
+		p.next()
+		return call, nil
+	}
+	for {
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		call.Args = append(call.Args, arg)
+
+		switch next := p.next(); next.Kind {
+		case tokenComma:
+			continue
+		case tokenRParen:
+			return call, nil
+		default:
+			return nil, &whereError{open.Pos, "unbalanced '('"}
+		}
+	}
+}
+
+// whereColumnDefinition returns the definition of a column where clauses can reference,
+// including the id, created and updated columns every entity has.
+func whereColumnDefinition(name string, columns map[string]Column) (Column, bool) {
+	switch name {
+	case "id":
+		return Column{Type: "int64"}, true
+	case "created", "updated":
+		return Column{Type: "datetime"}, true
+	}
+	col, ok := columns[name]
+	return col, ok
+}
+
+// checkWhere verifies the columns and functions of a parsed where clause and resolves the column
+// typing each parameter: its typeMapping, else the columns it is compared with, else the column
+// of the same name.
+func checkWhere(expr *whereExpr, typeMapping map[string]string, columns map[string]Column) (map[string]string, []error) {
+	var errs []error
+	var params []*whereExpr
+	compared := make(map[string][]string)
+
+	pair := func(a, b *whereExpr) {
+		if a.Kind != exprColumn || b.Kind != exprParam || slices.Contains(compared[b.Text], a.Text) {
+			return
+		}
+		if _, ok := whereColumnDefinition(a.Text, columns); ok {
+			compared[b.Text] = append(compared[b.Text], a.Text)
+		}
+	}
+
+	expr.walk(func(e *whereExpr) {
+		switch e.Kind {
+		case exprColumn:
+			if _, ok := whereColumnDefinition(e.Text, columns); !ok {
+				errs = append(errs, &whereError{e.Pos, fmt.Sprintf("unknown column '%s'", e.Text)})
+			}
+		case exprCall:
+			if !slices.Contains(whereFunctions, e.Text) {
+				errs = append(errs, &whereError{e.Pos, fmt.Sprintf("unknown function '%s'", e.Text)})
+			}
+		case exprParam:
+			params = append(params, e)
+		case exprBinary:
+			if slices.Contains(comparisonOperators, e.Text) || e.Text == "LIKE" {
+				pair(e.Args[0], e.Args[1])
+				pair(e.Args[1], e.Args[0])
+			}
+		case exprIn, exprBetween:
+			for _, arg := range e.Args[1:] {
+				pair(e.Args[0], arg)
+			}
+		}
+	})
+
+	errs = append(errs, checkSliceParams(expr, false)...)
+
+	resolved := make(map[string]string)
+	for _, param := range params {
+		name := param.Text
+		if _, done := resolved[name]; done {
+			continue
+		}
+
+		for _, other := range params {
+			if other.Text == name && other.Slice != param.Slice {
+				errs = append(errs, &whereError{other.Pos, fmt.Sprintf("parameter ':%s' is used both as a slice and a single value", name)})
+				break
+			}
+		}
+
+		if mapped, ok := typeMapping[name]; ok {
+			resolved[name] = mapped
+			continue
+		}
+
+		if candidates := compared[name]; len(candidates) > 0 {
+			first, _ := whereColumnDefinition(candidates[0], columns)
+			for _, candidate := range candidates[1:] {
+				other, _ := whereColumnDefinition(candidate, columns)
+				if columnGoType(other, false) != columnGoType(first, false) {
+					errs = append(errs, &whereError{param.Pos, fmt.Sprintf("parameter ':%s' is compared with columns of different types (%s); add it to typeMapping", name, strings.Join(candidates, ", "))})
+					break
+				}
+			}
+			resolved[name] = candidates[0]
+			continue
+		}
+
+		if _, ok := whereColumnDefinition(name, columns); ok {
+			resolved[name] = name
+			continue
+		}
+		errs = append(errs, &whereError{param.Pos, fmt.Sprintf("parameter ':%s' doesn't resolve to a column; compare it with a column or add it to typeMapping", name)})
+	}
+
+	return resolved, errs
+}
+
+// checkWhereDialect verifies a parsed where clause only uses SQL the dialect understands: SQLite has
+// no NOW() and no INTERVAL, PostgreSQL intervals are strings like INTERVAL '7 days' and only MySQL
+// quotes identifiers with backticks.
+func checkWhereDialect(expr *whereExpr, dialect string) []error {
+	var errs []error
+	expr.walk(func(e *whereExpr) {
+		switch {
+		case e.Kind == exprColumn && e.Quoted && !isMySQL(dialect):
+			errs = append(errs, &whereError{e.Pos, fmt.Sprintf("backticks around '%s' are MySQL only, %s identifiers are written without quotes", e.Text, dialect)})
+		case e.Kind == exprCall && slices.Contains(unsupportedWhereFunctions[dialect], e.Text):
+			errs = append(errs, &whereError{e.Pos, fmt.Sprintf("function '%s' is not supported by %s", e.Text, dialect)})
+		case e.Kind == exprInterval && isSQLite(dialect):
+			errs = append(errs, &whereError{e.Pos, "INTERVAL is not supported by sqlite"})
+		case e.Kind == exprInterval && isPostgres(dialect) && !strings.HasPrefix(e.Args[0].Text, "'"):
+			errs = append(errs, &whereError{e.Pos, "postgres intervals are written as a string, e.g. INTERVAL '7 days'"})
+		case e.Kind == exprInterval && isMySQL(dialect) && e.Text == "":
+			errs = append(errs, &whereError{e.Pos, fmt.Sprintf("expected an INTERVAL unit (%s)", strings.Join(intervalUnits, ", "))})
+		}
+	})
+	return errs
+}
+
+// checkSliceParams verifies slice parameters are the only value of an IN list that isn't negated.
+// Empty slices expand to NULL, which matches no rows, so NOT IN would match none instead of all.
+func checkSliceParams(expr *whereExpr, negated bool) []error {
+	var errs []error
+	switch {
+	case expr.Kind == exprParam && expr.Slice:
+		return []error{&whereError{expr.Pos, fmt.Sprintf("slice parameter ':%s...' must be the only value of an IN list", expr.Text)}}
+	case expr.Kind == exprIn && len(expr.Args) == 2 && expr.Args[1].Kind == exprParam && expr.Args[1].Slice:
+		if negated != expr.Not {
+			errs = append(errs, &whereError{expr.Args[1].Pos, fmt.Sprintf("slice parameter ':%s...' can't be negated, an empty slice matches no rows", expr.Args[1].Text)})
+		}
+		return append(errs, checkSliceParams(expr.Args[0], negated)...)
+	case expr.Kind == exprUnary && expr.Text == "NOT":
+		negated = !negated
+	}
+
+	for _, arg := range expr.Args {
+		errs = append(errs, checkSliceParams(arg, negated)...)
+	}
+	return errs
+}
+
+// whereSliceParams returns the names of the slice parameters (:name...) of a where clause.
+func whereSliceParams(where string) []string {
+	tokens, _ := lexWhere(where)
+
+	var params []string
+	for _, tok := range tokens {
+		if tok.Kind == tokenParam && tok.Slice && !slices.Contains(params, tok.Text) {
+			params = append(params, tok.Text)
+		}
+	}
+	return params
+}
+
+// whereParamColumns returns the column typing each parameter of a where clause. Parameters that
+// don't resolve are left out; validateWhere reports them.
+func whereParamColumns(where string, typeMapping map[string]string, columns map[string]Column) map[string]string {
+	expr, err := parseWhere(where)
+	if err != nil || expr == nil {
+		return nil
+	}
+	resolved, _ := checkWhere(expr, typeMapping, columns)
+	return resolved
+}
+
+// whereColumns returns the columns a where clause references, in order of appearance.
+func whereColumns(where string) []string {
+	expr, err := parseWhere(where)
+	if err != nil {
+		return nil
+	}
+
+	var cols []string
+	expr.walk(func(e *whereExpr) {
+		if e.Kind == exprColumn {
+			cols = append(cols, e.Text)
+		}
+	})
+	return cols
+}
+
+// whereHasOr reports whether a where clause combines conditions with OR.
+func whereHasOr(where string) bool {
+	expr, err := parseWhere(where)
+	if err != nil {
+		return false
+	}
+
+	found := false
+	expr.walk(func(e *whereExpr) {
+		found = found || (e.Kind == exprBinary && e.Text == "OR")
+	})
+	return found
+}
+
+// inferTypeMapping adds the column each parameter of where is compared with to typeMapping,
+// so generated signatures type the parameter like that column.
+func inferTypeMapping(where string, typeMapping map[string]string, columns map[string]Column) map[string]string {
+	for param, col := range whereParamColumns(where, typeMapping, columns) {
+		if _, ok := typeMapping[param]; ok || param == col {
+			continue
+		}
+		if typeMapping == nil {
+			typeMapping = make(map[string]string)
+		}
+		typeMapping[param] = col
+	}
+	return typeMapping
+}
+
+// inferTypeMappings applies inferTypeMapping to the where clause of every operation.
+func inferTypeMappings(config *EntityConfig) {
+	ops := &config.Operations
+	for i := range ops.Lists {
+		ops.Lists[i].TypeMapping = inferTypeMapping(listWhere(ops.Lists[i]), ops.Lists[i].TypeMapping, config.Columns)
+	}
+	for i := range ops.ListsBulk {
+		ops.ListsBulk[i].TypeMapping = inferTypeMapping(ops.ListsBulk[i].Where, ops.ListsBulk[i].TypeMapping, config.Columns)
+	}
+	for i := range ops.Deletes {
+		ops.Deletes[i].TypeMapping = inferTypeMapping(ops.Deletes[i].Where, ops.Deletes[i].TypeMapping, config.Columns)
+	}
+	for i := range ops.Plucks {
+		ops.Plucks[i].TypeMapping = inferTypeMapping(ops.Plucks[i].Where, ops.Plucks[i].TypeMapping, config.Columns)
+	}
+	for i := range ops.Aggregates {
+		ops.Aggregates[i].TypeMapping = inferTypeMapping(ops.Aggregates[i].Where, ops.Aggregates[i].TypeMapping, config.Columns)
+	}
+	for i := range ops.GroupCounts {
+		ops.GroupCounts[i].TypeMapping = inferTypeMapping(ops.GroupCounts[i].Where, ops.GroupCounts[i].TypeMapping, config.Columns)
+	}
+	for i := range ops.Exists {
+		ops.Exists[i].TypeMapping = inferTypeMapping(ops.Exists[i].Where, ops.Exists[i].TypeMapping, config.Columns)
+	}
+	for i := range ops.Searches {
+		ops.Searches[i].TypeMapping = inferTypeMapping(ops.Searches[i].Where, ops.Searches[i].TypeMapping, config.Columns)
+	}
+}
@@ -0,0 +1,239 @@
+package generator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseWhere_Errors(t *testing.T) {
+	tests := []struct {
+		where    string
+		expected string
+	}{
+		{where: "age > :age)", expected: "unbalanced ')' at offset 10"},
+		{where: "(age > :age", expected: "unbalanced '(' at offset 0"},
+		{where: "age > :age; DROP TABLE users", expected: "multiple statements are not allowed at offset 10"},
+		{where: "age > :age -- comment", expected: "comments are not allowed at offset 11"},
+		{where: "email = 'a@example.com", expected: "unterminated string at offset 8"},
+		{where: "age > :", expected: "expected a parameter name after ':' at offset 6"},
+		{where: "age >", expected: "unexpected end of where clause at offset 5"},
+		{where: "age > :age AND", expected: "unexpected end of where clause at offset 14"},
+		{where: "age :age", expected: "unexpected ':age' at offset 4"},
+		{where: "status IN :statuses", expected: "expected '(' after IN at offset 10"},
+		{where: "nickname IS :nickname", expected: "expected NULL after IS at offset 12"},
+		{where: "created > NOW() - INTERVAL 1 FORTNIGHT", expected: "expected an INTERVAL unit"},
+		{where: "AND age > :age", expected: "expected an expression, found 'AND' at offset 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.where, func(t *testing.T) {
+			_, err := parseWhere(tt.where)
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("expected error %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestCheckWhere(t *testing.T) {
+	columns := map[string]Column{
+		"age":        {Type: "int8"},
+		"status":     {Type: "varchar"},
+		"nickname":   {Type: "varchar", AllowNull: true},
+		"expires_at": {Type: "datetime"},
+	}
+
+	tests := []struct {
+		where       string
+		typeMapping map[string]string
+		expected    map[string]string
+		errors      []string
+	}{
+		{
+			where:    "age = :minAge OR age > :minAge",
+			expected: map[string]string{"minAge": "age"},
+		},
+		{
+			where:    "status IN ('active', :other) AND age BETWEEN :low AND :high",
+			expected: map[string]string{"other": "status", "low": "age", "high": "age"},
+		},
+		{
+			where:    "LOWER(nickname) LIKE :nickname AND created > NOW() - INTERVAL 7 DAY",
+			expected: map[string]string{"nickname": "nickname"},
+		},
+		{
+			where:    "`status` = :wanted AND nickname IS NOT NULL",
+			expected: map[string]string{"wanted": "status"},
+		},
+		{
+			where:       "expires_at < :cutoff OR (age > 3 AND updated < :cutoff)",
+			typeMapping: map[string]string{"cutoff": "expires_at"},
+			expected:    map[string]string{"cutoff": "expires_at"},
+		},
+		{
+			where:    "expires_at < :cutoff OR updated < :cutoff",
+			expected: map[string]string{"cutoff": "expires_at"},
+		},
+		{
+			where:    "agee > :age",
+			expected: map[string]string{"age": "age"},
+			errors:   []string{"unknown column 'agee' at offset 0"},
+		},
+		{
+			where:    "age > :min",
+			expected: map[string]string{"min": "age"},
+		},
+		{
+			where:    "SLEEP(5) = 0 AND :limit > 3",
+			expected: map[string]string{},
+			errors: []string{
+				"unknown function 'SLEEP' at offset 0",
+				"parameter ':limit' doesn't resolve to a column; compare it with a column or add it to typeMapping at offset 17",
+			},
+		},
+		{
+			where:    "age = :value OR status = :value",
+			expected: map[string]string{"value": "age"},
+			errors:   []string{"parameter ':value' is compared with columns of different types (age, status); add it to typeMapping at offset 6"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.where, func(t *testing.T) {
+			expr, err := parseWhere(tt.where)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resolved, errs := checkWhere(expr, tt.typeMapping, columns)
+			if !reflect.DeepEqual(resolved, tt.expected) {
+				t.Errorf("expected params %v, got %v", tt.expected, resolved)
+			}
+
+			var got []string
+			for _, err := range errs {
+				got = append(got, err.Error())
+			}
+			if !reflect.DeepEqual(got, tt.errors) {
+				t.Errorf("expected errors %q, got %q", tt.errors, got)
+			}
+		})
+	}
+}
+
+func TestCheckWhereDialect(t *testing.T) {
+	tests := []struct {
+		where   string
+		dialect string
+		errors  []string
+	}{
+		{where: "created > NOW() - INTERVAL 7 DAY AND `status` = :status", dialect: DialectMySQL},
+		{where: "created > NOW() - INTERVAL '7' DAY", dialect: DialectMySQL},
+		{where: "created > NOW() - INTERVAL '7 days'", dialect: DialectMySQL, errors: []string{"expected an INTERVAL unit (SECOND, MINUTE, HOUR, DAY, WEEK, MONTH, YEAR) at offset 18"}},
+		{where: "created > NOW() - INTERVAL '7 days' AND status = :status", dialect: DialectPostgres},
+		{where: "created > NOW() - INTERVAL 7 DAY", dialect: DialectPostgres, errors: []string{"postgres intervals are written as a string, e.g. INTERVAL '7 days' at offset 18"}},
+		{where: "`status` = :status", dialect: DialectPostgres, errors: []string{"backticks around 'status' are MySQL only, postgres identifiers are written without quotes at offset 0"}},
+		{where: "created > CURRENT_TIMESTAMP AND LOWER(status) = :status", dialect: DialectSQLite},
+		{
+			where:   "created > NOW() - INTERVAL 7 DAY AND `status` = :status",
+			dialect: DialectSQLite,
+			errors: []string{
+				"function 'NOW' is not supported by sqlite at offset 10",
+				"INTERVAL is not supported by sqlite at offset 18",
+				"backticks around 'status' are MySQL only, sqlite identifiers are written without quotes at offset 37",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect+" "+tt.where, func(t *testing.T) {
+			expr, err := parseWhere(tt.where)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, err := range checkWhereDialect(expr, tt.dialect) {
+				got = append(got, err.Error())
+			}
+			if !reflect.DeepEqual(got, tt.errors) {
+				t.Errorf("expected errors %q, got %q", tt.errors, got)
+			}
+		})
+	}
+}
+
+func TestWhereParams_IgnoreStringLiterals(t *testing.T) {
+	where := "status = 'at 10:30' AND age > :age AND nickname <> 'it''s :not'"
+
+	if got := extractParams(where); !reflect.DeepEqual(got, []string{"age"}) {
+		t.Errorf("expected only the age param, got %v", got)
+	}
+	if got := replaceParams(where); got != "status = 'at 10:30' AND age > ? AND nickname <> 'it''s :not'" {
+		t.Errorf("unexpected replaced where %q", got)
+	}
+	if got := whereColumns(where); !reflect.DeepEqual(got, []string{"status", "age", "nickname"}) {
+		t.Errorf("unexpected columns %v", got)
+	}
+	if whereHasOr("status = 'this OR that'") || !whereHasOr("age > 3 or age < 1") {
+		t.Error("expected OR to be found outside of string literals only")
+	}
+}
+
+func TestInferTypeMapping(t *testing.T) {
+	columns := map[string]Column{"age": {Type: "int8"}}
+	list := ListConfig{Name: "list_by_age", Where: "age >= :minAge AND id > :after"}
+	list.TypeMapping = inferTypeMapping(list.Where, list.TypeMapping, columns)
+
+	params, err := countFuncParams(list, columns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params != "minage int8, after int64" {
+		t.Errorf("unexpected func params %q", params)
+	}
+}
+
+func TestCheckWhere_SliceParams(t *testing.T) {
+	columns := map[string]Column{"status": {Type: "varchar", AllowNull: true}, "age": {Type: "int8"}}
+
+	tests := []struct {
+		where  string
+		errors []string
+	}{
+		{where: "status IN (:statuses...) OR age > :age"},
+		{where: "NOT (age > 3 AND NOT status IN (:statuses...))"},
+		{where: "status NOT IN (:statuses...)", errors: []string{"slice parameter ':statuses...' can't be negated, an empty slice matches no rows at offset 15"}},
+		{where: "NOT (status IN (:statuses...))", errors: []string{"slice parameter ':statuses...' can't be negated, an empty slice matches no rows at offset 16"}},
+		{where: "status = :statuses...", errors: []string{"slice parameter ':statuses...' must be the only value of an IN list at offset 9"}},
+		{where: "status IN ('a', :statuses...)", errors: []string{"slice parameter ':statuses...' must be the only value of an IN list at offset 16"}},
+		{where: "status IN (:statuses...) OR status = :statuses", errors: []string{"parameter ':statuses' is used both as a slice and a single value at offset 37"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.where, func(t *testing.T) {
+			expr, err := parseWhere(tt.where)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resolved, errs := checkWhere(expr, nil, columns)
+			if resolved["statuses"] != "status" {
+				t.Errorf("expected statuses to be typed by status, got %v", resolved)
+			}
+
+			var got []string
+			for _, err := range errs {
+				got = append(got, err.Error())
+			}
+			if !reflect.DeepEqual(got, tt.errors) {
+				t.Errorf("expected errors %q, got %q", tt.errors, got)
+			}
+		})
+	}
+
+	if got := whereSliceParams("status IN (:statuses...) AND age IN (:ages...) AND age > :age"); !reflect.DeepEqual(got, []string{"statuses", "ages"}) {
+		t.Errorf("unexpected slice params %v", got)
+	}
+}